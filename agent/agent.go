@@ -16,9 +16,12 @@
 //
 // Rate Limiting:
 //
-// To prevent overwhelming the API server, the agent implements 100ms delays
-// between container sync operations during bulk operations. This spreads the
-// request load over time and stays well within the server's 100 req/s limit.
+// To prevent overwhelming the API server, the agent delays container syncs
+// during bulk operations. At startup it reads the server's rate limit from
+// GET /api/v1/limits and paces itself to half of it (100ms between syncs if
+// the server does not report a limit). Requests rejected with 429 are
+// retried after the Retry-After delay and slow the pace down until a bulk
+// sync runs without being throttled.
 //
 // Error Handling:
 //
@@ -36,6 +39,9 @@
 //	    "us-east",
 //	    "/var/run/docker.sock",
 //	    "agent-token",
+//	    0,              // HTTP port (0 = disabled)
+//	    30*time.Second, // Sync interval (0 = event-driven only)
+//	    agent.DockerTLSConfig{}, // TLS material for a tcp:// daemon
 //	)
 //	if err != nil {
 //	    log.Fatal(err)
@@ -51,14 +57,19 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
+	"log/slog"
 	"net"
 	"net/http"
-	"net/url"
 	"os"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/docker/docker/api/types"
@@ -88,120 +99,72 @@ type Agent struct {
 	hostInfo         *models.Host
 	authToken        string
 	httpPort         int // HTTP server port (0 = disabled)
+	httpSecurity     HTTPSecurity
 	startTime        time.Time
+	countersMu       sync.Mutex // Guards the counters below
 	syncCount        int64
 	failedSyncs      int64
 	eventsCount      int64
 	lastSyncTime     time.Time
 	lastSyncDuration time.Duration
+	lastSyncFailed   bool
+	containerCount   int
+	metricsAddress   string // Separate listener for /metrics and /healthz ("" = HTTP server)
+	pacer            *syncPacer
+	logs             *logBuffer // nil unless log shipping is enabled
+	taskConcurrency  int        // Tasks run in parallel (0 = DefaultTaskConcurrency)
+	backoff          RetryBackoff
+	fingerprints     *syncFingerprints
+	stateFile        string // Where fingerprints persist ("" = memory only)
+	backupDir        string // Where volume archives go ("" = models.DefaultBackupDir)
+	allowExec        bool   // Whether exec tasks may run commands in containers
+	syncBatchSize    int    // Containers per bulk sync request (0 = one request per container)
+	discovery        DiscoverySelector
+	taskPush         bool                         // Receive task notifications over a WebSocket
+	tasks            atomic.Pointer[TaskExecutor] // Set by Start
+	draining         atomic.Bool                  // Whether Drain was called
 }
 
-// NewAgent creates a new agent instance.
-func NewAgent(apiURL, hostID, datacenter, dockerSocket, agentToken string, httpPort int) (*Agent, error) {
+// DefaultSyncInterval is the time between full container syncs and metrics
+// reports unless configured otherwise (agent.sync_interval).
+const DefaultSyncInterval = 30 * time.Second
+
+// MinSyncInterval is the shortest sync interval NewAgent accepts, so a typo
+// can't make the agent hammer the API server.
+const MinSyncInterval = time.Second
+
+// NewAgent creates a new agent instance. syncInterval is the time between
+// periodic full syncs and metrics reports; 0 disables periodic full syncs,
+// leaving event-driven updates, and reports metrics every
+// DefaultSyncInterval. dockerTLS is the client certificate for a tcp://
+// Docker daemon; invalid TLS material fails instead of falling back to
+// plaintext.
+func NewAgent(apiURL, hostID, datacenter, dockerSocket, agentToken string, httpPort int, syncInterval time.Duration, dockerTLS DockerTLSConfig) (*Agent, error) {
 	if apiURL == "" {
 		return nil, fmt.Errorf("api URL is required")
 	}
 	if hostID == "" {
 		return nil, fmt.Errorf("host ID is required")
 	}
+	if syncInterval < 0 || (syncInterval > 0 && syncInterval < MinSyncInterval) {
+		return nil, fmt.Errorf("sync interval must be 0 (disabled) or at least %v, got %v", MinSyncInterval, syncInterval)
+	}
 
 	// Use default Docker socket if not specified
 	if dockerSocket == "" {
 		dockerSocket = "/var/run/docker.sock"
 	}
 
-	var tunnel *network.SSHTunnel
-	var dockerClient *dockerclient.Client
-
-	// Check if using SSH connection
-	if strings.HasPrefix(dockerSocket, "ssh://") {
-		// Parse SSH URL: ssh://user@host:port
-		u, err := url.Parse(dockerSocket)
-		if err != nil {
-			return nil, fmt.Errorf("failed to parse SSH URL: %w", err)
-		}
-
-		// Extract username from URL
-		username := u.User.Username()
-		if username == "" {
-			return nil, fmt.Errorf("SSH URL must include username (e.g., ssh://user@host)")
-		}
-
-		// Extract host and port
-		host := u.Hostname()
-		port := u.Port()
-		if port == "" {
-			port = "22" // Default SSH port
-		}
-		sshAddress := net.JoinHostPort(host, port)
-
-		// Get SSH key path from environment
-		sshKeyPath := os.Getenv("DOCKER_SSH_IDENTITY")
-		if sshKeyPath == "" {
-			sshKeyPath = os.Getenv("HOME") + "/.ssh/id_rsa"
-		}
-
-		log.Printf("Creating SSH tunnel to %s@%s using key %s", username, sshAddress, sshKeyPath)
-
-		// Create SSH tunnel
-		tunnel, err = network.NewSSHTunnel(sshAddress, username, sshKeyPath, "")
-		if err != nil {
-			return nil, fmt.Errorf("failed to create SSH tunnel: %w", err)
-		}
-
-		log.Printf("✓ SSH tunnel established to %s", sshAddress)
-
-		// Create custom HTTP client with tunnel
-		customHTTPClient := &http.Client{
-			Transport: &http.Transport{
-				DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
-					// For Docker over SSH, we connect to the remote Docker socket
-					return tunnel.Dial("unix", "/var/run/docker.sock")
-				},
-			},
-		}
-
-		// Create Docker client with custom HTTP client
-		dockerClient, err = dockerclient.NewClientWithOpts(
-			dockerclient.WithHost("http://docker"),
-			dockerclient.WithHTTPClient(customHTTPClient),
-			dockerclient.WithAPIVersionNegotiation(),
-		)
-		if err != nil {
-			_ = tunnel.Close()
-			return nil, fmt.Errorf("failed to create Docker client: %w", err)
-		}
-	} else {
-		// Non-SSH connection (unix://, tcp://, or plain path)
-		dockerHost := dockerSocket
-		if !strings.Contains(dockerSocket, "://") {
-			dockerHost = "unix://" + dockerSocket
-		}
-
-		// Set DOCKER_HOST for non-SSH connections
-		if err := os.Setenv("DOCKER_HOST", dockerHost); err != nil {
-			return nil, fmt.Errorf("failed to set DOCKER_HOST: %w", err)
-		}
-
-		// Create standard Docker client
-		var err error
-		dockerClient, err = dockerclient.NewClientWithOpts(
-			dockerclient.FromEnv,
-			dockerclient.WithAPIVersionNegotiation(),
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to create Docker client: %w", err)
-		}
+	conn, err := ConnectDocker(dockerSocket, "", dockerTLS)
+	if err != nil {
+		return nil, err
 	}
 
 	// Verify Docker connection
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
-	_, err := dockerClient.Ping(ctx)
-	if err != nil {
-		if tunnel != nil {
-			_ = tunnel.Close()
-		}
+	if _, err := conn.Ping(ctx); err != nil {
+		_ = conn.Close()
 		return nil, fmt.Errorf("failed to connect to Docker: %w", err)
 	}
 
@@ -210,19 +173,29 @@ func NewAgent(apiURL, hostID, datacenter, dockerSocket, agentToken string, httpP
 		hostID:       hostID,
 		datacenter:   datacenter,
 		dockerSocket: dockerSocket,
-		docker:       dockerClient,
+		docker:       conn.Client,
 		httpClient: &http.Client{
 			Timeout: 30 * time.Second,
 		},
-		sshTunnel:    tunnel,
-		syncInterval: 30 * time.Second,
-		authToken:    agentToken,
-		httpPort:     httpPort,
+		sshTunnel:     conn.tunnel,
+		syncInterval:  syncInterval,
+		authToken:     agentToken,
+		httpPort:      httpPort,
+		pacer:         newSyncPacer(),
+		backoff:       DefaultRetryBackoff(),
+		fingerprints:  newSyncFingerprints(),
+		syncBatchSize: DefaultSyncBatchSize,
+		taskPush:      true,
+		startTime:     time.Now(),
 	}, nil
 }
 
-// Close closes the agent and cleans up resources.
+// Close closes the agent and cleans up resources. It writes the sync
+// fingerprints to the state file, if one is set.
 func (a *Agent) Close() error {
+	if err := a.saveSyncState(); err != nil {
+		log.Printf("Warning: Failed to save sync state: %v", err)
+	}
 	if a.sshTunnel != nil {
 		return a.sshTunnel.Close()
 	}
@@ -241,12 +214,22 @@ func (a *Agent) Start(ctx context.Context) error {
 			return fmt.Errorf("failed to start HTTP server: %w", err)
 		}
 	}
+	if a.metricsAddress != "" {
+		if err := a.startMetricsServer(ctx); err != nil {
+			return fmt.Errorf("failed to start metrics server: %w", err)
+		}
+	}
 
 	// Verify authentication before proceeding
 	if err := a.verifyAuthentication(ctx); err != nil {
 		return fmt.Errorf("authentication failed: %w\n\nThe agent cannot operate without valid authentication.\nPlease check:\n  1. Agent token is configured in config.yaml\n  2. Token is valid and not expired\n  3. Server security.auth_enabled matches agent configuration", err)
 	}
 
+	// Pace container syncs to the server's rate limit
+	if err := a.negotiateRateLimit(ctx); err != nil {
+		log.Printf("Warning: Failed to read server rate limit: %v", err)
+	}
+
 	// Register host with API server
 	if err := a.registerHost(ctx); err != nil {
 		log.Printf("Warning: Failed to register host: %v", err)
@@ -267,14 +250,32 @@ func (a *Agent) Start(ctx context.Context) error {
 		log.Printf("Warning: Initial sync failed: %v", err)
 	}
 
-	// Start periodic sync in background
-	go a.periodicSync(ctx)
+	// Start periodic sync in background, unless disabled
+	if a.syncInterval > 0 {
+		go a.periodicSync(ctx)
+	} else {
+		log.Printf("Periodic full sync disabled, relying on Docker events")
+	}
+
+	// Persist sync fingerprints, so a restart doesn't resend every container
+	if a.stateFile != "" {
+		go a.periodicStateSave(ctx)
+	}
 
 	// Start periodic metrics reporting
 	go a.periodicMetricsReport(ctx)
 
+	// Start periodic Docker system info reporting
+	go a.periodicSystemInfoReport(ctx)
+
+	// Start shipping the agent's own logs, if enabled
+	if a.logs != nil {
+		go a.periodicLogShipping(ctx)
+	}
+
 	// Start task executor for deployment operations
 	taskExecutor := NewTaskExecutor(a, 5*time.Second)
+	a.tasks.Store(taskExecutor)
 	go func() {
 		if err := taskExecutor.Start(ctx); err != nil && err != context.Canceled {
 			log.Printf("Task executor error: %v", err)
@@ -300,7 +301,7 @@ func (a *Agent) verifyAuthentication(ctx context.Context) error {
 		req.Header.Set("Authorization", "Bearer "+a.authToken)
 	}
 
-	resp, err := a.httpClient.Do(req)
+	resp, err := a.doRequestWithBackoff(req)
 	if err != nil {
 		return fmt.Errorf("failed to connect to API server: %w", err)
 	}
@@ -325,6 +326,20 @@ func (a *Agent) verifyAuthentication(ctx context.Context) error {
 	return nil
 }
 
+// CheckDocker verifies that the Docker daemon answers on the agent's socket.
+func (a *Agent) CheckDocker(ctx context.Context) error {
+	if _, err := a.docker.Ping(ctx); err != nil {
+		return fmt.Errorf("docker daemon at %s is not reachable: %w", a.dockerSocket, err)
+	}
+	return nil
+}
+
+// CheckAuthentication verifies, without starting the agent, that the API
+// server is reachable and accepts the agent's token.
+func (a *Agent) CheckAuthentication(ctx context.Context) error {
+	return a.verifyAuthentication(ctx)
+}
+
 // registerHost registers this host with the API server.
 func (a *Agent) registerHost(ctx context.Context) error {
 	// Get Docker host info
@@ -361,7 +376,7 @@ func (a *Agent) registerHost(ctx context.Context) error {
 		return fmt.Errorf("failed to marshal host: %w", err)
 	}
 
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(data))
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(data))
 	if err != nil {
 		return fmt.Errorf("failed to create request: %w", err)
 	}
@@ -370,7 +385,7 @@ func (a *Agent) registerHost(ctx context.Context) error {
 		req.Header.Set("Authorization", "Bearer "+a.authToken)
 	}
 
-	resp, err := a.httpClient.Do(req)
+	resp, err := a.doRequestWithBackoff(req)
 	if err != nil {
 		return fmt.Errorf("failed to register host: %w", err)
 	}
@@ -385,52 +400,133 @@ func (a *Agent) registerHost(ctx context.Context) error {
 	return nil
 }
 
-// syncContainers discovers all containers and syncs them with the API.
+// syncContainers discovers the containers the discovery selector tracks
+// (all by default) and syncs them with the API, logging and counting the
+// sync.
 func (a *Agent) syncContainers(ctx context.Context) error {
-	// List all containers (including stopped ones)
-	containers, err := a.docker.ContainerList(ctx, container.ListOptions{All: true})
+	started := time.Now()
+	synced, skipped := a.fingerprints.synced.Load(), a.fingerprints.skipped.Load()
+	slog.Info("sync started", "host_id", a.hostID)
+
+	count, err := a.syncTrackedContainers(ctx)
+	duration := time.Since(started)
+	a.recordSync(duration, count, err)
 	if err != nil {
-		return fmt.Errorf("failed to list containers: %w", err)
+		slog.Error("sync failed",
+			"host_id", a.hostID,
+			"containers", count,
+			"duration_ms", duration.Milliseconds(),
+			"error", err.Error(),
+		)
+		return err
+	}
+
+	slog.Info("sync completed",
+		"host_id", a.hostID,
+		"containers", count,
+		"synced", a.fingerprints.synced.Load()-synced,
+		"skipped", a.fingerprints.skipped.Load()-skipped,
+		"duration_ms", duration.Milliseconds(),
+	)
+	return nil
+}
+
+// syncTrackedContainers does the work of syncContainers and returns how
+// many containers it discovered.
+func (a *Agent) syncTrackedContainers(ctx context.Context) (int, error) {
+	// List the containers to track (including stopped ones)
+	containers, err := a.listTrackedContainers(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list containers: %w", err)
 	}
 
 	log.Printf("Discovered %d containers", len(containers))
 
-	// Build set of container IDs that exist in Docker
-	dockerContainerIDs := make(map[string]bool)
+	// Build set of container IDs that are tracked
+	tracked := make(map[string]bool)
 	for _, c := range containers {
-		dockerContainerIDs[c.ID] = true
+		tracked[c.ID] = true
 	}
 
-	// Sync each container with rate limiting to avoid overwhelming the API
-	for i, c := range containers {
-		if err := a.syncContainer(ctx, c.ID); err != nil {
-			log.Printf("Warning: Failed to sync container %s: %v", c.ID[:12], err)
+	if a.syncBatchSize > 0 {
+		// Sync containers in batches through the bulk endpoint
+		ids := make([]string, len(containers))
+		for i, c := range containers {
+			ids[i] = c.ID
+		}
+		if err := a.syncContainersBulk(ctx, ids); err != nil {
+			return len(containers), err
 		}
+	} else {
+		// Sync each container with rate limiting to avoid overwhelming the API
+		for i, c := range containers {
+			if err := a.syncContainer(ctx, c.ID); err != nil {
+				// Don't retry every remaining container against a server that is down
+				if errors.Is(err, errAPIUnavailable) {
+					return len(containers), fmt.Errorf("aborted sync after %d of %d containers: %w", i, len(containers), err)
+				}
+				log.Printf("Warning: Failed to sync container %s: %v", c.ID[:12], err)
+			}
 
-		// Add delay between syncs to respect rate limits (except for the last one)
-		if i < len(containers)-1 {
-			time.Sleep(100 * time.Millisecond)
+			// Add delay between syncs to respect rate limits (except for the last one)
+			if i < len(containers)-1 {
+				if err := a.pacer.wait(ctx); err != nil {
+					return len(containers), err
+				}
+			}
 		}
 	}
+	a.pacer.relax()
+
+	// Sample resource usage on full syncs only; events don't pay for it.
+	// Usage is reported apart from the containers, so it doesn't make
+	// unchanged containers look changed.
+	if err := a.reportContainerUsage(ctx, a.sampleContainerStats(ctx, containers)); err != nil {
+		log.Printf("Warning: Failed to report container usage: %v", err)
+	}
+
+	// Forget containers removed since they were last synced
+	a.fingerprints.retain(tracked)
+
+	// Build set of container IDs that exist in Docker
+	dockerContainerIDs := tracked
+	if !a.discovery.Empty() {
+		all, err := a.docker.ContainerList(ctx, container.ListOptions{All: true})
+		if err != nil {
+			return len(containers), fmt.Errorf("failed to list containers: %w", err)
+		}
+		dockerContainerIDs = make(map[string]bool, len(all))
+		for _, c := range all {
+			dockerContainerIDs[c.ID] = true
+		}
+
+		// Containers that stopped matching the selector leave the graph
+		a.removeUntrackedContainers(ctx, dockerContainerIDs, tracked)
+	}
 
 	// Clean up ignore list: remove entries for containers that no longer exist in Docker
 	// This handles the edge case where the agent missed a "destroy" event
 	a.cleanupIgnoreList(ctx, dockerContainerIDs)
 
-	return nil
+	return len(containers), nil
 }
 
 // syncContainer syncs a single container with the API server.
 //
 // This function implements a smart CREATE-or-UPDATE strategy:
-//  1. First checks if container exists via GET request
-//  2. If GET returns 200 OK: container exists, use PUT to UPDATE
-//  3. If GET returns any other status (404, 401, etc.): use POST to CREATE
+//  1. First checks if container exists via HEAD request
+//  2. If HEAD returns 410 Gone: a user deleted the container and it is
+//     tombstoned (in the ignore list), skip it
+//  3. If HEAD returns 200 OK with the container's content hash: the server
+//     is up to date, skip the update
+//  4. If HEAD returns 200 OK with another hash: use PUT to UPDATE
+//  5. If HEAD returns any other status (404, 401, etc.): use POST to CREATE
 //
 // This approach handles various scenarios:
 //   - New containers that don't exist yet (404 → POST)
-//   - Authentication issues on GET (401 → POST, which may succeed)
+//   - Authentication issues on HEAD (401 → POST, which may succeed)
 //   - Existing containers that need updates (200 → PUT)
+//   - Unchanged containers in a periodic sync (200, same hash → no request)
 //
 // The function also handles the case where a container no longer exists in
 // Docker (IsErrNotFound), which is normal when containers are removed.
@@ -438,50 +534,23 @@ func (a *Agent) syncContainers(ctx context.Context) error {
 // Rate limiting is handled by the caller (syncContainers) which adds delays
 // between calls to this function.
 func (a *Agent) syncContainer(ctx context.Context, containerID string) error {
-	// Inspect container for full details
-	inspect, err := a.docker.ContainerInspect(ctx, containerID)
-	if err != nil {
-		// Container no longer exists in Docker - this is normal when containers are removed
-		if dockerclient.IsErrNotFound(err) {
-			log.Printf("Container %s no longer exists in Docker, skipping sync", containerID[:12])
-			return nil
-		}
-		return fmt.Errorf("failed to inspect container: %w", err)
+	container, err := a.inspectContainer(ctx, containerID)
+	if err != nil || container == nil {
+		return err
 	}
 
-	// Convert to Graphium container model
-	container := a.dockerToGraphium(inspect)
-
-	// Check if this container is in the ignore list (user-deleted containers)
-	ignoreURL := fmt.Sprintf("%s/api/v1/containers/%s/ignored", a.apiURL, container.ID)
-	ignoreReq, err := http.NewRequestWithContext(ctx, "HEAD", ignoreURL, nil)
-	if err != nil {
-		log.Printf("Warning: Failed to create ignore check request for %s: %v", containerID[:12], err)
-		// Continue with sync despite error (fail-open)
-	} else {
-		if a.authToken != "" {
-			ignoreReq.Header.Set("Authorization", "Bearer "+a.authToken)
-		}
-
-		ignoreResp, err := a.httpClient.Do(ignoreReq)
-		if err != nil {
-			log.Printf("Warning: Failed to check ignore list for %s: %v", containerID[:12], err)
-			// Continue with sync despite error (fail-open)
-		} else {
-			_ = ignoreResp.Body.Close()
-
-			// If container is ignored (200 OK), skip syncing
-			if ignoreResp.StatusCode == http.StatusOK {
-				log.Printf("Container %s is in ignore list, skipping sync", containerID[:12])
-				return nil
-			}
-			// If 404 (not ignored), continue with normal sync below
-		}
+	// Skip containers that didn't change since they were last synced
+	hash := container.ContentHash()
+	if a.fingerprints.unchanged(container.ID, hash) {
+		a.fingerprints.skipped.Add(1)
+		return nil
 	}
 
-	// Check if container already exists
+	// Check if container already exists, and whether the stored copy
+	// differs from what Docker reports. Containers users deleted are
+	// tombstoned and answer 410 Gone.
 	url := fmt.Sprintf("%s/api/v1/containers/%s", a.apiURL, container.ID)
-	checkReq, err := http.NewRequest("GET", url, nil)
+	checkReq, err := http.NewRequestWithContext(ctx, "HEAD", url, nil)
 	if err != nil {
 		return fmt.Errorf("failed to create check request: %w", err)
 	}
@@ -489,12 +558,24 @@ func (a *Agent) syncContainer(ctx context.Context, containerID string) error {
 		checkReq.Header.Set("Authorization", "Bearer "+a.authToken)
 	}
 
-	resp, err := a.httpClient.Do(checkReq)
+	resp, err := a.doRequestWithBackoff(checkReq)
 	if err != nil {
 		return fmt.Errorf("failed to check container: %w", err)
 	}
 	_ = resp.Body.Close()
 
+	if resp.StatusCode == http.StatusGone {
+		log.Printf("Container %s is in ignore list, skipping sync", containerID[:12])
+		return nil
+	}
+
+	// Skip the update if the server already has this content
+	if resp.StatusCode == http.StatusOK && resp.Header.Get(models.ContentHashHeader) == hash {
+		a.fingerprints.record(container.ID, hash)
+		a.fingerprints.skipped.Add(1)
+		return nil
+	}
+
 	var method string
 	var endpoint string
 
@@ -516,7 +597,7 @@ func (a *Agent) syncContainer(ctx context.Context, containerID string) error {
 		return fmt.Errorf("failed to marshal container: %w", err)
 	}
 
-	req, err := http.NewRequest(method, endpoint, bytes.NewBuffer(data))
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, bytes.NewBuffer(data))
 	if err != nil {
 		return fmt.Errorf("failed to create request: %w", err)
 	}
@@ -525,7 +606,7 @@ func (a *Agent) syncContainer(ctx context.Context, containerID string) error {
 		req.Header.Set("Authorization", "Bearer "+a.authToken)
 	}
 
-	resp, err = a.httpClient.Do(req)
+	resp, err = a.doRequestWithBackoff(req)
 	if err != nil {
 		return fmt.Errorf("failed to sync container: %w", err)
 	}
@@ -536,10 +617,41 @@ func (a *Agent) syncContainer(ctx context.Context, containerID string) error {
 		return fmt.Errorf("API error: %s - %s", resp.Status, string(body))
 	}
 
-	log.Printf("✓ Synced container: %s (%s)", inspect.Name, container.Status)
+	a.fingerprints.record(container.ID, hash)
+	a.fingerprints.synced.Add(1)
+
+	log.Printf("✓ Synced container: %s (%s)", container.Name, container.Status)
 	return nil
 }
 
+// inspectContainer inspects a Docker container and converts it to the
+// Graphium container model, including the registry digest of its image.
+// It returns nil if the container no longer exists in Docker.
+func (a *Agent) inspectContainer(ctx context.Context, containerID string) (*models.Container, error) {
+	// Inspect container for full details
+	inspect, err := a.docker.ContainerInspect(ctx, containerID)
+	if err != nil {
+		// Container no longer exists in Docker - this is normal when containers are removed
+		if dockerclient.IsErrNotFound(err) {
+			log.Printf("Container %s no longer exists in Docker, skipping sync", containerID[:12])
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to inspect container: %w", err)
+	}
+
+	// Convert to Graphium container model
+	container := a.dockerToGraphium(inspect)
+
+	// Record the registry digest of the running image for update detection
+	if digest, err := a.imageDigest(ctx, inspect.Image, container.Image); err != nil {
+		log.Printf("Warning: Failed to resolve image digest for %s: %v", containerID[:12], err)
+	} else {
+		container.ImageDigest = digest
+	}
+
+	return container, nil
+}
+
 // monitorEvents monitors Docker events and syncs changes in real-time.
 func (a *Agent) monitorEvents(ctx context.Context) error {
 	// Subscribe to Docker events
@@ -566,7 +678,8 @@ func (a *Agent) monitorEvents(ctx context.Context) error {
 				})
 			}
 		case event := <-eventsChan:
-			if event.Type == events.ContainerEventType {
+			// Container events carry the container's labels as attributes
+			if event.Type == events.ContainerEventType && a.discovery.Matches(event.Actor.Attributes) {
 				a.handleContainerEvent(ctx, event)
 			}
 		}
@@ -577,7 +690,23 @@ func (a *Agent) monitorEvents(ctx context.Context) error {
 func (a *Agent) handleContainerEvent(ctx context.Context, event events.Message) {
 	containerID := event.Actor.ID
 
-	log.Printf("Docker event: %s - %s", event.Action, containerID[:12])
+	a.recordEvent()
+	slog.Info("event received",
+		"host_id", a.hostID,
+		"action", string(event.Action),
+		"container_id", containerID[:12],
+	)
+
+	// Whatever changed, the next sync of the container must reach the server
+	a.fingerprints.forget(containerID)
+
+	// Health check results arrive as "health_status: healthy" etc.
+	if strings.HasPrefix(string(event.Action), string(events.ActionHealthStatus)) {
+		if err := a.syncContainer(ctx, containerID); err != nil {
+			log.Printf("Failed to update container health: %v", err)
+		}
+		return
+	}
 
 	switch event.Action {
 	case "create", "start", "restart", "unpause":
@@ -598,31 +727,36 @@ func (a *Agent) handleContainerEvent(ctx context.Context, event events.Message)
 		}
 
 	case "destroy", "remove":
-		// Remove container from API
-		url := fmt.Sprintf("%s/api/v1/containers/%s", a.apiURL, containerID)
-		req, err := http.NewRequest("DELETE", url, nil)
-		if err != nil {
-			log.Printf("Failed to create delete request: %v", err)
-			return
-		}
-		if a.authToken != "" {
-			req.Header.Set("Authorization", "Bearer "+a.authToken)
-		}
+		a.removeContainer(ctx, containerID)
+	}
+}
 
-		resp, err := a.httpClient.Do(req)
-		if err != nil {
-			log.Printf("Failed to delete container: %v", err)
-			return
-		}
-		_ = resp.Body.Close()
+// removeContainer removes a container from the API and from the ignore
+// list, which the deletion adds it to.
+func (a *Agent) removeContainer(ctx context.Context, containerID string) {
+	url := fmt.Sprintf("%s/api/v1/containers/%s", a.apiURL, containerID)
+	req, err := http.NewRequestWithContext(ctx, "DELETE", url, nil)
+	if err != nil {
+		log.Printf("Failed to create delete request: %v", err)
+		return
+	}
+	if a.authToken != "" {
+		req.Header.Set("Authorization", "Bearer "+a.authToken)
+	}
 
-		if resp.StatusCode == http.StatusOK || resp.StatusCode == http.StatusNotFound {
-			log.Printf("✓ Container removed: %s", containerID[:12])
-		}
+	resp, err := a.doRequest(req)
+	if err != nil {
+		log.Printf("Failed to delete container: %v", err)
+		return
+	}
+	_ = resp.Body.Close()
 
-		// Clean up: remove from ignore list (container no longer exists)
-		a.removeFromIgnoreList(containerID)
+	if resp.StatusCode == http.StatusOK || resp.StatusCode == http.StatusNotFound {
+		log.Printf("✓ Container removed: %s", containerID[:12])
 	}
+
+	// Clean up: remove from ignore list (container no longer exists)
+	a.removeFromIgnoreList(containerID)
 }
 
 // removeFromIgnoreList removes a container from the ignore list.
@@ -642,7 +776,7 @@ func (a *Agent) removeFromIgnoreList(containerID string) {
 		req.Header.Set("Authorization", "Bearer "+a.authToken)
 	}
 
-	resp, err := a.httpClient.Do(req)
+	resp, err := a.doRequest(req)
 	if err != nil {
 		log.Printf("Warning: Failed to remove %s from ignore list: %v", containerID[:12], err)
 		return
@@ -656,7 +790,8 @@ func (a *Agent) removeFromIgnoreList(containerID string) {
 }
 
 // cleanupIgnoreList removes stale entries from the ignore list.
-// An ignore list entry is considered stale if the container no longer exists in Docker.
+// An ignore list entry of this host is considered stale if the container no
+// longer exists in Docker.
 // This handles the edge case where the agent missed a "destroy" event or was offline.
 func (a *Agent) cleanupIgnoreList(ctx context.Context, dockerContainerIDs map[string]bool) {
 	// Fetch current ignore list from API
@@ -671,7 +806,7 @@ func (a *Agent) cleanupIgnoreList(ctx context.Context, dockerContainerIDs map[st
 		req.Header.Set("Authorization", "Bearer "+a.authToken)
 	}
 
-	resp, err := a.httpClient.Do(req)
+	resp, err := a.doRequest(req)
 	if err != nil {
 		log.Printf("Warning: Failed to fetch ignore list: %v", err)
 		return
@@ -685,17 +820,19 @@ func (a *Agent) cleanupIgnoreList(ctx context.Context, dockerContainerIDs map[st
 
 	// Parse ignore list response
 	var ignoreList []struct {
-		ContainerID string `json:"container_id"`
+		ContainerID string `json:"containerId"`
+		HostID      string `json:"hostId"`
 	}
 	if err := json.NewDecoder(resp.Body).Decode(&ignoreList); err != nil {
 		log.Printf("Warning: Failed to decode ignore list: %v", err)
 		return
 	}
 
-	// Check each ignored container and remove if not in Docker
+	// Check each ignored container of this host and remove if not in Docker;
+	// other hosts' containers are never in this Docker
 	cleanedCount := 0
 	for _, entry := range ignoreList {
-		if !dockerContainerIDs[entry.ContainerID] {
+		if entry.ContainerID != "" && entry.HostID == a.hostID && !dockerContainerIDs[entry.ContainerID] {
 			// Container doesn't exist in Docker, remove from ignore list
 			log.Printf("Cleaning up stale ignore list entry for %s (not in Docker)", entry.ContainerID[:12])
 			a.removeFromIgnoreList(entry.ContainerID)
@@ -718,17 +855,21 @@ func (a *Agent) periodicSync(ctx context.Context) {
 		case <-ctx.Done():
 			return
 		case <-ticker.C:
-			log.Printf("Running periodic sync...")
-			if err := a.syncContainers(ctx); err != nil {
-				log.Printf("Periodic sync error: %v", err)
-			}
+			// syncContainers logs the outcome
+			_ = a.syncContainers(ctx)
 		}
 	}
 }
 
-// periodicMetricsReport collects and reports system metrics periodically.
+// periodicMetricsReport collects and reports system metrics every sync
+// interval, or every DefaultSyncInterval if periodic sync is disabled, and
+// logs a summary of the agent's counters.
 func (a *Agent) periodicMetricsReport(ctx context.Context) {
-	ticker := time.NewTicker(a.syncInterval)
+	interval := a.syncInterval
+	if interval <= 0 {
+		interval = DefaultSyncInterval
+	}
+	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 
 	// Report immediately on start
@@ -744,6 +885,7 @@ func (a *Agent) periodicMetricsReport(ctx context.Context) {
 			if err := a.reportMetrics(ctx); err != nil {
 				log.Printf("Metrics report error: %v", err)
 			}
+			a.logSummary()
 		}
 	}
 }
@@ -763,6 +905,9 @@ func (a *Agent) reportMetrics(ctx context.Context) error {
 		"memoryUsagePercent": metrics.MemoryUsagePercent,
 		"lastMetricsUpdate":  metrics.Timestamp.Format(time.RFC3339),
 	}
+	if a.draining.Load() {
+		update["status"] = hostStatusDraining
+	}
 
 	data, err := json.Marshal(update)
 	if err != nil {
@@ -780,7 +925,7 @@ func (a *Agent) reportMetrics(ctx context.Context) error {
 		req.Header.Set("Authorization", "Bearer "+a.authToken)
 	}
 
-	resp, err := a.httpClient.Do(req)
+	resp, err := a.doRequestWithBackoff(req)
 	if err != nil {
 		return fmt.Errorf("failed to send metrics: %w", err)
 	}
@@ -852,21 +997,198 @@ func (a *Agent) dockerToGraphium(inspect types.ContainerJSON) *models.Container
 		}
 	}
 
+	// Health check status, if the container defines a health check
+	health := ""
+	if inspect.State.Health != nil && inspect.State.Health.Status != container.NoHealthcheck {
+		health = inspect.State.Health.Status
+	}
+
 	// Clean container name (remove leading /)
 	name := strings.TrimPrefix(inspect.Name, "/")
 
+	labels := containerLabels(inspect.Config.Labels, name)
+
+	stopGracePeriod := 0
+	if inspect.Config.StopTimeout != nil {
+		stopGracePeriod = *inspect.Config.StopTimeout
+	}
+
 	return &models.Container{
-		Context:  "https://schema.org",
-		Type:     "SoftwareApplication",
-		ID:       inspect.ID,
-		Name:     name,
-		Image:    inspect.Config.Image,
-		Status:   status,
-		HostedOn: a.hostID,
-		Ports:    ports,
-		Env:      env,
-		Created:  inspect.Created,
+		Context:   "https://schema.org",
+		Type:      "SoftwareApplication",
+		ID:        inspect.ID,
+		Name:      name,
+		Image:     inspect.Config.Image,
+		Status:    status,
+		Health:    health,
+		HostedOn:  a.hostID,
+		Ports:     ports,
+		Env:       env,
+		Labels:    labels,
+		Created:   inspect.Created,
+		Resources: containerResources(inspect.HostConfig, inspect.Config.Labels),
+
+		DockerName:      name,
+		DockerLabels:    labels,
+		StopGracePeriod: stopGracePeriod,
+		Restart:         restartState(inspect),
+	}
+}
+
+// restartState returns the restart state of a container from docker
+// inspect, or nil if it never exited.
+func restartState(inspect types.ContainerJSON) *models.RestartState {
+	if inspect.ContainerJSONBase == nil || inspect.State == nil {
+		return nil
+	}
+
+	var finishedAt *time.Time
+	if finished, err := time.Parse(time.RFC3339Nano, inspect.State.FinishedAt); err == nil && !finished.IsZero() {
+		finishedAt = &finished
+	}
+	if finishedAt == nil && inspect.RestartCount == 0 {
+		return nil
+	}
+
+	state := &models.RestartState{
+		RestartCount: inspect.RestartCount,
+		Restarting:   inspect.State.Restarting,
+		ExitCode:     inspect.State.ExitCode,
+		OOMKilled:    inspect.State.OOMKilled,
+		FinishedAt:   finishedAt,
+	}
+	if inspect.HostConfig != nil {
+		state.Policy = string(inspect.HostConfig.RestartPolicy.Name)
+	}
+	if finishedAt != nil {
+		state.LastExitReason = models.ExitReason(inspect.State.ExitCode, inspect.State.OOMKilled, inspect.State.Error)
+	}
+	return state
+}
+
+// containerResources returns the effective limits and reservations of a
+// container from its Docker host config, or nil if it has none. The CPU
+// reservation comes from the label set by the stack deployer.
+func containerResources(hostConfig *container.HostConfig, labels map[string]string) *models.ResourceConstraints {
+	if hostConfig == nil {
+		return nil
+	}
+
+	limits := models.ResourceLimits{Memory: hostConfig.Memory}
+	if hostConfig.Memory > 0 {
+		limits.MemorySwap = hostConfig.MemorySwap
+	}
+	switch {
+	case hostConfig.NanoCPUs > 0:
+		limits.CPUs = float64(hostConfig.NanoCPUs) / 1e9
+	case hostConfig.CPUQuota > 0 && hostConfig.CPUPeriod > 0:
+		limits.CPUs = float64(hostConfig.CPUQuota) / float64(hostConfig.CPUPeriod)
+	}
+	if hostConfig.PidsLimit != nil && *hostConfig.PidsLimit > 0 {
+		limits.Pids = *hostConfig.PidsLimit
+	}
+
+	reservations := models.ResourceReservations{Memory: hostConfig.MemoryReservation}
+	if cpus, err := strconv.ParseFloat(labels[models.CPUReservationLabel], 64); err == nil && cpus > 0 {
+		reservations.CPUs = cpus
+	}
+
+	resources := &models.ResourceConstraints{}
+	if limits != (models.ResourceLimits{}) {
+		resources.Limits = &limits
+	}
+	if reservations != (models.ResourceReservations{}) {
+		resources.Reservations = &reservations
+	}
+	if resources.Limits == nil && resources.Reservations == nil {
+		return nil
+	}
+	return resources
+}
+
+// Bounds on the Docker labels stored with a container. Labels are saved in
+// the container document and indexed by the containers_by_label view, so
+// images carrying e.g. embedded manifests or long descriptions don't bloat
+// either.
+const (
+	maxContainerLabels     = 256
+	maxContainerLabelBytes = 4096
+)
+
+// containerLabels returns the Docker labels of a container to store, or nil
+// if it has none. Labels with a key or value over maxContainerLabelBytes are
+// dropped rather than truncated, so label queries never match a cut-off
+// value; beyond maxContainerLabels, labels are kept in key order.
+func containerLabels(labels map[string]string, name string) map[string]string {
+	if len(labels) == 0 {
+		return nil
+	}
+
+	keys := make([]string, 0, len(labels))
+	for key, value := range labels {
+		if len(key) > maxContainerLabelBytes || len(value) > maxContainerLabelBytes {
+			continue
+		}
+		keys = append(keys, key)
+	}
+	dropped := len(labels) - len(keys)
+	if len(keys) > maxContainerLabels {
+		sort.Strings(keys)
+		dropped += len(keys) - maxContainerLabels
+		keys = keys[:maxContainerLabels]
+	}
+	if dropped > 0 {
+		log.Printf("Warning: Dropped %d of %d labels of container %s (at most %d labels of %d bytes are kept)",
+			dropped, len(labels), name, maxContainerLabels, maxContainerLabelBytes)
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+
+	kept := make(map[string]string, len(keys))
+	for _, key := range keys {
+		kept[key] = labels[key]
+	}
+	return kept
+}
+
+// imageDigest returns the registry digest (sha256:...) of a local image.
+// Images that were never pulled from a registry have no digest and return "".
+func (a *Agent) imageDigest(ctx context.Context, imageID, imageRef string) (string, error) {
+	imageInspect, err := a.docker.ImageInspect(ctx, imageID)
+	if err != nil {
+		return "", err
+	}
+	return repoDigestFor(imageInspect.RepoDigests, imageRef), nil
+}
+
+// repoDigestFor picks the digest of the repository the container was started
+// from out of an image's RepoDigests (repo@sha256:...). An image can be known
+// under several repositories; the first digest is used if none matches.
+func repoDigestFor(repoDigests []string, imageRef string) string {
+	if i := strings.Index(imageRef, "@"); i >= 0 {
+		return imageRef[i+1:]
+	}
+
+	repo := imageRef
+	if i := strings.LastIndex(repo, ":"); i > strings.LastIndex(repo, "/") {
+		repo = repo[:i]
+	}
+
+	fallback := ""
+	for _, repoDigest := range repoDigests {
+		parts := strings.SplitN(repoDigest, "@", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		if parts[0] == repo || strings.TrimPrefix(parts[0], "docker.io/library/") == repo || strings.TrimPrefix(parts[0], "docker.io/") == repo {
+			return parts[1]
+		}
+		if fallback == "" {
+			fallback = parts[1]
+		}
 	}
+	return fallback
 }
 
 // getHostIP attempts to determine the host's IP address.
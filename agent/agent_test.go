@@ -0,0 +1,60 @@
+package agent
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestContainerLabels(t *testing.T) {
+	long := strings.Repeat("x", maxContainerLabelBytes+1)
+	many := make(map[string]string, maxContainerLabels+10)
+	for i := 0; i < maxContainerLabels+10; i++ {
+		many[fmt.Sprintf("label-%03d", i)] = "v"
+	}
+
+	tests := []struct {
+		name    string
+		labels  map[string]string
+		want    int
+		kept    []string
+		dropped []string
+	}{
+		{name: "nil", labels: nil, want: 0},
+		{name: "empty", labels: map[string]string{}, want: 0},
+		{name: "kept", labels: map[string]string{"app": "web", "tier": "front"}, want: 2, kept: []string{"app", "tier"}},
+		{name: "oversized value", labels: map[string]string{"app": "web", "blob": long}, want: 1, kept: []string{"app"}, dropped: []string{"blob"}},
+		{name: "oversized key", labels: map[string]string{"app": "web", long: "v"}, want: 1, kept: []string{"app"}, dropped: []string{long}},
+		{name: "only oversized", labels: map[string]string{"blob": long}, want: 0},
+		{
+			name:    "label cap",
+			labels:  many,
+			want:    maxContainerLabels,
+			kept:    []string{"label-000", fmt.Sprintf("label-%03d", maxContainerLabels-1)},
+			dropped: []string{fmt.Sprintf("label-%03d", maxContainerLabels)},
+		},
+	}
+
+	for _, tt := range tests {
+		got := containerLabels(tt.labels, "web-1")
+		if tt.want == 0 {
+			if got != nil {
+				t.Errorf("%s: containerLabels() = %v, want nil", tt.name, got)
+			}
+			continue
+		}
+		if len(got) != tt.want {
+			t.Errorf("%s: containerLabels() kept %d labels, want %d", tt.name, len(got), tt.want)
+		}
+		for _, key := range tt.kept {
+			if got[key] != tt.labels[key] {
+				t.Errorf("%s: expected label %.20s to be kept", tt.name, key)
+			}
+		}
+		for _, key := range tt.dropped {
+			if _, ok := got[key]; ok {
+				t.Errorf("%s: expected label %.20s to be dropped", tt.name, key)
+			}
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package agent
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"net/http"
+	"time"
+)
+
+// errAPIUnavailable is returned once a request still fails after all
+// retries because the API server was unreachable or unavailable.
+var errAPIUnavailable = errors.New("API server unavailable")
+
+// RetryBackoff is how the agent retries API requests that failed because
+// the server was unreachable or answered 502, 503 or 504. The wait doubles
+// per retry and is jittered, so agents (and container syncs) that failed
+// together don't retry in lockstep. Zero fields fall back to
+// DefaultRetryBackoff.
+type RetryBackoff struct {
+	// BaseDelay is the wait before the first retry
+	BaseDelay time.Duration
+
+	// MaxDelay caps the wait between retries
+	MaxDelay time.Duration
+
+	// MaxAttempts is how often a request is sent at most (1 = no retries)
+	MaxAttempts int
+}
+
+// DefaultRetryBackoff returns the default retry policy: 4 attempts, waiting
+// about 0.5s, 1s and 2s in between.
+func DefaultRetryBackoff() RetryBackoff {
+	return RetryBackoff{
+		BaseDelay:   500 * time.Millisecond,
+		MaxDelay:    10 * time.Second,
+		MaxAttempts: 4,
+	}
+}
+
+// withDefaults fills unset fields from DefaultRetryBackoff.
+func (b RetryBackoff) withDefaults() RetryBackoff {
+	defaults := DefaultRetryBackoff()
+	if b.BaseDelay <= 0 {
+		b.BaseDelay = defaults.BaseDelay
+	}
+	if b.MaxDelay <= 0 {
+		b.MaxDelay = defaults.MaxDelay
+	}
+	if b.MaxAttempts <= 0 {
+		b.MaxAttempts = defaults.MaxAttempts
+	}
+	return b
+}
+
+// delay returns the wait before the given retry (1 for the first): a random
+// duration between half and all of BaseDelay doubled per earlier retry,
+// capped at MaxDelay.
+func (b RetryBackoff) delay(retry int) time.Duration {
+	wait := b.BaseDelay
+	for i := 1; i < retry && wait < b.MaxDelay; i++ {
+		wait *= 2
+	}
+	wait = min(wait, b.MaxDelay)
+	half := wait / 2
+	return half + time.Duration(rand.Int64N(int64(wait-half)+1))
+}
+
+// SetRetryBackoff sets how the agent retries requests to an unavailable API
+// server (agent retry_* config). It must be called before Start.
+func (a *Agent) SetRetryBackoff(backoff RetryBackoff) {
+	a.backoff = backoff.withDefaults()
+}
+
+// serverUnavailable reports whether a response means the API server (or the
+// proxy in front of it) is temporarily unavailable.
+func serverUnavailable(resp *http.Response) bool {
+	switch resp.StatusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// doRequestWithBackoff sends a request like doRequest and retries it with
+// the agent's RetryBackoff while the server is unreachable or unavailable.
+// Requests with a body are only retried if it can be rewound (GetBody).
+// Cancelling the request's context aborts the wait immediately. When all
+// attempts fail it logs once and returns an error wrapping
+// errAPIUnavailable.
+func (a *Agent) doRequestWithBackoff(req *http.Request) (*http.Response, error) {
+	backoff := a.backoff.withDefaults()
+	ctx := req.Context()
+
+	var lastErr error
+	attempt := 1
+	for ; ; attempt++ {
+		resp, err := a.doRequest(req)
+		if err == nil && !serverUnavailable(resp) {
+			return resp, nil
+		}
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			if resp != nil {
+				_ = resp.Body.Close()
+			}
+			return nil, ctxErr
+		}
+
+		if err != nil {
+			lastErr = err
+		} else {
+			lastErr = fmt.Errorf("HTTP %s", resp.Status)
+			_ = resp.Body.Close()
+		}
+
+		rewindable := req.Body == nil || req.GetBody != nil
+		if attempt >= backoff.MaxAttempts || !rewindable {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(backoff.delay(attempt)):
+		}
+
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req.Body = body
+		}
+	}
+
+	log.Printf("API server unavailable: %s %s failed after %d attempt(s) (%d retries): %v",
+		req.Method, req.URL.Path, attempt, attempt-1, lastErr)
+	return nil, fmt.Errorf("%w: %s %s: %v", errAPIUnavailable, req.Method, req.URL.Path, lastErr)
+}
@@ -0,0 +1,159 @@
+package agent
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+
+	"evalgo.org/graphium/models"
+)
+
+// DefaultSyncBatchSize is how many containers a full sync sends per bulk
+// request unless configured otherwise (agent.sync_batch_size).
+const DefaultSyncBatchSize = 50
+
+// bulkSyncIgnored is the bulk result error of containers in the ignore list.
+const bulkSyncIgnored = "ignored"
+
+// bulkSyncResult is the outcome of one container of a bulk sync request.
+type bulkSyncResult struct {
+	ID      string `json:"id"`
+	Error   string `json:"error,omitempty"`
+	Reason  string `json:"reason,omitempty"`
+	Success bool   `json:"success"`
+}
+
+// SetSyncBatchSize sets how many containers a full sync sends per bulk
+// request; 0 syncs them one request at a time. It must be called before
+// Start.
+func (a *Agent) SetSyncBatchSize(size int) {
+	a.syncBatchSize = max(size, 0)
+}
+
+// syncContainersBulk syncs containers in batches of syncBatchSize through
+// POST /api/v1/containers/bulk, which creates new containers and updates
+// existing ones. Containers that didn't change since they were last synced
+// aren't sent. If a batch request fails, its containers are synced one at a
+// time; so are containers the bulk endpoint refused, so one bad document
+// doesn't block the others.
+func (a *Agent) syncContainersBulk(ctx context.Context, containerIDs []string) error {
+	pending := make([]*models.Container, 0, len(containerIDs))
+	hashes := make(map[string]string, len(containerIDs))
+	for _, id := range containerIDs {
+		container, err := a.inspectContainer(ctx, id)
+		if err != nil {
+			log.Printf("Warning: Failed to sync container %s: %v", id[:12], err)
+			continue
+		}
+		if container == nil {
+			continue
+		}
+
+		hash := container.ContentHash()
+		if a.fingerprints.unchanged(container.ID, hash) {
+			a.fingerprints.skipped.Add(1)
+			continue
+		}
+		hashes[container.ID] = hash
+		pending = append(pending, container)
+	}
+
+	for start := 0; start < len(pending); start += a.syncBatchSize {
+		batch := pending[start:min(start+a.syncBatchSize, len(pending))]
+
+		var retry []*models.Container
+		results, err := a.postContainerBatch(ctx, batch)
+		switch {
+		case errors.Is(err, errAPIUnavailable):
+			return fmt.Errorf("aborted sync after %d of %d containers: %w", start, len(pending), err)
+		case err != nil:
+			log.Printf("Warning: Bulk sync of %d containers failed, syncing them one at a time: %v", len(batch), err)
+			retry = batch
+		default:
+			for _, container := range batch {
+				result, ok := results[container.ID]
+				switch {
+				case ok && result.Success:
+					a.fingerprints.record(container.ID, hashes[container.ID])
+					a.fingerprints.synced.Add(1)
+				case ok && result.Error == bulkSyncIgnored:
+					log.Printf("Container %s is in ignore list, skipping sync", container.ID[:12])
+				default:
+					log.Printf("Warning: Bulk sync of container %s failed, syncing it alone: %s %s",
+						container.ID[:12], result.Error, result.Reason)
+					retry = append(retry, container)
+				}
+			}
+			log.Printf("✓ Synced %d containers", len(batch)-len(retry))
+		}
+
+		for _, container := range retry {
+			if err := a.syncContainer(ctx, container.ID); err != nil {
+				if errors.Is(err, errAPIUnavailable) {
+					return fmt.Errorf("aborted sync after %d of %d containers: %w", start, len(pending), err)
+				}
+				log.Printf("Warning: Failed to sync container %s: %v", container.ID[:12], err)
+			}
+			if err := a.pacer.wait(ctx); err != nil {
+				return err
+			}
+		}
+
+		// Add delay between batches to respect rate limits (except after the last one)
+		if start+a.syncBatchSize < len(pending) {
+			if err := a.pacer.wait(ctx); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// postContainerBatch sends containers to the bulk endpoint and returns the
+// results by container ID.
+func (a *Agent) postContainerBatch(ctx context.Context, containers []*models.Container) (map[string]bulkSyncResult, error) {
+	data, err := json.Marshal(containers)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal containers: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/api/v1/containers/bulk", a.apiURL)
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if a.authToken != "" {
+		req.Header.Set("Authorization", "Bearer "+a.authToken)
+	}
+
+	resp, err := a.doRequestWithBackoff(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("API error: %s - %s", resp.Status, string(body))
+	}
+
+	var response struct {
+		Results []bulkSyncResult `json:"results"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("failed to decode bulk response: %w", err)
+	}
+
+	results := make(map[string]bulkSyncResult, len(response.Results))
+	for _, result := range response.Results {
+		results[result.ID] = result
+	}
+	return results, nil
+}
@@ -0,0 +1,257 @@
+package agent
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/network"
+
+	"evalgo.org/graphium/models"
+)
+
+// Connectivity probe modes reported in the task result.
+const (
+	probeModeNamespace = "container-namespace" // probes run inside the source container's network namespace
+	probeModeHost      = "host"                // probes run from the agent's host network
+)
+
+// executeConnectivityCheck probes whether a container can reach a target
+// service and measures the latency. Probes run inside the source container's
+// network namespace where the agent has the privileges to enter it, and from
+// the host network otherwise.
+func (e *TaskExecutor) executeConnectivityCheck(ctx context.Context, task *models.AgentTask) (*models.TaskResult, error) {
+	var payload struct {
+		models.ConnectivityCheckPayload
+
+		// Object is set by scheduled actions targeting a container
+		Object *models.ActionObject `json:"object,omitempty"`
+	}
+	if err := task.GetPayloadAs(&payload); err != nil {
+		return nil, fmt.Errorf("invalid connectivity payload: %w", err)
+	}
+
+	containerID := payload.ContainerID
+	if containerID == "" && payload.Object != nil {
+		containerID = payload.Object.ID
+	}
+	if containerID == "" {
+		return nil, fmt.Errorf("missing 'containerId' field in payload")
+	}
+
+	host, port, err := net.SplitHostPort(payload.Target)
+	if err != nil || host == "" || port == "" {
+		return nil, fmt.Errorf("invalid target %q: must be host:port", payload.Target)
+	}
+
+	protocol := payload.Protocol
+	if protocol == "" {
+		protocol = models.ConnectivityProtocolTCP
+	}
+	if protocol != models.ConnectivityProtocolTCP && protocol != models.ConnectivityProtocolHTTP {
+		return nil, fmt.Errorf("invalid protocol: %s", protocol)
+	}
+	attempts := payload.Attempts
+	if attempts <= 0 {
+		attempts = 3
+	}
+	timeout := time.Duration(payload.Timeout) * time.Second
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+
+	source, err := e.agent.docker.ContainerInspect(ctx, containerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to inspect container %s: %w", containerID, err)
+	}
+	if source.State == nil || !source.State.Running {
+		return &models.TaskResult{
+			Success: false,
+			Message: fmt.Sprintf("Container %s is not running", strings.TrimPrefix(source.Name, "/")),
+			Data: map[string]interface{}{
+				"container_id": containerID,
+				"target":       payload.Target,
+			},
+		}, nil
+	}
+
+	ip, err := e.resolveProbeTarget(ctx, source, host)
+	if err != nil {
+		return &models.TaskResult{
+			Success: false,
+			Message: fmt.Sprintf("Cannot resolve %s: %v", host, err),
+			Data: map[string]interface{}{
+				"container_id": containerID,
+				"target":       payload.Target,
+				"error":        err.Error(),
+			},
+		}, nil
+	}
+	address := net.JoinHostPort(ip, port)
+
+	mode := probeModeNamespace
+	dial := func(ctx context.Context) (net.Conn, error) {
+		return dialInNetNamespace(ctx, source.State.Pid, address, timeout)
+	}
+	if err := checkNetNamespace(source.State.Pid); err != nil {
+		log.Printf("Connectivity check: probing from the host network, cannot enter the namespace of %s: %v", containerID, err)
+		mode = probeModeHost
+		dialer := &net.Dialer{Timeout: timeout}
+		dial = func(ctx context.Context) (net.Conn, error) {
+			return dialer.DialContext(ctx, "tcp", address)
+		}
+	}
+
+	var latencies []float64
+	var probeErrors []string
+	statusCode := 0
+	for i := 0; i < attempts; i++ {
+		latency, code, err := probeOnce(ctx, dial, protocol, payload.Target, payload.Path, timeout)
+		if err != nil {
+			probeErrors = append(probeErrors, err.Error())
+			continue
+		}
+		statusCode = code
+		latencies = append(latencies, float64(latency.Microseconds())/1000)
+	}
+
+	data := map[string]interface{}{
+		"container_id":     containerID,
+		"container_name":   strings.TrimPrefix(source.Name, "/"),
+		"target":           payload.Target,
+		"resolved_address": address,
+		"protocol":         protocol,
+		"mode":             mode,
+		"attempts":         attempts,
+		"successes":        len(latencies),
+	}
+	if len(latencies) > 0 {
+		minLatency, maxLatency, total := latencies[0], latencies[0], 0.0
+		for _, l := range latencies {
+			minLatency = min(minLatency, l)
+			maxLatency = max(maxLatency, l)
+			total += l
+		}
+		data["latency_min_ms"] = minLatency
+		data["latency_avg_ms"] = total / float64(len(latencies))
+		data["latency_max_ms"] = maxLatency
+	}
+	if protocol == models.ConnectivityProtocolHTTP && statusCode != 0 {
+		data["status_code"] = statusCode
+	}
+	if len(probeErrors) > 0 {
+		data["errors"] = probeErrors
+	}
+
+	success := len(latencies) == attempts
+	message := fmt.Sprintf("%s reachable from %s (%d/%d probes)", payload.Target, data["container_name"], len(latencies), attempts)
+	if !success {
+		message = fmt.Sprintf("%s unreachable from %s (%d/%d probes failed)", payload.Target, data["container_name"], attempts-len(latencies), attempts)
+	}
+
+	return &models.TaskResult{
+		Success: success,
+		Message: message,
+		Data:    data,
+	}, nil
+}
+
+// resolveProbeTarget resolves a target host to an IP address. Container
+// names and network aliases on networks shared with the source resolve to
+// the target's address on that network; other names use DNS.
+func (e *TaskExecutor) resolveProbeTarget(ctx context.Context, source container.InspectResponse, host string) (string, error) {
+	if ip := net.ParseIP(host); ip != nil {
+		return host, nil
+	}
+
+	if source.NetworkSettings != nil {
+		for _, endpoint := range source.NetworkSettings.Networks {
+			if endpoint == nil || endpoint.NetworkID == "" {
+				continue
+			}
+			if ip := e.lookupOnNetwork(ctx, endpoint.NetworkID, host); ip != "" {
+				return ip, nil
+			}
+		}
+	}
+
+	addrs, err := net.DefaultResolver.LookupHost(ctx, host)
+	if err != nil {
+		return "", err
+	}
+	return addrs[0], nil
+}
+
+// lookupOnNetwork returns the IP of the container named host, or carrying
+// host as an alias, on a Docker network.
+func (e *TaskExecutor) lookupOnNetwork(ctx context.Context, networkID, host string) string {
+	resource, err := e.agent.docker.NetworkInspect(ctx, networkID, network.InspectOptions{})
+	if err != nil {
+		return ""
+	}
+
+	ipOf := func(endpoint network.EndpointResource) string {
+		ip, _, _ := strings.Cut(endpoint.IPv4Address, "/")
+		return ip
+	}
+
+	for _, endpoint := range resource.Containers {
+		if endpoint.Name == host {
+			return ipOf(endpoint)
+		}
+	}
+	for id, endpoint := range resource.Containers {
+		info, err := e.agent.docker.ContainerInspect(ctx, id)
+		if err != nil || info.NetworkSettings == nil {
+			continue
+		}
+		settings := info.NetworkSettings.Networks[resource.Name]
+		if settings != nil && (slices.Contains(settings.Aliases, host) || slices.Contains(settings.DNSNames, host)) {
+			return ipOf(endpoint)
+		}
+	}
+	return ""
+}
+
+// probeOnce connects to the target once and returns the latency: the TCP
+// connect time, or the time to the HTTP response status for HTTP probes.
+func probeOnce(ctx context.Context, dial func(context.Context) (net.Conn, error), protocol, target, path string, timeout time.Duration) (time.Duration, int, error) {
+	start := time.Now()
+	conn, err := dial(ctx)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer conn.Close()
+
+	if protocol != models.ConnectivityProtocolHTTP {
+		return time.Since(start), 0, nil
+	}
+
+	if path == "" {
+		path = "/"
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://"+target+path, nil)
+	if err != nil {
+		return 0, 0, err
+	}
+	req.Close = true
+
+	_ = conn.SetDeadline(start.Add(timeout))
+	if err := req.Write(conn); err != nil {
+		return 0, 0, fmt.Errorf("failed to send HTTP request: %w", err)
+	}
+	resp, err := http.ReadResponse(bufio.NewReader(conn), req)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to read HTTP response: %w", err)
+	}
+	_ = resp.Body.Close()
+
+	return time.Since(start), resp.StatusCode, nil
+}
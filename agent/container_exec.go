@@ -2,65 +2,57 @@ package agent
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
-	"io"
 	"time"
 
 	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/pkg/stdcopy"
 
 	"evalgo.org/graphium/models"
 )
 
-// executeContainerExec executes a command inside a running container
-func (e *TaskExecutor) executeContainerExec(ctx context.Context, payload map[string]interface{}) (*models.TaskResult, error) {
+// SetAllowExec sets whether exec tasks may run commands inside containers
+// on this host; the server decides which ones. It must be called before
+// Start.
+func (a *Agent) SetAllowExec(allow bool) {
+	a.allowExec = allow
+}
+
+// executeContainerExec executes a command inside a running container: an
+// exec ControlAction or a workflow's container-exec step. Stdout and stderr
+// are kept up to the payload's output limit each. If the task times out,
+// the result is reported as failed, but Docker can't stop the command,
+// which may keep running in the container.
+func (e *TaskExecutor) executeContainerExec(ctx context.Context, payloadMap map[string]interface{}) (*models.TaskResult, error) {
 	startTime := time.Now()
 
-	// Extract container ID
-	containerID, ok := payload["containerId"].(string)
-	if !ok || containerID == "" {
-		return nil, fmt.Errorf("missing or invalid 'containerId' field in payload")
+	if !e.agent.allowExec {
+		return nil, fmt.Errorf("exec is disabled on this agent (agent.allow_exec)")
 	}
 
-	// Extract command
-	commandRaw, ok := payload["command"]
-	if !ok {
-		return nil, fmt.Errorf("missing 'command' field in payload")
+	data, err := json.Marshal(payloadMap)
+	if err != nil {
+		return nil, fmt.Errorf("invalid exec payload: %w", err)
 	}
-
-	// Convert command to string slice
-	var cmd []string
-	switch v := commandRaw.(type) {
-	case []interface{}:
-		cmd = make([]string, len(v))
-		for i, c := range v {
-			cmd[i] = fmt.Sprintf("%v", c)
-		}
-	case []string:
-		cmd = v
-	case string:
-		// Single command string
-		cmd = []string{"/bin/sh", "-c", v}
-	default:
-		return nil, fmt.Errorf("invalid command format: must be string or array")
+	var payload models.ExecPayload
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return nil, fmt.Errorf("invalid exec payload: %w", err)
 	}
+	if err := payload.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid exec payload: %w", err)
+	}
+	containerID := payload.ContainerID
+	cmd := []string(payload.Command)
 
-	// Extract optional working directory
-	workDir, _ := payload["workDir"].(string)
-
-	// Extract optional environment variables
 	var env []string
-	if envRaw, ok := payload["env"].(map[string]interface{}); ok {
-		for k, v := range envRaw {
-			env = append(env, fmt.Sprintf("%s=%v", k, v))
-		}
+	for k, v := range payload.Env {
+		env = append(env, fmt.Sprintf("%s=%s", k, v))
 	}
 
-	// Extract optional user
-	user, _ := payload["user"].(string)
-
 	// Create exec configuration
 	execConfig := container.ExecOptions{
-		User:         user,
+		User:         payload.User,
 		Privileged:   false,
 		Tty:          false,
 		AttachStdin:  false,
@@ -69,7 +61,7 @@ func (e *TaskExecutor) executeContainerExec(ctx context.Context, payload map[str
 		Detach:       false,
 		DetachKeys:   "",
 		Env:          env,
-		WorkingDir:   workDir,
+		WorkingDir:   payload.WorkDir,
 		Cmd:          cmd,
 	}
 
@@ -103,15 +95,27 @@ func (e *TaskExecutor) executeContainerExec(ctx context.Context, payload map[str
 	}
 	defer resp.Close()
 
-	// Read the output
-	output, err := io.ReadAll(resp.Reader)
-	if err != nil {
+	// Unblock the copy below if the task times out or is cancelled
+	stop := context.AfterFunc(ctx, resp.Close)
+	defer stop()
+
+	// Read the output, keeping up to the limit of each stream
+	limit := payload.OutputLimit()
+	stdout := &cappedBuffer{limit: limit}
+	stderr := &cappedBuffer{limit: limit}
+	if _, err := stdcopy.StdCopy(stdout, stderr, resp.Reader); err != nil {
+		if ctx.Err() != nil {
+			err = fmt.Errorf("command did not finish in time and may still be running: %w", ctx.Err())
+		}
 		return &models.TaskResult{
 			Success: false,
 			Message: fmt.Sprintf("Failed to read exec output: %v", err),
 			Data: map[string]interface{}{
 				"container_id": containerID,
 				"command":      cmd,
+				"exec_id":      execResp.ID,
+				"stdout":       stdout.String(),
+				"stderr":       stderr.String(),
 				"error":        err.Error(),
 			},
 		}, nil
@@ -126,7 +130,8 @@ func (e *TaskExecutor) executeContainerExec(ctx context.Context, payload map[str
 			Data: map[string]interface{}{
 				"container_id": containerID,
 				"command":      cmd,
-				"output":       string(output),
+				"stdout":       stdout.String(),
+				"stderr":       stderr.String(),
 				"error":        err.Error(),
 			},
 		}, nil
@@ -146,12 +151,16 @@ func (e *TaskExecutor) executeContainerExec(ctx context.Context, payload map[str
 		Success: success,
 		Message: message,
 		Data: map[string]interface{}{
-			"container_id": containerID,
-			"command":      cmd,
-			"exit_code":    exitCode,
-			"output":       string(output),
-			"duration_ms":  duration.Milliseconds(),
-			"exec_id":      execResp.ID,
+			"container_id":     containerID,
+			"command":          cmd,
+			"exit_code":        exitCode,
+			"stdout":           stdout.String(),
+			"stderr":           stderr.String(),
+			"stdout_truncated": stdout.truncated,
+			"stderr_truncated": stderr.truncated,
+			"output":           stdout.String(), // Kept for workflows referencing it
+			"duration_ms":      duration.Milliseconds(),
+			"exec_id":          execResp.ID,
 		},
 	}, nil
 }
@@ -0,0 +1,159 @@
+package agent
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+
+	"evalgo.org/graphium/models"
+)
+
+// statsConcurrency is how many containers the agent samples stats of in
+// parallel. Docker takes about a second per sample to measure CPU usage.
+const statsConcurrency = 8
+
+// statsTimeout bounds reading the stats of one container.
+const statsTimeout = 10 * time.Second
+
+// sampleContainerStats reads one stats sample of each running container,
+// keyed by container ID. Stopped containers get an empty sample (no usage).
+// Containers whose stats can't be read are left out and keep their last
+// sample on the server, so one failing container doesn't fail the sync.
+func (a *Agent) sampleContainerStats(ctx context.Context, containers []container.Summary) map[string]*container.StatsResponse {
+	var mu sync.Mutex
+	samples := make(map[string]*container.StatsResponse, len(containers))
+
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, statsConcurrency)
+	for _, c := range containers {
+		if c.State != container.StateRunning {
+			samples[c.ID] = &container.StatsResponse{Read: time.Now()}
+			continue
+		}
+
+		wg.Add(1)
+		go func(id string) {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+
+			stats, err := a.readContainerStats(ctx, id)
+			if err != nil {
+				log.Printf("Warning: Failed to read stats of container %s: %v", id[:12], err)
+				return
+			}
+			mu.Lock()
+			samples[id] = stats
+			mu.Unlock()
+		}(c.ID)
+	}
+	wg.Wait()
+
+	return samples
+}
+
+// readContainerStats reads one stats sample of a container.
+func (a *Agent) readContainerStats(ctx context.Context, containerID string) (*container.StatsResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, statsTimeout)
+	defer cancel()
+
+	reader, err := a.docker.ContainerStats(ctx, containerID, false)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Body.Close()
+
+	var stats container.StatsResponse
+	if err := json.NewDecoder(reader.Body).Decode(&stats); err != nil {
+		return nil, err
+	}
+	if stats.Read.IsZero() {
+		stats.Read = time.Now()
+	}
+	return &stats, nil
+}
+
+// containerUsage returns the usage of a container from a stats sample,
+// computing CPU percent and memory usage the way docker stats does.
+func containerUsage(containerID string, stats *container.StatsResponse) models.ContainerUsageSample {
+	usage := models.ContainerUsageSample{ContainerID: containerID, SampledAt: stats.Read}
+
+	cpuDelta := float64(stats.CPUStats.CPUUsage.TotalUsage) - float64(stats.PreCPUStats.CPUUsage.TotalUsage)
+	systemDelta := float64(stats.CPUStats.SystemUsage) - float64(stats.PreCPUStats.SystemUsage)
+	cpus := float64(stats.CPUStats.OnlineCPUs)
+	if cpus == 0 {
+		cpus = float64(len(stats.CPUStats.CPUUsage.PercpuUsage))
+	}
+	if cpuDelta > 0 && systemDelta > 0 {
+		usage.CPUPercent = cpuDelta / systemDelta * cpus * 100
+	}
+
+	// Page cache can be reclaimed, so it doesn't count as used
+	memory := stats.MemoryStats.Usage
+	if inactive, ok := stats.MemoryStats.Stats["inactive_file"]; ok && inactive < memory {
+		memory -= inactive
+	} else if inactive, ok := stats.MemoryStats.Stats["total_inactive_file"]; ok && inactive < memory {
+		memory -= inactive
+	}
+	usage.MemoryUsage = int64(memory)
+
+	for _, network := range stats.Networks {
+		usage.NetworkRx += int64(network.RxBytes)
+		usage.NetworkTx += int64(network.TxBytes)
+	}
+	return usage
+}
+
+// reportContainerUsage sends the usage samples of a sync to the API server
+// in one request. Usage is reported apart from the containers: it changes
+// on every sample and would otherwise make every running container look
+// changed to the sync.
+func (a *Agent) reportContainerUsage(ctx context.Context, stats map[string]*container.StatsResponse) error {
+	if len(stats) == 0 {
+		return nil
+	}
+
+	samples := make([]models.ContainerUsageSample, 0, len(stats))
+	for id, sample := range stats {
+		samples = append(samples, containerUsage(id, sample))
+	}
+	sort.Slice(samples, func(i, j int) bool {
+		return samples[i].ContainerID < samples[j].ContainerID
+	})
+
+	data, err := json.Marshal(map[string]interface{}{"containers": samples})
+	if err != nil {
+		return fmt.Errorf("failed to marshal usage: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/api/v1/hosts/%s/container-usage", a.apiURL, a.hostID)
+	req, err := http.NewRequestWithContext(ctx, "PUT", url, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if a.authToken != "" {
+		req.Header.Set("Authorization", "Bearer "+a.authToken)
+	}
+
+	resp, err := a.doRequestWithBackoff(req)
+	if err != nil {
+		return fmt.Errorf("failed to send usage: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("usage update failed: %s - %s", resp.Status, string(body))
+	}
+	return nil
+}
@@ -101,10 +101,7 @@ func (d *AgentDeployer) DeleteContainer(ctx context.Context, payload *models.Del
 
 	// Stop container if running (unless force is true)
 	if !payload.Force {
-		timeout := payload.StopTimeout
-		if timeout == 0 {
-			timeout = 10
-		}
+		timeout := d.stopGracePeriod(ctx, containerID, payload.StopTimeout, models.DefaultStopGracePeriod)
 
 		if err := evecommon.ContainerStop(ctx, d.docker, containerID, timeout); err != nil {
 			// Ignore error if container is already stopped
@@ -135,6 +132,20 @@ func (d *AgentDeployer) DeleteContainer(ctx context.Context, payload *models.Del
 	return result, nil
 }
 
+// stopGracePeriod returns the seconds to wait for a container to stop before
+// it is killed: the requested timeout if set, otherwise the container's own
+// stop grace period, otherwise fallback.
+func (d *AgentDeployer) stopGracePeriod(ctx context.Context, containerID string, requested, fallback int) int {
+	if requested > 0 {
+		return requested
+	}
+	inspect, err := d.docker.ContainerInspect(ctx, containerID)
+	if err == nil && inspect.Config != nil && inspect.Config.StopTimeout != nil && *inspect.Config.StopTimeout > 0 {
+		return *inspect.Config.StopTimeout
+	}
+	return fallback
+}
+
 // StopContainer stops a running container.
 func (d *AgentDeployer) StopContainer(ctx context.Context, payload *models.ControlContainerPayload) (*models.TaskResult, error) {
 	containerID := payload.ContainerID
@@ -142,10 +153,7 @@ func (d *AgentDeployer) StopContainer(ctx context.Context, payload *models.Contr
 		return nil, fmt.Errorf("container ID is required")
 	}
 
-	timeout := payload.Timeout
-	if timeout == 0 {
-		timeout = 10
-	}
+	timeout := d.stopGracePeriod(ctx, containerID, payload.Timeout, models.DefaultStopGracePeriod)
 
 	if err := evecommon.ContainerStop(ctx, d.docker, containerID, timeout); err != nil {
 		return nil, fmt.Errorf("failed to stop container: %w", err)
@@ -155,6 +163,7 @@ func (d *AgentDeployer) StopContainer(ctx context.Context, payload *models.Contr
 		Success:     true,
 		ContainerID: containerID,
 		Message:     fmt.Sprintf("Container %s stopped successfully", payload.ContainerName),
+		Data:        d.containerStateData(ctx, containerID),
 	}
 
 	return result, nil
@@ -175,11 +184,114 @@ func (d *AgentDeployer) StartContainer(ctx context.Context, payload *models.Cont
 		Success:     true,
 		ContainerID: containerID,
 		Message:     fmt.Sprintf("Container %s started successfully", payload.ContainerName),
+		Data:        d.containerStateData(ctx, containerID),
+	}
+
+	return result, nil
+}
+
+// PauseContainer freezes the processes of a running container without
+// stopping it. Pausing a container that is already paused succeeds without
+// a change; a container that isn't running can't be paused.
+func (d *AgentDeployer) PauseContainer(ctx context.Context, payload *models.ControlContainerPayload) (*models.TaskResult, error) {
+	containerID := payload.ContainerID
+	if containerID == "" {
+		return nil, fmt.Errorf("container ID is required")
+	}
+
+	inspect, err := d.docker.ContainerInspect(ctx, containerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to inspect container: %w", err)
+	}
+	if inspect.State == nil {
+		return nil, fmt.Errorf("container %s has no state", containerID)
+	}
+	switch {
+	case inspect.State.Paused:
+		return &models.TaskResult{
+			Success:     true,
+			ContainerID: containerID,
+			Message:     fmt.Sprintf("Container %s is already paused", payload.ContainerName),
+			Data:        stateData(inspect.State, false),
+		}, nil
+	case !inspect.State.Running:
+		return nil, fmt.Errorf("container %s is not running (state: %s)", containerID, inspect.State.Status)
+	}
+
+	if err := d.docker.ContainerPause(ctx, containerID); err != nil {
+		return nil, fmt.Errorf("failed to pause container: %w", err)
+	}
+
+	result := &models.TaskResult{
+		Success:     true,
+		ContainerID: containerID,
+		Message:     fmt.Sprintf("Container %s paused successfully", payload.ContainerName),
+		Data:        d.containerStateData(ctx, containerID),
 	}
 
 	return result, nil
 }
 
+// UnpauseContainer resumes the processes of a paused container.
+// Unpausing a container that isn't paused succeeds without a change.
+func (d *AgentDeployer) UnpauseContainer(ctx context.Context, payload *models.ControlContainerPayload) (*models.TaskResult, error) {
+	containerID := payload.ContainerID
+	if containerID == "" {
+		return nil, fmt.Errorf("container ID is required")
+	}
+
+	inspect, err := d.docker.ContainerInspect(ctx, containerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to inspect container: %w", err)
+	}
+	if inspect.State == nil {
+		return nil, fmt.Errorf("container %s has no state", containerID)
+	}
+	if !inspect.State.Paused {
+		return &models.TaskResult{
+			Success:     true,
+			ContainerID: containerID,
+			Message:     fmt.Sprintf("Container %s is not paused (state: %s)", payload.ContainerName, inspect.State.Status),
+			Data:        stateData(inspect.State, false),
+		}, nil
+	}
+
+	if err := d.docker.ContainerUnpause(ctx, containerID); err != nil {
+		return nil, fmt.Errorf("failed to unpause container: %w", err)
+	}
+
+	result := &models.TaskResult{
+		Success:     true,
+		ContainerID: containerID,
+		Message:     fmt.Sprintf("Container %s unpaused successfully", payload.ContainerName),
+		Data:        d.containerStateData(ctx, containerID),
+	}
+
+	return result, nil
+}
+
+// containerStateData returns the state of a container for TaskResult.Data,
+// so callers can tell paused from stopped containers. The state is left out
+// if the container can't be inspected.
+func (d *AgentDeployer) containerStateData(ctx context.Context, containerID string) map[string]interface{} {
+	inspect, err := d.docker.ContainerInspect(ctx, containerID)
+	if err != nil || inspect.State == nil {
+		return map[string]interface{}{}
+	}
+	return stateData(inspect.State, true)
+}
+
+// stateData returns a container state for TaskResult.Data; changed tells
+// whether the task changed the state.
+func stateData(state *container.State, changed bool) map[string]interface{} {
+	return map[string]interface{}{
+		"state":   state.Status,
+		"running": state.Running,
+		"paused":  state.Paused,
+		"changed": changed,
+	}
+}
+
 // RestartContainer restarts a container.
 func (d *AgentDeployer) RestartContainer(ctx context.Context, payload *models.ControlContainerPayload) (*models.TaskResult, error) {
 	containerID := payload.ContainerID
@@ -187,9 +299,12 @@ func (d *AgentDeployer) RestartContainer(ctx context.Context, payload *models.Co
 		return nil, fmt.Errorf("container ID is required")
 	}
 
-	timeout := payload.Timeout
-	if timeout == 0 {
-		timeout = 10
+	// A forced restart kills the container right away. Stopping a
+	// container also cancels a restart Docker is backing off, so the start
+	// below bypasses the backoff.
+	timeout := 0
+	if !payload.Force {
+		timeout = d.stopGracePeriod(ctx, containerID, payload.Timeout, models.DefaultStopGracePeriod)
 	}
 
 	// Stop first
@@ -205,10 +320,14 @@ func (d *AgentDeployer) RestartContainer(ctx context.Context, payload *models.Co
 		return nil, fmt.Errorf("failed to start container: %w", err)
 	}
 
+	message := fmt.Sprintf("Container %s restarted successfully", payload.ContainerName)
+	if payload.Force {
+		message = fmt.Sprintf("Container %s force-restarted, bypassing the restart backoff", payload.ContainerName)
+	}
 	result := &models.TaskResult{
 		Success:     true,
 		ContainerID: containerID,
-		Message:     fmt.Sprintf("Container %s restarted successfully", payload.ContainerName),
+		Message:     message,
 	}
 
 	return result, nil
@@ -259,6 +378,10 @@ func (d *AgentDeployer) specToDockerConfig(spec *models.ContainerSpec, payload *
 		User:       spec.User,
 		Labels:     payload.Labels,
 	}
+	if spec.StopGracePeriod > 0 {
+		stopTimeout := spec.StopGracePeriod
+		containerConfig.StopTimeout = &stopTimeout
+	}
 
 	// Exposed ports
 	exposedPorts := make(nat.PortSet)
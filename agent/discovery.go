@@ -0,0 +1,137 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+
+	"evalgo.org/graphium/models"
+)
+
+// DiscoverySelector selects the containers the agent tracks by their Docker
+// labels, e.g. to leave out containers of other teams on a shared host.
+// Labels are given as "key" (the label is set) or "key=value". An empty
+// selector tracks all containers.
+type DiscoverySelector struct {
+	// Include are labels a container must all have to be tracked
+	Include []string
+
+	// Exclude are labels none of which a tracked container may have
+	Exclude []string
+}
+
+// SetDiscoverySelector restricts the containers the agent tracks. It must be
+// called before Start.
+func (a *Agent) SetDiscoverySelector(selector DiscoverySelector) {
+	a.discovery = selector
+}
+
+// Empty reports whether the selector tracks all containers.
+func (s DiscoverySelector) Empty() bool {
+	return len(s.Include) == 0 && len(s.Exclude) == 0
+}
+
+// Matches reports whether a container with labels is tracked.
+func (s DiscoverySelector) Matches(labels map[string]string) bool {
+	for _, selector := range s.Include {
+		if !labelMatches(labels, selector) {
+			return false
+		}
+	}
+	for _, selector := range s.Exclude {
+		if labelMatches(labels, selector) {
+			return false
+		}
+	}
+	return true
+}
+
+// listFilters returns the Docker filters of the include labels, so Docker
+// only lists candidates. Docker can't exclude labels; Matches does.
+func (s DiscoverySelector) listFilters() filters.Args {
+	args := filters.NewArgs()
+	for _, selector := range s.Include {
+		args.Add("label", selector)
+	}
+	return args
+}
+
+// labelMatches reports whether labels satisfy one "key" or "key=value"
+// selector.
+func labelMatches(labels map[string]string, selector string) bool {
+	key, value, hasValue := strings.Cut(selector, "=")
+	actual, ok := labels[key]
+	if !hasValue {
+		return ok
+	}
+	return ok && actual == value
+}
+
+// listTrackedContainers lists the containers (including stopped ones) the
+// discovery selector tracks.
+func (a *Agent) listTrackedContainers(ctx context.Context) ([]container.Summary, error) {
+	containers, err := a.docker.ContainerList(ctx, container.ListOptions{
+		All:     true,
+		Filters: a.discovery.listFilters(),
+	})
+	if err != nil || len(a.discovery.Exclude) == 0 {
+		return containers, err
+	}
+
+	tracked := containers[:0]
+	for _, c := range containers {
+		if a.discovery.Matches(c.Labels) {
+			tracked = append(tracked, c)
+		}
+	}
+	return tracked, nil
+}
+
+// removeUntrackedContainers removes containers from the API that still
+// exist in Docker but no longer match the discovery selector (e.g. after a
+// label was removed or the selector changed), like a destroy event does.
+// dockerContainerIDs are all containers in Docker, tracked the tracked ones.
+func (a *Agent) removeUntrackedContainers(ctx context.Context, dockerContainerIDs, tracked map[string]bool) {
+	url := fmt.Sprintf("%s/api/v1/query/containers/by-host/%s", a.apiURL, a.hostID)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		log.Printf("Warning: Failed to create container list request: %v", err)
+		return
+	}
+	if a.authToken != "" {
+		req.Header.Set("Authorization", "Bearer "+a.authToken)
+	}
+
+	resp, err := a.doRequestWithBackoff(req)
+	if err != nil {
+		log.Printf("Warning: Failed to list containers of host %s: %v", a.hostID, err)
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		log.Printf("Warning: Failed to list containers of host %s: %s", a.hostID, resp.Status)
+		return
+	}
+
+	var result struct {
+		Containers []*models.Container `json:"containers"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		log.Printf("Warning: Failed to decode containers of host %s: %v", a.hostID, err)
+		return
+	}
+
+	for _, c := range result.Containers {
+		if dockerContainerIDs[c.ID] && !tracked[c.ID] {
+			log.Printf("Container %s no longer matches the discovery selector", c.ID[:12])
+			a.removeContainer(ctx, c.ID)
+		}
+	}
+}
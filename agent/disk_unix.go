@@ -0,0 +1,14 @@
+//go:build !windows
+
+package agent
+
+import "syscall"
+
+// diskUsage returns the total and free bytes of the filesystem holding path.
+func diskUsage(path string) (total, free int64, err error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(path, &stat); err != nil {
+		return 0, 0, err
+	}
+	return int64(stat.Blocks) * int64(stat.Bsize), int64(stat.Bavail) * int64(stat.Bsize), nil
+}
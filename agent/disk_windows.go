@@ -0,0 +1,10 @@
+//go:build windows
+
+package agent
+
+import "fmt"
+
+// diskUsage is not supported on Windows.
+func diskUsage(path string) (total, free int64, err error) {
+	return 0, 0, fmt.Errorf("disk usage is not supported on Windows")
+}
@@ -0,0 +1,160 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"slices"
+	"strings"
+	"time"
+
+	"evalgo.org/graphium/models"
+)
+
+// executeDNSCheck resolves a hostname and, if the task expects records,
+// checks that every resolved record is one of them. A name that doesn't
+// exist (NXDOMAIN) is reported apart from a resolver that failed or timed
+// out, since only the latter may pass on the next run.
+func (e *TaskExecutor) executeDNSCheck(ctx context.Context, task *models.AgentTask) (*models.TaskResult, error) {
+	var payload models.DNSCheckPayload
+	if err := task.GetPayloadAs(&payload); err != nil {
+		return nil, fmt.Errorf("invalid dns payload: %w", err)
+	}
+	if err := payload.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid dns payload: %w", err)
+	}
+
+	recordType := payload.RecordType
+	if recordType == "" {
+		recordType = models.DNSRecordA
+	}
+	timeout := time.Duration(payload.Timeout) * time.Second
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	data := map[string]interface{}{
+		"hostname":    payload.Hostname,
+		"record_type": recordType,
+	}
+	if payload.Nameserver != "" {
+		data["nameserver"] = payload.Nameserver
+	}
+
+	start := time.Now()
+	records, err := resolveRecords(ctx, dnsResolver(payload.Nameserver), payload.Hostname, recordType)
+	data["duration_ms"] = float64(time.Since(start).Microseconds()) / 1000
+
+	if err != nil {
+		data["error"] = err.Error()
+		var dnsErr *net.DNSError
+		if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+			data["nxdomain"] = true
+			return &models.TaskResult{
+				Success: false,
+				Message: fmt.Sprintf("%s does not exist (NXDOMAIN)", payload.Hostname),
+				Data:    data,
+			}, nil
+		}
+		data["transient"] = true
+		return &models.TaskResult{
+			Success: false,
+			Message: fmt.Sprintf("Resolving %s %s failed (resolver error, may be transient): %v", recordType, payload.Hostname, err),
+			Data:    data,
+		}, nil
+	}
+
+	data["records"] = records
+	if len(records) == 0 {
+		return &models.TaskResult{
+			Success: false,
+			Message: fmt.Sprintf("%s has no %s records", payload.Hostname, recordType),
+			Data:    data,
+		}, nil
+	}
+
+	if len(payload.Expected) > 0 {
+		expected := make([]string, len(payload.Expected))
+		for i, record := range payload.Expected {
+			expected[i] = normalizeRecord(record, recordType)
+		}
+		var unexpected []string
+		for _, record := range records {
+			if !slices.Contains(expected, record) {
+				unexpected = append(unexpected, record)
+			}
+		}
+		data["expected"] = expected
+		if len(unexpected) > 0 {
+			data["unexpected"] = unexpected
+			return &models.TaskResult{
+				Success: false,
+				Message: fmt.Sprintf("%s resolves to unexpected %s records: %s", payload.Hostname, recordType, strings.Join(unexpected, ", ")),
+				Data:    data,
+			}, nil
+		}
+	}
+
+	return &models.TaskResult{
+		Success: true,
+		Message: fmt.Sprintf("%s resolves to %s", payload.Hostname, strings.Join(records, ", ")),
+		Data:    data,
+	}, nil
+}
+
+// dnsResolver returns a resolver that asks nameserver (host:port), or the
+// host's resolver if nameserver is empty.
+func dnsResolver(nameserver string) *net.Resolver {
+	if nameserver == "" {
+		return net.DefaultResolver
+	}
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			var dialer net.Dialer
+			return dialer.DialContext(ctx, network, nameserver)
+		},
+	}
+}
+
+// resolveRecords resolves the records of one type of a hostname, normalized
+// for comparison.
+func resolveRecords(ctx context.Context, resolver *net.Resolver, hostname, recordType string) ([]string, error) {
+	if recordType == models.DNSRecordCNAME {
+		cname, err := resolver.LookupCNAME(ctx, hostname)
+		if err != nil {
+			return nil, err
+		}
+		return []string{normalizeRecord(cname, recordType)}, nil
+	}
+
+	network := "ip4"
+	if recordType == models.DNSRecordAAAA {
+		network = "ip6"
+	}
+	ips, err := resolver.LookupIP(ctx, network, hostname)
+	if err != nil {
+		return nil, err
+	}
+	records := make([]string, len(ips))
+	for i, ip := range ips {
+		records[i] = ip.String()
+	}
+	slices.Sort(records)
+	return records, nil
+}
+
+// normalizeRecord returns a record in the form resolveRecords reports it:
+// IP addresses in canonical form, names lowercased without the trailing dot.
+func normalizeRecord(record, recordType string) string {
+	if recordType == models.DNSRecordCNAME {
+		return strings.ToLower(strings.TrimSuffix(record, "."))
+	}
+	if ip := net.ParseIP(record); ip != nil {
+		return ip.String()
+	}
+	return record
+}
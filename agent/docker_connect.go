@@ -0,0 +1,153 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	dockerclient "github.com/docker/docker/client"
+
+	"eve.evalgo.org/network"
+)
+
+// DockerConnection is a Docker client and, for ssh:// sockets, the SSH
+// tunnel it dials through. Closing it closes both.
+type DockerConnection struct {
+	*dockerclient.Client
+
+	tunnel *network.SSHTunnel
+}
+
+// Close closes the Docker client and the SSH tunnel.
+func (c *DockerConnection) Close() error {
+	err := c.Client.Close()
+	if c.tunnel != nil {
+		if tunnelErr := c.tunnel.Close(); err == nil {
+			err = tunnelErr
+		}
+	}
+	return err
+}
+
+// ConnectDocker creates a Docker client for a Docker socket:
+//   - ssh://user@host[:port] tunnels to /var/run/docker.sock on the host
+//     with the key at sshKeyPath (default: $DOCKER_SSH_IDENTITY or
+//     ~/.ssh/id_rsa)
+//   - tcp:// uses the TLS client certificate in tlsFiles, or the Docker CLI
+//     layout in DOCKER_CERT_PATH, if there is one
+//   - unix:// or a plain path connects to a local socket
+//
+// It doesn't ping the daemon.
+func ConnectDocker(socket, sshKeyPath string, tlsFiles DockerTLSConfig) (*DockerConnection, error) {
+	if !tlsFiles.IsZero() && !strings.HasPrefix(socket, "tcp://") {
+		return nil, fmt.Errorf("docker TLS settings require a tcp:// docker socket, got %s", socket)
+	}
+
+	if strings.HasPrefix(socket, "ssh://") {
+		return connectDockerSSH(socket, sshKeyPath)
+	}
+
+	if tlsFiles := tlsFiles.resolve(); strings.HasPrefix(socket, "tcp://") && !tlsFiles.IsZero() {
+		// Remote daemon over TCP with TLS client certificate
+		if err := tlsFiles.validate(); err != nil {
+			return nil, err
+		}
+
+		client, err := dockerclient.NewClientWithOpts(
+			dockerclient.WithHost(socket),
+			dockerclient.WithTLSClientConfig(tlsFiles.CAFile, tlsFiles.CertFile, tlsFiles.KeyFile),
+			dockerclient.WithAPIVersionNegotiation(),
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create Docker client: %w", err)
+		}
+
+		log.Printf("Connecting to Docker at %s with TLS client certificate %s", socket, tlsFiles.CertFile)
+		return &DockerConnection{Client: client}, nil
+	}
+
+	// Non-SSH connection (unix://, tcp://, or plain path)
+	dockerHost := socket
+	if !strings.Contains(socket, "://") {
+		dockerHost = "unix://" + socket
+	}
+	if strings.HasPrefix(dockerHost, "tcp://") {
+		log.Printf("Warning: Connecting to Docker at %s without TLS", dockerHost)
+	}
+
+	client, err := dockerclient.NewClientWithOpts(
+		dockerclient.FromEnv,
+		dockerclient.WithHost(dockerHost),
+		dockerclient.WithAPIVersionNegotiation(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Docker client: %w", err)
+	}
+	return &DockerConnection{Client: client}, nil
+}
+
+// connectDockerSSH creates a Docker client that dials the host's Docker
+// socket through an SSH tunnel.
+func connectDockerSSH(socket, sshKeyPath string) (*DockerConnection, error) {
+	// Parse SSH URL: ssh://user@host:port
+	u, err := url.Parse(socket)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse SSH URL: %w", err)
+	}
+
+	// Extract username from URL
+	username := u.User.Username()
+	if username == "" {
+		return nil, fmt.Errorf("SSH URL must include username (e.g., ssh://user@host)")
+	}
+
+	// Extract host and port
+	host := u.Hostname()
+	port := u.Port()
+	if port == "" {
+		port = "22" // Default SSH port
+	}
+	sshAddress := net.JoinHostPort(host, port)
+
+	if sshKeyPath == "" {
+		sshKeyPath = os.Getenv("DOCKER_SSH_IDENTITY")
+	}
+	if sshKeyPath == "" {
+		sshKeyPath = os.Getenv("HOME") + "/.ssh/id_rsa"
+	}
+
+	log.Printf("Creating SSH tunnel to %s@%s using key %s", username, sshAddress, sshKeyPath)
+
+	tunnel, err := network.NewSSHTunnel(sshAddress, username, sshKeyPath, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create SSH tunnel: %w", err)
+	}
+
+	log.Printf("✓ SSH tunnel established to %s", sshAddress)
+
+	// Create custom HTTP client with tunnel
+	customHTTPClient := &http.Client{
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+				// For Docker over SSH, we connect to the remote Docker socket
+				return tunnel.Dial("unix", "/var/run/docker.sock")
+			},
+		},
+	}
+
+	client, err := dockerclient.NewClientWithOpts(
+		dockerclient.WithHost("http://docker"),
+		dockerclient.WithHTTPClient(customHTTPClient),
+		dockerclient.WithAPIVersionNegotiation(),
+	)
+	if err != nil {
+		_ = tunnel.Close()
+		return nil, fmt.Errorf("failed to create Docker client: %w", err)
+	}
+	return &DockerConnection{Client: client, tunnel: tunnel}, nil
+}
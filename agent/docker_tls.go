@@ -0,0 +1,70 @@
+package agent
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// DockerTLSConfig is the TLS material the agent uses to connect to a remote
+// Docker daemon on tcp://. The files follow the Docker CLI layout: if none
+// is configured and DOCKER_CERT_PATH is set, the agent uses ca.pem,
+// cert.pem and key.pem in that directory.
+type DockerTLSConfig struct {
+	// CAFile is the CA certificate the daemon's certificate must be signed
+	// by (ca.pem); empty trusts the system roots
+	CAFile string
+
+	// CertFile is the agent's client certificate (cert.pem)
+	CertFile string
+
+	// KeyFile is the private key of CertFile (key.pem)
+	KeyFile string
+}
+
+// IsZero reports whether no TLS material is set.
+func (c DockerTLSConfig) IsZero() bool {
+	return c.CAFile == "" && c.CertFile == "" && c.KeyFile == ""
+}
+
+// resolve returns the configured TLS material or, if there is none, the
+// files of the Docker CLI layout in DOCKER_CERT_PATH.
+func (c DockerTLSConfig) resolve() DockerTLSConfig {
+	if !c.IsZero() {
+		return c
+	}
+	certPath := os.Getenv("DOCKER_CERT_PATH")
+	if certPath == "" {
+		return c
+	}
+	return DockerTLSConfig{
+		CAFile:   filepath.Join(certPath, "ca.pem"),
+		CertFile: filepath.Join(certPath, "cert.pem"),
+		KeyFile:  filepath.Join(certPath, "key.pem"),
+	}
+}
+
+// validate loads the TLS material, so missing or invalid files fail
+// NewAgent instead of the agent falling back to plaintext.
+func (c DockerTLSConfig) validate() error {
+	if (c.CertFile == "") != (c.KeyFile == "") {
+		return fmt.Errorf("docker TLS client certificate and key must be set together")
+	}
+	if c.CertFile != "" {
+		if _, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile); err != nil {
+			return fmt.Errorf("invalid docker TLS client certificate %s / key %s: %w", c.CertFile, c.KeyFile, err)
+		}
+	}
+	if c.CAFile != "" {
+		pem, err := os.ReadFile(c.CAFile)
+		if err != nil {
+			return fmt.Errorf("failed to read docker TLS CA %s: %w", c.CAFile, err)
+		}
+		if !x509.NewCertPool().AppendCertsFromPEM(pem) {
+			return fmt.Errorf("invalid docker TLS CA %s: no PEM certificates found", c.CAFile)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package agent
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// hostStatusDraining is the host status the agent reports while it drains.
+const hostStatusDraining = "draining"
+
+// drainFlushTimeout bounds reporting interrupted tasks and the final sync,
+// which run even if Drain's context already expired.
+const drainFlushTimeout = 30 * time.Second
+
+// interruptedTaskError is the error reported with tasks Drain gave up on.
+const interruptedTaskError = "agent shut down before the task finished"
+
+// Drain prepares the agent for shutdown: it stops taking new tasks, reports
+// the host as draining, waits for running tasks until ctx is done, and syncs
+// the containers one last time. Tasks still running when ctx is done are
+// reported as interrupted, so the server queues them again; their results
+// are discarded. Call Drain before cancelling the context passed to Start
+// and before Close.
+func (a *Agent) Drain(ctx context.Context) error {
+	log.Printf("Draining agent for host %s", a.hostID)
+	a.draining.Store(true)
+
+	flushCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), drainFlushTimeout)
+	defer cancel()
+
+	if err := a.reportMetrics(flushCtx); err != nil {
+		log.Printf("Warning: Failed to report draining status: %v", err)
+	}
+
+	if e := a.tasks.Load(); e != nil {
+		for _, taskID := range e.drain(ctx) {
+			log.Printf("Task %s interrupted by shutdown", taskID)
+			if err := e.reportTaskStatus(taskID, "interrupted", interruptedTaskError, nil); err != nil {
+				log.Printf("Warning: Failed to report interrupted task %s: %v", taskID, err)
+			}
+		}
+	}
+
+	if err := a.syncContainers(flushCtx); err != nil {
+		log.Printf("Warning: Final sync failed: %v", err)
+		return err
+	}
+
+	log.Printf("✓ Agent drained")
+	return nil
+}
+
+// drain stops starting tasks and waits for the running ones until ctx is
+// done. It returns the IDs of the tasks still running then, except those
+// already reporting their result; executeTask discards the others' results.
+func (e *TaskExecutor) drain(ctx context.Context) []string {
+	e.mu.Lock()
+	e.draining = true
+	e.mu.Unlock()
+	e.Stop()
+
+	done := make(chan struct{})
+	go func() {
+		e.tasks.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.abandoned = true
+	interrupted := make([]string, 0, len(e.inFlight))
+	for taskID := range e.inFlight {
+		if !e.reporting[taskID] {
+			interrupted = append(interrupted, taskID)
+		}
+	}
+	return interrupted
+}
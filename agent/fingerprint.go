@@ -0,0 +1,64 @@
+package agent
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// syncFingerprints remembers the content hash each container had when it
+// was last synced successfully, so full syncs skip containers that didn't
+// change without asking the server. With a state file (SetStateFile) it
+// survives restarts; otherwise the first sync after a restart checks every
+// container.
+type syncFingerprints struct {
+	mu     sync.Mutex
+	hashes map[string]string
+	dirty  bool // Changed since the state file was last written
+
+	synced  atomic.Int64 // Containers sent to the server
+	skipped atomic.Int64 // Containers the server already had
+}
+
+func newSyncFingerprints() *syncFingerprints {
+	return &syncFingerprints{hashes: make(map[string]string)}
+}
+
+// unchanged reports whether a container was last synced with this hash.
+func (f *syncFingerprints) unchanged(containerID, hash string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.hashes[containerID] == hash
+}
+
+// record remembers the hash of a container the server now has.
+func (f *syncFingerprints) record(containerID, hash string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.hashes[containerID] != hash {
+		f.hashes[containerID] = hash
+		f.dirty = true
+	}
+}
+
+// forget drops a container's hash, so its next sync reaches the server.
+func (f *syncFingerprints) forget(containerID string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.hashes[containerID]; ok {
+		delete(f.hashes, containerID)
+		f.dirty = true
+	}
+}
+
+// retain drops the hashes of containers not in containerIDs, e.g. of
+// containers removed while the agent was down.
+func (f *syncFingerprints) retain(containerIDs map[string]bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for id := range f.hashes {
+		if !containerIDs[id] {
+			delete(f.hashes, id)
+			f.dirty = true
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package agent
+
+import (
+	"crypto/subtle"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// HTTPSecurity configures access control for the agent HTTP server.
+//
+// Modes and their security posture:
+//   - No auth (default): the server binds to localhost only, so just local
+//     processes (e.g. the Graphium server managing this agent) can reach it.
+//     Anyone with a shell on the host can still call every endpoint.
+//   - Bearer token (AuthToken): every route except publicPaths requires
+//     "Authorization: Bearer <token>". The token is sent in clear text unless
+//     TLS is enabled as well, so use it with TLS on untrusted networks.
+//   - TLS (TLSCertFile/TLSKeyFile): traffic is encrypted. TLS alone does not
+//     authenticate callers and keeps the localhost-only default.
+//   - Mutual TLS (TLSClientCAFile): every route except publicPaths requires a
+//     client certificate signed by the given CA. Can be combined with a token.
+//
+// When any auth is configured the server listens on all interfaces unless
+// BindAddress says otherwise.
+type HTTPSecurity struct {
+	// AuthToken is the shared bearer token required on all routes except
+	// publicPaths
+	AuthToken string
+
+	// TLSCertFile and TLSKeyFile enable HTTPS
+	TLSCertFile string
+	TLSKeyFile  string
+
+	// TLSClientCAFile enables mutual TLS using this CA bundle
+	TLSClientCAFile string
+
+	// BindAddress overrides the listen address (host part only)
+	BindAddress string
+}
+
+// authenticated reports whether callers must authenticate.
+func (s HTTPSecurity) authenticated() bool {
+	return s.AuthToken != "" || s.TLSClientCAFile != ""
+}
+
+// tlsEnabled reports whether the server serves HTTPS.
+func (s HTTPSecurity) tlsEnabled() bool {
+	return s.TLSCertFile != ""
+}
+
+// validate checks that the settings are consistent.
+func (s HTTPSecurity) validate() error {
+	if (s.TLSCertFile == "") != (s.TLSKeyFile == "") {
+		return fmt.Errorf("both http_tls_cert and http_tls_key must be set to enable TLS")
+	}
+	if s.TLSClientCAFile != "" && !s.tlsEnabled() {
+		return fmt.Errorf("http_tls_client_ca requires http_tls_cert and http_tls_key")
+	}
+	return nil
+}
+
+// listenAddr returns the address the HTTP server listens on.
+func (s HTTPSecurity) listenAddr(port int) string {
+	host := s.BindAddress
+	if host == "" && !s.authenticated() {
+		host = "127.0.0.1"
+	}
+	return fmt.Sprintf("%s:%d", host, port)
+}
+
+// scheme returns the URL scheme of the HTTP server.
+func (s HTTPSecurity) scheme() string {
+	if s.tlsEnabled() {
+		return "https"
+	}
+	return "http"
+}
+
+// tlsConfig builds the server TLS configuration, or nil when TLS is disabled.
+// Client certificates are verified when presented; whether one is required is
+// decided per route by the auth middleware so publicPaths stay open.
+func (s HTTPSecurity) tlsConfig() (*tls.Config, error) {
+	if !s.tlsEnabled() {
+		return nil, nil
+	}
+
+	config := &tls.Config{MinVersion: tls.VersionTLS12}
+	if s.TLSClientCAFile != "" {
+		pem, err := os.ReadFile(s.TLSClientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read client CA: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in client CA %s", s.TLSClientCAFile)
+		}
+		config.ClientCAs = pool
+		config.ClientAuth = tls.VerifyClientCertIfGiven
+	}
+	return config, nil
+}
+
+// publicPaths are the routes that never require auth, so orchestrators and
+// scrapers can reach them without credentials.
+var publicPaths = map[string]bool{
+	"/health":  true,
+	"/healthz": true,
+	"/metrics": true,
+}
+
+// middleware enforces the configured auth on every route except publicPaths.
+func (s HTTPSecurity) middleware(next http.Handler) http.Handler {
+	if !s.authenticated() {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if publicPaths[r.URL.Path] {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if s.TLSClientCAFile != "" && (r.TLS == nil || len(r.TLS.VerifiedChains) == 0) {
+			slog.Warn("auth rejected", "path", r.URL.Path, "remote_addr", r.RemoteAddr, "reason", "missing client certificate")
+			http.Error(w, "client certificate required", http.StatusUnauthorized)
+			return
+		}
+
+		if s.AuthToken != "" {
+			token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if subtle.ConstantTimeCompare([]byte(token), []byte(s.AuthToken)) != 1 {
+				slog.Warn("auth rejected", "path", r.URL.Path, "remote_addr", r.RemoteAddr, "reason", "invalid token")
+				w.Header().Set("WWW-Authenticate", `Bearer realm="graphium-agent"`)
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// SetHTTPSecurity configures authentication for the agent HTTP server.
+// It must be called before Start.
+func (a *Agent) SetHTTPSecurity(security HTTPSecurity) error {
+	if err := security.validate(); err != nil {
+		return err
+	}
+	a.httpSecurity = security
+	return nil
+}
@@ -0,0 +1,115 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// SetMetricsAddress serves /metrics and /healthz on a separate listener at
+// addr (host:port), e.g. 127.0.0.1:9101 to keep them local. Empty serves
+// them on the agent HTTP server. It must be called before Start.
+func (a *Agent) SetMetricsAddress(addr string) {
+	a.metricsAddress = addr
+}
+
+// startMetricsServer serves /metrics and /healthz on metricsAddress without
+// auth until ctx is done.
+func (a *Agent) startMetricsServer(ctx context.Context) error {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /metrics", a.handleMetrics)
+	mux.HandleFunc("GET /healthz", a.handleHealthz)
+
+	server := &http.Server{
+		Addr:         a.metricsAddress,
+		Handler:      mux,
+		ReadTimeout:  10 * time.Second,
+		WriteTimeout: 10 * time.Second,
+	}
+
+	log.Printf("Starting agent metrics server on http://%s", server.Addr)
+	go func() {
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Printf("Metrics server error: %v", err)
+		}
+	}()
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			log.Printf("Metrics server shutdown error: %v", err)
+		}
+	}()
+
+	return nil
+}
+
+// handleMetrics serves the agent's counters in the Prometheus text format.
+func (a *Agent) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	c := a.counters()
+	labels := fmt.Sprintf(`{host_id=%q}`, a.hostID)
+
+	var b strings.Builder
+	metric := func(name, kind, help string, value float64) {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s %s\n%s%s %g\n", name, help, name, kind, name, labels, value)
+	}
+	metric("graphium_agent_syncs_total", "counter", "Full container syncs that succeeded.", float64(c.SyncCount))
+	metric("graphium_agent_sync_failures_total", "counter", "Full container syncs that failed.", float64(c.FailedSyncs))
+	metric("graphium_agent_events_total", "counter", "Docker container events received.", float64(c.EventsCount))
+	metric("graphium_agent_containers_synced_total", "counter", "Containers sent to the server.", float64(a.fingerprints.synced.Load()))
+	metric("graphium_agent_containers_skipped_total", "counter", "Containers skipped because they didn't change.", float64(a.fingerprints.skipped.Load()))
+	metric("graphium_agent_containers", "gauge", "Containers tracked by the last successful sync.", float64(c.Containers))
+	metric("graphium_agent_last_sync_duration_seconds", "gauge", "Duration of the last successful sync.", c.LastSyncDuration.Seconds())
+	lastSync := 0.0
+	if !c.LastSync.IsZero() {
+		lastSync = float64(c.LastSync.Unix())
+	}
+	metric("graphium_agent_last_sync_timestamp_seconds", "gauge", "Unix time of the last successful sync (0 = none yet).", lastSync)
+	metric("graphium_agent_uptime_seconds", "gauge", "Seconds since the agent started.", time.Since(a.startTime).Seconds())
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_, _ = w.Write([]byte(b.String()))
+}
+
+// handleHealthz reports whether the agent is keeping the server up to date:
+// 200 while the last full sync succeeded within twice the sync interval,
+// 503 otherwise. Without periodic syncs the last sync only has to have
+// succeeded.
+func (a *Agent) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	c := a.counters()
+
+	status, code := "ok", http.StatusOK
+	reason := ""
+	switch {
+	case c.LastSyncFailed:
+		reason = "last sync failed"
+	case c.LastSync.IsZero():
+		reason = "no successful sync yet"
+	case a.syncInterval > 0 && time.Since(c.LastSync) > 2*a.syncInterval:
+		reason = fmt.Sprintf("last successful sync %s ago (sync interval %s)",
+			time.Since(c.LastSync).Round(time.Second), a.syncInterval)
+	}
+	if reason != "" {
+		status, code = "unhealthy", http.StatusServiceUnavailable
+	}
+
+	response := map[string]interface{}{
+		"status":   status,
+		"lastSync": c.LastSync,
+	}
+	if reason != "" {
+		response["reason"] = reason
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.Printf("Failed to encode healthz response: %v", err)
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/pkg/stdcopy"
 	"github.com/gorilla/mux"
 )
 
@@ -30,6 +31,9 @@ func (a *Agent) startHTTPServer(ctx context.Context, port int) error {
 	// Health check endpoint
 	router.HandleFunc("/health", a.handleHealth).Methods("GET")
 
+	// Status endpoint (health plus Docker reachability, used by the server's agent health check)
+	router.HandleFunc("/status", a.handleStatus).Methods("GET")
+
 	// Container logs endpoint
 	router.HandleFunc("/containers/{id}/logs", a.handleContainerLogs).Methods("GET")
 
@@ -39,18 +43,39 @@ func (a *Agent) startHTTPServer(ctx context.Context, port int) error {
 	// List containers endpoint
 	router.HandleFunc("/containers", a.handleListContainers).Methods("GET")
 
+	// Prometheus metrics and self health check, unless they have their own listener
+	if a.metricsAddress == "" {
+		router.HandleFunc("/metrics", a.handleMetrics).Methods("GET")
+		router.HandleFunc("/healthz", a.handleHealthz).Methods("GET")
+	}
+
+	tlsConfig, err := a.httpSecurity.tlsConfig()
+	if err != nil {
+		return err
+	}
+
 	server := &http.Server{
-		Addr:         fmt.Sprintf(":%d", port),
-		Handler:      router,
+		Addr:         a.httpSecurity.listenAddr(port),
+		Handler:      a.httpSecurity.middleware(router),
+		TLSConfig:    tlsConfig,
 		ReadTimeout:  30 * time.Second,
 		WriteTimeout: 30 * time.Second,
 	}
 
-	log.Printf("Starting agent HTTP server on port %d", port)
+	log.Printf("Starting agent HTTP server on %s://%s", a.httpSecurity.scheme(), server.Addr)
+	if !a.httpSecurity.authenticated() {
+		log.Printf("Agent HTTP server has no authentication configured; listening on localhost only")
+	}
 
 	// Start server in background
 	go func() {
-		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		var err error
+		if a.httpSecurity.tlsEnabled() {
+			err = server.ListenAndServeTLS(a.httpSecurity.TLSCertFile, a.httpSecurity.TLSKeyFile)
+		} else {
+			err = server.ListenAndServe()
+		}
+		if err != nil && err != http.ErrServerClosed {
 			log.Printf("HTTP server error: %v", err)
 		}
 	}()
@@ -71,17 +96,20 @@ func (a *Agent) startHTTPServer(ctx context.Context, port int) error {
 // handleHealth returns agent health status
 func (a *Agent) handleHealth(w http.ResponseWriter, r *http.Request) {
 	uptime := time.Since(a.startTime)
+	counters := a.counters()
 
 	response := map[string]interface{}{
-		"status":           "healthy",
-		"hostId":           a.hostID,
-		"datacenter":       a.datacenter,
-		"uptime":           uptime.Seconds(),
-		"syncCount":        a.syncCount,
-		"failedSyncs":      a.failedSyncs,
-		"eventsCount":      a.eventsCount,
-		"lastSync":         a.lastSyncTime,
-		"lastSyncDuration": a.lastSyncDuration.Milliseconds(),
+		"status":            "healthy",
+		"hostId":            a.hostID,
+		"datacenter":        a.datacenter,
+		"uptime":            uptime.Seconds(),
+		"syncCount":         counters.SyncCount,
+		"failedSyncs":       counters.FailedSyncs,
+		"eventsCount":       counters.EventsCount,
+		"lastSync":          counters.LastSync,
+		"lastSyncDuration":  counters.LastSyncDuration.Milliseconds(),
+		"syncedContainers":  a.fingerprints.synced.Load(),
+		"skippedContainers": a.fingerprints.skipped.Load(),
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -90,6 +118,49 @@ func (a *Agent) handleHealth(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// handleStatus returns the agent's self-reported status and counters,
+// including whether the Docker daemon is reachable.
+func (a *Agent) handleStatus(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	status := "healthy"
+	dockerReachable := true
+	dockerError := ""
+	if _, err := a.docker.Ping(ctx); err != nil {
+		status = "degraded"
+		dockerReachable = false
+		dockerError = err.Error()
+	}
+	counters := a.counters()
+
+	response := map[string]interface{}{
+		"status":            status,
+		"hostId":            a.hostID,
+		"datacenter":        a.datacenter,
+		"uptime":            time.Since(a.startTime).Seconds(),
+		"dockerReachable":   dockerReachable,
+		"syncCount":         counters.SyncCount,
+		"failedSyncs":       counters.FailedSyncs,
+		"eventsCount":       counters.EventsCount,
+		"lastSync":          counters.LastSync,
+		"lastSyncDuration":  counters.LastSyncDuration.Milliseconds(),
+		"syncedContainers":  a.fingerprints.synced.Load(),
+		"skippedContainers": a.fingerprints.skipped.Load(),
+	}
+	if dockerError != "" {
+		response["dockerError"] = dockerError
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if !dockerReachable {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.Printf("Failed to encode status response: %v", err)
+	}
+}
+
 // handleContainerLogs streams container logs
 func (a *Agent) handleContainerLogs(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
@@ -147,35 +218,48 @@ func (a *Agent) handleContainerLogs(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("X-Accel-Buffering", "no") // Disable nginx buffering
 	}
 
-	// Stream logs to response
-	flusher, ok := w.(http.Flusher)
-	if !ok && follow {
-		http.Error(w, "Streaming not supported", http.StatusInternalServerError)
-		return
-	}
-
-	buf := make([]byte, 8192)
-	for {
-		n, err := logs.Read(buf)
-		if n > 0 {
-			// Docker logs include 8-byte headers, strip them
-			if n > 8 {
-				if _, writeErr := w.Write(buf[8:n]); writeErr != nil {
-					return
-				}
-				if follow && flusher != nil {
-					flusher.Flush()
-				}
-			}
-		}
-		if err == io.EOF {
-			break
-		}
-		if err != nil {
-			log.Printf("Error reading logs: %v", err)
+	// Follow streams last until the caller goes away, not the server's
+	// write timeout
+	rc := http.NewResponseController(w)
+	if follow {
+		if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+			http.Error(w, "Streaming not supported", http.StatusInternalServerError)
 			return
 		}
 	}
+
+	// TTY containers have a single raw stream; others are multiplexed and
+	// demultiplexed here so frames split across reads stay intact
+	tty := false
+	if info, err := a.docker.ContainerInspect(ctx, containerID); err == nil && info.Config != nil {
+		tty = info.Config.Tty
+	}
+
+	out := &flushWriter{w: w, rc: rc, flush: follow}
+	if tty {
+		_, err = io.Copy(out, logs)
+	} else {
+		_, err = stdcopy.StdCopy(out, out, logs)
+	}
+	if err != nil && ctx.Err() == nil {
+		log.Printf("Error reading logs: %v", err)
+	}
+}
+
+// flushWriter writes to an HTTP response, flushing after every write when
+// streaming.
+type flushWriter struct {
+	w     io.Writer
+	rc    *http.ResponseController
+	flush bool
+}
+
+func (f *flushWriter) Write(p []byte) (int, error) {
+	n, err := f.w.Write(p)
+	if err == nil && f.flush {
+		err = f.rc.Flush()
+	}
+	return n, err
 }
 
 // handleContainerInspect returns detailed container information
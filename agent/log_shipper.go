@@ -0,0 +1,163 @@
+package agent
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"evalgo.org/graphium/models"
+)
+
+// logShipInterval is how often the agent ships buffered log lines.
+const logShipInterval = 30 * time.Second
+
+// logBuffer keeps the agent's most recent log lines, redacted, until they
+// are shipped to the server. It is an io.Writer for the standard logger.
+type logBuffer struct {
+	mu      sync.Mutex
+	lines   []models.AgentLogLine
+	partial string
+	seq     int64
+	shipped int64
+}
+
+// Write splits p into lines and buffers the complete ones, dropping the
+// oldest beyond models.AgentLogsLimit.
+func (b *logBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	text := b.partial + string(p)
+	parts := strings.Split(text, "\n")
+	b.partial = parts[len(parts)-1]
+
+	now := time.Now()
+	for _, part := range parts[:len(parts)-1] {
+		if strings.TrimSpace(part) == "" {
+			continue
+		}
+		message := models.RedactSecrets(part)
+		if len(message) > models.MaxAgentLogLine {
+			message = message[:models.MaxAgentLogLine]
+		}
+		b.seq++
+		b.lines = append(b.lines, models.AgentLogLine{Seq: b.seq, Time: now, Message: message})
+	}
+	if len(b.lines) > models.AgentLogsLimit {
+		b.lines = append([]models.AgentLogLine(nil), b.lines[len(b.lines)-models.AgentLogsLimit:]...)
+	}
+	return len(p), nil
+}
+
+// pending returns the buffered lines not shipped yet.
+func (b *logBuffer) pending() []models.AgentLogLine {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var lines []models.AgentLogLine
+	for _, line := range b.lines {
+		if line.Seq > b.shipped {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// markShipped records that all lines up to seq reached the server.
+func (b *logBuffer) markShipped(seq int64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if seq > b.shipped {
+		b.shipped = seq
+	}
+}
+
+// EnableLogShipping captures the agent's log output so Start ships it to
+// the server periodically. Output still goes to the previous log writer.
+func (a *Agent) EnableLogShipping() {
+	if a.logs != nil {
+		return
+	}
+	a.logs = &logBuffer{}
+	if jsonLogOutput != nil {
+		// The standard logger feeds the JSON handler; capture its output
+		jsonLogOutput = io.MultiWriter(jsonLogOutput, a.logs)
+		slog.SetDefault(slog.New(slog.NewJSONHandler(jsonLogOutput, nil)))
+		return
+	}
+	log.SetOutput(io.MultiWriter(log.Writer(), a.logs))
+}
+
+// shipLogs sends the buffered log lines not shipped yet to the API server
+// and returns how many were sent.
+func (a *Agent) shipLogs(ctx context.Context) (int, error) {
+	if a.logs == nil {
+		return 0, fmt.Errorf("log shipping is not enabled on this agent")
+	}
+
+	lines := a.logs.pending()
+	if len(lines) == 0 {
+		return 0, nil
+	}
+
+	data, err := json.Marshal(map[string]interface{}{"lines": lines})
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal log lines: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/api/v1/agents/%s/logs", a.apiURL, a.hostID)
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(data))
+	if err != nil {
+		return 0, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if a.authToken != "" {
+		req.Header.Set("Authorization", "Bearer "+a.authToken)
+	}
+
+	resp, err := a.doRequest(req)
+	if err != nil {
+		return 0, fmt.Errorf("failed to send log lines: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return 0, fmt.Errorf("log shipping failed: %s - %s", resp.Status, string(body))
+	}
+
+	a.logs.markShipped(lines[len(lines)-1].Seq)
+	return len(lines), nil
+}
+
+// periodicLogShipping ships buffered log lines periodically, and once more
+// when the agent stops.
+func (a *Agent) periodicLogShipping(ctx context.Context) {
+	ticker := time.NewTicker(logShipInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			flushCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			if _, err := a.shipLogs(flushCtx); err != nil {
+				log.Printf("Final log shipping error: %v", err)
+			}
+			cancel()
+			return
+		case <-ticker.C:
+			if _, err := a.shipLogs(ctx); err != nil {
+				log.Printf("Log shipping error: %v", err)
+			}
+		}
+	}
+}
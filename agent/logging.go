@@ -0,0 +1,100 @@
+package agent
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"time"
+)
+
+// Log formats of the agent's output (agent.log_format).
+const (
+	LogFormatText = "text"
+	LogFormatJSON = "json"
+)
+
+// jsonLogOutput is where JSON log records are written; nil in text mode.
+var jsonLogOutput io.Writer
+
+// SetLogFormat sets the format of the agent's log output. Text, the
+// default, keeps the standard logger's lines and writes structured records
+// as "INFO msg key=value". JSON writes every line as one JSON object with
+// time, level and msg fields, including plain log.Printf output, so a log
+// aggregator can parse it. Structured records carry stable snake_case
+// fields (e.g. duration_ms, task_id).
+func SetLogFormat(format string) error {
+	switch format {
+	case "", LogFormatText:
+		return nil
+	case LogFormatJSON:
+		jsonLogOutput = log.Writer()
+		slog.SetDefault(slog.New(slog.NewJSONHandler(jsonLogOutput, nil)))
+		return nil
+	default:
+		return fmt.Errorf("unknown log format %q (expected text or json)", format)
+	}
+}
+
+// agentCounters is a snapshot of the agent's sync and event counters.
+type agentCounters struct {
+	SyncCount        int64
+	FailedSyncs      int64
+	EventsCount      int64
+	LastSync         time.Time // Last successful full sync
+	LastSyncDuration time.Duration
+	LastSyncFailed   bool // Whether the latest full sync failed
+	Containers       int  // Containers the last successful sync tracked
+}
+
+// counters returns a snapshot of the agent's counters.
+func (a *Agent) counters() agentCounters {
+	a.countersMu.Lock()
+	defer a.countersMu.Unlock()
+	return agentCounters{
+		SyncCount:        a.syncCount,
+		FailedSyncs:      a.failedSyncs,
+		EventsCount:      a.eventsCount,
+		LastSync:         a.lastSyncTime,
+		LastSyncDuration: a.lastSyncDuration,
+		LastSyncFailed:   a.lastSyncFailed,
+		Containers:       a.containerCount,
+	}
+}
+
+// recordSync counts a full sync of containers that took duration.
+func (a *Agent) recordSync(duration time.Duration, containers int, err error) {
+	a.countersMu.Lock()
+	defer a.countersMu.Unlock()
+	a.lastSyncFailed = err != nil
+	if err != nil {
+		a.failedSyncs++
+		return
+	}
+	a.syncCount++
+	a.lastSyncTime = time.Now()
+	a.lastSyncDuration = duration
+	a.containerCount = containers
+}
+
+// recordEvent counts a Docker event.
+func (a *Agent) recordEvent() {
+	a.countersMu.Lock()
+	defer a.countersMu.Unlock()
+	a.eventsCount++
+}
+
+// logSummary logs the agent's counters.
+func (a *Agent) logSummary() {
+	c := a.counters()
+	slog.Info("agent summary",
+		"host_id", a.hostID,
+		"sync_count", c.SyncCount,
+		"failed_syncs", c.FailedSyncs,
+		"events_count", c.EventsCount,
+		"last_sync", c.LastSync,
+		"last_sync_duration_ms", c.LastSyncDuration.Milliseconds(),
+		"synced_containers", a.fingerprints.synced.Load(),
+		"skipped_containers", a.fingerprints.skipped.Load(),
+	)
+}
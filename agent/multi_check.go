@@ -0,0 +1,137 @@
+package agent
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+
+	"evalgo.org/graphium/models"
+)
+
+// maxParallelChecks limits how many targets of a multi-target check are
+// checked at once.
+const maxParallelChecks = 10
+
+// executeMultiCheck checks every target of a multi-target health check and
+// reports one result per target. The check succeeds when at least the
+// quorum of targets pass.
+func (e *TaskExecutor) executeMultiCheck(ctx context.Context, payload *models.CheckHealthPayload) (*models.TaskResult, error) {
+	if err := payload.ValidateTargets(); err != nil {
+		return nil, fmt.Errorf("invalid check targets: %w", err)
+	}
+
+	results := make([]models.CheckTargetResult, len(payload.Targets))
+	sem := make(chan struct{}, maxParallelChecks)
+	var wg sync.WaitGroup
+	for i, target := range payload.Targets {
+		wg.Add(1)
+		go func(i int, target models.CheckTarget) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			results[i] = e.checkTarget(ctx, payload, target)
+		}(i, target)
+	}
+	wg.Wait()
+
+	passed := 0
+	for _, result := range results {
+		if result.Success {
+			passed++
+		}
+	}
+	required := payload.RequiredPasses()
+	success := passed >= required
+
+	return &models.TaskResult{
+		Success: success,
+		Message: fmt.Sprintf("Health check %s: %d of %d targets passed (%d required)",
+			map[bool]string{true: "passed", false: "failed"}[success], passed, len(results), required),
+		Data: map[string]interface{}{
+			"targets":  results,
+			"passed":   passed,
+			"failed":   len(results) - passed,
+			"required": required,
+		},
+	}, nil
+}
+
+// checkTarget checks one target, using the payload's settings as defaults.
+func (e *TaskExecutor) checkTarget(ctx context.Context, payload *models.CheckHealthPayload, target models.CheckTarget) models.CheckTargetResult {
+	start := time.Now()
+	var result models.CheckTargetResult
+	if target.URL != "" {
+		result = checkHTTPTarget(ctx, payload, target)
+	} else {
+		result = e.checkContainerTarget(ctx, target.ContainerID)
+	}
+	result.Target = target.Name()
+	result.DurationMs = time.Since(start).Milliseconds()
+	return result
+}
+
+// checkHTTPTarget requests a URL target and compares the status code.
+func checkHTTPTarget(ctx context.Context, payload *models.CheckHealthPayload, target models.CheckTarget) models.CheckTargetResult {
+	method := target.Method
+	if method == "" {
+		method = payload.Method
+	}
+	expected := target.ExpectedStatusCode
+	if expected == 0 {
+		expected = payload.ExpectedStatusCode
+	}
+
+	var body io.Reader
+	if payload.Body != "" {
+		body = bytes.NewBufferString(payload.Body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target.URL, body)
+	if err != nil {
+		return models.CheckTargetResult{Error: fmt.Sprintf("failed to create request: %v", err)}
+	}
+	for key, value := range payload.Headers {
+		req.Header.Set(key, value)
+	}
+
+	client := &http.Client{Timeout: time.Duration(payload.Timeout) * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return models.CheckTargetResult{Error: err.Error()}
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1024))
+
+	return models.CheckTargetResult{
+		Success:    resp.StatusCode == expected,
+		StatusCode: resp.StatusCode,
+	}
+}
+
+// checkContainerTarget passes when the container is running and not unhealthy.
+func (e *TaskExecutor) checkContainerTarget(ctx context.Context, containerID string) models.CheckTargetResult {
+	inspect, err := e.agent.docker.ContainerInspect(ctx, containerID)
+	if err != nil {
+		return models.CheckTargetResult{Error: err.Error()}
+	}
+	if inspect.State == nil {
+		return models.CheckTargetResult{Error: "container state unavailable"}
+	}
+
+	status := inspect.State.Status
+	healthy := true
+	if inspect.State.Health != nil && inspect.State.Health.Status != container.NoHealthcheck {
+		status += "/" + inspect.State.Health.Status
+		healthy = inspect.State.Health.Status != container.Unhealthy
+	}
+
+	return models.CheckTargetResult{
+		Success: inspect.State.Running && healthy,
+		Status:  status,
+	}
+}
@@ -0,0 +1,82 @@
+//go:build linux
+
+package agent
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"runtime"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// checkNetNamespace verifies that the agent can enter the network namespace
+// of a process, which needs access to the host's /proc and CAP_SYS_ADMIN.
+func checkNetNamespace(pid int) error {
+	_, err := inNetNamespace(pid, func() (net.Conn, error) { return nil, nil })
+	return err
+}
+
+// dialInNetNamespace opens a TCP connection from inside the network
+// namespace of a process. The socket stays in that namespace after the
+// calling thread switches back.
+func dialInNetNamespace(ctx context.Context, pid int, address string, timeout time.Duration) (net.Conn, error) {
+	return inNetNamespace(pid, func() (net.Conn, error) {
+		dialer := &net.Dialer{Timeout: timeout}
+		return dialer.DialContext(ctx, "tcp", address)
+	})
+}
+
+// inNetNamespace runs fn on a dedicated OS thread switched into the network
+// namespace of pid. If the thread cannot switch back it stays locked, so the
+// runtime discards it when the goroutine exits.
+func inNetNamespace(pid int, fn func() (net.Conn, error)) (net.Conn, error) {
+	if pid <= 0 {
+		return nil, fmt.Errorf("container has no process")
+	}
+
+	type result struct {
+		conn net.Conn
+		err  error
+	}
+	done := make(chan result, 1)
+
+	go func() {
+		runtime.LockOSThread()
+
+		origin, err := os.Open("/proc/thread-self/ns/net")
+		if err != nil {
+			runtime.UnlockOSThread()
+			done <- result{err: fmt.Errorf("failed to open own network namespace: %w", err)}
+			return
+		}
+		defer origin.Close()
+
+		target, err := os.Open(fmt.Sprintf("/proc/%d/ns/net", pid))
+		if err != nil {
+			runtime.UnlockOSThread()
+			done <- result{err: fmt.Errorf("failed to open network namespace of pid %d: %w", pid, err)}
+			return
+		}
+		defer target.Close()
+
+		if err := unix.Setns(int(target.Fd()), unix.CLONE_NEWNET); err != nil {
+			runtime.UnlockOSThread()
+			done <- result{err: fmt.Errorf("failed to enter network namespace: %w", err)}
+			return
+		}
+
+		conn, err := fn()
+
+		if restoreErr := unix.Setns(int(origin.Fd()), unix.CLONE_NEWNET); restoreErr == nil {
+			runtime.UnlockOSThread()
+		}
+		done <- result{conn: conn, err: err}
+	}()
+
+	r := <-done
+	return r.conn, r.err
+}
@@ -0,0 +1,20 @@
+//go:build !linux
+
+package agent
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"time"
+)
+
+// checkNetNamespace reports that network namespaces are Linux-only.
+func checkNetNamespace(pid int) error {
+	return fmt.Errorf("network namespaces are only supported on Linux")
+}
+
+// dialInNetNamespace is not supported outside Linux.
+func dialInNetNamespace(ctx context.Context, pid int, address string, timeout time.Duration) (net.Conn, error) {
+	return nil, checkNetNamespace(pid)
+}
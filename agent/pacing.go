@@ -0,0 +1,201 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// requestsPerSync is the number of API requests a container sync makes:
+	// the ignore-list check, the existence check, and the create or update
+	requestsPerSync = 3
+
+	// defaultSyncDelay is the delay between container syncs when the
+	// server's rate limit is unknown (servers without GET /api/v1/limits)
+	defaultSyncDelay = 100 * time.Millisecond
+
+	// minBackoffDelay is the smallest delay after the server rejected a request
+	minBackoffDelay = 50 * time.Millisecond
+
+	// maxSyncDelay caps the delay between container syncs while backing off
+	maxSyncDelay = 5 * time.Second
+
+	// maxRateLimitRetries is how often a request rejected with 429 is retried
+	maxRateLimitRetries = 3
+
+	// maxRetryAfter caps the wait requested by a Retry-After header
+	maxRetryAfter = time.Minute
+)
+
+// syncPacer spaces out container syncs. The base delay is derived from the
+// server's rate limit; it doubles each time the server answers 429 and
+// recovers towards the base after bulk syncs that were not throttled.
+type syncPacer struct {
+	mu        sync.Mutex
+	base      time.Duration
+	delay     time.Duration
+	throttled bool
+}
+
+func newSyncPacer() *syncPacer {
+	return &syncPacer{base: defaultSyncDelay, delay: defaultSyncDelay}
+}
+
+// syncDelayForLimit returns the delay between container syncs that uses at
+// most half of a rate limit of rateLimit requests per second, leaving room
+// for the agent's other requests. A rate limit of 0 means unlimited.
+func syncDelayForLimit(rateLimit int) time.Duration {
+	if rateLimit <= 0 {
+		return 0
+	}
+	return requestsPerSync * 2 * time.Second / time.Duration(rateLimit)
+}
+
+// setBase sets the delay negotiated with the server.
+func (p *syncPacer) setBase(delay time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.base = delay
+	p.delay = delay
+}
+
+// current returns the delay to wait between container syncs.
+func (p *syncPacer) current() time.Duration {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.delay
+}
+
+// backoff slows down after the server rejected a request.
+func (p *syncPacer) backoff() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.delay = min(max(p.delay*2, minBackoffDelay), maxSyncDelay)
+	p.throttled = true
+}
+
+// relax halves the delay, down to the base, if no request was rejected
+// since the last call.
+func (p *syncPacer) relax() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.throttled {
+		p.delay = max(p.delay/2, p.base)
+	}
+	p.throttled = false
+}
+
+// wait sleeps for the current delay or until ctx is done.
+func (p *syncPacer) wait(ctx context.Context) error {
+	delay := p.current()
+	if delay <= 0 {
+		return ctx.Err()
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(delay):
+		return nil
+	}
+}
+
+// retryAfter parses a Retry-After header, given in seconds or as an HTTP
+// date. It defaults to one second.
+func retryAfter(header string) time.Duration {
+	wait := time.Second
+	if seconds, err := strconv.Atoi(header); err == nil {
+		wait = time.Duration(seconds) * time.Second
+	} else if date, err := http.ParseTime(header); err == nil {
+		wait = time.Until(date)
+	}
+	return min(max(wait, 0), maxRetryAfter)
+}
+
+// doRequest sends a request to the API server. Requests rejected with 429
+// are retried after the server's Retry-After delay, up to
+// maxRateLimitRetries times, and slow down the agent's sync pace.
+func (a *Agent) doRequest(req *http.Request) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		resp, err := a.httpClient.Do(req)
+		if err == nil && (resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden) {
+			slog.Warn("auth failure",
+				"host_id", a.hostID,
+				"method", req.Method,
+				"path", req.URL.Path,
+				"status", resp.StatusCode,
+			)
+		}
+		if err != nil || resp.StatusCode != http.StatusTooManyRequests {
+			return resp, err
+		}
+
+		a.pacer.backoff()
+		rewindable := req.Body == nil || req.GetBody != nil
+		if attempt == maxRateLimitRetries || !rewindable {
+			return resp, nil
+		}
+		wait := retryAfter(resp.Header.Get("Retry-After"))
+		_ = resp.Body.Close()
+
+		log.Printf("Rate limited by API server, retrying %s %s in %v", req.Method, req.URL.Path, wait)
+		select {
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		case <-time.After(wait):
+		}
+
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req.Body = body
+		}
+	}
+}
+
+// negotiateRateLimit reads the server's rate limit from GET /api/v1/limits
+// and paces container syncs accordingly. Servers without the endpoint keep
+// the default pace.
+func (a *Agent) negotiateRateLimit(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", a.apiURL+"/api/v1/limits", nil)
+	if err != nil {
+		return fmt.Errorf("failed to create limits request: %w", err)
+	}
+	if a.authToken != "" {
+		req.Header.Set("Authorization", "Bearer "+a.authToken)
+	}
+
+	resp, err := a.doRequest(req)
+	if err != nil {
+		return fmt.Errorf("failed to get limits: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("API returned HTTP %d, keeping a %v sync delay", resp.StatusCode, defaultSyncDelay)
+	}
+
+	var limits struct {
+		RateLimit int `json:"rateLimit"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&limits); err != nil {
+		return fmt.Errorf("failed to decode limits: %w", err)
+	}
+
+	delay := syncDelayForLimit(limits.RateLimit)
+	a.pacer.setBase(delay)
+	if limits.RateLimit > 0 {
+		log.Printf("✓ Server rate limit is %d req/s, syncing containers every %v", limits.RateLimit, delay)
+	} else {
+		log.Printf("✓ Server has no rate limit, syncing containers without delay")
+	}
+	return nil
+}
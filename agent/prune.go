@@ -0,0 +1,97 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/docker/docker/api/types/build"
+	"github.com/docker/docker/api/types/filters"
+
+	"evalgo.org/graphium/models"
+)
+
+// executePrune removes unused Docker resources selected by the payload.
+// Docker's prune APIs never remove resources used by a container, and
+// resources labelled models.ManagedLabel are excluded as well.
+func (e *TaskExecutor) executePrune(ctx context.Context, task *models.AgentTask) (*models.TaskResult, error) {
+	var payload models.PrunePayload
+	if err := task.GetPayloadAs(&payload); err != nil {
+		return nil, fmt.Errorf("invalid prune payload: %w", err)
+	}
+
+	if payload.Mode == "" {
+		payload.Mode = models.PruneModeDangling
+	}
+	if payload.Mode != models.PruneModeDangling && payload.Mode != models.PruneModeAll {
+		return nil, fmt.Errorf("invalid prune mode: %s", payload.Mode)
+	}
+	all := payload.Mode == models.PruneModeAll
+
+	// Skip anything Graphium created itself
+	unmanaged := func() filters.Args {
+		return filters.NewArgs(filters.Arg("label!", models.ManagedLabel))
+	}
+
+	var reclaimed uint64
+	data := map[string]interface{}{
+		"mode": payload.Mode,
+	}
+
+	if payload.Images {
+		args := unmanaged()
+		args.Add("dangling", fmt.Sprintf("%t", !all))
+		report, err := e.agent.docker.ImagesPrune(ctx, args)
+		if err != nil {
+			return nil, fmt.Errorf("failed to prune images: %w", err)
+		}
+		deleted := 0
+		for _, item := range report.ImagesDeleted {
+			if item.Deleted != "" {
+				deleted++
+			}
+		}
+		reclaimed += report.SpaceReclaimed
+		data["images_deleted"] = deleted
+	}
+
+	if payload.Volumes {
+		args := unmanaged()
+		if all {
+			// Without all=true only anonymous volumes are pruned
+			args.Add("all", "true")
+		}
+		report, err := e.agent.docker.VolumesPrune(ctx, args)
+		if err != nil {
+			return nil, fmt.Errorf("failed to prune volumes: %w", err)
+		}
+		reclaimed += report.SpaceReclaimed
+		data["volumes_deleted"] = len(report.VolumesDeleted)
+	}
+
+	if payload.Networks {
+		report, err := e.agent.docker.NetworksPrune(ctx, unmanaged())
+		if err != nil {
+			return nil, fmt.Errorf("failed to prune networks: %w", err)
+		}
+		data["networks_deleted"] = len(report.NetworksDeleted)
+	}
+
+	if payload.BuildCache {
+		report, err := e.agent.docker.BuildCachePrune(ctx, build.CachePruneOptions{All: all})
+		if err != nil {
+			return nil, fmt.Errorf("failed to prune build cache: %w", err)
+		}
+		reclaimed += report.SpaceReclaimed
+		data["build_cache_deleted"] = len(report.CachesDeleted)
+	}
+
+	data["reclaimed_bytes"] = reclaimed
+	log.Printf("Prune (%s) reclaimed %d bytes", payload.Mode, reclaimed)
+
+	return &models.TaskResult{
+		Success: true,
+		Message: fmt.Sprintf("Pruned unused resources, reclaimed %d bytes", reclaimed),
+		Data:    data,
+	}, nil
+}
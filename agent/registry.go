@@ -37,7 +37,7 @@ func (a *Agent) registerWithRegistry(ctx context.Context) error {
 			hostname = a.hostID
 		}
 		if a.httpPort > 0 {
-			agentURL = fmt.Sprintf("http://%s:%d", hostname, a.httpPort)
+			agentURL = fmt.Sprintf("%s://%s:%d", a.httpSecurity.scheme(), hostname, a.httpPort)
 		} else {
 			log.Printf("Warning: Agent HTTP port not configured, skipping registry registration")
 			return nil
@@ -131,14 +131,15 @@ func (a *Agent) sendRegistryHeartbeat(ctx context.Context) error {
 	serviceID := fmt.Sprintf("graphium-agent-%s", a.hostID)
 	heartbeatURL := fmt.Sprintf("%s/v1/api/services/%s/heartbeat", registryURL, serviceID)
 
+	counters := a.counters()
 	heartbeat := map[string]interface{}{
 		"timestamp": time.Now().Format(time.RFC3339),
 		"status":    "healthy",
 		"metrics": map[string]interface{}{
 			"uptime":       time.Since(a.startTime).Seconds(),
-			"syncCount":    a.syncCount,
-			"failedSyncs":  a.failedSyncs,
-			"eventsCount":  a.eventsCount,
+			"syncCount":    counters.SyncCount,
+			"failedSyncs":  counters.FailedSyncs,
+			"eventsCount":  counters.EventsCount,
 		},
 	}
 
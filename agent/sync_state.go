@@ -0,0 +1,158 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// DefaultStateFile is where the agent keeps its sync fingerprints unless
+// configured otherwise (agent.state_file).
+const DefaultStateFile = "/var/lib/graphium/agent-state.json"
+
+// syncStateVersion is the schema version of the state file. Files of other
+// versions are ignored.
+const syncStateVersion = 1
+
+// stateSaveInterval is how often the agent writes changed fingerprints to
+// its state file.
+const stateSaveInterval = time.Minute
+
+// syncState is the content of the state file.
+type syncState struct {
+	Version int               `json:"version"`
+	HostID  string            `json:"hostId"`
+	SavedAt time.Time         `json:"savedAt"`
+	Hashes  map[string]string `json:"hashes"`
+}
+
+// SetStateFile sets the file the agent keeps its sync fingerprints in and
+// loads them, so the first sync after a restart only sends containers that
+// changed meanwhile. An empty path keeps them in memory only. It must be
+// called before Start.
+func (a *Agent) SetStateFile(path string) {
+	a.stateFile = path
+	if path == "" {
+		return
+	}
+
+	hashes, err := loadSyncState(path, a.hostID)
+	if err != nil {
+		log.Printf("Warning: Ignoring sync state %s, syncing all containers: %v", path, err)
+		return
+	}
+	if hashes != nil {
+		a.fingerprints.mu.Lock()
+		a.fingerprints.hashes = hashes
+		a.fingerprints.mu.Unlock()
+		log.Printf("✓ Loaded sync state of %d containers from %s", len(hashes), path)
+	}
+}
+
+// loadSyncState reads the fingerprints of hostID from a state file. It
+// returns nil without an error if the file doesn't exist.
+func loadSyncState(path, hostID string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var state syncState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("corrupt state file: %w", err)
+	}
+	if state.Version != syncStateVersion {
+		return nil, fmt.Errorf("unsupported state version %d (expected %d)", state.Version, syncStateVersion)
+	}
+	if state.HostID != hostID {
+		return nil, fmt.Errorf("state belongs to host %q, not %q", state.HostID, hostID)
+	}
+	if state.Hashes == nil {
+		state.Hashes = make(map[string]string)
+	}
+	return state.Hashes, nil
+}
+
+// saveSyncState writes the fingerprints to the state file if they changed
+// since the last write. The file is replaced atomically, so a crash while
+// writing leaves the previous state.
+func (a *Agent) saveSyncState() error {
+	if a.stateFile == "" {
+		return nil
+	}
+
+	f := a.fingerprints
+	f.mu.Lock()
+	if !f.dirty {
+		f.mu.Unlock()
+		return nil
+	}
+	data, err := json.Marshal(syncState{
+		Version: syncStateVersion,
+		HostID:  a.hostID,
+		SavedAt: time.Now().UTC(),
+		Hashes:  f.hashes,
+	})
+	f.dirty = false
+	f.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("failed to marshal sync state: %w", err)
+	}
+
+	if err := writeFileAtomic(a.stateFile, data); err != nil {
+		f.mu.Lock()
+		f.dirty = true
+		f.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// writeFileAtomic writes data to a temporary file next to path and renames
+// it over path.
+func writeFileAtomic(path string, data []byte) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create state directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(dir, filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create state file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to write state file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write state file: %w", err)
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// periodicStateSave writes changed fingerprints to the state file every
+// stateSaveInterval until ctx is done.
+func (a *Agent) periodicStateSave(ctx context.Context) {
+	ticker := time.NewTicker(stateSaveInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := a.saveSyncState(); err != nil {
+				log.Printf("Warning: Failed to save sync state: %v", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package agent
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"evalgo.org/graphium/models"
+)
+
+// systemInfoInterval is how often the agent reports the Docker system info.
+const systemInfoInterval = 5 * time.Minute
+
+// collectSystemInfo gathers the Docker engine configuration and state of this host.
+func (a *Agent) collectSystemInfo(ctx context.Context) (*models.HostSystemInfo, error) {
+	info, err := a.docker.Info(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get Docker info: %w", err)
+	}
+
+	systemInfo := &models.HostSystemInfo{
+		HostID:            a.hostID,
+		DockerVersion:     info.ServerVersion,
+		OperatingSystem:   info.OperatingSystem,
+		OSType:            info.OSType,
+		Architecture:      info.Architecture,
+		KernelVersion:     info.KernelVersion,
+		StorageDriver:     info.Driver,
+		CgroupDriver:      info.CgroupDriver,
+		CgroupVersion:     info.CgroupVersion,
+		DockerRootDir:     info.DockerRootDir,
+		CPU:               info.NCPU,
+		Memory:            info.MemTotal,
+		Containers:        info.Containers,
+		ContainersRunning: info.ContainersRunning,
+		ContainersPaused:  info.ContainersPaused,
+		ContainersStopped: info.ContainersStopped,
+		Images:            info.Images,
+		Warnings:          info.Warnings,
+		ReportedAt:        time.Now(),
+	}
+
+	// The Docker root may not be visible when the agent runs in a container
+	if info.DockerRootDir != "" {
+		if total, free, err := diskUsage(info.DockerRootDir); err == nil {
+			systemInfo.DiskTotal = total
+			systemInfo.DiskFree = free
+		}
+	}
+
+	return systemInfo, nil
+}
+
+// reportSystemInfo collects the Docker system info and sends it to the API server.
+func (a *Agent) reportSystemInfo(ctx context.Context) (*models.HostSystemInfo, error) {
+	info, err := a.collectSystemInfo(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(info)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal system info: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/api/v1/hosts/%s/system-info", a.apiURL, a.hostID)
+	req, err := http.NewRequestWithContext(ctx, "PUT", url, bytes.NewBuffer(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if a.authToken != "" {
+		req.Header.Set("Authorization", "Bearer "+a.authToken)
+	}
+
+	resp, err := a.doRequest(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send system info: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("system info update failed: %s - %s", resp.Status, string(body))
+	}
+
+	return info, nil
+}
+
+// periodicSystemInfoReport reports the Docker system info on start and then periodically.
+func (a *Agent) periodicSystemInfoReport(ctx context.Context) {
+	ticker := time.NewTicker(systemInfoInterval)
+	defer ticker.Stop()
+
+	if _, err := a.reportSystemInfo(ctx); err != nil {
+		log.Printf("Warning: Initial system info report failed: %v", err)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := a.reportSystemInfo(ctx); err != nil {
+				log.Printf("System info report error: %v", err)
+			}
+		}
+	}
+}
@@ -9,9 +9,13 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"log/slog"
 	"net/http"
 	"os"
+	"sort"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/docker/docker/api/types/container"
@@ -19,26 +23,78 @@ import (
 	"evalgo.org/graphium/models"
 )
 
-// TaskExecutor polls the server for tasks and executes them.
+// DefaultTaskConcurrency is how many tasks an agent runs in parallel
+// unless configured otherwise (agent.task_concurrency).
+const DefaultTaskConcurrency = 4
+
+// Task timeouts used when a task doesn't set TimeoutSeconds. A task that
+// exceeds its timeout is reported as failed and frees its slot.
+const (
+	fastTaskTimeout = 5 * time.Minute
+	slowTaskTimeout = 30 * time.Minute
+)
+
+// TaskExecutor fetches tasks from the server and executes them, up to
+// concurrency tasks in parallel. Slow tasks (deploys, deletes, workflows)
+// never take the last free slot, so checks and control tasks keep running
+// while a deploy is slow. With task push enabled the server notifies it of
+// new tasks over a WebSocket; it polls every pollInterval while the socket
+// is down and every taskPushPollInterval while it is up.
 type TaskExecutor struct {
 	agent        *Agent
 	deployer     *AgentDeployer
 	pollInterval time.Duration
 	running      bool
 	stopChan     chan struct{}
+
+	slots     chan struct{} // One per running task
+	slowSlots chan struct{} // One per running slow task
+
+	mu         sync.Mutex
+	inFlight   map[string]bool // IDs of the tasks being executed
+	containers map[string]bool // Containers a running task acts on
+	reporting  map[string]bool // Running tasks whose result is being reported
+	draining   bool            // Whether drain stopped starting tasks
+	abandoned  bool            // Whether drain gave up on the running tasks
+	tasks      sync.WaitGroup  // Tasks claimed by tryStart
+
+	wake          chan struct{} // Task notifications waiting to be fetched
+	pushConnected atomic.Bool   // Whether the task socket is connected
+}
+
+// SetTaskConcurrency sets how many tasks the agent runs in parallel.
+// It must be called before Start; n <= 0 keeps DefaultTaskConcurrency.
+func (a *Agent) SetTaskConcurrency(n int) {
+	a.taskConcurrency = n
 }
 
-// NewTaskExecutor creates a new task executor.
+// NewTaskExecutor creates a new task executor running up to the agent's
+// task concurrency tasks in parallel.
 func NewTaskExecutor(agent *Agent, pollInterval time.Duration) *TaskExecutor {
 	if pollInterval == 0 {
 		pollInterval = 5 * time.Second // Default: poll every 5 seconds
 	}
 
+	concurrency := agent.taskConcurrency
+	if concurrency <= 0 {
+		concurrency = DefaultTaskConcurrency
+	}
+	slowConcurrency := concurrency - 1
+	if slowConcurrency < 1 {
+		slowConcurrency = 1
+	}
+
 	return &TaskExecutor{
 		agent:        agent,
 		deployer:     NewDeployer(agent.docker, agent.hostID, agent.hostID),
 		pollInterval: pollInterval,
 		stopChan:     make(chan struct{}),
+		slots:        make(chan struct{}, concurrency),
+		slowSlots:    make(chan struct{}, slowConcurrency),
+		inFlight:     make(map[string]bool),
+		containers:   make(map[string]bool),
+		reporting:    make(map[string]bool),
+		wake:         make(chan struct{}, 1),
 	}
 }
 
@@ -49,7 +105,19 @@ func (e *TaskExecutor) Start(ctx context.Context) error {
 	}
 
 	e.running = true
-	log.Printf("Task executor started (polling every %v)", e.pollInterval)
+	log.Printf("Task executor started (polling every %v, running up to %d tasks in parallel)", e.pollInterval, cap(e.slots))
+
+	if e.agent.taskPush {
+		go e.listenForTasks(ctx)
+	}
+
+	var lastPoll time.Time
+	poll := func() {
+		lastPoll = time.Now()
+		if err := e.pollAndExecuteTasks(ctx); err != nil {
+			log.Printf("Error polling/executing tasks: %v", err)
+		}
+	}
 
 	ticker := time.NewTicker(e.pollInterval)
 	defer ticker.Stop()
@@ -57,9 +125,14 @@ func (e *TaskExecutor) Start(ctx context.Context) error {
 	for {
 		select {
 		case <-ticker.C:
-			if err := e.pollAndExecuteTasks(ctx); err != nil {
-				log.Printf("Error polling/executing tasks: %v", err)
+			// The task socket reports new tasks; poll only as a safety net
+			if e.pushConnected.Load() && time.Since(lastPoll) < taskPushPollInterval {
+				continue
 			}
+			poll()
+
+		case <-e.wake:
+			poll()
 
 		case <-e.stopChan:
 			log.Println("Task executor stopped")
@@ -81,7 +154,19 @@ func (e *TaskExecutor) Stop() {
 	}
 }
 
-// pollAndExecuteTasks fetches pending tasks and executes them.
+// stopped reports whether Stop was called.
+func (e *TaskExecutor) stopped() bool {
+	select {
+	case <-e.stopChan:
+		return true
+	default:
+		return false
+	}
+}
+
+// pollAndExecuteTasks fetches pending tasks and starts those a slot is
+// free for, fast tasks first. Tasks that can't start yet stay pending on
+// the server and are fetched again by the next poll.
 func (e *TaskExecutor) pollAndExecuteTasks(ctx context.Context) error {
 	// Fetch pending tasks from server
 	tasks, err := e.fetchPendingTasks()
@@ -95,24 +180,159 @@ func (e *TaskExecutor) pollAndExecuteTasks(ctx context.Context) error {
 
 	log.Printf("Fetched %d pending task(s)", len(tasks))
 
-	// Execute each task
+	orderTasks(tasks)
+
 	for _, task := range tasks {
-		if err := e.executeTask(ctx, task); err != nil {
-			log.Printf("Failed to execute task %s: %v", task.ID, err)
-			// Report failure
-			if reportErr := e.reportTaskStatus(task.ID, "failed", err.Error(), nil); reportErr != nil {
-				log.Printf("Failed to report task failure status: %v", reportErr)
-			}
+		if !e.tryStart(task) {
+			continue
 		}
+
+		go func(task *models.AgentTask) {
+			defer e.finish(task)
+
+			if err := e.executeTask(ctx, task); err != nil {
+				log.Printf("Failed to execute task %s: %v", task.ID, err)
+				// Report failure
+				if reportErr := e.reportTaskStatus(task.ID, "failed", err.Error(), nil); reportErr != nil {
+					log.Printf("Failed to report task failure status: %v", reportErr)
+				}
+			}
+		}(task)
 	}
 
 	return nil
 }
 
-// fetchPendingTasks fetches pending tasks for this agent from the server.
+// isSlowTask reports whether a task may run for minutes: deploys, deletes
+// (which stop containers or prune), scaling, workflows and volume backups.
+func isSlowTask(task *models.AgentTask) bool {
+	switch task.Type {
+	case "ActivateAction", "DeleteAction", "ScaleAction", "WorkflowAction":
+		return true
+	case "TransferAction":
+		var payload struct {
+			Action string `json:"action"`
+		}
+		return task.GetPayloadAs(&payload) == nil && payload.Action == models.TransferBackupVolume
+	}
+	return false
+}
+
+// orderTasks sorts tasks fast ones first, then by priority (higher
+// first), then oldest first.
+func orderTasks(tasks []*models.AgentTask) {
+	sort.SliceStable(tasks, func(i, j int) bool {
+		if slowI, slowJ := isSlowTask(tasks[i]), isSlowTask(tasks[j]); slowI != slowJ {
+			return !slowI
+		}
+		if tasks[i].Priority != tasks[j].Priority {
+			return tasks[i].Priority > tasks[j].Priority
+		}
+		return tasks[i].CreatedAt.Before(tasks[j].CreatedAt)
+	})
+}
+
+// taskContainer returns the container a task acts on, if any.
+func taskContainer(task *models.AgentTask) string {
+	if task.ContainerID != "" {
+		return task.ContainerID
+	}
+	var payload map[string]interface{}
+	if task.GetPayloadAs(&payload) == nil {
+		if id, ok := payload["containerId"].(string); ok {
+			return id
+		}
+	}
+	return ""
+}
+
+// tryStart claims a slot for a task without blocking. It refuses tasks
+// that are already running, tasks on a container another task acts on,
+// tasks no slot is free for, and all tasks once the executor drains.
+func (e *TaskExecutor) tryStart(task *models.AgentTask) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.draining || e.inFlight[task.ID] {
+		return false
+	}
+	containerID := taskContainer(task)
+	if containerID != "" && e.containers[containerID] {
+		return false
+	}
+
+	slow := isSlowTask(task)
+	if slow {
+		select {
+		case e.slowSlots <- struct{}{}:
+		default:
+			return false
+		}
+	}
+	select {
+	case e.slots <- struct{}{}:
+	default:
+		if slow {
+			<-e.slowSlots
+		}
+		return false
+	}
+
+	e.inFlight[task.ID] = true
+	if containerID != "" {
+		e.containers[containerID] = true
+	}
+	e.tasks.Add(1)
+	return true
+}
+
+// claimResult marks a task's result as being reported, unless drain gave
+// up on the task and reports it as interrupted instead.
+func (e *TaskExecutor) claimResult(taskID string) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.abandoned {
+		return false
+	}
+	e.reporting[taskID] = true
+	return true
+}
+
+// finish releases the slot of a task claimed by tryStart.
+func (e *TaskExecutor) finish(task *models.AgentTask) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	delete(e.inFlight, task.ID)
+	delete(e.reporting, task.ID)
+	if containerID := taskContainer(task); containerID != "" {
+		delete(e.containers, containerID)
+	}
+	<-e.slots
+	if isSlowTask(task) {
+		<-e.slowSlots
+	}
+	e.tasks.Done()
+}
+
+// taskTimeout returns how long a task may run.
+func taskTimeout(task *models.AgentTask) time.Duration {
+	if task.TimeoutSeconds > 0 {
+		return time.Duration(task.TimeoutSeconds) * time.Second
+	}
+	if isSlowTask(task) {
+		return slowTaskTimeout
+	}
+	return fastTaskTimeout
+}
+
+// fetchPendingTasks fetches all pending tasks for this agent from the
+// server, highest priority and oldest first. They are not limited, so tasks
+// that can't start yet don't hide the ones behind them.
 func (e *TaskExecutor) fetchPendingTasks() ([]*models.AgentTask, error) {
 	// Build API URL - use semantic status (PotentialActionStatus = pending)
-	url := fmt.Sprintf("%s/api/v1/agents/%s/tasks?status=%s&limit=10", e.agent.apiURL, e.agent.hostID, models.TaskStatusPending)
+	url := fmt.Sprintf("%s/api/v1/agents/%s/tasks?status=%s", e.agent.apiURL, e.agent.hostID, models.TaskStatusPending)
 
 	// Create request
 	req, err := http.NewRequest("GET", url, nil)
@@ -126,7 +346,7 @@ func (e *TaskExecutor) fetchPendingTasks() ([]*models.AgentTask, error) {
 	}
 
 	// Execute request
-	resp, err := e.agent.httpClient.Do(req)
+	resp, err := e.agent.doRequest(req)
 	if err != nil {
 		return nil, err
 	}
@@ -146,16 +366,76 @@ func (e *TaskExecutor) fetchPendingTasks() ([]*models.AgentTask, error) {
 	return tasks, nil
 }
 
-// executeTask executes a single task.
+// executeTask executes a single task and reports its status. All status
+// reports of a task are sent from here, in order; a task that exceeds its
+// timeout is reported as failed, and a late result is discarded. It returns
+// only once the task stopped running, so the task's slot and container stay
+// claimed until then.
 func (e *TaskExecutor) executeTask(ctx context.Context, task *models.AgentTask) error {
 	log.Printf("Executing task %s (type: %s)", task.ID, task.Type)
+	started := time.Now()
 
 	// Mark task as running
 	if err := e.reportTaskStatus(task.ID, models.TaskStatusRunning, "", nil); err != nil {
 		log.Printf("Warning: Failed to mark task as running: %v", err)
 	}
 
-	// Execute based on task @type (Schema.org Action type)
+	timeout := taskTimeout(task)
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	type outcome struct {
+		result *models.TaskResult
+		err    error
+	}
+	done := make(chan outcome, 1)
+	go func() {
+		result, err := e.performTask(ctx, task)
+		done <- outcome{result, err}
+	}()
+
+	var result *models.TaskResult
+	var err error
+	select {
+	case out := <-done:
+		result, err = out.result, out.err
+	case <-ctx.Done():
+		err = fmt.Errorf("task did not finish within %v", timeout)
+		// Keep the slot and the container until the task stops acting on it
+		defer func() { <-done }()
+	}
+
+	// Drain already reported the task as interrupted
+	if !e.claimResult(task.ID) {
+		log.Printf("Discarding result of interrupted task %s", task.ID)
+		return nil
+	}
+
+	// Report result
+	if err != nil {
+		slog.Warn("task executed",
+			"host_id", e.agent.hostID,
+			"task_id", task.ID,
+			"task_type", task.Type,
+			"status", "failed",
+			"duration_ms", time.Since(started).Milliseconds(),
+			"error", err.Error(),
+		)
+		return e.reportTaskStatus(task.ID, models.TaskStatusFailed, err.Error(), nil)
+	}
+
+	slog.Info("task executed",
+		"host_id", e.agent.hostID,
+		"task_id", task.ID,
+		"task_type", task.Type,
+		"status", "completed",
+		"duration_ms", time.Since(started).Milliseconds(),
+	)
+	return e.reportTaskStatus(task.ID, models.TaskStatusCompleted, "", result)
+}
+
+// performTask runs a task according to its @type (Schema.org Action type).
+func (e *TaskExecutor) performTask(ctx context.Context, task *models.AgentTask) (*models.TaskResult, error) {
 	var result *models.TaskResult
 	var err error
 
@@ -163,7 +443,7 @@ func (e *TaskExecutor) executeTask(ctx context.Context, task *models.AgentTask)
 	case "ActivateAction": // Deploy/start/restart containers
 		result, err = e.executeDeploy(ctx, task)
 
-	case "DeleteAction": // Delete containers
+	case "DeleteAction": // Delete containers, prune unused resources
 		result, err = e.executeDelete(ctx, task)
 
 	case "DeactivateAction": // Stop containers
@@ -181,18 +461,14 @@ func (e *TaskExecutor) executeTask(ctx context.Context, task *models.AgentTask)
 	case "WorkflowAction": // Composite workflows
 		result, err = e.executeWorkflow(ctx, task)
 
+	case "ScaleAction": // Converge the replicas of a scale group
+		result, err = e.executeScale(ctx, task)
+
 	default:
 		err = fmt.Errorf("unsupported task type: %s", task.Type)
 	}
 
-	// Report result
-	if err != nil {
-		log.Printf("Task %s failed: %v", task.ID, err)
-		return e.reportTaskStatus(task.ID, models.TaskStatusFailed, err.Error(), nil)
-	}
-
-	log.Printf("Task %s completed successfully", task.ID)
-	return e.reportTaskStatus(task.ID, models.TaskStatusCompleted, "", result)
+	return result, err
 }
 
 // executeDeploy executes a deploy task.
@@ -207,6 +483,15 @@ func (e *TaskExecutor) executeDeploy(ctx context.Context, task *models.AgentTask
 
 // executeDelete executes a delete task.
 func (e *TaskExecutor) executeDelete(ctx context.Context, task *models.AgentTask) (*models.TaskResult, error) {
+	// Route to resource pruning if specified
+	var rawPayload map[string]interface{}
+	if err := task.GetPayloadAs(&rawPayload); err != nil {
+		return nil, fmt.Errorf("invalid delete payload: %w", err)
+	}
+	if action, ok := rawPayload["action"].(string); ok && action == "prune" {
+		return e.executePrune(ctx, task)
+	}
+
 	var payload models.DeleteContainerPayload
 	if err := task.GetPayloadAs(&payload); err != nil {
 		return nil, fmt.Errorf("invalid delete payload: %w", err)
@@ -247,15 +532,28 @@ func (e *TaskExecutor) executeRestart(ctx context.Context, task *models.AgentTas
 
 // executeCheck executes a health check task.
 func (e *TaskExecutor) executeCheck(ctx context.Context, task *models.AgentTask) (*models.TaskResult, error) {
-	// First, check if this is a TLS certificate check
+	// Read the check type from the raw payload
 	var rawPayload map[string]interface{}
 	if err := task.GetPayloadAs(&rawPayload); err != nil {
 		return nil, fmt.Errorf("invalid check payload: %w", err)
 	}
 
-	// Route to TLS certificate check if specified
-	if checkType, ok := rawPayload["checkType"].(string); ok && checkType == "tls-certificate" {
+	// Route by check type; checks without one are HTTP health checks
+	checkType, _ := rawPayload["checkType"].(string)
+	switch checkType {
+	case "tls-certificate":
 		return e.executeTLSCertificateCheck(ctx, rawPayload)
+	case models.CheckTypeConnectivity:
+		// Connectivity probe between services
+		return e.executeConnectivityCheck(ctx, task)
+	case models.CheckTypeTCPPort:
+		// TCP port check for services without an HTTP endpoint
+		return e.executeTCPPortCheck(ctx, task)
+	case models.CheckTypeDNS:
+		return e.executeDNSCheck(ctx, task)
+	case models.CheckTypeSystemInfo:
+		// Refresh of the host's Docker system info
+		return e.executeSystemInfoRefresh(ctx)
 	}
 
 	// Otherwise, execute HTTP health check
@@ -275,6 +573,11 @@ func (e *TaskExecutor) executeCheck(ctx context.Context, task *models.AgentTask)
 		payload.Timeout = 5
 	}
 
+	// Check every target of a multi-target check
+	if len(payload.Targets) > 0 {
+		return e.executeMultiCheck(ctx, &payload)
+	}
+
 	// Create HTTP request
 	req, err := http.NewRequestWithContext(ctx, payload.Method, payload.URL, nil)
 	if err != nil {
@@ -340,6 +643,10 @@ func (e *TaskExecutor) executeCheck(ctx context.Context, task *models.AgentTask)
 	}, nil
 }
 
+// controlStopTimeout is the seconds control tasks wait for a container
+// without a stop grace period to stop before it is killed.
+const controlStopTimeout = 30
+
 // executeControl executes a control task (dispatches to specific operations).
 func (e *TaskExecutor) executeControl(ctx context.Context, task *models.AgentTask) (*models.TaskResult, error) {
 	// Parse payload to get control parameters
@@ -359,15 +666,23 @@ func (e *TaskExecutor) executeControl(ctx context.Context, task *models.AgentTas
 		return nil, fmt.Errorf("missing or invalid 'containerId' field in payload")
 	}
 
-	timeout := 30.0 // default timeout
+	// Without a timeout, stop and restart give the container its own stop
+	// grace period, or controlStopTimeout if it has none
+	timeout := 0
 	if timeoutVal, ok := payload["timeout"].(float64); ok {
-		timeout = timeoutVal
+		timeout = int(timeoutVal)
+	}
+	if timeout <= 0 && (action == "stop" || action == "restart") {
+		timeout = e.deployer.stopGracePeriod(ctx, containerID, 0, controlStopTimeout)
 	}
 
+	force, _ := payload["force"].(bool)
+
 	// Create control payload
 	controlPayload := &models.ControlContainerPayload{
 		ContainerID: containerID,
-		Timeout:     int(timeout),
+		Timeout:     timeout,
+		Force:       force,
 	}
 
 	// Execute based on action
@@ -379,11 +694,11 @@ func (e *TaskExecutor) executeControl(ctx context.Context, task *models.AgentTas
 	case "start":
 		return e.deployer.StartContainer(ctx, controlPayload)
 	case "pause":
-		// Pause operation would be similar to stop
-		return e.deployer.StopContainer(ctx, controlPayload)
+		return e.deployer.PauseContainer(ctx, controlPayload)
 	case "unpause":
-		// Unpause operation would be similar to start
-		return e.deployer.StartContainer(ctx, controlPayload)
+		return e.deployer.UnpauseContainer(ctx, controlPayload)
+	case models.ControlExec:
+		return e.executeContainerExec(ctx, payload)
 	default:
 		return nil, fmt.Errorf("unsupported control action: %s", action)
 	}
@@ -398,6 +713,17 @@ func (e *TaskExecutor) executeTransfer(ctx context.Context, task *models.AgentTa
 	}
 
 	action, _ := payload["action"].(string)
+
+	// Shipping the agent's own logs doesn't involve a container
+	if action == models.TransferShipLogs {
+		return e.executeShipLogs(ctx)
+	}
+
+	// Volume backups act on a volume, not a container
+	if action == models.TransferBackupVolume {
+		return e.executeBackupVolume(ctx, task)
+	}
+
 	containerID, ok := payload["containerId"].(string)
 	if !ok {
 		return nil, fmt.Errorf("missing or invalid 'containerId' field in payload")
@@ -743,7 +1069,7 @@ func (e *TaskExecutor) reportTaskStatus(taskID string, status string, errorMsg s
 	}
 
 	// Execute request
-	resp, err := e.agent.httpClient.Do(req)
+	resp, err := e.agent.doRequest(req)
 	if err != nil {
 		return err
 	}
@@ -756,3 +1082,30 @@ func (e *TaskExecutor) reportTaskStatus(taskID string, status string, errorMsg s
 
 	return nil
 }
+
+// executeSystemInfoRefresh reports the host's Docker system info to the server.
+func (e *TaskExecutor) executeSystemInfoRefresh(ctx context.Context) (*models.TaskResult, error) {
+	info, err := e.agent.reportSystemInfo(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.TaskResult{
+		Success: true,
+		Message: fmt.Sprintf("Reported Docker %s system info (%d containers, %d running)",
+			info.DockerVersion, info.Containers, info.ContainersRunning),
+	}, nil
+}
+
+// executeShipLogs flushes the agent's buffered log lines to the server.
+func (e *TaskExecutor) executeShipLogs(ctx context.Context) (*models.TaskResult, error) {
+	shipped, err := e.agent.shipLogs(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.TaskResult{
+		Success: true,
+		Message: fmt.Sprintf("Shipped %d log lines", shipped),
+	}, nil
+}
@@ -0,0 +1,128 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/google/uuid"
+
+	"evalgo.org/graphium/models"
+)
+
+// executeScale converges the replicas of a scale group on this host to the
+// payload's count. Stopped replicas are cleaned up, missing ones created
+// from the payload's spec and surplus ones removed, newest first. The result
+// reports the running replicas before and after, on this host and, for a
+// step of a planned run, across the run's hosts.
+func (e *TaskExecutor) executeScale(ctx context.Context, task *models.AgentTask) (*models.TaskResult, error) {
+	var payload models.ScalePayload
+	if err := task.GetPayloadAs(&payload); err != nil {
+		return nil, fmt.Errorf("invalid scale payload: %w", err)
+	}
+	if err := payload.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid scale payload: %w", err)
+	}
+
+	replicas, err := e.agent.docker.ContainerList(ctx, container.ListOptions{
+		All:     true,
+		Filters: filters.NewArgs(filters.Arg("label", models.ScaleGroupLabel+"="+payload.Group)),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list replicas of %s: %w", payload.Group, err)
+	}
+
+	var running []container.Summary
+	stopped := 0
+	for _, replica := range replicas {
+		if replica.State == "running" {
+			running = append(running, replica)
+			continue
+		}
+		if err := e.removeReplica(ctx, replica); err != nil {
+			return nil, err
+		}
+		stopped++
+	}
+	before := len(running)
+
+	created := 0
+	for i := before; i < payload.Replicas; i++ {
+		if err := e.createReplica(ctx, &payload); err != nil {
+			return nil, fmt.Errorf("created %d of %d missing replicas of %s: %w", created, payload.Replicas-before, payload.Group, err)
+		}
+		created++
+	}
+
+	removed := 0
+	if surplus := before - payload.Replicas; surplus > 0 {
+		sort.Slice(running, func(i, j int) bool {
+			return running[i].Created > running[j].Created
+		})
+		for _, replica := range running[:surplus] {
+			if err := e.removeReplica(ctx, replica); err != nil {
+				return nil, fmt.Errorf("removed %d of %d surplus replicas of %s: %w", removed, surplus, payload.Group, err)
+			}
+			removed++
+		}
+	}
+	after := before + created - removed
+
+	data := map[string]interface{}{
+		"group":    payload.Group,
+		"replicas": payload.Replicas,
+		"before":   before,
+		"after":    after,
+		"created":  created,
+		"removed":  removed,
+		"stopped":  stopped,
+	}
+	if payload.Plan != nil {
+		data["step"] = payload.Step + 1
+		data["steps"] = len(payload.Plan.Steps)
+		data["totalReplicas"] = payload.Plan.Replicas
+		data["totalBefore"] = payload.Plan.Before
+		data["totalAfter"] = payload.TotalAfter(after)
+	}
+
+	return &models.TaskResult{
+		Success: true,
+		Message: fmt.Sprintf("Scale group %s has %d of %d replicas on this host (was %d)", payload.Group, after, payload.Replicas, before),
+		Data:    data,
+	}, nil
+}
+
+// createReplica creates and starts a replica of a scale group, named after
+// the group and labelled with it.
+func (e *TaskExecutor) createReplica(ctx context.Context, payload *models.ScalePayload) error {
+	deploy := payload.Spec
+	deploy.ContainerSpec.Name = payload.Group + "-" + strings.SplitN(uuid.New().String(), "-", 2)[0]
+
+	deploy.Labels = make(map[string]string, len(payload.Spec.Labels)+2)
+	for key, value := range payload.Spec.Labels {
+		deploy.Labels[key] = value
+	}
+	deploy.Labels[models.ScaleGroupLabel] = payload.Group
+	deploy.Labels[models.ManagedLabel] = "true"
+
+	_, err := e.deployer.DeployContainer(ctx, &deploy)
+	return err
+}
+
+// removeReplica stops and removes a replica of a scale group.
+func (e *TaskExecutor) removeReplica(ctx context.Context, replica container.Summary) error {
+	name := replica.ID
+	if len(replica.Names) > 0 {
+		name = strings.TrimPrefix(replica.Names[0], "/")
+	}
+	if _, err := e.deployer.DeleteContainer(ctx, &models.DeleteContainerPayload{
+		ContainerID:   replica.ID,
+		ContainerName: name,
+	}); err != nil {
+		return fmt.Errorf("failed to remove replica %s: %w", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package agent
+
+import (
+	"testing"
+	"time"
+
+	"evalgo.org/graphium/models"
+)
+
+func newTestExecutor(concurrency, slowConcurrency int) *TaskExecutor {
+	return &TaskExecutor{
+		stopChan:   make(chan struct{}),
+		slots:      make(chan struct{}, concurrency),
+		slowSlots:  make(chan struct{}, slowConcurrency),
+		inFlight:   make(map[string]bool),
+		containers: make(map[string]bool),
+		reporting:  make(map[string]bool),
+		wake:       make(chan struct{}, 1),
+	}
+}
+
+func newTestTask(id, taskType, containerID string) *models.AgentTask {
+	return &models.AgentTask{ID: id, Type: taskType, ContainerID: containerID}
+}
+
+func TestOrderTasks(t *testing.T) {
+	now := time.Now()
+	deploy := newTestTask("deploy", "ActivateAction", "")
+	deploy.Priority = 10
+	oldCheck := newTestTask("old-check", "CheckAction", "")
+	oldCheck.CreatedAt = now.Add(-time.Minute)
+	newCheck := newTestTask("new-check", "CheckAction", "")
+	newCheck.CreatedAt = now
+	urgentStop := newTestTask("urgent-stop", "DeactivateAction", "")
+	urgentStop.Priority = 5
+	urgentStop.CreatedAt = now
+
+	tasks := []*models.AgentTask{deploy, newCheck, oldCheck, urgentStop}
+	orderTasks(tasks)
+
+	want := []string{"urgent-stop", "old-check", "new-check", "deploy"}
+	for i, task := range tasks {
+		if task.ID != want[i] {
+			t.Fatalf("Expected order %v, got task %s at %d", want, task.ID, i)
+		}
+	}
+}
+
+func TestTryStart_RefusesRunningTaskAndBusyContainer(t *testing.T) {
+	e := newTestExecutor(4, 3)
+
+	first := newTestTask("task-1", "ControlAction", "web-1")
+	if !e.tryStart(first) {
+		t.Fatal("Expected the first task to start")
+	}
+	if e.tryStart(first) {
+		t.Error("Expected a running task not to start again")
+	}
+	if e.tryStart(newTestTask("task-2", "DeactivateAction", "web-1")) {
+		t.Error("Expected a task on a busy container not to start")
+	}
+	if !e.tryStart(newTestTask("task-3", "DeactivateAction", "web-2")) {
+		t.Error("Expected a task on another container to start")
+	}
+}
+
+func TestTryStart_RespectsSlots(t *testing.T) {
+	e := newTestExecutor(2, 1)
+
+	if !e.tryStart(newTestTask("deploy-1", "ActivateAction", "")) {
+		t.Fatal("Expected the first slow task to start")
+	}
+	if e.tryStart(newTestTask("deploy-2", "ActivateAction", "")) {
+		t.Error("Expected a second slow task to wait for the slow slot")
+	}
+	if !e.tryStart(newTestTask("check-1", "CheckAction", "")) {
+		t.Fatal("Expected a fast task to use the free slot")
+	}
+	if e.tryStart(newTestTask("check-2", "CheckAction", "")) {
+		t.Error("Expected a task to wait once all slots are taken")
+	}
+	if len(e.slowSlots) != 1 {
+		t.Errorf("Expected a refused slow task to release its slow slot, got %d taken", len(e.slowSlots))
+	}
+}
+
+func TestTryStart_RefusesWhileDraining(t *testing.T) {
+	e := newTestExecutor(2, 1)
+	e.draining = true
+
+	if e.tryStart(newTestTask("task-1", "CheckAction", "")) {
+		t.Error("Expected no task to start while draining")
+	}
+}
+
+func TestFinish_ReleasesSlotAndContainer(t *testing.T) {
+	e := newTestExecutor(1, 1)
+	deploy := newTestTask("deploy-1", "ActivateAction", "web-1")
+
+	if !e.tryStart(deploy) {
+		t.Fatal("Expected the task to start")
+	}
+	e.reporting[deploy.ID] = true
+	e.finish(deploy)
+
+	if len(e.slots) != 0 || len(e.slowSlots) != 0 {
+		t.Errorf("Expected all slots free, got %d slots and %d slow slots taken", len(e.slots), len(e.slowSlots))
+	}
+	if e.inFlight[deploy.ID] || e.reporting[deploy.ID] || e.containers["web-1"] {
+		t.Error("Expected the task and its container to be released")
+	}
+	if !e.tryStart(newTestTask("stop-1", "DeactivateAction", "web-1")) {
+		t.Error("Expected a task on the released container to start")
+	}
+
+	drained := make(chan struct{})
+	go func() {
+		e.finish(newTestTask("stop-1", "DeactivateAction", "web-1"))
+		e.tasks.Wait()
+		close(drained)
+	}()
+	select {
+	case <-drained:
+	case <-time.After(time.Second):
+		t.Fatal("Expected finish to mark the task done")
+	}
+}
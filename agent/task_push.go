@@ -0,0 +1,144 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// taskPushPollInterval is how often the task executor still polls while its
+// task socket is connected, in case a notification was lost.
+const taskPushPollInterval = time.Minute
+
+// Reconnect delays of the task socket; the delay doubles per failed attempt.
+const (
+	taskSocketRetryMin = 5 * time.Second
+	taskSocketRetryMax = 2 * time.Minute
+)
+
+// taskSocketReadTimeout closes a task socket the server stopped pinging.
+// The server pings every 54 seconds.
+const taskSocketReadTimeout = 90 * time.Second
+
+// taskAvailableEvent is the event the server pushes when a task is created
+// for the agent.
+const taskAvailableEvent = "task_available"
+
+// SetTaskPush sets whether the agent receives task notifications over a
+// WebSocket (the default) or only polls for tasks. It must be called before
+// Start.
+func (a *Agent) SetTaskPush(enabled bool) {
+	a.taskPush = enabled
+}
+
+// taskSocketURL returns the WebSocket URL of the agent's task notifications.
+func taskSocketURL(apiURL, hostID string) string {
+	switch {
+	case strings.HasPrefix(apiURL, "https://"):
+		apiURL = "wss://" + strings.TrimPrefix(apiURL, "https://")
+	case strings.HasPrefix(apiURL, "http://"):
+		apiURL = "ws://" + strings.TrimPrefix(apiURL, "http://")
+	}
+	return fmt.Sprintf("%s/api/v1/ws/agents/%s/tasks", apiURL, hostID)
+}
+
+// notify wakes the executor to fetch pending tasks. Notifications that
+// arrive while a fetch is pending are coalesced into it.
+func (e *TaskExecutor) notify() {
+	select {
+	case e.wake <- struct{}{}:
+	default:
+	}
+}
+
+// listenForTasks keeps the agent's task socket connected until the executor
+// stops, reconnecting with a growing delay. While the socket is down the
+// executor polls every pollInterval.
+func (e *TaskExecutor) listenForTasks(ctx context.Context) {
+	url := taskSocketURL(e.agent.apiURL, e.agent.hostID)
+	delay := taskSocketRetryMin
+	for {
+		connected, err := e.receiveTaskNotifications(ctx, url)
+		e.pushConnected.Store(false)
+		if ctx.Err() != nil || e.stopped() {
+			return
+		}
+		if connected {
+			delay = taskSocketRetryMin
+		}
+		log.Printf("Task socket unavailable, polling every %v (retrying in %v): %v", e.pollInterval, delay, err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-e.stopChan:
+			return
+		case <-time.After(delay):
+		}
+		delay = min(delay*2, taskSocketRetryMax)
+	}
+}
+
+// receiveTaskNotifications connects the task socket and wakes the executor
+// on every task notification until the socket fails. It reports whether the
+// socket was connected.
+func (e *TaskExecutor) receiveTaskNotifications(ctx context.Context, url string) (bool, error) {
+	header := http.Header{}
+	if e.agent.authToken != "" {
+		header.Set("Authorization", "Bearer "+e.agent.authToken)
+	}
+
+	conn, resp, err := websocket.DefaultDialer.DialContext(ctx, url, header)
+	if err != nil {
+		if resp != nil {
+			return false, fmt.Errorf("%w (HTTP %s)", err, resp.Status)
+		}
+		return false, err
+	}
+	defer conn.Close()
+
+	// Unblock the read below once the executor stops
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-e.stopChan:
+		case <-done:
+		}
+		_ = conn.Close()
+	}()
+
+	_ = conn.SetReadDeadline(time.Now().Add(taskSocketReadTimeout))
+	conn.SetPingHandler(func(data string) error {
+		_ = conn.SetReadDeadline(time.Now().Add(taskSocketReadTimeout))
+		return conn.WriteControl(websocket.PongMessage, []byte(data), time.Now().Add(10*time.Second))
+	})
+
+	e.pushConnected.Store(true)
+	log.Printf("✓ Receiving task notifications over WebSocket")
+
+	// Tasks may have been created while the socket was down
+	e.notify()
+
+	for {
+		var event struct {
+			Type string `json:"type"`
+		}
+		if err := conn.ReadJSON(&event); err != nil {
+			return true, err
+		}
+		_ = conn.SetReadDeadline(time.Now().Add(taskSocketReadTimeout))
+
+		// Fetch the pending tasks instead of trusting the payload, so
+		// duplicate or stale notifications are harmless
+		if event.Type == taskAvailableEvent {
+			e.notify()
+		}
+	}
+}
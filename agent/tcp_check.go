@@ -0,0 +1,128 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"regexp"
+	"strings"
+	"time"
+
+	"evalgo.org/graphium/models"
+)
+
+// defaultBannerBytes is how much of a service's greeting a TCP port check
+// reads unless the task says otherwise.
+const defaultBannerBytes = 256
+
+// executeTCPPortCheck checks that a TCP port accepts connections and reports
+// the connect latency. With ExpectBanner set, it also reads the first bytes
+// the service sends and matches them. Cancelling ctx aborts the connect and
+// the read.
+func (e *TaskExecutor) executeTCPPortCheck(ctx context.Context, task *models.AgentTask) (*models.TaskResult, error) {
+	var payload models.TCPPortCheckPayload
+	if err := task.GetPayloadAs(&payload); err != nil {
+		return nil, fmt.Errorf("invalid tcp-port payload: %w", err)
+	}
+	if err := payload.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid tcp-port payload: %w", err)
+	}
+
+	timeout := time.Duration(payload.Timeout) * time.Second
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	data := map[string]interface{}{
+		"address": payload.Address,
+	}
+
+	var dialer net.Dialer
+	start := time.Now()
+	conn, err := dialer.DialContext(ctx, "tcp", payload.Address)
+	latency := time.Since(start)
+	if err != nil {
+		data["error"] = err.Error()
+		return &models.TaskResult{
+			Success: false,
+			Message: fmt.Sprintf("%s is not accepting connections: %v", payload.Address, err),
+			Data:    data,
+		}, nil
+	}
+	defer conn.Close()
+
+	data["latency_ms"] = float64(latency.Microseconds()) / 1000
+	data["remote_address"] = conn.RemoteAddr().String()
+
+	if payload.ExpectBanner == "" {
+		return &models.TaskResult{
+			Success: true,
+			Message: fmt.Sprintf("%s is accepting connections (%.1f ms)", payload.Address, data["latency_ms"]),
+			Data:    data,
+		}, nil
+	}
+
+	pattern := regexp.MustCompile(payload.ExpectBanner) // Validate compiled it already
+	limit := payload.BannerBytes
+	if limit == 0 {
+		limit = defaultBannerBytes
+	}
+
+	banner, err := readBanner(ctx, conn, pattern, limit)
+	matched := pattern.Match(banner)
+	data["banner"] = strings.ToValidUTF8(string(banner), "?")
+	data["banner_matched"] = matched
+	if matched {
+		return &models.TaskResult{
+			Success: true,
+			Message: fmt.Sprintf("%s is accepting connections and its banner matches (%.1f ms)", payload.Address, data["latency_ms"]),
+			Data:    data,
+		}, nil
+	}
+
+	message := fmt.Sprintf("%s accepted the connection but its banner does not match %q", payload.Address, payload.ExpectBanner)
+	if err != nil {
+		data["error"] = err.Error()
+		message = fmt.Sprintf("%s accepted the connection but sent no matching banner: %v", payload.Address, err)
+	}
+	return &models.TaskResult{
+		Success: false,
+		Message: message,
+		Data:    data,
+	}, nil
+}
+
+// readBanner reads from conn until pattern matches, limit bytes were read,
+// the service closes the connection or ctx is done. It returns what was read
+// and the error that stopped the read, if it stopped before a match.
+func readBanner(ctx context.Context, conn net.Conn, pattern *regexp.Regexp, limit int) ([]byte, error) {
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetReadDeadline(deadline)
+	}
+	stop := context.AfterFunc(ctx, func() { _ = conn.SetReadDeadline(time.Now()) })
+	defer stop()
+
+	banner := make([]byte, 0, limit)
+	buf := make([]byte, limit)
+	for len(banner) < limit {
+		n, err := conn.Read(buf[:limit-len(banner)])
+		banner = append(banner, buf[:n]...)
+		if pattern.Match(banner) {
+			return banner, nil
+		}
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return banner, fmt.Errorf("connection closed after %d bytes", len(banner))
+			}
+			if ctx.Err() != nil {
+				return banner, fmt.Errorf("no matching banner within the timeout")
+			}
+			return banner, err
+		}
+	}
+	return banner, nil
+}
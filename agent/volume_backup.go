@@ -0,0 +1,209 @@
+package agent
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/pkg/stdcopy"
+
+	"evalgo.org/graphium/models"
+)
+
+// maxBackupStderr caps the helper's stderr kept for error messages.
+const maxBackupStderr = 64 * 1024
+
+// SetBackupDir sets the directory backup-volume tasks write archives to
+// ("" = models.DefaultBackupDir); task destinations must lie within it. It
+// must be called before Start.
+func (a *Agent) SetBackupDir(dir string) {
+	a.backupDir = dir
+}
+
+// executeBackupVolume archives a named volume to a gzipped tarball in the
+// payload's destination directory. The volume is read by a throwaway helper
+// container that mounts it read-only and streams the archive to the agent;
+// the archive only appears under its final name once it's complete.
+func (e *TaskExecutor) executeBackupVolume(ctx context.Context, task *models.AgentTask) (*models.TaskResult, error) {
+	startTime := time.Now()
+
+	var payload models.BackupVolumePayload
+	if err := task.GetPayloadAs(&payload); err != nil {
+		return nil, fmt.Errorf("invalid backup payload: %w", err)
+	}
+	if err := payload.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid backup payload: %w", err)
+	}
+
+	defaultDir := e.agent.backupDir
+	if defaultDir == "" {
+		defaultDir = models.DefaultBackupDir
+	}
+	dir, err := payload.DestinationDir(defaultDir)
+	if err != nil {
+		return nil, err
+	}
+	image := payload.HelperImage
+	if image == "" {
+		image = models.DefaultBackupHelperImage
+	}
+
+	if _, err := e.agent.docker.VolumeInspect(ctx, payload.Volume); err != nil {
+		return nil, fmt.Errorf("failed to inspect volume %s: %w", payload.Volume, err)
+	}
+	if err := e.deployer.pullImage(ctx, image, "if-not-present"); err != nil {
+		return nil, fmt.Errorf("failed to pull backup helper image %s: %w", image, err)
+	}
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		return nil, fmt.Errorf("failed to create backup directory %s: %w", dir, err)
+	}
+
+	archive := filepath.Join(dir, fmt.Sprintf("%s-%s.tar.gz", payload.Volume, startTime.Format("20060102-150405")))
+	partial := archive + ".partial"
+	file, err := os.OpenFile(partial, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create archive %s: %w", partial, err)
+	}
+	complete := false
+	defer func() {
+		file.Close()
+		if !complete {
+			os.Remove(partial)
+		}
+	}()
+
+	hash := sha256.New()
+	counter := &countingWriter{}
+	if err := e.archiveVolume(ctx, payload.Volume, image, io.MultiWriter(file, hash, counter)); err != nil {
+		return nil, err
+	}
+	if err := file.Sync(); err != nil {
+		return nil, fmt.Errorf("failed to write archive %s: %w", partial, err)
+	}
+	if err := file.Close(); err != nil {
+		return nil, fmt.Errorf("failed to write archive %s: %w", partial, err)
+	}
+	if err := os.Rename(partial, archive); err != nil {
+		return nil, fmt.Errorf("failed to finish archive %s: %w", archive, err)
+	}
+	complete = true
+
+	duration := time.Since(startTime)
+	return &models.TaskResult{
+		Success: true,
+		Message: fmt.Sprintf("Backed up volume %s to %s (%d bytes)", payload.Volume, archive, counter.n),
+		Data: map[string]interface{}{
+			"volume":       payload.Volume,
+			"archive":      archive,
+			"size_bytes":   counter.n,
+			"sha256":       hex.EncodeToString(hash.Sum(nil)),
+			"duration_ms":  duration.Milliseconds(),
+			"helper_image": image,
+		},
+	}, nil
+}
+
+// archiveVolume runs a helper container that tars and gzips the volume to
+// its stdout, and copies that to w. The helper has no network and is
+// removed afterwards, whether or not the archive succeeded.
+func (e *TaskExecutor) archiveVolume(ctx context.Context, volume, image string, w io.Writer) error {
+	docker := e.agent.docker
+
+	resp, err := docker.ContainerCreate(ctx,
+		&container.Config{
+			Image:        image,
+			Cmd:          []string{"tar", "-czf", "-", "-C", "/volume", "."},
+			AttachStdout: true,
+			AttachStderr: true,
+			Labels:       map[string]string{models.ManagedLabel: "true"},
+		},
+		&container.HostConfig{
+			NetworkMode: "none",
+			Mounts: []mount.Mount{{
+				Type:     mount.TypeVolume,
+				Source:   volume,
+				Target:   "/volume",
+				ReadOnly: true,
+			}},
+		},
+		nil, nil, "")
+	if err != nil {
+		return fmt.Errorf("failed to create backup helper for volume %s: %w", volume, err)
+	}
+	defer func() {
+		removeCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		_ = docker.ContainerRemove(removeCtx, resp.ID, container.RemoveOptions{Force: true})
+	}()
+
+	attach, err := docker.ContainerAttach(ctx, resp.ID, container.AttachOptions{
+		Stream: true,
+		Stdout: true,
+		Stderr: true,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to attach to backup helper: %w", err)
+	}
+	defer attach.Close()
+
+	// Unblock the copy below if the task is cancelled
+	stop := context.AfterFunc(ctx, attach.Close)
+	defer stop()
+
+	if err := docker.ContainerStart(ctx, resp.ID, container.StartOptions{}); err != nil {
+		return fmt.Errorf("failed to start backup helper: %w", err)
+	}
+
+	stderr := &cappedBuffer{limit: maxBackupStderr}
+	if _, err := stdcopy.StdCopy(w, stderr, attach.Reader); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return fmt.Errorf("failed to read archive of volume %s: %w", volume, err)
+	}
+
+	statusCh, errCh := docker.ContainerWait(ctx, resp.ID, container.WaitConditionNotRunning)
+	select {
+	case err := <-errCh:
+		return fmt.Errorf("failed to wait for backup helper: %w", err)
+	case status := <-statusCh:
+		if status.StatusCode != 0 {
+			return fmt.Errorf("backup helper exited with code %d: %s", status.StatusCode, bytes.TrimSpace(stderr.Bytes()))
+		}
+	}
+	return nil
+}
+
+// countingWriter counts the bytes written to it.
+type countingWriter struct {
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	c.n += int64(len(p))
+	return len(p), nil
+}
+
+// cappedBuffer keeps the first limit bytes written to it and drops the rest.
+type cappedBuffer struct {
+	bytes.Buffer
+	limit     int
+	truncated bool // Whether bytes were dropped
+}
+
+func (c *cappedBuffer) Write(p []byte) (int, error) {
+	room := max(c.limit-c.Len(), 0)
+	c.Buffer.Write(p[:min(room, len(p))])
+	if len(p) > room {
+		c.truncated = true
+	}
+	return len(p), nil
+}
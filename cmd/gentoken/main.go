@@ -1,6 +1,7 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"time"
 
@@ -11,9 +12,10 @@ func main() {
 	// Use the agent secret from config.yaml
 	agentSecret := "change-me-in-production-use-different-secret-for-agents"
 	hostID := "localhost-docker"
-	expiration := 8760 * time.Hour // 1 year
+	expiration := flag.Duration("expiration", 168*time.Hour, "token lifetime (security.agent_token_expiration defaults to 7 days)")
+	flag.Parse()
 
-	token, err := auth.GenerateAgentToken(agentSecret, hostID, expiration)
+	token, err := auth.GenerateAgentToken(agentSecret, hostID, *expiration)
 	if err != nil {
 		panic(err)
 	}
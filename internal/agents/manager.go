@@ -138,7 +138,7 @@ func (m *Manager) StartAgent(configID string) error {
 	agentToken, err := auth.GenerateAgentToken(
 		m.config.Security.AgentTokenSecret,
 		cfg.HostID,
-		365*24*time.Hour, // 1 year token for managed agents
+		m.config.Security.AgentTokenExpiration,
 	)
 	if err != nil {
 		return fmt.Errorf("failed to generate agent token: %w", err)
@@ -425,3 +425,16 @@ func (m *Manager) GetAgentHTTPURL(hostID string) string {
 
 	return ""
 }
+
+// HasRunningAgent reports whether a managed agent is running for a host.
+func (m *Manager) HasRunningAgent(hostID string) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, agent := range m.agents {
+		if agent.Config.HostID == hostID && agent.State.Status == "running" {
+			return true
+		}
+	}
+	return false
+}
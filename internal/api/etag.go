@@ -0,0 +1,75 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	headerETag        = "ETag"
+	headerIfNoneMatch = "If-None-Match"
+)
+
+// revisionETag returns the strong ETag of a document with CouchDB revision
+// rev, or "" when the revision is unknown.
+func revisionETag(rev string) string {
+	if rev == "" {
+		return ""
+	}
+	return `"` + rev + `"`
+}
+
+// docRevision identifies a document version in a list ETag.
+type docRevision struct {
+	ID  string
+	Rev string
+}
+
+// listETag returns a weak ETag for a page of documents from their IDs and
+// revisions and the total number of matches. The JSON body differs only when
+// one of them does.
+func listETag(total int, docs []docRevision) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%d\n", total)
+	for _, doc := range docs {
+		fmt.Fprintf(h, "%s %s\n", doc.ID, doc.Rev)
+	}
+	return `W/"` + hex.EncodeToString(h.Sum(nil))[:32] + `"`
+}
+
+// etagMatches reports whether the If-None-Match header value matches etag,
+// using the weak comparison of RFC 9110.
+func etagMatches(ifNoneMatch, etag string) bool {
+	if ifNoneMatch == "" || etag == "" {
+		return false
+	}
+	if strings.TrimSpace(ifNoneMatch) == "*" {
+		return true
+	}
+	want := strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		if strings.TrimPrefix(strings.TrimSpace(candidate), "W/") == want {
+			return true
+		}
+	}
+	return false
+}
+
+// notModified sets the ETag header and reports whether the client's cached
+// copy is current. Callers return c.NoContent(http.StatusNotModified) then.
+func notModified(c echo.Context, etag string) bool {
+	if etag == "" {
+		return false
+	}
+	c.Response().Header().Set(headerETag, etag)
+	method := c.Request().Method
+	if method != http.MethodGet && method != http.MethodHead {
+		return false
+	}
+	return etagMatches(c.Request().Header.Get(headerIfNoneMatch), etag)
+}
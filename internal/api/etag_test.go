@@ -0,0 +1,74 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+)
+
+func TestEtagMatches(t *testing.T) {
+	tests := []struct {
+		name        string
+		ifNoneMatch string
+		etag        string
+		want        bool
+	}{
+		{"no header", "", `"3-abc"`, false},
+		{"no etag", `"3-abc"`, "", false},
+		{"same revision", `"3-abc"`, `"3-abc"`, true},
+		{"older revision", `"2-def"`, `"3-abc"`, false},
+		{"one of several", `"1-x", "3-abc"`, `"3-abc"`, true},
+		{"weak comparison", `W/"ff00"`, `W/"ff00"`, true},
+		{"weak against strong", `W/"3-abc"`, `"3-abc"`, true},
+		{"any", "*", `"3-abc"`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := etagMatches(tt.ifNoneMatch, tt.etag); got != tt.want {
+				t.Errorf("etagMatches(%q, %q) = %v, want %v", tt.ifNoneMatch, tt.etag, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestListETag(t *testing.T) {
+	page := []docRevision{{ID: "a", Rev: "1-x"}, {ID: "b", Rev: "4-y"}}
+	etag := listETag(2, page)
+
+	if etag != listETag(2, []docRevision{{ID: "a", Rev: "1-x"}, {ID: "b", Rev: "4-y"}}) {
+		t.Error("Expected the same page to have the same ETag")
+	}
+	if etag == listETag(2, []docRevision{{ID: "a", Rev: "1-x"}, {ID: "b", Rev: "5-z"}}) {
+		t.Error("Expected a new revision to change the ETag")
+	}
+	if etag == listETag(3, page) {
+		t.Error("Expected a new total to change the ETag")
+	}
+	if etag[:3] != `W/"` {
+		t.Errorf("Expected a weak ETag, got %s", etag)
+	}
+}
+
+func TestNotModified(t *testing.T) {
+	e := echo.New()
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/hosts/h1", nil)
+	req.Header.Set(headerIfNoneMatch, `"2-abc"`)
+	rec := httptest.NewRecorder()
+	if !notModified(e.NewContext(req, rec), revisionETag("2-abc")) {
+		t.Error("Expected a matching revision to be not modified")
+	}
+	if got := rec.Header().Get(headerETag); got != `"2-abc"` {
+		t.Errorf("Expected ETag \"2-abc\", got %s", got)
+	}
+
+	rec = httptest.NewRecorder()
+	if notModified(e.NewContext(req, rec), revisionETag("3-def")) {
+		t.Error("Expected a new revision to be modified")
+	}
+	if notModified(e.NewContext(req, httptest.NewRecorder()), revisionETag("")) {
+		t.Error("Expected an unknown revision to be modified")
+	}
+}
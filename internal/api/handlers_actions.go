@@ -1,12 +1,17 @@
 package api
 
 import (
+	"encoding/json"
+	"errors"
 	"net/http"
+	"slices"
+	"strings"
 	"time"
 
-	"eve.evalgo.org/semantic"
 	"github.com/labstack/echo/v4"
 
+	"evalgo.org/graphium/internal/scheduler"
+	"evalgo.org/graphium/internal/stack"
 	"evalgo.org/graphium/models"
 )
 
@@ -22,14 +27,45 @@ func (s *Server) CreateScheduledAction(c echo.Context) error {
 	if action.Name == "" {
 		return BadRequestError("Action name is required", "")
 	}
-	if action.Agent == "" {
+	if action.Agent == "" && action.Type != models.ActionTypeScale {
 		return BadRequestError("Agent (host ID) is required", "")
 	}
-	if action.Schedule == nil {
-		return BadRequestError("Schedule is required", "")
+	if action.Schedule == nil && action.DependsOn == "" {
+		return BadRequestError("Schedule is required unless the action depends on another", "")
 	}
-	if action.Schedule.RepeatFrequency == "" {
-		return BadRequestError("Schedule repeat frequency is required", "")
+	if action.Schedule != nil {
+		scheduler.NormalizeSchedule(action.Schedule)
+		if err := scheduler.ValidateSchedule(action.Schedule); err != nil {
+			return BadRequestError("Invalid schedule", err.Error())
+		}
+	}
+	if action.Condition != nil {
+		if err := action.Condition.Validate(); err != nil {
+			return BadRequestError("Invalid condition", err.Error())
+		}
+	}
+	if action.RetryPolicy != nil {
+		if err := action.RetryPolicy.Validate(); err != nil {
+			return BadRequestError("Invalid retry policy", err.Error())
+		}
+	}
+	if err := models.ValidateNotifyOn(action.NotifyOn); err != nil {
+		return BadRequestError("Invalid notifyOn", err.Error())
+	}
+	if err := s.validateChainedActions(&action); err != nil {
+		return err
+	}
+	if err := validateCheckTargets(action.Instrument); err != nil {
+		return BadRequestError("Invalid check targets", err.Error())
+	}
+	if err := validateScaleSpec(&action); err != nil {
+		return BadRequestError("Invalid scale spec", err.Error())
+	}
+	if err := validateBackupVolume(&action); err != nil {
+		return BadRequestError("Invalid backup parameters", err.Error())
+	}
+	if err := s.checkActionExec(&action); err != nil {
+		return err
 	}
 
 	// Set defaults
@@ -114,12 +150,46 @@ func (s *Server) UpdateScheduledAction(c echo.Context) error {
 	if err := c.Bind(&updates); err != nil {
 		return BadRequestError("Invalid request body", err.Error())
 	}
+	if updates.Schedule != nil {
+		scheduler.NormalizeSchedule(updates.Schedule)
+		if err := scheduler.ValidateSchedule(updates.Schedule); err != nil {
+			return BadRequestError("Invalid schedule", err.Error())
+		}
+	}
+	if updates.Condition != nil {
+		if err := updates.Condition.Validate(); err != nil {
+			return BadRequestError("Invalid condition", err.Error())
+		}
+	}
+	if updates.RetryPolicy != nil {
+		if err := updates.RetryPolicy.Validate(); err != nil {
+			return BadRequestError("Invalid retry policy", err.Error())
+		}
+	}
+	if err := models.ValidateNotifyOn(updates.NotifyOn); err != nil {
+		return BadRequestError("Invalid notifyOn", err.Error())
+	}
 
 	// Preserve system fields
 	updates.ID = existing.ID
 	updates.Rev = existing.Rev
 	updates.CreatedAt = existing.CreatedAt
 	updates.UpdatedAt = time.Now()
+	updates.Attempt = existing.Attempt
+	updates.RetryAt = existing.RetryAt
+	updates.LastOutcome = existing.LastOutcome
+
+	// Disabling an action cancels the retry of its failed run
+	if !updates.Enabled || updates.RetryPolicy == nil {
+		updates.RetryAt = nil
+	}
+
+	if err := s.validateChainedActions(&updates); err != nil {
+		return err
+	}
+	if err := validateCheckTargets(updates.Instrument); err != nil {
+		return BadRequestError("Invalid check targets", err.Error())
+	}
 
 	// Set defaults for required fields if not provided
 	if updates.Context == "" {
@@ -128,6 +198,15 @@ func (s *Server) UpdateScheduledAction(c echo.Context) error {
 	if updates.Type == "" {
 		updates.Type = existing.Type
 	}
+	if err := validateScaleSpec(&updates); err != nil {
+		return BadRequestError("Invalid scale spec", err.Error())
+	}
+	if err := validateBackupVolume(&updates); err != nil {
+		return BadRequestError("Invalid backup parameters", err.Error())
+	}
+	if err := s.checkActionExec(&updates); err != nil {
+		return err
+	}
 
 	// Update in database
 	if err := s.storage.UpdateScheduledAction(&updates); err != nil {
@@ -176,21 +255,10 @@ func (s *Server) ExecuteScheduledAction(c echo.Context) error {
 		return NotFoundError("Scheduled action", id)
 	}
 
-	// Create a task from this action
-	now := time.Now()
-	task := &models.AgentTask{
-		Context:      "https://schema.org",
-		Type:         action.Type, // Use action's @type directly (CheckAction, ControlAction, etc.)
-		ID:           models.GenerateID("task"),
-		HostID:       action.Agent,
-		ActionStatus: models.TaskStatusPending,
-		ScheduledBy:  action.ID,
-		CreatedAt:    now,
-		Agent: &semantic.SemanticAgent{
-			Type: "SoftwareApplication",
-			Name: action.Agent,
-		},
-	}
+	// Create a task from this action, using its @type directly
+	// (CheckAction, ControlAction, etc.)
+	task := newAgentTask(action.Type, "", action.Agent, "")
+	task.ScheduledBy = action.ID
 
 	// Check if this is a composite action (workflow)
 	isCompositeAction := false
@@ -227,9 +295,22 @@ func (s *Server) ExecuteScheduledAction(c echo.Context) error {
 		}
 	}
 
-	// Create the task
-	if err := s.storage.CreateTask(task); err != nil {
-		return InternalError("Failed to create task", err.Error())
+	// Spread the replicas of a scale action over the hosts
+	if action.Type == models.ActionTypeScale {
+		if s.scheduler == nil {
+			return InternalError("Failed to plan scale action", "scheduler is not running")
+		}
+		if err := s.scheduler.PlanScale(task, action); err != nil {
+			if errors.Is(err, stack.ErrInsufficientCapacity) {
+				return ConflictError("Failed to plan scale action", err.Error())
+			}
+			return BadRequestError("Failed to plan scale action", err.Error())
+		}
+	}
+
+	// Refuse tasks on protected containers and commands exec doesn't allow
+	if err := s.queueTask(task); err != nil {
+		return err
 	}
 
 	// Update action status
@@ -256,7 +337,8 @@ func (s *Server) GetScheduledActionHistory(c echo.Context) error {
 	}
 
 	// Verify action exists
-	if _, err := s.storage.GetScheduledAction(id); err != nil {
+	action, err := s.storage.GetScheduledAction(id)
+	if err != nil {
 		return NotFoundError("Scheduled action", id)
 	}
 
@@ -266,9 +348,235 @@ func (s *Server) GetScheduledActionHistory(c echo.Context) error {
 		return InternalError("Failed to get action history", err.Error())
 	}
 
+	// Each task's attempt tells retries apart from scheduled runs; retryAt
+	// is when the next attempt of a failed run is due
 	return c.JSON(http.StatusOK, map[string]interface{}{
 		"actionId": id,
 		"count":    len(tasks),
 		"tasks":    tasks,
+		"attempt":  action.Attempt,
+		"retryAt":  action.RetryAt,
+	})
+}
+
+// actionLink is a scheduled action linked to another in a chain.
+type actionLink struct {
+	ID      string `json:"id"`
+	Name    string `json:"name,omitempty"`
+	Enabled bool   `json:"enabled"`
+
+	// RunCondition is which outcomes trigger the action: on-success,
+	// on-failure or always
+	RunCondition string `json:"runCondition"`
+}
+
+// GetScheduledActionDependencies handles GET /api/v1/actions/:id/dependencies
+// Returns the actions whose runs trigger this action (upstream) and the
+// actions its runs trigger (downstream), through dependsOn as well as
+// onSuccess and onFailure links
+func (s *Server) GetScheduledActionDependencies(c echo.Context) error {
+	id := c.Param("id")
+	if id == "" {
+		return BadRequestError("Action ID is required", "")
+	}
+
+	action, err := s.storage.GetScheduledAction(id)
+	if err != nil {
+		return NotFoundError("Scheduled action", id)
+	}
+	actions, err := s.storage.ListScheduledActions(nil)
+	if err != nil {
+		return InternalError("Failed to get actions", err.Error())
+	}
+	byID := make(map[string]*models.ScheduledAction, len(actions))
+	for _, other := range actions {
+		byID[other.ID] = other
+	}
+	link := func(linkedID, runCondition string) actionLink {
+		l := actionLink{ID: linkedID, RunCondition: runCondition}
+		if linked := byID[linkedID]; linked != nil {
+			l.Name = linked.Name
+			l.Enabled = linked.Enabled
+		}
+		return l
+	}
+	runCondition := func(condition string) string {
+		if condition == "" {
+			return models.RunOnSuccess
+		}
+		return condition
+	}
+
+	upstream := []actionLink{}
+	downstream := []actionLink{}
+	if action.DependsOn != "" {
+		upstream = append(upstream, link(action.DependsOn, runCondition(action.RunCondition)))
+	}
+	if action.OnSuccess != "" {
+		downstream = append(downstream, link(action.OnSuccess, models.RunOnSuccess))
+	}
+	if action.OnFailure != "" {
+		downstream = append(downstream, link(action.OnFailure, models.RunOnFailure))
+	}
+	for _, other := range actions {
+		switch id {
+		case other.OnSuccess:
+			upstream = append(upstream, link(other.ID, models.RunOnSuccess))
+		case other.OnFailure:
+			upstream = append(upstream, link(other.ID, models.RunOnFailure))
+		}
+		if other.DependsOn == id {
+			downstream = append(downstream, link(other.ID, runCondition(other.RunCondition)))
+		}
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"actionId":   id,
+		"upstream":   upstream,
+		"downstream": downstream,
 	})
 }
+
+// validateChainedActions checks that the OnSuccess and OnFailure actions of
+// an action exist and that it doesn't trigger itself.
+func (s *Server) validateChainedActions(action *models.ScheduledAction) error {
+	fieldErrors := make(map[string]string)
+	links := map[string]string{"onSuccess": action.OnSuccess, "onFailure": action.OnFailure, "dependsOn": action.DependsOn}
+	for field, id := range links {
+		if id == "" {
+			continue
+		}
+		if id == action.ID {
+			fieldErrors[field] = "An action cannot trigger itself"
+			continue
+		}
+		if _, err := s.storage.GetScheduledAction(id); err != nil {
+			fieldErrors[field] = "Scheduled action " + id + " not found"
+		}
+	}
+	if err := models.ValidateRunCondition(action.RunCondition); err != nil {
+		fieldErrors["runCondition"] = err.Error()
+	}
+	if len(fieldErrors) > 0 {
+		return ValidationError("Invalid action chain", fieldErrors)
+	}
+
+	// Nothing triggers a new action yet, so only updates can close a cycle
+	if action.ID == "" {
+		return nil
+	}
+	actions, err := s.storage.ListScheduledActions(nil)
+	if err != nil {
+		return InternalError("Failed to check action chain", err.Error())
+	}
+	actions = slices.DeleteFunc(actions, func(other *models.ScheduledAction) bool { return other.ID == action.ID })
+	if cycle := models.FindActionCycle(append(actions, action), action.ID); cycle != nil {
+		return ValidationError("Invalid action chain", map[string]string{
+			"chain": "Action chain cycle: " + strings.Join(cycle, " -> "),
+		})
+	}
+	return nil
+}
+
+// validateCheckTargets checks the targets and quorum of a multi-target
+// health check in an action's instrument, if it has any.
+func validateCheckTargets(instrument map[string]interface{}) error {
+	if _, ok := instrument["targets"]; !ok {
+		return nil
+	}
+	data, err := json.Marshal(instrument)
+	if err != nil {
+		return err
+	}
+	var payload models.CheckHealthPayload
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return err
+	}
+	return payload.ValidateTargets()
+}
+
+// validateScaleSpec checks the scale spec in the instrument of a scale
+// action.
+func validateScaleSpec(action *models.ScheduledAction) error {
+	if action.Type != models.ActionTypeScale {
+		return nil
+	}
+	spec, err := models.ScaleSpecFromInstrument(action.Instrument)
+	if err != nil {
+		return err
+	}
+	return spec.Validate()
+}
+
+// checkActionExec rejects actions running a command the exec policy
+// doesn't allow when they are saved, rather than when they run.
+func (s *Server) checkActionExec(action *models.ScheduledAction) error {
+	if action.Instrument == nil {
+		return nil
+	}
+	task := &models.AgentTask{Type: action.Type}
+	if composite, _ := action.Instrument["compositeAction"].(bool); composite {
+		task.Type = "WorkflowAction"
+	}
+	if err := task.SetPayload(action.Instrument); err != nil {
+		return BadRequestError("Invalid instrument", err.Error())
+	}
+	if violation := s.execPolicy.ExecViolation(task, s.storage.GetContainer); violation != "" {
+		return NewAPIError(http.StatusForbidden, "Command is not allowed", violation)
+	}
+	return nil
+}
+
+// validateBackupVolume checks the instrument of a backup-volume transfer
+// action.
+func validateBackupVolume(action *models.ScheduledAction) error {
+	if action.Type != models.ActionTypeTransfer {
+		return nil
+	}
+	payload, err := models.BackupVolumePayloadFromInstrument(action.Instrument)
+	if err != nil || payload == nil {
+		return err
+	}
+	return payload.Validate()
+}
+
+// recordActionOutcome records the outcome of a finished task on the
+// scheduled action that created it, sending its notifications, and reports
+// whether the run goes on: a failed task is retried or a scale run's next
+// step was created. Failures are logged, not returned, so they never fail
+// the status update.
+func (s *Server) recordActionOutcome(task *models.AgentTask) bool {
+	if s.scheduler == nil || task.ScheduledBy == "" {
+		return false
+	}
+
+	undecided, err := s.scheduler.RecordOutcome(task)
+	if err != nil {
+		s.logger.WithError(err).Warn("Failed to record outcome of task " + task.ID)
+		return false
+	}
+	return undecided
+}
+
+// triggerChainedAction runs the OnSuccess or OnFailure action and the
+// dependent actions of the scheduled action that created a finished task.
+// Failures are logged, not returned, so they never fail the status update.
+func (s *Server) triggerChainedAction(task *models.AgentTask) {
+	if s.scheduler == nil || task.ScheduledBy == "" {
+		return
+	}
+
+	triggered, err := s.scheduler.TriggerChained(task)
+	if err != nil {
+		s.logger.WithError(err).Warn("Failed to trigger chained action for task " + task.ID)
+	}
+
+	for _, next := range triggered {
+		s.BroadcastGraphEvent("task_created", map[string]interface{}{
+			"taskId":      next.ID,
+			"taskType":    next.Type,
+			"agentId":     next.HostID,
+			"triggeredBy": task.ID,
+		})
+	}
+}
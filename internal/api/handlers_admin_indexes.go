@@ -0,0 +1,42 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+// getIndexHealth handles GET /api/v1/admin/indexes
+// @Summary Database index health
+// @Description Verify that every CouchDB index and view Graphium relies on exists and is queryable
+// @Tags Admin
+// @Produce json
+// @Success 200 {object} storage.IndexReport
+// @Failure 503 {object} APIError "CouchDB could not be reached"
+// @Router /admin/indexes [get]
+func (s *Server) getIndexHealth(c echo.Context) error {
+	report, err := s.storage.VerifyIndexes(c.Request().Context())
+	if err != nil {
+		return NewAPIError(http.StatusServiceUnavailable, "Failed to verify indexes", err.Error())
+	}
+	return c.JSON(http.StatusOK, report)
+}
+
+// rebuildIndexes handles POST /api/v1/admin/indexes/rebuild
+// @Summary Rebuild missing database indexes
+// @Description Recreate missing CouchDB indexes and views, then verify them again
+// @Tags Admin
+// @Produce json
+// @Success 200 {object} storage.IndexReport
+// @Failure 500 {object} APIError
+// @Failure 503 {object} APIError "CouchDB could not be reached"
+// @Router /admin/indexes/rebuild [post]
+func (s *Server) rebuildIndexes(c echo.Context) error {
+	report, err := s.storage.RebuildIndexes(c.Request().Context())
+	if err != nil {
+		return InternalError("Failed to rebuild indexes", err.Error())
+	}
+
+	c.Logger().Infof("Index rebuild finished: healthy=%v missing=%v", report.Healthy, report.Missing())
+	return c.JSON(http.StatusOK, report)
+}
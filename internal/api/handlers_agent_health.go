@@ -0,0 +1,191 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"evalgo.org/graphium/models"
+)
+
+// Agent health statuses
+const (
+	AgentHealthHealthy   = "healthy"
+	AgentHealthDegraded  = "degraded"
+	AgentHealthUnhealthy = "unhealthy"
+)
+
+// agentStatusProbeTimeout bounds the reachability probe to an agent's /status endpoint
+const agentStatusProbeTimeout = 5 * time.Second
+
+// AgentHealthResponse is the composite health of a single agent.
+type AgentHealthResponse struct {
+	AgentID string `json:"agentId"`
+	HostID  string `json:"hostId"`
+
+	// Status is healthy, degraded or unhealthy
+	Status string `json:"status"`
+
+	// ProcessStatus is the agent manager's view of the process (running, stopped, failed, ...)
+	ProcessStatus string `json:"processStatus"`
+
+	// Metrics describes the freshness of the agent's last metrics report
+	Metrics AgentMetricsHealth `json:"metrics"`
+
+	// Probe is the result of probing the agent's HTTP /status endpoint
+	Probe AgentProbeHealth `json:"probe"`
+
+	CheckedAt time.Time `json:"checkedAt"`
+}
+
+// AgentMetricsHealth describes the freshness of an agent's metrics reports.
+type AgentMetricsHealth struct {
+	LastUpdate string  `json:"lastUpdate,omitempty"`
+	AgeSeconds float64 `json:"ageSeconds,omitempty"`
+
+	// MaxAgeSeconds is the age after which metrics are considered stale
+	MaxAgeSeconds float64 `json:"maxAgeSeconds"`
+	Fresh         bool    `json:"fresh"`
+}
+
+// AgentProbeHealth is the result of probing an agent's HTTP /status endpoint.
+type AgentProbeHealth struct {
+	// Enabled is false when the agent has no HTTP server or isn't running locally
+	Enabled   bool   `json:"enabled"`
+	Reachable bool   `json:"reachable"`
+	URL       string `json:"url,omitempty"`
+	LatencyMs int64  `json:"latencyMs,omitempty"`
+	Error     string `json:"error,omitempty"`
+
+	// Report is the agent's self-reported status and counters
+	Report map[string]interface{} `json:"report,omitempty"`
+}
+
+// getAgentHealth handles GET /api/v1/agents/:id/health
+// @Summary Get agent health
+// @Description Composite agent health from the freshness of its metrics reports and a probe of its HTTP /status endpoint
+// @Tags Agents
+// @Produce json
+// @Param id path string true "Agent ID (e.g., agent:host-01)"
+// @Success 200 {object} AgentHealthResponse "Agent is healthy or degraded"
+// @Failure 404 {object} APIError
+// @Failure 503 {object} AgentHealthResponse "Agent is unhealthy"
+// @Router /agents/{id}/health [get]
+func (s *Server) getAgentHealth(c echo.Context) error {
+	id := c.Param("id")
+
+	config, err := s.storage.GetAgentConfig(id)
+	if err != nil {
+		return NotFoundError("Agent", id)
+	}
+
+	state, err := s.agentManager.GetAgentState(id)
+	if err != nil {
+		return InternalError("Failed to get agent state", err.Error())
+	}
+
+	response := AgentHealthResponse{
+		AgentID:       config.ID,
+		HostID:        config.HostID,
+		ProcessStatus: state.Status,
+		Metrics:       s.agentMetricsHealth(config),
+		CheckedAt:     time.Now(),
+	}
+
+	if url := s.agentManager.GetAgentHTTPURL(config.HostID); url != "" {
+		response.Probe = probeAgentStatus(c.Request().Context(), url+"/status", s.config.Agent.HTTPAuthToken)
+	}
+
+	response.Status = compositeAgentHealth(response.Metrics, response.Probe)
+
+	code := http.StatusOK
+	if response.Status == AgentHealthUnhealthy {
+		code = http.StatusServiceUnavailable
+	}
+	return c.JSON(code, response)
+}
+
+// agentMetricsHealth checks the host's last metrics report against the agent's sync interval.
+// Agents report metrics once per sync interval; three missed reports count as stale.
+func (s *Server) agentMetricsHealth(config *models.AgentConfig) AgentMetricsHealth {
+	interval := time.Duration(config.SyncInterval) * time.Second
+	if interval <= 0 {
+		interval = 30 * time.Second
+	}
+	maxAge := 3 * interval
+
+	health := AgentMetricsHealth{MaxAgeSeconds: maxAge.Seconds()}
+
+	host, err := s.storage.GetHost(config.HostID)
+	if err != nil || host.LastMetricsUpdate == "" {
+		return health
+	}
+	health.LastUpdate = host.LastMetricsUpdate
+
+	updated, err := time.Parse(time.RFC3339, host.LastMetricsUpdate)
+	if err != nil {
+		return health
+	}
+
+	age := time.Since(updated)
+	health.AgeSeconds = age.Seconds()
+	health.Fresh = age <= maxAge
+	return health
+}
+
+// probeAgentStatus requests an agent's /status endpoint, sending token as a
+// bearer token when the agent HTTP server requires one.
+func probeAgentStatus(ctx context.Context, url, token string) AgentProbeHealth {
+	probe := AgentProbeHealth{Enabled: true, URL: url}
+
+	ctx, cancel := context.WithTimeout(ctx, agentStatusProbeTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		probe.Error = err.Error()
+		return probe
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	start := time.Now()
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		probe.Error = err.Error()
+		return probe
+	}
+	defer resp.Body.Close()
+	probe.LatencyMs = time.Since(start).Milliseconds()
+
+	if err := json.NewDecoder(resp.Body).Decode(&probe.Report); err != nil {
+		probe.Error = "invalid status response: " + err.Error()
+		return probe
+	}
+
+	// The agent answers 503 when it is up but can't reach Docker
+	probe.Reachable = resp.StatusCode == http.StatusOK
+	if !probe.Reachable {
+		probe.Error = "agent reported " + resp.Status
+	}
+	return probe
+}
+
+// compositeAgentHealth combines metrics freshness and the status probe.
+// Without an HTTP endpoint, fresh metrics alone mean healthy.
+func compositeAgentHealth(metrics AgentMetricsHealth, probe AgentProbeHealth) string {
+	switch {
+	case !probe.Enabled && metrics.Fresh:
+		return AgentHealthHealthy
+	case probe.Enabled && probe.Reachable && metrics.Fresh:
+		return AgentHealthHealthy
+	case metrics.Fresh || (probe.Enabled && (probe.Reachable || probe.Report != nil)):
+		return AgentHealthDegraded
+	default:
+		return AgentHealthUnhealthy
+	}
+}
@@ -0,0 +1,96 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCompositeAgentHealth(t *testing.T) {
+	tests := []struct {
+		name    string
+		metrics AgentMetricsHealth
+		probe   AgentProbeHealth
+		want    string
+	}{
+		{
+			name:    "fresh metrics without http endpoint",
+			metrics: AgentMetricsHealth{Fresh: true},
+			want:    AgentHealthHealthy,
+		},
+		{
+			name:    "fresh metrics and reachable probe",
+			metrics: AgentMetricsHealth{Fresh: true},
+			probe:   AgentProbeHealth{Enabled: true, Reachable: true},
+			want:    AgentHealthHealthy,
+		},
+		{
+			name:    "fresh metrics but probe fails",
+			metrics: AgentMetricsHealth{Fresh: true},
+			probe:   AgentProbeHealth{Enabled: true},
+			want:    AgentHealthDegraded,
+		},
+		{
+			name:  "stale metrics but probe reachable",
+			probe: AgentProbeHealth{Enabled: true, Reachable: true},
+			want:  AgentHealthDegraded,
+		},
+		{
+			name: "stale metrics without http endpoint",
+			want: AgentHealthUnhealthy,
+		},
+		{
+			name:  "stale metrics and probe fails",
+			probe: AgentProbeHealth{Enabled: true},
+			want:  AgentHealthUnhealthy,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := compositeAgentHealth(tt.metrics, tt.probe); got != tt.want {
+				t.Errorf("compositeAgentHealth() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProbeAgentStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Header.Get("Authorization") != "Bearer agent-secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"error":"unauthorized"}`))
+			return
+		}
+		if r.URL.Query().Get("docker") == "down" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_, _ = w.Write([]byte(`{"status":"degraded","dockerReachable":false}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"status":"healthy","syncCount":42}`))
+	}))
+	defer server.Close()
+
+	probe := probeAgentStatus(context.Background(), server.URL+"/status", "agent-secret")
+	if !probe.Reachable {
+		t.Fatalf("Expected agent to be reachable, got error %q", probe.Error)
+	}
+	if probe.Report["syncCount"] != float64(42) {
+		t.Errorf("Expected syncCount 42 in report, got %v", probe.Report["syncCount"])
+	}
+
+	probe = probeAgentStatus(context.Background(), server.URL+"/status?docker=down", "agent-secret")
+	if probe.Reachable {
+		t.Error("Expected agent without Docker to be reported as not reachable")
+	}
+	if probe.Report == nil {
+		t.Error("Expected the degraded report to be kept")
+	}
+
+	probe = probeAgentStatus(context.Background(), server.URL+"/status", "")
+	if probe.Reachable {
+		t.Error("Expected probe without the agent token to be rejected")
+	}
+}
@@ -0,0 +1,145 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"evalgo.org/graphium/internal/auth"
+	"evalgo.org/graphium/models"
+)
+
+// defaultAgentLogsTail is how many lines GET /agents/:id/logs returns
+// without a tail parameter.
+const defaultAgentLogsTail = 200
+
+// AgentLogsRequest is the body of POST /agents/:id/logs.
+type AgentLogsRequest struct {
+	Lines []models.AgentLogLine `json:"lines"`
+}
+
+// AgentLogsResponse is the response of GET /agents/:id/logs.
+type AgentLogsResponse struct {
+	HostID    string                `json:"hostId"`
+	UpdatedAt time.Time             `json:"updatedAt"`
+	Count     int                   `json:"count"`
+	Lines     []models.AgentLogLine `json:"lines"`
+}
+
+// getAgentLogs handles GET /api/v1/agents/:id/logs
+// @Summary Get agent logs
+// @Description Get the most recent log output shipped by the agent of a host. Agents only ship logs when agent.ship_logs is enabled; secrets are redacted and the server keeps the last 1000 lines.
+// @Tags Agents
+// @Produce json
+// @Param id path string true "Host ID of the agent"
+// @Param tail query int false "Number of most recent lines to return (default: 200, max: 1000)"
+// @Success 200 {object} AgentLogsResponse
+// @Failure 400 {object} APIError
+// @Failure 500 {object} APIError
+// @Router /agents/{id}/logs [get]
+func (s *Server) getAgentLogs(c echo.Context) error {
+	hostID := c.Param("id")
+
+	tail := defaultAgentLogsTail
+	if raw := c.QueryParam("tail"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 1 {
+			return BadRequestError("Invalid tail", "tail must be a positive integer")
+		}
+		tail = parsed
+	}
+	if tail > models.AgentLogsLimit {
+		tail = models.AgentLogsLimit
+	}
+
+	logs, err := s.storage.GetAgentLogs(hostID)
+	if err != nil {
+		return InternalError("Failed to get agent logs", err.Error())
+	}
+
+	lines := logs.Tail(tail)
+	return c.JSON(http.StatusOK, AgentLogsResponse{
+		HostID:    hostID,
+		UpdatedAt: logs.UpdatedAt,
+		Count:     len(lines),
+		Lines:     lines,
+	})
+}
+
+// appendAgentLogs handles POST /api/v1/agents/:id/logs
+// @Summary Ship agent logs
+// @Description Append log lines to the shipped logs of a host's agent. Called by the agent. Lines are redacted again and only the last 1000 are kept.
+// @Tags Agents
+// @Accept json
+// @Produce json
+// @Param id path string true "Host ID of the agent"
+// @Param lines body AgentLogsRequest true "Log lines, oldest first"
+// @Success 200 {object} map[string]interface{} "Number of stored lines"
+// @Failure 400 {object} APIError
+// @Failure 404 {object} APIError
+// @Failure 500 {object} APIError
+// @Router /agents/{id}/logs [post]
+func (s *Server) appendAgentLogs(c echo.Context) error {
+	hostID := c.Param("id")
+
+	if _, err := s.storage.GetHost(hostID); err != nil {
+		return NotFoundError("Host", hostID)
+	}
+
+	var req AgentLogsRequest
+	if err := c.Bind(&req); err != nil {
+		return BadRequestError("Invalid request body", "Failed to parse JSON: "+err.Error())
+	}
+	if len(req.Lines) > models.AgentLogsLimit {
+		req.Lines = req.Lines[len(req.Lines)-models.AgentLogsLimit:]
+	}
+
+	logs, err := s.storage.AppendAgentLogs(hostID, req.Lines)
+	if err != nil {
+		return InternalError("Failed to save agent logs", err.Error())
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"hostId":   hostID,
+		"received": len(req.Lines),
+		"stored":   len(logs.Lines),
+	})
+}
+
+// flushAgentLogs handles POST /api/v1/agents/:id/logs/flush
+// @Summary Flush agent logs
+// @Description Queue a task asking the host's agent to ship its buffered log lines now, instead of waiting for the next periodic flush. The task fails if the agent doesn't have log shipping enabled.
+// @Tags Agents
+// @Produce json
+// @Param id path string true "Host ID of the agent"
+// @Success 202 {object} models.AgentTask "Queued flush task"
+// @Failure 404 {object} APIError
+// @Failure 500 {object} APIError
+// @Router /agents/{id}/logs/flush [post]
+func (s *Server) flushAgentLogs(c echo.Context) error {
+	hostID := c.Param("id")
+
+	if _, err := s.storage.GetHost(hostID); err != nil {
+		return NotFoundError("Host", hostID)
+	}
+
+	createdBy := ""
+	if userID, ok := auth.GetUserID(c); ok {
+		createdBy = userID
+	}
+
+	task := newAgentTask("TransferAction", "Ship agent logs of "+hostID, hostID, createdBy)
+	if err := task.SetPayload(map[string]interface{}{
+		"action": models.TransferShipLogs,
+	}); err != nil {
+		return InternalError("Failed to encode flush payload", err.Error())
+	}
+
+	if err := s.queueTask(task); err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusAccepted, task)
+}
@@ -12,13 +12,13 @@ import (
 )
 
 // @Summary Get agent tasks
-// @Description Get pending tasks for a specific agent
+// @Description Get pending tasks for a specific agent, highest priority and oldest first
 // @Tags Agent Tasks
 // @Accept json
 // @Produce json
 // @Param id path string true "Agent ID"
 // @Param status query string false "Filter by status (pending, assigned, running, completed, failed)"
-// @Param limit query int false "Maximum number of tasks to return (default: 10)"
+// @Param limit query int false "Maximum number of tasks to return (default: all)"
 // @Success 200 {array} models.AgentTask "List of tasks"
 // @Failure 400 {object} ErrorResponse
 // @Failure 401 {object} ErrorResponse "Unauthorized"
@@ -96,18 +96,19 @@ func (s *Server) updateTaskStatus(c echo.Context) error {
 
 	// Validate status
 	validStatuses := map[string]bool{
-		"pending":   true,
-		"assigned":  true,
-		"running":   true,
-		"completed": true,
-		"failed":    true,
-		"cancelled": true,
+		"pending":     true,
+		"assigned":    true,
+		"running":     true,
+		"completed":   true,
+		"failed":      true,
+		"cancelled":   true,
+		"interrupted": true,
 	}
 
 	if !validStatuses[update.Status] {
 		return c.JSON(http.StatusBadRequest, ErrorResponse{
 			Error:   "invalid status",
-			Details: "status must be one of: pending, assigned, running, completed, failed, cancelled",
+			Details: "status must be one of: pending, assigned, running, completed, failed, cancelled, interrupted",
 		})
 	}
 
@@ -122,6 +123,7 @@ func (s *Server) updateTaskStatus(c echo.Context) error {
 
 	// Update task status based on the new status
 	now := time.Now()
+	status := update.Status
 
 	switch update.Status {
 	case "assigned":
@@ -169,9 +171,27 @@ func (s *Server) updateTaskStatus(c echo.Context) error {
 			}
 		}
 		task.Error.Message = update.Error
+
+	case "interrupted":
+		// The agent stopped before the task finished; queue it again
+		// unless it ran out of retries
+		if task.Requeue(update.Error) {
+			status = task.ActionStatus
+			break
+		}
+		status = "failed"
+		if task.EndTime == nil {
+			task.EndTime = &now
+		}
+		if task.Error == nil {
+			task.Error = &semantic.SemanticError{
+				Type: "Error",
+			}
+		}
+		task.Error.Message = update.Error
 	}
 
-	task.ActionStatus = update.Status
+	task.ActionStatus = status
 
 	// Update task in database
 	if err := s.storage.UpdateTask(task); err != nil {
@@ -189,6 +209,16 @@ func (s *Server) updateTaskStatus(c echo.Context) error {
 		"stackId": task.StackID,
 	})
 
+	if update.Status == "completed" {
+		s.recordRedeployedContainer(task)
+	}
+
+	// Retry a failed task of the scheduled action, or run its
+	// OnSuccess/OnFailure action once the run is decided
+	if !s.recordActionOutcome(task) {
+		s.triggerChainedAction(task)
+	}
+
 	return c.JSON(http.StatusOK, task)
 }
 
@@ -283,13 +313,14 @@ func (s *Server) getTaskStatistics(c echo.Context) error {
 }
 
 // @Summary Retry a failed task
-// @Description Create a new task based on a failed task
+// @Description Create a new task based on a failed task. The new task must pass the protection and exec policies in force now.
 // @Tags Agent Tasks
 // @Accept json
 // @Produce json
 // @Param id path string true "Task ID"
 // @Success 200 {object} models.AgentTask "New retry task"
 // @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} APIError "Container is protected or command is not allowed"
 // @Failure 404 {object} ErrorResponse "Task not found"
 // @Failure 500 {object} ErrorResponse
 // @Router /tasks/{id}/retry [post]
@@ -301,7 +332,15 @@ func (s *Server) retryTask(c echo.Context) error {
 		})
 	}
 
-	newTask, err := s.storage.RetryTask(taskID)
+	task, err := s.storage.GetTask(taskID)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, ErrorResponse{
+			Error:   "task not found",
+			Details: err.Error(),
+		})
+	}
+
+	newTask, err := task.Retry()
 	if err != nil {
 		return c.JSON(http.StatusBadRequest, ErrorResponse{
 			Error:   "failed to retry task",
@@ -309,6 +348,12 @@ func (s *Server) retryTask(c echo.Context) error {
 		})
 	}
 
+	// The retry is a new task: the policies in force now apply, not those
+	// the original task passed
+	if err := s.queueTask(newTask); err != nil {
+		return err
+	}
+
 	return c.JSON(http.StatusOK, newTask)
 }
 
@@ -379,6 +424,11 @@ func (s *Server) createTask(c echo.Context) error {
 		task.Priority = 5
 	}
 
+	// Refuse tasks on protected containers and commands exec doesn't allow
+	if err := s.checkTaskProtection(&task); err != nil {
+		return err
+	}
+
 	// Create task in database
 	if err := s.storage.CreateTask(&task); err != nil {
 		return c.JSON(http.StatusInternalServerError, ErrorResponse{
@@ -398,6 +448,43 @@ func (s *Server) createTask(c echo.Context) error {
 	return c.JSON(http.StatusCreated, task)
 }
 
+// newAgentTask returns a pending task of a type for the agent on a host.
+func newAgentTask(taskType, name, hostID, createdBy string) *models.AgentTask {
+	return &models.AgentTask{
+		ID:           models.GenerateID("task"),
+		Context:      "https://schema.org",
+		Type:         taskType,
+		Name:         name,
+		ActionStatus: models.TaskStatusPending,
+		HostID:       hostID,
+		CreatedAt:    time.Now(),
+		CreatedBy:    createdBy,
+		Agent: &semantic.SemanticAgent{
+			Type: "SoftwareApplication",
+			Name: hostID,
+		},
+	}
+}
+
+// queueTask refuses tasks on protected containers and commands exec doesn't
+// allow, then stores the task and announces it.
+func (s *Server) queueTask(task *models.AgentTask) error {
+	if err := s.checkTaskProtection(task); err != nil {
+		return err
+	}
+	if err := s.storage.CreateTask(task); err != nil {
+		return InternalError("Failed to create task", err.Error())
+	}
+
+	s.BroadcastGraphEvent("task_created", map[string]interface{}{
+		"taskId":   task.ID,
+		"taskType": task.Type,
+		"agentId":  task.HostID,
+		"stackId":  task.StackID,
+	})
+	return nil
+}
+
 // TaskStatusUpdate represents a task status update request.
 type TaskStatusUpdate struct {
 	Status string             `json:"status"`
@@ -0,0 +1,32 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+
+	"evalgo.org/graphium/models"
+)
+
+func TestRetryTask_ChecksExecPolicy(t *testing.T) {
+	task := &models.AgentTask{ID: "exec-1", Type: "ControlAction", ContainerID: "c-redis"}
+	if err := task.SetPayload(map[string]interface{}{
+		"action":      models.ControlExec,
+		"containerId": "c-redis",
+		"command":     []string{"redis-cli", "FLUSHALL"},
+	}); err != nil {
+		t.Fatalf("SetPayload failed: %v", err)
+	}
+
+	retry, err := task.Retry()
+	if err != nil {
+		t.Fatalf("Retry failed: %v", err)
+	}
+
+	// exec.enabled was turned off since the task first ran
+	s := &Server{protection: &models.ProtectionPolicy{}, execPolicy: &models.ExecPolicy{}}
+	err = s.checkTaskProtection(retry)
+	apiErr, ok := err.(*APIError)
+	if !ok || apiErr.Code != http.StatusForbidden {
+		t.Errorf("Expected the retried exec task to be refused, got %v", err)
+	}
+}
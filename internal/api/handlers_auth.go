@@ -54,6 +54,18 @@ type UserResponse struct {
 	LastLoginAt *time.Time    `json:"last_login_at,omitempty"`
 }
 
+// MeResponse is the current user together with the lifetime of the token
+// used for the request, so clients can warn before it expires.
+type MeResponse struct {
+	*UserResponse
+
+	// TokenExpiresAt is when the access token expires
+	TokenExpiresAt *time.Time `json:"token_expires_at,omitempty"`
+
+	// TokenExpiresIn is the remaining token lifetime in seconds
+	TokenExpiresIn int64 `json:"token_expires_in,omitempty"`
+}
+
 // login handles POST /api/v1/auth/login
 // @Summary User login
 // @Description Authenticate user with username and password, returns JWT tokens
@@ -358,12 +370,12 @@ func (s *Server) logout(c echo.Context) error {
 
 // me handles GET /api/v1/auth/me
 // @Summary Get current user
-// @Description Get information about the currently authenticated user
+// @Description Get information about the currently authenticated user and the remaining lifetime of the access token
 // @Tags Authentication
 // @Accept json
 // @Produce json
 // @Security BearerAuth
-// @Success 200 {object} UserResponse "Current user information"
+// @Success 200 {object} MeResponse "Current user information"
 // @Failure 401 {object} APIError "Unauthorized"
 // @Failure 500 {object} APIError "Internal server error"
 // @Router /auth/me [get]
@@ -378,7 +390,16 @@ func (s *Server) me(c echo.Context) error {
 		return InternalError("Failed to get user", err.Error())
 	}
 
-	return c.JSON(http.StatusOK, toUserResponse(user))
+	resp := MeResponse{UserResponse: toUserResponse(user)}
+	if claims, ok := auth.GetClaims(c); ok && claims.ExpiresAt != nil {
+		expiresAt := claims.ExpiresAt.Time
+		resp.TokenExpiresAt = &expiresAt
+		if remaining := time.Until(expiresAt); remaining > 0 {
+			resp.TokenExpiresIn = int64(remaining.Seconds())
+		}
+	}
+
+	return c.JSON(http.StatusOK, resp)
 }
 
 // toUserResponse converts a User model to UserResponse (removes sensitive fields)
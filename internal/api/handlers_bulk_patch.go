@@ -0,0 +1,298 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	"evalgo.org/graphium/models"
+)
+
+// maxBulkPatchItems is the maximum number of documents a single bulk PATCH request may change
+const maxBulkPatchItems = 1000
+
+// immutablePatchFields are document fields a bulk PATCH must not change.
+var immutablePatchFields = []string{"@id", "_id", "_rev", "@context", "@type"}
+
+// BulkPatchItem is one document change of a bulk PATCH request.
+type BulkPatchItem struct {
+	// ID is the document to change
+	ID string `json:"id"`
+
+	// Fields are the fields to change, as a JSON merge patch (RFC 7386) of
+	// the document: fields are replaced, objects merged and fields set to
+	// null removed. Fields absent from it are left untouched
+	Fields json.RawMessage `json:"fields,omitempty" swaggertype:"object"`
+
+	// Patch is the same as Fields, kept for existing clients; an item sets
+	// one of them
+	Patch json.RawMessage `json:"patch,omitempty" swaggertype:"object"`
+}
+
+// changes returns the item's merge patch and the name of the field that
+// holds it.
+func (item *BulkPatchItem) changes() (json.RawMessage, string) {
+	if len(item.Fields) > 0 {
+		return item.Fields, "fields"
+	}
+	return item.Patch, "patch"
+}
+
+// bulkPatchContainers handles PATCH /api/v1/containers/bulk
+// @Summary Bulk patch containers
+// @Description Apply partial updates ({id, fields}, a JSON merge patch) to each of many containers in a single _bulk_docs request, e.g. to re-home containers to a renamed host. Each patch is validated against the container model; a container whose save conflicts with a concurrent update is patched again and retried once. Removing or changing a label set by Docker is refused. Results are per container, in request order.
+// @Tags Containers
+// @Accept json
+// @Produce json
+// @Param request body []BulkPatchItem true "Containers and the fields to change"
+// @Success 200 {object} BulkResponse
+// @Failure 400 {object} APIError
+// @Failure 500 {object} APIError
+// @Router /containers/bulk [patch]
+func (s *Server) bulkPatchContainers(c echo.Context) error {
+	var items []BulkPatchItem
+	if err := c.Bind(&items); err != nil {
+		return BadRequestError("Invalid request body", err.Error())
+	}
+
+	patches, err := validateBulkPatch[models.Container](items)
+	if err != nil {
+		return err
+	}
+
+	// Containers may only be moved to hosts that exist
+	fieldErrors := make(map[string]string)
+	for i, item := range items {
+		var target struct {
+			HostedOn *string `json:"hostedOn"`
+		}
+		patch, field := item.changes()
+		if json.Unmarshal(patch, &target) != nil || target.HostedOn == nil || *target.HostedOn == "" {
+			continue
+		}
+		if _, err := s.storage.GetHost(*target.HostedOn); err != nil {
+			fieldErrors[fmt.Sprintf("[%d].%s.hostedOn", i, field)] = "Host " + *target.HostedOn + " does not exist"
+		}
+	}
+	if len(fieldErrors) > 0 {
+		return ValidationError("Validation failed", fieldErrors)
+	}
+
+	changes := make(map[string][]models.FieldChange, len(items))
+	results, containers, err := s.storage.BulkPatchContainers(bulkPatchIDs(items), func(container *models.Container) error {
+		before := *container
+		if err := applyMergePatch(container, patches[container.ID]); err != nil {
+			return err
+		}
+		// Only agents report the Docker name and labels
+		container.DockerName, container.DockerLabels = before.DockerName, before.DockerLabels
+		if key := container.DockerLabelConflict(); key != "" {
+			return fmt.Errorf("label %s is set by Docker and can't be removed or changed", key)
+		}
+		if container.Name == "" {
+			return fmt.Errorf("container name is required")
+		}
+		if container.Image == "" {
+			return fmt.Errorf("container image (executableName) is required")
+		}
+
+		// Update state of the previous image reference no longer applies
+		if container.Image != before.Image && container.LatestImageDigest == before.LatestImageDigest {
+			container.LatestImageDigest = ""
+			container.ImageCheckedAt = nil
+		}
+		container.RefreshUpdateAvailable()
+		s.markProtected(container)
+
+		changes[container.ID] = models.DiffContainers(&before, container)
+		return nil
+	})
+	if err != nil {
+		return InternalError("Failed to patch containers", err.Error())
+	}
+
+	for _, container := range containers {
+		if len(changes[container.ID]) > 0 {
+			s.recordContainerChanges(c, container, changes[container.ID])
+			if healthChanged(changes[container.ID]) {
+				s.recordHealthTransition(container)
+			}
+		}
+		s.autoAssignStack(container)
+		s.observeWatches(container)
+		s.BroadcastGraphEvent(EventContainerUpdated, container)
+	}
+
+	return c.JSON(http.StatusOK, toBulkResponse(results))
+}
+
+// bulkPatchHosts handles PATCH /api/v1/hosts/bulk
+// @Summary Bulk patch hosts
+// @Description Apply partial updates ({id, fields}, a JSON merge patch) to each of many hosts in a single _bulk_docs request, e.g. to fix the datacenter of a rack of hosts. Each patch is validated against the host model; a host whose save conflicts with a concurrent update is patched again and retried once. Results are per host, in request order.
+// @Tags Hosts
+// @Accept json
+// @Produce json
+// @Param request body []BulkPatchItem true "Hosts and the fields to change"
+// @Success 200 {object} BulkResponse
+// @Failure 400 {object} APIError
+// @Failure 500 {object} APIError
+// @Router /hosts/bulk [patch]
+func (s *Server) bulkPatchHosts(c echo.Context) error {
+	var items []BulkPatchItem
+	if err := c.Bind(&items); err != nil {
+		return BadRequestError("Invalid request body", err.Error())
+	}
+
+	patches, err := validateBulkPatch[models.Host](items)
+	if err != nil {
+		return err
+	}
+
+	results, hosts, err := s.storage.BulkPatchHosts(bulkPatchIDs(items), func(host *models.Host) error {
+		if err := applyMergePatch(host, patches[host.ID]); err != nil {
+			return err
+		}
+		if host.Name == "" {
+			return fmt.Errorf("host name is required")
+		}
+		if host.IPAddress == "" {
+			return fmt.Errorf("host IP address is required")
+		}
+		return nil
+	})
+	if err != nil {
+		return InternalError("Failed to patch hosts", err.Error())
+	}
+
+	for _, host := range hosts {
+		s.BroadcastGraphEvent(EventHostUpdated, host)
+	}
+
+	return c.JSON(http.StatusOK, toBulkResponse(results))
+}
+
+// validateBulkPatch checks the items of a bulk PATCH request: unique ids and
+// fields (or patches) that are JSON objects of fields of T with values of the right
+// type, not touching the document's identity. It returns the patches by id.
+func validateBulkPatch[T any](items []BulkPatchItem) (map[string]json.RawMessage, error) {
+	if len(items) == 0 {
+		return nil, ValidationError("Validation failed", map[string]string{"items": "At least one item is required"})
+	}
+	if len(items) > maxBulkPatchItems {
+		return nil, ValidationError("Validation failed", map[string]string{
+			"items": fmt.Sprintf("At most %d items are allowed per request", maxBulkPatchItems),
+		})
+	}
+
+	patches := make(map[string]json.RawMessage, len(items))
+	fieldErrors := make(map[string]string)
+	for i, item := range items {
+		if item.ID == "" {
+			fieldErrors[fmt.Sprintf("[%d].id", i)] = "Id is required"
+			continue
+		}
+		if _, ok := patches[item.ID]; ok {
+			fieldErrors[fmt.Sprintf("[%d].id", i)] = "Duplicate id " + item.ID
+			continue
+		}
+		if len(item.Fields) > 0 && len(item.Patch) > 0 {
+			fieldErrors[fmt.Sprintf("[%d].fields", i)] = "Set fields or patch, not both"
+			continue
+		}
+		patch, field := item.changes()
+		if err := validatePatch[T](patch); err != nil {
+			fieldErrors[fmt.Sprintf("[%d].%s", i, field)] = err.Error()
+			continue
+		}
+		patches[item.ID] = patch
+	}
+	if len(fieldErrors) > 0 {
+		return nil, ValidationError("Validation failed", fieldErrors)
+	}
+	return patches, nil
+}
+
+// validatePatch checks that a merge patch is a non-empty JSON object whose
+// fields are fields of T with values of the right type.
+func validatePatch[T any](patch json.RawMessage) error {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(patch, &fields); err != nil || fields == nil {
+		return fmt.Errorf("patch must be a JSON object")
+	}
+	if len(fields) == 0 {
+		return fmt.Errorf("patch must change at least one field")
+	}
+	for _, field := range immutablePatchFields {
+		if _, ok := fields[field]; ok {
+			return fmt.Errorf("field %s cannot be patched", field)
+		}
+	}
+
+	var doc T
+	decoder := json.NewDecoder(bytes.NewReader(patch))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&doc); err != nil {
+		return fmt.Errorf("invalid patch: %v", err)
+	}
+	return nil
+}
+
+// applyMergePatch applies a JSON merge patch (RFC 7386) to a document.
+func applyMergePatch[T any](doc *T, patch json.RawMessage) error {
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return fmt.Errorf("failed to encode document: %w", err)
+	}
+	var target map[string]interface{}
+	if err := json.Unmarshal(data, &target); err != nil {
+		return fmt.Errorf("failed to encode document: %w", err)
+	}
+	var changes map[string]interface{}
+	if err := json.Unmarshal(patch, &changes); err != nil {
+		return fmt.Errorf("patch must be a JSON object")
+	}
+
+	merged, err := json.Marshal(mergePatch(target, changes))
+	if err != nil {
+		return fmt.Errorf("failed to apply patch: %w", err)
+	}
+	var patched T
+	if err := json.Unmarshal(merged, &patched); err != nil {
+		return fmt.Errorf("failed to apply patch: %w", err)
+	}
+	*doc = patched
+	return nil
+}
+
+// mergePatch merges patch into target: null removes a field, objects are
+// merged recursively and any other value replaces the field.
+func mergePatch(target, patch map[string]interface{}) map[string]interface{} {
+	if target == nil {
+		target = make(map[string]interface{}, len(patch))
+	}
+	for key, value := range patch {
+		if value == nil {
+			delete(target, key)
+			continue
+		}
+		if object, ok := value.(map[string]interface{}); ok {
+			existing, _ := target[key].(map[string]interface{})
+			target[key] = mergePatch(existing, object)
+			continue
+		}
+		target[key] = value
+	}
+	return target
+}
+
+// bulkPatchIDs returns the ids of bulk PATCH items in request order.
+func bulkPatchIDs(items []BulkPatchItem) []string {
+	ids := make([]string, len(items))
+	for i, item := range items {
+		ids[i] = item.ID
+	}
+	return ids
+}
@@ -0,0 +1,97 @@
+package api
+
+import (
+	"encoding/json"
+	"testing"
+
+	"evalgo.org/graphium/models"
+)
+
+func TestValidateBulkPatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		items   []BulkPatchItem
+		wantErr bool
+	}{
+		{name: "valid", items: []BulkPatchItem{
+			{ID: "a", Patch: json.RawMessage(`{"hostedOn": "host-2"}`)},
+			{ID: "b", Patch: json.RawMessage(`{"labels": {"team": null}}`)},
+		}},
+		{name: "fields", items: []BulkPatchItem{
+			{ID: "a", Fields: json.RawMessage(`{"hostedOn": "host-2"}`)},
+			{ID: "b", Fields: json.RawMessage(`{"labels": {"env": "prod"}}`)},
+		}},
+		{name: "fields and patch", items: []BulkPatchItem{
+			{ID: "a", Fields: json.RawMessage(`{"name": "web"}`), Patch: json.RawMessage(`{"name": "api"}`)},
+		}, wantErr: true},
+		{name: "invalid fields", items: []BulkPatchItem{{ID: "a", Fields: json.RawMessage(`{"name": 42}`)}}, wantErr: true},
+		{name: "no items", items: nil, wantErr: true},
+		{name: "missing id", items: []BulkPatchItem{{Patch: json.RawMessage(`{"name": "web"}`)}}, wantErr: true},
+		{name: "duplicate id", items: []BulkPatchItem{
+			{ID: "a", Patch: json.RawMessage(`{"name": "web"}`)},
+			{ID: "a", Patch: json.RawMessage(`{"name": "api"}`)},
+		}, wantErr: true},
+		{name: "not an object", items: []BulkPatchItem{{ID: "a", Patch: json.RawMessage(`["name"]`)}}, wantErr: true},
+		{name: "empty patch", items: []BulkPatchItem{{ID: "a", Patch: json.RawMessage(`{}`)}}, wantErr: true},
+		{name: "unknown field", items: []BulkPatchItem{{ID: "a", Patch: json.RawMessage(`{"hostname": "x"}`)}}, wantErr: true},
+		{name: "wrong type", items: []BulkPatchItem{{ID: "a", Patch: json.RawMessage(`{"name": 42}`)}}, wantErr: true},
+		{name: "identity", items: []BulkPatchItem{{ID: "a", Patch: json.RawMessage(`{"@id": "b"}`)}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := validateBulkPatch[models.Container](tt.items)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateBulkPatch() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateBulkPatch_ReturnsFields(t *testing.T) {
+	patches, err := validateBulkPatch[models.Container]([]BulkPatchItem{
+		{ID: "a", Fields: json.RawMessage(`{"hostedOn": "host-2"}`)},
+		{ID: "b", Patch: json.RawMessage(`{"name": "api"}`)},
+	})
+	if err != nil {
+		t.Fatalf("validateBulkPatch() failed: %v", err)
+	}
+	if string(patches["a"]) != `{"hostedOn": "host-2"}` || string(patches["b"]) != `{"name": "api"}` {
+		t.Errorf("Unexpected patches %s", patches)
+	}
+}
+
+func TestApplyMergePatch(t *testing.T) {
+	container := &models.Container{
+		ID:       "c1",
+		Rev:      "3-abc",
+		Name:     "web",
+		Image:    "nginx:1.27",
+		HostedOn: "host-1",
+		Labels:   map[string]string{"team": "web", "tier": "frontend"},
+	}
+
+	patch := json.RawMessage(`{"hostedOn": "host-2", "labels": {"team": null, "env": "prod"}}`)
+	if err := applyMergePatch(container, patch); err != nil {
+		t.Fatalf("applyMergePatch() failed: %v", err)
+	}
+
+	if container.HostedOn != "host-2" {
+		t.Errorf("Expected hostedOn host-2, got %q", container.HostedOn)
+	}
+	if container.ID != "c1" || container.Rev != "3-abc" || container.Name != "web" {
+		t.Errorf("Expected unpatched fields to be kept, got %+v", container)
+	}
+	want := map[string]string{"tier": "frontend", "env": "prod"}
+	if len(container.Labels) != len(want) || container.Labels["tier"] != "frontend" || container.Labels["env"] != "prod" {
+		t.Errorf("Expected labels %v, got %v", want, container.Labels)
+	}
+
+	// null removes a field
+	if err := applyMergePatch(container, json.RawMessage(`{"labels": null}`)); err != nil {
+		t.Fatalf("applyMergePatch() failed: %v", err)
+	}
+	if container.Labels != nil {
+		t.Errorf("Expected labels to be removed, got %v", container.Labels)
+	}
+}
@@ -0,0 +1,263 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"evalgo.org/graphium/internal/auth"
+)
+
+// maxBulkActionIDs is the maximum number of containers a single bulk
+// start/stop/restart request may control
+const maxBulkActionIDs = 500
+
+// Container control actions of the bulk endpoints.
+const (
+	bulkActionStart   = "start"
+	bulkActionStop    = "stop"
+	bulkActionRestart = "restart"
+)
+
+// BulkContainerActionRequest is the request body of POST
+// /api/v1/containers/bulk/{start,stop,restart}.
+type BulkContainerActionRequest struct {
+	// IDs are the containers to control
+	IDs []string `json:"ids"`
+
+	// Timeout is the stop timeout in seconds for stop and restart (0 uses
+	// the container's stop grace period)
+	Timeout int `json:"timeout,omitempty"`
+}
+
+// BulkContainerActionResult is the outcome of one container of a bulk
+// control request.
+type BulkContainerActionResult struct {
+	ID      string `json:"id"`
+	HostID  string `json:"hostId,omitempty"`
+	TaskID  string `json:"taskId,omitempty"`
+	Error   string `json:"error,omitempty"`
+	Success bool   `json:"success"`
+}
+
+// BulkContainerActionResponse is the response of a bulk control request.
+type BulkContainerActionResponse struct {
+	// Action is start, stop or restart
+	Action string `json:"action"`
+
+	Total   int `json:"total"`
+	Success int `json:"success"`
+	Failed  int `json:"failed"`
+
+	// TaskIDs are the queued control tasks, to poll with GET /tasks/:id
+	TaskIDs []string `json:"taskIds"`
+
+	// Results are the per-container outcomes in request order
+	Results []BulkContainerActionResult `json:"results"`
+
+	// Details describe every container no task was queued for
+	Details []string `json:"details,omitempty"`
+}
+
+// bulkStartContainers handles POST /api/v1/containers/bulk/start
+// @Summary Bulk start containers
+// @Description Queue a start control task for each container on the agent of its host. Containers that don't exist, aren't placed, are protected or whose host has no running agent are reported in details.
+// @Tags Containers
+// @Accept json
+// @Produce json
+// @Param request body BulkContainerActionRequest true "Containers to start"
+// @Success 202 {object} BulkContainerActionResponse
+// @Failure 400 {object} APIError
+// @Router /containers/bulk/start [post]
+func (s *Server) bulkStartContainers(c echo.Context) error {
+	return s.bulkContainerAction(c, bulkActionStart)
+}
+
+// bulkStopContainers handles POST /api/v1/containers/bulk/stop
+// @Summary Bulk stop containers
+// @Description Queue a stop control task for each container on the agent of its host. Containers that don't exist, aren't placed, are protected or whose host has no running agent are reported in details.
+// @Tags Containers
+// @Accept json
+// @Produce json
+// @Param request body BulkContainerActionRequest true "Containers to stop"
+// @Success 202 {object} BulkContainerActionResponse
+// @Failure 400 {object} APIError
+// @Router /containers/bulk/stop [post]
+func (s *Server) bulkStopContainers(c echo.Context) error {
+	return s.bulkContainerAction(c, bulkActionStop)
+}
+
+// bulkRestartContainers handles POST /api/v1/containers/bulk/restart
+// @Summary Bulk restart containers
+// @Description Queue a restart control task for each container on the agent of its host. Containers that don't exist, aren't placed, are protected or whose host has no running agent are reported in details.
+// @Tags Containers
+// @Accept json
+// @Produce json
+// @Param request body BulkContainerActionRequest true "Containers to restart"
+// @Success 202 {object} BulkContainerActionResponse
+// @Failure 400 {object} APIError
+// @Router /containers/bulk/restart [post]
+func (s *Server) bulkRestartContainers(c echo.Context) error {
+	return s.bulkContainerAction(c, bulkActionRestart)
+}
+
+// bulkContainerAction queues one control task per container, routed to the
+// host the container runs on.
+func (s *Server) bulkContainerAction(c echo.Context, action string) error {
+	var req BulkContainerActionRequest
+	if err := c.Bind(&req); err != nil {
+		return BadRequestError("Invalid request body", err.Error())
+	}
+	if err := validateBulkActionRequest(req); err != nil {
+		return err
+	}
+
+	createdBy := ""
+	if userID, ok := auth.GetUserID(c); ok {
+		createdBy = userID
+	}
+
+	response := BulkContainerActionResponse{
+		Action:  action,
+		Total:   len(req.IDs),
+		TaskIDs: []string{},
+		Results: make([]BulkContainerActionResult, 0, len(req.IDs)),
+	}
+	agentRunning := make(map[string]bool)
+	for _, id := range req.IDs {
+		result := s.queueContainerAction(id, action, req.Timeout, createdBy, agentRunning)
+		if result.Success {
+			response.Success++
+			response.TaskIDs = append(response.TaskIDs, result.TaskID)
+		} else {
+			response.Failed++
+			response.Details = append(response.Details, fmt.Sprintf("%s: %s", id, result.Error))
+		}
+		response.Results = append(response.Results, result)
+	}
+
+	return c.JSON(http.StatusAccepted, response)
+}
+
+// validateBulkActionRequest checks the ids and timeout of a bulk control request.
+func validateBulkActionRequest(req BulkContainerActionRequest) error {
+	fieldErrors := make(map[string]string)
+	if len(req.IDs) == 0 {
+		fieldErrors["ids"] = "At least one id is required"
+	} else if len(req.IDs) > maxBulkActionIDs {
+		fieldErrors["ids"] = fmt.Sprintf("At most %d ids are allowed per request", maxBulkActionIDs)
+	} else {
+		seen := make(map[string]bool, len(req.IDs))
+		for _, id := range req.IDs {
+			if id == "" {
+				fieldErrors["ids"] = "Container ids cannot be empty"
+				break
+			}
+			if seen[id] {
+				fieldErrors["ids"] = "Duplicate container id " + id
+				break
+			}
+			seen[id] = true
+		}
+	}
+	if req.Timeout < 0 {
+		fieldErrors["timeout"] = "Timeout cannot be negative"
+	}
+	if len(fieldErrors) > 0 {
+		return ValidationError("Validation failed", fieldErrors)
+	}
+	return nil
+}
+
+// queueContainerAction creates the control task of one container of a bulk
+// request. agentRunning caches the agent check per host.
+func (s *Server) queueContainerAction(id, action string, timeout int, createdBy string, agentRunning map[string]bool) BulkContainerActionResult {
+	result := BulkContainerActionResult{ID: id}
+
+	container, err := s.storage.GetContainer(id)
+	if err != nil {
+		result.Error = "container not found"
+		return result
+	}
+	result.HostID = container.HostedOn
+	if container.HostedOn == "" {
+		result.Error = "container is not placed on a host"
+		return result
+	}
+
+	running, checked := agentRunning[container.HostedOn]
+	if !checked {
+		running = s.hostAgentRunning(container.HostedOn)
+		agentRunning[container.HostedOn] = running
+	}
+	if !running {
+		result.Error = "host " + container.HostedOn + " has no running agent"
+		return result
+	}
+
+	task := newAgentTask("ControlAction", bulkActionName(action)+" "+container.Name, container.HostedOn, createdBy)
+	task.ContainerID = container.ID
+	payload := map[string]interface{}{
+		"action":        action,
+		"containerId":   container.ID,
+		"containerName": container.Name,
+	}
+	if timeout > 0 && action != bulkActionStart {
+		payload["timeout"] = timeout
+	}
+	if err := task.SetPayload(payload); err != nil {
+		result.Error = "failed to encode task payload: " + err.Error()
+		return result
+	}
+
+	// Refuse to stop or control protected containers
+	if err := s.queueTask(task); err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	result.TaskID = task.ID
+	result.Success = true
+	return result
+}
+
+// bulkActionName returns the task name prefix of a control action.
+func bulkActionName(action string) string {
+	switch action {
+	case bulkActionStart:
+		return "Start"
+	case bulkActionStop:
+		return "Stop"
+	default:
+		return "Restart"
+	}
+}
+
+// hostAgentRunning reports whether an agent is running for a host and takes
+// tasks: either an agent managed by this server, or a standalone agent that
+// reported metrics within three sync intervals. Draining agents take no new
+// tasks.
+func (s *Server) hostAgentRunning(hostID string) bool {
+	host, err := s.storage.GetHost(hostID)
+	if err == nil && host.Status == "draining" {
+		return false
+	}
+	if s.agentManager != nil && s.agentManager.HasRunningAgent(hostID) {
+		return true
+	}
+	if err != nil || host.LastMetricsUpdate == "" {
+		return false
+	}
+
+	updated, err := time.Parse(time.RFC3339, host.LastMetricsUpdate)
+	if err != nil {
+		return false
+	}
+	interval := 30 * time.Second
+	if s.config != nil && s.config.Agent.SyncInterval > 0 {
+		interval = s.config.Agent.SyncInterval
+	}
+	return time.Since(updated) <= 3*interval
+}
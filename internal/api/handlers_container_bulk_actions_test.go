@@ -0,0 +1,61 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+)
+
+func TestValidateBulkActionRequest(t *testing.T) {
+	tooMany := make([]string, maxBulkActionIDs+1)
+
+	tests := []struct {
+		name    string
+		req     BulkContainerActionRequest
+		wantErr string
+	}{
+		{name: "valid", req: BulkContainerActionRequest{IDs: []string{"a", "b"}, Timeout: 10}},
+		{name: "no ids", req: BulkContainerActionRequest{}, wantErr: "ids"},
+		{name: "too many ids", req: BulkContainerActionRequest{IDs: tooMany}, wantErr: "ids"},
+		{name: "empty id", req: BulkContainerActionRequest{IDs: []string{"a", ""}}, wantErr: "ids"},
+		{name: "duplicate id", req: BulkContainerActionRequest{IDs: []string{"a", "a"}}, wantErr: "ids"},
+		{name: "negative timeout", req: BulkContainerActionRequest{IDs: []string{"a"}, Timeout: -1}, wantErr: "timeout"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateBulkActionRequest(tt.req)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("validateBulkActionRequest() error = %v", err)
+				}
+				return
+			}
+			apiErr, ok := err.(*APIError)
+			if !ok {
+				t.Fatalf("Expected an API error, got %v", err)
+			}
+			if _, ok := apiErr.FieldError[tt.wantErr]; !ok {
+				t.Errorf("Expected a %s error, got %+v", tt.wantErr, apiErr.FieldError)
+			}
+		})
+	}
+}
+
+func TestBulkStopContainers_RequiresIDs(t *testing.T) {
+	s := &Server{}
+	e := echo.New()
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/containers/bulk/stop", strings.NewReader(`{"ids": []}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	c := e.NewContext(req, httptest.NewRecorder())
+
+	err := s.bulkStopContainers(c)
+	apiErr, ok := err.(*APIError)
+	if !ok || apiErr.Code != http.StatusBadRequest {
+		t.Fatalf("Expected 400 without ids, got %v", err)
+	}
+}
@@ -0,0 +1,119 @@
+package api
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+
+	"evalgo.org/graphium/internal/auth"
+	"evalgo.org/graphium/models"
+)
+
+// ConnectivityCheckRequest is the request body for POST /api/v1/containers/:id/connectivity.
+type ConnectivityCheckRequest struct {
+	// Target is the service to reach as host:port (a container name or network alias, or a host)
+	Target string `json:"target"`
+
+	// Protocol is "tcp" (default) or "http"
+	Protocol string `json:"protocol,omitempty"`
+
+	// Path is the request path for HTTP probes (default: /)
+	Path string `json:"path,omitempty"`
+
+	// Attempts is the number of probes (default: 3, max: 20)
+	Attempts int `json:"attempts,omitempty"`
+
+	// Timeout is the timeout per attempt in seconds (default: 5, max: 60)
+	Timeout int `json:"timeout,omitempty"`
+}
+
+// checkContainerConnectivity handles POST /api/v1/containers/:id/connectivity
+// @Summary Check connectivity from a container to a service
+// @Description Queue a connectivity check for the container's agent. The agent probes the target over TCP or HTTP from the container's network namespace (or from the host network if it cannot enter it) and reports reachability and min/avg/max latency in the task result.
+// @Description The same check can be scheduled as a CheckAction with instrument {"checkType": "connectivity", "target": "db:5432"} and the container as object.
+// @Tags Containers
+// @Accept json
+// @Produce json
+// @Param id path string true "Container ID"
+// @Param request body ConnectivityCheckRequest true "Probe target and options"
+// @Success 202 {object} models.AgentTask "Queued check task"
+// @Failure 400 {object} APIError
+// @Failure 404 {object} APIError
+// @Failure 500 {object} APIError
+// @Router /containers/{id}/connectivity [post]
+func (s *Server) checkContainerConnectivity(c echo.Context) error {
+	id := c.Param("id")
+
+	var req ConnectivityCheckRequest
+	if err := c.Bind(&req); err != nil {
+		return BadRequestError("Invalid request body", err.Error())
+	}
+
+	container, err := s.storage.GetContainer(id)
+	if err != nil {
+		return NotFoundError("Container", id)
+	}
+	if container.HostedOn == "" {
+		return BadRequestError("Container has no host", "The container is not assigned to a host, so no agent can run the check")
+	}
+
+	payload, err := newConnectivityPayload(container.ID, req)
+	if err != nil {
+		return BadRequestError("Invalid connectivity check", err.Error())
+	}
+
+	createdBy := ""
+	if userID, ok := auth.GetUserID(c); ok {
+		createdBy = userID
+	}
+
+	task := newAgentTask("CheckAction", fmt.Sprintf("Connectivity from %s to %s", container.Name, payload.Target), container.HostedOn, createdBy)
+	task.ContainerID = container.ID
+	if err := task.SetPayload(payload); err != nil {
+		return InternalError("Failed to encode connectivity payload", err.Error())
+	}
+
+	if err := s.queueTask(task); err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusAccepted, task)
+}
+
+// newConnectivityPayload validates a connectivity check request and converts it to a task payload.
+func newConnectivityPayload(containerID string, req ConnectivityCheckRequest) (*models.ConnectivityCheckPayload, error) {
+	host, port, err := net.SplitHostPort(req.Target)
+	if err != nil || host == "" || port == "" {
+		return nil, fmt.Errorf("target must be host:port, got %q", req.Target)
+	}
+
+	protocol := strings.ToLower(req.Protocol)
+	if protocol == "" {
+		protocol = models.ConnectivityProtocolTCP
+	}
+	if protocol != models.ConnectivityProtocolTCP && protocol != models.ConnectivityProtocolHTTP {
+		return nil, fmt.Errorf("protocol must be %q or %q, got %q", models.ConnectivityProtocolTCP, models.ConnectivityProtocolHTTP, req.Protocol)
+	}
+	if req.Path != "" && !strings.HasPrefix(req.Path, "/") {
+		return nil, fmt.Errorf("path must start with /")
+	}
+	if req.Attempts < 0 || req.Attempts > 20 {
+		return nil, fmt.Errorf("attempts must be between 1 and 20")
+	}
+	if req.Timeout < 0 || req.Timeout > 60 {
+		return nil, fmt.Errorf("timeout must be between 1 and 60 seconds")
+	}
+
+	return &models.ConnectivityCheckPayload{
+		CheckType:   models.CheckTypeConnectivity,
+		ContainerID: containerID,
+		Target:      req.Target,
+		Protocol:    protocol,
+		Path:        req.Path,
+		Attempts:    req.Attempts,
+		Timeout:     req.Timeout,
+	}, nil
+}
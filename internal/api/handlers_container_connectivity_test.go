@@ -0,0 +1,38 @@
+package api
+
+import (
+	"testing"
+
+	"evalgo.org/graphium/models"
+)
+
+func TestNewConnectivityPayload(t *testing.T) {
+	payload, err := newConnectivityPayload("web-1", ConnectivityCheckRequest{Target: "db:5432"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if payload.CheckType != models.CheckTypeConnectivity || payload.Protocol != models.ConnectivityProtocolTCP {
+		t.Errorf("Expected a tcp connectivity check, got %+v", payload)
+	}
+	if payload.ContainerID != "web-1" || payload.Target != "db:5432" {
+		t.Errorf("Unexpected source or target: %+v", payload)
+	}
+
+	if _, err := newConnectivityPayload("web-1", ConnectivityCheckRequest{Target: "api:8080", Protocol: "HTTP", Path: "/health"}); err != nil {
+		t.Errorf("Expected an HTTP probe to be accepted, got %v", err)
+	}
+
+	invalid := []ConnectivityCheckRequest{
+		{Target: "db"},
+		{Target: ":5432"},
+		{Target: "db:5432", Protocol: "udp"},
+		{Target: "api:8080", Protocol: "http", Path: "health"},
+		{Target: "db:5432", Attempts: 100},
+		{Target: "db:5432", Timeout: -1},
+	}
+	for _, req := range invalid {
+		if _, err := newConnectivityPayload("web-1", req); err == nil {
+			t.Errorf("Expected an error for %+v", req)
+		}
+	}
+}
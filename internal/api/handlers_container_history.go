@@ -0,0 +1,81 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"evalgo.org/graphium/internal/auth"
+	"evalgo.org/graphium/models"
+)
+
+// ContainerHistoryResponse lists the change history of a container.
+type ContainerHistoryResponse struct {
+	ContainerID string                         `json:"containerId"`
+	Count       int                            `json:"count"`
+	Changes     []*models.ContainerChangeEntry `json:"changes"`
+}
+
+// recordContainerChanges stores a change entry for an updated container.
+// Failures are logged, not returned, so they never fail the update.
+func (s *Server) recordContainerChanges(c echo.Context, container *models.Container, changes []models.FieldChange) {
+	changedBy, _ := auth.GetUserID(c)
+
+	entry := &models.ContainerChangeEntry{
+		ContainerID:   container.ID,
+		ContainerName: container.Name,
+		HostID:        container.HostedOn,
+		ChangedBy:     changedBy,
+		ChangedAt:     time.Now(),
+		Changes:       changes,
+	}
+	if err := s.storage.SaveContainerChange(entry); err != nil {
+		s.logger.WithError(err).Warn("Failed to record changes of container " + container.ID)
+		return
+	}
+
+	// Keep the history of each container bounded
+	if _, err := s.storage.PruneContainerHistory(container.ID, s.config.Server.ContainerHistoryLimit); err != nil {
+		s.logger.WithError(err).Warn("Failed to prune history of container " + container.ID)
+	}
+}
+
+// getContainerHistory handles GET /api/v1/containers/:id/history
+// @Summary Get container change history
+// @Description List the field-level changes recorded for a container (status transitions, image, port, label changes, ...), newest first. Environment variables are reported by name only. Updates that change nothing are not recorded. Only the newest server.container_history_limit entries are kept.
+// @Tags Containers
+// @Produce json
+// @Param id path string true "Container ID"
+// @Param limit query int false "Maximum number of entries (default: all)"
+// @Success 200 {object} ContainerHistoryResponse
+// @Failure 400 {object} APIError
+// @Failure 500 {object} APIError
+// @Router /containers/{id}/history [get]
+func (s *Server) getContainerHistory(c echo.Context) error {
+	id := c.Param("id")
+
+	limit := 0
+	if raw := c.QueryParam("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 0 {
+			return BadRequestError("Invalid limit", "limit must be a non-negative integer")
+		}
+		limit = parsed
+	}
+
+	entries, err := s.storage.GetContainerHistory(id, limit)
+	if err != nil {
+		return InternalError("Failed to get container history", err.Error())
+	}
+	if entries == nil {
+		entries = []*models.ContainerChangeEntry{}
+	}
+
+	return c.JSON(http.StatusOK, ContainerHistoryResponse{
+		ContainerID: id,
+		Count:       len(entries),
+		Changes:     entries,
+	})
+}
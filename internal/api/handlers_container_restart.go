@@ -0,0 +1,96 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+
+	"evalgo.org/graphium/internal/auth"
+	"evalgo.org/graphium/models"
+)
+
+// ContainerRestartResponse is the response of POST /containers/:id/restart.
+type ContainerRestartResponse struct {
+	// Task is the queued restart control task
+	Task *models.AgentTask `json:"task"`
+
+	// Force is set if the restart bypasses Docker's restart backoff
+	Force bool `json:"force"`
+
+	// RestartCount is how often Docker restarted the container so far
+	RestartCount int `json:"restartCount"`
+
+	// Restarting is set if Docker was backing off the container's restarts
+	Restarting bool `json:"restarting"`
+
+	// LastExitReason describes why the container last exited
+	LastExitReason string `json:"lastExitReason,omitempty"`
+}
+
+// restartContainer handles POST /api/v1/containers/:id/restart
+// @Summary Restart a container
+// @Description Queue a restart control task for a container. With force=true the agent kills the container and starts it right away, bypassing the restart backoff Docker applies to crash-looping containers. The response includes the restart count and last exit reason last reported by the agent.
+// @Tags Containers
+// @Produce json
+// @Param id path string true "Container ID"
+// @Param force query bool false "Kill and start immediately, bypassing Docker's restart backoff (default: false)"
+// @Success 202 {object} ContainerRestartResponse
+// @Failure 400 {object} APIError
+// @Failure 403 {object} APIError "Container is protected"
+// @Failure 404 {object} APIError
+// @Failure 500 {object} APIError
+// @Router /containers/{id}/restart [post]
+func (s *Server) restartContainer(c echo.Context) error {
+	id := c.Param("id")
+
+	force := false
+	if raw := c.QueryParam("force"); raw != "" {
+		parsed, err := strconv.ParseBool(raw)
+		if err != nil {
+			return BadRequestError("Invalid force", "force must be true or false")
+		}
+		force = parsed
+	}
+
+	container, err := s.storage.GetContainer(id)
+	if err != nil {
+		return NotFoundError("Container", id)
+	}
+	if container.HostedOn == "" {
+		return BadRequestError("Container is not placed", "container "+id+" has no host to restart it on")
+	}
+
+	createdBy := ""
+	if userID, ok := auth.GetUserID(c); ok {
+		createdBy = userID
+	}
+
+	name := "Restart " + container.Name
+	if force {
+		name = "Force restart " + container.Name
+	}
+	task := newAgentTask("ControlAction", name, container.HostedOn, createdBy)
+	task.ContainerID = container.ID
+	if err := task.SetPayload(map[string]interface{}{
+		"action":        "restart",
+		"containerId":   container.ID,
+		"containerName": container.Name,
+		"force":         force,
+	}); err != nil {
+		return InternalError("Failed to encode restart payload", err.Error())
+	}
+
+	// Refuse to restart protected containers
+	if err := s.queueTask(task); err != nil {
+		return err
+	}
+
+	response := ContainerRestartResponse{Task: task, Force: force}
+	if container.Restart != nil {
+		response.RestartCount = container.Restart.RestartCount
+		response.Restarting = container.Restart.Restarting
+		response.LastExitReason = container.Restart.LastExitReason
+	}
+	return c.JSON(http.StatusAccepted, response)
+}
@@ -0,0 +1,61 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	"evalgo.org/graphium/models"
+)
+
+// ContainerUsageRequest carries the usage samples an agent took of the
+// containers on its host.
+type ContainerUsageRequest struct {
+	Containers []models.ContainerUsageSample `json:"containers"`
+}
+
+// ContainerUsageResponse reports what happened to the samples.
+type ContainerUsageResponse struct {
+	// Updated is the number of containers whose usage was stored
+	Updated int `json:"updated"`
+
+	// Skipped is the number of samples of unknown containers, of
+	// containers on other hosts, or that couldn't be stored
+	Skipped int `json:"skipped"`
+}
+
+// updateContainerUsage handles PUT /api/v1/hosts/:id/container-usage
+// @Summary Report container usage
+// @Description Store the CPU, memory and network usage an agent sampled of the containers on its host. Usage is reported apart from the containers, so it doesn't make them look changed to the agent's sync, and it is not recorded in the change history. Samples of containers on other hosts are skipped.
+// @Tags Hosts
+// @Accept json
+// @Produce json
+// @Param id path string true "Host ID"
+// @Param usage body ContainerUsageRequest true "Usage samples"
+// @Success 200 {object} ContainerUsageResponse
+// @Failure 400 {object} ErrorResponse
+// @Router /hosts/{id}/container-usage [put]
+func (s *Server) updateContainerUsage(c echo.Context) error {
+	hostID := c.Param("id")
+
+	var req ContainerUsageRequest
+	if err := c.Bind(&req); err != nil {
+		return BadRequestError("Invalid request body", "Failed to parse JSON: "+err.Error())
+	}
+
+	var resp ContainerUsageResponse
+	for i := range req.Containers {
+		sample := &req.Containers[i]
+		stored, err := s.storage.RecordContainerUsage(hostID, sample)
+		if err != nil {
+			s.logger.WithError(err).Warn("Failed to store usage of container " + sample.ContainerID)
+		}
+		if !stored {
+			resp.Skipped++
+			continue
+		}
+		resp.Updated++
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
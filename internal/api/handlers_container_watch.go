@@ -0,0 +1,145 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"evalgo.org/graphium/internal/auth"
+	"evalgo.org/graphium/internal/watch"
+	"evalgo.org/graphium/models"
+)
+
+// containerRemovedStatus is reported to watches when a watched container is deleted.
+const containerRemovedStatus = "removed"
+
+// CreateWatchRequest is the request body for POST /api/v1/containers/:id/watch.
+type CreateWatchRequest struct {
+	// Duration is how long the watch lives, e.g. "15m" (default: server.watch_default_duration)
+	Duration string `json:"duration,omitempty"`
+
+	// WebhookURL optionally receives a JSON POST for every change. It must
+	// target a public address or a host in server.watch_webhook_allowlist.
+	WebhookURL string `json:"webhookUrl,omitempty"`
+}
+
+// WatchesResponse lists container watches.
+type WatchesResponse struct {
+	Count   int           `json:"count"`
+	Watches []watch.Watch `json:"watches"`
+}
+
+// observeWatches reports a stored container's status to the watch registry.
+func (s *Server) observeWatches(container *models.Container) {
+	s.watches.Observe(container.ID, container.Name, container.Status)
+}
+
+// notifyWatchOwner sends a container watch change to the owner's WebSocket clients.
+func (s *Server) notifyWatchOwner(change watch.Change) {
+	s.debugLog("DEBUG: watch %s: container %s changed from %s to %s", change.WatchID, change.ContainerID, change.OldStatus, change.NewStatus)
+	if change.UserID == "" {
+		return
+	}
+	if err := s.wsHub.SendToUser(change.UserID, EventContainerWatch, change); err != nil {
+		s.logger.WithError(err).Warn("Failed to send container watch notification")
+	}
+}
+
+// createContainerWatch handles POST /api/v1/containers/:id/watch
+// @Summary Watch a container for state changes
+// @Description Register a temporary watch that notifies the calling user when the container's status changes, via a container_watch WebSocket event and an optional webhook. Watches expire after their duration and are not persisted.
+// @Tags Containers
+// @Accept json
+// @Produce json
+// @Param id path string true "Container ID"
+// @Param request body CreateWatchRequest false "Watch options"
+// @Success 201 {object} watch.Watch
+// @Failure 400 {object} APIError
+// @Failure 404 {object} APIError
+// @Failure 429 {object} APIError "Watch limit of the user reached"
+// @Router /containers/{id}/watch [post]
+func (s *Server) createContainerWatch(c echo.Context) error {
+	id := c.Param("id")
+
+	var req CreateWatchRequest
+	if c.Request().ContentLength != 0 {
+		if err := c.Bind(&req); err != nil {
+			return BadRequestError("Invalid request body", err.Error())
+		}
+	}
+
+	var duration time.Duration
+	if req.Duration != "" {
+		parsed, err := time.ParseDuration(req.Duration)
+		if err != nil {
+			return BadRequestError("Invalid duration", err.Error())
+		}
+		duration = parsed
+	}
+
+	container, err := s.storage.GetContainer(id)
+	if err != nil {
+		return NotFoundError("Container", id)
+	}
+
+	userID, _ := auth.GetUserID(c)
+	w, err := s.watches.Add(container.ID, userID, req.WebhookURL, duration, container.Status)
+	if err != nil {
+		if errors.Is(err, watch.ErrLimitReached) {
+			return NewAPIError(http.StatusTooManyRequests, "Too many watches", err.Error())
+		}
+		return BadRequestError("Invalid watch", err.Error())
+	}
+
+	return c.JSON(http.StatusCreated, w)
+}
+
+// listWatches handles GET /api/v1/watches
+// @Summary List container watches
+// @Description List the caller's active container watches. Admins may pass all=true to list every user's watches.
+// @Tags Containers
+// @Produce json
+// @Param all query bool false "List watches of all users (admin only)"
+// @Success 200 {object} WatchesResponse
+// @Failure 403 {object} APIError
+// @Router /watches [get]
+func (s *Server) listWatches(c echo.Context) error {
+	userID, _ := auth.GetUserID(c)
+	if c.QueryParam("all") == "true" {
+		if userID != "" && !auth.HasRole(c, models.RoleAdmin) {
+			return NewAPIError(http.StatusForbidden, "Admin role required", "Only admins can list all watches")
+		}
+		userID = ""
+	}
+
+	watches := s.watches.List(userID)
+	return c.JSON(http.StatusOK, WatchesResponse{Count: len(watches), Watches: watches})
+}
+
+// cancelWatch handles DELETE /api/v1/watches/:id
+// @Summary Cancel a container watch
+// @Description Cancel one of the caller's container watches. Admins may cancel any watch.
+// @Tags Containers
+// @Param id path string true "Watch ID"
+// @Success 204 "Watch cancelled"
+// @Failure 404 {object} APIError
+// @Router /watches/{id} [delete]
+func (s *Server) cancelWatch(c echo.Context) error {
+	id := c.Param("id")
+
+	userID, _ := auth.GetUserID(c)
+	if auth.HasRole(c, models.RoleAdmin) {
+		userID = ""
+	}
+
+	if err := s.watches.Cancel(id, userID); err != nil {
+		if errors.Is(err, watch.ErrNotFound) {
+			return NotFoundError("Watch", id)
+		}
+		return InternalError("Failed to cancel watch", err.Error())
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
@@ -1,49 +1,117 @@
 package api
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
+	"strconv"
+	"time"
 
+	"eve.evalgo.org/db"
 	"github.com/labstack/echo/v4"
 
-	_ "evalgo.org/graphium/internal/storage" // imported for Server.storage field
+	"evalgo.org/graphium/internal/auth"
+	"evalgo.org/graphium/internal/storage"
 	"evalgo.org/graphium/models"
 )
 
+// RenameContainerRequest is the request body for renaming a container to follow
+// the {stack-name}-{container-name} convention.
+type RenameContainerRequest struct {
+	StackName string `json:"stackName"`
+}
+
 // listContainers handles GET /api/v1/containers
 // @Summary List containers
-// @Description Get a paginated list of containers with optional filtering by status, host, or datacenter
+// @Description Get a paginated list of containers, sorted by name unless sort is given, with optional filtering by status, host, or datacenter and a case-insensitive search (q) over name, ID, image and host datacenter. Ties are ordered by ID, so pages are stable; total is the number of matching containers. Offset paging suits UIs jumping between pages; programs walking all containers (e.g. sync tools) should prefer cursor paging, which costs the same on every page and isn't shifted by concurrent changes
 // @Tags Containers
 // @Accept json
 // @Produce json
-// @Param status query string false "Filter by container status (running, stopped, paused, etc.)"
+// @Param status query string false "Filter by container status (running, stopped, paused, etc.); comma-separated statuses match any"
 // @Param host query string false "Filter by host ID"
-// @Param datacenter query string false "Filter by datacenter location"
+// @Param datacenter query string false "Filter by the datacenter of the container's host; comma-separated datacenters match any"
+// @Param q query string false "Search name, ID, image and host datacenter (substring, case-insensitive)"
+// @Param sort query string false "Sort field" Enums(name, created, status, host) default(name)
+// @Param order query string false "Sort order" Enums(asc, desc) default(asc)
 // @Param limit query int false "Maximum number of items to return (default: 100, max: 1000)" minimum(1) maximum(1000)
 // @Param offset query int false "Number of items to skip (default: 0)" minimum(0)
-// @Success 200 {object} PaginatedContainersResponse "Successfully retrieved containers"
+// @Param cursor query string false "Cursor paging: empty for the first page, then the previous page's next_cursor. Pages are ordered by ID and have no total"
+// @Param If-None-Match header string false "ETag of a cached page"
+// @Success 200 {object} PaginatedContainersResponse "Successfully retrieved containers (a CursorContainersResponse with cursor)"
+// @Header 200 {string} ETag "Weak ETag of the page"
+// @Success 304 "Page unchanged since the given ETag"
+// @Failure 400 {object} APIError "Search query too long or invalid sort"
 // @Failure 500 {object} ErrorResponse "Internal server error"
 // @Router /containers [get]
 func (s *Server) listContainers(c echo.Context) error {
 	// Parse query parameters
 	filters := make(map[string]interface{})
 
-	if status := c.QueryParam("status"); status != "" {
-		filters["status"] = status
+	if statuses := parseListParam(c, "status"); len(statuses) > 0 {
+		filters["status"] = listFilter(statuses)
 	}
 	if hostID := c.QueryParam("host"); hostID != "" {
 		filters["hostedOn"] = hostID
 	}
-	if datacenter := c.QueryParam("datacenter"); datacenter != "" {
-		// For datacenter filtering, we need to use a more complex query
-		// This is a simplified version
-		filters["location"] = datacenter
+
+	// Containers have no location; the datacenter filter and share links
+	// scoped to a datacenter select the containers on its hosts
+	var allowedHosts map[string]bool
+	if datacenters := parseListParam(c, "datacenter"); len(datacenters) > 0 {
+		hosts, err := s.storage.ListHosts(map[string]interface{}{"location": listFilter(datacenters)})
+		if err != nil {
+			return InternalError("Failed to resolve datacenter hosts", err.Error())
+		}
+		allowedHosts = make(map[string]bool, len(hosts))
+		for _, host := range hosts {
+			allowedHosts[host.ID] = true
+		}
+	}
+	if hostIDs, scoped, err := s.shareScopeHostIDs(c); err != nil {
+		return InternalError("Failed to resolve share link scope", err.Error())
+	} else if scoped {
+		if allowedHosts != nil {
+			for id := range allowedHosts {
+				if !hostIDs[id] {
+					delete(allowedHosts, id)
+				}
+			}
+		} else {
+			allowedHosts = hostIDs
+		}
+	}
+	if allowedHosts != nil {
+		filters["hostedOn"] = scopedHostFilter(allowedHosts, c.QueryParam("host"))
+	}
+
+	query, err := parseSearchQuery(c)
+	if err != nil {
+		return err
+	}
+
+	if after, cursor, err := parseCursor(c); err != nil {
+		return err
+	} else if cursor {
+		return s.listContainersByCursor(c, query, filters, after)
+	}
+
+	order, err := parseListSort(c, containerSortFields)
+	if err != nil {
+		return err
 	}
 
 	// Parse pagination parameters
 	limit, offset := parsePagination(c)
 
-	containers, err := s.storage.ListContainers(filters)
+	// Matches are deduplicated and sorted before the page is sliced
+	var containers []*models.Container
+	var total int
+	if query != "" {
+		containers, total, err = s.storage.SearchContainers(query, filters, order, limit, offset)
+	} else {
+		containers, total, err = s.storage.ListContainersPage(filters, order, limit, offset)
+	}
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, ErrorResponse{
 			Error:   "failed to list containers",
@@ -51,11 +119,13 @@ func (s *Server) listContainers(c echo.Context) error {
 		})
 	}
 
-	// Get total count before pagination
-	total := len(containers)
-
-	// Apply pagination
-	containers = paginateSliceContainers(containers, limit, offset)
+	revisions := make([]docRevision, len(containers))
+	for i, container := range containers {
+		revisions[i] = docRevision{ID: container.ID, Rev: container.Rev}
+	}
+	if notModified(c, listETag(total, revisions)) {
+		return c.NoContent(http.StatusNotModified)
+	}
 
 	return c.JSON(http.StatusOK, PaginatedContainersResponse{
 		Count:      len(containers),
@@ -66,17 +136,56 @@ func (s *Server) listContainers(c echo.Context) error {
 	})
 }
 
-// getContainer handles GET /api/v1/containers/:id
+// listContainersByCursor responds with the cursor page of containers after
+// after.
+func (s *Server) listContainersByCursor(c echo.Context, query string, filters map[string]interface{}, after string) error {
+	limit, _ := parsePagination(c)
+
+	var containers []*models.Container
+	var next string
+	var err error
+	if query != "" {
+		containers, next, err = s.storage.SearchContainersAfter(query, filters, after, limit)
+	} else {
+		containers, next, err = s.storage.ListContainersAfter(filters, after, limit)
+	}
+	if err != nil {
+		return InternalError("Failed to list containers", err.Error())
+	}
+
+	revisions := make([]docRevision, len(containers))
+	for i, container := range containers {
+		revisions[i] = docRevision{ID: container.ID, Rev: container.Rev}
+	}
+	if notModified(c, listETag(0, append(revisions, docRevision{ID: next}))) {
+		return c.NoContent(http.StatusNotModified)
+	}
+
+	return c.JSON(http.StatusOK, CursorContainersResponse{
+		Count:      len(containers),
+		Limit:      limit,
+		NextCursor: encodeCursor(next),
+		Containers: containers,
+	})
+}
+
+// getContainer handles GET and HEAD /api/v1/containers/:id
 // @Summary Get container by ID
-// @Description Get detailed information about a specific container by its ID
+// @Description Get detailed information about a specific container by its ID. The X-Content-Hash header carries a hash of the fields agents report; HEAD returns only the headers, so agents can skip unchanged updates. The ETag is the document revision; a matching If-None-Match returns 304.
 // @Tags Containers
 // @Accept json
 // @Produce json
 // @Param id path string true "Container ID"
+// @Param If-None-Match header string false "ETag of a cached copy"
 // @Success 200 {object} models.Container "Successfully retrieved container"
+// @Header 200 {string} X-Content-Hash "Content hash of the agent-reported fields"
+// @Header 200 {string} ETag "Document revision"
+// @Success 304 "Container unchanged since the given ETag"
 // @Failure 400 {object} APIError "Bad request - Container ID is required"
 // @Failure 404 {object} APIError "Container not found"
+// @Failure 410 {object} APIError "Container was deleted and is tombstoned"
 // @Router /containers/{id} [get]
+// @Router /containers/{id} [head]
 func (s *Server) getContainer(c echo.Context) error {
 	id := c.Param("id")
 
@@ -86,9 +195,23 @@ func (s *Server) getContainer(c echo.Context) error {
 
 	container, err := s.storage.GetContainer(id)
 	if err != nil {
+		// Tell agents a deleted container is gone for good, so they skip it
+		if tombstone, tombErr := s.storage.GetContainerTombstone(id); tombErr == nil && tombstone != nil {
+			return NewAPIError(http.StatusGone, "Container was deleted", "container "+id+" is tombstoned: "+tombstone.Reason)
+		}
+		return NotFoundError("Container", id)
+	}
+
+	if hostIDs, scoped, err := s.shareScopeHostIDs(c); err != nil {
+		return InternalError("Failed to resolve share link scope", err.Error())
+	} else if scoped && !hostIDs[container.HostedOn] {
 		return NotFoundError("Container", id)
 	}
 
+	c.Response().Header().Set(models.ContentHashHeader, container.ContentHash())
+	if notModified(c, revisionETag(container.Rev)) {
+		return c.NoContent(http.StatusNotModified)
+	}
 	return c.JSON(http.StatusOK, container)
 }
 
@@ -101,6 +224,7 @@ func (s *Server) getContainer(c echo.Context) error {
 // @Param container body models.Container true "Container object (JSON-LD format)"
 // @Success 201 {object} models.Container "Successfully created container"
 // @Failure 400 {object} APIError "Bad request - Invalid request body or validation errors"
+// @Failure 409 {object} APIError "Container was deleted and is tombstoned"
 // @Failure 500 {object} APIError "Internal server error"
 // @Router /containers [post]
 func (s *Server) createContainer(c echo.Context) error {
@@ -126,17 +250,25 @@ func (s *Server) createContainer(c echo.Context) error {
 	if container.ID == "" {
 		container.ID = generateID("container", container.Name)
 	}
+	s.keepDockerOrigin(c, &container, nil)
+	s.markProtected(&container)
 
 	// Save container
 	if err := s.storage.SaveContainer(&container); err != nil {
+		if errors.Is(err, storage.ErrContainerTombstoned) {
+			return ConflictError("Container was deleted", err.Error()+"; clear it with DELETE /api/v1/containers/"+container.ID+"/ignored")
+		}
 		return InternalError("Failed to create container", err.Error())
 	}
 
-	// Auto-assign to stack based on naming convention
-	if err := s.storage.AutoAssignContainerToStack(container.ID, container.Name); err != nil {
-		// Log error but don't fail the request
-		fmt.Printf("Warning: Failed to auto-assign container %s to stack: %v\n", container.ID, err)
-	}
+	// Start the container's health timeline
+	s.recordHealthTransition(&container)
+
+	// Auto-assign to a stack by labels or naming convention
+	s.autoAssignStack(&container)
+
+	// Notify watches of status changes
+	s.observeWatches(&container)
 
 	// Broadcast WebSocket event
 	s.BroadcastGraphEvent(EventContainerAdded, container)
@@ -155,6 +287,7 @@ func (s *Server) createContainer(c echo.Context) error {
 // @Success 200 {object} models.Container "Successfully updated container"
 // @Failure 400 {object} APIError "Bad request - Invalid request body or validation errors"
 // @Failure 404 {object} APIError "Container not found"
+// @Failure 409 {object} APIError "A label set by Docker was removed or changed"
 // @Failure 500 {object} APIError "Internal server error"
 // @Router /containers/{id} [put]
 func (s *Server) updateContainer(c echo.Context) error {
@@ -191,30 +324,123 @@ func (s *Server) updateContainer(c echo.Context) error {
 	container.ID = id
 	container.Rev = existing.Rev
 
+	s.keepDockerOrigin(c, &container, existing)
+	keepServerState(&container, existing)
+	if err := dockerLabelConflict(&container); err != nil {
+		return err
+	}
+	container.RefreshUpdateAvailable()
+	s.markProtected(&container)
+
+	// Skip no-op updates (e.g. an unchanged agent re-sync) to avoid revision churn
+	changes := models.DiffContainers(existing, &container)
+	if len(changes) == 0 {
+		s.autoAssignStack(&container)
+		return c.JSON(http.StatusOK, container)
+	}
+
 	// Update container
 	if err := s.storage.SaveContainer(&container); err != nil {
 		return InternalError("Failed to update container", err.Error())
 	}
 
-	// Auto-assign to stack based on naming convention
-	if err := s.storage.AutoAssignContainerToStack(container.ID, container.Name); err != nil {
-		// Log error but don't fail the request
-		fmt.Printf("Warning: Failed to auto-assign container %s to stack: %v\n", container.ID, err)
+	// Record what changed in the container's history
+	s.recordContainerChanges(c, &container, changes)
+	if healthChanged(changes) {
+		s.recordHealthTransition(&container)
 	}
 
+	// Auto-assign to a stack by labels or naming convention
+	s.autoAssignStack(&container)
+
+	// Notify watches of status changes
+	s.observeWatches(&container)
+
 	// Broadcast WebSocket event
 	s.BroadcastGraphEvent(EventContainerUpdated, container)
 
 	return c.JSON(http.StatusOK, container)
 }
 
+// keepServerState carries state the server maintains over from the stored
+// container to an update of it.
+//
+// Usage is kept, as agents report it separately (PUT
+// /hosts/:id/container-usage). Labels set through the API are kept, as
+// agents report only Docker's, which are merged over the stored ones; an
+// explicitly empty set clears them. The image update checker's state is
+// kept until the container runs a different image reference, and the image
+// digest until an update reports one.
+func keepServerState(container, existing *models.Container) {
+	container.CPUPercent = existing.CPUPercent
+	container.MemoryUsage = existing.MemoryUsage
+	container.NetworkRx = existing.NetworkRx
+	container.NetworkTx = existing.NetworkTx
+	container.StatsSampledAt = existing.StatsSampledAt
+	if container.Labels == nil {
+		container.Labels = existing.Labels
+	} else if len(container.Labels) > 0 && len(existing.Labels) > 0 {
+		labels := make(map[string]string, len(existing.Labels)+len(container.Labels))
+		for key, value := range existing.Labels {
+			labels[key] = value
+		}
+		for key, value := range container.Labels {
+			labels[key] = value
+		}
+		container.Labels = labels
+	}
+	if container.LatestImageDigest == "" && container.Image == existing.Image {
+		container.LatestImageDigest = existing.LatestImageDigest
+		container.ImageCheckedAt = existing.ImageCheckedAt
+	}
+	if container.ImageDigest == "" {
+		container.ImageDigest = existing.ImageDigest
+	}
+}
+
+// keepDockerOrigin keeps the Docker name and labels of a container unless
+// an agent reports the container; existing is nil for a new one. Agents
+// that don't send them yet report Docker's labels as Labels.
+func (s *Server) keepDockerOrigin(c echo.Context, container, existing *models.Container) {
+	if s.reportedByAgent(c) {
+		if container.DockerName == "" {
+			container.DockerName = container.Name
+			container.DockerLabels = container.Labels
+		}
+		return
+	}
+	container.DockerName, container.DockerLabels = "", nil
+	if existing != nil {
+		container.DockerName = existing.DockerName
+		container.DockerLabels = existing.DockerLabels
+	}
+}
+
+// dockerLabelConflict rejects an update that removes or changes a label set
+// by Docker: the agent would report it again on its next sync.
+func dockerLabelConflict(container *models.Container) error {
+	if key := container.DockerLabelConflict(); key != "" {
+		return ConflictError("Label set by Docker",
+			"Label '"+key+"' is set by Docker and can't be removed or changed; change it in the container's configuration")
+	}
+	return nil
+}
+
+// reportedByAgent reports whether an agent sent the request. Without
+// authentication every caller is trusted like an agent.
+func (s *Server) reportedByAgent(c echo.Context) bool {
+	return !s.config.Security.AuthEnabled || auth.HasRole(c, models.RoleAgent)
+}
+
 // deleteContainer handles DELETE /api/v1/containers/:id
 // @Summary Delete a container
-// @Description Delete an existing container by its ID
+// @Description Delete an existing container by its ID. Unless tombstone is false, a tombstone keeps agents from syncing the container back until it expires after tombstone_ttl (default server.container_tombstone_ttl; 0 keeps it until cleared with DELETE /containers/{id}/ignored).
 // @Tags Containers
 // @Accept json
 // @Produce json
 // @Param id path string true "Container ID"
+// @Param tombstone query bool false "Keep agents from re-creating the container (default: true)"
+// @Param tombstone_ttl query string false "How long the tombstone lasts, e.g. 24h (0: until cleared)"
 // @Success 200 {object} MessageResponse "Successfully deleted container"
 // @Failure 400 {object} APIError "Bad request - Container ID is required"
 // @Failure 404 {object} APIError "Container not found"
@@ -227,6 +453,11 @@ func (s *Server) deleteContainer(c echo.Context) error {
 		return BadRequestError("Container ID is required", "The 'id' parameter cannot be empty")
 	}
 
+	tombstone, ttl, err := parseTombstoneOptions(c, s.config.Server.ContainerTombstoneTTL)
+	if err != nil {
+		return err
+	}
+
 	// Get container to retrieve revision and host info
 	container, err := s.storage.GetContainer(id)
 	if err != nil {
@@ -239,10 +470,12 @@ func (s *Server) deleteContainer(c echo.Context) error {
 		fmt.Printf("Warning: Failed to remove container %s from stacks: %v\n", id, err)
 	}
 
-	// Add container to ignore list to prevent agent from re-syncing it
-	if err := s.storage.AddToIgnoreList(id, container.HostedOn, "user-deleted via API", "system"); err != nil {
-		// Log the error but don't fail the delete operation
-		fmt.Printf("Warning: Failed to add container %s to ignore list: %v\n", id, err)
+	// Tombstone the container to prevent agents from re-syncing it
+	if tombstone {
+		if err := s.storage.TombstoneContainer(id, container.HostedOn, "user-deleted via API", "system", ttl); err != nil {
+			// Log the error but don't fail the delete operation
+			fmt.Printf("Warning: Failed to tombstone container %s: %v\n", id, err)
+		}
 	}
 
 	// Delete container
@@ -250,6 +483,9 @@ func (s *Server) deleteContainer(c echo.Context) error {
 		return InternalError("Failed to delete container", err.Error())
 	}
 
+	// Notify watches that the container is gone
+	s.watches.Observe(id, container.Name, containerRemovedStatus)
+
 	// Broadcast WebSocket event
 	s.BroadcastGraphEvent(EventContainerRemoved, map[string]string{"id": id})
 
@@ -259,14 +495,38 @@ func (s *Server) deleteContainer(c echo.Context) error {
 	})
 }
 
+// parseTombstoneOptions parses the tombstone and tombstone_ttl query
+// parameters of a container deletion. defaultTTL applies without
+// tombstone_ttl.
+func parseTombstoneOptions(c echo.Context, defaultTTL time.Duration) (bool, time.Duration, error) {
+	tombstone := true
+	if raw := c.QueryParam("tombstone"); raw != "" {
+		parsed, err := strconv.ParseBool(raw)
+		if err != nil {
+			return false, 0, BadRequestError("Invalid tombstone", "tombstone must be true or false")
+		}
+		tombstone = parsed
+	}
+
+	ttl := defaultTTL
+	if raw := c.QueryParam("tombstone_ttl"); raw != "" {
+		parsed, err := time.ParseDuration(raw)
+		if err != nil || parsed < 0 {
+			return false, 0, BadRequestError("Invalid tombstone_ttl", "tombstone_ttl must be a non-negative duration such as 24h")
+		}
+		ttl = parsed
+	}
+	return tombstone, ttl, nil
+}
+
 // bulkCreateContainers handles POST /api/v1/containers/bulk
-// @Summary Bulk create containers
-// @Description Create multiple containers in a single request
+// @Summary Bulk create or update containers
+// @Description Create or update multiple containers in a single request. Existing containers are updated like PUT /containers/{id}: labels and image update state are kept, labels set by Docker are restored instead of rejected, and unchanged containers aren't saved again. Containers in the ignore list are skipped with the error "ignored". Results are per container, in request order.
 // @Tags Containers
 // @Accept json
 // @Produce json
 // @Param containers body []models.Container true "Array of container objects (JSON-LD format)"
-// @Success 200 {object} BulkResponse "Successfully created containers"
+// @Success 200 {object} BulkResponse "Successfully created or updated containers"
 // @Failure 400 {object} APIError "Bad request - Invalid request body or validation errors"
 // @Failure 500 {object} APIError "Internal server error"
 // @Router /containers/bulk [post]
@@ -298,48 +558,88 @@ func (s *Server) bulkCreateContainers(c echo.Context) error {
 		return ValidationError("Validation failed for one or more containers", fieldErrors)
 	}
 
-	// Bulk save
-	results, err := s.storage.BulkSaveContainers(containers)
+	// Don't bring back containers users deleted (e.g. re-reported by an agent)
+	ignoreList, err := s.storage.ListIgnored()
 	if err != nil {
-		return InternalError("Failed to bulk create containers", err.Error())
+		return InternalError("Failed to check ignore list", err.Error())
+	}
+	ignored := make(map[string]bool, len(ignoreList))
+	for _, entry := range ignoreList {
+		ignored[entry.ContainerID] = true
+	}
+	upserts := make([]*models.Container, 0, len(containers))
+	for _, container := range containers {
+		if !ignored[container.ID] {
+			upserts = append(upserts, container)
+		}
 	}
 
-	// Auto-assign containers to stacks based on naming convention
-	for i, result := range results {
-		if result.OK && i < len(containers) {
-			if err := s.storage.AutoAssignContainerToStack(containers[i].ID, containers[i].Name); err != nil {
-				// Log error but don't fail the request
-				fmt.Printf("Warning: Failed to auto-assign container %s to stack: %v\n", containers[i].ID, err)
+	// Create new containers and update existing ones like updateContainer
+	created := make(map[string]bool, len(upserts))
+	changes := make(map[string][]models.FieldChange, len(upserts))
+	var unchanged []*models.Container
+	var results []db.BulkResult
+	var saved []*models.Container
+	if len(upserts) > 0 {
+		results, saved, err = s.storage.BulkUpsertContainers(upserts, func(container, existing *models.Container) bool {
+			s.keepDockerOrigin(c, container, existing)
+			if existing == nil {
+				created[container.ID] = true
+				s.markProtected(container)
+				return true
+			}
+			keepServerState(container, existing)
+			container.RestoreDockerLabels()
+			container.RefreshUpdateAvailable()
+			s.markProtected(container)
+
+			changes[container.ID] = models.DiffContainers(existing, container)
+			if len(changes[container.ID]) == 0 {
+				unchanged = append(unchanged, container)
+				return false
 			}
+			return true
+		})
+		if err != nil {
+			return InternalError("Failed to bulk save containers", err.Error())
 		}
 	}
 
-	// Count successes and failures and convert to API type
-	successCount := 0
-	failureCount := 0
-	apiResults := make([]BulkResult, len(results))
-	for i, result := range results {
-		if result.OK {
-			successCount++
-		} else {
-			failureCount++
+	for _, container := range saved {
+		if created[container.ID] {
+			s.recordHealthTransition(container)
+			s.autoAssignStack(container)
+			s.observeWatches(container)
+			s.BroadcastGraphEvent(EventContainerAdded, container)
+			continue
 		}
-		// Convert db.BulkResult to api.BulkResult
-		apiResults[i] = BulkResult{
-			ID:      result.ID,
-			Rev:     result.Rev,
-			Error:   result.Error,
-			Reason:  result.Reason,
-			Success: result.OK,
+		s.recordContainerChanges(c, container, changes[container.ID])
+		if healthChanged(changes[container.ID]) {
+			s.recordHealthTransition(container)
 		}
+		s.autoAssignStack(container)
+		s.observeWatches(container)
+		s.BroadcastGraphEvent(EventContainerUpdated, container)
+	}
+	for _, container := range unchanged {
+		s.autoAssignStack(container)
 	}
 
-	return c.JSON(http.StatusOK, BulkResponse{
-		Total:   len(results),
-		Success: successCount,
-		Failed:  failureCount,
-		Results: apiResults,
-	})
+	// Report ignored containers in request order among the saved ones
+	byID := make(map[string]db.BulkResult, len(results))
+	for _, result := range results {
+		byID[result.ID] = result
+	}
+	ordered := make([]db.BulkResult, len(containers))
+	for i, container := range containers {
+		if ignored[container.ID] {
+			ordered[i] = db.BulkResult{ID: container.ID, Error: "ignored", Reason: "container is in the ignore list"}
+			continue
+		}
+		ordered[i] = byID[container.ID]
+	}
+
+	return c.JSON(http.StatusOK, toBulkResponse(ordered))
 }
 
 // getContainersByHost handles GET /api/v1/query/containers/by-host/:hostId
@@ -350,6 +650,12 @@ func (s *Server) getContainersByHost(c echo.Context) error {
 		return BadRequestError("Host ID is required", "The 'hostId' parameter cannot be empty")
 	}
 
+	if hostIDs, scoped, err := s.shareScopeHostIDs(c); err != nil {
+		return InternalError("Failed to resolve share link scope", err.Error())
+	} else if scoped && !hostIDs[hostID] {
+		return NotFoundError("Host", hostID)
+	}
+
 	containers, err := s.storage.GetContainersByHost(hostID)
 	if err != nil {
 		return InternalError("Failed to query containers by host", err.Error())
@@ -361,6 +667,88 @@ func (s *Server) getContainersByHost(c echo.Context) error {
 	})
 }
 
+// getContainerNameCollisions handles GET /api/v1/query/containers/name-collisions
+// @Summary List container name collisions
+// @Description List container names used by more than one container on the same host (for cleanup)
+// @Tags Containers
+// @Produce json
+// @Param host query string false "Restrict to a single host ID"
+// @Success 200 {object} map[string]interface{} "Name collisions grouped by host"
+// @Failure 500 {object} APIError "Internal server error"
+// @Router /query/containers/name-collisions [get]
+func (s *Server) getContainerNameCollisions(c echo.Context) error {
+	collisions, err := s.storage.GetContainerNameCollisions(c.QueryParam("host"))
+	if err != nil {
+		return InternalError("Failed to find container name collisions", err.Error())
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"count":      len(collisions),
+		"collisions": collisions,
+	})
+}
+
+// renameContainer handles POST /api/v1/containers/:id/rename
+// @Summary Rename a container for a stack
+// @Description Rename a container to {stack-name}-{container-name} in Docker and the database
+// @Tags Containers
+// @Accept json
+// @Produce json
+// @Param id path string true "Container ID"
+// @Param request body RenameContainerRequest true "Stack to rename the container for"
+// @Success 200 {object} models.Container "Renamed container"
+// @Failure 400 {object} APIError "Bad request - stackName is required"
+// @Failure 404 {object} APIError "Container not found"
+// @Failure 409 {object} APIError "Name already used by another container on the same host"
+// @Failure 500 {object} APIError "Internal server error"
+// @Router /containers/{id}/rename [post]
+func (s *Server) renameContainer(c echo.Context) error {
+	id := c.Param("id")
+
+	var req RenameContainerRequest
+	if err := c.Bind(&req); err != nil {
+		return BadRequestError("Invalid request body", err.Error())
+	}
+	if req.StackName == "" {
+		return ValidationError("Validation failed", map[string]string{"stackName": "Stack name is required"})
+	}
+
+	container, err := s.storage.GetContainer(id)
+	if err != nil {
+		return NotFoundError("Container", id)
+	}
+
+	// Resolve the Docker socket of the container's host
+	resolver := &APIHostResolver{storage: s.storage}
+	hostInfo, err := resolver.ResolveHost(container.HostedOn)
+	if err != nil {
+		return InternalError("Failed to resolve container host", err.Error())
+	}
+
+	if err := s.storage.RenameContainerForStack(id, req.StackName, hostInfo.DockerSocket); err != nil {
+		return renameError(err)
+	}
+
+	renamed, err := s.storage.GetContainer(id)
+	if err != nil {
+		return InternalError("Failed to reload container", err.Error())
+	}
+
+	// Broadcast WebSocket event
+	s.BroadcastGraphEvent(EventContainerUpdated, renamed)
+
+	return c.JSON(http.StatusOK, renamed)
+}
+
+// renameError maps an error renaming a container to its API error: 409 if
+// the name is used by another container on the host, 500 otherwise.
+func renameError(err error) *APIError {
+	if errors.Is(err, storage.ErrContainerNameCollision) {
+		return ConflictError("Container name collision", err.Error())
+	}
+	return InternalError("Failed to rename container", err.Error())
+}
+
 // getContainersByStatus handles GET /api/v1/query/containers/by-status/:status
 func (s *Server) getContainersByStatus(c echo.Context) error {
 	status := c.Param("status")
@@ -389,6 +777,64 @@ func (s *Server) getContainersByStatus(c echo.Context) error {
 	})
 }
 
+// getContainersByImage handles GET /api/v1/query/containers/by-image/:image
+// @Summary Find containers by image
+// @Description List the containers running an exact image reference, e.g. nginx:1.19. Escape slashes in the image as %2F.
+// @Tags Containers
+// @Produce json
+// @Param image path string true "Image reference (URL-escaped)"
+// @Success 200 {object} ContainersResponse
+// @Failure 400 {object} APIError
+// @Failure 500 {object} APIError
+// @Router /query/containers/by-image/{image} [get]
+func (s *Server) getContainersByImage(c echo.Context) error {
+	image, err := url.PathUnescape(c.Param("image"))
+	if err != nil {
+		return BadRequestError("Invalid image", err.Error())
+	}
+	if image == "" {
+		return BadRequestError("Image is required", "The 'image' parameter cannot be empty")
+	}
+
+	containers, err := s.storage.GetContainersByImage(image)
+	if err != nil {
+		return InternalError("Failed to query containers by image", err.Error())
+	}
+
+	return c.JSON(http.StatusOK, ContainersResponse{
+		Count:      len(containers),
+		Containers: containers,
+	})
+}
+
+// getContainersByLabel handles GET /api/v1/query/containers/by-label
+// @Summary Find containers by label
+// @Description List the containers with a label, e.g. app=shop or team=web, to group services without relying on naming conventions. Without a value, every container that has the label matches. Labels include the Docker labels agents report and labels set through the API.
+// @Tags Containers
+// @Produce json
+// @Param key query string true "Label key"
+// @Param value query string false "Label value (default: any)"
+// @Success 200 {object} ContainersResponse
+// @Failure 400 {object} APIError
+// @Failure 500 {object} APIError
+// @Router /query/containers/by-label [get]
+func (s *Server) getContainersByLabel(c echo.Context) error {
+	key := c.QueryParam("key")
+	if key == "" {
+		return BadRequestError("Label key is required", "Set the 'key' query parameter")
+	}
+
+	containers, err := s.storage.GetContainersByLabel(key, c.QueryParam("value"))
+	if err != nil {
+		return InternalError("Failed to query containers by label", err.Error())
+	}
+
+	return c.JSON(http.StatusOK, ContainersResponse{
+		Count:      len(containers),
+		Containers: containers,
+	})
+}
+
 // checkContainerIgnored handles HEAD /api/v1/containers/:id/ignored
 // Returns 200 if container is ignored, 404 if not ignored
 func (s *Server) checkContainerIgnored(c echo.Context) error {
@@ -0,0 +1,176 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"evalgo.org/graphium/internal/auth"
+	"evalgo.org/graphium/internal/config"
+	"evalgo.org/graphium/internal/storage"
+	"evalgo.org/graphium/models"
+)
+
+func TestKeepServerState(t *testing.T) {
+	checked := time.Now()
+	existing := &models.Container{
+		Image:             "nginx:1.25",
+		ImageDigest:       "sha256:old",
+		LatestImageDigest: "sha256:new",
+		ImageCheckedAt:    &checked,
+		Labels:            map[string]string{"stack": "web"},
+		CPUPercent:        12.5,
+		StatsSampledAt:    &checked,
+	}
+
+	// An agent update without labels or update state keeps them
+	update := &models.Container{Image: "nginx:1.25"}
+	keepServerState(update, existing)
+	if update.Labels["stack"] != "web" {
+		t.Errorf("Expected labels to be kept, got %v", update.Labels)
+	}
+	if update.ImageDigest != "sha256:old" || update.LatestImageDigest != "sha256:new" || update.ImageCheckedAt != &checked {
+		t.Errorf("Expected image update state to be kept, got %+v", update)
+	}
+	if update.CPUPercent != 12.5 || update.StatsSampledAt != &checked {
+		t.Errorf("Expected the last usage sample to be kept, got %+v", update)
+	}
+
+	// Labels an agent reports are merged over the stored ones
+	update = &models.Container{Image: "nginx:1.25", Labels: map[string]string{"app": "shop", "stack": "api"}}
+	keepServerState(update, existing)
+	if len(update.Labels) != 2 || update.Labels["app"] != "shop" || update.Labels["stack"] != "api" {
+		t.Errorf("Expected reported labels merged over stored ones, got %v", update.Labels)
+	}
+	if existing.Labels["stack"] != "web" {
+		t.Errorf("Expected stored labels to be left unchanged, got %v", existing.Labels)
+	}
+
+	// Usage is only reported through the usage endpoint
+	sampled := checked.Add(time.Minute)
+	update = &models.Container{Image: "nginx:1.25", StatsSampledAt: &sampled}
+	keepServerState(update, existing)
+	if update.CPUPercent != 12.5 || update.StatsSampledAt != &checked {
+		t.Errorf("Expected the stored usage sample to be kept, got %+v", update)
+	}
+
+	// A new image reference drops the update state of the old one
+	update = &models.Container{Image: "nginx:1.27", ImageDigest: "sha256:other", Labels: map[string]string{}}
+	keepServerState(update, existing)
+	if update.LatestImageDigest != "" || update.ImageCheckedAt != nil {
+		t.Errorf("Expected update state of the old image to be dropped, got %+v", update)
+	}
+	if update.ImageDigest != "sha256:other" {
+		t.Errorf("Expected the reported digest to win, got %s", update.ImageDigest)
+	}
+	if len(update.Labels) != 0 {
+		t.Errorf("Expected explicitly set labels to win, got %v", update.Labels)
+	}
+}
+
+func TestKeepDockerOrigin(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Security.AuthEnabled = true
+	s := &Server{config: cfg}
+	request := func(role models.Role) echo.Context {
+		c := echo.New().NewContext(httptest.NewRequest("PUT", "/api/v1/containers/c1", nil), httptest.NewRecorder())
+		c.Set(auth.ContextKeyClaims, &auth.Claims{Roles: []models.Role{role}})
+		return c
+	}
+	existing := &models.Container{
+		Name:         "web",
+		DockerName:   "web",
+		DockerLabels: map[string]string{"app": "shop"},
+	}
+
+	// Users can't change what Docker reported
+	update := &models.Container{
+		Name:         "db",
+		Labels:       map[string]string{"maintenance": "true"},
+		DockerName:   "db",
+		DockerLabels: map[string]string{"maintenance": "true"},
+	}
+	s.keepDockerOrigin(request(models.RoleUser), update, existing)
+	if update.DockerName != "web" || len(update.DockerLabels) != 1 || update.DockerLabels["app"] != "shop" {
+		t.Errorf("Expected the Docker name and labels to be kept, got %q %v", update.DockerName, update.DockerLabels)
+	}
+	created := &models.Container{Name: "db", DockerName: "db", DockerLabels: map[string]string{"maintenance": "true"}}
+	s.keepDockerOrigin(request(models.RoleUser), created, nil)
+	if created.DockerName != "" || created.DockerLabels != nil {
+		t.Errorf("Expected a user-created container to have no Docker name or labels, got %q %v", created.DockerName, created.DockerLabels)
+	}
+
+	// Agents report them
+	update = &models.Container{Name: "web-2", DockerName: "web-2", DockerLabels: map[string]string{"app": "api"}}
+	s.keepDockerOrigin(request(models.RoleAgent), update, existing)
+	if update.DockerName != "web-2" || update.DockerLabels["app"] != "api" {
+		t.Errorf("Expected the reported Docker name and labels, got %q %v", update.DockerName, update.DockerLabels)
+	}
+	update = &models.Container{Name: "web", Labels: map[string]string{"app": "shop"}}
+	s.keepDockerOrigin(request(models.RoleAgent), update, existing)
+	if update.DockerName != "web" || update.DockerLabels["app"] != "shop" {
+		t.Errorf("Expected an older agent's name and labels to be taken as Docker's, got %q %v", update.DockerName, update.DockerLabels)
+	}
+}
+
+func TestDockerLabelConflict(t *testing.T) {
+	existing := &models.Container{
+		Labels:       map[string]string{"app": "shop", "tier": "front"},
+		DockerLabels: map[string]string{"app": "shop"},
+	}
+
+	update := &models.Container{Labels: map[string]string{}, DockerLabels: existing.DockerLabels}
+	keepServerState(update, existing)
+	err := dockerLabelConflict(update)
+	apiErr, ok := err.(*APIError)
+	if !ok || apiErr.Code != http.StatusConflict {
+		t.Fatalf("Expected clearing a Docker label to conflict, got %v", err)
+	}
+
+	update = &models.Container{Labels: map[string]string{"tier": "back"}, DockerLabels: existing.DockerLabels}
+	keepServerState(update, existing)
+	if err := dockerLabelConflict(update); err != nil {
+		t.Errorf("Expected changing an API label to be allowed, got %v", err)
+	}
+}
+
+func TestRenameError(t *testing.T) {
+	collision := fmt.Errorf("%w: shop-web is used by container c2 on host h1", storage.ErrContainerNameCollision)
+	if err := renameError(collision); err.Code != http.StatusConflict || err.Details != collision.Error() {
+		t.Errorf("Expected a name collision to be a 409 with its reason, got %d %q", err.Code, err.Details)
+	}
+	if err := renameError(errors.New("failed to rename container in Docker")); err.Code != http.StatusInternalServerError {
+		t.Errorf("Expected other rename failures to be a 500, got %d", err.Code)
+	}
+}
+
+func TestParseTombstoneOptions(t *testing.T) {
+	e := echo.New()
+	parse := func(query string) (bool, time.Duration, error) {
+		req := httptest.NewRequest("DELETE", "/api/v1/containers/c1?"+query, nil)
+		return parseTombstoneOptions(e.NewContext(req, httptest.NewRecorder()), 6*time.Hour)
+	}
+
+	if tombstone, ttl, err := parse(""); err != nil || !tombstone || ttl != 6*time.Hour {
+		t.Errorf("Expected a tombstone with the default TTL, got %v %v %v", tombstone, ttl, err)
+	}
+	if tombstone, ttl, err := parse("tombstone_ttl=0"); err != nil || !tombstone || ttl != 0 {
+		t.Errorf("Expected a tombstone until cleared, got %v %v %v", tombstone, ttl, err)
+	}
+	if tombstone, ttl, err := parse("tombstone_ttl=30m"); err != nil || ttl != 30*time.Minute || !tombstone {
+		t.Errorf("Expected a 30m tombstone, got %v %v %v", tombstone, ttl, err)
+	}
+	if tombstone, _, err := parse("tombstone=false"); err != nil || tombstone {
+		t.Errorf("Expected no tombstone, got %v %v", tombstone, err)
+	}
+	for _, query := range []string{"tombstone=maybe", "tombstone_ttl=soon", "tombstone_ttl=-1h"} {
+		if _, _, err := parse(query); err == nil {
+			t.Errorf("Expected %q to be rejected", query)
+		}
+	}
+}
@@ -0,0 +1,318 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+
+	"evalgo.org/graphium/models"
+)
+
+// CustomTypeListResponse lists the registered custom types.
+type CustomTypeListResponse struct {
+	Count int                  `json:"count"`
+	Types []*models.CustomType `json:"types"`
+}
+
+// CustomEntityListResponse lists entities of custom types.
+type CustomEntityListResponse struct {
+	Count    int                    `json:"count"`
+	Entities []*models.CustomEntity `json:"entities"`
+}
+
+// listCustomTypes handles GET /api/v1/custom-types
+// @Summary List custom types
+// @Description List the custom JSON-LD types registered in the configuration (graph.custom_types) and through the API, with their edge rules.
+// @Tags Custom Types
+// @Produce json
+// @Success 200 {object} CustomTypeListResponse
+// @Failure 500 {object} APIError
+// @Router /custom-types [get]
+func (s *Server) listCustomTypes(c echo.Context) error {
+	types, err := s.storage.ListCustomTypes()
+	if err != nil {
+		return InternalError("Failed to list custom types", err.Error())
+	}
+	return c.JSON(http.StatusOK, CustomTypeListResponse{Count: len(types), Types: types})
+}
+
+// getCustomType handles GET /api/v1/custom-types/:name
+// @Summary Get a custom type
+// @Tags Custom Types
+// @Produce json
+// @Param name path string true "Type name"
+// @Success 200 {object} models.CustomType
+// @Failure 404 {object} APIError
+// @Router /custom-types/{name} [get]
+func (s *Server) getCustomType(c echo.Context) error {
+	name := c.Param("name")
+
+	ct, err := s.storage.GetCustomType(name)
+	if err != nil {
+		return NotFoundError("Custom type", name)
+	}
+	return c.JSON(http.StatusOK, ct)
+}
+
+// createCustomType handles POST /api/v1/custom-types
+// @Summary Register a custom type
+// @Description Register a custom JSON-LD @type. Entities of the type (POST /entities) appear as nodes in the graph; each edge rule links an entity to the containers, hosts or custom entities whose IDs one of its properties holds.
+// @Tags Custom Types
+// @Accept json
+// @Produce json
+// @Param type body models.CustomType true "Type name, vocabulary and edge rules"
+// @Success 201 {object} models.CustomType
+// @Failure 400 {object} APIError
+// @Failure 409 {object} APIError "Type already registered"
+// @Failure 500 {object} APIError
+// @Router /custom-types [post]
+func (s *Server) createCustomType(c echo.Context) error {
+	var ct models.CustomType
+	if err := c.Bind(&ct); err != nil {
+		return BadRequestError("Invalid request body", "Failed to parse JSON: "+err.Error())
+	}
+	ct.Rev = ""
+
+	if err := ct.Validate(); err != nil {
+		return BadRequestError("Invalid custom type", err.Error())
+	}
+	if _, err := s.storage.GetCustomType(ct.Name); err == nil {
+		return NewAPIError(http.StatusConflict, "Custom type already registered", "type "+ct.Name+" is already registered")
+	}
+
+	if err := s.storage.SaveCustomType(&ct); err != nil {
+		return InternalError("Failed to save custom type", err.Error())
+	}
+
+	s.BroadcastGraphEvent("custom_type_created", map[string]interface{}{
+		"name": ct.Name,
+	})
+
+	return c.JSON(http.StatusCreated, ct)
+}
+
+// deleteCustomType handles DELETE /api/v1/custom-types/:name
+// @Summary Unregister a custom type
+// @Description Remove a custom type registered through the API. Types with entities, and types registered in the configuration, can't be removed.
+// @Tags Custom Types
+// @Param name path string true "Type name"
+// @Success 204
+// @Failure 404 {object} APIError
+// @Failure 409 {object} APIError "Type has entities or is configured"
+// @Failure 500 {object} APIError
+// @Router /custom-types/{name} [delete]
+func (s *Server) deleteCustomType(c echo.Context) error {
+	name := c.Param("name")
+
+	ct, err := s.storage.GetCustomType(name)
+	if err != nil {
+		return NotFoundError("Custom type", name)
+	}
+	if ct.Source == models.CustomTypeSourceConfig {
+		return NewAPIError(http.StatusConflict, "Custom type is configured", "type "+name+" is registered in graph.custom_types; remove it from the configuration")
+	}
+
+	entities, err := s.storage.ListCustomEntities(name)
+	if err != nil {
+		return InternalError("Failed to list entities", err.Error())
+	}
+	if len(entities) > 0 {
+		return NewAPIError(http.StatusConflict, "Custom type has entities", "delete the entities of type "+name+" first")
+	}
+
+	if err := s.storage.DeleteCustomType(ct); err != nil {
+		return InternalError("Failed to delete custom type", err.Error())
+	}
+
+	s.BroadcastGraphEvent("custom_type_deleted", map[string]interface{}{
+		"name": name,
+	})
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// listCustomEntities handles GET /api/v1/entities
+// @Summary List custom entities
+// @Tags Custom Types
+// @Produce json
+// @Param type query string false "Only entities of this custom type"
+// @Success 200 {object} CustomEntityListResponse
+// @Failure 500 {object} APIError
+// @Router /entities [get]
+func (s *Server) listCustomEntities(c echo.Context) error {
+	entities, err := s.storage.ListCustomEntities(c.QueryParam("type"))
+	if err != nil {
+		return InternalError("Failed to list entities", err.Error())
+	}
+	return c.JSON(http.StatusOK, CustomEntityListResponse{Count: len(entities), Entities: entities})
+}
+
+// getCustomEntity handles GET /api/v1/entities/:id
+// @Summary Get a custom entity
+// @Tags Custom Types
+// @Produce json
+// @Param id path string true "Entity ID"
+// @Success 200 {object} models.CustomEntity
+// @Failure 404 {object} APIError
+// @Router /entities/{id} [get]
+func (s *Server) getCustomEntity(c echo.Context) error {
+	id := c.Param("id")
+
+	entity, err := s.storage.GetCustomEntity(id)
+	if err != nil {
+		return NotFoundError("Entity", id)
+	}
+	return c.JSON(http.StatusOK, entity)
+}
+
+// createCustomEntity handles POST /api/v1/entities
+// @Summary Create a custom entity
+// @Description Create an entity of a registered custom type. Its @type must name the type; edge properties must hold an ID or a list of IDs.
+// @Tags Custom Types
+// @Accept json
+// @Produce json
+// @Param entity body models.CustomEntity true "Entity"
+// @Success 201 {object} models.CustomEntity
+// @Failure 400 {object} APIError
+// @Failure 500 {object} APIError
+// @Router /entities [post]
+func (s *Server) createCustomEntity(c echo.Context) error {
+	var entity models.CustomEntity
+	if err := c.Bind(&entity); err != nil {
+		return BadRequestError("Invalid request body", "Failed to parse JSON: "+err.Error())
+	}
+	entity.Rev = ""
+
+	if err := s.validateCustomEntity(&entity); err != nil {
+		return err
+	}
+
+	if err := s.storage.SaveCustomEntity(&entity); err != nil {
+		return InternalError("Failed to save entity", err.Error())
+	}
+
+	s.BroadcastGraphEvent("entity_created", entity)
+
+	return c.JSON(http.StatusCreated, entity)
+}
+
+// updateCustomEntity handles PUT /api/v1/entities/:id
+// @Summary Update a custom entity
+// @Description Replace the name and properties of an entity. Its @type can't change.
+// @Tags Custom Types
+// @Accept json
+// @Produce json
+// @Param id path string true "Entity ID"
+// @Param entity body models.CustomEntity true "Entity"
+// @Success 200 {object} models.CustomEntity
+// @Failure 400 {object} APIError
+// @Failure 404 {object} APIError
+// @Failure 500 {object} APIError
+// @Router /entities/{id} [put]
+func (s *Server) updateCustomEntity(c echo.Context) error {
+	id := c.Param("id")
+
+	existing, err := s.storage.GetCustomEntity(id)
+	if err != nil {
+		return NotFoundError("Entity", id)
+	}
+
+	var entity models.CustomEntity
+	if err := c.Bind(&entity); err != nil {
+		return BadRequestError("Invalid request body", "Failed to parse JSON: "+err.Error())
+	}
+	if entity.Type != "" && entity.Type != existing.Type {
+		return BadRequestError("Invalid entity", "the @type of an entity can't change")
+	}
+
+	entity.ID = existing.ID
+	entity.Rev = existing.Rev
+	entity.Type = existing.Type
+	entity.CreatedAt = existing.CreatedAt
+	if err := s.validateCustomEntity(&entity); err != nil {
+		return err
+	}
+
+	if err := s.storage.SaveCustomEntity(&entity); err != nil {
+		return InternalError("Failed to save entity", err.Error())
+	}
+
+	s.BroadcastGraphEvent("entity_updated", entity)
+
+	return c.JSON(http.StatusOK, entity)
+}
+
+// deleteCustomEntity handles DELETE /api/v1/entities/:id
+// @Summary Delete a custom entity
+// @Tags Custom Types
+// @Param id path string true "Entity ID"
+// @Success 204
+// @Failure 404 {object} APIError
+// @Failure 500 {object} APIError
+// @Router /entities/{id} [delete]
+func (s *Server) deleteCustomEntity(c echo.Context) error {
+	id := c.Param("id")
+
+	entity, err := s.storage.GetCustomEntity(id)
+	if err != nil {
+		return NotFoundError("Entity", id)
+	}
+
+	if err := s.storage.DeleteCustomEntity(entity.ID, entity.Rev); err != nil {
+		return InternalError("Failed to delete entity", err.Error())
+	}
+
+	s.BroadcastGraphEvent("entity_deleted", map[string]interface{}{
+		"id":   entity.ID,
+		"type": entity.Type,
+	})
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// validateCustomEntity checks an entity against its registered type and
+// sets its @context to the type's vocabulary if it has none.
+func (s *Server) validateCustomEntity(entity *models.CustomEntity) error {
+	if entity.Type == "" {
+		return BadRequestError("Invalid entity", "@type is required")
+	}
+	ct, err := s.storage.GetCustomType(entity.Type)
+	if err != nil {
+		return BadRequestError("Unknown type", "type "+entity.Type+" is not registered; register it with POST /api/v1/custom-types")
+	}
+	if err := ct.ValidateEntity(entity); err != nil {
+		return BadRequestError("Invalid entity", err.Error())
+	}
+	if entity.Context == "" {
+		entity.Context = ct.EntityVocabulary()
+	}
+	return nil
+}
+
+// getGraph handles GET /api/v1/query/graph
+// @Summary Get the infrastructure graph
+// @Description Get all hosts, containers and entities of registered custom types as graph nodes, keyed by ID, with hostedOn and dependsOn edges between containers and hosts and the edges derived by the custom types' edge rules. Containers no stack lists are included unless orphans is false; the default is the graph.include_orphans setting.
+// @Tags Query
+// @Produce json
+// @Param orphans query bool false "Include containers no stack lists"
+// @Success 200 {object} map[string]interface{} "Nodes and edges"
+// @Failure 400 {object} APIError
+// @Failure 500 {object} APIError
+// @Router /query/graph [get]
+func (s *Server) getGraph(c echo.Context) error {
+	includeOrphans := s.config.Graph.IncludeOrphans
+	if value := c.QueryParam("orphans"); value != "" {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			return BadRequestError("Invalid orphans parameter", "orphans must be true or false")
+		}
+		includeOrphans = parsed
+	}
+
+	graph, err := s.storage.GetGraphData(includeOrphans)
+	if err != nil {
+		return InternalError("Failed to build graph", err.Error())
+	}
+	return c.JSON(http.StatusOK, graph)
+}
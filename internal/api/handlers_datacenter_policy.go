@@ -0,0 +1,136 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	"evalgo.org/graphium/internal/auth"
+	"evalgo.org/graphium/internal/config"
+	"evalgo.org/graphium/models"
+)
+
+// DatacenterPolicyResponse is a datacenter's stored policy and the settings
+// the deployer applies there after falling back to the global configuration.
+type DatacenterPolicyResponse struct {
+	Datacenter string `json:"datacenter"`
+
+	// Configured reports whether the datacenter has a stored policy
+	Configured bool `json:"configured"`
+
+	Policy *models.DatacenterPolicy `json:"policy,omitempty"`
+
+	Effective EffectiveDatacenterPolicy `json:"effective"`
+}
+
+// EffectiveDatacenterPolicy is what deployments into a datacenter use.
+type EffectiveDatacenterPolicy struct {
+	PlacementStrategy string `json:"placementStrategy"`
+	AllowOvercommit   bool   `json:"allowOvercommit"`
+
+	// NetworkMode is empty when Docker's default applies
+	NetworkMode string `json:"networkMode,omitempty"`
+
+	// Protected patterns combine the global and the datacenter's patterns
+	ProtectedNamePatterns []string `json:"protectedNamePatterns"`
+	ProtectedImages       []string `json:"protectedImages"`
+}
+
+// newDatacenterPolicyResponse resolves a datacenter's policy (nil if none)
+// against the global configuration.
+func newDatacenterPolicyResponse(datacenter string, policy *models.DatacenterPolicy, cfg *config.Config) DatacenterPolicyResponse {
+	effective := EffectiveDatacenterPolicy{
+		PlacementStrategy:     cfg.Deploy.PlacementStrategy,
+		AllowOvercommit:       cfg.Server.AllowOvercommit,
+		ProtectedNamePatterns: append([]string{}, cfg.Deploy.ProtectedNamePatterns...),
+		ProtectedImages:       append([]string{}, cfg.Deploy.ProtectedImages...),
+	}
+	if effective.PlacementStrategy == "" {
+		effective.PlacementStrategy = models.PlacementFirstFit
+	}
+
+	if policy != nil {
+		if policy.PlacementStrategy != "" {
+			effective.PlacementStrategy = policy.PlacementStrategy
+		}
+		if policy.AllowOvercommit != nil {
+			effective.AllowOvercommit = *policy.AllowOvercommit
+		}
+		effective.NetworkMode = policy.NetworkMode
+		effective.ProtectedNamePatterns = append(effective.ProtectedNamePatterns, policy.ProtectedNamePatterns...)
+		effective.ProtectedImages = append(effective.ProtectedImages, policy.ProtectedImages...)
+	}
+
+	return DatacenterPolicyResponse{
+		Datacenter: datacenter,
+		Configured: policy != nil,
+		Policy:     policy,
+		Effective:  effective,
+	}
+}
+
+// getDatacenterPolicy handles GET /api/v1/datacenters/:datacenter/policy
+// @Summary Get datacenter policy
+// @Description Get the deployment policy of a datacenter and the effective settings after falling back to the global configuration. A datacenter without a policy uses the global settings.
+// @Tags Datacenters
+// @Produce json
+// @Param datacenter path string true "Datacenter (host location)"
+// @Success 200 {object} DatacenterPolicyResponse
+// @Router /datacenters/{datacenter}/policy [get]
+func (s *Server) getDatacenterPolicy(c echo.Context) error {
+	datacenter := c.Param("datacenter")
+
+	policy, err := s.storage.GetDatacenterPolicy(datacenter)
+	if err != nil {
+		policy = nil
+	}
+
+	return c.JSON(http.StatusOK, newDatacenterPolicyResponse(datacenter, policy, s.config))
+}
+
+// putDatacenterPolicy handles PUT /api/v1/datacenters/:datacenter/policy
+// @Summary Set datacenter policy
+// @Description Replace the deployment policy of a datacenter: default placement strategy (first-fit, spread, binpack), overcommit, additional protected containers and the network mode of containers in stacks without a network. Unset fields fall back to the global configuration.
+// @Tags Datacenters
+// @Accept json
+// @Produce json
+// @Param datacenter path string true "Datacenter (host location)"
+// @Param policy body models.DatacenterPolicy true "Datacenter policy"
+// @Success 200 {object} DatacenterPolicyResponse
+// @Failure 400 {object} APIError
+// @Failure 500 {object} APIError
+// @Router /datacenters/{datacenter}/policy [put]
+func (s *Server) putDatacenterPolicy(c echo.Context) error {
+	datacenter := c.Param("datacenter")
+
+	var policy models.DatacenterPolicy
+	if err := c.Bind(&policy); err != nil {
+		return BadRequestError("Invalid request body", err.Error())
+	}
+	policy.Datacenter = datacenter
+
+	if err := s.validateDatacenterPolicy(&policy); err != nil {
+		return BadRequestError("Invalid datacenter policy", err.Error())
+	}
+
+	if userID, ok := auth.GetUserID(c); ok {
+		policy.UpdatedBy = userID
+	}
+	if err := s.storage.SaveDatacenterPolicy(&policy); err != nil {
+		return InternalError("Failed to save datacenter policy", err.Error())
+	}
+	s.debugLog("Datacenter policy for %s updated by %s", datacenter, policy.UpdatedBy)
+
+	return c.JSON(http.StatusOK, newDatacenterPolicyResponse(datacenter, &policy, s.config))
+}
+
+// datacenterPolicies loads all datacenter policies for a deployment. Without
+// them deployments fall back to the global settings.
+func (s *Server) datacenterPolicies() map[string]*models.DatacenterPolicy {
+	policies, err := s.storage.ListDatacenterPolicies()
+	if err != nil {
+		s.logger.WithError(err).Warn("Failed to load datacenter policies, using global deployment settings")
+		return nil
+	}
+	return policies
+}
@@ -0,0 +1,46 @@
+package api
+
+import (
+	"reflect"
+	"testing"
+
+	"evalgo.org/graphium/internal/config"
+	"evalgo.org/graphium/models"
+)
+
+func TestNewDatacenterPolicyResponse(t *testing.T) {
+	cfg := &config.Config{
+		Server: config.ServerConfig{AllowOvercommit: true},
+		Deploy: config.DeployConfig{PlacementStrategy: "spread", ProtectedNamePatterns: []string{"traefik"}},
+	}
+
+	resp := newDatacenterPolicyResponse("core", nil, cfg)
+	if resp.Configured || resp.Policy != nil {
+		t.Errorf("Expected no configured policy, got %+v", resp)
+	}
+	if resp.Effective.PlacementStrategy != "spread" || !resp.Effective.AllowOvercommit {
+		t.Errorf("Expected global defaults, got %+v", resp.Effective)
+	}
+
+	overcommit := false
+	policy := &models.DatacenterPolicy{
+		Datacenter:            "edge",
+		PlacementStrategy:     models.PlacementBinPack,
+		AllowOvercommit:       &overcommit,
+		ProtectedNamePatterns: []string{"mqtt-*"},
+		NetworkMode:           "host",
+	}
+	resp = newDatacenterPolicyResponse("edge", policy, cfg)
+	if !resp.Configured {
+		t.Error("Expected a configured policy")
+	}
+	if resp.Effective.PlacementStrategy != models.PlacementBinPack || resp.Effective.AllowOvercommit || resp.Effective.NetworkMode != "host" {
+		t.Errorf("Expected the policy to override the global settings, got %+v", resp.Effective)
+	}
+	if want := []string{"traefik", "mqtt-*"}; !reflect.DeepEqual(resp.Effective.ProtectedNamePatterns, want) {
+		t.Errorf("Expected protected patterns %v, got %v", want, resp.Effective.ProtectedNamePatterns)
+	}
+	if len(cfg.Deploy.ProtectedNamePatterns) != 1 {
+		t.Errorf("Expected the global patterns to be left alone, got %v", cfg.Deploy.ProtectedNamePatterns)
+	}
+}
@@ -0,0 +1,95 @@
+package api
+
+import (
+	"net/http"
+	"sort"
+
+	"github.com/labstack/echo/v4"
+
+	"evalgo.org/graphium/models"
+)
+
+// DeploymentEventsResponse is a page of a deployment's event timeline.
+type DeploymentEventsResponse struct {
+	DeploymentID string                   `json:"deploymentId"`
+	StackID      string                   `json:"stackId"`
+	Status       string                   `json:"status"`
+	Phase        string                   `json:"phase,omitempty"`
+	Count        int                      `json:"count"`
+	Total        int                      `json:"total"`
+	Limit        int                      `json:"limit"`
+	Offset       int                      `json:"offset"`
+	Events       []models.DeploymentEvent `json:"events"`
+}
+
+// deploymentEventTypes are the event types recorded by the deployer.
+var deploymentEventTypes = map[string]bool{"info": true, "warning": true, "error": true}
+
+// filterDeploymentEvents returns the events matching the type, phase and
+// container filters (empty filters match everything), oldest first.
+func filterDeploymentEvents(events []models.DeploymentEvent, eventType, phase, container string) []models.DeploymentEvent {
+	filtered := make([]models.DeploymentEvent, 0, len(events))
+	for _, event := range events {
+		if (eventType == "" || event.Type == eventType) &&
+			(phase == "" || event.Phase == phase) &&
+			(container == "" || event.Container == container) {
+			filtered = append(filtered, event)
+		}
+	}
+	sort.SliceStable(filtered, func(i, j int) bool {
+		return filtered[i].Timestamp.Before(filtered[j].Timestamp)
+	})
+	return filtered
+}
+
+// getDeploymentEvents handles GET /api/v1/deployments/:id/events
+// @Summary Get deployment event timeline
+// @Description Get the ordered event timeline of a completed or in-progress deployment, oldest first, to diagnose where and why a deployment failed
+// @Tags stacks
+// @Produce json
+// @Param id path string true "Deployment ID"
+// @Param type query string false "Filter by event type (info, warning, error)"
+// @Param phase query string false "Filter by deployment phase (e.g. network-creation, container-creation)"
+// @Param container query string false "Filter by container name"
+// @Param limit query int false "Maximum number of events to return (default: 100, max: 1000)" minimum(1) maximum(1000)
+// @Param offset query int false "Number of events to skip (default: 0)" minimum(0)
+// @Success 200 {object} DeploymentEventsResponse
+// @Failure 400 {object} APIError
+// @Failure 404 {object} APIError
+// @Router /deployments/{id}/events [get]
+func (s *Server) getDeploymentEvents(c echo.Context) error {
+	id := c.Param("id")
+
+	eventType := c.QueryParam("type")
+	if eventType != "" && !deploymentEventTypes[eventType] {
+		return BadRequestError("Invalid event type", "type must be one of info, warning, error")
+	}
+
+	// Other documents decode as well, so check the type
+	state, err := s.storage.GetDeploymentState(id)
+	if err != nil || state.Type != "DeploymentState" {
+		return NotFoundError("Deployment", id)
+	}
+
+	events := filterDeploymentEvents(state.Events, eventType, c.QueryParam("phase"), c.QueryParam("container"))
+	total := len(events)
+
+	limit, offset := parsePagination(c)
+	if offset >= total {
+		events = []models.DeploymentEvent{}
+	} else {
+		events = events[offset:min(offset+limit, total)]
+	}
+
+	return c.JSON(http.StatusOK, DeploymentEventsResponse{
+		DeploymentID: state.ID,
+		StackID:      state.StackID,
+		Status:       state.Status,
+		Phase:        state.Phase,
+		Count:        len(events),
+		Total:        total,
+		Limit:        limit,
+		Offset:       offset,
+		Events:       events,
+	})
+}
@@ -0,0 +1,41 @@
+package api
+
+import (
+	"testing"
+	"time"
+
+	"evalgo.org/graphium/models"
+)
+
+func TestFilterDeploymentEvents(t *testing.T) {
+	start := time.Now()
+	events := []models.DeploymentEvent{
+		{Timestamp: start, Type: "info", Phase: "initialization", Message: "Starting deployment"},
+		{Timestamp: start.Add(3 * time.Second), Type: "error", Phase: "container-creation", Container: "db", Message: "pull failed"},
+		{Timestamp: start.Add(time.Second), Type: "info", Phase: "network-creation", Message: "Creating network"},
+		{Timestamp: start.Add(2 * time.Second), Type: "warning", Phase: "container-creation", Container: "web", Message: "slow start"},
+	}
+
+	all := filterDeploymentEvents(events, "", "", "")
+	if len(all) != 4 {
+		t.Fatalf("Expected 4 events, got %d", len(all))
+	}
+	for i := 1; i < len(all); i++ {
+		if all[i].Timestamp.Before(all[i-1].Timestamp) {
+			t.Fatalf("Expected events oldest first, got %+v", all)
+		}
+	}
+
+	if got := filterDeploymentEvents(events, "info", "", ""); len(got) != 2 {
+		t.Errorf("Expected 2 info events, got %d", len(got))
+	}
+	if got := filterDeploymentEvents(events, "", "container-creation", ""); len(got) != 2 || got[0].Container != "web" {
+		t.Errorf("Expected the container-creation events in order, got %+v", got)
+	}
+	if got := filterDeploymentEvents(events, "error", "container-creation", "db"); len(got) != 1 || got[0].Message != "pull failed" {
+		t.Errorf("Expected the db error, got %+v", got)
+	}
+	if got := filterDeploymentEvents(events, "error", "network-creation", ""); len(got) != 0 {
+		t.Errorf("Expected no events, got %+v", got)
+	}
+}
@@ -0,0 +1,127 @@
+package api
+
+import (
+	"net/http"
+	"sort"
+
+	"github.com/labstack/echo/v4"
+
+	"evalgo.org/graphium/models"
+)
+
+// redactedValue replaces the value of sensitive environment variables.
+const redactedValue = "[REDACTED]"
+
+// EnvDiffContainer identifies one side of an environment diff.
+type EnvDiffContainer struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// EnvDiffEntry is an environment variable present on only one side.
+type EnvDiffEntry struct {
+	Key      string `json:"key"`
+	Value    string `json:"value"`
+	Redacted bool   `json:"redacted,omitempty"`
+}
+
+// EnvDiffChange is an environment variable whose value differs between the containers.
+type EnvDiffChange struct {
+	Key      string `json:"key"`
+	A        string `json:"a"`
+	B        string `json:"b"`
+	Redacted bool   `json:"redacted,omitempty"`
+}
+
+// EnvDiffResponse is the response for GET /api/v1/containers/diff/env.
+type EnvDiffResponse struct {
+	A         EnvDiffContainer `json:"a"`
+	B         EnvDiffContainer `json:"b"`
+	OnlyInA   []EnvDiffEntry   `json:"onlyInA"`
+	OnlyInB   []EnvDiffEntry   `json:"onlyInB"`
+	Different []EnvDiffChange  `json:"different"`
+	SameCount int              `json:"sameCount"`
+}
+
+// diffContainerEnv handles GET /api/v1/containers/diff/env
+// @Summary Diff environment variables of two containers
+// @Description Compare the stored environment of two containers. Returns keys present in only one container and keys whose values differ. Values of sensitive keys (passwords, tokens, keys, ...) are redacted.
+// @Tags Containers
+// @Produce json
+// @Param a query string true "First container ID"
+// @Param b query string true "Second container ID"
+// @Success 200 {object} EnvDiffResponse
+// @Failure 400 {object} APIError
+// @Failure 404 {object} APIError
+// @Router /containers/diff/env [get]
+func (s *Server) diffContainerEnv(c echo.Context) error {
+	idA := c.QueryParam("a")
+	idB := c.QueryParam("b")
+	if idA == "" || idB == "" {
+		return BadRequestError("Both containers are required", "Query parameters 'a' and 'b' must be container IDs")
+	}
+
+	containerA, err := s.storage.GetContainer(idA)
+	if err != nil {
+		return NotFoundError("Container", idA)
+	}
+	containerB, err := s.storage.GetContainer(idB)
+	if err != nil {
+		return NotFoundError("Container", idB)
+	}
+
+	return c.JSON(http.StatusOK, diffEnv(containerA, containerB))
+}
+
+// diffEnv compares the environments of two containers. Keys are sorted.
+func diffEnv(a, b *models.Container) *EnvDiffResponse {
+	response := &EnvDiffResponse{
+		A:         EnvDiffContainer{ID: a.ID, Name: a.Name},
+		B:         EnvDiffContainer{ID: b.ID, Name: b.Name},
+		OnlyInA:   []EnvDiffEntry{},
+		OnlyInB:   []EnvDiffEntry{},
+		Different: []EnvDiffChange{},
+	}
+
+	redact := func(key, value string) (string, bool) {
+		if models.IsSecretKey(key) {
+			return redactedValue, true
+		}
+		return value, false
+	}
+
+	for _, key := range sortedKeys(a.Env) {
+		valueA := a.Env[key]
+		valueB, ok := b.Env[key]
+		switch {
+		case !ok:
+			value, redacted := redact(key, valueA)
+			response.OnlyInA = append(response.OnlyInA, EnvDiffEntry{Key: key, Value: value, Redacted: redacted})
+		case valueA != valueB:
+			shownA, redacted := redact(key, valueA)
+			shownB, _ := redact(key, valueB)
+			response.Different = append(response.Different, EnvDiffChange{Key: key, A: shownA, B: shownB, Redacted: redacted})
+		default:
+			response.SameCount++
+		}
+	}
+
+	for _, key := range sortedKeys(b.Env) {
+		if _, ok := a.Env[key]; !ok {
+			value, redacted := redact(key, b.Env[key])
+			response.OnlyInB = append(response.OnlyInB, EnvDiffEntry{Key: key, Value: value, Redacted: redacted})
+		}
+	}
+
+	return response
+}
+
+// sortedKeys returns the keys of m in sorted order.
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
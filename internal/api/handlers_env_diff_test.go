@@ -0,0 +1,65 @@
+package api
+
+import (
+	"testing"
+
+	"evalgo.org/graphium/models"
+)
+
+func TestDiffEnv(t *testing.T) {
+	a := &models.Container{
+		ID:   "web-1",
+		Name: "web",
+		Env: map[string]string{
+			"LOG_LEVEL":   "info",
+			"PORT":        "8080",
+			"DB_PASSWORD": "one",
+			"ONLY_A":      "x",
+			"STRIPE_KEY":  "sk_live_abc",
+		},
+	}
+	b := &models.Container{
+		ID:   "web-2",
+		Name: "web",
+		Env: map[string]string{
+			"LOG_LEVEL":     "debug",
+			"PORT":          "8080",
+			"DB_PASSWORD":   "two",
+			"GITHUB_TOKEN":  "ghp_abc",
+			"FEATURE_FLAGS": "beta",
+		},
+	}
+
+	diff := diffEnv(a, b)
+
+	if diff.SameCount != 1 {
+		t.Errorf("Expected 1 identical key, got %d", diff.SameCount)
+	}
+
+	if len(diff.OnlyInA) != 2 || diff.OnlyInA[0].Key != "ONLY_A" || diff.OnlyInA[0].Value != "x" {
+		t.Fatalf("Unexpected onlyInA: %+v", diff.OnlyInA)
+	}
+	if key := diff.OnlyInA[1]; key.Key != "STRIPE_KEY" || key.Value != redactedValue || !key.Redacted {
+		t.Errorf("Expected STRIPE_KEY to be redacted, got %+v", key)
+	}
+
+	if len(diff.OnlyInB) != 2 {
+		t.Fatalf("Expected 2 keys only in b, got %+v", diff.OnlyInB)
+	}
+	if diff.OnlyInB[0].Key != "FEATURE_FLAGS" || diff.OnlyInB[0].Redacted {
+		t.Errorf("Unexpected first onlyInB entry: %+v", diff.OnlyInB[0])
+	}
+	if token := diff.OnlyInB[1]; token.Key != "GITHUB_TOKEN" || token.Value != redactedValue || !token.Redacted {
+		t.Errorf("Expected GITHUB_TOKEN to be redacted, got %+v", token)
+	}
+
+	if len(diff.Different) != 2 {
+		t.Fatalf("Expected 2 differing keys, got %+v", diff.Different)
+	}
+	if pw := diff.Different[0]; pw.Key != "DB_PASSWORD" || pw.A != redactedValue || pw.B != redactedValue || !pw.Redacted {
+		t.Errorf("Expected DB_PASSWORD difference to be redacted, got %+v", pw)
+	}
+	if lvl := diff.Different[1]; lvl.Key != "LOG_LEVEL" || lvl.A != "info" || lvl.B != "debug" {
+		t.Errorf("Unexpected LOG_LEVEL difference: %+v", lvl)
+	}
+}
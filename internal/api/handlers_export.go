@@ -0,0 +1,356 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+
+	"evalgo.org/graphium/models"
+)
+
+// Media types of the graph export and import formats.
+const (
+	mimeJSON   = "application/json"
+	mimeJSONLD = "application/ld+json"
+	mimeNDJSON = "application/x-ndjson"
+)
+
+// schemaContext is the JSON-LD @context of all Graphium documents.
+const schemaContext = "https://schema.org"
+
+// importBatchSize is the number of imported documents saved per bulk request.
+const importBatchSize = 500
+
+// exportFlushInterval is how many exported documents are written between
+// flushes of the response.
+const exportFlushInterval = 200
+
+// exportGraph handles GET /api/v1/export
+// @Summary Export the graph
+// @Description Stream all hosts, containers and stacks, without CouchDB revisions. format=jsonld (default) returns one JSON-LD document with a schema.org @context and the documents in @graph; format=ndjson returns one JSON-LD document per line. Documents are fetched in batches and streamed, so large graphs are not buffered. The output can be loaded with POST /import.
+// @Tags Export
+// @Produce application/ld+json
+// @Produce application/x-ndjson
+// @Param format query string false "Output format" Enums(jsonld, ndjson) default(jsonld)
+// @Success 200 {object} map[string]interface{} "Graph export"
+// @Failure 400 {object} APIError "Invalid format"
+// @Router /export [get]
+func (s *Server) exportGraph(c echo.Context) error {
+	ndjson, err := parseGraphFormat(c.QueryParam("format"), "")
+	if err != nil {
+		return err
+	}
+
+	res := c.Response()
+	if ndjson {
+		res.Header().Set(echo.HeaderContentType, mimeNDJSON)
+		res.Header().Set(echo.HeaderContentDisposition, `attachment; filename="graphium-export.ndjson"`)
+	} else {
+		res.Header().Set(echo.HeaderContentType, mimeJSONLD)
+		res.Header().Set(echo.HeaderContentDisposition, `attachment; filename="graphium-export.jsonld"`)
+	}
+	res.WriteHeader(http.StatusOK)
+
+	if !ndjson {
+		if _, err := io.WriteString(res, `{"@context":"`+schemaContext+`","@graph":[`); err != nil {
+			return nil
+		}
+	}
+
+	written := 0
+	err = s.storage.ExportDocuments(func(doc map[string]interface{}) error {
+		if ndjson {
+			// Each line is a standalone JSON-LD document
+			if context, _ := doc["@context"].(string); context == "" {
+				doc["@context"] = schemaContext
+			}
+		} else if doc["@context"] == schemaContext {
+			// The graph's @context applies
+			delete(doc, "@context")
+		}
+
+		encoded, err := json.Marshal(doc)
+		if err != nil {
+			return err
+		}
+		if ndjson {
+			encoded = append(encoded, '\n')
+		} else if written > 0 {
+			encoded = append([]byte{','}, encoded...)
+		}
+		if _, err := res.Write(encoded); err != nil {
+			return err
+		}
+
+		written++
+		if written%exportFlushInterval == 0 {
+			res.Flush()
+		}
+		return nil
+	})
+	if err != nil {
+		// The status is already sent; a JSON-LD export is left unterminated,
+		// so clients can't mistake it for a complete one
+		s.logger.WithError(err).Error("Graph export failed")
+		return nil
+	}
+
+	if !ndjson {
+		if _, err := io.WriteString(res, "]}\n"); err != nil {
+			return nil
+		}
+	}
+	s.debugLog("Exported %d documents", written)
+	return nil
+}
+
+// importGraph handles POST /api/v1/import
+// @Summary Import a graph export
+// @Description Load hosts, containers and stacks from an export (GET /export), overwriting stored documents with the same ID, e.g. to restore a backup or migrate between instances. The format is taken from the format parameter, else from the Content-Type (application/x-ndjson or application/ld+json). The body is read as a stream and saved in batches. Documents must have an @id and the fields required by their type (SoftwareApplication, ComputerServer, ComputerSystem or ItemList); invalid documents and tombstoned containers fail individually. Results are per document, grouped by batch.
+// @Tags Export
+// @Accept application/ld+json
+// @Accept application/x-ndjson
+// @Produce json
+// @Param format query string false "Input format" Enums(jsonld, ndjson)
+// @Param body body object true "JSON-LD document with @graph, or one document per line"
+// @Success 200 {object} BulkResponse
+// @Failure 400 {object} APIError "Malformed input; documents before the error are imported"
+// @Failure 500 {object} APIError
+// @Router /import [post]
+func (s *Server) importGraph(c echo.Context) error {
+	ndjson, err := parseGraphFormat(c.QueryParam("format"), c.Request().Header.Get(echo.HeaderContentType))
+	if err != nil {
+		return err
+	}
+
+	importer := &graphImporter{server: s}
+	decode := decodeGraph
+	if ndjson {
+		decode = decodeNDJSON
+	}
+
+	if err := decode(c.Request().Body, importer.add); err != nil {
+		var storeErr *importStoreError
+		if errors.As(err, &storeErr) {
+			return InternalError("Failed to import documents", storeErr.Error())
+		}
+		// Flush what was read, so the error reports a consistent state
+		if flushErr := importer.flush(); flushErr != nil {
+			return InternalError("Failed to import documents", flushErr.Error())
+		}
+		return BadRequestError("Invalid import", fmt.Sprintf("%v (%d documents imported)", err, importer.response.Success))
+	}
+	if err := importer.flush(); err != nil {
+		return InternalError("Failed to import documents", err.Error())
+	}
+
+	s.logger.Infof("Imported %d documents, %d failed", importer.response.Success, importer.response.Failed)
+	if importer.response.Success > 0 {
+		s.BroadcastGraphEvent(EventGraphRefresh, map[string]int{"imported": importer.response.Success})
+	}
+	if importer.response.Results == nil {
+		importer.response.Results = []BulkResult{}
+	}
+	return c.JSON(http.StatusOK, importer.response)
+}
+
+// parseGraphFormat reports whether format selects NDJSON. Without a format,
+// an NDJSON contentType does; JSON-LD is the default.
+func parseGraphFormat(format, contentType string) (bool, error) {
+	switch format {
+	case "jsonld":
+		return false, nil
+	case "ndjson":
+		return true, nil
+	case "":
+		return strings.HasPrefix(contentType, mimeNDJSON), nil
+	default:
+		return false, BadRequestError("Invalid format parameter", "format must be jsonld or ndjson. Got: "+format)
+	}
+}
+
+// decodeGraph calls fn with each node of the @graph array of a JSON-LD
+// document, reading r as a stream. Other top-level keys are skipped.
+func decodeGraph(r io.Reader, fn func(node json.RawMessage) error) error {
+	dec := json.NewDecoder(r)
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return fmt.Errorf("expected a JSON-LD object with @graph")
+	}
+
+	found := false
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		if key, _ := tok.(string); key != "@graph" {
+			var skip json.RawMessage
+			if err := dec.Decode(&skip); err != nil {
+				return err
+			}
+			continue
+		}
+
+		found = true
+		if tok, err := dec.Token(); err != nil || tok != json.Delim('[') {
+			return fmt.Errorf("@graph must be an array")
+		}
+		for dec.More() {
+			var node json.RawMessage
+			if err := dec.Decode(&node); err != nil {
+				return err
+			}
+			if err := fn(node); err != nil {
+				return err
+			}
+		}
+		if _, err := dec.Token(); err != nil {
+			return err
+		}
+	}
+	if !found {
+		return fmt.Errorf("missing @graph")
+	}
+	return nil
+}
+
+// decodeNDJSON calls fn with each document of a stream of JSON documents,
+// one per line.
+func decodeNDJSON(r io.Reader, fn func(node json.RawMessage) error) error {
+	dec := json.NewDecoder(r)
+	for {
+		var node json.RawMessage
+		if err := dec.Decode(&node); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if err := fn(node); err != nil {
+			return err
+		}
+	}
+}
+
+// importStoreError is a storage failure while importing, as opposed to
+// malformed input.
+type importStoreError struct{ err error }
+
+func (e *importStoreError) Error() string { return e.err.Error() }
+
+// graphImporter validates imported documents and saves them in batches.
+type graphImporter struct {
+	server     *Server
+	hosts      []*models.Host
+	containers []*models.Container
+	stacks     []*models.Stack
+	response   BulkResponse
+}
+
+// add validates node and queues it, saving the queue once it is full.
+func (imp *graphImporter) add(node json.RawMessage) error {
+	if err := imp.queue(node); err != nil {
+		imp.fail(err.id, err.reason)
+	}
+	if len(imp.hosts)+len(imp.containers)+len(imp.stacks) >= importBatchSize {
+		if err := imp.flush(); err != nil {
+			return &importStoreError{err}
+		}
+	}
+	return nil
+}
+
+// invalidNode describes an imported document that can't be saved.
+type invalidNode struct {
+	id     string
+	reason string
+}
+
+// queue decodes node by its @type and queues it if it is valid.
+func (imp *graphImporter) queue(node json.RawMessage) *invalidNode {
+	var head struct {
+		ID   string `json:"@id"`
+		Type string `json:"@type"`
+	}
+	if err := json.Unmarshal(node, &head); err != nil {
+		return &invalidNode{reason: "document must be a JSON object: " + err.Error()}
+	}
+	if head.ID == "" {
+		return &invalidNode{reason: "@id is required"}
+	}
+	invalid := func(reason string) *invalidNode { return &invalidNode{id: head.ID, reason: reason} }
+
+	switch head.Type {
+	case "SoftwareApplication":
+		var container models.Container
+		if err := json.Unmarshal(node, &container); err != nil {
+			return invalid(err.Error())
+		}
+		if container.Name == "" || container.Image == "" {
+			return invalid("container name and image (executableName) are required")
+		}
+		imp.containers = append(imp.containers, &container)
+	case "ComputerServer", "ComputerSystem":
+		var host models.Host
+		if err := json.Unmarshal(node, &host); err != nil {
+			return invalid(err.Error())
+		}
+		if host.Name == "" || host.IPAddress == "" {
+			return invalid("host name and ipAddress are required")
+		}
+		imp.hosts = append(imp.hosts, &host)
+	case "ItemList":
+		var stack models.Stack
+		if err := json.Unmarshal(node, &stack); err != nil {
+			return invalid(err.Error())
+		}
+		if stack.Name == "" {
+			return invalid("stack name is required")
+		}
+		imp.stacks = append(imp.stacks, &stack)
+	default:
+		return invalid("unsupported @type " + head.Type)
+	}
+	return nil
+}
+
+// fail records a document that was not imported.
+func (imp *graphImporter) fail(id, reason string) {
+	imp.response.Total++
+	imp.response.Failed++
+	imp.response.Results = append(imp.response.Results, BulkResult{ID: id, Error: "invalid", Reason: reason})
+}
+
+// flush saves the queued documents.
+func (imp *graphImporter) flush() error {
+	if len(imp.hosts)+len(imp.containers)+len(imp.stacks) == 0 {
+		return nil
+	}
+
+	results, err := imp.server.storage.ImportDocuments(imp.hosts, imp.containers, imp.stacks)
+	if err != nil {
+		return err
+	}
+	imp.hosts, imp.containers, imp.stacks = nil, nil, nil
+
+	for _, result := range results {
+		imp.response.Total++
+		if result.OK {
+			imp.response.Success++
+		} else {
+			imp.response.Failed++
+		}
+		imp.response.Results = append(imp.response.Results, BulkResult{
+			ID:      result.ID,
+			Rev:     result.Rev,
+			Error:   result.Error,
+			Reason:  result.Reason,
+			Success: result.OK,
+		})
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package api
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestDecodeGraph(t *testing.T) {
+	doc := `{
+		"@context": "https://schema.org",
+		"generator": {"name": "graphium"},
+		"@graph": [
+			{"@id": "h1", "@type": "ComputerServer"},
+			{"@id": "c1", "@type": "SoftwareApplication"}
+		]
+	}`
+
+	var ids []string
+	err := decodeGraph(strings.NewReader(doc), func(node json.RawMessage) error {
+		var head struct {
+			ID string `json:"@id"`
+		}
+		if err := json.Unmarshal(node, &head); err != nil {
+			return err
+		}
+		ids = append(ids, head.ID)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("decodeGraph() error = %v", err)
+	}
+	if strings.Join(ids, ",") != "h1,c1" {
+		t.Errorf("Expected nodes h1,c1, got %v", ids)
+	}
+
+	for _, invalid := range []string{`[]`, `{"@context": "https://schema.org"}`, `{"@graph": {}}`, `{"@graph": [{"@id": "h1"}`} {
+		if err := decodeGraph(strings.NewReader(invalid), func(json.RawMessage) error { return nil }); err == nil {
+			t.Errorf("Expected an error for %s", invalid)
+		}
+	}
+}
+
+func TestDecodeNDJSON(t *testing.T) {
+	count := 0
+	err := decodeNDJSON(strings.NewReader("{\"@id\":\"h1\"}\n{\"@id\":\"c1\"}\n\n"), func(json.RawMessage) error {
+		count++
+		return nil
+	})
+	if err != nil || count != 2 {
+		t.Errorf("decodeNDJSON() = %d documents, %v; want 2, nil", count, err)
+	}
+
+	if err := decodeNDJSON(strings.NewReader("{\"@id\":\"h1\"}\n{\"@id\":"), func(json.RawMessage) error { return nil }); err == nil {
+		t.Error("Expected an error for a truncated line")
+	}
+}
+
+func TestParseGraphFormat(t *testing.T) {
+	tests := []struct {
+		format, contentType string
+		wantNDJSON          bool
+		wantErr             bool
+	}{
+		{"", "", false, false},
+		{"", mimeJSONLD, false, false},
+		{"", mimeNDJSON, true, false},
+		{"ndjson", mimeJSON, true, false},
+		{"jsonld", mimeNDJSON, false, false},
+		{"csv", "", false, true},
+	}
+	for _, tt := range tests {
+		ndjson, err := parseGraphFormat(tt.format, tt.contentType)
+		if ndjson != tt.wantNDJSON || (err != nil) != tt.wantErr {
+			t.Errorf("parseGraphFormat(%q, %q) = %v, %v; want %v, error %v", tt.format, tt.contentType, ndjson, err, tt.wantNDJSON, tt.wantErr)
+		}
+	}
+}
+
+func TestGraphImporter_Queue(t *testing.T) {
+	tests := []struct {
+		name    string
+		node    string
+		wantErr bool
+	}{
+		{"container", `{"@id": "c1", "@type": "SoftwareApplication", "name": "web", "executableName": "nginx"}`, false},
+		{"host", `{"@id": "h1", "@type": "ComputerServer", "name": "h1", "ipAddress": "10.0.0.1"}`, false},
+		{"stack", `{"@id": "s1", "@type": "ItemList", "name": "shop"}`, false},
+		{"container without image", `{"@id": "c2", "@type": "SoftwareApplication", "name": "web"}`, true},
+		{"host without IP", `{"@id": "h2", "@type": "ComputerSystem", "name": "h2"}`, true},
+		{"missing @id", `{"@type": "ItemList", "name": "shop"}`, true},
+		{"unknown type", `{"@id": "x1", "@type": "Person"}`, true},
+		{"not an object", `[1, 2]`, true},
+	}
+
+	imp := &graphImporter{}
+	queued := 0
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			invalid := imp.queue(json.RawMessage(tt.node))
+			if (invalid != nil) != tt.wantErr {
+				t.Errorf("queue() = %+v, wantErr %v", invalid, tt.wantErr)
+			}
+			if invalid == nil {
+				queued++
+			}
+		})
+	}
+	if len(imp.hosts) != 1 || len(imp.containers) != 1 || len(imp.stacks) != 1 || queued != 3 {
+		t.Errorf("Expected one host, container and stack queued, got %d, %d, %d", len(imp.hosts), len(imp.containers), len(imp.stacks))
+	}
+}
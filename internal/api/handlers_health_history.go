@@ -0,0 +1,112 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"evalgo.org/graphium/models"
+)
+
+// Health history windows.
+const (
+	defaultHealthWindow = 24 * time.Hour
+	maxHealthWindow     = 30 * 24 * time.Hour
+)
+
+// HealthHistoryResponse is a container's status and health timeline over a
+// window, one segment per state, for rendering as a sparkline.
+type HealthHistoryResponse struct {
+	ContainerID string    `json:"containerId"`
+	Window      string    `json:"window"`
+	From        time.Time `json:"from"`
+	To          time.Time `json:"to"`
+
+	// Transitions is the number of state changes within the window; a high
+	// count indicates a flapping container
+	Transitions int `json:"transitions"`
+
+	// UpRatio is the share (0-1) of the recorded time the container was
+	// running and not unhealthy
+	UpRatio float64 `json:"upRatio"`
+
+	Segments []models.HealthSegment `json:"segments"`
+}
+
+// recordHealthTransition adds a container's status and health to its health
+// history. Failures are logged, not returned, so they never fail the update.
+func (s *Server) recordHealthTransition(container *models.Container) {
+	if err := s.storage.RecordHealthTransition(container, time.Now()); err != nil {
+		s.logger.WithError(err).Warn("Failed to record health of container " + container.ID)
+	}
+}
+
+// healthChanged reports whether changes include the status or health.
+func healthChanged(changes []models.FieldChange) bool {
+	for _, change := range changes {
+		if change.Field == "status" || change.Field == "health" {
+			return true
+		}
+	}
+	return false
+}
+
+// newHealthHistoryResponse builds the timeline of history from now-window to now.
+func newHealthHistoryResponse(containerID string, history *models.HealthHistory, window time.Duration, now time.Time) HealthHistoryResponse {
+	from := now.Add(-window)
+	segments, transitions := history.Timeline(from, now)
+
+	var up, total time.Duration
+	for _, segment := range segments {
+		duration := segment.End.Sub(segment.Start)
+		total += duration
+		if segment.Up {
+			up += duration
+		}
+	}
+
+	resp := HealthHistoryResponse{
+		ContainerID: containerID,
+		Window:      window.String(),
+		From:        from,
+		To:          now,
+		Transitions: transitions,
+		Segments:    segments,
+	}
+	if total > 0 {
+		resp.UpRatio = float64(up) / float64(total)
+	}
+	return resp
+}
+
+// getContainerHealthHistory handles GET /api/v1/containers/:id/health-history
+// @Summary Get container health history
+// @Description Get a container's status and health timeline (e.g. healthy, unhealthy, starting, stopped) as segments suitable for a sparkline, with the number of transitions to spot flapping. The last 500 transitions per container are kept.
+// @Tags Containers
+// @Produce json
+// @Param id path string true "Container ID"
+// @Param window query string false "Time window as a duration, up to 720h (default: 24h)"
+// @Success 200 {object} HealthHistoryResponse
+// @Failure 400 {object} APIError
+// @Failure 500 {object} APIError
+// @Router /containers/{id}/health-history [get]
+func (s *Server) getContainerHealthHistory(c echo.Context) error {
+	id := c.Param("id")
+
+	window := defaultHealthWindow
+	if raw := c.QueryParam("window"); raw != "" {
+		parsed, err := time.ParseDuration(raw)
+		if err != nil || parsed <= 0 || parsed > maxHealthWindow {
+			return BadRequestError("Invalid window", "window must be a positive duration up to 720h, e.g. 24h")
+		}
+		window = parsed
+	}
+
+	history, err := s.storage.GetHealthHistory(id)
+	if err != nil {
+		return InternalError("Failed to get container health history", err.Error())
+	}
+
+	return c.JSON(http.StatusOK, newHealthHistoryResponse(id, history, window, time.Now()))
+}
@@ -0,0 +1,48 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"evalgo.org/graphium/models"
+)
+
+func TestNewHealthHistoryResponse(t *testing.T) {
+	now := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	history := &models.HealthHistory{}
+	history.Record("running", "healthy", now.Add(-48*time.Hour))
+	history.Record("running", "unhealthy", now.Add(-6*time.Hour))
+	history.Record("running", "healthy", now.Add(-5*time.Hour))
+
+	resp := newHealthHistoryResponse("c1", history, 24*time.Hour, now)
+	if resp.Window != "24h0m0s" || !resp.From.Equal(now.Add(-24*time.Hour)) {
+		t.Errorf("Unexpected window %s from %s", resp.Window, resp.From)
+	}
+	if resp.Transitions != 2 || len(resp.Segments) != 3 {
+		t.Errorf("Expected 2 transitions and 3 segments, got %d and %d", resp.Transitions, len(resp.Segments))
+	}
+	if want := 23.0 / 24.0; resp.UpRatio != want {
+		t.Errorf("Expected up ratio %f, got %f", want, resp.UpRatio)
+	}
+}
+
+func TestGetContainerHealthHistory_InvalidWindow(t *testing.T) {
+	s := &Server{}
+	e := echo.New()
+
+	for _, window := range []string{"yesterday", "-1h", "1000h"} {
+		c := e.NewContext(httptest.NewRequest(http.MethodGet, "/api/v1/containers/c1/health-history?window="+window, nil), httptest.NewRecorder())
+		c.SetParamNames("id")
+		c.SetParamValues("c1")
+
+		err := s.getContainerHealthHistory(c)
+		apiErr, ok := err.(*APIError)
+		if !ok || apiErr.Code != http.StatusBadRequest {
+			t.Errorf("Expected 400 for window %q, got %v", window, err)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	"evalgo.org/graphium/models"
+)
+
+// HostCapacityResponse reports a host's capacity and the resources committed
+// to stack containers placed on it.
+type HostCapacityResponse struct {
+	HostID string `json:"hostId"`
+
+	// CPU and Memory are the host's total capacity (0 means unknown, not enforced)
+	CPU    int   `json:"cpu"`
+	Memory int64 `json:"memory"`
+
+	CommittedCPUs   float64 `json:"committedCpus"`
+	CommittedMemory int64   `json:"committedMemory"`
+
+	// RemainingCPUs and RemainingMemory are negative when the host is overcommitted
+	RemainingCPUs   float64 `json:"remainingCpus"`
+	RemainingMemory int64   `json:"remainingMemory"`
+
+	AllowOvercommit bool `json:"allowOvercommit"`
+}
+
+// getHostCapacity handles GET /api/v1/hosts/:id/capacity
+// @Summary Get host capacity
+// @Description Show the CPU and memory committed on a host by the current deployments of all stacks, and what remains for new placements. A container commits its reservation, or its limit if it has no reservation.
+// @Tags Hosts
+// @Produce json
+// @Param id path string true "Host ID"
+// @Success 200 {object} HostCapacityResponse
+// @Failure 404 {object} APIError
+// @Router /hosts/{id}/capacity [get]
+func (s *Server) getHostCapacity(c echo.Context) error {
+	id := c.Param("id")
+
+	resolver := &APIHostResolver{storage: s.storage}
+	info, err := resolver.ResolveHost(id)
+	if err != nil {
+		return NotFoundError("Host", id)
+	}
+
+	allowOvercommit := s.config.Server.AllowOvercommit
+	if info.Host.Datacenter != "" {
+		if policy, err := s.storage.GetDatacenterPolicy(info.Host.Datacenter); err == nil && policy.AllowOvercommit != nil {
+			allowOvercommit = *policy.AllowOvercommit
+		}
+	}
+
+	return c.JSON(http.StatusOK, newHostCapacityResponse(info, allowOvercommit))
+}
+
+// newHostCapacityResponse computes the remaining capacity of a resolved host.
+func newHostCapacityResponse(info *models.HostInfo, allowOvercommit bool) HostCapacityResponse {
+	return HostCapacityResponse{
+		HostID:          info.Host.ID,
+		CPU:             info.Host.CPU,
+		Memory:          info.Host.Memory,
+		CommittedCPUs:   info.CurrentLoad.CommittedCPUs,
+		CommittedMemory: info.CurrentLoad.CommittedMemory,
+		RemainingCPUs:   float64(info.Host.CPU) - info.CurrentLoad.CommittedCPUs,
+		RemainingMemory: info.Host.Memory - info.CurrentLoad.CommittedMemory,
+		AllowOvercommit: allowOvercommit,
+	}
+}
@@ -0,0 +1,94 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	"evalgo.org/graphium/internal/auth"
+	"evalgo.org/graphium/models"
+)
+
+// PruneHostRequest is the request body for POST /api/v1/hosts/:id/prune.
+type PruneHostRequest struct {
+	Images     bool   `json:"images"`
+	Volumes    bool   `json:"volumes"`
+	Networks   bool   `json:"networks"`
+	BuildCache bool   `json:"buildCache"`
+	Mode       string `json:"mode"`
+}
+
+// pruneHost handles POST /api/v1/hosts/:id/prune
+// @Summary Prune unused Docker resources on a host
+// @Description Queue a prune task for the host's agent. Mode "dangling" (default) removes dangling images and anonymous volumes; "all" removes every unused resource of the selected kinds.
+// @Description Resources in use by a container or labelled graphium.managed are never removed. The task result reports pruned counts and reclaimed_bytes.
+// @Tags Hosts
+// @Accept json
+// @Produce json
+// @Param id path string true "Host ID"
+// @Param request body PruneHostRequest true "Resources to prune"
+// @Success 202 {object} models.AgentTask "Queued prune task"
+// @Failure 400 {object} APIError
+// @Failure 404 {object} APIError
+// @Failure 500 {object} APIError
+// @Router /hosts/{id}/prune [post]
+func (s *Server) pruneHost(c echo.Context) error {
+	hostID := c.Param("id")
+
+	var req PruneHostRequest
+	if err := c.Bind(&req); err != nil {
+		return BadRequestError("Invalid request body", err.Error())
+	}
+
+	payload, err := newPrunePayload(req)
+	if err != nil {
+		return BadRequestError("Invalid prune request", err.Error())
+	}
+
+	if _, err := s.storage.GetHost(hostID); err != nil {
+		return NotFoundError("Host", hostID)
+	}
+
+	createdBy := ""
+	if userID, ok := auth.GetUserID(c); ok {
+		createdBy = userID
+	}
+
+	task := newAgentTask("DeleteAction", "Prune unused Docker resources on "+hostID, hostID, createdBy)
+	if err := task.SetPayload(payload); err != nil {
+		return InternalError("Failed to encode prune payload", err.Error())
+	}
+
+	if err := s.queueTask(task); err != nil {
+		return err
+	}
+
+	c.Logger().Infof("Prune task %s queued for host %s by %s (mode %s)", task.ID, hostID, createdBy, payload.Mode)
+
+	return c.JSON(http.StatusAccepted, task)
+}
+
+// newPrunePayload validates a prune request and converts it to a task payload.
+func newPrunePayload(req PruneHostRequest) (*models.PrunePayload, error) {
+	if !req.Images && !req.Volumes && !req.Networks && !req.BuildCache {
+		return nil, fmt.Errorf("select at least one of images, volumes, networks or buildCache")
+	}
+
+	mode := req.Mode
+	if mode == "" {
+		mode = models.PruneModeDangling
+	}
+	if mode != models.PruneModeDangling && mode != models.PruneModeAll {
+		return nil, fmt.Errorf("mode must be %q or %q, got %q", models.PruneModeDangling, models.PruneModeAll, mode)
+	}
+
+	return &models.PrunePayload{
+		Action:     "prune",
+		Images:     req.Images,
+		Volumes:    req.Volumes,
+		Networks:   req.Networks,
+		BuildCache: req.BuildCache,
+		Mode:       mode,
+	}, nil
+}
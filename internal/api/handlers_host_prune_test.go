@@ -0,0 +1,27 @@
+package api
+
+import (
+	"testing"
+
+	"evalgo.org/graphium/models"
+)
+
+func TestNewPrunePayload(t *testing.T) {
+	payload, err := newPrunePayload(PruneHostRequest{Images: true, Volumes: true})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if payload.Action != "prune" || payload.Mode != models.PruneModeDangling {
+		t.Errorf("Expected prune action in dangling mode, got %+v", payload)
+	}
+	if !payload.Images || !payload.Volumes || payload.Networks || payload.BuildCache {
+		t.Errorf("Unexpected resource selection: %+v", payload)
+	}
+
+	if _, err := newPrunePayload(PruneHostRequest{Mode: models.PruneModeAll}); err == nil {
+		t.Error("Expected an error when no resource kind is selected")
+	}
+	if _, err := newPrunePayload(PruneHostRequest{Networks: true, Mode: "everything"}); err == nil {
+		t.Error("Expected an error for an unknown mode")
+	}
+}
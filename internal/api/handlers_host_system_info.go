@@ -0,0 +1,108 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"evalgo.org/graphium/internal/auth"
+	"evalgo.org/graphium/models"
+)
+
+// getHostSystemInfo handles GET /api/v1/hosts/:id/system-info
+// @Summary Get host Docker system info
+// @Description Get the Docker engine configuration and state last reported by the host's agent: engine version, OS, kernel, storage and cgroup drivers, container counts by state, image count and disk size. Agents refresh it periodically; POST /hosts/{id}/system-info/refresh requests a fresh report.
+// @Tags Hosts
+// @Produce json
+// @Param id path string true "Host ID"
+// @Success 200 {object} models.HostSystemInfo
+// @Failure 404 {object} APIError
+// @Router /hosts/{id}/system-info [get]
+func (s *Server) getHostSystemInfo(c echo.Context) error {
+	id := c.Param("id")
+
+	info, err := s.storage.GetHostSystemInfo(id)
+	if err != nil {
+		return NotFoundError("Host system info", id)
+	}
+
+	return c.JSON(http.StatusOK, info)
+}
+
+// updateHostSystemInfo handles PUT /api/v1/hosts/:id/system-info
+// @Summary Report host Docker system info
+// @Description Replace the Docker system info of a host. Called by the host's agent.
+// @Tags Hosts
+// @Accept json
+// @Produce json
+// @Param id path string true "Host ID"
+// @Param info body models.HostSystemInfo true "Docker system info"
+// @Success 200 {object} models.HostSystemInfo
+// @Failure 400 {object} APIError
+// @Failure 404 {object} APIError
+// @Failure 500 {object} APIError
+// @Router /hosts/{id}/system-info [put]
+func (s *Server) updateHostSystemInfo(c echo.Context) error {
+	id := c.Param("id")
+
+	if _, err := s.storage.GetHost(id); err != nil {
+		return NotFoundError("Host", id)
+	}
+
+	var info models.HostSystemInfo
+	if err := c.Bind(&info); err != nil {
+		return BadRequestError("Invalid request body", "Failed to parse JSON: "+err.Error())
+	}
+
+	info.HostID = id
+	if info.ReportedAt.IsZero() {
+		info.ReportedAt = time.Now()
+	}
+
+	if err := s.storage.SaveHostSystemInfo(&info); err != nil {
+		return InternalError("Failed to save host system info", err.Error())
+	}
+
+	s.BroadcastGraphEvent("host_system_info_updated", map[string]interface{}{
+		"hostId": id,
+	})
+
+	return c.JSON(http.StatusOK, info)
+}
+
+// refreshHostSystemInfo handles POST /api/v1/hosts/:id/system-info/refresh
+// @Summary Refresh host Docker system info
+// @Description Queue a task asking the host's agent to report its Docker system info now, instead of waiting for the next periodic report.
+// @Tags Hosts
+// @Produce json
+// @Param id path string true "Host ID"
+// @Success 202 {object} models.AgentTask "Queued refresh task"
+// @Failure 404 {object} APIError
+// @Failure 500 {object} APIError
+// @Router /hosts/{id}/system-info/refresh [post]
+func (s *Server) refreshHostSystemInfo(c echo.Context) error {
+	hostID := c.Param("id")
+
+	if _, err := s.storage.GetHost(hostID); err != nil {
+		return NotFoundError("Host", hostID)
+	}
+
+	createdBy := ""
+	if userID, ok := auth.GetUserID(c); ok {
+		createdBy = userID
+	}
+
+	task := newAgentTask("CheckAction", "Refresh Docker system info of "+hostID, hostID, createdBy)
+	if err := task.SetPayload(map[string]interface{}{
+		"checkType": models.CheckTypeSystemInfo,
+	}); err != nil {
+		return InternalError("Failed to encode refresh payload", err.Error())
+	}
+
+	if err := s.queueTask(task); err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusAccepted, task)
+}
@@ -1,51 +1,91 @@
 package api
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 
 	"github.com/labstack/echo/v4"
 
+	"evalgo.org/graphium/internal/auth"
 	"evalgo.org/graphium/models"
 )
 
 // listHosts handles GET /api/v1/hosts
 // @Summary List hosts
-// @Description Get a paginated list of hosts with optional filtering by status and datacenter
+// @Description Get a paginated list of hosts, sorted by name unless sort is given, with optional filtering by status and datacenter and a case-insensitive search (q) over name, ID, IP address and datacenter. Ties are ordered by ID, so pages are stable; total is the number of matching hosts. Programs walking all hosts should prefer cursor paging
 // @Tags Hosts
 // @Accept json
 // @Produce json
 // @Param limit query int false "Maximum number of items to return" default(10)
 // @Param offset query int false "Number of items to skip" default(0)
-// @Param status query string false "Filter by host status"
-// @Param datacenter query string false "Filter by datacenter location"
-// @Success 200 {object} PaginatedHostsResponse
+// @Param status query string false "Filter by host status; comma-separated statuses match any"
+// @Param datacenter query string false "Filter by datacenter location; comma-separated datacenters match any"
+// @Param q query string false "Search name, ID, IP address and datacenter (substring, case-insensitive)"
+// @Param cursor query string false "Cursor paging: empty for the first page, then the previous page's next_cursor. Pages are ordered by type (ComputerServer before ComputerSystem) and ID and have no total"
+// @Param sort query string false "Sort field" Enums(name, status, datacenter) default(name)
+// @Param order query string false "Sort order" Enums(asc, desc) default(asc)
+// @Param If-None-Match header string false "ETag of a cached page"
+// @Success 200 {object} PaginatedHostsResponse "Hosts (a CursorHostsResponse with cursor)"
+// @Header 200 {string} ETag "Weak ETag of the page"
+// @Success 304 "Page unchanged since the given ETag"
+// @Failure 400 {object} APIError
 // @Failure 500 {object} ErrorResponse
 // @Router /hosts [get]
 func (s *Server) listHosts(c echo.Context) error {
 	// Parse query parameters
 	filters := make(map[string]interface{})
 
-	if status := c.QueryParam("status"); status != "" {
-		filters["status"] = status
+	if statuses := parseListParam(c, "status"); len(statuses) > 0 {
+		filters["status"] = listFilter(statuses)
 	}
-	if datacenter := c.QueryParam("datacenter"); datacenter != "" {
+	if datacenters := parseListParam(c, "datacenter"); len(datacenters) > 0 {
+		filters["location"] = listFilter(datacenters)
+	}
+	// Share links scoped to a datacenter only see its hosts
+	if datacenter, ok := auth.GetShareScope(c); ok && datacenter != "" {
 		filters["location"] = datacenter
 	}
 
+	query, err := parseSearchQuery(c)
+	if err != nil {
+		return err
+	}
+
+	if after, cursor, err := parseCursor(c); err != nil {
+		return err
+	} else if cursor {
+		return s.listHostsByCursor(c, query, filters, after)
+	}
+
+	order, err := parseListSort(c, hostSortFields)
+	if err != nil {
+		return err
+	}
+
 	// Parse pagination parameters
 	limit, offset := parsePagination(c)
 
-	hosts, err := s.storage.ListHosts(filters)
+	// Matches are sorted before the page is sliced
+	var hosts []*models.Host
+	var total int
+	if query != "" {
+		hosts, total, err = s.storage.SearchHosts(query, filters, order, limit, offset)
+	} else {
+		hosts, total, err = s.storage.ListHostsPage(filters, order, limit, offset)
+	}
 	if err != nil {
 		return InternalError("Failed to list hosts", err.Error())
 	}
 
-	// Get total count before pagination
-	total := len(hosts)
-
-	// Apply pagination
-	hosts = paginateSliceHosts(hosts, limit, offset)
+	revisions := make([]docRevision, len(hosts))
+	for i, host := range hosts {
+		revisions[i] = docRevision{ID: host.ID, Rev: host.Rev}
+	}
+	if notModified(c, listETag(total, revisions)) {
+		return c.NoContent(http.StatusNotModified)
+	}
 
 	return c.JSON(http.StatusOK, PaginatedHostsResponse{
 		Count:  len(hosts),
@@ -56,14 +96,49 @@ func (s *Server) listHosts(c echo.Context) error {
 	})
 }
 
+// listHostsByCursor responds with the cursor page of hosts after after.
+func (s *Server) listHostsByCursor(c echo.Context, query string, filters map[string]interface{}, after string) error {
+	limit, _ := parsePagination(c)
+
+	var hosts []*models.Host
+	var next string
+	var err error
+	if query != "" {
+		hosts, next, err = s.storage.SearchHostsAfter(query, filters, after, limit)
+	} else {
+		hosts, next, err = s.storage.ListHostsAfter(filters, after, limit)
+	}
+	if err != nil {
+		return InternalError("Failed to list hosts", err.Error())
+	}
+
+	revisions := make([]docRevision, len(hosts))
+	for i, host := range hosts {
+		revisions[i] = docRevision{ID: host.ID, Rev: host.Rev}
+	}
+	if notModified(c, listETag(0, append(revisions, docRevision{ID: next}))) {
+		return c.NoContent(http.StatusNotModified)
+	}
+
+	return c.JSON(http.StatusOK, CursorHostsResponse{
+		Count:      len(hosts),
+		Limit:      limit,
+		NextCursor: encodeCursor(next),
+		Hosts:      hosts,
+	})
+}
+
 // getHost handles GET /api/v1/hosts/:id
 // @Summary Get a host by ID
-// @Description Retrieve detailed information about a specific host
+// @Description Retrieve detailed information about a specific host. The ETag is the document revision; a matching If-None-Match returns 304.
 // @Tags Hosts
 // @Accept json
 // @Produce json
 // @Param id path string true "Host ID"
+// @Param If-None-Match header string false "ETag of a cached copy"
 // @Success 200 {object} models.Host
+// @Header 200 {string} ETag "Document revision"
+// @Success 304 "Host unchanged since the given ETag"
 // @Failure 400 {object} ErrorResponse
 // @Failure 404 {object} ErrorResponse
 // @Router /hosts/{id} [get]
@@ -75,10 +150,13 @@ func (s *Server) getHost(c echo.Context) error {
 	}
 
 	host, err := s.storage.GetHost(id)
-	if err != nil {
+	if err != nil || !shareScopeAllowsHost(c, host) {
 		return NotFoundError("Host", id)
 	}
 
+	if notModified(c, revisionETag(host.Rev)) {
+		return c.NoContent(http.StatusNotModified)
+	}
 	return c.JSON(http.StatusOK, host)
 }
 
@@ -108,6 +186,9 @@ func (s *Server) createHost(c echo.Context) error {
 	if host.IPAddress == "" {
 		fieldErrors["ipAddress"] = "Host IP address is required"
 	}
+	if err := host.ValidateDockerEndpoint(); err != nil {
+		fieldErrors["dockerEndpoint"] = err.Error()
+	}
 	if len(fieldErrors) > 0 {
 		return ValidationError("Validation failed", fieldErrors)
 	}
@@ -130,7 +211,7 @@ func (s *Server) createHost(c echo.Context) error {
 
 // updateHost handles PUT /api/v1/hosts/:id
 // @Summary Update a host
-// @Description Update an existing host with new information. ID and revision are preserved.
+// @Description Update an existing host with new information. ID and revision are preserved, as are tags and the Docker connection (dockerEndpoint, dockerTLS, dockerSSHKeyPath) when omitted; an empty or null dockerEndpoint clears the connection.
 // @Tags Hosts
 // @Accept json
 // @Produce json
@@ -154,8 +235,17 @@ func (s *Server) updateHost(c echo.Context) error {
 		return NotFoundError("Host", id)
 	}
 
+	// The fields present in the body tell a cleared setting from an omitted one
+	body, err := io.ReadAll(c.Request().Body)
+	if err != nil {
+		return BadRequestError("Invalid request body", err.Error())
+	}
 	var host models.Host
-	if err := c.Bind(&host); err != nil {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &host); err != nil {
+		return BadRequestError("Invalid request body", "Failed to parse JSON: "+err.Error())
+	}
+	if err := json.Unmarshal(body, &fields); err != nil {
 		return BadRequestError("Invalid request body", "Failed to parse JSON: "+err.Error())
 	}
 
@@ -167,6 +257,9 @@ func (s *Server) updateHost(c echo.Context) error {
 	if host.IPAddress == "" {
 		fieldErrors["ipAddress"] = "Host IP address is required"
 	}
+	if err := host.ValidateDockerEndpoint(); err != nil {
+		fieldErrors["dockerEndpoint"] = err.Error()
+	}
 	if len(fieldErrors) > 0 {
 		return ValidationError("Validation failed", fieldErrors)
 	}
@@ -175,6 +268,14 @@ func (s *Server) updateHost(c echo.Context) error {
 	host.ID = id
 	host.Rev = existing.Rev
 
+	// Agents don't know about tags; keep them unless the update sets them
+	if host.Tags == nil {
+		host.Tags = existing.Tags
+	}
+
+	// Nor about the Docker endpoint the server connects through
+	keepDockerConnection(&host, existing, fields)
+
 	// Update host
 	if err := s.storage.SaveHost(&host); err != nil {
 		return InternalError("Failed to update host", err.Error())
@@ -186,6 +287,23 @@ func (s *Server) updateHost(c echo.Context) error {
 	return c.JSON(http.StatusOK, host)
 }
 
+// dockerConnectionFields are the JSON fields of a host's Docker connection.
+var dockerConnectionFields = []string{"dockerEndpoint", "dockerTLS", "dockerSSHKeyPath"}
+
+// keepDockerConnection keeps the Docker connection of the existing host
+// unless the update's fields include any of it. An empty or null
+// dockerEndpoint clears the connection.
+func keepDockerConnection(host, existing *models.Host, fields map[string]json.RawMessage) {
+	for _, field := range dockerConnectionFields {
+		if _, ok := fields[field]; ok {
+			return
+		}
+	}
+	host.DockerEndpoint = existing.DockerEndpoint
+	host.DockerTLS = existing.DockerTLS
+	host.DockerSSHKeyPath = existing.DockerSSHKeyPath
+}
+
 // deleteHost handles DELETE /api/v1/hosts/:id
 // @Summary Delete a host
 // @Description Delete a host by its ID. This operation broadcasts a WebSocket event.
@@ -305,6 +423,9 @@ func (s *Server) getHostsByDatacenter(c echo.Context) error {
 	if datacenter == "" {
 		return BadRequestError("Datacenter is required", "The 'datacenter' parameter cannot be empty")
 	}
+	if !shareScopeAllowsDatacenter(c, datacenter) {
+		return NewAPIError(http.StatusForbidden, "Forbidden", "Share link does not grant access to this datacenter")
+	}
 
 	hosts, err := s.storage.GetHostsByDatacenter(datacenter)
 	if err != nil {
@@ -319,7 +440,7 @@ func (s *Server) getHostsByDatacenter(c echo.Context) error {
 
 // updateHostMetrics handles PUT /api/v1/hosts/:id/metrics
 // @Summary Update host metrics
-// @Description Update CPU and memory usage metrics for a host
+// @Description Update CPU and memory usage metrics for a host, and optionally its status (active or draining) as reported by its agent
 // @Tags Hosts
 // @Accept json
 // @Produce json
@@ -349,6 +470,7 @@ func (s *Server) updateHostMetrics(c echo.Context) error {
 		MemoryUsage        int64   `json:"memoryUsage"`
 		MemoryUsagePercent float64 `json:"memoryUsagePercent"`
 		LastMetricsUpdate  string  `json:"lastMetricsUpdate"`
+		Status             string  `json:"status"`
 	}
 
 	if err := c.Bind(&update); err != nil {
@@ -361,6 +483,15 @@ func (s *Server) updateHostMetrics(c echo.Context) error {
 	host.MemoryUsagePercent = update.MemoryUsagePercent
 	host.LastMetricsUpdate = update.LastMetricsUpdate
 
+	// Agents report "draining" while they shut down and "active" otherwise
+	switch update.Status {
+	case "":
+	case "active", "draining":
+		host.Status = update.Status
+	default:
+		return BadRequestError("Invalid host status", "status must be active or draining")
+	}
+
 	// Update host in storage
 	if err := s.storage.UpdateHost(host); err != nil {
 		return InternalError("Failed to update host metrics", err.Error())
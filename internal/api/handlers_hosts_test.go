@@ -0,0 +1,42 @@
+package api
+
+import (
+	"encoding/json"
+	"testing"
+
+	"evalgo.org/graphium/models"
+)
+
+func TestKeepDockerConnection(t *testing.T) {
+	existing := &models.Host{
+		DockerEndpoint:   "ssh://deploy@web-1",
+		DockerSSHKeyPath: "/keys/web-1",
+	}
+
+	tests := []struct {
+		name     string
+		body     string
+		endpoint string
+		keyPath  string
+	}{
+		{"omitted", `{"name": "web-1"}`, "ssh://deploy@web-1", "/keys/web-1"},
+		{"cleared", `{"name": "web-1", "dockerEndpoint": ""}`, "", ""},
+		{"null", `{"name": "web-1", "dockerEndpoint": null}`, "", ""},
+		{"replaced", `{"name": "web-1", "dockerEndpoint": "tcp://web-1:2375"}`, "tcp://web-1:2375", ""},
+	}
+	for _, tt := range tests {
+		var host models.Host
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal([]byte(tt.body), &host); err != nil {
+			t.Fatal(err)
+		}
+		if err := json.Unmarshal([]byte(tt.body), &fields); err != nil {
+			t.Fatal(err)
+		}
+		keepDockerConnection(&host, existing, fields)
+		if host.DockerEndpoint != tt.endpoint || host.DockerSSHKeyPath != tt.keyPath {
+			t.Errorf("%s: got endpoint %q and key path %q, want %q and %q",
+				tt.name, host.DockerEndpoint, host.DockerSSHKeyPath, tt.endpoint, tt.keyPath)
+		}
+	}
+}
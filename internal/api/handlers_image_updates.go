@@ -0,0 +1,53 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	"evalgo.org/graphium/internal/imageupdates"
+)
+
+// getOutdatedContainers handles GET /api/v1/query/containers/outdated
+// @Summary List containers with image updates
+// @Description List containers whose image tag points to a newer digest in the registry
+// @Tags Containers
+// @Produce json
+// @Success 200 {object} ContainersResponse
+// @Failure 500 {object} APIError
+// @Router /query/containers/outdated [get]
+func (s *Server) getOutdatedContainers(c echo.Context) error {
+	containers, err := s.storage.ListContainers(map[string]interface{}{
+		"updateAvailable": true,
+	})
+	if err != nil {
+		return InternalError("Failed to query outdated containers", err.Error())
+	}
+
+	return c.JSON(http.StatusOK, ContainersResponse{
+		Count:      len(containers),
+		Containers: containers,
+	})
+}
+
+// checkImageUpdates handles POST /api/v1/containers/image-updates/check
+// @Summary Check for image updates
+// @Description Compare every container's image digest with the current digest of its tag in the registry
+// @Tags Containers
+// @Produce json
+// @Success 200 {object} imageupdates.Report
+// @Failure 409 {object} APIError "A check is already running"
+// @Failure 500 {object} APIError
+// @Router /containers/image-updates/check [post]
+func (s *Server) checkImageUpdates(c echo.Context) error {
+	report, err := s.imageChecker.CheckAll(c.Request().Context())
+	if errors.Is(err, imageupdates.ErrCheckRunning) {
+		return ConflictError("Image update check already running", err.Error())
+	}
+	if err != nil {
+		return InternalError("Image update check failed", err.Error())
+	}
+
+	return c.JSON(http.StatusOK, report)
+}
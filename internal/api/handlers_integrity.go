@@ -69,6 +69,26 @@ func (s *Server) scanIntegrity(c echo.Context) error {
 	return c.JSON(http.StatusOK, report)
 }
 
+// deduplicateContainers handles POST /api/v1/integrity/deduplicate
+// @Summary Delete duplicate container documents
+// @Description Delete all but the newest document of every container stored more than once (same @id). The newest copy has the latest dateCreated, then the highest revision; the surviving copy is never deleted.
+// @Tags Integrity
+// @Produce json
+// @Success 200 {object} storage.DedupeResult
+// @Failure 500 {object} ErrorResponse
+// @Router /integrity/deduplicate [post]
+func (s *Server) deduplicateContainers(c echo.Context) error {
+	result, err := s.storage.DeduplicateContainers()
+	if err != nil {
+		return InternalError("Container deduplication failed", err.Error())
+	}
+
+	if result.Removed > 0 {
+		s.logger.Infof("Removed %d duplicate document(s) of %d container(s)", result.Removed, result.Containers)
+	}
+	return c.JSON(http.StatusOK, result)
+}
+
 // getHealth handles GET /api/v1/integrity/health
 // @Summary Get database health status
 // @Description Get comprehensive database health metrics including issue counts, health score, and recommendations
@@ -0,0 +1,50 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+	"golang.org/x/time/rate"
+)
+
+// LimitsResponse describes the request limits the server enforces.
+type LimitsResponse struct {
+	// RateLimit is the sustained number of requests per second allowed per
+	// client IP (0 means unlimited)
+	RateLimit int `json:"rateLimit"`
+
+	// Burst is the number of requests a client may send at once before the
+	// rate limit applies
+	Burst int `json:"burst"`
+}
+
+// rateLimiter limits requests per client IP to security.rate_limit requests
+// per second. Rejected requests get a 429 with a Retry-After header, so
+// clients such as the agent can back off.
+func (s *Server) rateLimiter() echo.MiddlewareFunc {
+	return middleware.RateLimiterWithConfig(middleware.RateLimiterConfig{
+		Store: middleware.NewRateLimiterMemoryStore(rate.Limit(s.config.Security.RateLimit)),
+		DenyHandler: func(c echo.Context, identifier string, err error) error {
+			// A token is refilled within a second at any configured rate
+			c.Response().Header().Set("Retry-After", "1")
+			return NewAPIError(http.StatusTooManyRequests, "Too many requests",
+				"rate limit exceeded, see GET /api/v1/limits")
+		},
+	})
+}
+
+// getLimits handles GET /api/v1/limits
+// @Summary Get server request limits
+// @Description Report the rate limit the server enforces per client, so clients such as agents can pace their requests. Requests over the limit are rejected with 429 and a Retry-After header.
+// @Tags Statistics
+// @Produce json
+// @Success 200 {object} LimitsResponse
+// @Router /limits [get]
+func (s *Server) getLimits(c echo.Context) error {
+	// The memory store allows bursts of one second's worth of requests
+	return c.JSON(http.StatusOK, LimitsResponse{
+		RateLimit: s.config.Security.RateLimit,
+		Burst:     s.config.Security.RateLimit,
+	})
+}
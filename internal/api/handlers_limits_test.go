@@ -0,0 +1,47 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+
+	"evalgo.org/graphium/internal/config"
+)
+
+func TestRateLimiter_RetryAfter(t *testing.T) {
+	s := &Server{config: &config.Config{Security: config.SecurityConfig{RateLimit: 1}}}
+
+	e := echo.New()
+	e.HTTPErrorHandler = HTTPErrorHandler
+	e.Use(s.rateLimiter())
+	e.GET("/api/v1/limits", s.getLimits)
+
+	get := func() *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/limits", nil))
+		return rec
+	}
+
+	rec := get()
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", rec.Code)
+	}
+	var limits LimitsResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &limits); err != nil {
+		t.Fatalf("Failed to decode limits: %v", err)
+	}
+	if limits.RateLimit != 1 || limits.Burst != 1 {
+		t.Errorf("Expected a limit of 1 req/s with a burst of 1, got %+v", limits)
+	}
+
+	rec = get()
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("Expected 429, got %d", rec.Code)
+	}
+	if got := rec.Header().Get("Retry-After"); got != "1" {
+		t.Errorf("Expected Retry-After 1, got %q", got)
+	}
+}
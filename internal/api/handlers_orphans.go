@@ -0,0 +1,36 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	"evalgo.org/graphium/models"
+)
+
+// getOrphanedContainers handles GET /api/v1/query/containers/orphaned
+// @Summary List orphaned containers
+// @Description List containers no stack lists (no-stack), without an existing host (no-host), or listed by a stack whose current deployment didn't place them (no-deployment), with the reasons for each and counts per category. Containers no stack lists are hidden from the graph when orphans=false.
+// @Tags Query
+// @Produce json
+// @Param category query string false "Only containers of a category (no-stack, no-host, no-deployment)"
+// @Success 200 {object} models.OrphanReport
+// @Failure 400 {object} APIError
+// @Failure 500 {object} APIError
+// @Router /query/containers/orphaned [get]
+func (s *Server) getOrphanedContainers(c echo.Context) error {
+	category := c.QueryParam("category")
+	if category != "" && !models.IsOrphanCategory(category) {
+		return BadRequestError("Invalid category", "category must be no-stack, no-host or no-deployment")
+	}
+
+	report, err := s.storage.FindOrphanedContainers()
+	if err != nil {
+		return InternalError("Failed to find orphaned containers", err.Error())
+	}
+	if category != "" {
+		report = report.Only(category)
+	}
+
+	return c.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,177 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+
+	"evalgo.org/graphium/models"
+)
+
+// validPortProtocols are the protocols accepted by the port query.
+var validPortProtocols = map[string]bool{"tcp": true, "udp": true, "sctp": true}
+
+// portQuery describes which port bindings a container must have to match.
+// Zero values mean "any".
+type portQuery struct {
+	HostPort      int
+	ContainerPort int
+	Protocol      string
+}
+
+// matches reports whether a single port binding satisfies the query.
+// Bindings without a protocol are treated as tcp, Docker's default.
+func (q portQuery) matches(port models.Port) bool {
+	if q.HostPort != 0 && port.HostPort != q.HostPort {
+		return false
+	}
+	if q.ContainerPort != 0 && port.ContainerPort != q.ContainerPort {
+		return false
+	}
+	if q.Protocol != "" {
+		protocol := strings.ToLower(port.Protocol)
+		if protocol == "" {
+			protocol = "tcp"
+		}
+		if protocol != q.Protocol {
+			return false
+		}
+	}
+	return true
+}
+
+// matchingPorts returns the port bindings of a container that satisfy the query.
+func (q portQuery) matchingPorts(container *models.Container) []models.Port {
+	var ports []models.Port
+	for _, port := range container.Ports {
+		if q.matches(port) {
+			ports = append(ports, port)
+		}
+	}
+	return ports
+}
+
+// PortMatchHost identifies the host of a matching container.
+type PortMatchHost struct {
+	ID        string `json:"id"`
+	Name      string `json:"name,omitempty"`
+	IPAddress string `json:"ipAddress,omitempty"`
+}
+
+// PortMatch is a container with the port bindings that matched the query.
+type PortMatch struct {
+	Container *models.Container `json:"container"`
+	Host      *PortMatchHost    `json:"host,omitempty"`
+	Ports     []models.Port     `json:"ports"`
+}
+
+// PortQueryResponse is the response for GET /api/v1/query/containers/by-port.
+type PortQueryResponse struct {
+	Count   int         `json:"count"`
+	Matches []PortMatch `json:"matches"`
+}
+
+// getContainersByPort handles GET /api/v1/query/containers/by-port
+// @Summary Find containers by port
+// @Description Find containers that publish a host port and/or expose a container port, with their hosts.
+// @Description Host port lookups use the containers_by_host_port view; containerPort-only lookups scan all containers.
+// @Tags Containers
+// @Produce json
+// @Param hostPort query int false "Published host port"
+// @Param containerPort query int false "Port inside the container"
+// @Param protocol query string false "Protocol filter (tcp, udp, sctp)"
+// @Success 200 {object} PortQueryResponse
+// @Failure 400 {object} APIError
+// @Failure 500 {object} APIError
+// @Router /query/containers/by-port [get]
+func (s *Server) getContainersByPort(c echo.Context) error {
+	query, err := parsePortQuery(c)
+	if err != nil {
+		return err
+	}
+
+	var containers []*models.Container
+	if query.HostPort != 0 {
+		containers, err = s.storage.GetContainersByHostPort(query.HostPort)
+	} else {
+		containers, err = s.storage.ListContainers(nil)
+	}
+	if err != nil {
+		return InternalError("Failed to query containers by port", err.Error())
+	}
+
+	hosts := make(map[string]*PortMatchHost)
+	matches := []PortMatch{}
+	for _, container := range containers {
+		ports := query.matchingPorts(container)
+		if len(ports) == 0 {
+			continue
+		}
+
+		match := PortMatch{Container: container, Ports: ports}
+		if container.HostedOn != "" {
+			host, ok := hosts[container.HostedOn]
+			if !ok {
+				host = &PortMatchHost{ID: container.HostedOn}
+				if h, err := s.storage.GetHost(container.HostedOn); err == nil {
+					host.Name = h.Name
+					host.IPAddress = h.IPAddress
+				}
+				hosts[container.HostedOn] = host
+			}
+			match.Host = host
+		}
+		matches = append(matches, match)
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].Container.HostedOn != matches[j].Container.HostedOn {
+			return matches[i].Container.HostedOn < matches[j].Container.HostedOn
+		}
+		return matches[i].Container.Name < matches[j].Container.Name
+	})
+
+	return c.JSON(http.StatusOK, PortQueryResponse{
+		Count:   len(matches),
+		Matches: matches,
+	})
+}
+
+// parsePortQuery reads and validates the port query parameters.
+func parsePortQuery(c echo.Context) (portQuery, error) {
+	var query portQuery
+
+	parsePort := func(name string) (int, error) {
+		raw := c.QueryParam(name)
+		if raw == "" {
+			return 0, nil
+		}
+		port, err := strconv.Atoi(raw)
+		if err != nil || port < 1 || port > 65535 {
+			return 0, BadRequestError("Invalid port", fmt.Sprintf("%s must be a number between 1 and 65535, got %q", name, raw))
+		}
+		return port, nil
+	}
+
+	var err error
+	if query.HostPort, err = parsePort("hostPort"); err != nil {
+		return query, err
+	}
+	if query.ContainerPort, err = parsePort("containerPort"); err != nil {
+		return query, err
+	}
+	if query.HostPort == 0 && query.ContainerPort == 0 {
+		return query, BadRequestError("Port is required", "Set hostPort and/or containerPort")
+	}
+
+	query.Protocol = strings.ToLower(c.QueryParam("protocol"))
+	if query.Protocol != "" && !validPortProtocols[query.Protocol] {
+		return query, BadRequestError("Invalid protocol", fmt.Sprintf("protocol must be one of: tcp, udp, sctp. Got: %s", query.Protocol))
+	}
+
+	return query, nil
+}
@@ -0,0 +1,80 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+
+	"evalgo.org/graphium/models"
+)
+
+func TestPortQuery_MatchingPorts(t *testing.T) {
+	container := &models.Container{
+		ID: "web-1",
+		Ports: []models.Port{
+			{HostPort: 443, ContainerPort: 8443, Protocol: "tcp"},
+			{HostPort: 443, ContainerPort: 8443, Protocol: "udp"},
+			{HostPort: 8080, ContainerPort: 80},
+			{ContainerPort: 22, Protocol: "tcp"},
+		},
+	}
+
+	tests := []struct {
+		name  string
+		query portQuery
+		want  int
+	}{
+		{"host port", portQuery{HostPort: 443}, 2},
+		{"host port and protocol", portQuery{HostPort: 443, Protocol: "udp"}, 1},
+		{"empty protocol defaults to tcp", portQuery{HostPort: 8080, Protocol: "tcp"}, 1},
+		{"container port", portQuery{ContainerPort: 22}, 1},
+		{"host and container port", portQuery{HostPort: 443, ContainerPort: 80}, 0},
+		{"no match", portQuery{HostPort: 22}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.query.matchingPorts(container); len(got) != tt.want {
+				t.Errorf("Expected %d matching ports, got %+v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestParsePortQuery(t *testing.T) {
+	tests := []struct {
+		rawQuery string
+		wantErr  bool
+		want     portQuery
+	}{
+		{"hostPort=443", false, portQuery{HostPort: 443}},
+		{"containerPort=22&protocol=TCP", false, portQuery{ContainerPort: 22, Protocol: "tcp"}},
+		{"", true, portQuery{}},
+		{"hostPort=abc", true, portQuery{}},
+		{"hostPort=70000", true, portQuery{}},
+		{"hostPort=443&protocol=icmp", true, portQuery{}},
+	}
+
+	e := echo.New()
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/query/containers/by-port?"+tt.rawQuery, nil)
+		c := e.NewContext(req, httptest.NewRecorder())
+
+		got, err := parsePortQuery(c)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("%q: expected an error", tt.rawQuery)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: unexpected error: %v", tt.rawQuery, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%q: expected %+v, got %+v", tt.rawQuery, tt.want, got)
+		}
+	}
+}
@@ -17,15 +17,17 @@ func (s *Server) traverseGraph(c echo.Context) error {
 		relationField = "dependsOn" // default
 	}
 
+	// depth=0 requests an unlimited traversal; the storage layer still
+	// applies the server-side cap (server.max_graph_depth)
 	maxDepth := 5 // default
 	if depthStr := c.QueryParam("depth"); depthStr != "" {
-		if d, err := strconv.Atoi(depthStr); err == nil && d > 0 {
+		if d, err := strconv.Atoi(depthStr); err == nil && d >= 0 {
 			maxDepth = d
 		}
 	}
 
 	// Get relationship graph
-	graph, err := s.storage.GetContainerDependencyGraph(id, maxDepth)
+	graph, depth, truncated, err := s.storage.GetContainerDependencyGraph(id, maxDepth)
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, ErrorResponse{
 			Error:   "failed to traverse graph",
@@ -36,7 +38,8 @@ func (s *Server) traverseGraph(c echo.Context) error {
 	return c.JSON(http.StatusOK, map[string]interface{}{
 		"id":            id,
 		"relationField": relationField,
-		"maxDepth":      maxDepth,
+		"maxDepth":      depth,
+		"truncated":     truncated,
 		"graph":         graph,
 	})
 }
@@ -63,6 +66,9 @@ func (s *Server) getDependents(c echo.Context) error {
 // getDatacenterTopology handles GET /api/v1/query/topology/:datacenter
 func (s *Server) getDatacenterTopology(c echo.Context) error {
 	datacenter := c.Param("datacenter")
+	if !shareScopeAllowsDatacenter(c, datacenter) {
+		return NewAPIError(http.StatusForbidden, "Forbidden", "Share link does not grant access to this datacenter")
+	}
 
 	// Get datacenter topology
 	topology, err := s.storage.GetDatacenterTopology(datacenter)
@@ -0,0 +1,173 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	"evalgo.org/graphium/internal/auth"
+	"evalgo.org/graphium/internal/stack"
+	"evalgo.org/graphium/models"
+)
+
+// UpdateSecretRequest is the request body for PUT /api/v1/secrets/:name.
+type UpdateSecretRequest struct {
+	// Value is the new secret value
+	Value string `json:"value"`
+}
+
+// SecretReferencesResponse lists the containers that mount a secret.
+type SecretReferencesResponse struct {
+	Secret     string                  `json:"secret"`
+	References []stack.SecretReference `json:"references"`
+}
+
+// UpdateSecretResponse reports the effect of updating a secret.
+type UpdateSecretResponse struct {
+	Secret       string                  `json:"secret"`
+	References   []stack.SecretReference `json:"references"`
+	RestartTasks []string                `json:"restartTasks"`
+	Errors       []string                `json:"errors,omitempty"`
+}
+
+// secretStore returns the configured secret store, or nil if none is configured.
+func (s *Server) secretStore() *stack.DirSecretStore {
+	if s.config.Security.SecretsDir == "" {
+		return nil
+	}
+	return stack.NewDirSecretStore(s.config.Security.SecretsDir)
+}
+
+// findSecretReferences returns every deployed container that mounts the named secret.
+func (s *Server) findSecretReferences(name string) ([]stack.SecretReference, error) {
+	stacks, err := s.storage.ListStacks(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	refs := []stack.SecretReference{}
+	for _, stk := range stacks {
+		refs = append(refs, stack.FindSecretReferences(stk, name)...)
+	}
+	return refs, nil
+}
+
+// getSecretReferences handles GET /api/v1/secrets/:name/references
+// @Summary List containers that mount a secret
+// @Description List the containers of current stack deployments that mount the named secret as a file
+// @Tags Secrets
+// @Produce json
+// @Param name path string true "Secret name"
+// @Success 200 {object} SecretReferencesResponse
+// @Failure 500 {object} APIError
+// @Router /secrets/{name}/references [get]
+func (s *Server) getSecretReferences(c echo.Context) error {
+	name := c.Param("name")
+
+	refs, err := s.findSecretReferences(name)
+	if err != nil {
+		return InternalError("Failed to find secret references", err.Error())
+	}
+
+	return c.JSON(http.StatusOK, &SecretReferencesResponse{Secret: name, References: refs})
+}
+
+// updateSecret handles PUT /api/v1/secrets/:name
+// @Summary Update a secret
+// @Description Write a new secret value. Containers that mount the secret with restartOnChange get the new file content and a restart agent task; protected containers are left alone and reported in errors.
+// @Tags Secrets
+// @Accept json
+// @Produce json
+// @Param name path string true "Secret name"
+// @Param request body UpdateSecretRequest true "New secret value"
+// @Success 200 {object} UpdateSecretResponse
+// @Failure 400 {object} APIError
+// @Failure 503 {object} APIError "No secret store configured"
+// @Failure 500 {object} APIError
+// @Router /secrets/{name} [put]
+func (s *Server) updateSecret(c echo.Context) error {
+	name := c.Param("name")
+
+	var req UpdateSecretRequest
+	if err := c.Bind(&req); err != nil {
+		return BadRequestError("Invalid request body", err.Error())
+	}
+
+	store := s.secretStore()
+	if store == nil {
+		return NewAPIError(http.StatusServiceUnavailable, "No secret store configured", "Set security.secrets_dir to manage secrets")
+	}
+
+	ctx := c.Request().Context()
+	if err := store.PutSecret(ctx, name, []byte(req.Value)); err != nil {
+		return BadRequestError("Failed to update secret", err.Error())
+	}
+
+	refs, err := s.findSecretReferences(name)
+	if err != nil {
+		return InternalError("Secret updated but references could not be resolved", err.Error())
+	}
+
+	response := &UpdateSecretResponse{
+		Secret:       name,
+		References:   refs,
+		RestartTasks: []string{},
+	}
+
+	createdBy := ""
+	if userID, ok := auth.GetUserID(c); ok {
+		createdBy = userID
+	}
+
+	deployer := stack.NewDeployer(nil, nil, &APIDockerClientFactory{storage: s.storage})
+	deployer.Secrets = store
+
+	for _, ref := range refs {
+		if !ref.RestartOnChange {
+			continue
+		}
+		if ref.ContainerID == "" || ref.HostID == "" {
+			response.Errors = append(response.Errors, ref.ContainerName+": container is not placed")
+			continue
+		}
+
+		// Refuse to restart protected containers before touching their files
+		task := newRestartTask(ref, createdBy)
+		if err := s.checkTaskProtection(task); err != nil {
+			response.Errors = append(response.Errors, ref.ContainerName+": "+err.Error())
+			continue
+		}
+
+		// The file was copied at creation time, so place the new content first
+		if err := deployer.RefreshFiles(ctx, ref.HostID, ref.ContainerID, []models.FileMount{ref.File}); err != nil {
+			response.Errors = append(response.Errors, ref.ContainerName+": "+err.Error())
+			continue
+		}
+
+		if err := s.queueTask(task); err != nil {
+			response.Errors = append(response.Errors, ref.ContainerName+": "+err.Error())
+			continue
+		}
+		response.RestartTasks = append(response.RestartTasks, task.ID)
+	}
+
+	s.BroadcastGraphEvent("secret_updated", map[string]interface{}{
+		"secret":       name,
+		"restartTasks": response.RestartTasks,
+	})
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// newRestartTask creates a restart agent task for a container that mounts a changed secret.
+func newRestartTask(ref stack.SecretReference, createdBy string) *models.AgentTask {
+	task := newAgentTask("ControlAction", "Restart "+ref.ContainerName+" after secret change", ref.HostID, createdBy)
+	task.StackID = ref.StackID
+	task.ContainerID = ref.ContainerID
+	_ = task.SetPayload(map[string]interface{}{
+		"action":        "restart",
+		"containerId":   ref.ContainerID,
+		"containerName": ref.ContainerName,
+	})
+	return task
+}
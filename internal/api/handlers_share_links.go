@@ -0,0 +1,268 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"evalgo.org/graphium/internal/auth"
+	"evalgo.org/graphium/models"
+)
+
+// CreateShareLinkRequest is the request body for creating a read-only share link.
+type CreateShareLinkRequest struct {
+	// Name is a human-readable label (e.g. the incident the link is for)
+	Name string `json:"name"`
+
+	// Datacenter restricts the link to a single datacenter (optional)
+	Datacenter string `json:"datacenter"`
+
+	// TTL is the link lifetime as a Go duration (e.g. "30m", "2h").
+	// Defaults to security.share_link_default_ttl, capped at security.share_link_max_ttl.
+	TTL string `json:"ttl"`
+}
+
+// ShareLinkResponse is returned when a share link is created.
+type ShareLinkResponse struct {
+	Link  *models.ShareLink `json:"shareLink"`
+	Token string            `json:"token"`
+	URL   string            `json:"url"`
+}
+
+// createShareLink handles POST /api/v1/share-links
+// @Summary Create a read-only share link
+// @Description Mint a short-lived, read-only viewer token, optionally scoped to a datacenter
+// @Tags Share Links
+// @Accept json
+// @Produce json
+// @Param request body CreateShareLinkRequest true "Share link options"
+// @Success 201 {object} ShareLinkResponse
+// @Failure 400 {object} APIError
+// @Failure 500 {object} APIError
+// @Router /share-links [post]
+func (s *Server) createShareLink(c echo.Context) error {
+	var req CreateShareLinkRequest
+	if err := c.Bind(&req); err != nil {
+		return BadRequestError("Invalid request body", err.Error())
+	}
+
+	ttl := s.config.Security.ShareLinkDefaultTTL
+	if req.TTL != "" {
+		parsed, err := time.ParseDuration(req.TTL)
+		if err != nil || parsed <= 0 {
+			return ValidationError("Validation failed", map[string]string{"ttl": "TTL must be a positive duration (e.g. 30m, 2h)"})
+		}
+		ttl = parsed
+	}
+	if maxTTL := s.config.Security.ShareLinkMaxTTL; maxTTL > 0 && ttl > maxTTL {
+		return ValidationError("Validation failed", map[string]string{"ttl": fmt.Sprintf("TTL must not exceed %s", maxTTL)})
+	}
+
+	now := time.Now()
+	link := &models.ShareLink{
+		Name:       req.Name,
+		Datacenter: req.Datacenter,
+		CreatedAt:  now,
+		ExpiresAt:  now.Add(ttl),
+	}
+	if userID, ok := auth.GetUserID(c); ok {
+		link.CreatedBy = userID
+	}
+
+	if err := s.storage.CreateShareLink(link); err != nil {
+		return InternalError("Failed to create share link", err.Error())
+	}
+
+	token, err := s.authMiddle.JWTService().GenerateShareToken(link)
+	if err != nil {
+		return InternalError("Failed to generate share token", err.Error())
+	}
+
+	return c.JSON(http.StatusCreated, ShareLinkResponse{
+		Link:  link,
+		Token: token,
+		URL:   fmt.Sprintf("%s://%s/?token=%s", c.Scheme(), c.Request().Host, token),
+	})
+}
+
+// listShareLinks handles GET /api/v1/share-links
+// @Summary List share links
+// @Description List all share links including revoked and expired ones
+// @Tags Share Links
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Failure 500 {object} APIError
+// @Router /share-links [get]
+func (s *Server) listShareLinks(c echo.Context) error {
+	links, err := s.storage.ListShareLinks()
+	if err != nil {
+		return InternalError("Failed to list share links", err.Error())
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"count":      len(links),
+		"shareLinks": links,
+	})
+}
+
+// revokeShareLink handles DELETE /api/v1/share-links/:id
+// @Summary Revoke a share link
+// @Description Revoke a share link so its token is rejected immediately
+// @Tags Share Links
+// @Produce json
+// @Param id path string true "Share link ID"
+// @Success 200 {object} models.ShareLink
+// @Failure 404 {object} APIError
+// @Router /share-links/{id} [delete]
+func (s *Server) revokeShareLink(c echo.Context) error {
+	id := c.Param("id")
+
+	link, err := s.storage.RevokeShareLink(id)
+	if err != nil {
+		return NotFoundError("Share link", id)
+	}
+
+	return c.JSON(http.StatusOK, link)
+}
+
+// checkShareLink verifies that a share link is still active.
+// It is used by the auth middleware to reject revoked links.
+func (s *Server) checkShareLink(linkID string) error {
+	link, err := s.storage.GetShareLink(linkID)
+	if err != nil {
+		return err
+	}
+	if !link.IsActive() {
+		return fmt.Errorf("share link %s is revoked or expired", linkID)
+	}
+	return nil
+}
+
+// shareScopeAllowsHost reports whether a request may see the given host.
+// Requests that don't use a datacenter-scoped share token may see all hosts.
+func shareScopeAllowsHost(c echo.Context, host *models.Host) bool {
+	datacenter, ok := auth.GetShareScope(c)
+	if !ok || datacenter == "" {
+		return true
+	}
+	return host != nil && host.Datacenter == datacenter
+}
+
+// shareScopeAllowsDatacenter reports whether a request may see the given datacenter.
+func shareScopeAllowsDatacenter(c echo.Context, datacenter string) bool {
+	scope, ok := auth.GetShareScope(c)
+	return !ok || scope == "" || scope == datacenter
+}
+
+// shareScopeHostIDs returns the IDs of the hosts a scoped share token may see.
+// The bool is false if the request is not restricted to a datacenter.
+func (s *Server) shareScopeHostIDs(c echo.Context) (map[string]bool, bool, error) {
+	datacenter, ok := auth.GetShareScope(c)
+	if !ok || datacenter == "" {
+		return nil, false, nil
+	}
+
+	hosts, err := s.storage.ListHosts(map[string]interface{}{"location": datacenter})
+	if err != nil {
+		return nil, true, err
+	}
+
+	hostIDs := make(map[string]bool, len(hosts))
+	for _, host := range hosts {
+		hostIDs[host.ID] = true
+	}
+	return hostIDs, true, nil
+}
+
+// scopedHostFilter returns the hostedOn list filter of a datacenter-scoped
+// share link: the requested host if it is in the datacenter, otherwise all
+// hosts of the datacenter. An empty list matches nothing.
+func scopedHostFilter(hostIDs map[string]bool, requested string) []string {
+	if requested != "" {
+		if hostIDs[requested] {
+			return []string{requested}
+		}
+		return []string{}
+	}
+
+	ids := make([]string, 0, len(hostIDs))
+	for id := range hostIDs {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// shareScopeEventFilter returns a WebSocket event filter for a datacenter-scoped
+// share link. It returns nil if the request is not restricted to a datacenter.
+//
+// The filter starts from a snapshot of the datacenter's hosts and containers and
+// tracks hosts and containers added while the client is connected. Events it
+// cannot attribute to the datacenter (stacks, tasks) are dropped.
+func (s *Server) shareScopeEventFilter(c echo.Context) (EventFilter, error) {
+	datacenter, ok := auth.GetShareScope(c)
+	if !ok || datacenter == "" {
+		return nil, nil
+	}
+
+	hostIDs, _, err := s.shareScopeHostIDs(c)
+	if err != nil {
+		return nil, err
+	}
+
+	containerIDs := make(map[string]bool)
+	for hostID := range hostIDs {
+		containers, err := s.storage.GetContainersByHost(hostID)
+		if err != nil {
+			return nil, err
+		}
+		for _, container := range containers {
+			containerIDs[container.ID] = true
+		}
+	}
+
+	return func(event GraphEvent) bool {
+		if event.Type == EventGraphRefresh {
+			return true
+		}
+
+		raw, ok := event.Data.(json.RawMessage)
+		if !ok {
+			return false
+		}
+		var data struct {
+			ID       string `json:"@id"`
+			PlainID  string `json:"id"`
+			HostedOn string `json:"hostedOn"`
+			Location string `json:"location"`
+		}
+		if err := json.Unmarshal(raw, &data); err != nil {
+			return false
+		}
+
+		switch event.Type {
+		case EventHostAdded, EventHostUpdated:
+			if data.Location == datacenter {
+				hostIDs[data.ID] = true
+				return true
+			}
+			return false
+		case EventHostRemoved:
+			return hostIDs[data.PlainID]
+		case EventContainerAdded, EventContainerUpdated:
+			if hostIDs[data.HostedOn] {
+				containerIDs[data.ID] = true
+				return true
+			}
+			return false
+		case EventContainerRemoved:
+			return containerIDs[data.PlainID]
+		default:
+			return false
+		}
+	}, nil
+}
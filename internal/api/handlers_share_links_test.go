@@ -0,0 +1,27 @@
+package api
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestScopedHostFilter(t *testing.T) {
+	hostIDs := map[string]bool{"host-b": true, "host-a": true}
+
+	tests := []struct {
+		name      string
+		requested string
+		want      []string
+	}{
+		{name: "all hosts of the datacenter", want: []string{"host-a", "host-b"}},
+		{name: "requested host in scope", requested: "host-b", want: []string{"host-b"}},
+		{name: "requested host out of scope", requested: "host-c", want: []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := scopedHostFilter(hostIDs, tt.requested); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("scopedHostFilter() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+
+	"evalgo.org/graphium/models"
+)
+
+// autoAssignStack adds a created or synced container to its stack when
+// server.auto_assign_stacks is enabled. Failures are logged, not returned,
+// so they never fail the container request.
+func (s *Server) autoAssignStack(container *models.Container) {
+	if !s.config.Server.AutoAssignStacks {
+		return
+	}
+
+	assignment, err := s.storage.AutoAssignContainerToStack(container)
+	if err != nil {
+		s.logger.WithError(err).Warn("Failed to auto-assign container " + container.ID + " to stack")
+		return
+	}
+
+	s.debugLog("DEBUG: stack auto-assign for container %s (%s): rule=%s stack=%s reason=%s",
+		container.ID, container.Name, assignment.Rule, assignment.StackName, assignment.Reason)
+}
+
+// previewStackAssignment handles GET /api/v1/stacks/auto-assign/preview
+// @Summary Preview stack auto-assignment
+// @Description Show which stack a container with the given name and labels would be assigned to, and which rule decided it. Label rules (graphium.stack, com.docker.compose.project) take priority over the name prefix. A Compose project matching no stack gets a new stack named after it, with ID stack:compose:{project} (rule label:com.docker.compose.project:new).
+// @Tags Stacks
+// @Produce json
+// @Param name query string false "Container name"
+// @Param label query []string false "Container label as key=value (repeatable)"
+// @Success 200 {object} models.StackAssignment
+// @Failure 400 {object} APIError
+// @Failure 500 {object} APIError
+// @Router /stacks/auto-assign/preview [get]
+func (s *Server) previewStackAssignment(c echo.Context) error {
+	name := c.QueryParam("name")
+
+	labels := make(map[string]string)
+	for _, raw := range c.QueryParams()["label"] {
+		key, value, ok := strings.Cut(raw, "=")
+		if !ok || key == "" {
+			return BadRequestError("Invalid label", "Labels must be given as key=value, got: "+raw)
+		}
+		labels[key] = value
+	}
+
+	if name == "" && len(labels) == 0 {
+		return BadRequestError("Name or label is required", "Set the 'name' and/or 'label' query parameters")
+	}
+
+	assignment, err := s.storage.PreviewStackAssignment(name, labels)
+	if err != nil {
+		return InternalError("Failed to preview stack assignment", err.Error())
+	}
+
+	return c.JSON(http.StatusOK, assignment)
+}
@@ -2,14 +2,18 @@ package api
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"time"
 
+	"github.com/docker/docker/api/types/registry"
 	"github.com/labstack/echo/v4"
 
 	"eve.evalgo.org/common"
 
+	"evalgo.org/graphium/agent"
+	"evalgo.org/graphium/internal/config"
 	"evalgo.org/graphium/internal/stack"
 	"evalgo.org/graphium/internal/storage"
 	"evalgo.org/graphium/models"
@@ -23,7 +27,24 @@ type DeployJSONLDStackRequest struct {
 	// Options for deployment
 	Timeout         int  `json:"timeout"`         // Timeout in seconds (default: 300)
 	RollbackOnError bool `json:"rollbackOnError"` // Auto-rollback on error (default: true)
-	PullImages      bool `json:"pullImages"`      // Pull images before deployment (default: false)
+	PullImages      bool `json:"pullImages"`      // Pull images before deployment (default: false; missing images are pulled anyway)
+
+	// PhaseTimeouts overrides the per-phase timeouts (optional)
+	PhaseTimeouts *PhaseTimeoutsRequest `json:"phaseTimeouts,omitempty"`
+
+	// Mode is "blue-green" to deploy alongside the stack's running
+	// deployment until promoted (optional)
+	Mode string `json:"mode,omitempty"`
+}
+
+// PhaseTimeoutsRequest contains per-phase deployment timeouts in seconds.
+// Zero values use the deployer defaults.
+type PhaseTimeoutsRequest struct {
+	Network int `json:"network"` // Network creation (default: 30)
+	Volume  int `json:"volume"`  // Volume creation (default: 30)
+	Pull    int `json:"pull"`    // Image pulls (default: 300)
+	Create  int `json:"create"`  // Create and start, per container (default: 60)
+	Health  int `json:"health"`  // Health wait, per wave (default: 120)
 }
 
 // DeploymentStateResponse represents a deployment state in API responses.
@@ -40,7 +61,30 @@ type DeploymentStateResponse struct {
 	StartedAt     time.Time                             `json:"startedAt"`
 	CompletedAt   *time.Time                            `json:"completedAt,omitempty"`
 	ErrorMessage  string                                `json:"errorMessage,omitempty"`
+	TimedOutPhase string                                `json:"timedOutPhase,omitempty"`
 	RollbackState *models.RollbackState                 `json:"rollbackState,omitempty"`
+	BlueGreen     *models.BlueGreenState                `json:"blueGreen,omitempty"`
+}
+
+// newDeploymentStateResponse converts a deployment state to its API response.
+func newDeploymentStateResponse(state *models.DeploymentState) *DeploymentStateResponse {
+	return &DeploymentStateResponse{
+		ID:            state.ID,
+		StackID:       state.StackID,
+		Status:        state.Status,
+		Phase:         state.Phase,
+		Progress:      state.Progress,
+		Placements:    state.Placements,
+		NetworkInfo:   state.NetworkInfo,
+		VolumeInfo:    state.VolumeInfo,
+		Events:        state.Events,
+		StartedAt:     state.StartedAt,
+		CompletedAt:   state.CompletedAt,
+		ErrorMessage:  state.ErrorMessage,
+		TimedOutPhase: state.TimedOutPhase,
+		RollbackState: state.RollbackState,
+		BlueGreen:     state.BlueGreen,
+	}
 }
 
 // ParseResultResponse represents the result of parsing a stack definition.
@@ -63,7 +107,9 @@ type ParseResultResponse struct {
 // @Param stack body DeployJSONLDStackRequest true "JSON-LD stack deployment configuration"
 // @Success 202 {object} DeploymentStateResponse
 // @Failure 400 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
+// @Failure 504 {object} ErrorResponse
 // @Router /api/v1/stacks/jsonld [post]
 func (s *Server) deployJSONLDStack(c echo.Context) error {
 	var req DeployJSONLDStackRequest
@@ -80,8 +126,16 @@ func (s *Server) deployJSONLDStack(c echo.Context) error {
 	// Parse the stack definition
 	parseResult, err := parser.Parse(&req.StackDefinition)
 	if err != nil {
+		if parseResult != nil && len(parseResult.Errors) > 0 {
+			return c.JSON(http.StatusBadRequest, map[string]interface{}{
+				"error":    "Stack validation failed",
+				"errors":   parseResult.Errors,
+				"warnings": parseResult.Warnings,
+			})
+		}
 		return BadRequestError("Failed to parse stack definition", err.Error())
 	}
+	resolver.ExcludeStackID = parseResult.Plan.StackNode.Name
 
 	// Check for errors
 	if len(parseResult.Errors) > 0 {
@@ -92,10 +146,31 @@ func (s *Server) deployJSONLDStack(c echo.Context) error {
 		})
 	}
 
+	// A blue-green deployment replaces the stack's running deployment
+	var blue *models.DeploymentState
+	switch stack.DeployMode(req.Mode) {
+	case "":
+	case stack.DeployModeBlueGreen:
+		if blue, err = s.runningDeployment(parseResult.Plan.StackNode.Name); err != nil {
+			return err
+		}
+	default:
+		return BadRequestError("Invalid deploy mode", fmt.Sprintf("unknown mode %q, expected blue-green", req.Mode))
+	}
+
 	// Create deployer
 	dbAdapter := &CouchDBAdapter{storage: s.storage}
 	clientFactory := &APIDockerClientFactory{storage: s.storage}
 	deployer := stack.NewDeployer(dbAdapter, resolver, clientFactory)
+	deployer.AllowOvercommit = s.config.Server.AllowOvercommit
+	deployer.Protection = s.protection
+	deployer.PlacementStrategy = s.config.Deploy.PlacementStrategy
+	deployer.Strategies = s.placementStrategies
+	deployer.Policies = s.datacenterPolicies()
+	deployer.RegistryAuth = registryAuth(s.config.Registries)
+	if store := s.secretStore(); store != nil {
+		deployer.Secrets = store
+	}
 
 	// Set deployment options
 	timeout := time.Duration(req.Timeout) * time.Second
@@ -108,10 +183,34 @@ func (s *Server) deployJSONLDStack(c echo.Context) error {
 		RollbackOnError: req.RollbackOnError,
 		StackName:       parseResult.Plan.StackNode.Name,
 		PullImages:      req.PullImages,
+		Concurrency:     s.config.Deploy.WaveConcurrency,
+		Mode:            stack.DeployMode(req.Mode),
+		Blue:            blue,
+	}
+	if req.PhaseTimeouts != nil {
+		opts.PhaseTimeouts = stack.PhaseTimeouts{
+			Network: time.Duration(req.PhaseTimeouts.Network) * time.Second,
+			Volume:  time.Duration(req.PhaseTimeouts.Volume) * time.Second,
+			Pull:    time.Duration(req.PhaseTimeouts.Pull) * time.Second,
+			Create:  time.Duration(req.PhaseTimeouts.Create) * time.Second,
+			Health:  time.Duration(req.PhaseTimeouts.Health) * time.Second,
+		}
 	}
 
 	// Deploy asynchronously
 	deploymentState, err := deployer.Deploy(ctx, parseResult.Plan, opts)
+	if errors.Is(err, stack.ErrProtectedContainer) {
+		return NewAPIError(http.StatusForbidden, "Deployment refused", err.Error())
+	}
+	if errors.Is(err, stack.ErrInsufficientCapacity) {
+		return ConflictError("Deployment refused", err.Error())
+	}
+	var timeoutErr *stack.DeployTimeoutError
+	if errors.As(err, &timeoutErr) {
+		c.Logger().Error("Deployment timed out: ", err)
+		return NewAPIError(http.StatusGatewayTimeout,
+			fmt.Sprintf("Deployment timed out after %s", timeoutErr.Timeout), timeoutErr.Err.Error())
+	}
 	if err != nil {
 		// Log the actual error for debugging
 		c.Logger().Error("Deployment error: ", err)
@@ -131,6 +230,17 @@ func (s *Server) deployJSONLDStack(c echo.Context) error {
 	// This allows the web UI to show stack links and enable stack management
 	stackID := deploymentState.StackID
 	if stackID != "" {
+		completedAt := deploymentState.StartedAt
+		if deploymentState.CompletedAt != nil {
+			completedAt = *deploymentState.CompletedAt
+		}
+		snapshot := &models.StackSnapshot{
+			DeploymentID: deploymentState.ID,
+			Definition:   req.StackDefinition,
+			Placements:   deploymentState.Placements,
+			DeployedAt:   completedAt,
+		}
+
 		// Collect all container IDs from placements
 		containerIDs := make([]string, 0, len(deploymentState.Placements))
 		for _, placement := range deploymentState.Placements {
@@ -156,6 +266,7 @@ func (s *Server) deployJSONLDStack(c echo.Context) error {
 				CreatedAt:   deploymentState.StartedAt,
 				UpdatedAt:   deploymentState.StartedAt,
 			}
+			newStack.RecordSuccessfulDeploy(snapshot)
 
 			if err := s.storage.SaveStack(newStack); err != nil {
 				c.Logger().Warnf("Failed to create Stack document for deployment %s: %v", deploymentState.ID, err)
@@ -178,6 +289,7 @@ func (s *Server) deployJSONLDStack(c echo.Context) error {
 			existingStack.Status = "running"
 			existingStack.DeployedAt = &deploymentState.StartedAt
 			existingStack.UpdatedAt = deploymentState.StartedAt
+			existingStack.RecordSuccessfulDeploy(snapshot)
 
 			if err := s.storage.UpdateStack(existingStack); err != nil {
 				c.Logger().Warnf("Failed to update Stack document for deployment %s: %v", deploymentState.ID, err)
@@ -189,25 +301,111 @@ func (s *Server) deployJSONLDStack(c echo.Context) error {
 	}
 
 	// Convert to response
-	response := &DeploymentStateResponse{
-		ID:            deploymentState.ID,
-		StackID:       deploymentState.StackID,
-		Status:        deploymentState.Status,
-		Phase:         deploymentState.Phase,
-		Progress:      deploymentState.Progress,
-		Placements:    deploymentState.Placements,
-		NetworkInfo:   deploymentState.NetworkInfo,
-		VolumeInfo:    deploymentState.VolumeInfo,
-		Events:        deploymentState.Events,
-		StartedAt:     deploymentState.StartedAt,
-		CompletedAt:   deploymentState.CompletedAt,
-		ErrorMessage:  deploymentState.ErrorMessage,
-		RollbackState: deploymentState.RollbackState,
-	}
+	response := newDeploymentStateResponse(deploymentState)
 
 	return c.JSON(http.StatusAccepted, response)
 }
 
+// registryAuth keys the configured registry credentials by registry host
+// for the deployer.
+func registryAuth(credentials []config.RegistryCredentials) map[string]registry.AuthConfig {
+	if len(credentials) == 0 {
+		return nil
+	}
+	auth := make(map[string]registry.AuthConfig, len(credentials))
+	for _, credential := range credentials {
+		auth[credential.Host] = registry.AuthConfig{
+			Username:      credential.Username,
+			Password:      credential.Password,
+			IdentityToken: credential.IdentityToken,
+		}
+	}
+	return auth
+}
+
+// runningDeployment returns the latest running deployment of a stack, which
+// a blue-green deployment replaces, or nil if there is none. A stack with a
+// blue-green deployment awaiting promotion can't be deployed blue-green
+// again.
+func (s *Server) runningDeployment(stackID string) (*models.DeploymentState, error) {
+	deployments, err := s.storage.GetDeploymentsByStackID(stackID)
+	if err != nil {
+		return nil, InternalError("Failed to get deployments", err.Error())
+	}
+
+	var running *models.DeploymentState
+	for _, deployment := range deployments {
+		if deployment.Status != "running" {
+			continue
+		}
+		if deployment.BlueGreen != nil && deployment.BlueGreen.Status == stack.BlueGreenPending {
+			return nil, NewAPIError(http.StatusConflict, "Deployment awaits promotion",
+				fmt.Sprintf("promote or remove blue-green deployment %s of stack %s first", deployment.ID, stackID))
+		}
+		if running == nil || deployment.StartedAt.After(running.StartedAt) {
+			running = deployment
+		}
+	}
+	return running, nil
+}
+
+// promoteJSONLDDeployment cuts a blue-green deployment over to its green containers.
+// @Summary Promote blue-green deployment
+// @Description Cut a pending blue-green deployment over to its green containers and remove the blue containers of the deployment it replaces
+// @Tags stacks
+// @Produce json
+// @Param id path string true "Deployment ID"
+// @Success 200 {object} DeploymentStateResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/stacks/jsonld/deployments/{id}/promote [post]
+func (s *Server) promoteJSONLDDeployment(c echo.Context) error {
+	id := c.Param("id")
+
+	state, err := s.storage.GetDeploymentState(id)
+	if err != nil {
+		return NotFoundError("Deployment", id)
+	}
+	if state.BlueGreen == nil || state.BlueGreen.Status != stack.BlueGreenPending || state.Status != "running" {
+		return NewAPIError(http.StatusConflict, "Deployment can't be promoted",
+			fmt.Sprintf("deployment %s is not a running blue-green deployment awaiting promotion", id))
+	}
+
+	deployer := stack.NewDeployer(&CouchDBAdapter{storage: s.storage}, nil, &APIDockerClientFactory{storage: s.storage})
+	if err := deployer.Promote(c.Request().Context(), state); err != nil {
+		c.Logger().Error("Promotion error: ", err)
+		return InternalError("Promotion failed", err.Error())
+	}
+
+	// The replaced deployment's containers are gone
+	if blueID := state.BlueGreen.BlueDeploymentID; blueID != "" {
+		if blue, err := s.storage.GetDeploymentState(blueID); err == nil {
+			blue.Status = "removed"
+			blue.Phase = "replaced"
+			if err := s.storage.UpdateDeploymentState(blue); err != nil {
+				c.Logger().Warnf("Failed to mark deployment %s replaced: %v", blueID, err)
+			}
+		}
+	}
+
+	// The stack now consists of the promoted containers
+	if stackDoc, err := s.storage.GetStack(state.StackID); err == nil {
+		stackDoc.Containers = stackDoc.Containers[:0]
+		for _, placement := range state.Placements {
+			if placement != nil && placement.ContainerID != "" {
+				stackDoc.Containers = append(stackDoc.Containers, placement.ContainerID)
+			}
+		}
+		stackDoc.UpdatedAt = time.Now()
+		if err := s.storage.UpdateStack(stackDoc); err != nil {
+			c.Logger().Warnf("Failed to update Stack document %s: %v", state.StackID, err)
+		}
+	}
+
+	return c.JSON(http.StatusOK, newDeploymentStateResponse(state))
+}
+
 // validateJSONLDStack validates a JSON-LD stack definition without deploying.
 // @Summary Validate JSON-LD stack
 // @Description Validate a JSON-LD stack definition and return any errors or warnings
@@ -266,21 +464,7 @@ func (s *Server) getJSONLDDeployment(c echo.Context) error {
 		return NotFoundError("Deployment", id)
 	}
 
-	response := &DeploymentStateResponse{
-		ID:            state.ID,
-		StackID:       state.StackID,
-		Status:        state.Status,
-		Phase:         state.Phase,
-		Progress:      state.Progress,
-		Placements:    state.Placements,
-		NetworkInfo:   state.NetworkInfo,
-		VolumeInfo:    state.VolumeInfo,
-		Events:        state.Events,
-		StartedAt:     state.StartedAt,
-		CompletedAt:   state.CompletedAt,
-		ErrorMessage:  state.ErrorMessage,
-		RollbackState: state.RollbackState,
-	}
+	response := newDeploymentStateResponse(&state)
 
 	return c.JSON(http.StatusOK, response)
 }
@@ -314,21 +498,7 @@ func (s *Server) listJSONLDDeployments(c echo.Context) error {
 	// Convert to response format
 	responses := make([]DeploymentStateResponse, len(deployments))
 	for i, deployment := range deployments {
-		responses[i] = DeploymentStateResponse{
-			ID:            deployment.ID,
-			StackID:       deployment.StackID,
-			Status:        deployment.Status,
-			Phase:         deployment.Phase,
-			Progress:      deployment.Progress,
-			Placements:    deployment.Placements,
-			NetworkInfo:   deployment.NetworkInfo,
-			VolumeInfo:    deployment.VolumeInfo,
-			Events:        deployment.Events,
-			StartedAt:     deployment.StartedAt,
-			CompletedAt:   deployment.CompletedAt,
-			ErrorMessage:  deployment.ErrorMessage,
-			RollbackState: deployment.RollbackState,
-		}
+		responses[i] = *newDeploymentStateResponse(deployment)
 	}
 
 	return c.JSON(http.StatusOK, responses)
@@ -337,10 +507,36 @@ func (s *Server) listJSONLDDeployments(c echo.Context) error {
 // APIHostResolver implements the stack.HostResolver interface using the storage layer.
 type APIHostResolver struct {
 	storage *storage.Storage
+
+	// ExcludeStackID leaves a stack's current placements out of the committed
+	// resources, so a redeploy doesn't compete with its own previous reservations
+	ExcludeStackID string
+}
+
+// committedResources returns the CPU and memory reserved on each host by the
+// current deployments of all stacks.
+func (r *APIHostResolver) committedResources() map[string]*models.ResourceReservations {
+	stacks, err := r.storage.ListStacks(nil)
+	if err != nil {
+		return map[string]*models.ResourceReservations{}
+	}
+
+	counted := make([]*models.Stack, 0, len(stacks))
+	for _, stk := range stacks {
+		if stk != nil && (r.ExcludeStackID == "" || stk.ID != r.ExcludeStackID) {
+			counted = append(counted, stk)
+		}
+	}
+	return models.CommittedByHost(counted)
 }
 
 // ResolveHost resolves an absolute @id URL to host information.
 func (r *APIHostResolver) ResolveHost(id string) (*models.HostInfo, error) {
+	return r.resolveHost(id, r.committedResources())
+}
+
+// resolveHost builds the host information using precomputed committed resources.
+func (r *APIHostResolver) resolveHost(id string, committed map[string]*models.ResourceReservations) (*models.HostInfo, error) {
 	// For now, treat the @id as the host ID directly
 	// In a full implementation, this would parse the URL and query by it
 	host, err := r.storage.GetHost(id)
@@ -348,22 +544,7 @@ func (r *APIHostResolver) ResolveHost(id string) (*models.HostInfo, error) {
 		return nil, fmt.Errorf("host %s not found: %w", id, err)
 	}
 
-	// Determine Docker socket from agent configuration
-	dockerSocket := ""
-
-	// Try to get agent config for this host (agent ID format: "agent:hostId")
-	agentID := fmt.Sprintf("agent:%s", id)
-	agentConfig, err := r.storage.GetAgentConfig(agentID)
-	if err == nil && agentConfig != nil {
-		// Use the agent's configured Docker socket
-		dockerSocket = agentConfig.DockerSocket
-	} else {
-		// Fall back to guessing based on IP address
-		dockerSocket = fmt.Sprintf("tcp://%s:2375", host.IPAddress)
-		if host.IPAddress == "localhost" || host.IPAddress == "127.0.0.1" {
-			dockerSocket = "unix:///var/run/docker.sock"
-		}
-	}
+	dockerSocket, _, _ := hostDockerConnection(r.storage, host)
 
 	// Get container count
 	containerCount := 0
@@ -372,14 +553,20 @@ func (r *APIHostResolver) ResolveHost(id string) (*models.HostInfo, error) {
 		containerCount = len(containers)
 	}
 
+	load := models.ResourceLoad{
+		CPUUsage:       0, // TODO: Get actual CPU usage
+		MemoryUsage:    0, // TODO: Get actual memory usage
+		ContainerCount: containerCount,
+	}
+	if reserved := committed[host.ID]; reserved != nil {
+		load.CommittedCPUs = reserved.CPUs
+		load.CommittedMemory = reserved.Memory
+	}
+
 	return &models.HostInfo{
 		Host:         host,
 		DockerSocket: dockerSocket,
-		CurrentLoad: models.ResourceLoad{
-			CPUUsage:       0, // TODO: Get actual CPU usage
-			MemoryUsage:    0, // TODO: Get actual memory usage
-			ContainerCount: containerCount,
-		},
+		CurrentLoad:  load,
 		AvailableResources: models.Resources{
 			CPU:    host.CPU,
 			Memory: host.Memory,
@@ -397,9 +584,10 @@ func (r *APIHostResolver) ListHosts() ([]*models.HostInfo, error) {
 		return nil, err
 	}
 
+	committed := r.committedResources()
 	hostInfos := make([]*models.HostInfo, len(hosts))
 	for i, host := range hosts {
-		info, err := r.ResolveHost(host.ID)
+		info, err := r.resolveHost(host.ID, committed)
 		if err != nil {
 			continue
 		}
@@ -437,48 +625,69 @@ func (f *APIDockerClientFactory) GetClient(ctx context.Context, hostID string) (
 		return nil, fmt.Errorf("host %s not found: %w", hostID, err)
 	}
 
-	// Determine Docker socket from agent configuration
-	dockerSocket := ""
-	sshKeyPath := ""
+	// Same SSH tunnel and TLS handling as the agent; closing the client
+	// closes the tunnel
+	dockerSocket, sshKeyPath, tlsFiles := hostDockerConnection(f.storage, host)
+	cli, err := agent.ConnectDocker(dockerSocket, sshKeyPath, tlsFiles)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Docker client for %s (socket: %s): %w", hostID, dockerSocket, err)
+	}
+
+	return cli, nil
+}
+
+// hostDockerConnection returns how the server connects to a host's Docker
+// daemon: the host's own DockerEndpoint, else the Docker socket of its agent
+// configuration, else tcp://<ipAddress>:2375 (the local socket for
+// localhost).
+func hostDockerConnection(store *storage.Storage, host *models.Host) (socket, sshKeyPath string, tlsFiles agent.DockerTLSConfig) {
+	if host.DockerEndpoint != "" {
+		if host.DockerTLS != nil {
+			tlsFiles = agent.DockerTLSConfig{
+				CAFile:   host.DockerTLS.CAFile,
+				CertFile: host.DockerTLS.CertFile,
+				KeyFile:  host.DockerTLS.KeyFile,
+			}
+		}
+		return host.DockerEndpoint, host.DockerSSHKeyPath, tlsFiles
+	}
 
 	// Try to get agent config for this host (agent ID format: "agent:hostId")
-	agentID := fmt.Sprintf("agent:%s", hostID)
-	agentConfig, err := f.storage.GetAgentConfig(agentID)
+	agentConfig, err := store.GetAgentConfig(fmt.Sprintf("agent:%s", host.ID))
 	if err == nil && agentConfig != nil {
 		// Use the agent's configured Docker socket
-		dockerSocket = agentConfig.DockerSocket
-		sshKeyPath = agentConfig.SSHKeyPath
-	} else {
-		// Fall back to guessing based on IP address
-		dockerSocket = fmt.Sprintf("tcp://%s:2375", host.IPAddress)
-		if host.IPAddress == "localhost" || host.IPAddress == "127.0.0.1" {
-			dockerSocket = "unix:///var/run/docker.sock"
-		}
+		return agentConfig.DockerSocket, agentConfig.SSHKeyPath, tlsFiles
 	}
 
-	// Use EVE's NewDockerClient which handles SSH tunnels
-	cli, err := common.NewDockerClient(dockerSocket, sshKeyPath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create Docker client for %s (socket: %s): %w", hostID, dockerSocket, err)
+	// Fall back to guessing based on IP address
+	if host.IPAddress == "localhost" || host.IPAddress == "127.0.0.1" {
+		return "unix:///var/run/docker.sock", "", tlsFiles
 	}
-
-	// Docker SDK client already implements common.DockerClient
-	return cli, nil
+	return fmt.Sprintf("tcp://%s:2375", host.IPAddress), "", tlsFiles
 }
 
 // listStacks returns all stacks.
 // @Summary List all stacks
-// @Description Get a list of all stacks in the system
+// @Description Get a list of all stacks in the system, sorted by name unless sort is given. Ties are ordered by ID
 // @Tags stacks
 // @Produce json
+// @Param sort query string false "Sort field" Enums(name, created, status, datacenter) default(name)
+// @Param order query string false "Sort order" Enums(asc, desc) default(asc)
 // @Success 200 {array} models.Stack
+// @Failure 400 {object} APIError "Invalid sort"
 // @Failure 500 {object} ErrorResponse
 // @Router /api/v1/stacks [get]
 func (s *Server) listStacks(c echo.Context) error {
+	order, err := parseListSort(c, stackSortFields)
+	if err != nil {
+		return err
+	}
+
 	stacks, err := s.storage.ListStacks(nil)
 	if err != nil {
 		return InternalError("Failed to list stacks", err.Error())
 	}
+	storage.SortStacks(stacks, order)
 	return c.JSON(http.StatusOK, stacks)
 }
 
@@ -0,0 +1,223 @@
+package api
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"evalgo.org/graphium/internal/auth"
+	"evalgo.org/graphium/internal/stack"
+	"evalgo.org/graphium/models"
+)
+
+// StackRollbackResponse describes the tasks created to roll a stack back.
+type StackRollbackResponse struct {
+	StackID      string    `json:"stackId"`
+	DeploymentID string    `json:"deploymentId"`
+	DeployedAt   time.Time `json:"deployedAt"`
+	RemoveTasks  []string  `json:"removeTasks"`
+	DeployTasks  []string  `json:"deployTasks"`
+}
+
+// rollbackStack redeploys the last known good deployment of a stack.
+// @Summary Roll back a stack
+// @Description Redeploy the stack definition and placements of the last known good deployment via agent tasks. The containers of the current deployment are removed first. The placements stay pending until the deploy tasks report the new containers; then the stack is running again.
+// @Tags stacks
+// @Produce json
+// @Param id path string true "Stack ID"
+// @Success 202 {object} StackRollbackResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse "No last known good deployment"
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/stacks/{id}/rollback [post]
+func (s *Server) rollbackStack(c echo.Context) error {
+	id := c.Param("id")
+
+	stk, err := s.storage.GetStack(id)
+	if err != nil {
+		return NotFoundError("Stack", id)
+	}
+	if stk.LastGood == nil {
+		return ConflictError("Stack has no last known good deployment", id)
+	}
+
+	parser := stack.NewStackParser(&APIHostResolver{storage: s.storage})
+	parseResult, err := parser.Parse(&stk.LastGood.Definition)
+	if err != nil {
+		return InternalError("Failed to parse last known good definition", err.Error())
+	}
+	if len(parseResult.Errors) > 0 {
+		return BadRequestError("Last known good definition is no longer valid", strings.Join(parseResult.Errors, "; "))
+	}
+
+	user := ""
+	if userID, ok := auth.GetUserID(c); ok {
+		user = userID
+	}
+
+	removeTasks, deployTasks, err := buildRollbackTasks(stk, parseResult.Plan, user)
+	if err != nil {
+		return BadRequestError("Cannot roll back stack", err.Error())
+	}
+
+	// Refuse to remove or replace protected containers before queueing any task
+	for _, task := range removeTasks {
+		if err := s.checkTaskProtection(task); err != nil {
+			return err
+		}
+	}
+	for _, spec := range parseResult.Plan.ContainerSpecs {
+		if ok, reason := s.protection.Protects(spec.Name, spec.Image); ok {
+			return NewAPIError(http.StatusForbidden, "Container is protected", "container "+spec.Name+" is protected: "+reason)
+		}
+	}
+
+	response := &StackRollbackResponse{
+		StackID:      stk.ID,
+		DeploymentID: stk.LastGood.DeploymentID,
+		DeployedAt:   stk.LastGood.DeployedAt,
+		RemoveTasks:  make([]string, 0, len(removeTasks)),
+		DeployTasks:  make([]string, 0, len(deployTasks)),
+	}
+
+	for _, task := range append(removeTasks, deployTasks...) {
+		if err := s.queueTask(task); err != nil {
+			return err
+		}
+		if task.Type == "DeleteAction" {
+			response.RemoveTasks = append(response.RemoveTasks, task.ID)
+		} else {
+			response.DeployTasks = append(response.DeployTasks, task.ID)
+		}
+	}
+
+	// The last known good deployment becomes current again. It stays the
+	// rollback target so a repeated rollback is a no-op redeploy. The
+	// deploy tasks recreate its containers, so its placements are pending
+	// until they report the new ones (recordRedeployedContainer).
+	now := time.Now()
+	stk.CurrentDeployment = stk.LastGood.PendingRedeploy()
+	stk.Status = "deploying"
+	stk.RolledBackAt = &now
+	stk.UpdatedAt = now
+	if err := s.storage.UpdateStack(stk); err != nil {
+		return InternalError("Failed to update stack", err.Error())
+	}
+
+	s.BroadcastGraphEvent("stack_rollback", map[string]interface{}{
+		"stackId":      stk.ID,
+		"deploymentId": stk.LastGood.DeploymentID,
+		"taskCount":    len(removeTasks) + len(deployTasks),
+	})
+
+	return c.JSON(http.StatusAccepted, response)
+}
+
+// recordRedeployedContainer records the container a completed rollback
+// deploy task created in the pending placement of its stack.
+func (s *Server) recordRedeployedContainer(task *models.AgentTask) {
+	if task.Type != "ActivateAction" || task.StackID == "" {
+		return
+	}
+	result, err := task.GetResult()
+	if err != nil || result == nil || result.ContainerID == "" {
+		return
+	}
+	var payload models.DeployContainerPayload
+	if err := task.GetPayloadAs(&payload); err != nil {
+		return
+	}
+	if _, err := s.storage.RecordRedeployedContainer(task.StackID, payload.ContainerSpec.Name, result.ContainerID); err != nil {
+		log.Printf("Failed to record container %s of stack %s: %v", payload.ContainerSpec.Name, task.StackID, err)
+	}
+}
+
+// buildRollbackTasks returns agent tasks that remove the containers of the
+// stack's current deployment and redeploy the containers of plan, which is
+// parsed from the stack's last known good definition. Each deploy task waits
+// for all remove tasks (container names are reused) and for the deploy tasks
+// of the containers it depends on.
+func buildRollbackTasks(stk *models.Stack, plan *models.DeploymentPlan, createdBy string) ([]*models.AgentTask, []*models.AgentTask, error) {
+	stackName := plan.StackNode.Name
+
+	newTask := func(taskType, hostID, name string) *models.AgentTask {
+		task := newAgentTask(taskType, name, hostID, createdBy)
+		task.StackID = stk.ID
+		return task
+	}
+
+	var removeTasks []*models.AgentTask
+	if stk.CurrentDeployment != nil {
+		for name, placement := range stk.CurrentDeployment.Placements {
+			if placement == nil || placement.ContainerID == "" || placement.HostID == "" {
+				continue
+			}
+			task := newTask("DeleteAction", placement.HostID, fmt.Sprintf("Rollback: remove %s", name))
+			task.ContainerID = placement.ContainerID
+			if err := task.SetPayload(models.DeleteContainerPayload{
+				ContainerID:   placement.ContainerID,
+				ContainerName: name,
+				Force:         true,
+			}); err != nil {
+				return nil, nil, err
+			}
+			removeTasks = append(removeTasks, task)
+		}
+	}
+
+	removeIDs := make([]string, 0, len(removeTasks))
+	for _, task := range removeTasks {
+		removeIDs = append(removeIDs, task.ID)
+	}
+
+	// Create deploy tasks wave by wave so dependencies already have task IDs
+	deployIDs := make(map[string]string)
+	var deployTasks []*models.AgentTask
+	for _, wave := range stack.NewStackParser(nil).GetContainersByWave(plan) {
+		for _, spec := range wave {
+			if len(spec.Files) > 0 {
+				return nil, nil, fmt.Errorf("container %s uses file mounts, which agent task deployments do not support", spec.Name)
+			}
+
+			containerName := fmt.Sprintf("%s-%s", stackName, spec.Name)
+			hostID := ""
+			if placement := stk.LastGood.Placements[containerName]; placement != nil {
+				hostID = placement.HostID
+			}
+			if hostID == "" {
+				hostID = plan.HostMap[spec.ID]
+			}
+			if hostID == "" {
+				return nil, nil, fmt.Errorf("no host known for container %s", spec.Name)
+			}
+
+			deploySpec := spec
+			deploySpec.Name = containerName
+
+			task := newTask("ActivateAction", hostID, fmt.Sprintf("Rollback: deploy %s", containerName))
+			task.DependsOn = append([]string{}, removeIDs...)
+			for _, dep := range spec.DependsOn {
+				if depID, ok := deployIDs[dep]; ok {
+					task.DependsOn = append(task.DependsOn, depID)
+				}
+			}
+			if err := task.SetPayload(models.DeployContainerPayload{
+				ContainerSpec: deploySpec,
+				Labels:        spec.Labels,
+				PullPolicy:    "if-not-present",
+			}); err != nil {
+				return nil, nil, err
+			}
+
+			deployIDs[spec.Name] = task.ID
+			deployTasks = append(deployTasks, task)
+		}
+	}
+
+	return removeTasks, deployTasks, nil
+}
@@ -0,0 +1,124 @@
+package api
+
+import (
+	"testing"
+
+	"evalgo.org/graphium/models"
+)
+
+func rollbackTestStack() (*models.Stack, *models.DeploymentPlan) {
+	stk := &models.Stack{
+		ID:   "shop",
+		Name: "shop",
+		CurrentDeployment: &models.StackSnapshot{
+			DeploymentID: "deployment-shop-2",
+			Placements: map[string]*models.ContainerPlacement{
+				"shop-db":  {ContainerID: "new-db", ContainerName: "shop-db", HostID: "host1"},
+				"shop-web": {ContainerID: "new-web", ContainerName: "shop-web", HostID: "host2"},
+			},
+		},
+		LastGood: &models.StackSnapshot{
+			DeploymentID: "deployment-shop-1",
+			Placements: map[string]*models.ContainerPlacement{
+				"shop-db":  {ContainerID: "old-db", ContainerName: "shop-db", HostID: "host1"},
+				"shop-web": {ContainerID: "old-web", ContainerName: "shop-web", HostID: "host3"},
+			},
+		},
+	}
+
+	plan := &models.DeploymentPlan{
+		StackNode: &models.GraphNode{ID: "shop", Name: "shop"},
+		ContainerSpecs: []models.ContainerSpec{
+			{ID: "db", Name: "db", Image: "postgres:15"},
+			{ID: "web", Name: "web", Image: "shop:1.0", DependsOn: []string{"db"}},
+		},
+		HostMap:         map[string]string{"db": "host1", "web": "host2"},
+		DependencyGraph: [][]string{{"db"}, {"web"}},
+	}
+
+	return stk, plan
+}
+
+func TestBuildRollbackTasks(t *testing.T) {
+	stk, plan := rollbackTestStack()
+
+	removeTasks, deployTasks, err := buildRollbackTasks(stk, plan, "alice")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(removeTasks) != 2 {
+		t.Fatalf("Expected 2 remove tasks, got %d", len(removeTasks))
+	}
+	removed := make(map[string]bool)
+	for _, task := range removeTasks {
+		if task.Type != "DeleteAction" {
+			t.Errorf("Expected DeleteAction, got %s", task.Type)
+		}
+		var payload models.DeleteContainerPayload
+		if err := task.GetPayloadAs(&payload); err != nil {
+			t.Fatalf("Failed to decode payload: %v", err)
+		}
+		if !payload.Force {
+			t.Error("Expected forced removal")
+		}
+		removed[payload.ContainerID] = true
+	}
+	if !removed["new-db"] || !removed["new-web"] {
+		t.Errorf("Expected current containers to be removed, got %v", removed)
+	}
+
+	if len(deployTasks) != 2 {
+		t.Fatalf("Expected 2 deploy tasks, got %d", len(deployTasks))
+	}
+
+	db, web := deployTasks[0], deployTasks[1]
+	var webPayload models.DeployContainerPayload
+	if err := web.GetPayloadAs(&webPayload); err != nil {
+		t.Fatalf("Failed to decode payload: %v", err)
+	}
+	if webPayload.ContainerSpec.Name != "shop-web" {
+		t.Errorf("Expected container name shop-web, got %s", webPayload.ContainerSpec.Name)
+	}
+	if web.HostID != "host3" {
+		t.Errorf("Expected web to return to its last good host host3, got %s", web.HostID)
+	}
+	if web.CreatedBy != "alice" || web.StackID != "shop" {
+		t.Errorf("Unexpected task metadata: createdBy=%s stackId=%s", web.CreatedBy, web.StackID)
+	}
+
+	// web waits for both removals and for db
+	deps := make(map[string]bool)
+	for _, id := range web.DependsOn {
+		deps[id] = true
+	}
+	if len(deps) != 3 || !deps[db.ID] || !deps[removeTasks[0].ID] || !deps[removeTasks[1].ID] {
+		t.Errorf("Unexpected web dependencies: %v", web.DependsOn)
+	}
+}
+
+func TestBuildRollbackTasks_RejectsFileMounts(t *testing.T) {
+	stk, plan := rollbackTestStack()
+	plan.ContainerSpecs[1].Files = []models.FileMount{{Target: "/etc/app.conf", Content: "x"}}
+
+	if _, _, err := buildRollbackTasks(stk, plan, ""); err == nil {
+		t.Error("Expected error for container with file mounts")
+	}
+}
+
+func TestBuildRollbackTasks_SkipsPendingPlacements(t *testing.T) {
+	stk, plan := rollbackTestStack()
+	stk.CurrentDeployment = stk.LastGood.PendingRedeploy()
+
+	// A rollback whose containers weren't recreated yet has none to remove
+	removeTasks, deployTasks, err := buildRollbackTasks(stk, plan, "alice")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(removeTasks) != 0 {
+		t.Errorf("Expected no remove tasks for pending placements, got %d", len(removeTasks))
+	}
+	if len(deployTasks) != 2 {
+		t.Errorf("Expected 2 deploy tasks, got %d", len(deployTasks))
+	}
+}
@@ -0,0 +1,275 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/labstack/echo/v4"
+
+	"evalgo.org/graphium/models"
+)
+
+// stackUsageCacheTTL is how long collected stack usage is served from the
+// cache before the hosts are asked again.
+const stackUsageCacheTTL = 10 * time.Second
+
+// stackUsageTimeout bounds how long collecting the usage of a stack may take.
+const stackUsageTimeout = 15 * time.Second
+
+// containerStatsClient is implemented by Docker clients that can read live
+// container stats (the Docker SDK client does).
+type containerStatsClient interface {
+	ContainerStats(ctx context.Context, containerID string, stream bool) (container.StatsResponseReader, error)
+}
+
+// containerSizeClient is implemented by Docker clients that can report the
+// size of a container's writable layer (the Docker SDK client does).
+type containerSizeClient interface {
+	ContainerInspectWithRaw(ctx context.Context, containerID string, getSize bool) (container.InspectResponse, []byte, error)
+}
+
+// stackUsageCache keeps recently collected stack usage, so dashboards
+// polling a stack don't query its hosts on every request.
+type stackUsageCache struct {
+	mu      sync.Mutex
+	entries map[string]*models.StackUsage
+}
+
+func newStackUsageCache() *stackUsageCache {
+	return &stackUsageCache{entries: make(map[string]*models.StackUsage)}
+}
+
+// get returns the cached usage of a stack if it is still fresh.
+func (c *stackUsageCache) get(stackID string, now time.Time) *models.StackUsage {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	usage, ok := c.entries[stackID]
+	if !ok || now.Sub(usage.CollectedAt) > stackUsageCacheTTL {
+		return nil
+	}
+	return usage
+}
+
+// put caches the usage of a stack and drops expired entries.
+func (c *stackUsageCache) put(usage *models.StackUsage) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for id, entry := range c.entries {
+		if usage.CollectedAt.Sub(entry.CollectedAt) > stackUsageCacheTTL {
+			delete(c.entries, id)
+		}
+	}
+	c.entries[usage.StackID] = usage
+}
+
+// getStackUsage handles GET /api/v1/stacks/:id/usage
+// @Summary Get stack resource usage
+// @Description Get the live CPU, memory, disk, block I/O and network usage of a deployed stack, read from the Docker engine of each host it is placed on. Returns totals for the stack, per service and per container. Containers on unreachable hosts are reported with unknown usage and excluded from the totals. Results are cached for 10 seconds.
+// @Tags Stacks
+// @Produce json
+// @Param id path string true "Stack ID"
+// @Success 200 {object} models.StackUsage
+// @Failure 400 {object} APIError "Stack is not deployed"
+// @Failure 404 {object} APIError
+// @Router /stacks/{id}/usage [get]
+func (s *Server) getStackUsage(c echo.Context) error {
+	id := c.Param("id")
+
+	stack, err := s.storage.GetStack(id)
+	if err != nil {
+		return NotFoundError("Stack", id)
+	}
+
+	if usage := s.stackUsage.get(stack.ID, time.Now()); usage != nil {
+		return c.JSON(http.StatusOK, usage)
+	}
+
+	placements := s.stackPlacements(stack)
+	if len(placements) == 0 {
+		return BadRequestError("Stack is not deployed", "stack "+id+" has no placed containers")
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request().Context(), stackUsageTimeout)
+	defer cancel()
+
+	usage := models.AggregateStackUsage(stack.ID, s.collectContainerUsage(ctx, placements), time.Now())
+	s.stackUsage.put(usage)
+
+	return c.JSON(http.StatusOK, usage)
+}
+
+// stackPlacements returns where the containers of a stack's current
+// deployment were placed, keyed by service name.
+func (s *Server) stackPlacements(stack *models.Stack) map[string]*models.ContainerPlacement {
+	if stack.CurrentDeployment != nil && len(stack.CurrentDeployment.Placements) > 0 {
+		return stack.CurrentDeployment.Placements
+	}
+	if state, err := s.storage.GetDeploymentState(stack.ID); err == nil && state != nil {
+		return state.Placements
+	}
+	return nil
+}
+
+// collectContainerUsage reads the live usage of placed containers, one
+// Docker client per host and all hosts in parallel. Containers whose host
+// or stats can't be read get an error instead of a usage.
+func (s *Server) collectContainerUsage(ctx context.Context, placements map[string]*models.ContainerPlacement) []models.ContainerUsage {
+	byHost := make(map[string][]models.ContainerUsage)
+	for service, placement := range placements {
+		if placement == nil {
+			continue
+		}
+		byHost[placement.HostID] = append(byHost[placement.HostID], models.ContainerUsage{
+			Service:     service,
+			ContainerID: placement.ContainerID,
+			HostID:      placement.HostID,
+		})
+	}
+
+	factory := &APIDockerClientFactory{storage: s.storage}
+
+	var (
+		mu     sync.Mutex
+		wg     sync.WaitGroup
+		result []models.ContainerUsage
+	)
+	for hostID, containers := range byHost {
+		wg.Add(1)
+		go func(hostID string, containers []models.ContainerUsage) {
+			defer wg.Done()
+			readHostUsage(ctx, factory, hostID, containers)
+
+			mu.Lock()
+			result = append(result, containers...)
+			mu.Unlock()
+		}(hostID, containers)
+	}
+	wg.Wait()
+
+	return result
+}
+
+// readHostUsage fills in the usage of the containers placed on one host.
+func readHostUsage(ctx context.Context, factory *APIDockerClientFactory, hostID string, containers []models.ContainerUsage) {
+	fail := func(message string) {
+		for i := range containers {
+			containers[i].Error = message
+		}
+	}
+
+	if hostID == "" {
+		fail("container is not placed on a host")
+		return
+	}
+
+	cli, err := factory.GetClient(ctx, hostID)
+	if err != nil {
+		fail("host " + hostID + " is unreachable: " + err.Error())
+		return
+	}
+	defer cli.Close()
+
+	statsClient, ok := cli.(containerStatsClient)
+	if !ok {
+		fail("the Docker client of host " + hostID + " can't read container stats")
+		return
+	}
+	sizeClient, _ := cli.(containerSizeClient)
+
+	var wg sync.WaitGroup
+	for i := range containers {
+		wg.Add(1)
+		go func(entry *models.ContainerUsage) {
+			defer wg.Done()
+
+			usage, err := readContainerUsage(ctx, statsClient, sizeClient, entry.ContainerID)
+			if err != nil {
+				entry.Error = err.Error()
+				return
+			}
+			entry.Usage = usage
+		}(&containers[i])
+	}
+	wg.Wait()
+}
+
+// readContainerUsage reads the live usage of one container. Docker takes
+// a second to sample the CPU usage.
+func readContainerUsage(ctx context.Context, statsClient containerStatsClient, sizeClient containerSizeClient, containerID string) (*models.ResourceUsage, error) {
+	if containerID == "" {
+		return nil, fmt.Errorf("container was not created")
+	}
+
+	reader, err := statsClient.ContainerStats(ctx, containerID, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read stats of container %s: %w", containerID, err)
+	}
+	defer reader.Body.Close()
+
+	var stats container.StatsResponse
+	if err := json.NewDecoder(reader.Body).Decode(&stats); err != nil {
+		return nil, fmt.Errorf("failed to decode stats of container %s: %w", containerID, err)
+	}
+
+	usage := usageFromStats(&stats)
+
+	// The writable layer size is optional; computing it can be slow
+	if sizeClient != nil {
+		if inspect, _, err := sizeClient.ContainerInspectWithRaw(ctx, containerID, true); err == nil && inspect.SizeRw != nil {
+			usage.DiskUsage = *inspect.SizeRw
+		}
+	}
+
+	return usage, nil
+}
+
+// usageFromStats converts Docker container stats to resource usage, the
+// way docker stats computes CPU percent and memory usage.
+func usageFromStats(stats *container.StatsResponse) *models.ResourceUsage {
+	usage := &models.ResourceUsage{
+		MemoryLimit: int64(stats.MemoryStats.Limit),
+	}
+
+	cpuDelta := float64(stats.CPUStats.CPUUsage.TotalUsage) - float64(stats.PreCPUStats.CPUUsage.TotalUsage)
+	systemDelta := float64(stats.CPUStats.SystemUsage) - float64(stats.PreCPUStats.SystemUsage)
+	cpus := float64(stats.CPUStats.OnlineCPUs)
+	if cpus == 0 {
+		cpus = float64(len(stats.CPUStats.CPUUsage.PercpuUsage))
+	}
+	if cpuDelta > 0 && systemDelta > 0 {
+		usage.CPUPercent = cpuDelta / systemDelta * cpus * 100
+	}
+
+	// Page cache can be reclaimed, so it doesn't count as used
+	memory := stats.MemoryStats.Usage
+	if inactive, ok := stats.MemoryStats.Stats["inactive_file"]; ok && inactive < memory {
+		memory -= inactive
+	} else if inactive, ok := stats.MemoryStats.Stats["total_inactive_file"]; ok && inactive < memory {
+		memory -= inactive
+	}
+	usage.MemoryUsage = int64(memory)
+
+	for _, entry := range stats.BlkioStats.IoServiceBytesRecursive {
+		switch strings.ToLower(entry.Op) {
+		case "read":
+			usage.BlockRead += int64(entry.Value)
+		case "write":
+			usage.BlockWrite += int64(entry.Value)
+		}
+	}
+
+	for _, network := range stats.Networks {
+		usage.NetworkRx += int64(network.RxBytes)
+		usage.NetworkTx += int64(network.TxBytes)
+	}
+
+	return usage
+}
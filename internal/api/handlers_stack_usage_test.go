@@ -0,0 +1,83 @@
+package api
+
+import (
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+
+	"evalgo.org/graphium/models"
+)
+
+func TestUsageFromStats(t *testing.T) {
+	stats := &container.StatsResponse{
+		CPUStats: container.CPUStats{
+			CPUUsage:    container.CPUUsage{TotalUsage: 3_000_000},
+			SystemUsage: 20_000_000,
+			OnlineCPUs:  4,
+		},
+		PreCPUStats: container.CPUStats{
+			CPUUsage:    container.CPUUsage{TotalUsage: 1_000_000},
+			SystemUsage: 10_000_000,
+		},
+		MemoryStats: container.MemoryStats{
+			Usage: 500,
+			Limit: 1000,
+			Stats: map[string]uint64{"inactive_file": 100},
+		},
+		BlkioStats: container.BlkioStats{
+			IoServiceBytesRecursive: []container.BlkioStatEntry{
+				{Op: "Read", Value: 10},
+				{Op: "write", Value: 20},
+				{Op: "read", Value: 5},
+			},
+		},
+		Networks: map[string]container.NetworkStats{
+			"eth0": {RxBytes: 100, TxBytes: 50},
+			"eth1": {RxBytes: 1, TxBytes: 2},
+		},
+	}
+
+	usage := usageFromStats(stats)
+
+	// 2ms of 10ms system time on 4 CPUs
+	if usage.CPUPercent != 80 {
+		t.Errorf("Expected 80%% CPU, got %v", usage.CPUPercent)
+	}
+	if usage.MemoryUsage != 400 || usage.MemoryLimit != 1000 {
+		t.Errorf("Expected 400 of 1000 bytes of memory without the page cache, got %d of %d", usage.MemoryUsage, usage.MemoryLimit)
+	}
+	if usage.BlockRead != 15 || usage.BlockWrite != 20 {
+		t.Errorf("Expected 15 bytes read and 20 written, got %d and %d", usage.BlockRead, usage.BlockWrite)
+	}
+	if usage.NetworkRx != 101 || usage.NetworkTx != 52 {
+		t.Errorf("Expected network totals of all interfaces, got rx %d tx %d", usage.NetworkRx, usage.NetworkTx)
+	}
+
+	// Without a previous sample there is no CPU usage
+	if usage := usageFromStats(&container.StatsResponse{}); usage.CPUPercent != 0 {
+		t.Errorf("Expected no CPU usage without samples, got %v", usage.CPUPercent)
+	}
+}
+
+func TestStackUsageCache(t *testing.T) {
+	cache := newStackUsageCache()
+	now := time.Now()
+
+	cache.put(&models.StackUsage{StackID: "stack-1", CollectedAt: now})
+
+	if cache.get("stack-1", now.Add(stackUsageCacheTTL/2)) == nil {
+		t.Error("Expected fresh usage to be cached")
+	}
+	if cache.get("stack-1", now.Add(2*stackUsageCacheTTL)) != nil {
+		t.Error("Expected expired usage not to be served")
+	}
+	if cache.get("stack-2", now) != nil {
+		t.Error("Expected no usage for an unknown stack")
+	}
+
+	cache.put(&models.StackUsage{StackID: "stack-2", CollectedAt: now.Add(2 * stackUsageCacheTTL)})
+	if _, ok := cache.entries["stack-1"]; ok {
+		t.Error("Expected expired entries to be dropped")
+	}
+}
@@ -46,6 +46,7 @@ func (s *Server) getStatistics(c echo.Context) error {
 		"runningStacks":         stats.RunningStacks,
 		"totalAgents":           stats.TotalAgents,
 		"runningAgents":         stats.RunningAgents,
+		"orphanedContainers":    stats.OrphanedContainers,
 		"hostsWithContainers":   len(stats.HostContainerCounts),
 		"containerDistribution": stats.HostContainerCounts,
 	})
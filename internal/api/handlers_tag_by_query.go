@@ -0,0 +1,167 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/labstack/echo/v4"
+
+	"evalgo.org/graphium/models"
+)
+
+// ContainerQueryFilter selects containers for tag-by-query. All set fields
+// must match.
+type ContainerQueryFilter struct {
+	// Status is the container status, e.g. running
+	Status string `json:"status,omitempty"`
+
+	// Image is an image reference or glob pattern; an image without a tag
+	// matches every tag, e.g. "nginx" matches "nginx:1.25"
+	Image string `json:"image,omitempty"`
+
+	// Host is the ID of the host the containers run on
+	Host string `json:"host,omitempty"`
+
+	// Env are environment variables the containers must have; an empty
+	// value matches any value
+	Env map[string]string `json:"env,omitempty"`
+}
+
+// IsEmpty reports whether the filter would match every container.
+func (f ContainerQueryFilter) IsEmpty() bool {
+	return f.Status == "" && f.Image == "" && f.Host == "" && len(f.Env) == 0
+}
+
+// Matches reports whether a container matches the image and env criteria.
+// Status and host are matched by the storage query.
+func (f ContainerQueryFilter) Matches(container *models.Container) bool {
+	if f.Image != "" && !models.ImageMatches(f.Image, container.Image) {
+		return false
+	}
+	for key, want := range f.Env {
+		value, ok := container.Env[key]
+		if !ok || (want != "" && value != want) {
+			return false
+		}
+	}
+	return true
+}
+
+// TagByQueryRequest is the request body for POST /api/v1/containers/tag-by-query.
+type TagByQueryRequest struct {
+	// Filter selects the containers to label
+	Filter ContainerQueryFilter `json:"filter"`
+
+	// All must be set to label every container when the filter is empty
+	All bool `json:"all,omitempty"`
+
+	// Add are labels to set on every matching container (existing keys are overwritten)
+	Add map[string]string `json:"add,omitempty"`
+
+	// Remove are label keys to remove from every matching container
+	Remove []string `json:"remove,omitempty"`
+}
+
+// TagByQueryResponse reports the containers a tag-by-query request updated.
+type TagByQueryResponse struct {
+	// Matched is the number of containers the filter selected
+	Matched int `json:"matched"`
+
+	// Affected is the number of containers whose labels were saved
+	Affected int `json:"affected"`
+
+	// IDs are the updated containers
+	IDs []string `json:"ids"`
+
+	// Failures are the containers that couldn't be saved
+	Failures []BulkResult `json:"failures,omitempty"`
+}
+
+// tagContainersByQuery handles POST /api/v1/containers/tag-by-query
+// @Summary Label containers matching a query
+// @Description Resolve the containers matching a filter (status, image, host, env) and set or remove labels on all of them in one bulk update. An empty filter is refused unless "all" is true; at most 1000 containers may match. Containers whose labels set by Docker would be removed or changed are reported as failures.
+// @Tags Containers
+// @Accept json
+// @Produce json
+// @Param request body TagByQueryRequest true "Filter and label operations"
+// @Success 200 {object} TagByQueryResponse
+// @Failure 400 {object} APIError
+// @Failure 500 {object} APIError
+// @Router /containers/tag-by-query [post]
+func (s *Server) tagContainersByQuery(c echo.Context) error {
+	var req TagByQueryRequest
+	if err := c.Bind(&req); err != nil {
+		return BadRequestError("Invalid request body", err.Error())
+	}
+
+	fieldErrors := make(map[string]string)
+	if req.Filter.IsEmpty() && !req.All {
+		fieldErrors["filter"] = `A non-empty filter is required; set "all": true to label every container`
+	}
+	if len(req.Add)+len(req.Remove) == 0 {
+		fieldErrors["add"] = "At least one add or remove operation is required"
+	}
+	for key := range req.Add {
+		if key == "" {
+			fieldErrors["add"] = "Label keys cannot be empty"
+		}
+	}
+	if len(fieldErrors) > 0 {
+		return ValidationError("Validation failed", fieldErrors)
+	}
+
+	filters := make(map[string]interface{})
+	if req.Filter.Status != "" {
+		filters["status"] = req.Filter.Status
+	}
+	if req.Filter.Host != "" {
+		filters["hostedOn"] = req.Filter.Host
+	}
+	containers, err := s.storage.ListContainers(filters)
+	if err != nil {
+		return InternalError("Failed to query containers", err.Error())
+	}
+
+	var ids []string
+	for _, container := range containers {
+		if req.Filter.Matches(container) {
+			ids = append(ids, container.ID)
+		}
+	}
+	sort.Strings(ids)
+
+	if len(ids) > maxBulkTagIDs {
+		return BadRequestError("Query matches too many containers",
+			fmt.Sprintf("%d containers match, at most %d may be labeled per request; narrow the filter", len(ids), maxBulkTagIDs))
+	}
+
+	response := TagByQueryResponse{Matched: len(ids), IDs: []string{}}
+	if len(ids) == 0 {
+		return c.JSON(http.StatusOK, response)
+	}
+
+	results, updated, err := s.storage.BulkUpdateContainerLabels(ids, req.Add, req.Remove)
+	if err != nil {
+		return InternalError("Failed to update container labels", err.Error())
+	}
+
+	for _, result := range toBulkResponse(results).Results {
+		if result.Success {
+			response.IDs = append(response.IDs, result.ID)
+		} else {
+			response.Failures = append(response.Failures, result)
+		}
+	}
+	response.Affected = len(response.IDs)
+
+	saved := savedIDs(results)
+	for _, container := range updated {
+		if rev, ok := saved[container.ID]; ok {
+			container.Rev = rev
+			s.BroadcastGraphEvent(EventContainerUpdated, container)
+		}
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
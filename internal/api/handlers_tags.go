@@ -0,0 +1,170 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+
+	"eve.evalgo.org/db"
+	"github.com/labstack/echo/v4"
+)
+
+// maxBulkTagIDs is the maximum number of documents a single bulk tag/label request may update
+const maxBulkTagIDs = 1000
+
+// BulkHostTagsRequest is the request body for POST /api/v1/hosts/bulk/tags.
+type BulkHostTagsRequest struct {
+	// IDs are the hosts to update
+	IDs []string `json:"ids"`
+
+	// Add are tags to add to every host
+	Add []string `json:"add,omitempty"`
+
+	// Remove are tags to remove from every host
+	Remove []string `json:"remove,omitempty"`
+}
+
+// BulkContainerLabelsRequest is the request body for POST /api/v1/containers/bulk/labels.
+type BulkContainerLabelsRequest struct {
+	// IDs are the containers to update
+	IDs []string `json:"ids"`
+
+	// Add are labels to set on every container (existing keys are overwritten)
+	Add map[string]string `json:"add,omitempty"`
+
+	// Remove are label keys to remove from every container
+	Remove []string `json:"remove,omitempty"`
+}
+
+// bulkUpdateHostTags handles POST /api/v1/hosts/bulk/tags
+// @Summary Bulk add/remove host tags
+// @Description Add and remove tags on many hosts in a single operation, with per-host results
+// @Tags Hosts
+// @Accept json
+// @Produce json
+// @Param request body BulkHostTagsRequest true "Hosts and tag operations"
+// @Success 200 {object} BulkResponse
+// @Failure 400 {object} APIError
+// @Failure 500 {object} APIError
+// @Router /hosts/bulk/tags [post]
+func (s *Server) bulkUpdateHostTags(c echo.Context) error {
+	var req BulkHostTagsRequest
+	if err := c.Bind(&req); err != nil {
+		return BadRequestError("Invalid request body", err.Error())
+	}
+
+	if err := validateBulkTagRequest(req.IDs, len(req.Add)+len(req.Remove)); err != nil {
+		return err
+	}
+
+	results, hosts, err := s.storage.BulkUpdateHostTags(req.IDs, req.Add, req.Remove)
+	if err != nil {
+		return InternalError("Failed to update host tags", err.Error())
+	}
+
+	response := toBulkResponse(results)
+
+	saved := savedIDs(results)
+	for _, host := range hosts {
+		if rev, ok := saved[host.ID]; ok {
+			host.Rev = rev
+			s.BroadcastGraphEvent(EventHostUpdated, host)
+		}
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// bulkUpdateContainerLabels handles POST /api/v1/containers/bulk/labels
+// @Summary Bulk add/remove container labels
+// @Description Set and remove labels on many containers in a single operation, with per-container results. Labels set by Docker can't be removed or changed; such containers are reported with the error "conflict".
+// @Tags Containers
+// @Accept json
+// @Produce json
+// @Param request body BulkContainerLabelsRequest true "Containers and label operations"
+// @Success 200 {object} BulkResponse
+// @Failure 400 {object} APIError
+// @Failure 500 {object} APIError
+// @Router /containers/bulk/labels [post]
+func (s *Server) bulkUpdateContainerLabels(c echo.Context) error {
+	var req BulkContainerLabelsRequest
+	if err := c.Bind(&req); err != nil {
+		return BadRequestError("Invalid request body", err.Error())
+	}
+
+	if err := validateBulkTagRequest(req.IDs, len(req.Add)+len(req.Remove)); err != nil {
+		return err
+	}
+	for key := range req.Add {
+		if key == "" {
+			return ValidationError("Validation failed", map[string]string{"add": "Label keys cannot be empty"})
+		}
+	}
+
+	results, containers, err := s.storage.BulkUpdateContainerLabels(req.IDs, req.Add, req.Remove)
+	if err != nil {
+		return InternalError("Failed to update container labels", err.Error())
+	}
+
+	response := toBulkResponse(results)
+
+	saved := savedIDs(results)
+	for _, container := range containers {
+		if rev, ok := saved[container.ID]; ok {
+			container.Rev = rev
+			s.BroadcastGraphEvent(EventContainerUpdated, container)
+		}
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// validateBulkTagRequest checks the ids and that at least one operation was requested.
+func validateBulkTagRequest(ids []string, operations int) error {
+	fieldErrors := make(map[string]string)
+	if len(ids) == 0 {
+		fieldErrors["ids"] = "At least one id is required"
+	} else if len(ids) > maxBulkTagIDs {
+		fieldErrors["ids"] = fmt.Sprintf("At most %d ids are allowed per request", maxBulkTagIDs)
+	}
+	if operations == 0 {
+		fieldErrors["add"] = "At least one add or remove operation is required"
+	}
+	if len(fieldErrors) > 0 {
+		return ValidationError("Validation failed", fieldErrors)
+	}
+	return nil
+}
+
+// toBulkResponse converts database bulk results to the API bulk response.
+func toBulkResponse(results []db.BulkResult) BulkResponse {
+	response := BulkResponse{
+		Total:   len(results),
+		Results: make([]BulkResult, len(results)),
+	}
+	for i, result := range results {
+		if result.OK {
+			response.Success++
+		} else {
+			response.Failed++
+		}
+		response.Results[i] = BulkResult{
+			ID:      result.ID,
+			Rev:     result.Rev,
+			Error:   result.Error,
+			Reason:  result.Reason,
+			Success: result.OK,
+		}
+	}
+	return response
+}
+
+// savedIDs maps the ids of successfully saved documents to their new revision.
+func savedIDs(results []db.BulkResult) map[string]string {
+	saved := make(map[string]string, len(results))
+	for _, result := range results {
+		if result.OK {
+			saved[result.ID] = result.Rev
+		}
+	}
+	return saved
+}
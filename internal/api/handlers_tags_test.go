@@ -0,0 +1,106 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"eve.evalgo.org/db"
+	"github.com/labstack/echo/v4"
+
+	"evalgo.org/graphium/models"
+)
+
+func TestValidateBulkTagRequest(t *testing.T) {
+	tooMany := make([]string, maxBulkTagIDs+1)
+
+	tests := []struct {
+		name       string
+		ids        []string
+		operations int
+		wantErr    bool
+	}{
+		{name: "valid", ids: []string{"a", "b"}, operations: 1},
+		{name: "no ids", ids: nil, operations: 1, wantErr: true},
+		{name: "no operations", ids: []string{"a"}, operations: 0, wantErr: true},
+		{name: "too many ids", ids: tooMany, operations: 1, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateBulkTagRequest(tt.ids, tt.operations)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateBulkTagRequest() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestToBulkResponse(t *testing.T) {
+	response := toBulkResponse([]db.BulkResult{
+		{ID: "a", Rev: "2-x", OK: true},
+		{ID: "b", Error: "not_found", Reason: "document does not exist"},
+		{ID: "c", Error: "conflict", Reason: "Document update conflict."},
+	})
+
+	if response.Total != 3 || response.Success != 1 || response.Failed != 2 {
+		t.Errorf("Expected 3 total, 1 success, 2 failed, got %d/%d/%d",
+			response.Total, response.Success, response.Failed)
+	}
+	if !response.Results[0].Success || response.Results[0].Rev != "2-x" {
+		t.Errorf("Expected first result to succeed with rev 2-x, got %+v", response.Results[0])
+	}
+	if response.Results[1].Error != "not_found" {
+		t.Errorf("Expected second result error not_found, got %q", response.Results[1].Error)
+	}
+}
+
+func TestContainerQueryFilter_Matches(t *testing.T) {
+	container := &models.Container{
+		Image: "nginx:1.25",
+		Env:   map[string]string{"APP_ENV": "prod", "DEBUG": ""},
+	}
+
+	tests := []struct {
+		name   string
+		filter ContainerQueryFilter
+		want   bool
+	}{
+		{name: "image without tag", filter: ContainerQueryFilter{Image: "nginx"}, want: true},
+		{name: "image with tag", filter: ContainerQueryFilter{Image: "nginx:1.25"}, want: true},
+		{name: "other tag", filter: ContainerQueryFilter{Image: "nginx:1.24"}, want: false},
+		{name: "image pattern", filter: ContainerQueryFilter{Image: "ngin*"}, want: true},
+		{name: "env value", filter: ContainerQueryFilter{Env: map[string]string{"APP_ENV": "prod"}}, want: true},
+		{name: "env any value", filter: ContainerQueryFilter{Env: map[string]string{"DEBUG": ""}}, want: true},
+		{name: "env mismatch", filter: ContainerQueryFilter{Env: map[string]string{"APP_ENV": "dev"}}, want: false},
+		{name: "env missing", filter: ContainerQueryFilter{Env: map[string]string{"REGION": ""}}, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filter.Matches(container); got != tt.want {
+				t.Errorf("Matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTagContainersByQuery_RequiresFilter(t *testing.T) {
+	s := &Server{}
+	e := echo.New()
+
+	body := `{"add": {"team": "web"}}`
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/containers/tag-by-query", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	c := e.NewContext(req, httptest.NewRecorder())
+
+	err := s.tagContainersByQuery(c)
+	apiErr, ok := err.(*APIError)
+	if !ok || apiErr.Code != http.StatusBadRequest {
+		t.Fatalf("Expected 400 for an empty filter, got %v", err)
+	}
+	if _, ok := apiErr.FieldError["filter"]; !ok {
+		t.Errorf("Expected a filter error, got %+v", apiErr.FieldError)
+	}
+}
@@ -3,9 +3,13 @@ package api
 import (
 	"log"
 	"net/http"
+	"strconv"
 
 	"github.com/gorilla/websocket"
 	"github.com/labstack/echo/v4"
+
+	"evalgo.org/graphium/internal/auth"
+	"evalgo.org/graphium/models"
 )
 
 var upgrader = websocket.Upgrader{
@@ -18,24 +22,63 @@ var upgrader = websocket.Upgrader{
 }
 
 // HandleWebSocket handles WebSocket connections for graph updates
+//
+// Every event carries a sequence number. A reconnecting client passes the last
+// sequence it saw as ?lastSeq=N; the hub replays the missed events before the
+// live stream resumes, or sends a resync_required event if they are no longer
+// buffered.
+//
+// Share link tokens may be passed as ?token=; links scoped to a datacenter only
+// receive events for that datacenter's hosts and containers.
 // @Summary WebSocket endpoint for real-time graph updates
 // @Description Establishes a WebSocket connection for receiving real-time graph events
 // @Tags websocket
 // @Accept json
 // @Produce json
+// @Param lastSeq query int false "Last event sequence seen before reconnecting (replays missed events)"
+// @Param token query string false "Share link token (read-only viewer access)"
 // @Success 101 {string} string "Switching Protocols"
 // @Router /ws/graph [get]
 func (s *Server) HandleWebSocket(c echo.Context) error {
+	var lastSeq uint64
+	resume := false
+	if seqStr := c.QueryParam("lastSeq"); seqStr != "" {
+		seq, err := strconv.ParseUint(seqStr, 10, 64)
+		if err != nil {
+			return BadRequestError("Invalid lastSeq parameter", err.Error())
+		}
+		lastSeq = seq
+		resume = true
+	}
+
+	// Share links scoped to a datacenter only receive that datacenter's events
+	filter, err := s.shareScopeEventFilter(c)
+	if err != nil {
+		return InternalError("Failed to resolve share link scope", err.Error())
+	}
+
 	ws, err := upgrader.Upgrade(c.Response(), c.Request(), nil)
 	if err != nil {
 		log.Printf("WebSocket upgrade error: %v", err)
 		return err
 	}
 
+	// Resuming clients need room for a full replay on top of live traffic
+	sendBuffer := 256
+	if resume {
+		sendBuffer += eventHistorySize
+	}
+
 	client := &Client{
-		hub:  s.wsHub,
-		conn: ws,
-		send: make(chan []byte, 256),
+		hub:     s.wsHub,
+		conn:    ws,
+		send:    make(chan []byte, sendBuffer),
+		lastSeq: lastSeq,
+		resume:  resume,
+		filter:  filter,
+	}
+	if userID, ok := auth.GetUserID(c); ok {
+		client.userID = userID
 	}
 
 	client.hub.register <- client
@@ -47,6 +90,74 @@ func (s *Server) HandleWebSocket(c echo.Context) error {
 	return nil
 }
 
+// HandleAgentTaskWebSocket handles WebSocket connections of agents waiting
+// for tasks. The agent receives a task_available event whenever a task is
+// created for it, and then fetches its pending tasks; graph events are not
+// sent. Agents keep polling GET /agents/{id}/tasks while the socket is down.
+// @Summary WebSocket endpoint for agent task notifications
+// @Description Establishes a WebSocket connection on which the agent receives a task_available event whenever a task is created for it
+// @Tags websocket
+// @Param id path string true "Agent (host) ID"
+// @Success 101 {string} string "Switching Protocols"
+// @Failure 401 {object} APIError "Missing or invalid agent token"
+// @Failure 403 {object} APIError "Token belongs to another agent"
+// @Router /ws/agents/{id}/tasks [get]
+func (s *Server) HandleAgentTaskWebSocket(c echo.Context) error {
+	agentID := c.Param("id")
+	if agentID == "" {
+		return BadRequestError("Agent ID is required", "The 'id' parameter cannot be empty")
+	}
+	if !agentMayConnect(c, agentID) {
+		return NewAPIError(http.StatusForbidden, "Forbidden", "The agent token was issued for another host")
+	}
+
+	ws, err := upgrader.Upgrade(c.Response(), c.Request(), nil)
+	if err != nil {
+		log.Printf("WebSocket upgrade error: %v", err)
+		return err
+	}
+
+	client := &Client{
+		hub:     s.wsHub,
+		conn:    ws,
+		send:    make(chan []byte, 16),
+		agentID: agentID,
+		filter:  func(GraphEvent) bool { return false },
+	}
+
+	client.hub.register <- client
+
+	go client.writePump()
+	go client.readPump()
+
+	return nil
+}
+
+// agentMayConnect reports whether the caller may receive the tasks of
+// agentID: an agent token must have been issued for that host. Without
+// agent authentication (no claims) anyone may connect.
+func agentMayConnect(c echo.Context, agentID string) bool {
+	claims, ok := auth.GetClaims(c)
+	if !ok {
+		return true
+	}
+	return claims.Subject == agentID
+}
+
+// notifyAgentTask tells the agent a task was created for it over its task
+// sockets, if it has any.
+func (s *Server) notifyAgentTask(task *models.AgentTask) {
+	if task.HostID == "" {
+		return
+	}
+	queued, err := s.wsHub.SendToAgent(task.HostID, EventTaskAvailable, map[string]string{"taskId": task.ID})
+	if err != nil {
+		s.logger.WithError(err).Warn("Failed to notify agent " + task.HostID + " of task " + task.ID)
+	} else if !queued {
+		s.debugLog("DEBUG: WebSocket hub busy, agent %s will poll for task %s", task.HostID, task.ID)
+	}
+}
+
 // GetWebSocketStats returns WebSocket connection statistics
 // @Summary Get WebSocket statistics
 // @Description Returns statistics about WebSocket connections
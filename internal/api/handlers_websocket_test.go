@@ -0,0 +1,32 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/labstack/echo/v4"
+
+	"evalgo.org/graphium/internal/auth"
+)
+
+func TestAgentMayConnect(t *testing.T) {
+	newContext := func(subject string) echo.Context {
+		c := echo.New().NewContext(httptest.NewRequest(http.MethodGet, "/", nil), httptest.NewRecorder())
+		if subject != "" {
+			c.Set(auth.ContextKeyClaims, &auth.Claims{RegisteredClaims: jwt.RegisteredClaims{Subject: subject}})
+		}
+		return c
+	}
+
+	if !agentMayConnect(newContext("host-1"), "host-1") {
+		t.Error("Agent should connect to its own task socket")
+	}
+	if agentMayConnect(newContext("host-2"), "host-1") {
+		t.Error("Agent should not connect to another host's task socket")
+	}
+	if !agentMayConnect(newContext(""), "host-1") {
+		t.Error("Without agent authentication anyone should connect")
+	}
+}
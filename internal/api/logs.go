@@ -1,15 +1,20 @@
 package api
 
 import (
+	"compress/gzip"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/docker/docker/api/types/container"
+	dockertime "github.com/docker/docker/api/types/time"
 	"github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/stdcopy"
 	"github.com/labstack/echo/v4"
 )
 
@@ -191,6 +196,10 @@ func (s *Server) proxyLogsToAgent(c echo.Context, containerID, agentURL string)
 		return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to create request: %v", err))
 	}
 
+	if s.config.Agent.HTTPAuthToken != "" {
+		req.Header.Set("Authorization", "Bearer "+s.config.Agent.HTTPAuthToken)
+	}
+
 	// Forward relevant headers
 	if userAgent := c.Request().Header.Get("User-Agent"); userAgent != "" {
 		req.Header.Set("User-Agent", userAgent)
@@ -247,19 +256,67 @@ func (s *Server) proxyLogsToAgent(c echo.Context, containerID, agentURL string)
 	return nil
 }
 
-// downloadContainerLogs downloads container logs as a file
+// downloadContainerLogs godoc
+// @Summary Download container logs
+// @Description Download container logs as a file, streamed from Docker. The response is gzip-compressed
+// @Description when the client accepts it. Downloads stop at the server's size limit
+// @Description (server.log_download_max_bytes) and end with a truncation notice.
+// @Tags containers
+// @Produce text/plain
+// @Produce application/x-ndjson
+// @Param id path string true "Container ID"
+// @Param since query string false "Only logs since this time (RFC3339, Unix timestamp or duration like 1h)"
+// @Param until query string false "Only logs before this time (RFC3339, Unix timestamp or duration like 1h)"
+// @Param tail query string false "Number of lines from the end, or all (default: 1000, or all with since/until)"
+// @Param format query string false "raw (timestamped text) or json (one object per line)" default(raw)
+// @Param Accept-Encoding header string false "gzip to compress the download"
+// @Success 200 {string} string "Container logs"
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /containers/{id}/logs/download [get]
 func (s *Server) downloadContainerLogs(c echo.Context) error {
 	containerID := c.Param("id")
 	if containerID == "" {
 		return echo.NewHTTPError(http.StatusBadRequest, "Container ID is required")
 	}
 
-	// Get lines parameter
-	linesStr := c.QueryParam("lines")
-	lines := 1000
-	if linesStr != "" {
-		if parsed, err := strconv.Atoi(linesStr); err == nil {
-			lines = parsed
+	format := c.QueryParam("format")
+	if format == "" {
+		format = logFormatRaw
+	}
+	if format != logFormatRaw && format != logFormatJSON {
+		return echo.NewHTTPError(http.StatusBadRequest, "format must be raw or json")
+	}
+
+	now := time.Now()
+	since := c.QueryParam("since")
+	if since != "" {
+		if _, err := dockertime.GetTimestamp(since, now); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid since: %v", err))
+		}
+	}
+	until := c.QueryParam("until")
+	if until != "" {
+		if _, err := dockertime.GetTimestamp(until, now); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid until: %v", err))
+		}
+	}
+
+	// tail defaults to the legacy lines parameter, or to everything within
+	// a since/until window
+	tail := c.QueryParam("tail")
+	if tail == "" {
+		tail = "1000"
+		if lines, err := strconv.Atoi(c.QueryParam("lines")); err == nil {
+			tail = strconv.Itoa(lines)
+		} else if since != "" || until != "" {
+			tail = "all"
+		}
+	}
+	if tail != "all" {
+		if n, err := strconv.Atoi(tail); err != nil || n < 0 {
+			return echo.NewHTTPError(http.StatusBadRequest, "tail must be a non-negative number or all")
 		}
 	}
 
@@ -296,15 +353,23 @@ func (s *Server) downloadContainerLogs(c echo.Context) error {
 		defer dockerClient.Close()
 	}
 
-	ctx, cancel := context.WithTimeout(c.Request().Context(), 30*time.Second)
-	defer cancel()
+	// The download lives as long as the client keeps reading
+	ctx := c.Request().Context()
+
+	// TTY containers have a single raw stream; others are multiplexed
+	tty := false
+	if info, err := dockerClient.ContainerInspect(ctx, containerID); err == nil && info.Config != nil {
+		tty = info.Config.Tty
+	}
 
 	// Fetch logs
 	logs, err := dockerClient.ContainerLogs(ctx, containerID, container.LogsOptions{
 		ShowStdout: true,
 		ShowStderr: true,
 		Timestamps: true,
-		Tail:       strconv.Itoa(lines),
+		Since:      since,
+		Until:      until,
+		Tail:       tail,
 	})
 	if err != nil {
 		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to fetch logs")
@@ -312,29 +377,41 @@ func (s *Server) downloadContainerLogs(c echo.Context) error {
 	defer logs.Close()
 
 	// Set download headers
-	filename := fmt.Sprintf("%s-logs-%s.txt", cont.Name, time.Now().Format("20060102-150405"))
-	c.Response().Header().Set(echo.HeaderContentType, "text/plain; charset=utf-8")
-	c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%s", filename))
+	contentType, extension := "text/plain; charset=utf-8", "log"
+	if format == logFormatJSON {
+		contentType, extension = "application/x-ndjson", "jsonl"
+	}
+	filename := fmt.Sprintf("%s-logs-%s.%s", cont.Name, now.Format("20060102-150405"), extension)
+	header := c.Response().Header()
+	header.Set(echo.HeaderContentType, contentType)
+	header.Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%s", filename))
+	header.Add(echo.HeaderVary, echo.HeaderAcceptEncoding)
+
+	var out io.Writer = c.Response()
+	if strings.Contains(c.Request().Header.Get(echo.HeaderAcceptEncoding), "gzip") {
+		header.Set(echo.HeaderContentEncoding, "gzip")
+		gz := gzip.NewWriter(c.Response())
+		defer gz.Close()
+		out = gz
+	}
 	c.Response().WriteHeader(http.StatusOK)
 
-	// Copy logs, stripping headers
-	buf := make([]byte, 8192)
-	for {
-		n, err := logs.Read(buf)
-		if n > 0 {
-			if n > 8 {
-				if _, writeErr := c.Response().Write(buf[8:n]); writeErr != nil {
-					return writeErr
-				}
-			}
-		}
-		if err == io.EOF {
-			break
-		}
-		if err != nil {
-			return err
+	exporter := &logExporter{out: out, format: format, limit: s.config.Server.LogDownloadMaxBytes}
+	stdout, stderr := exporter.stream("stdout"), exporter.stream("stderr")
+	if tty {
+		_, err = io.Copy(stdout, logs)
+	} else {
+		_, err = stdcopy.StdCopy(stdout, stderr, logs)
+	}
+	if err == nil {
+		if err = stdout.flush(); err == nil {
+			err = stderr.flush()
 		}
 	}
 
-	return nil
+	if errors.Is(err, errLogLimit) {
+		return exporter.writeTruncation()
+	}
+	// Headers are already sent; a failed stream can only be cut short
+	return err
 }
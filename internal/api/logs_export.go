@@ -0,0 +1,120 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Log download formats.
+const (
+	logFormatRaw  = "raw"  // Docker's text output, each line prefixed with its timestamp
+	logFormatJSON = "json" // one JSON object per line with timestamp, stream and message
+)
+
+// maxPartialLogLine is the longest log line buffered while waiting for its
+// newline; longer lines are split.
+const maxPartialLogLine = 64 * 1024
+
+// errLogLimit is returned once a log download reaches its size limit.
+var errLogLimit = errors.New("log download size limit reached")
+
+// logLine is one line of a JSON log download.
+type logLine struct {
+	Timestamp string `json:"timestamp,omitempty"`
+	Stream    string `json:"stream"`
+	Message   string `json:"message"`
+}
+
+// logTruncation ends a JSON log download that reached the size limit.
+type logTruncation struct {
+	Truncated bool   `json:"truncated"`
+	Message   string `json:"message"`
+}
+
+// logExporter writes log lines to out in a download format and stops with
+// errLogLimit before the written log content exceeds limit bytes (0 means
+// no limit).
+type logExporter struct {
+	out     io.Writer
+	format  string
+	limit   int64
+	written int64
+}
+
+// writeLine writes one log line, including its trailing newline if any.
+func (e *logExporter) writeLine(stream string, line []byte) error {
+	if e.limit > 0 && e.written+int64(len(line)) > e.limit {
+		return errLogLimit
+	}
+	e.written += int64(len(line))
+
+	if e.format != logFormatJSON {
+		_, err := e.out.Write(line)
+		return err
+	}
+
+	entry := logLine{Stream: stream, Message: string(bytes.TrimRight(line, "\r\n"))}
+	if timestamp, message, ok := bytes.Cut(line, []byte(" ")); ok {
+		if _, err := time.Parse(time.RFC3339Nano, string(timestamp)); err == nil {
+			entry.Timestamp = string(timestamp)
+			entry.Message = string(bytes.TrimRight(message, "\r\n"))
+		}
+	}
+	return json.NewEncoder(e.out).Encode(entry)
+}
+
+// writeTruncation tells the client that the download stopped at the limit.
+func (e *logExporter) writeTruncation() error {
+	message := fmt.Sprintf("log download truncated at the %d byte limit; narrow it with since, until or tail", e.limit)
+	if e.format == logFormatJSON {
+		return json.NewEncoder(e.out).Encode(logTruncation{Truncated: true, Message: message})
+	}
+	_, err := fmt.Fprintf(e.out, "\n[graphium] %s\n", message)
+	return err
+}
+
+// stream returns a writer for one output stream (stdout or stderr) that
+// passes complete lines to the exporter.
+func (e *logExporter) stream(name string) *logStreamWriter {
+	return &logStreamWriter{exporter: e, name: name}
+}
+
+// logStreamWriter splits a log stream into lines.
+type logStreamWriter struct {
+	exporter *logExporter
+	name     string
+	partial  []byte
+}
+
+func (w *logStreamWriter) Write(p []byte) (int, error) {
+	data := append(w.partial, p...)
+	for {
+		i := bytes.IndexByte(data, '\n')
+		if i < 0 {
+			if len(data) < maxPartialLogLine {
+				break
+			}
+			i = maxPartialLogLine - 1
+		}
+		if err := w.exporter.writeLine(w.name, data[:i+1]); err != nil {
+			return 0, err
+		}
+		data = data[i+1:]
+	}
+	w.partial = append([]byte(nil), data...)
+	return len(p), nil
+}
+
+// flush writes a final line that has no trailing newline.
+func (w *logStreamWriter) flush() error {
+	if len(w.partial) == 0 {
+		return nil
+	}
+	line := append(w.partial, '\n')
+	w.partial = nil
+	return w.exporter.writeLine(w.name, line)
+}
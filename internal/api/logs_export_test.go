@@ -0,0 +1,92 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestLogExporter_Raw(t *testing.T) {
+	var out bytes.Buffer
+	e := &logExporter{out: &out, format: logFormatRaw}
+	stdout := e.stream("stdout")
+
+	if _, err := stdout.Write([]byte("2024-01-01T00:00:00.000000001Z first\n2024-01-01T00:00:01Z sec")); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if _, err := stdout.Write([]byte("ond\n2024-01-01T00:00:02Z last")); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if err := stdout.flush(); err != nil {
+		t.Fatalf("flush failed: %v", err)
+	}
+
+	expected := "2024-01-01T00:00:00.000000001Z first\n2024-01-01T00:00:01Z second\n2024-01-01T00:00:02Z last\n"
+	if out.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, out.String())
+	}
+}
+
+func TestLogExporter_JSON(t *testing.T) {
+	var out bytes.Buffer
+	e := &logExporter{out: &out, format: logFormatJSON}
+
+	if _, err := e.stream("stderr").Write([]byte("2024-01-01T00:00:00.5Z boom\r\nno timestamp\n")); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 lines, got %d: %q", len(lines), out.String())
+	}
+
+	var first, second logLine
+	if err := json.Unmarshal([]byte(lines[0]), &first); err != nil {
+		t.Fatalf("Invalid JSON line: %v", err)
+	}
+	if first.Timestamp != "2024-01-01T00:00:00.5Z" || first.Stream != "stderr" || first.Message != "boom" {
+		t.Errorf("Unexpected first line: %+v", first)
+	}
+	if err := json.Unmarshal([]byte(lines[1]), &second); err != nil {
+		t.Fatalf("Invalid JSON line: %v", err)
+	}
+	if second.Timestamp != "" || second.Message != "no timestamp" {
+		t.Errorf("Unexpected second line: %+v", second)
+	}
+}
+
+func TestLogExporter_Limit(t *testing.T) {
+	var out bytes.Buffer
+	e := &logExporter{out: &out, format: logFormatRaw, limit: 10}
+	stdout := e.stream("stdout")
+
+	_, err := stdout.Write([]byte("12345\n67890\n"))
+	if !errors.Is(err, errLogLimit) {
+		t.Fatalf("Expected errLogLimit, got %v", err)
+	}
+	if out.String() != "12345\n" {
+		t.Errorf("Expected only the first line, got %q", out.String())
+	}
+
+	if err := e.writeTruncation(); err != nil {
+		t.Fatalf("writeTruncation failed: %v", err)
+	}
+	if !strings.Contains(out.String(), "truncated at the 10 byte limit") {
+		t.Errorf("Expected truncation notice, got %q", out.String())
+	}
+}
+
+func TestLogStreamWriter_LongLine(t *testing.T) {
+	var out bytes.Buffer
+	e := &logExporter{out: &out, format: logFormatRaw}
+
+	long := bytes.Repeat([]byte("x"), maxPartialLogLine+10)
+	if _, err := e.stream("stdout").Write(long); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if out.Len() != maxPartialLogLine {
+		t.Errorf("Expected a %d byte chunk to be written, got %d", maxPartialLogLine, out.Len())
+	}
+}
@@ -0,0 +1,286 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/gorilla/websocket"
+	"github.com/labstack/echo/v4"
+
+	"evalgo.org/graphium/models"
+)
+
+// Message types of a container log stream.
+const (
+	logStreamLine  = "log"
+	logStreamEnd   = "end"
+	logStreamError = "error"
+)
+
+// logStreamMaxLine is the longest log line a stream relays.
+const logStreamMaxLine = 1024 * 1024
+
+// logStreamMessage is one message of a container log stream.
+type logStreamMessage struct {
+	Type        string `json:"type"`
+	ContainerID string `json:"containerId"`
+	Line        string `json:"line,omitempty"`
+	Reason      string `json:"reason,omitempty"`
+}
+
+// logStream is a follow stream of a container's log lines.
+type logStream struct {
+	io.Reader
+	close func()
+}
+
+func (l *logStream) Close() error {
+	l.close()
+	return nil
+}
+
+// HandleContainerLogsWebSocket streams a container's logs live
+//
+// The owning agent follows the container's logs and the server relays every
+// line as a {"type":"log","line":...} message. The stream ends with an "end"
+// message when the container's log ends, or when it was idle (no log lines
+// and no client messages) for server.log_stream_idle_timeout; clients
+// watching a quiet container may send any message to keep it open. Closing
+// the socket stops the follow on the agent.
+// @Summary WebSocket endpoint for live container logs
+// @Description Establishes a WebSocket connection on which the container's log lines are streamed as they are written
+// @Tags websocket
+// @Param id path string true "Container ID"
+// @Param tail query string false "Number of lines from the end to start with, or all" default(100)
+// @Param timestamps query bool false "Include timestamps" default(true)
+// @Success 101 {string} string "Switching Protocols"
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /ws/containers/{id}/logs [get]
+func (s *Server) HandleContainerLogsWebSocket(c echo.Context) error {
+	containerID := c.Param("id")
+	if containerID == "" {
+		return BadRequestError("Container ID is required", "The 'id' parameter cannot be empty")
+	}
+
+	tail := c.QueryParam("tail")
+	if tail == "" {
+		tail = "100"
+	}
+	if tail != "all" {
+		if n, err := strconv.Atoi(tail); err != nil || n < 0 {
+			return BadRequestError("Invalid tail parameter", "tail must be a non-negative number or all")
+		}
+	}
+	timestamps := c.QueryParam("timestamps") != "false"
+
+	cont, err := s.storage.GetContainer(containerID)
+	if err != nil {
+		return NotFoundError("Container", containerID)
+	}
+
+	ws, err := upgrader.Upgrade(c.Response(), c.Request(), nil)
+	if err != nil {
+		log.Printf("WebSocket upgrade error: %v", err)
+		return err
+	}
+	defer ws.Close()
+
+	// Cancelling the context stops the follow on the agent or Docker
+	ctx, cancel := context.WithCancel(c.Request().Context())
+	defer cancel()
+
+	logs, err := s.openLogStream(ctx, cont, tail, timestamps)
+	if err != nil {
+		s.debugLog("DEBUG: Failed to stream logs of container %s: %v", containerID, err)
+		_ = writeLogStreamMessage(ws, logStreamMessage{Type: logStreamError, ContainerID: containerID, Reason: err.Error()})
+		return nil
+	}
+	defer logs.Close()
+
+	// Client messages only keep the stream alive; a failed read means the
+	// client went away
+	activity := make(chan struct{}, 1)
+	go func() {
+		defer cancel()
+		for {
+			if _, _, err := ws.ReadMessage(); err != nil {
+				return
+			}
+			select {
+			case activity <- struct{}{}:
+			default:
+			}
+		}
+	}()
+
+	lines := make(chan string)
+	scanErr := make(chan error, 1)
+	go func() {
+		var err error
+		defer func() {
+			scanErr <- err
+			close(lines)
+		}()
+		scanner := bufio.NewScanner(logs)
+		scanner.Buffer(make([]byte, 64*1024), logStreamMaxLine)
+		for scanner.Scan() {
+			select {
+			case lines <- scanner.Text():
+			case <-ctx.Done():
+				return
+			}
+		}
+		err = scanner.Err()
+	}()
+
+	// A nil channel never fires, so a zero timeout disables the idle check
+	idleTimeout := s.config.Server.LogStreamIdleTimeout
+	var idle *time.Timer
+	var idleC <-chan time.Time
+	if idleTimeout > 0 {
+		idle = time.NewTimer(idleTimeout)
+		defer idle.Stop()
+		idleC = idle.C
+	}
+	resetIdle := func() {
+		if idle != nil {
+			idle.Reset(idleTimeout)
+		}
+	}
+
+	ping := time.NewTicker(pingPeriod)
+	defer ping.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case line, ok := <-lines:
+			if !ok {
+				reason := "log stream ended"
+				if err := <-scanErr; err != nil {
+					reason = err.Error()
+				}
+				_ = writeLogStreamMessage(ws, logStreamMessage{Type: logStreamEnd, ContainerID: containerID, Reason: reason})
+				return nil
+			}
+			if err := writeLogStreamMessage(ws, logStreamMessage{Type: logStreamLine, ContainerID: containerID, Line: line}); err != nil {
+				return nil
+			}
+			resetIdle()
+		case <-activity:
+			resetIdle()
+		case <-idleC:
+			_ = writeLogStreamMessage(ws, logStreamMessage{
+				Type:        logStreamEnd,
+				ContainerID: containerID,
+				Reason:      fmt.Sprintf("idle for %v", idleTimeout),
+			})
+			return nil
+		case <-ping.C:
+			if err := ws.WriteControl(websocket.PingMessage, nil, time.Now().Add(writeWait)); err != nil {
+				return nil
+			}
+		}
+	}
+}
+
+// writeLogStreamMessage sends one message of a container log stream.
+func writeLogStreamMessage(ws *websocket.Conn, msg logStreamMessage) error {
+	_ = ws.SetWriteDeadline(time.Now().Add(writeWait)) //nolint:errcheck // Deadline errors are handled by WriteJSON
+	return ws.WriteJSON(msg)
+}
+
+// openLogStream opens a follow stream of a container's logs through the
+// agent of its host, or through the local Docker daemon if the host has no
+// agent HTTP server.
+func (s *Server) openLogStream(ctx context.Context, cont *models.Container, tail string, timestamps bool) (*logStream, error) {
+	if cont.HostedOn != "" && s.agentManager != nil {
+		if agentURL := s.agentManager.GetAgentHTTPURL(cont.HostedOn); agentURL != "" {
+			return s.openAgentLogStream(ctx, agentURL, cont.ID, tail, timestamps)
+		}
+	}
+
+	dockerClient, err := client.NewClientWithOpts(
+		client.FromEnv,
+		client.WithAPIVersionNegotiation(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to Docker: %w", err)
+	}
+
+	// TTY containers have a single raw stream; others are multiplexed
+	tty := false
+	if info, err := dockerClient.ContainerInspect(ctx, cont.ID); err == nil && info.Config != nil {
+		tty = info.Config.Tty
+	}
+
+	logs, err := dockerClient.ContainerLogs(ctx, cont.ID, container.LogsOptions{
+		ShowStdout: true,
+		ShowStderr: true,
+		Timestamps: timestamps,
+		Follow:     true,
+		Tail:       tail,
+	})
+	if err != nil {
+		dockerClient.Close()
+		return nil, fmt.Errorf("failed to fetch logs: %w", err)
+	}
+
+	closeAll := func() {
+		logs.Close()
+		dockerClient.Close()
+	}
+	if tty {
+		return &logStream{Reader: logs, close: closeAll}, nil
+	}
+
+	pr, pw := io.Pipe()
+	go func() {
+		_, err := stdcopy.StdCopy(pw, pw, logs)
+		pw.CloseWithError(err)
+	}()
+	return &logStream{Reader: pr, close: func() {
+		pr.Close()
+		closeAll()
+	}}, nil
+}
+
+// openAgentLogStream asks an agent to follow a container's logs.
+func (s *Server) openAgentLogStream(ctx context.Context, agentURL, containerID, tail string, timestamps bool) (*logStream, error) {
+	query := url.Values{}
+	query.Set("follow", "true")
+	query.Set("tail", tail)
+	query.Set("timestamps", strconv.FormatBool(timestamps))
+	logsURL := fmt.Sprintf("%s/containers/%s/logs?%s", agentURL, containerID, query.Encode())
+
+	req, err := http.NewRequestWithContext(ctx, "GET", logsURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	if s.config.Agent.HTTPAuthToken != "" {
+		req.Header.Set("Authorization", "Bearer "+s.config.Agent.HTTPAuthToken)
+	}
+
+	// No client timeout: the stream lasts until the context is cancelled
+	resp, err := (&http.Client{}).Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to agent: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("agent returned %s", resp.Status)
+	}
+	return &logStream{Reader: resp.Body, close: func() { resp.Body.Close() }}, nil
+}
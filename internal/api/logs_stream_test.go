@@ -0,0 +1,69 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"evalgo.org/graphium/internal/config"
+)
+
+func TestOpenAgentLogStream_FollowsAndCancels(t *testing.T) {
+	disconnected := make(chan struct{})
+	agent := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/containers/abc123/logs" {
+			t.Errorf("Unexpected path %s", r.URL.Path)
+		}
+		if r.URL.Query().Get("follow") != "true" || r.URL.Query().Get("tail") != "10" {
+			t.Errorf("Expected follow=true and tail=10, got %s", r.URL.RawQuery)
+		}
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			t.Errorf("Expected agent token, got %q", r.Header.Get("Authorization"))
+		}
+		fmt.Fprintln(w, "first")
+		fmt.Fprintln(w, "second")
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+		close(disconnected)
+	}))
+	defer agent.Close()
+
+	s := &Server{config: &config.Config{Agent: config.AgentConfig{HTTPAuthToken: "secret"}}}
+	ctx, cancel := context.WithCancel(context.Background())
+	logs, err := s.openAgentLogStream(ctx, agent.URL, "abc123", "10", true)
+	if err != nil {
+		t.Fatalf("openAgentLogStream failed: %v", err)
+	}
+	defer logs.Close()
+
+	scanner := bufio.NewScanner(logs)
+	for _, expected := range []string{"first", "second"} {
+		if !scanner.Scan() || scanner.Text() != expected {
+			t.Fatalf("Expected line %q, got %q (%v)", expected, scanner.Text(), scanner.Err())
+		}
+	}
+
+	// Cancelling the stream must stop the follow on the agent
+	cancel()
+	select {
+	case <-disconnected:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Agent follow was not cancelled")
+	}
+}
+
+func TestOpenAgentLogStream_AgentError(t *testing.T) {
+	agent := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "no such container", http.StatusInternalServerError)
+	}))
+	defer agent.Close()
+
+	s := &Server{config: &config.Config{}}
+	if _, err := s.openAgentLogStream(context.Background(), agent.URL, "abc123", "100", true); err == nil {
+		t.Fatal("Expected an error for a failed agent request")
+	}
+}
@@ -0,0 +1,147 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"evalgo.org/graphium/internal/storage"
+)
+
+// serverMetrics holds the Prometheus collectors served at /metrics.
+type serverMetrics struct {
+	registry        *prometheus.Registry
+	requestDuration *prometheus.HistogramVec
+	containers      *prometheus.GaugeVec
+	hosts           *prometheus.GaugeVec
+	stacks          *prometheus.GaugeVec
+	tasks           *prometheus.GaugeVec
+	deployments     *prometheus.GaugeVec
+	refreshErrors   prometheus.Counter
+}
+
+// newServerMetrics creates the server metrics on their own registry. The
+// WebSocket client gauge reads clients when scraped.
+func newServerMetrics(clients func() int) *serverMetrics {
+	byStatus := func(name, help, label string) *prometheus.GaugeVec {
+		return prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: "graphium",
+			Name:      name,
+			Help:      help,
+		}, []string{label})
+	}
+
+	m := &serverMetrics{
+		registry: prometheus.NewRegistry(),
+		requestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "graphium",
+			Name:      "http_request_duration_seconds",
+			Help:      "HTTP request latency by method, route and status code.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+		containers:  byStatus("containers", "Containers by status.", "status"),
+		hosts:       byStatus("hosts", "Hosts by status.", "status"),
+		stacks:      byStatus("stacks", "Stacks by status.", "status"),
+		tasks:       byStatus("tasks", "Agent tasks by action status.", "status"),
+		deployments: byStatus("deployments", "Stack deployments by status.", "status"),
+		refreshErrors: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: "graphium",
+			Name:      "metrics_refresh_errors_total",
+			Help:      "Failed refreshes of the entity gauges.",
+		}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.requestDuration,
+		m.containers,
+		m.hosts,
+		m.stacks,
+		m.tasks,
+		m.deployments,
+		m.refreshErrors,
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: "graphium",
+			Name:      "websocket_clients",
+			Help:      "Connected WebSocket clients.",
+		}, func() float64 { return float64(clients()) }),
+	)
+	return m
+}
+
+// middleware records the latency and status of every request. Requests are
+// labelled by route pattern (e.g. /api/v1/containers/:id) so IDs don't
+// create new series; unmatched requests share one route label.
+func (m *serverMetrics) middleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		start := time.Now()
+		err := next(c)
+
+		status := c.Response().Status
+		if err != nil && !c.Response().Committed {
+			// The error handler writes the response later, with this status
+			status = http.StatusInternalServerError
+			if he, ok := err.(*echo.HTTPError); ok {
+				status = he.Code
+			} else if apiErr, ok := err.(*APIError); ok {
+				status = apiErr.Code
+			}
+		}
+
+		route := c.Path()
+		if route == "" {
+			route = "unmatched"
+		}
+		m.requestDuration.WithLabelValues(c.Request().Method, route, strconv.Itoa(status)).
+			Observe(time.Since(start).Seconds())
+		return err
+	}
+}
+
+// handler serves the registry in the Prometheus exposition format.
+func (m *serverMetrics) handler() echo.HandlerFunc {
+	return echo.WrapHandler(promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{}))
+}
+
+// setCounts replaces the entity gauges with counts, dropping statuses that
+// no longer occur.
+func (m *serverMetrics) setCounts(counts *storage.EntityStatusCounts) {
+	set := func(gauge *prometheus.GaugeVec, byStatus map[string]int) {
+		gauge.Reset()
+		for status, n := range byStatus {
+			gauge.WithLabelValues(status).Set(float64(n))
+		}
+	}
+	set(m.containers, counts.Containers)
+	set(m.hosts, counts.Hosts)
+	set(m.stacks, counts.Stacks)
+	set(m.tasks, counts.Tasks)
+	set(m.deployments, counts.Deployments)
+}
+
+// runMetricsRefresh recounts the entities every interval so scrapes never
+// query CouchDB.
+func (s *Server) runMetricsRefresh(interval time.Duration) {
+	refresh := func() {
+		counts, err := s.storage.GetEntityStatusCounts()
+		if err != nil {
+			s.metrics.refreshErrors.Inc()
+			s.debugLog("Metrics: Failed to count entities: %v", err)
+			return
+		}
+		s.metrics.setCounts(counts)
+	}
+
+	refresh()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		refresh()
+	}
+}
@@ -0,0 +1,60 @@
+package api
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+
+	"evalgo.org/graphium/internal/storage"
+)
+
+func TestServerMetrics_Exposition(t *testing.T) {
+	m := newServerMetrics(func() int { return 3 })
+
+	e := echo.New()
+	e.HTTPErrorHandler = HTTPErrorHandler
+	e.Use(m.middleware)
+	e.GET("/metrics", m.handler())
+	e.GET("/api/v1/containers/:id", func(c echo.Context) error {
+		return NotFoundError("Container", c.Param("id"))
+	})
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/containers/abc", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("Expected 404, got %d", rec.Code)
+	}
+
+	m.setCounts(&storage.EntityStatusCounts{
+		Containers: map[string]int{"running": 2, "exited": 1},
+		Hosts:      map[string]int{"active": 1},
+	})
+	// Statuses missing from a refresh are dropped
+	m.setCounts(&storage.EntityStatusCounts{
+		Containers: map[string]int{"running": 2},
+		Hosts:      map[string]int{"active": 1},
+	})
+
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body, _ := io.ReadAll(rec.Body)
+	out := string(body)
+
+	for _, want := range []string{
+		`graphium_http_request_duration_seconds_count{method="GET",route="/api/v1/containers/:id",status="404"} 1`,
+		`graphium_containers{status="running"} 2`,
+		`graphium_hosts{status="active"} 1`,
+		`graphium_websocket_clients 3`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected metrics output to contain %q", want)
+		}
+	}
+	if strings.Contains(out, `graphium_containers{status="exited"}`) {
+		t.Error("Expected stale container status to be dropped")
+	}
+}
@@ -1,6 +1,7 @@
 package api
 
 import (
+	"slices"
 	"strings"
 
 	"github.com/labstack/echo/v4"
@@ -29,11 +30,11 @@ func ValidateContentType(next echo.HandlerFunc) echo.HandlerFunc {
 			}
 
 			// If there's a body (ContentLength > 0 or ContentLength == -1 with ContentType set),
-			// check if Content-Type is application/json
-			if contentType != "" && !strings.HasPrefix(contentType, "application/json") {
+			// check if Content-Type is JSON (graph imports may be JSON-LD or NDJSON)
+			if contentType != "" && !isJSONMediaType(contentType) {
 				return BadRequestError(
 					"Invalid Content-Type",
-					"Content-Type must be 'application/json'. Got: "+contentType,
+					"Content-Type must be 'application/json', 'application/ld+json' or 'application/x-ndjson'. Got: "+contentType,
 				)
 			}
 		}
@@ -42,6 +43,20 @@ func ValidateContentType(next echo.HandlerFunc) echo.HandlerFunc {
 	}
 }
 
+// jsonMediaTypes are the JSON media types the API accepts and returns.
+var jsonMediaTypes = []string{mimeJSON, mimeJSONLD, mimeNDJSON}
+
+// isJSONMediaType reports whether a Content-Type or Accept header value
+// includes one of the JSON media types.
+func isJSONMediaType(value string) bool {
+	for _, mediaType := range jsonMediaTypes {
+		if strings.Contains(value, mediaType) {
+			return true
+		}
+	}
+	return false
+}
+
 // ValidateAcceptHeader middleware ensures that clients can accept JSON responses
 func ValidateAcceptHeader(next echo.HandlerFunc) echo.HandlerFunc {
 	return func(c echo.Context) error {
@@ -58,8 +73,8 @@ func ValidateAcceptHeader(next echo.HandlerFunc) echo.HandlerFunc {
 			return next(c)
 		}
 
-		// Check if Accept includes application/json or */*
-		if !strings.Contains(accept, "application/json") &&
+		// Check if Accept includes a JSON media type or */*
+		if !isJSONMediaType(accept) &&
 			!strings.Contains(accept, "*/*") &&
 			!strings.Contains(accept, "application/*") {
 			return BadRequestError(
@@ -156,31 +171,33 @@ func ValidateIDFormat(next echo.HandlerFunc) echo.HandlerFunc {
 	}
 }
 
-// ValidateQueryParams middleware validates common query parameters
+// containerStatuses are the container statuses accepted by list filters.
+var containerStatuses = []string{"running", "stopped", "paused", "restarting", "exited", "dead", "created", "removing"}
+
+// hostStatuses are the host statuses accepted by list filters.
+var hostStatuses = []string{"active", "inactive", "maintenance", "unreachable", "draining"}
+
+// ValidateQueryParams middleware validates common query parameters of
+// container routes. status may list several comma-separated statuses.
 func ValidateQueryParams(next echo.HandlerFunc) echo.HandlerFunc {
-	return func(c echo.Context) error {
-		// Validate limit parameter
-		if limitStr := c.QueryParam("limit"); limitStr != "" {
-			// parsePagination handles validation, but we can add extra checks here if needed
-			// For now, just check it's not negative or non-numeric (parsePagination will handle this)
-		}
+	return validateStatusParam(containerStatuses, next)
+}
 
-		// Validate offset parameter
-		if offsetStr := c.QueryParam("offset"); offsetStr != "" {
-			// parsePagination handles validation
-		}
+// ValidateHostQueryParams middleware validates common query parameters of
+// host routes. status may list several comma-separated statuses.
+func ValidateHostQueryParams(next echo.HandlerFunc) echo.HandlerFunc {
+	return validateStatusParam(hostStatuses, next)
+}
 
-		// Validate status parameter if present
-		if status := c.QueryParam("status"); status != "" {
-			validStatuses := map[string]bool{
-				"running": true, "stopped": true, "paused": true,
-				"restarting": true, "exited": true, "dead": true,
-				"created": true, "removing": true,
-			}
-			if !validStatuses[status] {
+// validateStatusParam rejects requests whose status parameter has a value
+// outside valid. Limit and offset are validated by parsePagination.
+func validateStatusParam(valid []string, next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		for _, status := range parseListParam(c, "status") {
+			if !slices.Contains(valid, status) {
 				return BadRequestError(
 					"Invalid status parameter",
-					"Status must be one of: running, stopped, paused, restarting, exited, dead, created, removing. Got: "+status,
+					"Status must be one of: "+strings.Join(valid, ", ")+". Got: "+status,
 				)
 			}
 		}
@@ -45,6 +45,13 @@ func TestValidateContentType(t *testing.T) {
 			body:        "",
 			wantStatus:  http.StatusOK,
 		},
+		{
+			name:        "POST with application/x-ndjson - valid",
+			method:      "POST",
+			contentType: "application/x-ndjson",
+			body:        "{\"@id\":\"h1\"}\n",
+			wantStatus:  http.StatusOK,
+		},
 		{
 			name:        "PUT with application/json - valid",
 			method:      "PUT",
@@ -114,6 +121,11 @@ func TestValidateAcceptHeader(t *testing.T) {
 			accept:     "",
 			wantStatus: http.StatusOK,
 		},
+		{
+			name:       "application/ld+json - valid",
+			accept:     "application/ld+json",
+			wantStatus: http.StatusOK,
+		},
 		{
 			name:       "text/html - invalid",
 			accept:     "text/html",
@@ -248,6 +260,27 @@ func TestValidateQueryParams(t *testing.T) {
 			},
 			wantStatus: http.StatusBadRequest,
 		},
+		{
+			name: "valid statuses - running or restarting",
+			queryParams: map[string]string{
+				"status": "running,restarting",
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "one invalid status of several",
+			queryParams: map[string]string{
+				"status": "stopped,invalid_status",
+			},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "host status on container route",
+			queryParams: map[string]string{
+				"status": "active",
+			},
+			wantStatus: http.StatusBadRequest,
+		},
 		{
 			name:        "no query params",
 			queryParams: map[string]string{},
@@ -326,3 +359,31 @@ func TestSecurityHeaders(t *testing.T) {
 		}
 	}
 }
+
+func TestValidateHostQueryParams(t *testing.T) {
+	tests := []struct {
+		status  string
+		wantErr bool
+	}{
+		{"", false},
+		{"active", false},
+		{"active,draining", false},
+		{"running", true},
+		{"maintenance,bogus", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.status, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest("GET", "/?status="+tt.status, nil)
+			c := e.NewContext(req, httptest.NewRecorder())
+
+			err := ValidateHostQueryParams(func(c echo.Context) error {
+				return c.String(http.StatusOK, "OK")
+			})(c)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateHostQueryParams(status=%q) error = %v, wantErr %v", tt.status, err, tt.wantErr)
+			}
+		})
+	}
+}
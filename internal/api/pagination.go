@@ -1,11 +1,15 @@
 package api
 
 import (
+	"encoding/base64"
+	"fmt"
+	"sort"
 	"strconv"
+	"strings"
 
 	"github.com/labstack/echo/v4"
 
-	"evalgo.org/graphium/models"
+	"evalgo.org/graphium/internal/storage"
 )
 
 // parsePagination parses limit and offset from query parameters.
@@ -35,32 +39,107 @@ func parsePagination(c echo.Context) (limit, offset int) {
 	return limit, offset
 }
 
-// paginateSliceContainers applies pagination to a slice of containers.
-func paginateSliceContainers(containers []*models.Container, limit, offset int) []*models.Container {
-	// Handle edge cases
-	if offset >= len(containers) {
-		return []*models.Container{}
+// maxSearchQueryLength is the longest q search parameter accepted by the
+// list endpoints.
+const maxSearchQueryLength = 200
+
+// parseSearchQuery returns the trimmed q search parameter, or an error if it
+// is too long.
+func parseSearchQuery(c echo.Context) (string, error) {
+	query := strings.TrimSpace(c.QueryParam("q"))
+	if len(query) > maxSearchQueryLength {
+		return "", BadRequestError("Invalid search query", fmt.Sprintf("q must be at most %d characters", maxSearchQueryLength))
+	}
+	return query, nil
+}
+
+// Sort fields of the list endpoints, mapped to the stored JSON fields.
+var (
+	containerSortFields = map[string]string{"name": "name", "created": "dateCreated", "status": "status", "host": "hostedOn"}
+	hostSortFields      = map[string]string{"name": "name", "status": "status", "datacenter": "location"}
+	stackSortFields     = map[string]string{"name": "name", "created": "dateCreated", "status": "status", "datacenter": "location"}
+)
+
+// parseListSort returns the order given by the sort and order query
+// parameters; sort must be one of fields. The default is name, ascending.
+func parseListSort(c echo.Context, fields map[string]string) (storage.ListSort, error) {
+	order := storage.DefaultListSort
+	if name := c.QueryParam("sort"); name != "" {
+		field, ok := fields[name]
+		if !ok {
+			valid := make([]string, 0, len(fields))
+			for name := range fields {
+				valid = append(valid, name)
+			}
+			sort.Strings(valid)
+			return order, BadRequestError("Invalid sort parameter", "sort must be one of: "+strings.Join(valid, ", ")+". Got: "+name)
+		}
+		order.Field = field
+	}
+
+	switch c.QueryParam("order") {
+	case "", "asc":
+	case "desc":
+		order.Desc = true
+	default:
+		return order, BadRequestError("Invalid order parameter", "order must be asc or desc. Got: "+c.QueryParam("order"))
 	}
+	return order, nil
+}
 
-	end := offset + limit
-	if end > len(containers) {
-		end = len(containers)
+// parseCursor returns the ID a cursor page starts after. The bool is false
+// if the request doesn't use cursor paging; an empty cursor parameter
+// requests the first page. Cursor pages are ordered by ID, so sort and
+// offset can't be combined with a cursor.
+func parseCursor(c echo.Context) (string, bool, error) {
+	if !c.QueryParams().Has("cursor") {
+		return "", false, nil
+	}
+	if c.QueryParam("sort") != "" || c.QueryParam("offset") != "" {
+		return "", true, BadRequestError("Invalid cursor parameter", "cursor pages are ordered by ID and can't be combined with sort or offset")
 	}
 
-	return containers[offset:end]
+	cursor := c.QueryParam("cursor")
+	if cursor == "" {
+		return "", true, nil
+	}
+	after, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil || len(after) == 0 {
+		return "", true, BadRequestError("Invalid cursor parameter", "cursor must be a next_cursor value of a previous page")
+	}
+	return string(after), true, nil
 }
 
-// paginateSliceHosts applies pagination to a slice of hosts.
-func paginateSliceHosts(hosts []*models.Host, limit, offset int) []*models.Host {
-	// Handle edge cases
-	if offset >= len(hosts) {
-		return []*models.Host{}
+// encodeCursor returns the opaque cursor of the page after ID after, or ""
+// if there is no next page.
+func encodeCursor(after string) string {
+	if after == "" {
+		return ""
 	}
+	return base64.RawURLEncoding.EncodeToString([]byte(after))
+}
 
-	end := offset + limit
-	if end > len(hosts) {
-		end = len(hosts)
+// parseListParam splits a comma-separated query parameter, such as
+// status=running,restarting, into its distinct non-empty values.
+func parseListParam(c echo.Context, name string) []string {
+	var values []string
+	seen := make(map[string]bool)
+	for _, value := range strings.Split(c.QueryParam(name), ",") {
+		value = strings.TrimSpace(value)
+		if value != "" && !seen[value] {
+			seen[value] = true
+			values = append(values, value)
+		}
 	}
+	return values
+}
 
-	return hosts[offset:end]
+// listFilter returns the storage filter value matching any of values: the
+// value itself for one value, so single-value queries are unchanged, and a
+// []string ($in) for several.
+func listFilter(values []string) interface{} {
+	if len(values) == 1 {
+		return values[0]
+	}
+	return values
 }
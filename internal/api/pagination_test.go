@@ -2,11 +2,14 @@ package api
 
 import (
 	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/labstack/echo/v4"
 
-	"evalgo.org/graphium/models"
+	"evalgo.org/graphium/internal/storage"
 )
 
 func TestParsePagination(t *testing.T) {
@@ -97,136 +100,99 @@ func TestParsePagination(t *testing.T) {
 	}
 }
 
-func TestPaginateSliceContainers(t *testing.T) {
-	// Create test containers
-	containers := make([]*models.Container, 10)
-	for i := 0; i < 10; i++ {
-		containers[i] = &models.Container{
-			ID:   string(rune('A' + i)),
-			Name: "container-" + string(rune('0'+i)),
-		}
+func TestParseSearchQuery(t *testing.T) {
+	e := echo.New()
+	parse := func(q string) (string, error) {
+		req := httptest.NewRequest("GET", "/?q="+url.QueryEscape(q), nil)
+		return parseSearchQuery(e.NewContext(req, httptest.NewRecorder()))
 	}
 
-	tests := []struct {
-		name       string
-		containers []*models.Container
-		limit      int
-		offset     int
-		wantCount  int
-		wantFirst  string
-	}{
-		{
-			name:       "first page",
-			containers: containers,
-			limit:      5,
-			offset:     0,
-			wantCount:  5,
-			wantFirst:  "A",
-		},
-		{
-			name:       "second page",
-			containers: containers,
-			limit:      5,
-			offset:     5,
-			wantCount:  5,
-			wantFirst:  "F",
-		},
-		{
-			name:       "partial last page",
-			containers: containers,
-			limit:      7,
-			offset:     7,
-			wantCount:  3,
-			wantFirst:  "H",
-		},
-		{
-			name:       "offset beyond data",
-			containers: containers,
-			limit:      5,
-			offset:     20,
-			wantCount:  0,
-			wantFirst:  "",
-		},
-		{
-			name:       "limit exceeds remaining",
-			containers: containers,
-			limit:      100,
-			offset:     8,
-			wantCount:  2,
-			wantFirst:  "I",
-		},
+	if query, err := parse("  web-01 "); err != nil || query != "web-01" {
+		t.Errorf("parseSearchQuery() = %q, %v; want trimmed web-01", query, err)
 	}
+	if query, err := parse(""); err != nil || query != "" {
+		t.Errorf("parseSearchQuery() = %q, %v; want empty", query, err)
+	}
+	if _, err := parse(strings.Repeat("a", maxSearchQueryLength+1)); err == nil {
+		t.Error("Expected an error for a too long search query")
+	}
+}
 
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			result := paginateSliceContainers(tt.containers, tt.limit, tt.offset)
+func TestParseListParam(t *testing.T) {
+	e := echo.New()
+	parse := func(status string) []string {
+		req := httptest.NewRequest("GET", "/?status="+url.QueryEscape(status), nil)
+		return parseListParam(e.NewContext(req, httptest.NewRecorder()), "status")
+	}
 
-			if len(result) != tt.wantCount {
-				t.Errorf("paginateSliceContainers() count = %v, want %v", len(result), tt.wantCount)
-			}
+	if got := parse(""); len(got) != 0 {
+		t.Errorf("parseListParam() = %v, want none", got)
+	}
+	if got := parse("running"); !reflect.DeepEqual(got, []string{"running"}) {
+		t.Errorf("parseListParam() = %v, want [running]", got)
+	}
+	if got := parse(" running, restarting,,running "); !reflect.DeepEqual(got, []string{"running", "restarting"}) {
+		t.Errorf("parseListParam() = %v, want [running restarting]", got)
+	}
+}
 
-			if tt.wantCount > 0 && result[0].ID != tt.wantFirst {
-				t.Errorf("paginateSliceContainers() first ID = %v, want %v", result[0].ID, tt.wantFirst)
-			}
-		})
+func TestListFilter(t *testing.T) {
+	// A single value keeps the $eq filter
+	if got := listFilter([]string{"running"}); got != "running" {
+		t.Errorf("listFilter() = %#v, want \"running\"", got)
+	}
+	if got := listFilter([]string{"stopped", "exited"}); !reflect.DeepEqual(got, []string{"stopped", "exited"}) {
+		t.Errorf("listFilter() = %#v, want []string{stopped exited}", got)
 	}
 }
 
-func TestPaginateSliceHosts(t *testing.T) {
-	// Create test hosts
-	hosts := make([]*models.Host, 10)
-	for i := 0; i < 10; i++ {
-		hosts[i] = &models.Host{
-			ID:   string(rune('A' + i)),
-			Name: "host-" + string(rune('0'+i)),
-		}
+func TestParseListSort(t *testing.T) {
+	e := echo.New()
+	parse := func(query string) (storage.ListSort, error) {
+		req := httptest.NewRequest("GET", "/?"+query, nil)
+		return parseListSort(e.NewContext(req, httptest.NewRecorder()), containerSortFields)
 	}
 
-	tests := []struct {
-		name      string
-		hosts     []*models.Host
-		limit     int
-		offset    int
-		wantCount int
-		wantFirst string
-	}{
-		{
-			name:      "first page",
-			hosts:     hosts,
-			limit:     3,
-			offset:    0,
-			wantCount: 3,
-			wantFirst: "A",
-		},
-		{
-			name:      "middle page",
-			hosts:     hosts,
-			limit:     3,
-			offset:    3,
-			wantCount: 3,
-			wantFirst: "D",
-		},
-		{
-			name:      "empty result - offset beyond data",
-			hosts:     hosts,
-			limit:     5,
-			offset:    15,
-			wantCount: 0,
-			wantFirst: "",
-		},
+	if order, err := parse(""); err != nil || order != storage.DefaultListSort {
+		t.Errorf("parseListSort() = %+v, %v; want name ascending", order, err)
+	}
+	if order, err := parse("sort=created&order=desc"); err != nil || order != (storage.ListSort{Field: "dateCreated", Desc: true}) {
+		t.Errorf("parseListSort() = %+v, %v; want dateCreated descending", order, err)
+	}
+	if order, err := parse("sort=host"); err != nil || order.Field != "hostedOn" {
+		t.Errorf("parseListSort() = %+v, %v; want hostedOn", order, err)
+	}
+	if _, err := parse("sort=memory"); err == nil {
+		t.Error("Expected an error for an unknown sort field")
 	}
+	if _, err := parse("order=sideways"); err == nil {
+		t.Error("Expected an error for an unknown order")
+	}
+}
 
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			result := paginateSliceHosts(tt.hosts, tt.limit, tt.offset)
+func TestParseCursor(t *testing.T) {
+	e := echo.New()
+	parse := func(query string) (string, bool, error) {
+		req := httptest.NewRequest("GET", "/?"+query, nil)
+		return parseCursor(e.NewContext(req, httptest.NewRecorder()))
+	}
 
-			if len(result) != tt.wantCount {
-				t.Errorf("paginateSliceHosts() count = %v, want %v", len(result), tt.wantCount)
-			}
+	if _, cursor, err := parse("limit=10"); cursor || err != nil {
+		t.Errorf("parseCursor() = %v, %v; want offset paging", cursor, err)
+	}
+	if after, cursor, err := parse("cursor="); !cursor || after != "" || err != nil {
+		t.Errorf("parseCursor() = %q, %v, %v; want the first page", after, cursor, err)
+	}
+	if after, cursor, err := parse("cursor=" + encodeCursor("urn:container:abc/1")); !cursor || after != "urn:container:abc/1" || err != nil {
+		t.Errorf("parseCursor() = %q, %v, %v; want the page after urn:container:abc/1", after, cursor, err)
+	}
+	for _, query := range []string{"cursor=***", "cursor=abc&sort=name", "cursor=&offset=20"} {
+		if _, _, err := parse(query); err == nil {
+			t.Errorf("Expected an error for %s", query)
+		}
+	}
 
-			if tt.wantCount > 0 && result[0].ID != tt.wantFirst {
-				t.Errorf("paginateSliceHosts() first ID = %v, want %v", result[0].ID, tt.wantFirst)
-			}
-		})
+	if got := encodeCursor(""); got != "" {
+		t.Errorf("encodeCursor(\"\") = %q, want no cursor", got)
 	}
 }
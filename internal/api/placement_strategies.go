@@ -0,0 +1,36 @@
+package api
+
+import (
+	"fmt"
+
+	"evalgo.org/graphium/internal/stack"
+	"evalgo.org/graphium/models"
+)
+
+// RegisterPlacementStrategy adds a custom placement strategy that stacks and
+// datacenter policies can choose by name, like the built-in first-fit,
+// spread and binpack. Register strategies before the server starts.
+func (s *Server) RegisterPlacementStrategy(name string, strategy stack.PlacementStrategy) error {
+	if name == "" || strategy == nil {
+		return fmt.Errorf("placement strategy name and implementation are required")
+	}
+	if models.IsPlacementStrategy(name) {
+		return fmt.Errorf("placement strategy %s is built in", name)
+	}
+	if s.placementStrategies == nil {
+		s.placementStrategies = make(map[string]stack.PlacementStrategy)
+	}
+	s.placementStrategies[name] = strategy
+	return nil
+}
+
+// validateDatacenterPolicy validates a policy, accepting registered custom
+// placement strategies besides the built-in ones.
+func (s *Server) validateDatacenterPolicy(policy *models.DatacenterPolicy) error {
+	if _, custom := s.placementStrategies[policy.PlacementStrategy]; custom {
+		check := *policy
+		check.PlacementStrategy = ""
+		return check.Validate()
+	}
+	return policy.Validate()
+}
@@ -0,0 +1,39 @@
+package api
+
+import (
+	"context"
+	"testing"
+
+	"evalgo.org/graphium/internal/stack"
+	"evalgo.org/graphium/models"
+)
+
+type firstCandidateStrategy struct{}
+
+func (firstCandidateStrategy) SelectHost(ctx context.Context, spec *models.ContainerSpec, candidates []*models.HostInfo, state *stack.PlacementState) (string, error) {
+	return candidates[0].Host.ID, nil
+}
+
+func TestRegisterPlacementStrategy(t *testing.T) {
+	s := &Server{}
+
+	policy := &models.DatacenterPolicy{Datacenter: "edge", PlacementStrategy: "cheapest"}
+	if err := s.validateDatacenterPolicy(policy); err == nil {
+		t.Error("Expected an unregistered strategy to be rejected")
+	}
+
+	if err := s.RegisterPlacementStrategy(models.PlacementSpread, firstCandidateStrategy{}); err == nil {
+		t.Error("Expected built-in names to be refused")
+	}
+	if err := s.RegisterPlacementStrategy("cheapest", firstCandidateStrategy{}); err != nil {
+		t.Fatalf("Failed to register strategy: %v", err)
+	}
+
+	if err := s.validateDatacenterPolicy(policy); err != nil {
+		t.Errorf("Expected a registered strategy to be accepted, got %v", err)
+	}
+	policy.NetworkMode = "overlay"
+	if err := s.validateDatacenterPolicy(policy); err == nil {
+		t.Error("Expected the rest of the policy to still be validated")
+	}
+}
@@ -0,0 +1,25 @@
+package api
+
+import (
+	"net/http"
+
+	"evalgo.org/graphium/models"
+)
+
+// markProtected flags a container covered by the protection policy, so
+// clients show it read-only.
+func (s *Server) markProtected(container *models.Container) {
+	container.Protected, _ = s.protection.Protects(container.Name, container.Image)
+}
+
+// checkTaskProtection rejects tasks that would delete, stop or control a
+// protected container, or run a command the exec policy doesn't allow.
+func (s *Server) checkTaskProtection(task *models.AgentTask) error {
+	if violation := s.protection.ProtectedTarget(task, s.storage.GetContainer); violation != "" {
+		return NewAPIError(http.StatusForbidden, "Container is protected", violation)
+	}
+	if violation := s.execPolicy.ExecViolation(task, s.storage.GetContainer); violation != "" {
+		return NewAPIError(http.StatusForbidden, "Command is not allowed", violation)
+	}
+	return nil
+}
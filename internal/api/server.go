@@ -13,29 +13,39 @@ import (
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
 	echoSwagger "github.com/swaggo/echo-swagger"
-	"golang.org/x/time/rate"
 
 	_ "evalgo.org/graphium/docs" // Import generated docs
 	"evalgo.org/graphium/internal/agents"
 	"evalgo.org/graphium/internal/auth"
 	"evalgo.org/graphium/internal/config"
+	"evalgo.org/graphium/internal/imageupdates"
 	"evalgo.org/graphium/internal/integrity"
 	"evalgo.org/graphium/internal/scheduler"
+	"evalgo.org/graphium/internal/stack"
 	"evalgo.org/graphium/internal/storage"
+	"evalgo.org/graphium/internal/watch"
 	"evalgo.org/graphium/models"
 )
 
 // Server represents the Graphium API server.
 type Server struct {
-	echo         *echo.Echo
-	storage      *storage.Storage
-	config       *config.Config
-	wsHub        *Hub // WebSocket hub for real-time updates
-	authMiddle   *auth.Middleware
-	integrity    *integrity.Service   // Database integrity service
-	agentManager *agents.Manager      // Agent process manager
-	scheduler    *scheduler.Scheduler // Scheduled actions scheduler
-	logger       *common.ContextLogger
+	echo                *echo.Echo
+	storage             *storage.Storage
+	config              *config.Config
+	wsHub               *Hub // WebSocket hub for real-time updates
+	authMiddle          *auth.Middleware
+	integrity           *integrity.Service                 // Database integrity service
+	agentManager        *agents.Manager                    // Agent process manager
+	scheduler           *scheduler.Scheduler               // Scheduled actions scheduler
+	imageChecker        *imageupdates.Checker              // Image update checker
+	watches             *watch.Registry                    // Container watches (in memory)
+	stopImages          context.CancelFunc                 // Stops the background image update checker
+	protection          *models.ProtectionPolicy           // Containers Graphium must not delete, stop or control
+	execPolicy          *models.ExecPolicy                 // Commands tasks may run inside containers
+	stackUsage          *stackUsageCache                   // Recently collected stack resource usage
+	placementStrategies map[string]stack.PlacementStrategy // Custom placement strategies by name
+	metrics             *serverMetrics                     // Prometheus metrics (nil when disabled)
+	logger              *common.ContextLogger
 }
 
 // debugLog logs a message only if debug mode is enabled in config
@@ -66,6 +76,7 @@ func New(cfg *config.Config, store *storage.Storage, agentMgr *agents.Manager) *
 
 	// Create WebSocket hub
 	hub := NewHub()
+	hub.SetBatching(cfg.Server.WebSocketBatchWindow, cfg.Server.WebSocketBatchSize)
 
 	// Create auth middleware
 	authMiddle := auth.NewMiddleware(cfg)
@@ -78,8 +89,31 @@ func New(cfg *config.Config, store *storage.Storage, agentMgr *agents.Manager) *
 		integrityService = nil
 	}
 
+	// Containers Graphium must never delete, stop, control or replace
+	protection := &models.ProtectionPolicy{
+		NamePatterns: cfg.Deploy.ProtectedNamePatterns,
+		Images:       cfg.Deploy.ProtectedImages,
+	}
+
+	// Commands tasks may run inside containers
+	execPolicy := &models.ExecPolicy{Enabled: cfg.Exec.Enabled}
+	for _, rule := range cfg.Exec.Allowlist {
+		execPolicy.Rules = append(execPolicy.Rules, models.ExecRule{
+			Containers: rule.Containers,
+			Labels:     rule.Labels,
+			Commands:   rule.Commands,
+			User:       rule.User,
+			AllowEnv:   rule.AllowEnv,
+		})
+	}
+
 	// Initialize scheduler for scheduled actions
-	sched := scheduler.New(store)
+	sched := scheduler.New(store, protection)
+	sched.SetExecPolicy(execPolicy)
+	for _, notifier := range scheduler.NewNotifiers(cfg.Notifications) {
+		sched.AddNotifier(notifier)
+	}
+	sched.SetPlacement(cfg.Deploy.PlacementStrategy, cfg.Server.AllowOvercommit)
 
 	// Create server instance
 	server := &Server{
@@ -91,19 +125,56 @@ func New(cfg *config.Config, store *storage.Storage, agentMgr *agents.Manager) *
 		integrity:    integrityService,
 		agentManager: agentMgr,
 		scheduler:    sched,
+		imageChecker: imageupdates.NewChecker(store, cfg.ImageUpdates, cfg.Registries),
+		watches:      watch.NewRegistry(cfg.Server.WatchDefaultDuration, cfg.Server.WatchMaxDuration),
+		protection:   protection,
+		execPolicy:   execPolicy,
+		stackUsage:   newStackUsageCache(),
 		logger:       logger,
 	}
 
+	// Deliver container watch notifications to the watch owner's WebSocket clients
+	server.watches.OnChange(server.notifyWatchOwner)
+	server.watches.SetMaxPerUser(cfg.Server.WatchMaxPerUser)
+	server.watches.SetWebhookAllowlist(cfg.Server.WatchWebhookAllowlist)
+
+	// Push new tasks to the agents' task sockets
+	store.OnTaskCreated(server.notifyAgentTask)
+
+	// Reject share link tokens once their link is revoked
+	authMiddle.SetShareLinkChecker(server.checkShareLink)
+
 	// Start WebSocket hub in background
 	go hub.Run()
 
 	// Start task monitor for automatic cleanup
 	go server.runTaskMonitor()
 
+	// Count entities for the Prometheus gauges in background
+	if cfg.Server.MetricsEnabled {
+		server.metrics = newServerMetrics(hub.ClientCount)
+		interval := cfg.Server.MetricsRefreshInterval
+		if interval <= 0 {
+			interval = 30 * time.Second
+		}
+		go server.runMetricsRefresh(interval)
+	}
+
 	// Start scheduler for scheduled actions
 	sched.Start()
 	logger.Info("Scheduled actions scheduler started")
 
+	// Start image update checker if enabled (manual checks are always available)
+	server.imageChecker.OnUpdateAvailable(func(container *models.Container) {
+		server.BroadcastGraphEvent(EventImageUpdateAvailable, container)
+	})
+	if cfg.ImageUpdates.Enabled {
+		imagesCtx, stopImages := context.WithCancel(context.Background())
+		server.stopImages = stopImages
+		go server.imageChecker.Run(imagesCtx)
+		logger.Info("Image update checker started")
+	}
+
 	// Setup middleware
 	server.setupMiddleware()
 
@@ -123,15 +194,21 @@ func (s *Server) setupMiddleware() {
 	// Recover middleware
 	s.echo.Use(middleware.Recover())
 
+	// Request latency metrics
+	if s.metrics != nil {
+		s.echo.Use(s.metrics.middleware)
+	}
+
 	// Security headers middleware
 	s.echo.Use(SecurityHeaders)
 
 	// CORS middleware
 	if len(s.config.Security.AllowedOrigins) > 0 {
 		s.echo.Use(middleware.CORSWithConfig(middleware.CORSConfig{
-			AllowOrigins: s.config.Security.AllowedOrigins,
-			AllowMethods: []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodDelete},
-			AllowHeaders: []string{echo.HeaderOrigin, echo.HeaderContentType, echo.HeaderAccept, echo.HeaderAuthorization},
+			AllowOrigins:  s.config.Security.AllowedOrigins,
+			AllowMethods:  []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodDelete},
+			AllowHeaders:  []string{echo.HeaderOrigin, echo.HeaderContentType, echo.HeaderAccept, echo.HeaderAuthorization, headerIfNoneMatch},
+			ExposeHeaders: []string{headerETag},
 		}))
 	}
 
@@ -140,9 +217,7 @@ func (s *Server) setupMiddleware() {
 
 	// Rate limiting
 	if s.config.Security.RateLimit > 0 {
-		s.echo.Use(middleware.RateLimiter(middleware.NewRateLimiterMemoryStore(
-			rate.Limit(s.config.Security.RateLimit),
-		)))
+		s.echo.Use(s.rateLimiter())
 	}
 
 	// Content-Type validation middleware for API routes
@@ -166,45 +241,102 @@ func (s *Server) setupRoutes() {
 	s.echo.GET("/health", s.healthCheck)
 	s.echo.GET("/", s.healthCheck)
 
+	// Prometheus metrics
+	if s.metrics != nil {
+		if s.config.Server.MetricsRequireAuth {
+			s.echo.GET("/metrics", s.metrics.handler(), s.authMiddle.RequireRead)
+		} else {
+			s.echo.GET("/metrics", s.metrics.handler())
+		}
+	}
+
 	// Swagger UI documentation (public - but API endpoints are still protected)
 	s.echo.GET("/docs/*", echoSwagger.WrapHandler)
 
 	// API v1 group
 	v1 := s.echo.Group("/api/v1")
 
+	// Graph export and import
+	v1.GET("/export", s.exportGraph, s.authMiddle.RequireRead)
+	v1.POST("/import", s.importGraph, s.authMiddle.RequireAuth, s.authMiddle.RequireAdmin)
+
 	// Container routes
 	containers := v1.Group("/containers")
 	containers.Use(ValidateQueryParams) // Validate query parameters for list operations
-	containers.GET("", s.listContainers, s.authMiddle.RequireRead)
+	containers.GET("", s.listContainers, s.authMiddle.RequireReadOrShare)
 	containers.GET("/ignored", s.listIgnored, s.authMiddle.RequireAgentOrWrite) // List all ignored containers
-	containers.GET("/:id", s.getContainer, ValidateIDFormat, s.authMiddle.RequireRead)
+	containers.GET("/diff/env", s.diffContainerEnv, s.authMiddle.RequireRead)
+	containers.GET("/:id", s.getContainer, ValidateIDFormat, s.authMiddle.RequireReadOrShare)
+	containers.HEAD("/:id", s.getContainer, ValidateIDFormat, s.authMiddle.RequireReadOrShare)
+	containers.GET("/:id/history", s.getContainerHistory, ValidateIDFormat, s.authMiddle.RequireRead)
+	containers.GET("/:id/health-history", s.getContainerHealthHistory, ValidateIDFormat, s.authMiddle.RequireRead)
 	containers.HEAD("/:id/ignored", s.checkContainerIgnored, ValidateIDFormat, s.authMiddle.RequireAgentOrWrite)
 	containers.DELETE("/:id/ignored", s.removeFromIgnoreList, ValidateIDFormat, s.authMiddle.RequireAgentOrWrite)
 	// Note: logs endpoints moved after webHandler creation (see below)
 	containers.POST("", s.createContainer, s.authMiddle.RequireAgentOrWrite)
 	containers.PUT("/:id", s.updateContainer, ValidateIDFormat, s.authMiddle.RequireAgentOrWrite)
 	containers.DELETE("/:id", s.deleteContainer, ValidateIDFormat, s.authMiddle.RequireAgentOrWrite)
+	containers.POST("/:id/rename", s.renameContainer, ValidateIDFormat, s.authMiddle.RequireWrite)
+	containers.POST("/:id/restart", s.restartContainer, ValidateIDFormat, s.authMiddle.RequireWrite)
+	containers.POST("/:id/watch", s.createContainerWatch, ValidateIDFormat, s.authMiddle.RequireWrite)
+	containers.POST("/:id/connectivity", s.checkContainerConnectivity, ValidateIDFormat, s.authMiddle.RequireWrite)
 	containers.POST("/bulk", s.bulkCreateContainers, s.authMiddle.RequireAgentOrWrite)
+	containers.POST("/bulk/labels", s.bulkUpdateContainerLabels, s.authMiddle.RequireWrite)
+	containers.POST("/bulk/start", s.bulkStartContainers, s.authMiddle.RequireWrite)
+	containers.POST("/bulk/stop", s.bulkStopContainers, s.authMiddle.RequireWrite)
+	containers.POST("/bulk/restart", s.bulkRestartContainers, s.authMiddle.RequireWrite)
+	containers.PATCH("/bulk", s.bulkPatchContainers, s.authMiddle.RequireWrite)
+	containers.POST("/tag-by-query", s.tagContainersByQuery, s.authMiddle.RequireWrite)
+	containers.POST("/image-updates/check", s.checkImageUpdates, s.authMiddle.RequireWrite)
 
 	// Host routes
 	hosts := v1.Group("/hosts")
-	hosts.Use(ValidateQueryParams) // Validate query parameters for list operations
-	hosts.GET("", s.listHosts, s.authMiddle.RequireRead)
-	hosts.GET("/:id", s.getHost, ValidateIDFormat, s.authMiddle.RequireRead)
+	hosts.Use(ValidateHostQueryParams) // Validate query parameters for list operations
+	hosts.GET("", s.listHosts, s.authMiddle.RequireReadOrShare)
+	hosts.GET("/:id", s.getHost, ValidateIDFormat, s.authMiddle.RequireReadOrShare)
 	hosts.POST("", s.createHost, s.authMiddle.RequireAgentOrWrite)
 	hosts.PUT("/:id", s.updateHost, ValidateIDFormat, s.authMiddle.RequireAgentOrWrite)
 	hosts.PUT("/:id/metrics", s.updateHostMetrics, ValidateIDFormat, s.authMiddle.RequireAgentOrWrite)
+	hosts.PUT("/:id/container-usage", s.updateContainerUsage, ValidateIDFormat, s.authMiddle.RequireAgentOrWrite)
 	hosts.DELETE("/:id", s.deleteHost, ValidateIDFormat, s.authMiddle.RequireAgentOrWrite)
 	hosts.POST("/bulk", s.bulkCreateHosts, s.authMiddle.RequireAgentOrWrite)
+	hosts.POST("/bulk/tags", s.bulkUpdateHostTags, s.authMiddle.RequireWrite)
+	hosts.PATCH("/bulk", s.bulkPatchHosts, s.authMiddle.RequireWrite)
+	hosts.POST("/:id/prune", s.pruneHost, ValidateIDFormat, s.authMiddle.RequireAuth, s.authMiddle.RequireAdmin)
+	hosts.GET("/:id/capacity", s.getHostCapacity, ValidateIDFormat, s.authMiddle.RequireRead)
+	hosts.GET("/:id/system-info", s.getHostSystemInfo, ValidateIDFormat, s.authMiddle.RequireRead)
+	hosts.PUT("/:id/system-info", s.updateHostSystemInfo, ValidateIDFormat, s.authMiddle.RequireAgentOrWrite)
+	hosts.POST("/:id/system-info/refresh", s.refreshHostSystemInfo, ValidateIDFormat, s.authMiddle.RequireWrite)
 
 	// Query routes
 	query := v1.Group("/query")
-	query.GET("/containers/by-host/:hostId", s.getContainersByHost, ValidateIDFormat, s.authMiddle.RequireRead)
+	query.GET("/containers/by-host/:hostId", s.getContainersByHost, ValidateIDFormat, s.authMiddle.RequireReadOrShare)
 	query.GET("/containers/by-status/:status", s.getContainersByStatus, s.authMiddle.RequireRead)
-	query.GET("/hosts/by-datacenter/:datacenter", s.getHostsByDatacenter, s.authMiddle.RequireRead)
+	query.GET("/containers/by-image/:image", s.getContainersByImage, s.authMiddle.RequireRead)
+	query.GET("/containers/by-label", s.getContainersByLabel, s.authMiddle.RequireRead)
+	query.GET("/containers/name-collisions", s.getContainerNameCollisions, s.authMiddle.RequireRead)
+	query.GET("/containers/outdated", s.getOutdatedContainers, s.authMiddle.RequireRead)
+	query.GET("/containers/orphaned", s.getOrphanedContainers, s.authMiddle.RequireRead)
+	query.GET("/containers/by-port", s.getContainersByPort, s.authMiddle.RequireRead)
+	query.GET("/hosts/by-datacenter/:datacenter", s.getHostsByDatacenter, s.authMiddle.RequireReadOrShare)
 	query.GET("/traverse/:id", s.traverseGraph, ValidateIDFormat, s.authMiddle.RequireRead)
 	query.GET("/dependents/:id", s.getDependents, ValidateIDFormat, s.authMiddle.RequireRead)
-	query.GET("/topology/:datacenter", s.getDatacenterTopology, s.authMiddle.RequireRead)
+	query.GET("/topology/:datacenter", s.getDatacenterTopology, s.authMiddle.RequireReadOrShare)
+	query.GET("/graph", s.getGraph, s.authMiddle.RequireRead)
+
+	// Custom JSON-LD types and their entities
+	customTypes := v1.Group("/custom-types")
+	customTypes.GET("", s.listCustomTypes, s.authMiddle.RequireRead)
+	customTypes.GET("/:name", s.getCustomType, s.authMiddle.RequireRead)
+	customTypes.POST("", s.createCustomType, s.authMiddle.RequireAuth, s.authMiddle.RequireAdmin)
+	customTypes.DELETE("/:name", s.deleteCustomType, s.authMiddle.RequireAuth, s.authMiddle.RequireAdmin)
+
+	entities := v1.Group("/entities")
+	entities.GET("", s.listCustomEntities, s.authMiddle.RequireRead)
+	entities.GET("/:id", s.getCustomEntity, ValidateIDFormat, s.authMiddle.RequireRead)
+	entities.POST("", s.createCustomEntity, s.authMiddle.RequireWrite)
+	entities.PUT("/:id", s.updateCustomEntity, ValidateIDFormat, s.authMiddle.RequireWrite)
+	entities.DELETE("/:id", s.deleteCustomEntity, ValidateIDFormat, s.authMiddle.RequireWrite)
 
 	// Validation routes
 	validate := v1.Group("/validate")
@@ -215,11 +347,17 @@ func (s *Server) setupRoutes() {
 	// Database info
 	v1.GET("/info", s.getDatabaseInfo, s.authMiddle.RequireRead)
 
+	// Request limits, read by agents to pace their requests
+	v1.GET("/limits", s.getLimits, s.authMiddle.RequireRead)
+
 	// Stack routes (basic CRUD)
 	stackRoutes := v1.Group("/stacks")
 	stackRoutes.GET("", s.listStacks, s.authMiddle.RequireRead)
+	stackRoutes.GET("/auto-assign/preview", s.previewStackAssignment, s.authMiddle.RequireRead)
 	stackRoutes.GET("/:id", s.getStack, ValidateIDFormat, s.authMiddle.RequireRead)
 	stackRoutes.GET("/:id/deployment", s.getStackDeployment, ValidateIDFormat, s.authMiddle.RequireRead)
+	stackRoutes.GET("/:id/usage", s.getStackUsage, ValidateIDFormat, s.authMiddle.RequireRead)
+	stackRoutes.POST("/:id/rollback", s.rollbackStack, ValidateIDFormat, s.authMiddle.RequireWrite)
 
 	// JSON-LD Stack deployment routes
 	jsonldStacks := v1.Group("/stacks/jsonld")
@@ -227,6 +365,16 @@ func (s *Server) setupRoutes() {
 	jsonldStacks.POST("/validate", s.validateJSONLDStack, s.authMiddle.RequireRead)
 	jsonldStacks.GET("/deployments", s.listJSONLDDeployments, s.authMiddle.RequireRead)
 	jsonldStacks.GET("/deployments/:id", s.getJSONLDDeployment, ValidateIDFormat, s.authMiddle.RequireRead)
+	jsonldStacks.POST("/deployments/:id/promote", s.promoteJSONLDDeployment, ValidateIDFormat, s.authMiddle.RequireWrite)
+
+	// Deployment event timelines
+	deployments := v1.Group("/deployments")
+	deployments.GET("/:id/events", s.getDeploymentEvents, ValidateIDFormat, s.authMiddle.RequireRead)
+
+	// Datacenter policy routes
+	datacenters := v1.Group("/datacenters")
+	datacenters.GET("/:datacenter/policy", s.getDatacenterPolicy, s.authMiddle.RequireRead)
+	datacenters.PUT("/:datacenter/policy", s.putDatacenterPolicy, s.authMiddle.RequireAuth, s.authMiddle.RequireAdmin)
 
 	// Authentication routes
 	authRoutes := v1.Group("/auth")
@@ -249,13 +397,40 @@ func (s *Server) setupRoutes() {
 	stats.GET("/hosts/count", s.getHostCount, s.authMiddle.RequireRead)
 	stats.GET("/distribution", s.getHostContainerDistribution, s.authMiddle.RequireRead)
 
+	// WebSocket routes (real-time graph updates)
+	v1.GET("/ws/graph", s.HandleWebSocket, s.authMiddle.RequireReadOrShare)
+	v1.GET("/ws/stats", s.GetWebSocketStats, s.authMiddle.RequireReadOrShare)
+	v1.GET("/ws/agents/:id/tasks", s.HandleAgentTaskWebSocket, ValidateIDFormat, s.authMiddle.RequireAgentAuth)
+	v1.GET("/ws/containers/:id/logs", s.HandleContainerLogsWebSocket, ValidateIDFormat, s.authMiddle.RequireRead)
+
+	// Share link routes (read-only viewer tokens)
+	shareLinks := v1.Group("/share-links")
+	shareLinks.POST("", s.createShareLink, s.authMiddle.RequireAuth, s.authMiddle.RequireWrite)
+	shareLinks.GET("", s.listShareLinks, s.authMiddle.RequireAuth, s.authMiddle.RequireWrite)
+	shareLinks.DELETE("/:id", s.revokeShareLink, ValidateIDFormat, s.authMiddle.RequireAuth, s.authMiddle.RequireWrite)
+
 	// Container logs routes (API only - JWT auth)
 	v1.GET("/containers/:id/logs", s.getContainerLogs, ValidateIDFormat, s.authMiddle.RequireRead)
 	v1.GET("/containers/:id/logs/download", s.downloadContainerLogs, ValidateIDFormat, s.authMiddle.RequireRead)
 
+	// Secrets mounted into stack containers
+	secrets := v1.Group("/secrets")
+	secrets.GET("/:name/references", s.getSecretReferences, s.authMiddle.RequireRead)
+	secrets.PUT("/:name", s.updateSecret, s.authMiddle.RequireAuth, s.authMiddle.RequireAdmin)
+
+	// Container watches
+	v1.GET("/watches", s.listWatches, s.authMiddle.RequireRead)
+	v1.DELETE("/watches/:id", s.cancelWatch, s.authMiddle.RequireRead)
+
+	// Database index administration
+	admin := v1.Group("/admin")
+	admin.GET("/indexes", s.getIndexHealth, s.authMiddle.RequireAuth, s.authMiddle.RequireAdmin)
+	admin.POST("/indexes/rebuild", s.rebuildIndexes, s.authMiddle.RequireAuth, s.authMiddle.RequireAdmin)
+
 	// Integrity routes (database health and repair)
 	integrityRoutes := v1.Group("/integrity")
 	integrityRoutes.POST("/scan", s.scanIntegrity, s.authMiddle.RequireAdmin)
+	integrityRoutes.POST("/deduplicate", s.deduplicateContainers, s.authMiddle.RequireAdmin)
 	integrityRoutes.GET("/health", s.getHealth, s.authMiddle.RequireRead)
 	integrityRoutes.GET("/scans/:id", s.getScanReport, ValidateIDFormat, s.authMiddle.RequireRead)
 	integrityRoutes.GET("/scans", s.listScans, s.authMiddle.RequireRead)
@@ -267,6 +442,7 @@ func (s *Server) setupRoutes() {
 	agentRoutes := v1.Group("/agents")
 	agentRoutes.GET("", s.listAgents, s.authMiddle.RequireRead)
 	agentRoutes.GET("/:id", s.getAgent, ValidateIDFormat, s.authMiddle.RequireRead)
+	agentRoutes.GET("/:id/health", s.getAgentHealth, ValidateIDFormat, s.authMiddle.RequireRead)
 	agentRoutes.POST("", s.createAgent, s.authMiddle.RequireAdmin)
 	agentRoutes.PUT("/:id", s.updateAgent, ValidateIDFormat, s.authMiddle.RequireAdmin)
 	agentRoutes.DELETE("/:id", s.deleteAgent, ValidateIDFormat, s.authMiddle.RequireAdmin)
@@ -277,6 +453,11 @@ func (s *Server) setupRoutes() {
 	// Agent task routes (for agents to poll and update task status)
 	agentRoutes.GET("/:id/tasks", s.getAgentTasks, ValidateIDFormat, s.authMiddle.RequireAgentAuth)
 
+	// Agent log shipping routes
+	agentRoutes.GET("/:id/logs", s.getAgentLogs, ValidateIDFormat, s.authMiddle.RequireRead)
+	agentRoutes.POST("/:id/logs", s.appendAgentLogs, ValidateIDFormat, s.authMiddle.RequireAgentOrWrite)
+	agentRoutes.POST("/:id/logs/flush", s.flushAgentLogs, ValidateIDFormat, s.authMiddle.RequireWrite)
+
 	// Task management routes
 	tasks := v1.Group("/tasks")
 	tasks.POST("", s.createTask, s.authMiddle.RequireWrite)
@@ -296,17 +477,53 @@ func (s *Server) setupRoutes() {
 	actions.DELETE("/:id", s.DeleteScheduledAction, ValidateIDFormat, s.authMiddle.RequireWrite)
 	actions.POST("/:id/execute", s.ExecuteScheduledAction, ValidateIDFormat, s.authMiddle.RequireWrite)
 	actions.GET("/:id/history", s.GetScheduledActionHistory, ValidateIDFormat, s.authMiddle.RequireRead)
+	actions.GET("/:id/dependencies", s.GetScheduledActionDependencies, ValidateIDFormat, s.authMiddle.RequireRead)
 }
 
-// runTaskMonitor watches for completed deletion tasks and cleans up stack metadata.
+// runTaskMonitor watches for completed deletion tasks and cleans up stack
+// metadata. It also reconciles the status of deployed stacks.
 func (s *Server) runTaskMonitor() {
 	ticker := time.NewTicker(10 * time.Second)
 	defer ticker.Stop()
 
 	s.debugLog("Task monitor started")
 
+	lastShareLinkCleanup := time.Now()
+	var lastStackReconcile time.Time
+	lastContainerDedupe := time.Now()
+
 	for range ticker.C {
 		s.checkCompletedStackDeletions()
+
+		if interval := s.config.Server.StackReconcileInterval; interval > 0 && time.Since(lastStackReconcile) >= interval {
+			lastStackReconcile = time.Now()
+			s.reconcileStackStatuses()
+		}
+
+		if interval := s.config.Server.ContainerDedupeInterval; interval > 0 && time.Since(lastContainerDedupe) >= interval {
+			lastContainerDedupe = time.Now()
+			if result, err := s.storage.DeduplicateContainers(); err != nil {
+				s.debugLog("Task monitor: Failed to deduplicate containers: %v", err)
+			} else if result.Removed > 0 || len(result.Errors) > 0 {
+				s.logger.Infof("Removed %d duplicate document(s) of %d container(s), %d failed", result.Removed, result.Containers, len(result.Errors))
+			}
+		}
+
+		// Expired share links and tombstones are removed hourly; they are already
+		// rejected or ignored
+		if time.Since(lastShareLinkCleanup) >= time.Hour {
+			lastShareLinkCleanup = time.Now()
+			if deleted, err := s.storage.DeleteExpiredShareLinks(time.Now()); err != nil {
+				s.debugLog("Task monitor: Failed to delete expired share links: %v", err)
+			} else if deleted > 0 {
+				s.debugLog("Task monitor: Deleted %d expired share link(s)", deleted)
+			}
+			if deleted, err := s.storage.DeleteExpiredTombstones(time.Now()); err != nil {
+				s.debugLog("Task monitor: Failed to delete expired container tombstones: %v", err)
+			} else if deleted > 0 {
+				s.debugLog("Task monitor: Deleted %d expired container tombstone(s)", deleted)
+			}
+		}
 	}
 }
 
@@ -413,6 +630,11 @@ func (s *Server) Shutdown(ctx context.Context) error {
 		s.scheduler.Stop()
 	}
 
+	// Stop image update checker
+	if s.stopImages != nil {
+		s.stopImages()
+	}
+
 	// Shutdown Echo server
 	if err := s.echo.Shutdown(ctx); err != nil {
 		return fmt.Errorf("error shutting down server: %w", err)
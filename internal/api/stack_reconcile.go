@@ -0,0 +1,84 @@
+package api
+
+import (
+	"slices"
+	"time"
+
+	"evalgo.org/graphium/models"
+)
+
+// reconciledStackStatuses are the stack statuses the reconciler may replace.
+// Stacks in any other status (pending, deploying, stopping, deleting, error)
+// are being changed by a handler or need user attention, and are left alone.
+var reconciledStackStatuses = []string{
+	models.StackStatusRunning,
+	models.StackStatusDegraded,
+	models.StackStatusStopped,
+	models.StackStatusPartiallyFailed,
+}
+
+// reconcileStackStatuses derives the status of every deployed stack from the
+// state of the containers of its current deployment, so that containers
+// stopped or crashed outside Graphium are reflected in the stack status.
+// Stacks whose status or service states changed are saved and broadcast.
+func (s *Server) reconcileStackStatuses() {
+	stacks, err := s.storage.ListStacks(nil)
+	if err != nil {
+		s.debugLog("Task monitor: Failed to list stacks for reconciliation: %v", err)
+		return
+	}
+
+	var deployed []*models.Stack
+	for _, stack := range stacks {
+		if stack.CurrentDeployment != nil && len(stack.CurrentDeployment.Placements) > 0 &&
+			slices.Contains(reconciledStackStatuses, stack.Status) {
+			deployed = append(deployed, stack)
+		}
+	}
+	if len(deployed) == 0 {
+		return
+	}
+
+	containers, err := s.storage.ListContainers(nil)
+	if err != nil {
+		s.debugLog("Task monitor: Failed to list containers for reconciliation: %v", err)
+		return
+	}
+	states := make(map[string]string, len(containers))
+	for _, container := range containers {
+		states[container.ID] = container.Status
+	}
+
+	now := time.Now()
+	for _, stack := range deployed {
+		health := models.DeriveStackHealth(stack.CurrentDeployment.Placements, states, now)
+		if health == nil || (health.Status == stack.Status && health.Equal(stack.Health)) {
+			continue
+		}
+
+		previousStatus := stack.Status
+		stack.Status = health.Status
+		stack.Health = health
+		stack.UpdatedAt = now
+
+		// A conflict means a handler changed the stack meanwhile; the next
+		// pass reconciles it again
+		if err := s.storage.UpdateStack(stack); err != nil {
+			s.debugLog("Task monitor: Failed to update status of stack %s: %v", stack.ID, err)
+			continue
+		}
+
+		if previousStatus != health.Status {
+			s.debugLog("Task monitor: Stack %s is now %s (%d/%d services running)",
+				stack.ID, health.Status, health.Running, health.Total)
+		}
+
+		s.BroadcastGraphEvent(EventStackUpdated, map[string]interface{}{
+			"stackId":        stack.ID,
+			"name":           stack.Name,
+			"status":         health.Status,
+			"previousStatus": previousStatus,
+			"health":         health,
+		})
+	}
+}
@@ -46,6 +46,22 @@ type PaginatedHostsResponse struct {
 	Hosts  []*models.Host `json:"hosts"`
 }
 
+// CursorContainersResponse represents a cursor page of containers.
+type CursorContainersResponse struct {
+	Count      int                 `json:"count"`                 // Number of items in current page
+	Limit      int                 `json:"limit"`                 // Items per page
+	NextCursor string              `json:"next_cursor,omitempty"` // Cursor of the next page; absent on the last page
+	Containers []*models.Container `json:"containers"`
+}
+
+// CursorHostsResponse represents a cursor page of hosts.
+type CursorHostsResponse struct {
+	Count      int            `json:"count"`                 // Number of items in current page
+	Limit      int            `json:"limit"`                 // Items per page
+	NextCursor string         `json:"next_cursor,omitempty"` // Cursor of the next page; absent on the last page
+	Hosts      []*models.Host `json:"hosts"`
+}
+
 // BulkResult represents the result of a single bulk operation.
 type BulkResult struct {
 	ID      string `json:"id"`
@@ -26,20 +26,133 @@ const (
 	EventStackDeployed    GraphEventType = "stack_deployed"
 	EventStackError       GraphEventType = "stack_error"
 	EventGraphRefresh     GraphEventType = "graph_refresh"
+
+	// EventImageUpdateAvailable is sent when a newer image is available for a container's tag
+	EventImageUpdateAvailable GraphEventType = "image_update_available"
+
+	// EventConnected is sent to every client once registration (and any
+	// replay) is complete. Its data carries the latest sequence number.
+	EventConnected GraphEventType = "connected"
+
+	// EventContainerWatch is sent only to the owner of a container watch when
+	// the watched container changes state. It is not buffered for replay.
+	EventContainerWatch GraphEventType = "container_watch"
+
+	// EventResyncRequired tells a reconnecting client that the missed events
+	// are no longer buffered and it must reload the full graph.
+	EventResyncRequired GraphEventType = "resync_required"
+
+	// EventTaskAvailable is sent only to an agent's task sockets when a task
+	// is created for the agent. It is not buffered for replay.
+	EventTaskAvailable GraphEventType = "task_available"
 )
 
-// GraphEvent represents a change in the graph
+// eventHistorySize is the number of recent events kept for replay to reconnecting clients
+const eventHistorySize = 500
+
+// batchSuffix is appended to the type of coalesced events, e.g. container_removed_batch
+const batchSuffix = "_batch"
+
+// batchableEvents are the event types the hub may coalesce into a single
+// batch message. Bulk operations emit these in tight loops.
+var batchableEvents = map[GraphEventType]bool{
+	EventContainerAdded:   true,
+	EventContainerUpdated: true,
+	EventContainerRemoved: true,
+	EventHostAdded:        true,
+	EventHostUpdated:      true,
+	EventHostRemoved:      true,
+}
+
+// BatchEventData is the payload of a *_batch event. Items holds the data of
+// each coalesced event in order; IDs lists the IDs of the affected objects.
+type BatchEventData struct {
+	Count int               `json:"count"`
+	IDs   []string          `json:"ids"`
+	Items []json.RawMessage `json:"items"`
+}
+
+// GraphEvent represents a change in the graph.
+// Seq is a monotonically increasing sequence number assigned by the hub;
+// control events (connected, resync_required) are not buffered and carry seq 0.
 type GraphEvent struct {
+	Seq       uint64         `json:"seq"`
 	Type      GraphEventType `json:"type"`
 	Timestamp time.Time      `json:"timestamp"`
 	Data      interface{}    `json:"data"`
 }
 
+// bufferedEvent is a marshaled event kept in the replay buffer
+type bufferedEvent struct {
+	event   GraphEvent
+	message []byte
+
+	// parts are the coalesced events of a batch event (nil otherwise)
+	parts []GraphEvent
+}
+
+// EventFilter decides whether a client may receive an event.
+// Filters are only called from the hub's Run loop.
+type EventFilter func(event GraphEvent) bool
+
 // Client represents a WebSocket client connection
 type Client struct {
 	hub  *Hub
 	conn *websocket.Conn
 	send chan []byte
+
+	// lastSeq is the last sequence number the client saw before reconnecting
+	lastSeq uint64
+
+	// resume is true when the client asked for missed events to be replayed
+	resume bool
+
+	// filter restricts the events sent to the client (nil receives everything)
+	filter EventFilter
+
+	// userID is the authenticated user, used for targeted events (empty for share links)
+	userID string
+
+	// agentID is the agent whose task notifications the client receives
+	// (empty for graph clients)
+	agentID string
+}
+
+// messageFor returns the message to send the client for an event, and false
+// if the client may not receive it. Filters see the individual events of a
+// batch, so a filtered client gets a batch of just the events it may see.
+func (c *Client) messageFor(entry bufferedEvent) ([]byte, bool) {
+	if c.filter == nil {
+		return entry.message, true
+	}
+	if entry.parts == nil {
+		return entry.message, c.filter(entry.event)
+	}
+
+	accepted := make([]GraphEvent, 0, len(entry.parts))
+	for _, part := range entry.parts {
+		if c.filter(part) {
+			accepted = append(accepted, part)
+		}
+	}
+	switch len(accepted) {
+	case 0:
+		return nil, false
+	case len(entry.parts):
+		return entry.message, true
+	}
+
+	batch, err := newBatchEvent(accepted)
+	if err != nil {
+		return nil, false
+	}
+	batch.Seq = entry.event.Seq
+	batch.Timestamp = entry.event.Timestamp
+	message, err := json.Marshal(batch)
+	if err != nil {
+		return nil, false
+	}
+	return message, true
 }
 
 // Hub maintains the set of active clients and broadcasts messages
@@ -47,8 +160,8 @@ type Hub struct {
 	// Registered clients
 	clients map[*Client]bool
 
-	// Inbound messages from clients
-	broadcast chan []byte
+	// Events to broadcast to all clients
+	broadcast chan GraphEvent
 
 	// Register requests from clients
 	register chan *Client
@@ -56,25 +169,66 @@ type Hub struct {
 	// Unregister requests from clients
 	unregister chan *Client
 
+	// Events for the clients of a single user
+	direct chan userEvent
+
 	// Mutex for thread-safe operations
 	mu sync.RWMutex
+
+	// seq is the sequence number of the last broadcast event (owned by Run)
+	seq uint64
+
+	// history is a ring buffer of the most recent events (owned by Run)
+	history []bufferedEvent
+	next    int
+
+	// batchWindow and batchSize configure event coalescing (see SetBatching)
+	batchWindow time.Duration
+	batchSize   int
+
+	// pending holds batchable events of a single type waiting to be flushed
+	// at batchDeadline (owned by Run)
+	pending       []GraphEvent
+	batchDeadline <-chan time.Time
+}
+
+// userEvent is a control event addressed to one user's or one agent's clients
+type userEvent struct {
+	userID  string
+	agentID string
+	event   GraphEvent
 }
 
 // NewHub creates a new Hub instance
 func NewHub() *Hub {
 	return &Hub{
-		broadcast:  make(chan []byte, 256),
+		broadcast:  make(chan GraphEvent, 256),
 		register:   make(chan *Client),
 		unregister: make(chan *Client),
+		direct:     make(chan userEvent, 64),
 		clients:    make(map[*Client]bool),
+		history:    make([]bufferedEvent, 0, eventHistorySize),
 	}
 }
 
+// SetBatching enables coalescing of container and host events. Events of the
+// same type that arrive within window of the first one are sent as a single
+// <type>_batch event, or earlier once size events are pending. Any other event
+// flushes the pending batch first, so clients still see events in order.
+// A window of 0 disables batching. It must be called before Run.
+func (h *Hub) SetBatching(window time.Duration, size int) {
+	h.batchWindow = window
+	h.batchSize = size
+}
+
 // Run starts the hub's main loop
 func (h *Hub) Run() {
 	for {
 		select {
 		case client := <-h.register:
+			// Replay before adding the client so that no live event can
+			// overtake the replayed ones
+			h.replay(client)
 			h.mu.Lock()
 			h.clients[client] = true
 			h.mu.Unlock()
@@ -89,15 +243,18 @@ func (h *Hub) Run() {
 			h.mu.Unlock()
 			log.Printf("WebSocket client disconnected (total: %d)", len(h.clients))
 
-		case message := <-h.broadcast:
+		case event := <-h.broadcast:
+			h.enqueue(event)
+
+		case <-h.batchDeadline:
+			h.flushPending()
+
+		case target := <-h.direct:
 			h.mu.RLock()
 			for client := range h.clients {
-				select {
-				case client.send <- message:
-				default:
-					// Client is slow or disconnected, remove it
-					close(client.send)
-					delete(h.clients, client)
+				if (client.userID != "" && client.userID == target.userID) ||
+					(client.agentID != "" && client.agentID == target.agentID) {
+					h.sendControl(client, target.event.Type, target.event.Data)
 				}
 			}
 			h.mu.RUnlock()
@@ -105,18 +262,255 @@ func (h *Hub) Run() {
 	}
 }
 
-// BroadcastEvent sends an event to all connected clients
+// enqueue publishes an event or adds it to the pending batch
+func (h *Hub) enqueue(event GraphEvent) {
+	if h.batchWindow <= 0 || !batchableEvents[event.Type] {
+		h.flushPending()
+		h.publish(event, nil)
+		return
+	}
+
+	if len(h.pending) > 0 && h.pending[0].Type != event.Type {
+		h.flushPending()
+	}
+	h.pending = append(h.pending, event)
+
+	if h.batchSize > 0 && len(h.pending) >= h.batchSize {
+		h.flushPending()
+	} else if len(h.pending) == 1 {
+		h.batchDeadline = time.After(h.batchWindow)
+	}
+}
+
+// flushPending publishes the pending events, as a batch if there are several
+func (h *Hub) flushPending() {
+	pending := h.pending
+	h.pending = nil
+	h.batchDeadline = nil
+
+	switch len(pending) {
+	case 0:
+		return
+	case 1:
+		h.publish(pending[0], nil)
+		return
+	}
+
+	batch, err := newBatchEvent(pending)
+	if err != nil {
+		log.Printf("WebSocket batch marshal error: %v", err)
+		for _, event := range pending {
+			h.publish(event, nil)
+		}
+		return
+	}
+	h.publish(batch, pending)
+}
+
+// publish assigns the next sequence number to an event, records it for
+// replay and sends it to every client that accepts it
+func (h *Hub) publish(event GraphEvent, parts []GraphEvent) {
+	h.seq++
+	event.Seq = h.seq
+	message, err := json.Marshal(event)
+	if err != nil {
+		log.Printf("WebSocket event marshal error: %v", err)
+		return
+	}
+	entry := bufferedEvent{event: event, message: message, parts: parts}
+	h.record(entry)
+
+	h.mu.RLock()
+	for client := range h.clients {
+		message, ok := client.messageFor(entry)
+		if !ok {
+			continue
+		}
+		select {
+		case client.send <- message:
+		default:
+			// Client is slow or disconnected, remove it
+			close(client.send)
+			delete(h.clients, client)
+		}
+	}
+	h.mu.RUnlock()
+}
+
+// newBatchEvent coalesces events of one type into a single <type>_batch event
+func newBatchEvent(events []GraphEvent) (GraphEvent, error) {
+	data := BatchEventData{
+		Count: len(events),
+		IDs:   []string{},
+		Items: make([]json.RawMessage, 0, len(events)),
+	}
+	for _, event := range events {
+		raw, err := json.Marshal(event.Data)
+		if err != nil {
+			return GraphEvent{}, err
+		}
+		data.Items = append(data.Items, raw)
+
+		var ref struct {
+			ID      string `json:"@id"`
+			PlainID string `json:"id"`
+		}
+		if json.Unmarshal(raw, &ref) == nil {
+			if ref.ID != "" {
+				data.IDs = append(data.IDs, ref.ID)
+			} else if ref.PlainID != "" {
+				data.IDs = append(data.IDs, ref.PlainID)
+			}
+		}
+	}
+
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return GraphEvent{}, err
+	}
+	return GraphEvent{
+		Type:      events[0].Type + batchSuffix,
+		Timestamp: events[len(events)-1].Timestamp,
+		Data:      json.RawMessage(encoded),
+	}, nil
+}
+
+// record appends a marshaled event to the replay ring buffer
+func (h *Hub) record(entry bufferedEvent) {
+	if len(h.history) < eventHistorySize {
+		h.history = append(h.history, entry)
+		return
+	}
+	h.history[h.next] = entry
+	h.next = (h.next + 1) % eventHistorySize
+}
+
+// replay queues missed events for a reconnecting client. If the buffer no
+// longer covers the gap, or the missed events don't fit the client's send
+// buffer, a resync_required event is sent instead.
+// Every client finally receives a connected event with the latest sequence.
+func (h *Hub) replay(client *Client) {
+	if client.resume && client.lastSeq < h.seq {
+		oldest := h.seq + 1
+		if len(h.history) > 0 {
+			oldest = h.history[h.next%len(h.history)].event.Seq
+		}
+
+		if client.lastSeq+1 < oldest {
+			h.sendControl(client, EventResyncRequired, map[string]interface{}{
+				"reason":    "missed events are no longer buffered, please refresh",
+				"lastSeq":   client.lastSeq,
+				"oldestSeq": oldest,
+				"latestSeq": h.seq,
+			})
+		} else {
+			var missed [][]byte
+			for i := 0; i < len(h.history); i++ {
+				entry := h.history[(h.next+i)%len(h.history)]
+				if entry.event.Seq <= client.lastSeq {
+					continue
+				}
+				if message, ok := client.messageFor(entry); ok {
+					missed = append(missed, message)
+				}
+			}
+
+			// Leave room for the connected event
+			if len(missed) >= cap(client.send)-len(client.send) {
+				h.sendControl(client, EventResyncRequired, map[string]interface{}{
+					"reason":    "too many missed events, please refresh",
+					"lastSeq":   client.lastSeq,
+					"latestSeq": h.seq,
+				})
+			} else {
+				for _, message := range missed {
+					client.send <- message
+				}
+				log.Printf("WebSocket client resumed from seq %d (replayed %d events)", client.lastSeq, len(missed))
+			}
+		}
+	} else if client.resume && client.lastSeq > h.seq {
+		// The client saw sequence numbers from a previous server instance
+		h.sendControl(client, EventResyncRequired, map[string]interface{}{
+			"reason":    "server restarted, please refresh",
+			"lastSeq":   client.lastSeq,
+			"latestSeq": h.seq,
+		})
+	}
+
+	h.sendControl(client, EventConnected, map[string]interface{}{
+		"latestSeq": h.seq,
+	})
+}
+
+// sendControl queues an unbuffered control event for a single client
+func (h *Hub) sendControl(client *Client, eventType GraphEventType, data interface{}) {
+	message, err := json.Marshal(GraphEvent{
+		Type:      eventType,
+		Timestamp: time.Now(),
+		Data:      data,
+	})
+	if err != nil {
+		return
+	}
+	select {
+	case client.send <- message:
+	default:
+	}
+}
+
+// BroadcastEvent sends an event to all connected clients.
+// The hub assigns the event's sequence number and keeps it for replay.
 func (h *Hub) BroadcastEvent(event GraphEvent) error {
 	event.Timestamp = time.Now()
-	message, err := json.Marshal(event)
+
+	// Marshal the payload up front so encoding errors surface to the caller
+	data, err := json.Marshal(event.Data)
 	if err != nil {
 		return err
 	}
+	event.Data = json.RawMessage(data)
 
-	h.broadcast <- message
+	h.broadcast <- event
 	return nil
 }
 
+// SendToUser sends an event to every connected client of a user. Targeted
+// events bypass batching and the replay buffer and carry seq 0.
+func (h *Hub) SendToUser(userID string, eventType GraphEventType, data interface{}) error {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	h.direct <- userEvent{
+		userID: userID,
+		event:  GraphEvent{Type: eventType, Data: json.RawMessage(encoded)},
+	}
+	return nil
+}
+
+// SendToAgent sends an event to every connected task socket of an agent.
+// Like SendToUser it bypasses batching and the replay buffer. Agents fall
+// back to polling, so the event is dropped instead of blocking the caller
+// when the hub is busy; it reports whether the event was queued.
+func (h *Hub) SendToAgent(agentID string, eventType GraphEventType, data interface{}) (bool, error) {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return false, err
+	}
+
+	select {
+	case h.direct <- userEvent{
+		agentID: agentID,
+		event:   GraphEvent{Type: eventType, Data: json.RawMessage(encoded)},
+	}:
+		return true, nil
+	default:
+		return false, nil
+	}
+}
+
 // ClientCount returns the number of connected clients
 func (h *Hub) ClientCount() int {
 	h.mu.RLock()
@@ -0,0 +1,348 @@
+package api
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+// drainEvents decodes all messages currently queued for a client
+func drainEvents(t *testing.T, client *Client) []GraphEvent {
+	t.Helper()
+	var events []GraphEvent
+	for {
+		select {
+		case message := <-client.send:
+			var event GraphEvent
+			if err := json.Unmarshal(message, &event); err != nil {
+				t.Fatalf("Failed to decode event: %v", err)
+			}
+			events = append(events, event)
+		default:
+			return events
+		}
+	}
+}
+
+// recordEvents appends n events to the hub history as Run would
+func recordEvents(t *testing.T, hub *Hub, n int) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		hub.seq++
+		event := GraphEvent{Seq: hub.seq, Type: EventContainerUpdated}
+		message, err := json.Marshal(event)
+		if err != nil {
+			t.Fatalf("Failed to marshal event: %v", err)
+		}
+		hub.record(bufferedEvent{event: event, message: message})
+	}
+}
+
+func TestHubReplay(t *testing.T) {
+	tests := []struct {
+		name       string
+		recorded   int
+		resume     bool
+		lastSeq    uint64
+		filter     EventFilter
+		sendBuffer int
+		wantSeqs   []uint64
+		wantResync bool
+	}{
+		{
+			name:     "fresh client gets only connected event",
+			recorded: 5,
+			wantSeqs: nil,
+		},
+		{
+			name:     "resume replays missed events",
+			recorded: 5,
+			resume:   true,
+			lastSeq:  3,
+			wantSeqs: []uint64{4, 5},
+		},
+		{
+			name:     "resume skips events rejected by the client filter",
+			recorded: 5,
+			resume:   true,
+			lastSeq:  1,
+			filter:   func(event GraphEvent) bool { return event.Seq%2 == 0 },
+			wantSeqs: []uint64{2, 4},
+		},
+		{
+			name:     "resume when up to date replays nothing",
+			recorded: 5,
+			resume:   true,
+			lastSeq:  5,
+			wantSeqs: nil,
+		},
+		{
+			name:     "resume after ring wrapped",
+			recorded: eventHistorySize + 10,
+			resume:   true,
+			lastSeq:  eventHistorySize + 8,
+			wantSeqs: []uint64{eventHistorySize + 9, eventHistorySize + 10},
+		},
+		{
+			name:       "gap larger than buffer requires resync",
+			recorded:   eventHistorySize + 10,
+			resume:     true,
+			lastSeq:    5,
+			wantResync: true,
+		},
+		{
+			name:       "more missed events than the send buffer holds requires resync",
+			recorded:   10,
+			resume:     true,
+			lastSeq:    2,
+			sendBuffer: 4,
+			wantResync: true,
+		},
+		{
+			name:       "missed events filling the send buffer require resync",
+			recorded:   10,
+			resume:     true,
+			lastSeq:    6,
+			sendBuffer: 4,
+			wantResync: true,
+		},
+		{
+			name:       "missed events leaving room for connected are replayed",
+			recorded:   10,
+			resume:     true,
+			lastSeq:    7,
+			sendBuffer: 4,
+			wantSeqs:   []uint64{8, 9, 10},
+		},
+		{
+			name:       "sequence from previous server instance requires resync",
+			recorded:   2,
+			resume:     true,
+			lastSeq:    100,
+			wantResync: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hub := NewHub()
+			recordEvents(t, hub, tt.recorded)
+
+			sendBuffer := tt.sendBuffer
+			if sendBuffer == 0 {
+				sendBuffer = 256 + eventHistorySize
+			}
+			client := &Client{
+				hub:     hub,
+				send:    make(chan []byte, sendBuffer),
+				lastSeq: tt.lastSeq,
+				resume:  tt.resume,
+				filter:  tt.filter,
+			}
+			hub.replay(client)
+
+			events := drainEvents(t, client)
+			if len(events) == 0 {
+				t.Fatal("Expected at least the connected event")
+			}
+
+			last := events[len(events)-1]
+			if last.Type != EventConnected {
+				t.Errorf("Expected last event %s, got %s", EventConnected, last.Type)
+			}
+
+			var seqs []uint64
+			resync := false
+			for _, event := range events[:len(events)-1] {
+				if event.Type == EventResyncRequired {
+					resync = true
+					continue
+				}
+				seqs = append(seqs, event.Seq)
+			}
+
+			if resync != tt.wantResync {
+				t.Errorf("Expected resync %v, got %v", tt.wantResync, resync)
+			}
+			if len(seqs) != len(tt.wantSeqs) {
+				t.Fatalf("Expected replayed seqs %v, got %v", tt.wantSeqs, seqs)
+			}
+			for i := range seqs {
+				if seqs[i] != tt.wantSeqs[i] {
+					t.Errorf("Expected replayed seqs %v, got %v", tt.wantSeqs, seqs)
+					break
+				}
+			}
+		})
+	}
+}
+
+// removedEvent builds a container_removed event as BroadcastEvent would
+func removedEvent(t *testing.T, id string) GraphEvent {
+	t.Helper()
+	data, err := json.Marshal(map[string]string{"id": id})
+	if err != nil {
+		t.Fatalf("Failed to marshal event data: %v", err)
+	}
+	return GraphEvent{Type: EventContainerRemoved, Timestamp: time.Now(), Data: json.RawMessage(data)}
+}
+
+func TestHubBatching(t *testing.T) {
+	hub := NewHub()
+	hub.SetBatching(time.Hour, 3)
+
+	client := &Client{hub: hub, send: make(chan []byte, 16)}
+	filtered := &Client{hub: hub, send: make(chan []byte, 16), filter: func(event GraphEvent) bool {
+		var data struct {
+			ID string `json:"id"`
+		}
+		_ = json.Unmarshal(event.Data.(json.RawMessage), &data)
+		return data.ID != "b"
+	}}
+	hub.clients[client] = true
+	hub.clients[filtered] = true
+
+	// Two removals are held until a different event type arrives
+	hub.enqueue(removedEvent(t, "a"))
+	hub.enqueue(removedEvent(t, "b"))
+	if events := drainEvents(t, client); len(events) != 0 {
+		t.Fatalf("Expected events to be held, got %+v", events)
+	}
+	hub.enqueue(GraphEvent{Type: EventGraphRefresh, Data: json.RawMessage(`{}`)})
+
+	events := drainEvents(t, client)
+	if len(events) != 2 {
+		t.Fatalf("Expected batch and refresh events, got %+v", events)
+	}
+	if events[0].Type != EventContainerRemoved+batchSuffix || events[1].Type != EventGraphRefresh {
+		t.Errorf("Unexpected event order: %s, %s", events[0].Type, events[1].Type)
+	}
+	var batch BatchEventData
+	raw, _ := json.Marshal(events[0].Data)
+	if err := json.Unmarshal(raw, &batch); err != nil {
+		t.Fatalf("Failed to decode batch: %v", err)
+	}
+	if batch.Count != 2 || len(batch.IDs) != 2 || batch.IDs[0] != "a" || batch.IDs[1] != "b" {
+		t.Errorf("Unexpected batch data: %+v", batch)
+	}
+
+	// The filtered client only sees the removal it is allowed to see
+	events = drainEvents(t, filtered)
+	if len(events) != 2 || events[0].Type != EventContainerRemoved+batchSuffix {
+		t.Fatalf("Expected a single filtered batch, got %+v", events)
+	}
+	raw, _ = json.Marshal(events[0].Data)
+	if err := json.Unmarshal(raw, &batch); err != nil {
+		t.Fatalf("Failed to decode batch: %v", err)
+	}
+	if batch.Count != 1 || batch.IDs[0] != "a" {
+		t.Errorf("Expected filtered batch with only a, got %+v", batch)
+	}
+
+	// Reaching the size threshold flushes without waiting for the window
+	for _, id := range []string{"c", "d", "e"} {
+		hub.enqueue(removedEvent(t, id))
+	}
+	events = drainEvents(t, client)
+	if len(events) != 1 || events[0].Type != EventContainerRemoved+batchSuffix {
+		t.Fatalf("Expected a size-triggered batch, got %+v", events)
+	}
+	if len(hub.pending) != 0 || hub.batchDeadline != nil {
+		t.Error("Expected no pending events after flush")
+	}
+}
+
+func TestHubBatchingDisabled(t *testing.T) {
+	hub := NewHub()
+	client := &Client{hub: hub, send: make(chan []byte, 16)}
+	hub.clients[client] = true
+
+	hub.enqueue(removedEvent(t, "a"))
+	hub.enqueue(removedEvent(t, "b"))
+
+	events := drainEvents(t, client)
+	if len(events) != 2 || events[0].Type != EventContainerRemoved || events[1].Seq != 2 {
+		t.Errorf("Expected two individual events, got %+v", events)
+	}
+}
+
+func TestHubSendToUser(t *testing.T) {
+	hub := NewHub()
+	go hub.Run()
+
+	alice := &Client{hub: hub, send: make(chan []byte, 16), userID: "alice"}
+	bob := &Client{hub: hub, send: make(chan []byte, 16), userID: "bob"}
+	hub.register <- alice
+	hub.register <- bob
+
+	if err := hub.SendToUser("alice", EventContainerWatch, map[string]string{"containerId": "web-1"}); err != nil {
+		t.Fatalf("SendToUser failed: %v", err)
+	}
+
+	// Every client first receives its connected event
+	receive := func(client *Client) GraphEvent {
+		t.Helper()
+		for {
+			select {
+			case message := <-client.send:
+				var event GraphEvent
+				if err := json.Unmarshal(message, &event); err != nil {
+					t.Fatalf("Failed to decode event: %v", err)
+				}
+				if event.Type != EventConnected {
+					return event
+				}
+			case <-time.After(2 * time.Second):
+				return GraphEvent{}
+			}
+		}
+	}
+
+	if event := receive(alice); event.Type != EventContainerWatch || event.Seq != 0 {
+		t.Errorf("Expected an unsequenced container_watch event for alice, got %+v", event)
+	}
+
+	// Sending another event to alice proves bob's queue holds nothing targeted
+	if err := hub.SendToUser("alice", EventContainerWatch, nil); err != nil {
+		t.Fatalf("SendToUser failed: %v", err)
+	}
+	receive(alice)
+	if events := drainEvents(t, bob); len(events) != 1 || events[0].Type != EventConnected {
+		t.Errorf("Expected bob to receive only the connected event, got %+v", events)
+	}
+}
+
+func TestHubSendToAgent(t *testing.T) {
+	hub := NewHub()
+	go hub.Run()
+
+	never := func(GraphEvent) bool { return false }
+	agent1 := &Client{hub: hub, send: make(chan []byte, 16), agentID: "host-1", filter: never}
+	agent2 := &Client{hub: hub, send: make(chan []byte, 16), agentID: "host-2", filter: never}
+	hub.register <- agent1
+	hub.register <- agent2
+
+	if queued, err := hub.SendToAgent("host-1", EventTaskAvailable, map[string]string{"taskId": "task-1"}); err != nil || !queued {
+		t.Fatalf("SendToAgent failed: queued=%v err=%v", queued, err)
+	}
+	// Agent task sockets don't receive graph events
+	if err := hub.BroadcastEvent(GraphEvent{Type: EventContainerUpdated}); err != nil {
+		t.Fatalf("BroadcastEvent failed: %v", err)
+	}
+	// Round trip through Run so both events were handled
+	if _, err := hub.SendToAgent("host-1", EventTaskAvailable, nil); err != nil {
+		t.Fatalf("SendToAgent failed: %v", err)
+	}
+	time.Sleep(50 * time.Millisecond)
+
+	var types []GraphEventType
+	for _, event := range drainEvents(t, agent1) {
+		types = append(types, event.Type)
+	}
+	if len(types) != 3 || types[0] != EventConnected || types[1] != EventTaskAvailable || types[2] != EventTaskAvailable {
+		t.Errorf("Expected connected and two task_available events for host-1, got %v", types)
+	}
+	if events := drainEvents(t, agent2); len(events) != 1 || events[0].Type != EventConnected {
+		t.Errorf("Expected host-2 to receive only the connected event, got %+v", events)
+	}
+}
@@ -32,9 +32,21 @@ type Claims struct {
 	UserID   string        `json:"user_id"`
 	Username string        `json:"username"`
 	Roles    []models.Role `json:"roles"`
+
+	// ShareLinkID is set for read-only share link tokens
+	ShareLinkID string `json:"share_link_id,omitempty"`
+
+	// Datacenter restricts a share link token to a single datacenter
+	Datacenter string `json:"datacenter,omitempty"`
+
 	jwt.RegisteredClaims
 }
 
+// IsShareToken returns true if the claims belong to a share link token
+func (c *Claims) IsShareToken() bool {
+	return c.ShareLinkID != ""
+}
+
 // TokenPair represents an access token and refresh token
 type TokenPair struct {
 	AccessToken  string    `json:"access_token"`
@@ -117,6 +129,35 @@ func (s *JWTService) GenerateToken(user *models.User) (string, error) {
 	return tokenString, nil
 }
 
+// GenerateShareToken generates a read-only viewer token for a share link.
+// The token expires together with the link.
+func (s *JWTService) GenerateShareToken(link *models.ShareLink) (string, error) {
+	now := time.Now()
+
+	claims := Claims{
+		UserID:      link.ID,
+		Username:    "share-link",
+		Roles:       []models.Role{models.RoleViewer},
+		ShareLinkID: link.ID,
+		Datacenter:  link.Datacenter,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(link.ExpiresAt),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			Issuer:    "graphium-share",
+			Subject:   link.ID,
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	tokenString, err := token.SignedString(s.secret)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign share token: %w", err)
+	}
+
+	return tokenString, nil
+}
+
 // ValidateToken validates a JWT token and returns the claims
 // It tries to validate with the primary secret, and if that fails and an agent_token_secret
 // is configured, it will try that as well (for backward compatibility)
@@ -21,10 +21,14 @@ const (
 	ContextKeyClaims = "claims"
 )
 
+// ShareLinkChecker verifies that a share link is still active (not revoked or expired)
+type ShareLinkChecker func(linkID string) error
+
 // Middleware is the authentication middleware
 type Middleware struct {
-	jwtService *JWTService
-	config     *config.Config
+	jwtService       *JWTService
+	config           *config.Config
+	shareLinkChecker ShareLinkChecker
 }
 
 // NewMiddleware creates a new authentication middleware
@@ -35,6 +39,16 @@ func NewMiddleware(cfg *config.Config) *Middleware {
 	}
 }
 
+// SetShareLinkChecker sets the function used to check that share links are still active
+func (m *Middleware) SetShareLinkChecker(checker ShareLinkChecker) {
+	m.shareLinkChecker = checker
+}
+
+// JWTService returns the JWT service used by the middleware
+func (m *Middleware) JWTService() *JWTService {
+	return m.jwtService
+}
+
 // RequireAuth is middleware that requires JWT authentication
 func (m *Middleware) RequireAuth(next echo.HandlerFunc) echo.HandlerFunc {
 	return func(c echo.Context) error {
@@ -66,6 +80,11 @@ func (m *Middleware) RequireAuth(next echo.HandlerFunc) echo.HandlerFunc {
 			return echo.NewHTTPError(http.StatusUnauthorized, "invalid token")
 		}
 
+		// Share link tokens are only accepted by RequireReadOrShare
+		if claims.IsShareToken() {
+			return echo.NewHTTPError(http.StatusForbidden, "share link tokens cannot access this endpoint")
+		}
+
 		// Store claims in context
 		c.Set(ContextKeyClaims, claims)
 
@@ -73,6 +92,71 @@ func (m *Middleware) RequireAuth(next echo.HandlerFunc) echo.HandlerFunc {
 	}
 }
 
+// RequireReadOrShare is middleware that requires read permissions and also accepts
+// read-only share link tokens. Share tokens may be passed as a ?token= query
+// parameter so that share URLs and WebSocket connections work without headers.
+func (m *Middleware) RequireReadOrShare(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		// Skip if auth is disabled
+		if !m.config.Security.AuthEnabled {
+			return next(c)
+		}
+
+		// Regular requests use the Authorization header
+		if c.Request().Header.Get("Authorization") != "" {
+			authHeader := c.Request().Header.Get("Authorization")
+			parts := strings.SplitN(authHeader, " ", 2)
+			if len(parts) != 2 || parts[0] != "Bearer" {
+				return echo.NewHTTPError(http.StatusUnauthorized, "invalid authorization header format")
+			}
+			return m.authenticateShare(c, parts[1], next)
+		}
+
+		tokenString := c.QueryParam("token")
+		if tokenString == "" {
+			return echo.NewHTTPError(http.StatusUnauthorized, "missing authorization header")
+		}
+
+		return m.authenticateShare(c, tokenString, next)
+	}
+}
+
+// authenticateShare validates a user or share link token and calls next
+func (m *Middleware) authenticateShare(c echo.Context, tokenString string, next echo.HandlerFunc) error {
+	claims, err := m.jwtService.ValidateToken(tokenString)
+	if err != nil {
+		if err == ErrExpiredToken {
+			return echo.NewHTTPError(http.StatusUnauthorized, "token has expired")
+		}
+		return echo.NewHTTPError(http.StatusUnauthorized, "invalid token")
+	}
+
+	// Query parameter tokens are only accepted for share links
+	if !claims.IsShareToken() && c.QueryParam("token") == tokenString {
+		return echo.NewHTTPError(http.StatusUnauthorized, "only share link tokens may be passed as a query parameter")
+	}
+
+	// Share links can be revoked before their token expires
+	if claims.IsShareToken() && m.shareLinkChecker != nil {
+		if err := m.shareLinkChecker(claims.ShareLinkID); err != nil {
+			return echo.NewHTTPError(http.StatusUnauthorized, "share link is no longer valid")
+		}
+	}
+
+	c.Set(ContextKeyClaims, claims)
+	return next(c)
+}
+
+// GetShareScope returns the datacenter a share link token is restricted to.
+// The bool is false for regular (non-share) requests.
+func GetShareScope(c echo.Context) (string, bool) {
+	claims, ok := GetClaims(c)
+	if !ok || !claims.IsShareToken() {
+		return "", false
+	}
+	return claims.Datacenter, true
+}
+
 // RequireRole is middleware that requires a specific role
 func (m *Middleware) RequireRole(roles ...models.Role) echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
@@ -6,7 +6,6 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
-	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -38,6 +37,10 @@ func init() {
 }
 
 func runAgent(cmd *cobra.Command, args []string) error {
+	if err := agent.SetLogFormat(cfg.Agent.LogFormat); err != nil {
+		return err
+	}
+
 	// Get configuration values (command-line flags override config file)
 	apiURL := viper.GetString("agent.api_url")
 	hostID := viper.GetString("agent.host_id")
@@ -55,35 +58,9 @@ func runAgent(cmd *cobra.Command, args []string) error {
 	}
 	fmt.Println()
 
-	// Get agent authentication token
-	// Priority order:
-	// 1. TOKEN environment variable (set by agent manager for managed agents)
-	// 2. agent_token from config file (for standalone agents)
-	// 3. Generate token if auth is enabled
-	var agentToken string
-	if envToken := os.Getenv("TOKEN"); envToken != "" {
-		// Use token from environment (agent manager)
-		agentToken = envToken
-	} else if cfg.Agent.AgentToken != "" {
-		// Use pre-configured token from config file
-		agentToken = cfg.Agent.AgentToken
-	} else if cfg.Security.AuthEnabled {
-		// Use agent_token_secret if provided, otherwise fall back to jwt_secret
-		secret := cfg.Security.AgentTokenSecret
-		if secret == "" {
-			secret = cfg.Security.JWTSecret
-		}
-
-		// Generate a long-lived token (7 days) if no token configured
-		token, err := auth.GenerateAgentToken(
-			secret,
-			hostID,
-			7*24*time.Hour,
-		)
-		if err != nil {
-			return fmt.Errorf("failed to generate agent token: %w", err)
-		}
-		agentToken = token
+	agentToken, err := resolveAgentToken(hostID)
+	if err != nil {
+		return err
 	}
 
 	a, err := agent.NewAgent(
@@ -93,11 +70,48 @@ func runAgent(cmd *cobra.Command, args []string) error {
 		dockerSocket,
 		agentToken,
 		httpPort,
+		cfg.Agent.SyncInterval,
+		agent.DockerTLSConfig{
+			CAFile:   cfg.Agent.DockerTLSCA,
+			CertFile: cfg.Agent.DockerTLSCert,
+			KeyFile:  cfg.Agent.DockerTLSKey,
+		},
 	)
 	if err != nil {
 		return fmt.Errorf("failed to create agent: %w", err)
 	}
 
+	if err := a.SetHTTPSecurity(agent.HTTPSecurity{
+		AuthToken:       cfg.Agent.HTTPAuthToken,
+		TLSCertFile:     cfg.Agent.HTTPTLSCert,
+		TLSKeyFile:      cfg.Agent.HTTPTLSKey,
+		TLSClientCAFile: cfg.Agent.HTTPTLSClientCA,
+		BindAddress:     cfg.Agent.HTTPBindAddress,
+	}); err != nil {
+		return fmt.Errorf("invalid agent HTTP security settings: %w", err)
+	}
+
+	if cfg.Agent.ShipLogs {
+		a.EnableLogShipping()
+	}
+
+	a.SetTaskConcurrency(cfg.Agent.TaskConcurrency)
+	a.SetSyncBatchSize(cfg.Agent.SyncBatchSize)
+	a.SetRetryBackoff(agent.RetryBackoff{
+		BaseDelay:   cfg.Agent.RetryBaseDelay,
+		MaxDelay:    cfg.Agent.RetryMaxDelay,
+		MaxAttempts: cfg.Agent.RetryMaxAttempts,
+	})
+	a.SetTaskPush(cfg.Agent.TaskPush)
+	a.SetStateFile(cfg.Agent.StateFile)
+	a.SetBackupDir(cfg.Agent.BackupDir)
+	a.SetAllowExec(cfg.Agent.AllowExec)
+	a.SetMetricsAddress(cfg.Agent.MetricsAddress)
+	a.SetDiscoverySelector(agent.DiscoverySelector{
+		Include: cfg.Agent.DiscoveryLabels,
+		Exclude: cfg.Agent.DiscoveryExcludeLabels,
+	})
+
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
@@ -116,8 +130,55 @@ func runAgent(cmd *cobra.Command, args []string) error {
 	<-quit
 
 	fmt.Println("\n🛑 Stopping agent...")
+
+	// Let running tasks finish before stopping the agent
+	drainCtx, cancelDrain := context.WithTimeout(context.Background(), cfg.Agent.DrainTimeout)
+	if err := a.Drain(drainCtx); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: agent drain incomplete: %v\n", err)
+	}
+	cancelDrain()
+
 	cancel()
+	if err := a.Close(); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to close agent: %v\n", err)
+	}
 
 	fmt.Println("✓ Agent stopped")
 	return nil
 }
+
+// resolveAgentToken returns the token the agent authenticates with.
+// Priority order:
+// 1. TOKEN environment variable (set by agent manager for managed agents)
+// 2. agent_token from config file (for standalone agents)
+// 3. Generate token if auth is enabled
+func resolveAgentToken(hostID string) (string, error) {
+	if envToken := os.Getenv("TOKEN"); envToken != "" {
+		// Use token from environment (agent manager)
+		return envToken, nil
+	}
+	if cfg.Agent.AgentToken != "" {
+		// Use pre-configured token from config file
+		return cfg.Agent.AgentToken, nil
+	}
+	if !cfg.Security.AuthEnabled {
+		return "", nil
+	}
+
+	// Use agent_token_secret if provided, otherwise fall back to jwt_secret
+	secret := cfg.Security.AgentTokenSecret
+	if secret == "" {
+		secret = cfg.Security.JWTSecret
+	}
+
+	// Generate a token (security.agent_token_expiration) if no token configured
+	token, err := auth.GenerateAgentToken(
+		secret,
+		hostID,
+		cfg.Security.AgentTokenExpiration,
+	)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate agent token: %w", err)
+	}
+	return token, nil
+}
@@ -0,0 +1,270 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"evalgo.org/graphium/agent"
+	"evalgo.org/graphium/internal/storage"
+)
+
+var (
+	checkAgent      bool
+	checkSkipServer bool
+	checkTimeout    time.Duration
+)
+
+var checkCmd = &cobra.Command{
+	Use:   "check",
+	Short: "Validate the configuration and test connectivity",
+	Long: `Validate the configuration and test the connections Graphium needs,
+then print a pass/fail report with hints for every failed check.
+
+Server checks: CouchDB reachability and credentials, the database, and the
+required indexes and views. Agent checks (with --agent, or when agent.enabled
+is set): the Docker socket, and API server reachability and authentication.
+
+Exits with a non-zero status if any check fails.
+
+Examples:
+  graphium check
+  graphium check --config /etc/graphium/config.yaml --agent
+  graphium check --agent --skip-server`,
+	Args:          cobra.NoArgs,
+	RunE:          runCheck,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+// configErr holds the configuration error for graphium check, which reports
+// it instead of exiting during initialization.
+var configErr error
+
+func init() {
+	checkCmd.Flags().BoolVar(&checkAgent, "agent", false, "run the agent checks (default: when agent.enabled is set)")
+	checkCmd.Flags().BoolVar(&checkSkipServer, "skip-server", false, "skip the CouchDB checks")
+	checkCmd.Flags().DurationVar(&checkTimeout, "timeout", 10*time.Second, "timeout for each connectivity check")
+}
+
+// checkResult is one line of the check report.
+type checkResult struct {
+	name   string
+	passed bool
+	detail string
+	hint   string
+}
+
+// checkReport collects check results and prints them as they complete.
+type checkReport struct {
+	results []checkResult
+}
+
+func (r *checkReport) pass(name, detail string) {
+	r.add(checkResult{name: name, passed: true, detail: detail})
+}
+
+func (r *checkReport) fail(name, detail, hint string) {
+	r.add(checkResult{name: name, detail: detail, hint: hint})
+}
+
+func (r *checkReport) add(result checkResult) {
+	r.results = append(r.results, result)
+
+	mark := "✓"
+	if !result.passed {
+		mark = "✗"
+	}
+	fmt.Printf("%s %s", mark, result.name)
+	if result.detail != "" {
+		fmt.Printf(": %s", result.detail)
+	}
+	fmt.Println()
+	if result.hint != "" {
+		fmt.Printf("    → %s\n", result.hint)
+	}
+}
+
+func (r *checkReport) failures() int {
+	failed := 0
+	for _, result := range r.results {
+		if !result.passed {
+			failed++
+		}
+	}
+	return failed
+}
+
+func runCheck(cmd *cobra.Command, args []string) error {
+	report := &checkReport{}
+
+	fmt.Println("🔍 Graphium configuration check")
+	fmt.Println()
+
+	if configErr != nil {
+		report.fail("configuration", configErr.Error(),
+			"fix the config file (--config) or CG_* environment variables; see configs/config.yaml for all settings")
+		return fmt.Errorf("1 check failed")
+	}
+	report.pass("configuration", "loaded and valid")
+
+	if !checkSkipServer {
+		checkCouchDB(report)
+	}
+	if checkAgent || cfg.Agent.Enabled {
+		checkAgentConnectivity(report)
+	}
+
+	fmt.Println()
+	if failed := report.failures(); failed > 0 {
+		fmt.Printf("%d of %d checks failed\n", failed, len(report.results))
+		return fmt.Errorf("%d check(s) failed", failed)
+	}
+	fmt.Printf("All %d checks passed\n", len(report.results))
+	return nil
+}
+
+// checkCouchDB checks that CouchDB answers, accepts the credentials, and
+// holds the database with its indexes and views.
+func checkCouchDB(report *checkReport) {
+	client := &http.Client{Timeout: checkTimeout}
+
+	get := func(path string) (int, error) {
+		req, err := http.NewRequest(http.MethodGet, strings.TrimSuffix(cfg.CouchDB.URL, "/")+path, nil)
+		if err != nil {
+			return 0, err
+		}
+		if cfg.CouchDB.Username != "" {
+			req.SetBasicAuth(cfg.CouchDB.Username, cfg.CouchDB.Password)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return 0, err
+		}
+		_ = resp.Body.Close()
+		return resp.StatusCode, nil
+	}
+
+	code, err := get("/")
+	if err != nil {
+		report.fail("couchdb reachable", err.Error(),
+			fmt.Sprintf("check couchdb.url (%s) and that CouchDB is running", cfg.CouchDB.URL))
+		return
+	}
+	if code != http.StatusOK {
+		report.fail("couchdb reachable", fmt.Sprintf("HTTP %d", code),
+			fmt.Sprintf("couchdb.url (%s) does not look like a CouchDB server", cfg.CouchDB.URL))
+		return
+	}
+	report.pass("couchdb reachable", cfg.CouchDB.URL)
+
+	code, err = get("/" + url.PathEscape(cfg.CouchDB.Database))
+	switch {
+	case err != nil:
+		report.fail("couchdb database", err.Error(), "check the network connection to CouchDB")
+		return
+	case code == http.StatusUnauthorized || code == http.StatusForbidden:
+		report.fail("couchdb database", fmt.Sprintf("access denied (HTTP %d)", code),
+			"check couchdb.username and couchdb.password")
+		return
+	case code == http.StatusNotFound:
+		report.fail("couchdb database", fmt.Sprintf("database %q does not exist", cfg.CouchDB.Database),
+			"start graphium server once to create it, or check couchdb.database")
+		return
+	case code != http.StatusOK:
+		report.fail("couchdb database", fmt.Sprintf("HTTP %d", code), "check the CouchDB logs")
+		return
+	}
+	report.pass("couchdb database", cfg.CouchDB.Database)
+
+	// Connect read-only: creating the schema here would hide what is missing
+	store, err := storage.NewReadOnly(cfg)
+	if err != nil {
+		report.fail("couchdb indexes", err.Error(), "check the CouchDB logs")
+		return
+	}
+	defer store.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), checkTimeout)
+	defer cancel()
+
+	indexes, err := store.VerifyIndexes(ctx)
+	if err != nil {
+		report.fail("couchdb indexes", err.Error(), "check that the CouchDB user may read design documents")
+		return
+	}
+	for _, group := range []struct{ kind, name string }{
+		{"index", "couchdb indexes"},
+		{"view", "couchdb views"},
+	} {
+		present, missing := 0, []string{}
+		for _, status := range indexes.Indexes {
+			switch {
+			case status.Kind != group.kind:
+			case status.Present && status.Queryable:
+				present++
+			default:
+				missing = append(missing, status.Name)
+			}
+		}
+		if len(missing) > 0 {
+			report.fail(group.name, "missing or not usable: "+strings.Join(missing, ", "),
+				"start graphium server once to create them, or rebuild them with POST /api/v1/admin/indexes/rebuild")
+			continue
+		}
+		report.pass(group.name, fmt.Sprintf("%d present", present))
+	}
+}
+
+// checkAgentConnectivity checks the agent's Docker socket and its access to
+// the API server.
+func checkAgentConnectivity(report *checkReport) {
+	hostID := cfg.Agent.HostID
+	if hostID == "" {
+		report.fail("agent host id", "agent.host_id is not set", "set agent.host_id or pass --host-id to graphium agent")
+		return
+	}
+	if cfg.Agent.APIURL == "" {
+		report.fail("agent api url", "agent.api_url is not set", "set agent.api_url to the Graphium server URL")
+		return
+	}
+
+	token, err := resolveAgentToken(hostID)
+	if err != nil {
+		report.fail("agent token", err.Error(), "set agent.agent_token or security.agent_token_secret")
+		return
+	}
+
+	a, err := agent.NewAgent(cfg.Agent.APIURL, hostID, cfg.Agent.Datacenter, cfg.Agent.DockerSocket, token, 0, cfg.Agent.SyncInterval, agent.DockerTLSConfig{
+		CAFile:   cfg.Agent.DockerTLSCA,
+		CertFile: cfg.Agent.DockerTLSCert,
+		KeyFile:  cfg.Agent.DockerTLSKey,
+	})
+	if err != nil {
+		report.fail("docker socket", err.Error(), "check agent.docker_socket")
+		return
+	}
+	defer a.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), checkTimeout)
+	defer cancel()
+
+	if err := a.CheckDocker(ctx); err != nil {
+		report.fail("docker socket", err.Error(),
+			"check agent.docker_socket and that this user may access it (e.g. membership in the docker group)")
+	} else {
+		report.pass("docker socket", cfg.Agent.DockerSocket)
+	}
+
+	if err := a.CheckAuthentication(ctx); err != nil {
+		report.fail("api authentication", err.Error(),
+			"check agent.api_url, agent.agent_token and that security.auth_enabled matches the server")
+	} else {
+		report.pass("api authentication", cfg.Agent.APIURL)
+	}
+}
@@ -102,7 +102,7 @@ func init() {
 	listCmd.Flags().StringVar(&queryFormat, "format", "table", "output format (table, json)")
 
 	// Traverse command flags
-	traverseCmd.Flags().IntVar(&queryLimit, "depth", 5, "maximum traversal depth")
+	traverseCmd.Flags().IntVar(&queryLimit, "depth", 5, "maximum traversal depth (0 = unlimited, capped by server.max_graph_depth)")
 	traverseCmd.Flags().StringVar(&queryFormat, "format", "tree", "output format (tree, json)")
 
 	// Dependents command flags
@@ -196,7 +196,7 @@ func runTraverse(cmd *cobra.Command, args []string) error {
 	defer store.Close()
 
 	// Get dependency graph
-	graph, err := store.GetContainerDependencyGraph(id, queryLimit)
+	graph, depth, truncated, err := store.GetContainerDependencyGraph(id, queryLimit)
 	if err != nil {
 		return fmt.Errorf("failed to traverse graph: %w", err)
 	}
@@ -209,6 +209,10 @@ func runTraverse(cmd *cobra.Command, args []string) error {
 	fmt.Printf("Dependency graph for: %s\n\n", id)
 	printGraph(graph, 0)
 
+	if truncated {
+		fmt.Printf("\nNote: traversal truncated at the server depth limit (%d)\n", depth)
+	}
+
 	return nil
 }
 
@@ -46,6 +46,7 @@ func init() {
 	rootCmd.AddCommand(agentCmd)
 	rootCmd.AddCommand(integrityCmd)
 	rootCmd.AddCommand(tokenCmd)
+	rootCmd.AddCommand(checkCmd)
 	rootCmd.AddCommand(versionCmd)
 
 	rootCmd.SetVersionTemplate(`{{with .Name}}{{printf "%s " .}}{{end}}{{printf "%s" .Version}}
@@ -56,6 +57,11 @@ func initConfig() {
 	var err error
 	cfg, err = config.Load(cfgFile)
 	if err != nil {
+		// graphium check reports configuration errors itself
+		if cmd, _, findErr := rootCmd.Find(os.Args[1:]); findErr == nil && cmd == checkCmd {
+			configErr = err
+			return
+		}
 		fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
 		os.Exit(1)
 	}
@@ -21,7 +21,8 @@ var generateAgentTokenCmd = &cobra.Command{
 	Long: `Generate a JWT token for agent authentication.
 
 The token is signed with the agent_token_secret from the configuration file
-and includes the host ID in the claims. By default, tokens expire after 1 year.
+and includes the host ID in the claims. Tokens expire after
+security.agent_token_expiration (default: 7 days) unless --expiration is given.
 
 Examples:
   # Generate token for localhost-docker
@@ -43,7 +44,7 @@ var (
 
 func init() {
 	// Add flags to generate command
-	generateAgentTokenCmd.Flags().Int64Var(&tokenExpiration, "expiration", 8760, "Token expiration in hours (default: 8760 = 1 year)")
+	generateAgentTokenCmd.Flags().Int64Var(&tokenExpiration, "expiration", 0, "Token expiration in hours (default: security.agent_token_expiration)")
 	generateAgentTokenCmd.Flags().StringVar(&tokenSecret, "secret", "", "Agent token secret (default: from config file)")
 
 	// Add subcommands
@@ -78,8 +79,14 @@ Please either:
 		}
 	}
 
-	// Convert expiration from hours to duration
+	// Convert expiration from hours to duration, defaulting to the configured lifetime
 	expiration := time.Duration(tokenExpiration) * time.Hour
+	if expiration <= 0 {
+		expiration = 168 * time.Hour
+		if cfg != nil && cfg.Security.AgentTokenExpiration > 0 {
+			expiration = cfg.Security.AgentTokenExpiration
+		}
+	}
 
 	// Generate token
 	token, err := auth.GenerateAgentToken(secret, hostID, expiration)
@@ -91,7 +98,7 @@ Please either:
 	fmt.Printf("Agent Token Generated Successfully\n")
 	fmt.Printf("==================================\n\n")
 	fmt.Printf("Host ID:    %s\n", hostID)
-	fmt.Printf("Expiration: %s (%d hours)\n", expiration, tokenExpiration)
+	fmt.Printf("Expiration: %s (%.0f hours)\n", expiration, expiration.Hours())
 	fmt.Printf("\nToken:\n%s\n\n", token)
 	fmt.Printf("Add this to your agent configuration:\n")
 	fmt.Printf("  agent:\n")
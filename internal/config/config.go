@@ -34,7 +34,10 @@ package config
 import (
 	"errors"
 	"fmt"
+	"net"
+	"net/url"
 	"os"
+	"path"
 	"strings"
 	"time"
 
@@ -61,6 +64,28 @@ type Config struct {
 
 	// Security contains security and rate limiting settings
 	Security SecurityConfig `mapstructure:"security"`
+
+	// ImageUpdates contains settings for detecting newer container images
+	ImageUpdates ImageUpdatesConfig `mapstructure:"image_updates"`
+
+	// Deploy contains the protection policy for critical containers and
+	// deployment defaults
+	Deploy DeployConfig `mapstructure:"deploy"`
+
+	// Registries contains the credentials for private registries, used to
+	// pull images in deployments and to check images for updates. A list
+	// rather than a map keyed by host, as viper would split hosts like
+	// ghcr.io into nested keys
+	Registries []RegistryCredentials `mapstructure:"registries"`
+
+	// Exec contains which commands tasks may run inside containers
+	Exec ExecConfig `mapstructure:"exec"`
+
+	// Graph contains custom JSON-LD types shown in the graph
+	Graph GraphConfig `mapstructure:"graph"`
+
+	// Notifications contains where scheduled actions send notifications
+	Notifications NotificationsConfig `mapstructure:"notifications"`
 }
 
 // ServerConfig contains HTTP server configuration.
@@ -91,6 +116,74 @@ type ServerConfig struct {
 
 	// TLSKey is the path to the TLS private key file
 	TLSKey string `mapstructure:"tls_key"`
+
+	// MaxGraphDepth is the hard cap on dependency-graph traversal depth.
+	// It applies even when a client requests unlimited depth (default: 50)
+	MaxGraphDepth int `mapstructure:"max_graph_depth"`
+
+	// WebSocketBatchWindow is how long container and host events of the same
+	// type are collected into one *_batch WebSocket message (0 disables batching)
+	WebSocketBatchWindow time.Duration `mapstructure:"websocket_batch_window"`
+
+	// WebSocketBatchSize flushes a batch early once it holds this many events
+	WebSocketBatchSize int `mapstructure:"websocket_batch_size"`
+
+	// AutoAssignStacks adds created and synced containers to a matching stack,
+	// chosen by graphium.stack / Compose project labels or name prefix (default: true)
+	AutoAssignStacks bool `mapstructure:"auto_assign_stacks"`
+
+	// WatchDefaultDuration is how long a container watch lives when the
+	// request gives no duration (default: 30m)
+	WatchDefaultDuration time.Duration `mapstructure:"watch_default_duration"`
+
+	// WatchMaxDuration is the longest duration a container watch may request (default: 24h)
+	WatchMaxDuration time.Duration `mapstructure:"watch_max_duration"`
+
+	// WatchMaxPerUser caps the active container watches of a single user
+	// (default: 20, 0 = unlimited)
+	WatchMaxPerUser int `mapstructure:"watch_max_per_user"`
+
+	// WatchWebhookAllowlist lists the hosts watch webhooks may target. If
+	// empty, webhooks may target any host with public addresses only.
+	WatchWebhookAllowlist []string `mapstructure:"watch_webhook_allowlist"`
+
+	// AllowOvercommit lets stack deployments place containers whose CPU/memory
+	// reservation exceeds a host's remaining capacity (default: false)
+	AllowOvercommit bool `mapstructure:"allow_overcommit"`
+
+	// StackReconcileInterval is how often the status of deployed stacks is
+	// derived from the state of their containers (0 disables, default: 30s)
+	StackReconcileInterval time.Duration `mapstructure:"stack_reconcile_interval"`
+
+	// LogDownloadMaxBytes caps the log content of one container log download;
+	// longer downloads end with a truncation notice (0 disables, default: 100 MiB)
+	LogDownloadMaxBytes int64 `mapstructure:"log_download_max_bytes"`
+
+	// LogStreamIdleTimeout closes a live container log stream after this long
+	// without log lines or client messages (0 disables, default: 5m)
+	LogStreamIdleTimeout time.Duration `mapstructure:"log_stream_idle_timeout"`
+
+	// ContainerDedupeInterval is how often duplicate container documents are
+	// deleted, keeping the newest copy (0 disables, default: 0)
+	ContainerDedupeInterval time.Duration `mapstructure:"container_dedupe_interval"`
+
+	// ContainerTombstoneTTL is how long a deleted container stays tombstoned,
+	// so agents don't sync it back (0 keeps it until cleared, default: 0)
+	ContainerTombstoneTTL time.Duration `mapstructure:"container_tombstone_ttl"`
+
+	// ContainerHistoryLimit is how many change history entries are kept per
+	// container; older entries are deleted (0 keeps all, default: 100)
+	ContainerHistoryLimit int `mapstructure:"container_history_limit"`
+
+	// MetricsEnabled serves Prometheus metrics at /metrics (default: true)
+	MetricsEnabled bool `mapstructure:"metrics_enabled"`
+
+	// MetricsRequireAuth requires read access for /metrics (default: false)
+	MetricsRequireAuth bool `mapstructure:"metrics_require_auth"`
+
+	// MetricsRefreshInterval is how often the container, host, stack, task
+	// and deployment gauges are recounted (default: 30s)
+	MetricsRefreshInterval time.Duration `mapstructure:"metrics_refresh_interval"`
 }
 
 // CouchDBConfig contains CouchDB connection settings.
@@ -128,14 +221,109 @@ type AgentConfig struct {
 	// Datacenter is the datacenter/location identifier
 	Datacenter string `mapstructure:"datacenter"`
 
-	// SyncInterval is the duration between container syncs
+	// SyncInterval is the duration between full container syncs and metrics
+	// reports (default: 30s). 0 disables periodic full syncs, leaving
+	// event-driven updates; otherwise it must be at least 1s.
 	SyncInterval time.Duration `mapstructure:"sync_interval"`
 
 	// DockerSocket is the path to the Docker socket
 	DockerSocket string `mapstructure:"docker_socket"`
 
+	// DockerTLSCA, DockerTLSCert and DockerTLSKey are the CA, client
+	// certificate and key for a tcp:// Docker daemon (the ca.pem, cert.pem
+	// and key.pem of the Docker CLI). Unset, the agent uses the files in
+	// DOCKER_CERT_PATH if set.
+	DockerTLSCA   string `mapstructure:"docker_tls_ca"`
+	DockerTLSCert string `mapstructure:"docker_tls_cert"`
+	DockerTLSKey  string `mapstructure:"docker_tls_key"`
+
 	// AgentToken is the JWT token for agent authentication
 	AgentToken string `mapstructure:"agent_token"`
+
+	// HTTPAuthToken is a shared bearer token required by the agent HTTP server on
+	// all routes except /health, /healthz and /metrics. The server sends it
+	// when calling agents.
+	HTTPAuthToken string `mapstructure:"http_auth_token"`
+
+	// HTTPTLSCert is the certificate file that enables HTTPS on the agent HTTP server
+	HTTPTLSCert string `mapstructure:"http_tls_cert"`
+
+	// HTTPTLSKey is the private key file for HTTPTLSCert
+	HTTPTLSKey string `mapstructure:"http_tls_key"`
+
+	// HTTPTLSClientCA is a CA bundle; when set, callers need a client certificate it signed
+	HTTPTLSClientCA string `mapstructure:"http_tls_client_ca"`
+
+	// HTTPBindAddress is the host the agent HTTP server listens on.
+	// Defaults to 127.0.0.1 without auth and all interfaces with auth.
+	HTTPBindAddress string `mapstructure:"http_bind_address"`
+
+	// MetricsAddress is a separate host:port serving the agent's unauthenticated
+	// /metrics and /healthz, e.g. 127.0.0.1:9101 to keep them local. Empty
+	// serves them on the agent HTTP server (http_port).
+	MetricsAddress string `mapstructure:"metrics_address"`
+
+	// LogFormat is the format of the agent's log output: "text" (default,
+	// for local development) or "json" (one object per line, for log
+	// aggregators)
+	LogFormat string `mapstructure:"log_format"`
+
+	// ShipLogs sends the agent's own recent log output (with secrets
+	// redacted) to the server, readable via GET /api/v1/agents/:id/logs
+	ShipLogs bool `mapstructure:"ship_logs"`
+
+	// TaskConcurrency is how many tasks the agent runs in parallel. Slow
+	// tasks (deploys, deletes, workflows) leave one slot free for checks
+	// and control tasks.
+	TaskConcurrency int `mapstructure:"task_concurrency"`
+
+	// TaskPush makes the agent receive task notifications over a WebSocket
+	// instead of waiting for its next poll (default: true). False keeps
+	// polling every 5 seconds only.
+	TaskPush bool `mapstructure:"task_push"`
+
+	// DrainTimeout is how long a stopping agent waits for running tasks to
+	// finish (default: 1m; 0 doesn't wait). Tasks still running then are
+	// reported as interrupted, so the server queues them again.
+	DrainTimeout time.Duration `mapstructure:"drain_timeout"`
+
+	// SyncBatchSize is how many containers a full sync sends per bulk
+	// request (default: 50). 0 syncs containers one request at a time.
+	SyncBatchSize int `mapstructure:"sync_batch_size"`
+
+	// RetryBaseDelay is how long the agent waits before retrying a request
+	// the API server didn't answer or answered 502, 503 or 504 (default:
+	// 500ms). The wait doubles per retry up to RetryMaxDelay (default: 10s).
+	RetryBaseDelay time.Duration `mapstructure:"retry_base_delay"`
+	RetryMaxDelay  time.Duration `mapstructure:"retry_max_delay"`
+
+	// RetryMaxAttempts is how often such a request is sent at most
+	// (default: 4; 1 doesn't retry)
+	RetryMaxAttempts int `mapstructure:"retry_max_attempts"`
+
+	// StateFile is where the agent keeps the content hashes of the
+	// containers it synced, so a restart doesn't resend unchanged containers
+	// (default: /var/lib/graphium/agent-state.json). Empty keeps them in
+	// memory only.
+	StateFile string `mapstructure:"state_file"`
+
+	// BackupDir is where backup-volume tasks write volume archives
+	// (default: /var/lib/graphium/backups). A destination a task names must
+	// lie within it.
+	BackupDir string `mapstructure:"backup_dir"`
+
+	// AllowExec lets exec tasks run commands inside containers on this host,
+	// as far as the server's exec policy allows them; false refuses them
+	// whatever the server allows (default: true)
+	AllowExec bool `mapstructure:"allow_exec"`
+
+	// DiscoveryLabels are Docker labels ("key" or "key=value") a container
+	// must all have to be tracked; empty tracks all containers
+	DiscoveryLabels []string `mapstructure:"discovery_labels"`
+
+	// DiscoveryExcludeLabels are Docker labels that keep a container from
+	// being tracked
+	DiscoveryExcludeLabels []string `mapstructure:"discovery_exclude_labels"`
 }
 
 // AgentsManagerConfig contains configuration for the agent manager.
@@ -183,7 +371,8 @@ type SecurityConfig struct {
 	// JWTSecret is the secret key for signing JWT tokens
 	JWTSecret string `mapstructure:"jwt_secret"`
 
-	// JWTExpiration is the JWT token expiration duration (default: 24h)
+	// JWTExpiration is the lifetime of user access tokens issued at login and
+	// refresh (default: 24h). It must not exceed RefreshTokenExpiration.
 	JWTExpiration time.Duration `mapstructure:"jwt_expiration"`
 
 	// RefreshTokenExpiration is the refresh token expiration duration (default: 7 days)
@@ -191,6 +380,179 @@ type SecurityConfig struct {
 
 	// AgentTokenSecret is the secret key for agent authentication tokens
 	AgentTokenSecret string `mapstructure:"agent_token_secret"`
+
+	// AgentTokenExpiration is the lifetime of generated agent tokens (default: 168h = 7 days)
+	AgentTokenExpiration time.Duration `mapstructure:"agent_token_expiration"`
+
+	// ShareLinkDefaultTTL is the lifetime of a read-only share link when none is requested (default: 1h)
+	ShareLinkDefaultTTL time.Duration `mapstructure:"share_link_default_ttl"`
+
+	// ShareLinkMaxTTL is the longest lifetime a share link may be created with (default: 24h)
+	ShareLinkMaxTTL time.Duration `mapstructure:"share_link_max_ttl"`
+
+	// SecretsDir is a directory holding one file per secret, referenced by
+	// stack file mounts. Secret file mounts are rejected when empty.
+	SecretsDir string `mapstructure:"secrets_dir"`
+}
+
+// ImageUpdatesConfig contains settings for the image update checker.
+// The checker compares the digest of each running container's image with the
+// current digest of its tag in the registry.
+type ImageUpdatesConfig struct {
+	// Enabled turns on the background image update checker (default: false)
+	Enabled bool `mapstructure:"enabled"`
+
+	// CheckInterval is how often all tracked images are checked (default: 6h)
+	CheckInterval time.Duration `mapstructure:"check_interval"`
+
+	// RequestInterval is the minimum delay between registry requests, to stay
+	// within registry rate limits (default: 2s)
+	RequestInterval time.Duration `mapstructure:"request_interval"`
+
+	// NotifyWebhook is an optional URL that receives a JSON POST when an update
+	// becomes available for a container
+	NotifyWebhook string `mapstructure:"notify_webhook"`
+}
+
+// DeployConfig lists containers Graphium must never delete, stop, control
+// or replace, e.g. production-critical infrastructure, and the global
+// deployment defaults. Patterns use path.Match glob syntax.
+type DeployConfig struct {
+	// ProtectedNamePatterns are glob patterns matched against container names
+	ProtectedNamePatterns []string `mapstructure:"protected_name_patterns"`
+
+	// ProtectedImages are image references or glob patterns; an entry without
+	// a tag protects every tag of the image
+	ProtectedImages []string `mapstructure:"protected_images"`
+
+	// PlacementStrategy is how stack containers without an assigned host are
+	// placed (first-fit, spread, binpack; default: first-fit). Datacenter
+	// policies override it per datacenter
+	PlacementStrategy string `mapstructure:"placement_strategy"`
+
+	// WaveConcurrency is how many containers of a deployment wave are created
+	// and started at once; waves still deploy one after another (default: 4)
+	WaveConcurrency int `mapstructure:"wave_concurrency"`
+}
+
+// ExecConfig decides which commands exec tasks (ControlAction "exec" and
+// workflow "container-exec" steps) may run inside containers. Exec is off
+// unless enabled, and then only allowed for commands the allowlist lists
+// for the target container.
+type ExecConfig struct {
+	// Enabled allows exec tasks at all (default: false)
+	Enabled bool `mapstructure:"enabled"`
+
+	// Allowlist are the allowed commands per container
+	Allowlist []ExecRule `mapstructure:"allowlist"`
+}
+
+// ExecRule allows commands in the containers matching all of its
+// container patterns and labels. Names and labels are the ones agents
+// report from Docker, not ones edited through the API.
+type ExecRule struct {
+	// Containers are glob patterns (path.Match syntax) matched against
+	// Docker container names
+	Containers []string `mapstructure:"containers"`
+
+	// Labels are Docker labels ("key" or "key=value") a container must all have.
+	// A list rather than a map, as viper lowercases map keys
+	Labels []string `mapstructure:"labels"`
+
+	// Commands are the allowed command lines, matched exactly: a command
+	// given as an array must have the line's words as arguments
+	Commands []string `mapstructure:"commands"`
+
+	// User is the user the commands must run as (default: the
+	// container's user)
+	User string `mapstructure:"user"`
+
+	// AllowEnv allows tasks to set environment variables of the commands
+	AllowEnv bool `mapstructure:"allow_env"`
+}
+
+// GraphConfig registers custom JSON-LD types whose entities appear in the
+// graph next to containers and hosts. Types can also be registered through
+// the API (POST /api/v1/custom-types).
+type GraphConfig struct {
+	// CustomTypes are the custom types and their edge rules
+	CustomTypes []CustomTypeConfig `mapstructure:"custom_types"`
+
+	// IncludeOrphans shows containers no stack lists in the graph unless a
+	// request sets orphans=false (default: true)
+	IncludeOrphans bool `mapstructure:"include_orphans"`
+}
+
+// CustomTypeConfig registers a custom JSON-LD type.
+type CustomTypeConfig struct {
+	// Name is the @type of the entities, e.g. Database or ex:LoadBalancer
+	Name string `mapstructure:"name"`
+
+	// Vocabulary is the @context of the entities (default: https://schema.org)
+	Vocabulary string `mapstructure:"vocabulary"`
+
+	// Description describes what the entities model
+	Description string `mapstructure:"description"`
+
+	// Edges derive graph edges from entity properties
+	Edges []EdgeRuleConfig `mapstructure:"edges"`
+}
+
+// EdgeRuleConfig derives graph edges from an entity property holding IDs.
+type EdgeRuleConfig struct {
+	// Property is the entity property holding the target ID(s)
+	Property string `mapstructure:"property"`
+
+	// Relation is the edge type (default: the property name)
+	Relation string `mapstructure:"relation"`
+
+	// Target is container, host or the name of a custom type
+	Target string `mapstructure:"target"`
+}
+
+// NotificationsConfig configures where scheduled actions whose notifyOn
+// matches a run send their notifications.
+type NotificationsConfig struct {
+	// WebhookURL receives a JSON POST for every notification (default: none)
+	WebhookURL string `mapstructure:"webhook_url"`
+
+	// SMTP sends notifications by email
+	SMTP SMTPConfig `mapstructure:"smtp"`
+}
+
+// SMTPConfig contains the mail server notifications are sent through.
+type SMTPConfig struct {
+	// Host is the mail server; empty disables email (default: none)
+	Host string `mapstructure:"host"`
+
+	// Port is the mail server port (default: 587)
+	Port int `mapstructure:"port"`
+
+	// Username and Password authenticate with the mail server, if set
+	Username string `mapstructure:"username"`
+	Password string `mapstructure:"password"`
+
+	// From is the sender address
+	From string `mapstructure:"from"`
+
+	// To are the recipient addresses
+	To []string `mapstructure:"to"`
+}
+
+// RegistryCredentials contains the credentials for a container registry.
+type RegistryCredentials struct {
+	// Host is the registry host (e.g. ghcr.io, registry.example.com:5000, docker.io)
+	Host string `mapstructure:"host"`
+
+	// Username for registry authentication
+	Username string `mapstructure:"username"`
+
+	// Password or access token for registry authentication
+	Password string `mapstructure:"password"`
+
+	// IdentityToken is an OAuth identity token deployments pull images
+	// with instead of a password
+	IdentityToken string `mapstructure:"identity_token"`
 }
 
 var cfg *Config
@@ -264,6 +626,24 @@ func setDefaults(v *viper.Viper) {
 	v.SetDefault("server.shutdown_timeout", "10s")
 	v.SetDefault("server.debug", false)
 	v.SetDefault("server.tls_enabled", false)
+	v.SetDefault("server.max_graph_depth", 50)
+	v.SetDefault("server.websocket_batch_window", "100ms")
+	v.SetDefault("server.websocket_batch_size", 100)
+	v.SetDefault("server.auto_assign_stacks", true)
+	v.SetDefault("server.watch_default_duration", "30m")
+	v.SetDefault("server.watch_max_duration", "24h")
+	v.SetDefault("server.watch_max_per_user", 20)
+	v.SetDefault("server.watch_webhook_allowlist", []string{})
+	v.SetDefault("server.allow_overcommit", false)
+	v.SetDefault("server.stack_reconcile_interval", "30s")
+	v.SetDefault("server.log_download_max_bytes", 100*1024*1024)
+	v.SetDefault("server.log_stream_idle_timeout", "5m")
+	v.SetDefault("server.container_dedupe_interval", "0s")
+	v.SetDefault("server.container_tombstone_ttl", "0s")
+	v.SetDefault("server.container_history_limit", 100)
+	v.SetDefault("server.metrics_enabled", true)
+	v.SetDefault("server.metrics_require_auth", false)
+	v.SetDefault("server.metrics_refresh_interval", "30s")
 
 	v.SetDefault("couchdb.url", "http://localhost:5984")
 	v.SetDefault("couchdb.database", "graphium")
@@ -276,6 +656,26 @@ func setDefaults(v *viper.Viper) {
 	v.SetDefault("agent.api_url", "http://localhost:8080")
 	v.SetDefault("agent.sync_interval", "30s")
 	v.SetDefault("agent.docker_socket", "/var/run/docker.sock")
+	v.SetDefault("agent.docker_tls_ca", "")
+	v.SetDefault("agent.docker_tls_cert", "")
+	v.SetDefault("agent.docker_tls_key", "")
+	v.SetDefault("agent.http_auth_token", "")
+	v.SetDefault("agent.http_bind_address", "")
+	v.SetDefault("agent.metrics_address", "")
+	v.SetDefault("agent.log_format", "text")
+	v.SetDefault("agent.ship_logs", false)
+	v.SetDefault("agent.task_concurrency", 4)
+	v.SetDefault("agent.task_push", true)
+	v.SetDefault("agent.drain_timeout", "1m")
+	v.SetDefault("agent.sync_batch_size", 50)
+	v.SetDefault("agent.retry_base_delay", "500ms")
+	v.SetDefault("agent.retry_max_delay", "10s")
+	v.SetDefault("agent.retry_max_attempts", 4)
+	v.SetDefault("agent.state_file", "/var/lib/graphium/agent-state.json")
+	v.SetDefault("agent.backup_dir", "/var/lib/graphium/backups")
+	v.SetDefault("agent.allow_exec", true)
+	v.SetDefault("agent.discovery_labels", []string{})
+	v.SetDefault("agent.discovery_exclude_labels", []string{})
 
 	v.SetDefault("agents.logs_path", "./logs")
 
@@ -293,6 +693,28 @@ func setDefaults(v *viper.Viper) {
 	v.SetDefault("security.jwt_expiration", "24h")
 	v.SetDefault("security.refresh_token_expiration", "168h") // 7 days
 	v.SetDefault("security.agent_token_secret", "change-me-in-production")
+	v.SetDefault("security.agent_token_expiration", "168h") // 7 days
+	v.SetDefault("security.share_link_default_ttl", "1h")
+	v.SetDefault("security.share_link_max_ttl", "24h")
+	v.SetDefault("security.secrets_dir", "")
+
+	v.SetDefault("image_updates.enabled", false)
+	v.SetDefault("image_updates.check_interval", "6h")
+	v.SetDefault("image_updates.request_interval", "2s")
+
+	v.SetDefault("deploy.protected_name_patterns", []string{})
+	v.SetDefault("deploy.protected_images", []string{})
+	v.SetDefault("deploy.placement_strategy", "first-fit")
+	v.SetDefault("deploy.wave_concurrency", 4)
+	v.SetDefault("registries", []RegistryCredentials{})
+	v.SetDefault("exec.enabled", false)
+	v.SetDefault("exec.allowlist", []ExecRule{})
+	v.SetDefault("graph.include_orphans", true)
+
+	v.SetDefault("notifications.webhook_url", "")
+	v.SetDefault("notifications.smtp.host", "")
+	v.SetDefault("notifications.smtp.port", 587)
+	v.SetDefault("notifications.smtp.to", []string{})
 }
 
 func validate(cfg *Config) error {
@@ -308,6 +730,107 @@ func validate(cfg *Config) error {
 		return fmt.Errorf("couchdb database is required")
 	}
 
+	sec := cfg.Security
+	if sec.JWTExpiration < 0 || sec.RefreshTokenExpiration < 0 {
+		return fmt.Errorf("token expirations must not be negative")
+	}
+	if sec.AgentTokenExpiration <= 0 {
+		return fmt.Errorf("agent_token_expiration must be positive, got %s", sec.AgentTokenExpiration)
+	}
+	if sec.JWTExpiration > 0 && sec.RefreshTokenExpiration > 0 && sec.JWTExpiration > sec.RefreshTokenExpiration {
+		return fmt.Errorf("jwt_expiration (%s) must not exceed refresh_token_expiration (%s)",
+			sec.JWTExpiration, sec.RefreshTokenExpiration)
+	}
+
+	for _, pattern := range append(append([]string{}, cfg.Deploy.ProtectedNamePatterns...), cfg.Deploy.ProtectedImages...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid deploy protection pattern %q: %w", pattern, err)
+		}
+	}
+
+	if cfg.Agent.SyncInterval < 0 || (cfg.Agent.SyncInterval > 0 && cfg.Agent.SyncInterval < time.Second) {
+		return fmt.Errorf("invalid agent sync_interval %s (expected 0 to disable or at least 1s)", cfg.Agent.SyncInterval)
+	}
+	if cfg.Agent.MetricsAddress != "" {
+		if _, _, err := net.SplitHostPort(cfg.Agent.MetricsAddress); err != nil {
+			return fmt.Errorf("invalid agent metrics_address %q (expected host:port): %w", cfg.Agent.MetricsAddress, err)
+		}
+	}
+	switch cfg.Agent.LogFormat {
+	case "", "text", "json":
+	default:
+		return fmt.Errorf("invalid agent log_format %q (expected text or json)", cfg.Agent.LogFormat)
+	}
+	if cfg.Agent.DrainTimeout < 0 {
+		return fmt.Errorf("invalid agent drain_timeout %s (expected 0 or more)", cfg.Agent.DrainTimeout)
+	}
+	if cfg.Agent.SyncBatchSize < 0 {
+		return fmt.Errorf("invalid agent sync_batch_size %d (expected 0 to disable or a positive size)", cfg.Agent.SyncBatchSize)
+	}
+	if cfg.Agent.RetryBaseDelay < 0 || cfg.Agent.RetryMaxDelay < 0 {
+		return fmt.Errorf("invalid agent retry delays %s/%s (expected 0 or more)", cfg.Agent.RetryBaseDelay, cfg.Agent.RetryMaxDelay)
+	}
+	if cfg.Agent.RetryMaxAttempts < 0 {
+		return fmt.Errorf("invalid agent retry_max_attempts %d (expected 1 or more)", cfg.Agent.RetryMaxAttempts)
+	}
+	if (cfg.Agent.DockerTLSCert == "") != (cfg.Agent.DockerTLSKey == "") {
+		return fmt.Errorf("agent docker_tls_cert and docker_tls_key must be set together")
+	}
+	for _, label := range append(append([]string{}, cfg.Agent.DiscoveryLabels...), cfg.Agent.DiscoveryExcludeLabels...) {
+		if key, _, _ := strings.Cut(label, "="); strings.TrimSpace(key) == "" {
+			return fmt.Errorf("invalid agent discovery label %q (expected key or key=value)", label)
+		}
+	}
+
+	switch cfg.Deploy.PlacementStrategy {
+	case "", "first-fit", "spread", "binpack":
+	default:
+		return fmt.Errorf("invalid deploy placement_strategy %q (expected first-fit, spread or binpack)", cfg.Deploy.PlacementStrategy)
+	}
+
+	if cfg.Deploy.WaveConcurrency < 0 {
+		return fmt.Errorf("invalid deploy wave_concurrency %d (must not be negative)", cfg.Deploy.WaveConcurrency)
+	}
+
+	for i, credential := range cfg.Registries {
+		if credential.Host == "" {
+			return fmt.Errorf("invalid registries entry %d: host is required", i)
+		}
+	}
+
+	for i, rule := range cfg.Exec.Allowlist {
+		if len(rule.Containers) == 0 && len(rule.Labels) == 0 {
+			return fmt.Errorf("invalid exec allowlist entry %d: containers or labels are required", i)
+		}
+		if len(rule.Commands) == 0 {
+			return fmt.Errorf("invalid exec allowlist entry %d: commands are required", i)
+		}
+		for _, pattern := range rule.Containers {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return fmt.Errorf("invalid exec allowlist entry %d: container pattern %q: %w", i, pattern, err)
+			}
+		}
+		for _, label := range rule.Labels {
+			if key, _, _ := strings.Cut(label, "="); strings.TrimSpace(key) == "" {
+				return fmt.Errorf("invalid exec allowlist entry %d: label %q (expected key or key=value)", i, label)
+			}
+		}
+	}
+
+	if webhook := cfg.Notifications.WebhookURL; webhook != "" {
+		if parsed, err := url.Parse(webhook); err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return fmt.Errorf("invalid notifications webhook_url %q (expected an absolute http or https URL)", webhook)
+		}
+	}
+	if smtp := cfg.Notifications.SMTP; smtp.Host != "" {
+		if smtp.Port < 1 || smtp.Port > 65535 {
+			return fmt.Errorf("invalid notifications smtp port: %d", smtp.Port)
+		}
+		if smtp.From == "" || len(smtp.To) == 0 {
+			return fmt.Errorf("notifications smtp from and to are required when smtp host is set")
+		}
+	}
+
 	return nil
 }
 
@@ -36,6 +36,75 @@ func TestLoadDefaults(t *testing.T) {
 	if cfg.Server.TLSEnabled != false {
 		t.Errorf("Expected default tls_enabled false, got %v", cfg.Server.TLSEnabled)
 	}
+	if cfg.Server.MaxGraphDepth != 50 {
+		t.Errorf("Expected default max graph depth 50, got %d", cfg.Server.MaxGraphDepth)
+	}
+	if cfg.Server.WebSocketBatchWindow != 100*time.Millisecond {
+		t.Errorf("Expected default websocket batch window 100ms, got %v", cfg.Server.WebSocketBatchWindow)
+	}
+	if cfg.Server.WebSocketBatchSize != 100 {
+		t.Errorf("Expected default websocket batch size 100, got %d", cfg.Server.WebSocketBatchSize)
+	}
+	if !cfg.Server.AutoAssignStacks {
+		t.Error("Expected auto_assign_stacks to default to true")
+	}
+	if cfg.Server.WatchDefaultDuration != 30*time.Minute {
+		t.Errorf("Expected default watch duration 30m, got %v", cfg.Server.WatchDefaultDuration)
+	}
+	if cfg.Server.WatchMaxDuration != 24*time.Hour {
+		t.Errorf("Expected max watch duration 24h, got %v", cfg.Server.WatchMaxDuration)
+	}
+	if cfg.Server.WatchMaxPerUser != 20 {
+		t.Errorf("Expected max watches per user 20, got %d", cfg.Server.WatchMaxPerUser)
+	}
+	if len(cfg.Server.WatchWebhookAllowlist) != 0 {
+		t.Errorf("Expected no watch webhook allowlist, got %v", cfg.Server.WatchWebhookAllowlist)
+	}
+	if cfg.Server.AllowOvercommit {
+		t.Error("Expected allow_overcommit to default to false")
+	}
+	if cfg.Server.StackReconcileInterval != 30*time.Second {
+		t.Errorf("Expected stack reconcile interval 30s, got %v", cfg.Server.StackReconcileInterval)
+	}
+	if cfg.Server.LogDownloadMaxBytes != 100*1024*1024 {
+		t.Errorf("Expected log download limit 100 MiB, got %d", cfg.Server.LogDownloadMaxBytes)
+	}
+	if cfg.Server.LogStreamIdleTimeout != 5*time.Minute {
+		t.Errorf("Expected log stream idle timeout 5m, got %v", cfg.Server.LogStreamIdleTimeout)
+	}
+	if cfg.Server.ContainerDedupeInterval != 0 {
+		t.Errorf("Expected container deduplication to be disabled by default, got %v", cfg.Server.ContainerDedupeInterval)
+	}
+	if cfg.Server.ContainerTombstoneTTL != 0 {
+		t.Errorf("Expected container tombstones not to expire by default, got %v", cfg.Server.ContainerTombstoneTTL)
+	}
+	if cfg.Server.ContainerHistoryLimit != 100 {
+		t.Errorf("Expected container history limit 100, got %d", cfg.Server.ContainerHistoryLimit)
+	}
+	if !cfg.Server.MetricsEnabled || cfg.Server.MetricsRequireAuth {
+		t.Errorf("Expected unauthenticated metrics enabled by default, got enabled=%v require_auth=%v", cfg.Server.MetricsEnabled, cfg.Server.MetricsRequireAuth)
+	}
+	if cfg.Server.MetricsRefreshInterval != 30*time.Second {
+		t.Errorf("Expected metrics refresh interval 30s, got %v", cfg.Server.MetricsRefreshInterval)
+	}
+	if len(cfg.Deploy.ProtectedNamePatterns) != 0 || len(cfg.Deploy.ProtectedImages) != 0 {
+		t.Errorf("Expected no protected containers by default, got %+v", cfg.Deploy)
+	}
+	if cfg.Deploy.PlacementStrategy != "first-fit" {
+		t.Errorf("Expected default placement strategy 'first-fit', got '%s'", cfg.Deploy.PlacementStrategy)
+	}
+	if cfg.Deploy.WaveConcurrency != 4 {
+		t.Errorf("Expected default wave concurrency 4, got %d", cfg.Deploy.WaveConcurrency)
+	}
+	if len(cfg.Registries) != 0 {
+		t.Errorf("Expected no registry credentials by default, got %v", cfg.Registries)
+	}
+	if len(cfg.Graph.CustomTypes) != 0 {
+		t.Errorf("Expected no custom types by default, got %d", len(cfg.Graph.CustomTypes))
+	}
+	if !cfg.Graph.IncludeOrphans {
+		t.Error("Expected orphaned containers to be included in the graph by default")
+	}
 
 	// Test CouchDB defaults
 	if cfg.CouchDB.URL != "http://localhost:5984" {
@@ -70,6 +139,42 @@ func TestLoadDefaults(t *testing.T) {
 	if cfg.Agent.DockerSocket != "/var/run/docker.sock" {
 		t.Errorf("Expected default docker socket '/var/run/docker.sock', got '%s'", cfg.Agent.DockerSocket)
 	}
+	if cfg.Agent.HTTPAuthToken != "" {
+		t.Errorf("Expected empty default agent HTTP auth token, got '%s'", cfg.Agent.HTTPAuthToken)
+	}
+	if cfg.Agent.ShipLogs {
+		t.Error("Expected agent log shipping to be disabled by default")
+	}
+	if cfg.Agent.TaskConcurrency != 4 {
+		t.Errorf("Expected default agent task concurrency 4, got %d", cfg.Agent.TaskConcurrency)
+	}
+	if !cfg.Agent.TaskPush {
+		t.Error("Expected agent task push to be enabled by default")
+	}
+	if cfg.Agent.LogFormat != "text" {
+		t.Errorf("Expected default agent log format text, got %s", cfg.Agent.LogFormat)
+	}
+	if cfg.Agent.DrainTimeout != time.Minute {
+		t.Errorf("Expected default agent drain timeout 1m, got %v", cfg.Agent.DrainTimeout)
+	}
+	if cfg.Agent.SyncBatchSize != 50 {
+		t.Errorf("Expected default agent sync batch size 50, got %d", cfg.Agent.SyncBatchSize)
+	}
+	if cfg.Agent.RetryBaseDelay != 500*time.Millisecond || cfg.Agent.RetryMaxDelay != 10*time.Second || cfg.Agent.RetryMaxAttempts != 4 {
+		t.Errorf("Expected default agent retries 500ms/10s/4, got %v/%v/%d", cfg.Agent.RetryBaseDelay, cfg.Agent.RetryMaxDelay, cfg.Agent.RetryMaxAttempts)
+	}
+	if cfg.Agent.StateFile != "/var/lib/graphium/agent-state.json" {
+		t.Errorf("Expected default agent state file /var/lib/graphium/agent-state.json, got %s", cfg.Agent.StateFile)
+	}
+	if cfg.Agent.BackupDir != "/var/lib/graphium/backups" {
+		t.Errorf("Expected default agent backup dir /var/lib/graphium/backups, got %s", cfg.Agent.BackupDir)
+	}
+	if !cfg.Agent.AllowExec {
+		t.Error("Expected agents to allow exec by default, as far as the server's exec policy does")
+	}
+	if len(cfg.Agent.DiscoveryLabels) != 0 || len(cfg.Agent.DiscoveryExcludeLabels) != 0 {
+		t.Errorf("Expected no default discovery labels, got %v and %v", cfg.Agent.DiscoveryLabels, cfg.Agent.DiscoveryExcludeLabels)
+	}
 
 	// Test Logging defaults
 	if cfg.Logging.Level != "info" {
@@ -110,9 +215,45 @@ func TestLoadDefaults(t *testing.T) {
 	if cfg.Security.RefreshTokenExpiration != 168*time.Hour {
 		t.Errorf("Expected default refresh token expiration 168h, got %v", cfg.Security.RefreshTokenExpiration)
 	}
+	if cfg.Security.AgentTokenExpiration != 168*time.Hour {
+		t.Errorf("Expected default agent token expiration 168h, got %v", cfg.Security.AgentTokenExpiration)
+	}
 	if cfg.Security.AgentTokenSecret != "change-me-in-production" {
 		t.Errorf("Expected default agent_token_secret 'change-me-in-production', got '%s'", cfg.Security.AgentTokenSecret)
 	}
+	if cfg.Security.ShareLinkDefaultTTL != time.Hour {
+		t.Errorf("Expected default share link TTL 1h, got %v", cfg.Security.ShareLinkDefaultTTL)
+	}
+	if cfg.Security.ShareLinkMaxTTL != 24*time.Hour {
+		t.Errorf("Expected default share link max TTL 24h, got %v", cfg.Security.ShareLinkMaxTTL)
+	}
+	if cfg.Security.SecretsDir != "" {
+		t.Errorf("Expected empty default secrets dir, got %s", cfg.Security.SecretsDir)
+	}
+
+	// Test ImageUpdates defaults
+	if cfg.ImageUpdates.Enabled {
+		t.Error("Expected image update checker to be disabled by default")
+	}
+	if cfg.ImageUpdates.CheckInterval != 6*time.Hour {
+		t.Errorf("Expected default image check interval 6h, got %v", cfg.ImageUpdates.CheckInterval)
+	}
+	if cfg.ImageUpdates.RequestInterval != 2*time.Second {
+		t.Errorf("Expected default registry request interval 2s, got %v", cfg.ImageUpdates.RequestInterval)
+	}
+
+	// Test Exec defaults
+	if cfg.Exec.Enabled || len(cfg.Exec.Allowlist) != 0 {
+		t.Errorf("Expected exec to be disabled by default, got %+v", cfg.Exec)
+	}
+
+	// Test Notifications defaults
+	if cfg.Notifications.WebhookURL != "" || cfg.Notifications.SMTP.Host != "" {
+		t.Errorf("Expected notifications to be disabled by default, got %+v", cfg.Notifications)
+	}
+	if cfg.Notifications.SMTP.Port != 587 {
+		t.Errorf("Expected default SMTP port 587, got %d", cfg.Notifications.SMTP.Port)
+	}
 }
 
 // TestValidation tests the configuration validation logic.
@@ -192,10 +333,321 @@ func TestValidation(t *testing.T) {
 			expectErr: true,
 			errMsg:    "couchdb database is required",
 		},
+		{
+			name: "access token outlives refresh token",
+			cfg: &Config{
+				Server: ServerConfig{
+					Port: 8080,
+				},
+				CouchDB: CouchDBConfig{
+					URL:      "http://localhost:5984",
+					Database: "graphium",
+				},
+				Security: SecurityConfig{
+					JWTExpiration:          48 * time.Hour,
+					RefreshTokenExpiration: 24 * time.Hour,
+				},
+			},
+			expectErr: true,
+			errMsg:    "must not exceed refresh_token_expiration",
+		},
+		{
+			name: "negative agent token expiration",
+			cfg: &Config{
+				Server: ServerConfig{
+					Port: 8080,
+				},
+				CouchDB: CouchDBConfig{
+					URL:      "http://localhost:5984",
+					Database: "graphium",
+				},
+				Security: SecurityConfig{
+					AgentTokenExpiration: -time.Hour,
+				},
+			},
+			expectErr: true,
+			errMsg:    "agent_token_expiration must be positive",
+		},
+		{
+			name: "malformed protection pattern",
+			cfg: &Config{
+				Server: ServerConfig{
+					Port: 8080,
+				},
+				CouchDB: CouchDBConfig{
+					URL:      "http://localhost:5984",
+					Database: "graphium",
+				},
+				Deploy: DeployConfig{
+					ProtectedNamePatterns: []string{"prod-[db"},
+				},
+			},
+			expectErr: true,
+			errMsg:    "invalid deploy protection pattern",
+		},
+		{
+			name: "unknown placement strategy",
+			cfg: &Config{
+				Server: ServerConfig{
+					Port: 8080,
+				},
+				CouchDB: CouchDBConfig{
+					URL:      "http://localhost:5984",
+					Database: "graphium",
+				},
+				Deploy: DeployConfig{
+					PlacementStrategy: "random",
+				},
+			},
+			expectErr: true,
+			errMsg:    "invalid deploy placement_strategy",
+		},
+		{
+			name: "negative wave concurrency",
+			cfg: &Config{
+				Server: ServerConfig{
+					Port: 8080,
+				},
+				CouchDB: CouchDBConfig{
+					URL:      "http://localhost:5984",
+					Database: "graphium",
+				},
+				Deploy: DeployConfig{
+					WaveConcurrency: -1,
+				},
+			},
+			expectErr: true,
+			errMsg:    "invalid deploy wave_concurrency",
+		},
+		{
+			name: "registry credential without host",
+			cfg: &Config{
+				Server: ServerConfig{
+					Port: 8080,
+				},
+				CouchDB: CouchDBConfig{
+					URL:      "http://localhost:5984",
+					Database: "graphium",
+				},
+				Registries: []RegistryCredentials{{Username: "deploy-bot", Password: "secret"}},
+			},
+			expectErr: true,
+			errMsg:    "invalid registries entry 0",
+		},
+		{
+			name: "exec allowlist entry without containers",
+			cfg: &Config{
+				Server: ServerConfig{
+					Port: 8080,
+				},
+				CouchDB: CouchDBConfig{
+					URL:      "http://localhost:5984",
+					Database: "graphium",
+				},
+				Exec: ExecConfig{
+					Enabled:   true,
+					Allowlist: []ExecRule{{Commands: []string{"redis-cli FLUSHALL"}}},
+				},
+			},
+			expectErr: true,
+			errMsg:    "invalid exec allowlist entry 0: containers or labels are required",
+		},
+		{
+			name: "relative notifications webhook",
+			cfg: &Config{
+				Server: ServerConfig{
+					Port: 8080,
+				},
+				CouchDB: CouchDBConfig{
+					URL:      "http://localhost:5984",
+					Database: "graphium",
+				},
+				Notifications: NotificationsConfig{
+					WebhookURL: "/hooks/graphium",
+				},
+			},
+			expectErr: true,
+			errMsg:    "invalid notifications webhook_url",
+		},
+		{
+			name: "smtp without recipients",
+			cfg: &Config{
+				Server: ServerConfig{
+					Port: 8080,
+				},
+				CouchDB: CouchDBConfig{
+					URL:      "http://localhost:5984",
+					Database: "graphium",
+				},
+				Notifications: NotificationsConfig{
+					SMTP: SMTPConfig{Host: "smtp.example.com", Port: 587, From: "graphium@example.com"},
+				},
+			},
+			expectErr: true,
+			errMsg:    "notifications smtp from and to are required",
+		},
+		{
+			name: "sub-second agent sync interval",
+			cfg: &Config{
+				Server: ServerConfig{
+					Port: 8080,
+				},
+				CouchDB: CouchDBConfig{
+					URL:      "http://localhost:5984",
+					Database: "graphium",
+				},
+				Agent: AgentConfig{
+					SyncInterval: 500 * time.Millisecond,
+				},
+			},
+			expectErr: true,
+			errMsg:    "invalid agent sync_interval",
+		},
+		{
+			name: "disabled agent sync interval",
+			cfg: &Config{
+				Server: ServerConfig{
+					Port: 8080,
+				},
+				CouchDB: CouchDBConfig{
+					URL:      "http://localhost:5984",
+					Database: "graphium",
+				},
+				Agent: AgentConfig{
+					SyncInterval: 0,
+				},
+			},
+			expectErr: false,
+		},
+		{
+			name: "negative agent sync batch size",
+			cfg: &Config{
+				Server: ServerConfig{
+					Port: 8080,
+				},
+				CouchDB: CouchDBConfig{
+					URL:      "http://localhost:5984",
+					Database: "graphium",
+				},
+				Agent: AgentConfig{
+					SyncBatchSize: -1,
+				},
+			},
+			expectErr: true,
+			errMsg:    "invalid agent sync_batch_size",
+		},
+		{
+			name: "negative agent drain timeout",
+			cfg: &Config{
+				Server: ServerConfig{
+					Port: 8080,
+				},
+				CouchDB: CouchDBConfig{
+					URL:      "http://localhost:5984",
+					Database: "graphium",
+				},
+				Agent: AgentConfig{
+					DrainTimeout: -time.Second,
+				},
+			},
+			expectErr: true,
+			errMsg:    "invalid agent drain_timeout",
+		},
+		{
+			name: "negative agent retry attempts",
+			cfg: &Config{
+				Server: ServerConfig{
+					Port: 8080,
+				},
+				CouchDB: CouchDBConfig{
+					URL:      "http://localhost:5984",
+					Database: "graphium",
+				},
+				Agent: AgentConfig{
+					RetryMaxAttempts: -1,
+				},
+			},
+			expectErr: true,
+			errMsg:    "invalid agent retry_max_attempts",
+		},
+		{
+			name: "unknown agent log format",
+			cfg: &Config{
+				Server: ServerConfig{
+					Port: 8080,
+				},
+				CouchDB: CouchDBConfig{
+					URL:      "http://localhost:5984",
+					Database: "graphium",
+				},
+				Agent: AgentConfig{
+					LogFormat: "xml",
+				},
+			},
+			expectErr: true,
+			errMsg:    "invalid agent log_format",
+		},
+		{
+			name: "agent metrics address without port",
+			cfg: &Config{
+				Server: ServerConfig{
+					Port: 8080,
+				},
+				CouchDB: CouchDBConfig{
+					URL:      "http://localhost:5984",
+					Database: "graphium",
+				},
+				Agent: AgentConfig{
+					MetricsAddress: "127.0.0.1",
+				},
+			},
+			expectErr: true,
+			errMsg:    "invalid agent metrics_address",
+		},
+		{
+			name: "agent discovery label without key",
+			cfg: &Config{
+				Server: ServerConfig{
+					Port: 8080,
+				},
+				CouchDB: CouchDBConfig{
+					URL:      "http://localhost:5984",
+					Database: "graphium",
+				},
+				Agent: AgentConfig{
+					DiscoveryLabels:        []string{"graphium.managed=true"},
+					DiscoveryExcludeLabels: []string{"=other"},
+				},
+			},
+			expectErr: true,
+			errMsg:    "invalid agent discovery label",
+		},
+		{
+			name: "agent docker TLS certificate without key",
+			cfg: &Config{
+				Server: ServerConfig{
+					Port: 8080,
+				},
+				CouchDB: CouchDBConfig{
+					URL:      "http://localhost:5984",
+					Database: "graphium",
+				},
+				Agent: AgentConfig{
+					DockerSocket:  "tcp://docker.example.com:2376",
+					DockerTLSCert: "/etc/graphium/docker/cert.pem",
+				},
+			},
+			expectErr: true,
+			errMsg:    "docker_tls_cert and docker_tls_key must be set together",
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
+			// Load always sets the agent token lifetime
+			if tt.cfg.Security.AgentTokenExpiration == 0 {
+				tt.cfg.Security.AgentTokenExpiration = 168 * time.Hour
+			}
 			err := validate(tt.cfg)
 			if tt.expectErr {
 				if err == nil {
@@ -212,6 +664,17 @@ func TestValidation(t *testing.T) {
 	}
 }
 
+func TestValidation_ZeroAgentTokenExpiration(t *testing.T) {
+	cfg := &Config{
+		Server:  ServerConfig{Port: 8080},
+		CouchDB: CouchDBConfig{URL: "http://localhost:5984", Database: "graphium"},
+	}
+	err := validate(cfg)
+	if err == nil || !contains(err.Error(), "agent_token_expiration must be positive") {
+		t.Errorf("Expected a zero agent token expiration to be rejected, got %v", err)
+	}
+}
+
 // TestBuildURL tests the BuildURL method of CouchDBConfig.
 func TestBuildURL(t *testing.T) {
 	tests := []struct {
@@ -0,0 +1,229 @@
+// Package imageupdates detects when a newer image is available for a running
+// container's tag.
+//
+// The agent records the registry digest of each container's image
+// (Container.ImageDigest). The checker periodically resolves the current
+// digest of each tracked tag in its registry and flags containers whose
+// digest differs as UpdateAvailable. Each image is resolved once per run and
+// registry requests are rate-limited (image_updates.request_interval).
+//
+// Example usage:
+//
+//	checker := imageupdates.NewChecker(store, cfg.ImageUpdates, cfg.Registries)
+//	checker.OnUpdateAvailable(func(c *models.Container) { ... })
+//	go checker.Run(ctx)
+package imageupdates
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"evalgo.org/graphium/internal/config"
+	"evalgo.org/graphium/internal/storage"
+	"evalgo.org/graphium/models"
+)
+
+// ErrCheckRunning is returned when a check is started while another is still running.
+var ErrCheckRunning = errors.New("an image update check is already running")
+
+// Report summarizes a single check run.
+type Report struct {
+	StartedAt   time.Time `json:"startedAt"`
+	CompletedAt time.Time `json:"completedAt"`
+
+	// Checked is the number of containers compared against their registry
+	Checked int `json:"checked"`
+
+	// Outdated is the number of containers with an update available
+	Outdated int `json:"outdated"`
+
+	// NewlyOutdated are the containers that became outdated in this run
+	NewlyOutdated []string `json:"newlyOutdated"`
+
+	// Skipped is the number of containers without a digest or pinned to a digest
+	Skipped int `json:"skipped"`
+
+	// Errors maps image references to the error resolving them
+	Errors map[string]string `json:"errors,omitempty"`
+}
+
+// Checker compares running container images with their registries.
+type Checker struct {
+	storage  *storage.Storage
+	registry *RegistryClient
+	cfg      config.ImageUpdatesConfig
+
+	webhookClient     *http.Client
+	onUpdateAvailable func(container *models.Container)
+
+	// running prevents overlapping check runs
+	running sync.Mutex
+}
+
+// NewChecker creates an image update checker that authenticates with the
+// given registry credentials.
+func NewChecker(store *storage.Storage, cfg config.ImageUpdatesConfig, registries []config.RegistryCredentials) *Checker {
+	return &Checker{
+		storage:       store,
+		registry:      NewRegistryClient(cfg, registries),
+		cfg:           cfg,
+		webhookClient: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// OnUpdateAvailable registers a callback invoked when a container becomes outdated.
+func (c *Checker) OnUpdateAvailable(fn func(container *models.Container)) {
+	c.onUpdateAvailable = fn
+}
+
+// Run checks all images every CheckInterval until the context is cancelled.
+func (c *Checker) Run(ctx context.Context) {
+	interval := c.cfg.CheckInterval
+	if interval <= 0 {
+		interval = 6 * time.Hour
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if report, err := c.CheckAll(ctx); err != nil {
+			log.Printf("Warning: image update check failed: %v", err)
+		} else if len(report.NewlyOutdated) > 0 {
+			log.Printf("Image update check: %d container(s) have updates available", report.Outdated)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// CheckAll checks every container with a known image digest.
+func (c *Checker) CheckAll(ctx context.Context) (*Report, error) {
+	if !c.running.TryLock() {
+		return nil, ErrCheckRunning
+	}
+	defer c.running.Unlock()
+
+	report := &Report{
+		StartedAt:     time.Now(),
+		NewlyOutdated: []string{},
+		Errors:        make(map[string]string),
+	}
+
+	containers, err := c.storage.ListContainers(nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list containers: %w", err)
+	}
+
+	// Resolve each image reference once per run
+	latest := make(map[string]string)
+
+	for _, container := range containers {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		if container.ImageDigest == "" {
+			report.Skipped++
+			continue
+		}
+
+		ref, err := ParseImageRef(container.Image)
+		if err != nil || ref.Digest != "" {
+			// Pinned digests never change
+			report.Skipped++
+			continue
+		}
+
+		digest, resolved := latest[container.Image]
+		if !resolved {
+			if _, failed := report.Errors[container.Image]; failed {
+				continue
+			}
+			digest, err = c.registry.ResolveDigest(ctx, ref)
+			if err != nil {
+				report.Errors[container.Image] = err.Error()
+				continue
+			}
+			latest[container.Image] = digest
+		}
+
+		report.Checked++
+
+		wasOutdated := container.UpdateAvailable
+		now := time.Now()
+		container.LatestImageDigest = digest
+		container.ImageCheckedAt = &now
+		container.RefreshUpdateAvailable()
+
+		if container.UpdateAvailable {
+			report.Outdated++
+		}
+
+		if err := c.storage.SaveContainer(container); err != nil {
+			report.Errors[container.Image] = fmt.Sprintf("failed to save container %s: %v", container.ID, err)
+			continue
+		}
+
+		if container.UpdateAvailable && !wasOutdated {
+			report.NewlyOutdated = append(report.NewlyOutdated, container.ID)
+			c.notify(ctx, container)
+		}
+	}
+
+	report.CompletedAt = time.Now()
+	return report, nil
+}
+
+// notify fires the update callback and the optional webhook.
+func (c *Checker) notify(ctx context.Context, container *models.Container) {
+	if c.onUpdateAvailable != nil {
+		c.onUpdateAvailable(container)
+	}
+
+	if c.cfg.NotifyWebhook == "" {
+		return
+	}
+
+	payload, err := json.Marshal(map[string]interface{}{
+		"event":         "image_update_available",
+		"containerId":   container.ID,
+		"containerName": container.Name,
+		"hostId":        container.HostedOn,
+		"image":         container.Image,
+		"currentDigest": container.ImageDigest,
+		"latestDigest":  container.LatestImageDigest,
+	})
+	if err != nil {
+		return
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.cfg.NotifyWebhook, bytes.NewReader(payload))
+	if err != nil {
+		log.Printf("Warning: failed to create image update webhook request: %v", err)
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.webhookClient.Do(req)
+	if err != nil {
+		log.Printf("Warning: image update webhook failed: %v", err)
+		return
+	}
+	_ = resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		log.Printf("Warning: image update webhook returned %s", resp.Status)
+	}
+}
@@ -0,0 +1,295 @@
+package imageupdates
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"evalgo.org/graphium/internal/config"
+)
+
+const (
+	// dockerHubRegistry is the registry API host for images without a registry prefix
+	dockerHubRegistry = "registry-1.docker.io"
+
+	// manifestAccept lists the manifest media types a tag may resolve to.
+	// Multi-arch images resolve to an index, whose digest is what Docker
+	// records in RepoDigests.
+	manifestAccept = "application/vnd.oci.image.index.v1+json, " +
+		"application/vnd.docker.distribution.manifest.list.v2+json, " +
+		"application/vnd.oci.image.manifest.v1+json, " +
+		"application/vnd.docker.distribution.manifest.v2+json"
+)
+
+// ImageRef is a parsed image reference.
+type ImageRef struct {
+	// Registry is the registry API host (e.g. registry-1.docker.io, ghcr.io)
+	Registry string
+
+	// Repository is the repository path (e.g. library/nginx)
+	Repository string
+
+	// Tag is the image tag (latest if none was given)
+	Tag string
+
+	// Digest is set when the reference is pinned to a digest
+	Digest string
+}
+
+// ParseImageRef parses an image reference such as nginx, nginx:1.25,
+// ghcr.io/org/app:v1 or registry.local:5000/app@sha256:...
+func ParseImageRef(ref string) (*ImageRef, error) {
+	if ref == "" {
+		return nil, fmt.Errorf("empty image reference")
+	}
+
+	parsed := &ImageRef{}
+
+	name := ref
+	if i := strings.Index(name, "@"); i >= 0 {
+		parsed.Digest = name[i+1:]
+		name = name[:i]
+	}
+	if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		parsed.Tag = name[i+1:]
+		name = name[:i]
+	}
+	if parsed.Tag == "" && parsed.Digest == "" {
+		parsed.Tag = "latest"
+	}
+
+	// The first path component is a registry if it looks like a host
+	parts := strings.SplitN(name, "/", 2)
+	if len(parts) == 2 && (strings.ContainsAny(parts[0], ".:") || parts[0] == "localhost") {
+		parsed.Registry = parts[0]
+		parsed.Repository = parts[1]
+	} else {
+		parsed.Registry = dockerHubRegistry
+		parsed.Repository = name
+	}
+
+	if parsed.Registry == "docker.io" || parsed.Registry == "index.docker.io" {
+		parsed.Registry = dockerHubRegistry
+	}
+	if parsed.Registry == dockerHubRegistry && !strings.Contains(parsed.Repository, "/") {
+		parsed.Repository = "library/" + parsed.Repository
+	}
+
+	if parsed.Repository == "" {
+		return nil, fmt.Errorf("invalid image reference %q", ref)
+	}
+
+	return parsed, nil
+}
+
+// RegistryClient resolves image tags to digests using the registry HTTP API (v2).
+type RegistryClient struct {
+	httpClient  *http.Client
+	credentials map[string]config.RegistryCredentials
+
+	// minInterval is the minimum delay between registry requests
+	minInterval time.Duration
+	mu          sync.Mutex
+	lastRequest time.Time
+}
+
+// NewRegistryClient creates a registry client with the configured credentials.
+func NewRegistryClient(cfg config.ImageUpdatesConfig, registries []config.RegistryCredentials) *RegistryClient {
+	credentials := make(map[string]config.RegistryCredentials, len(registries))
+	for _, cred := range registries {
+		host := cred.Host
+		if host == "docker.io" || host == "index.docker.io" {
+			host = dockerHubRegistry
+		}
+		credentials[host] = cred
+	}
+
+	return &RegistryClient{
+		httpClient:  &http.Client{Timeout: 30 * time.Second},
+		credentials: credentials,
+		minInterval: cfg.RequestInterval,
+	}
+}
+
+// ResolveDigest returns the current digest of an image tag in its registry.
+func (r *RegistryClient) ResolveDigest(ctx context.Context, ref *ImageRef) (string, error) {
+	manifestURL := fmt.Sprintf("%s/v2/%s/manifests/%s", r.baseURL(ref.Registry), ref.Repository, ref.Tag)
+
+	resp, err := r.headManifest(ctx, manifestURL, "")
+	if err != nil {
+		return "", err
+	}
+
+	// Most registries require a token even for public images
+	if resp.StatusCode == http.StatusUnauthorized {
+		challenge := resp.Header.Get("WWW-Authenticate")
+		authHeader, err := r.authorize(ctx, ref, challenge)
+		if err != nil {
+			return "", err
+		}
+		resp, err = r.headManifest(ctx, manifestURL, authHeader)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("registry returned %s for %s/%s:%s", resp.Status, ref.Registry, ref.Repository, ref.Tag)
+	}
+
+	digest := resp.Header.Get("Docker-Content-Digest")
+	if digest == "" {
+		return "", fmt.Errorf("registry did not return a digest for %s/%s:%s", ref.Registry, ref.Repository, ref.Tag)
+	}
+
+	return digest, nil
+}
+
+// baseURL returns the registry API base URL. Plain HTTP is only used for local registries.
+func (r *RegistryClient) baseURL(registry string) string {
+	if strings.HasPrefix(registry, "localhost") || strings.HasPrefix(registry, "127.0.0.1") {
+		return "http://" + registry
+	}
+	return "https://" + registry
+}
+
+// headManifest sends a rate-limited HEAD request for a manifest.
+func (r *RegistryClient) headManifest(ctx context.Context, manifestURL, authHeader string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, manifestURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", manifestAccept)
+	if authHeader != "" {
+		req.Header.Set("Authorization", authHeader)
+	}
+
+	resp, err := r.do(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query registry: %w", err)
+	}
+	_ = resp.Body.Close()
+
+	return resp, nil
+}
+
+// authorize answers a WWW-Authenticate challenge and returns the Authorization header to use.
+func (r *RegistryClient) authorize(ctx context.Context, ref *ImageRef, challenge string) (string, error) {
+	cred, hasCred := r.credentials[ref.Registry]
+
+	scheme, params := parseChallenge(challenge)
+	switch strings.ToLower(scheme) {
+	case "basic":
+		if !hasCred {
+			return "", fmt.Errorf("registry %s requires credentials", ref.Registry)
+		}
+		req, _ := http.NewRequest(http.MethodGet, "/", nil)
+		req.SetBasicAuth(cred.Username, cred.Password)
+		return req.Header.Get("Authorization"), nil
+
+	case "bearer":
+		realm := params["realm"]
+		if realm == "" {
+			return "", fmt.Errorf("registry %s sent a bearer challenge without realm", ref.Registry)
+		}
+
+		query := url.Values{}
+		if service := params["service"]; service != "" {
+			query.Set("service", service)
+		}
+		scope := params["scope"]
+		if scope == "" {
+			scope = fmt.Sprintf("repository:%s:pull", ref.Repository)
+		}
+		query.Set("scope", scope)
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, realm+"?"+query.Encode(), nil)
+		if err != nil {
+			return "", err
+		}
+		if hasCred {
+			req.SetBasicAuth(cred.Username, cred.Password)
+		}
+
+		resp, err := r.do(ctx, req)
+		if err != nil {
+			return "", fmt.Errorf("failed to get registry token: %w", err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			return "", fmt.Errorf("registry token request failed: %s", resp.Status)
+		}
+
+		var token struct {
+			Token       string `json:"token"`
+			AccessToken string `json:"access_token"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+			return "", fmt.Errorf("failed to decode registry token: %w", err)
+		}
+		if token.Token == "" {
+			token.Token = token.AccessToken
+		}
+		return "Bearer " + token.Token, nil
+
+	default:
+		return "", fmt.Errorf("registry %s uses unsupported auth scheme %q", ref.Registry, scheme)
+	}
+}
+
+// do sends a request, waiting as needed to respect the minimum request interval.
+func (r *RegistryClient) do(ctx context.Context, req *http.Request) (*http.Response, error) {
+	r.mu.Lock()
+	wait := r.minInterval - time.Since(r.lastRequest)
+	if wait > 0 {
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			r.mu.Unlock()
+			return nil, ctx.Err()
+		}
+	}
+	r.lastRequest = time.Now()
+	r.mu.Unlock()
+
+	return r.httpClient.Do(req)
+}
+
+// parseChallenge parses a WWW-Authenticate header such as
+// Bearer realm="https://auth.docker.io/token",service="registry.docker.io"
+func parseChallenge(header string) (string, map[string]string) {
+	params := make(map[string]string)
+
+	scheme, rest, _ := strings.Cut(strings.TrimSpace(header), " ")
+	for rest != "" {
+		var pair string
+		// Values are quoted and may contain commas (e.g. multiple scopes)
+		key, value, ok := strings.Cut(rest, "=")
+		if !ok {
+			break
+		}
+		key = strings.TrimSpace(key)
+		value = strings.TrimSpace(value)
+		if strings.HasPrefix(value, `"`) {
+			end := strings.Index(value[1:], `"`)
+			if end < 0 {
+				params[key] = value[1:]
+				break
+			}
+			pair = value[1 : end+1]
+			rest = strings.TrimPrefix(strings.TrimSpace(value[end+2:]), ",")
+		} else {
+			pair, rest, _ = strings.Cut(value, ",")
+		}
+		params[key] = pair
+		rest = strings.TrimSpace(rest)
+	}
+
+	return scheme, params
+}
@@ -0,0 +1,114 @@
+package imageupdates
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"evalgo.org/graphium/internal/config"
+)
+
+func TestParseImageRef(t *testing.T) {
+	tests := []struct {
+		ref        string
+		registry   string
+		repository string
+		tag        string
+		digest     string
+	}{
+		{"nginx", dockerHubRegistry, "library/nginx", "latest", ""},
+		{"nginx:1.25", dockerHubRegistry, "library/nginx", "1.25", ""},
+		{"grafana/grafana:10.0.0", dockerHubRegistry, "grafana/grafana", "10.0.0", ""},
+		{"docker.io/library/redis:7", dockerHubRegistry, "library/redis", "7", ""},
+		{"ghcr.io/org/app:v1", "ghcr.io", "org/app", "v1", ""},
+		{"registry.local:5000/team/app", "registry.local:5000", "team/app", "latest", ""},
+		{"localhost/app:dev", "localhost", "app", "dev", ""},
+		{"nginx@sha256:abc", dockerHubRegistry, "library/nginx", "", "sha256:abc"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ref, func(t *testing.T) {
+			ref, err := ParseImageRef(tt.ref)
+			if err != nil {
+				t.Fatalf("ParseImageRef(%q) failed: %v", tt.ref, err)
+			}
+			if ref.Registry != tt.registry {
+				t.Errorf("Expected registry %q, got %q", tt.registry, ref.Registry)
+			}
+			if ref.Repository != tt.repository {
+				t.Errorf("Expected repository %q, got %q", tt.repository, ref.Repository)
+			}
+			if ref.Tag != tt.tag {
+				t.Errorf("Expected tag %q, got %q", tt.tag, ref.Tag)
+			}
+			if ref.Digest != tt.digest {
+				t.Errorf("Expected digest %q, got %q", tt.digest, ref.Digest)
+			}
+		})
+	}
+}
+
+func TestParseChallenge(t *testing.T) {
+	scheme, params := parseChallenge(`Bearer realm="https://auth.docker.io/token",service="registry.docker.io",scope="repository:library/nginx:pull"`)
+
+	if scheme != "Bearer" {
+		t.Errorf("Expected scheme Bearer, got %q", scheme)
+	}
+	if params["realm"] != "https://auth.docker.io/token" {
+		t.Errorf("Unexpected realm %q", params["realm"])
+	}
+	if params["service"] != "registry.docker.io" {
+		t.Errorf("Unexpected service %q", params["service"])
+	}
+	if params["scope"] != "repository:library/nginx:pull" {
+		t.Errorf("Unexpected scope %q", params["scope"])
+	}
+}
+
+func TestResolveDigest_BearerAuth(t *testing.T) {
+	const digest = "sha256:0123456789abcdef"
+
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/token":
+			user, pass, ok := r.BasicAuth()
+			if !ok || user != "bot" || pass != "secret" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			_, _ = w.Write([]byte(`{"token":"abc"}`))
+
+		case strings.HasPrefix(r.URL.Path, "/v2/team/app/manifests/"):
+			if r.Header.Get("Authorization") != "Bearer abc" {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="`+server.URL+`/token",service="test"`)
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.Header().Set("Docker-Content-Digest", digest)
+
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	host := strings.TrimPrefix(server.URL, "http://")
+	client := NewRegistryClient(config.ImageUpdatesConfig{}, []config.RegistryCredentials{
+		{Host: host, Username: "bot", Password: "secret"},
+	})
+	client.httpClient = server.Client()
+
+	ref := &ImageRef{Registry: host, Repository: "team/app", Tag: "v1"}
+
+	// httptest listens on 127.0.0.1, which the client treats as a local plain HTTP registry
+	got, err := client.ResolveDigest(context.Background(), ref)
+	if err != nil {
+		t.Fatalf("ResolveDigest failed: %v", err)
+	}
+	if got != digest {
+		t.Errorf("Expected digest %s, got %s", digest, got)
+	}
+}
@@ -0,0 +1,184 @@
+package scheduler
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"evalgo.org/graphium/models"
+)
+
+// TriggerChained runs the actions a finished task of a scheduled action
+// triggers: its OnSuccess or OnFailure action and the enabled actions that
+// depend on it with a matching run condition. A triggered task gets the
+// finished task as its trigger and, unless its action targets one already,
+// the container the finished task acted on. It returns the triggered tasks,
+// and an error for every action it couldn't run, e.g. because the chain
+// would loop or grow too deep.
+func (s *Scheduler) TriggerChained(finished *models.AgentTask) ([]*models.AgentTask, error) {
+	done, success := models.TaskOutcome(finished.ActionStatus)
+	if !done || finished.ScheduledBy == "" {
+		return nil, nil
+	}
+
+	source, err := s.storage.GetScheduledAction(finished.ScheduledBy)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get action %s: %w", finished.ScheduledBy, err)
+	}
+
+	var targets []*models.ScheduledAction
+	var errs []error
+	targetID := source.OnFailure
+	if success {
+		targetID = source.OnSuccess
+	}
+	if targetID != "" {
+		target, err := s.storage.GetScheduledAction(targetID)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to get chained action %s: %w", targetID, err))
+		} else {
+			targets = append(targets, target)
+		}
+	}
+
+	dependents, err := s.storage.GetDependentScheduledActions(source.ID)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("failed to get actions depending on %s: %w", source.ID, err))
+	}
+	for _, dependent := range dependents {
+		if dependent.Enabled && dependent.RunsAfter(success) && dependent.ID != targetID {
+			targets = append(targets, dependent)
+		}
+	}
+
+	path := append(append([]string{}, finished.ChainPath...), source.ID)
+	var tasks []*models.AgentTask
+	for _, target := range targets {
+		task, err := s.triggerAction(finished, success, source, target, path)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if task != nil {
+			tasks = append(tasks, task)
+		}
+	}
+	return tasks, errors.Join(errs...)
+}
+
+// triggerAction runs target after the finished task of source, which
+// followed the actions in path. It returns nil if target's condition
+// doesn't hold.
+func (s *Scheduler) triggerAction(finished *models.AgentTask, success bool, source, target *models.ScheduledAction, path []string) (*models.AgentTask, error) {
+	if err := models.CheckActionChain(path, target.ID); err != nil {
+		return nil, err
+	}
+
+	trigger := newActionTrigger(finished, success)
+
+	if target.Condition != nil {
+		holds, err := s.evaluateCondition(target)
+		if err != nil {
+			return nil, fmt.Errorf("failed to evaluate condition of chained action %s: %w", target.ID, err)
+		}
+		if !holds {
+			log.Printf("Skipping chained action %s: condition %s not met\n", target.ID, target.Condition)
+			target.MarkSkipped("Condition not met: " + target.Condition.String())
+			if err := s.storage.UpdateScheduledAction(target); err != nil {
+				log.Printf("Error updating action %s: %v\n", target.ID, err)
+			}
+			return nil, nil
+		}
+	}
+
+	task, err := s.createTaskFromAction(target)
+	if err != nil {
+		return nil, err
+	}
+	task.TriggeredBy = trigger
+	task.ChainPath = path
+	if err := withTrigger(task, trigger); err != nil {
+		return nil, fmt.Errorf("failed to set task payload: %w", err)
+	}
+
+	// Refuse tasks on protected containers and commands exec doesn't allow
+	if name, violation := s.refusal(task); violation != "" {
+		target.MarkRunFailed(&models.ActionError{
+			Type:        "Thing",
+			Name:        name,
+			Description: violation,
+			Timestamp:   time.Now(),
+		})
+		if err := s.storage.UpdateScheduledAction(target); err != nil {
+			log.Printf("Error updating action %s: %v\n", target.ID, err)
+		}
+		return nil, fmt.Errorf("refusing chained action %s: %s", target.ID, violation)
+	}
+
+	if err := s.storage.CreateTask(task); err != nil {
+		return nil, fmt.Errorf("failed to create task for chained action %s: %w", target.ID, err)
+	}
+
+	target.MarkStarted()
+	if err := s.storage.UpdateScheduledAction(target); err != nil {
+		log.Printf("Error updating action %s: %v\n", target.ID, err)
+	}
+
+	log.Printf("Created task %s for action %s, triggered by task %s of action %s\n",
+		task.ID, target.ID, finished.ID, source.ID)
+	return task, nil
+}
+
+// newActionTrigger describes a finished task for the action it triggers.
+func newActionTrigger(finished *models.AgentTask, success bool) *models.ActionTrigger {
+	trigger := &models.ActionTrigger{
+		TaskID:      finished.ID,
+		ActionID:    finished.ScheduledBy,
+		Success:     success,
+		ContainerID: finished.ContainerID,
+	}
+
+	var payload map[string]interface{}
+	if trigger.ContainerID == "" && finished.GetPayloadAs(&payload) == nil {
+		if id, ok := payload["containerId"].(string); ok {
+			trigger.ContainerID = id
+		} else if object, ok := payload["object"].(map[string]interface{}); ok {
+			if id, ok := object["@id"].(string); ok && object["@type"] == "SoftwareApplication" {
+				trigger.ContainerID = id
+			}
+		}
+	}
+
+	if result, err := finished.GetResult(); err == nil && result != nil {
+		trigger.Message = result.Message
+		if trigger.ContainerID == "" {
+			trigger.ContainerID = result.ContainerID
+		}
+	}
+	if trigger.Message == "" && finished.Error != nil {
+		trigger.Message = finished.Error.Message
+	}
+	return trigger
+}
+
+// withTrigger adds the trigger to a task's payload and, if the task doesn't
+// target a container yet, points it at the trigger's container.
+func withTrigger(task *models.AgentTask, trigger *models.ActionTrigger) error {
+	payload := make(map[string]interface{})
+	if task.Object != nil && task.Object.Properties != nil {
+		payload = task.Object.Properties
+	}
+	payload["trigger"] = trigger
+
+	if trigger.ContainerID != "" {
+		if _, ok := payload["containerId"]; !ok {
+			payload["containerId"] = trigger.ContainerID
+		}
+		if task.ContainerID == "" {
+			task.ContainerID = trigger.ContainerID
+		}
+	}
+
+	return task.SetPayload(payload)
+}
@@ -0,0 +1,245 @@
+package scheduler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+
+	"evalgo.org/graphium/models"
+)
+
+// cronParser parses standard five-field cron expressions (minute, hour,
+// day of month, month, day of week) and descriptors like @daily.
+var cronParser = cron.NewParser(cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor)
+
+// ParseCron parses a cron expression. The timezone of a schedule is set by
+// its scheduleTimezone, so CRON_TZ and TZ prefixes are refused.
+func ParseCron(expr string) (cron.Schedule, error) {
+	expr = strings.TrimSpace(expr)
+	if strings.HasPrefix(expr, "CRON_TZ=") || strings.HasPrefix(expr, "TZ=") {
+		return nil, fmt.Errorf("invalid cron expression %q: set the timezone with scheduleTimezone instead", expr)
+	}
+	schedule, err := cronParser.Parse(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cron expression %q: %v (expected minute, hour, day of month, month and day of week, e.g. \"0 9 * * 1-5\" for weekdays at 9am)", expr, err)
+	}
+	return schedule, nil
+}
+
+// NormalizeSchedule rewrites a schedule's repeat frequency in canonical
+// form, e.g. " pt1h30m " as PT1H30M. Frequencies that don't parse are left
+// for ValidateSchedule to reject.
+func NormalizeSchedule(schedule *models.Schedule) {
+	frequency := strings.TrimSpace(schedule.RepeatFrequency)
+	if frequency == "" || strings.Contains(frequency, " ") {
+		return
+	}
+	if d, err := parseISO8601Duration(frequency); err == nil {
+		schedule.RepeatFrequency = d.String()
+	}
+}
+
+// cronExpression returns the cron expression of a schedule, if it runs on
+// one. A repeat frequency containing spaces is read as a cron expression
+// too, as before cronExpression existed.
+func cronExpression(schedule *Schedule) string {
+	if schedule.CronExpression != "" {
+		return schedule.CronExpression
+	}
+	if strings.Contains(schedule.RepeatFrequency, " ") {
+		return schedule.RepeatFrequency
+	}
+	return ""
+}
+
+// ValidateSchedule checks that a schedule has either a cron expression or a
+// repeat frequency, that its cron expression parses or its repeat frequency
+// is a duration the scheduler can honor, and that its timezone is known.
+func ValidateSchedule(schedule *models.Schedule) error {
+	switch {
+	case schedule.CronExpression != "" && schedule.RepeatFrequency != "":
+		return fmt.Errorf("set either cronExpression or repeatFrequency, not both")
+	case schedule.CronExpression == "" && schedule.RepeatFrequency == "":
+		return fmt.Errorf("cronExpression or repeatFrequency is required")
+	}
+	if expr := cronExpression(schedule); expr != "" {
+		if _, err := ParseCron(expr); err != nil {
+			return err
+		}
+	} else if err := validateRepeatFrequency(schedule.RepeatFrequency); err != nil {
+		return err
+	}
+	if schedule.ScheduleTimezone != "" {
+		if _, err := time.LoadLocation(schedule.ScheduleTimezone); err != nil {
+			return fmt.Errorf("unknown scheduleTimezone %q: use an IANA timezone like \"Europe/Berlin\"", schedule.ScheduleTimezone)
+		}
+	}
+	return nil
+}
+
+// nextCronExecution returns the first time after t matching a cron
+// expression, evaluated in loc.
+func nextCronExecution(expr string, t time.Time, loc *time.Location) *time.Time {
+	schedule, err := ParseCron(expr)
+	if err != nil {
+		return nil
+	}
+	next := schedule.Next(t.In(loc))
+	if next.IsZero() {
+		return nil
+	}
+	return &next
+}
+
+// FormatSchedule describes a schedule for people, e.g. "At 09:00 on
+// weekdays (Europe/Berlin)" or "Every 5 minutes".
+func FormatSchedule(schedule *models.Schedule) string {
+	if schedule == nil {
+		return ""
+	}
+	var description string
+	if expr := cronExpression(schedule); expr != "" {
+		description = describeCron(expr)
+		if schedule.ScheduleTimezone != "" {
+			description += " (" + schedule.ScheduleTimezone + ")"
+		}
+		return description
+	}
+	duration, err := parseISO8601Duration(schedule.RepeatFrequency)
+	if err != nil {
+		return schedule.RepeatFrequency
+	}
+	return "Every " + duration.describe()
+}
+
+// describeDuration renders a duration in its largest whole unit, e.g.
+// "5 minutes" or "day".
+func describeDuration(d time.Duration) string {
+	units := []struct {
+		name string
+		size time.Duration
+	}{
+		{"week", 7 * 24 * time.Hour},
+		{"day", 24 * time.Hour},
+		{"hour", time.Hour},
+		{"minute", time.Minute},
+		{"second", time.Second},
+	}
+	for _, unit := range units {
+		if d >= unit.size && d%unit.size == 0 {
+			return plural(int(d/unit.size), unit.name)
+		}
+	}
+	return d.String()
+}
+
+// plural returns "unit" for one and "n units" otherwise.
+func plural(n int, unit string) string {
+	if n == 1 {
+		return unit
+	}
+	return strconv.Itoa(n) + " " + unit + "s"
+}
+
+// cronDescriptors are the descriptions of the cron descriptors.
+var cronDescriptors = map[string]string{
+	"@yearly":   "Every year on January 1 at 00:00",
+	"@annually": "Every year on January 1 at 00:00",
+	"@monthly":  "On day 1 of every month at 00:00",
+	"@weekly":   "Every Sunday at 00:00",
+	"@daily":    "Every day at 00:00",
+	"@midnight": "Every day at 00:00",
+	"@hourly":   "Every hour",
+}
+
+// describeCron describes a cron expression, falling back to the expression
+// itself for fields it can't put in words.
+func describeCron(expr string) string {
+	expr = strings.TrimSpace(expr)
+	if description, ok := cronDescriptors[expr]; ok {
+		return description
+	}
+	if every, ok := strings.CutPrefix(expr, "@every "); ok {
+		if d, err := time.ParseDuration(every); err == nil {
+			return "Every " + describeDuration(d)
+		}
+	}
+
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return "Cron " + expr
+	}
+	minute, hour, dom, month, dow := fields[0], fields[1], fields[2], fields[3], fields[4]
+
+	var parts []string
+	switch {
+	case isNumber(minute) && isNumber(hour):
+		parts = append(parts, fmt.Sprintf("At %02d:%02d", atoi(hour), atoi(minute)))
+	case minute == "*" && hour == "*":
+		parts = append(parts, "Every minute")
+	case strings.HasPrefix(minute, "*/") && hour == "*":
+		parts = append(parts, "Every "+plural(atoi(minute[2:]), "minute"))
+	case isNumber(minute) && hour == "*":
+		parts = append(parts, fmt.Sprintf("At minute %s of every hour", minute))
+	case isNumber(minute) && strings.HasPrefix(hour, "*/"):
+		parts = append(parts, fmt.Sprintf("At minute %s of every %s", minute, plural(atoi(hour[2:]), "hour")))
+	default:
+		parts = append(parts, fmt.Sprintf("At minute %s past hour %s", minute, hour))
+	}
+
+	if dom != "*" {
+		parts = append(parts, "on day "+strings.ReplaceAll(dom, ",", ", ")+" of the month")
+	}
+	if dow != "*" {
+		parts = append(parts, describeWeekdays(dow))
+	}
+	if month != "*" {
+		parts = append(parts, "in "+describeList(month, 1, func(n int) string { return time.Month(n).String() }))
+	}
+	return strings.Join(parts, " ")
+}
+
+// describeWeekdays describes the day-of-week field of a cron expression.
+func describeWeekdays(field string) string {
+	switch strings.ToUpper(field) {
+	case "1-5", "MON-FRI":
+		return "on weekdays"
+	case "0,6", "6,0", "SAT,SUN", "SUN,SAT":
+		return "on weekends"
+	}
+	return "on " + describeList(field, 0, func(n int) string { return time.Weekday(n % 7).String() })
+}
+
+// describeList names the numbers of a cron field list like "1,3-5" with
+// name, leaving names and steps as written. first is the lowest value of
+// the field.
+func describeList(field string, first int, name func(int) string) string {
+	var names []string
+	for _, item := range strings.Split(field, ",") {
+		from, to, isRange := strings.Cut(item, "-")
+		switch {
+		case isNumber(from) && !isRange && atoi(from) >= first:
+			names = append(names, name(atoi(from)))
+		case isNumber(from) && isNumber(to) && atoi(from) >= first:
+			names = append(names, name(atoi(from))+" to "+name(atoi(to)))
+		default:
+			names = append(names, item)
+		}
+	}
+	return strings.Join(names, ", ")
+}
+
+// isNumber reports whether a cron field is a single number.
+func isNumber(field string) bool {
+	_, err := strconv.Atoi(field)
+	return err == nil
+}
+
+// atoi returns the number of a cron field, or 0.
+func atoi(field string) int {
+	n, _ := strconv.Atoi(field)
+	return n
+}
@@ -0,0 +1,156 @@
+package scheduler
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"evalgo.org/graphium/models"
+)
+
+func TestValidateSchedule(t *testing.T) {
+	tests := []struct {
+		name     string
+		schedule models.Schedule
+		wantErr  string
+	}{
+		{"interval", models.Schedule{RepeatFrequency: "PT5M"}, ""},
+		{"cron", models.Schedule{CronExpression: "0 9 * * 1-5", ScheduleTimezone: "Europe/Berlin"}, ""},
+		{"descriptor", models.Schedule{CronExpression: "@daily"}, ""},
+		{"neither", models.Schedule{}, "is required"},
+		{"both", models.Schedule{RepeatFrequency: "PT5M", CronExpression: "0 9 * * *"}, "not both"},
+		{"invalid cron", models.Schedule{CronExpression: "0 25 * * *"}, "invalid cron expression"},
+		{"too few fields", models.Schedule{CronExpression: "0 9 *"}, "expected minute, hour"},
+		{"cron timezone", models.Schedule{CronExpression: "CRON_TZ=UTC 0 9 * * *"}, "scheduleTimezone"},
+		{"invalid cron in repeat frequency", models.Schedule{RepeatFrequency: "0 9 * *"}, "invalid cron expression"},
+		{"unknown timezone", models.Schedule{CronExpression: "0 9 * * *", ScheduleTimezone: "Mars/Olympus"}, "unknown scheduleTimezone"},
+		{"odd interval", models.Schedule{RepeatFrequency: "P1DT6H"}, ""},
+		{"invalid interval", models.Schedule{RepeatFrequency: "PT7X"}, "invalid duration"},
+		{"interval too short", models.Schedule{RepeatFrequency: "PT10S"}, "shorter than 30s"},
+	}
+	for _, tt := range tests {
+		err := ValidateSchedule(&tt.schedule)
+		switch {
+		case tt.wantErr == "" && err != nil:
+			t.Errorf("%s: unexpected error %v", tt.name, err)
+		case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+			t.Errorf("%s: expected an error containing %q, got %v", tt.name, tt.wantErr, err)
+		}
+	}
+}
+
+func TestCalculateNextExecution_CronInTimezone(t *testing.T) {
+	s := &Scheduler{}
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("timezone data unavailable: %v", err)
+	}
+	schedule := &Schedule{CronExpression: "0 9 * * 1-5", ScheduleTimezone: "America/New_York"}
+
+	// Friday 14:00 UTC is 10:00 in New York, so the next run is Monday 9am there
+	last := time.Date(2026, 10, 16, 14, 0, 0, 0, time.UTC)
+	next := s.calculateNextExecution(last, schedule, loc)
+	want := time.Date(2026, 10, 19, 9, 0, 0, 0, loc)
+	if next == nil || !next.Equal(want) {
+		t.Errorf("Expected next execution %v, got %v", want, next)
+	}
+}
+
+func TestCalculateNextExecution_Interval(t *testing.T) {
+	s := &Scheduler{}
+	last := time.Date(2026, 10, 16, 14, 0, 0, 0, time.UTC)
+	next := s.calculateNextExecution(last, &Schedule{RepeatFrequency: "PT5M"}, time.UTC)
+	if next == nil || !next.Equal(last.Add(5*time.Minute)) {
+		t.Errorf("Expected next execution 5 minutes later, got %v", next)
+	}
+}
+
+func TestCalculateNextExecution_CalendarMonth(t *testing.T) {
+	s := &Scheduler{}
+	last := time.Date(2026, 3, 15, 9, 0, 0, 0, time.UTC)
+	next := s.calculateNextExecution(last, &Schedule{RepeatFrequency: "P1M"}, time.UTC)
+	if want := time.Date(2026, 4, 15, 9, 0, 0, 0, time.UTC); next == nil || !next.Equal(want) {
+		t.Errorf("Expected next execution %v, got %v", want, next)
+	}
+}
+
+func TestNormalizeSchedule(t *testing.T) {
+	schedule := &models.Schedule{RepeatFrequency: " pt1h30m "}
+	NormalizeSchedule(schedule)
+	if schedule.RepeatFrequency != "PT1H30M" {
+		t.Errorf("Expected PT1H30M, got %q", schedule.RepeatFrequency)
+	}
+
+	schedule = &models.Schedule{RepeatFrequency: "0 9 * * *"}
+	NormalizeSchedule(schedule)
+	if schedule.RepeatFrequency != "0 9 * * *" {
+		t.Errorf("Expected the cron expression to be kept, got %q", schedule.RepeatFrequency)
+	}
+}
+
+func TestShouldExecuteFirstTime_CronWaitsForMatch(t *testing.T) {
+	s := &Scheduler{}
+	action := &models.ScheduledAction{
+		CreatedAt: time.Date(2026, 10, 1, 8, 30, 0, 0, time.UTC),
+		Schedule:  &Schedule{CronExpression: "0 0 1 * *"},
+	}
+
+	if s.shouldExecuteFirstTime(action, time.Date(2026, 10, 15, 0, 0, 0, 0, time.UTC), time.UTC) {
+		t.Error("Expected the action not to run before the first of the next month")
+	}
+	if !s.shouldExecuteFirstTime(action, time.Date(2026, 11, 1, 0, 0, 30, 0, time.UTC), time.UTC) {
+		t.Error("Expected the action to run on the first of the month")
+	}
+}
+
+func TestShouldExecute_FailedRunWaitsForNextRun(t *testing.T) {
+	s := &Scheduler{}
+	action := &models.ScheduledAction{
+		Enabled:   true,
+		CreatedAt: time.Now().Add(-time.Hour),
+		Schedule:  &Schedule{RepeatFrequency: "PT5M"},
+		Condition: &models.ActionCondition{Source: "container", Field: "status", Operator: "eq", Value: "running"},
+	}
+	if !s.shouldExecute(action, time.Now()) {
+		t.Fatal("Expected the action to be due")
+	}
+
+	// The condition can't be evaluated, so the run fails before a task exists
+	action.MarkRunFailed(&models.ActionError{Name: "ConditionError"})
+
+	if s.shouldExecute(action, time.Now().Add(30*time.Second)) {
+		t.Error("Expected a failed run not to fire again on the next tick")
+	}
+	if !s.shouldExecute(action, time.Now().Add(5*time.Minute+time.Second)) {
+		t.Error("Expected the action to run again at its next scheduled time")
+	}
+}
+
+func TestFormatSchedule(t *testing.T) {
+	tests := []struct {
+		schedule models.Schedule
+		want     string
+	}{
+		{models.Schedule{RepeatFrequency: "PT5M"}, "Every 5 minutes"},
+		{models.Schedule{RepeatFrequency: "P1D"}, "Every day"},
+		{models.Schedule{RepeatFrequency: "PT7M"}, "Every 7 minutes"},
+		{models.Schedule{RepeatFrequency: "PT90S"}, "Every 90 seconds"},
+		{models.Schedule{RepeatFrequency: "PT1H30M"}, "Every 90 minutes"},
+		{models.Schedule{RepeatFrequency: "P1DT6H"}, "Every 30 hours"},
+		{models.Schedule{RepeatFrequency: "P1M"}, "Every month"},
+		{models.Schedule{RepeatFrequency: "P1M15D"}, "Every month and 15 days"},
+		{models.Schedule{CronExpression: "0 9 * * 1-5", ScheduleTimezone: "Europe/Berlin"}, "At 09:00 on weekdays (Europe/Berlin)"},
+		{models.Schedule{CronExpression: "0 0 1 * *"}, "At 00:00 on day 1 of the month"},
+		{models.Schedule{CronExpression: "*/15 * * * *"}, "Every 15 minutes"},
+		{models.Schedule{CronExpression: "30 * * * *"}, "At minute 30 of every hour"},
+		{models.Schedule{CronExpression: "0 18 * 1,7 0,6"}, "At 18:00 on weekends in January, July"},
+		{models.Schedule{CronExpression: "0 6 * * 1,3"}, "At 06:00 on Monday, Wednesday"},
+		{models.Schedule{CronExpression: "@hourly"}, "Every hour"},
+		{models.Schedule{RepeatFrequency: "0 12 * * *"}, "At 12:00"},
+	}
+	for _, tt := range tests {
+		if got := FormatSchedule(&tt.schedule); got != tt.want {
+			t.Errorf("FormatSchedule(%+v) = %q, want %q", tt.schedule, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,210 @@
+package scheduler
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// evaluationInterval is how often the scheduler evaluates actions, and so
+// the shortest repeat frequency it can honor.
+const evaluationInterval = 30 * time.Second
+
+// isoDuration is an ISO 8601 duration like P1DT6H. Years and months are
+// calendar units; the other units are exact.
+type isoDuration struct {
+	years, months, weeks, days int
+	clock                      time.Duration // The part after T
+}
+
+// parseISO8601Duration parses an ISO 8601 duration:
+// P[nY][nM][nW][nD][T[nH][nM][nS]], e.g. PT90S, PT1H30M, P1DT6H or P1M.
+// Units must appear in that order and values must be whole numbers.
+func parseISO8601Duration(duration string) (isoDuration, error) {
+	var d isoDuration
+	if duration == "" {
+		return d, fmt.Errorf("empty duration")
+	}
+	rest, ok := strings.CutPrefix(strings.ToUpper(duration), "P")
+	if !ok {
+		return d, fmt.Errorf("invalid duration %q: must start with P, e.g. PT5M or P1D", duration)
+	}
+	datePart, timePart, hasTime := strings.Cut(rest, "T")
+	if hasTime && timePart == "" {
+		return d, fmt.Errorf("invalid duration %q: T must be followed by hours, minutes or seconds", duration)
+	}
+
+	dateUnits := []struct {
+		designator byte
+		value      *int
+	}{
+		{'Y', &d.years}, {'M', &d.months}, {'W', &d.weeks}, {'D', &d.days},
+	}
+	components := 0
+	next := 0
+	for datePart != "" {
+		value, designator, remaining, err := nextComponent(datePart)
+		if err != nil {
+			return d, fmt.Errorf("invalid duration %q: %v", duration, err)
+		}
+		for next < len(dateUnits) && dateUnits[next].designator != designator {
+			next++
+		}
+		if next == len(dateUnits) {
+			return d, fmt.Errorf("invalid duration %q: unexpected %c in the date part (use Y, M, W and D, in that order)", duration, designator)
+		}
+		*dateUnits[next].value = int(value)
+		next++
+		datePart = remaining
+		components++
+	}
+
+	timeUnits := []struct {
+		designator byte
+		unit       time.Duration
+	}{
+		{'H', time.Hour}, {'M', time.Minute}, {'S', time.Second},
+	}
+	next = 0
+	for timePart != "" {
+		value, designator, remaining, err := nextComponent(timePart)
+		if err != nil {
+			return d, fmt.Errorf("invalid duration %q: %v", duration, err)
+		}
+		for next < len(timeUnits) && timeUnits[next].designator != designator {
+			next++
+		}
+		if next == len(timeUnits) {
+			return d, fmt.Errorf("invalid duration %q: unexpected %c in the time part (use H, M and S, in that order)", duration, designator)
+		}
+		unit := timeUnits[next].unit
+		if value > int64(math.MaxInt64/unit) || d.clock > math.MaxInt64-time.Duration(value)*unit {
+			return d, fmt.Errorf("invalid duration %q: too long", duration)
+		}
+		d.clock += time.Duration(value) * unit
+		next++
+		timePart = remaining
+		components++
+	}
+
+	if components == 0 {
+		return d, fmt.Errorf("invalid duration %q: no value, e.g. PT5M or P1D", duration)
+	}
+	days := int64(d.years)*365 + int64(d.months)*30 + int64(d.weeks)*7 + int64(d.days) + int64(d.clock/(24*time.Hour))
+	if days >= int64(math.MaxInt64/(24*time.Hour)) {
+		return d, fmt.Errorf("invalid duration %q: too long", duration)
+	}
+	return d, nil
+}
+
+// nextComponent splits the leading "<number><designator>" off s.
+func nextComponent(s string) (int64, byte, string, error) {
+	i := 0
+	for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+		i++
+	}
+	if i == len(s) {
+		return 0, 0, "", fmt.Errorf("%q has no unit", s)
+	}
+	if i == 0 {
+		if s[0] == '.' || s[0] == ',' || s[0] == '-' {
+			return 0, 0, "", fmt.Errorf("only whole, positive numbers are supported")
+		}
+		return 0, 0, "", fmt.Errorf("expected a number before %c", s[0])
+	}
+	if s[i] == '.' || s[i] == ',' {
+		return 0, 0, "", fmt.Errorf("fractional values are not supported")
+	}
+	value, err := strconv.ParseInt(s[:i], 10, 32)
+	if err != nil {
+		return 0, 0, "", fmt.Errorf("%s is too large", s[:i])
+	}
+	return value, s[i], s[i+1:], nil
+}
+
+// fixed returns the part of the duration that doesn't depend on the
+// calendar: weeks, days and the time part.
+func (d isoDuration) fixed() time.Duration {
+	return time.Duration(d.weeks*7+d.days)*24*time.Hour + d.clock
+}
+
+// addTo returns t plus the duration. Adding months keeps the day of the
+// month where it exists and takes the month's last day where it doesn't,
+// so a month after January 31 is February 28 (or 29), not March 3.
+func (d isoDuration) addTo(t time.Time) time.Time {
+	if d.years != 0 || d.months != 0 {
+		first := time.Date(t.Year(), t.Month(), 1, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+		first = first.AddDate(d.years, d.months, 0)
+		lastDay := first.AddDate(0, 1, -1).Day()
+		t = first.AddDate(0, 0, min(t.Day(), lastDay)-1)
+	}
+	return t.Add(d.fixed())
+}
+
+// approximate returns the duration, counting years as 365 days and months
+// as 30.
+func (d isoDuration) approximate() time.Duration {
+	return time.Duration(d.years*365+d.months*30)*24*time.Hour + d.fixed()
+}
+
+// String returns the duration in canonical form, e.g. PT1H30M, without
+// zero components.
+func (d isoDuration) String() string {
+	var b strings.Builder
+	b.WriteString("P")
+	for _, c := range []struct {
+		value      int
+		designator string
+	}{{d.years, "Y"}, {d.months, "M"}, {d.weeks, "W"}, {d.days, "D"}} {
+		if c.value != 0 {
+			b.WriteString(strconv.Itoa(c.value) + c.designator)
+		}
+	}
+	if d.clock != 0 {
+		b.WriteString("T")
+		hours, minutes, seconds := d.clock/time.Hour, d.clock%time.Hour/time.Minute, d.clock%time.Minute/time.Second
+		for _, c := range []struct {
+			value      time.Duration
+			designator string
+		}{{hours, "H"}, {minutes, "M"}, {seconds, "S"}} {
+			if c.value != 0 {
+				b.WriteString(strconv.FormatInt(int64(c.value), 10) + c.designator)
+			}
+		}
+	}
+	if b.Len() == 1 {
+		return "PT0S"
+	}
+	return b.String()
+}
+
+// describe renders the duration for people, e.g. "90 minutes", "30 hours"
+// or "month and 15 days".
+func (d isoDuration) describe() string {
+	var parts []string
+	if d.years != 0 {
+		parts = append(parts, plural(d.years, "year"))
+	}
+	if d.months != 0 {
+		parts = append(parts, plural(d.months, "month"))
+	}
+	if fixed := d.fixed(); fixed != 0 {
+		parts = append(parts, describeDuration(fixed))
+	}
+	return strings.Join(parts, " and ")
+}
+
+// validateRepeatFrequency checks that the scheduler can honor a repeat
+// frequency: a valid ISO 8601 duration no shorter than evaluationInterval.
+func validateRepeatFrequency(frequency string) error {
+	d, err := parseISO8601Duration(frequency)
+	if err != nil {
+		return err
+	}
+	if d.approximate() < evaluationInterval {
+		return fmt.Errorf("repeatFrequency %s is shorter than %s, the interval the scheduler evaluates actions at", frequency, evaluationInterval)
+	}
+	return nil
+}
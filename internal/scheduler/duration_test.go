@@ -0,0 +1,89 @@
+package scheduler
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseISO8601Duration(t *testing.T) {
+	tests := []struct {
+		duration string
+		want     time.Duration
+		add      time.Duration // addTo from 2026-01-31, if it differs from want
+	}{
+		{"PT30S", 30 * time.Second, 0},
+		{"PT90S", 90 * time.Second, 0},
+		{"PT7M", 7 * time.Minute, 0},
+		{"PT1H30M", 90 * time.Minute, 0},
+		{"P1DT6H", 30 * time.Hour, 0},
+		{"P2W", 14 * 24 * time.Hour, 0},
+		{"pt5m", 5 * time.Minute, 0},
+		{"P1M", 30 * 24 * time.Hour, 28 * 24 * time.Hour},
+		{"P1Y", 365 * 24 * time.Hour, 365 * 24 * time.Hour},
+	}
+	start := time.Date(2026, 1, 31, 12, 0, 0, 0, time.UTC)
+	for _, tt := range tests {
+		d, err := parseISO8601Duration(tt.duration)
+		if err != nil {
+			t.Errorf("parseISO8601Duration(%q): unexpected error %v", tt.duration, err)
+			continue
+		}
+		if got := d.approximate(); got != tt.want {
+			t.Errorf("parseISO8601Duration(%q) = %v, want %v", tt.duration, got, tt.want)
+		}
+		add := tt.add
+		if add == 0 {
+			add = tt.want
+		}
+		if got := d.addTo(start); !got.Equal(start.Add(add)) {
+			t.Errorf("%q after %v = %v, want %v", tt.duration, start, got, start.Add(add))
+		}
+	}
+}
+
+func TestParseISO8601Duration_Invalid(t *testing.T) {
+	tests := []struct {
+		duration string
+		wantErr  string
+	}{
+		{"", "empty duration"},
+		{"5M", "must start with P"},
+		{"P", "no value"},
+		{"PT", "T must be followed"},
+		{"P1DT", "T must be followed"},
+		{"PT7X", "unexpected X"},
+		{"PT30M1H", "unexpected H"},
+		{"P1D2M", "unexpected M"},
+		{"PT1.5H", "fractional values"},
+		{"PT-5M", "whole, positive numbers"},
+		{"PT5", "has no unit"},
+		{"P99999999999D", "too large"},
+		{"P2000000000D", "too long"},
+	}
+	for _, tt := range tests {
+		_, err := parseISO8601Duration(tt.duration)
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("parseISO8601Duration(%q): expected an error containing %q, got %v", tt.duration, tt.wantErr, err)
+		}
+	}
+}
+
+func TestISODuration_String(t *testing.T) {
+	tests := map[string]string{
+		"PT90S":     "PT1M30S",
+		"pt1h30m":   "PT1H30M",
+		"P1DT6H":    "P1DT6H",
+		"P0DT5M":    "PT5M",
+		"P1Y2M3W4D": "P1Y2M3W4D",
+	}
+	for duration, want := range tests {
+		d, err := parseISO8601Duration(duration)
+		if err != nil {
+			t.Fatalf("parseISO8601Duration(%q): unexpected error %v", duration, err)
+		}
+		if got := d.String(); got != want {
+			t.Errorf("parseISO8601Duration(%q).String() = %q, want %q", duration, got, want)
+		}
+	}
+}
@@ -0,0 +1,255 @@
+package scheduler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"mime"
+	"net"
+	"net/http"
+	"net/smtp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"evalgo.org/graphium/internal/config"
+	"evalgo.org/graphium/models"
+)
+
+// Notification events.
+const (
+	EventActionFailed    = "action_failed"
+	EventActionSucceeded = "action_succeeded"
+	EventActionRecovered = "action_recovered"
+)
+
+// notifyTimeout bounds the delivery of a notification by one notifier.
+const notifyTimeout = 30 * time.Second
+
+// Notification describes a decided run of a scheduled action.
+type Notification struct {
+	Event      string    `json:"event"`
+	ActionID   string    `json:"actionId"`
+	ActionName string    `json:"actionName"`
+	ActionType string    `json:"actionType"`
+	Agent      string    `json:"agent"`
+	TaskID     string    `json:"taskId"`
+	Success    bool      `json:"success"`
+	Attempt    int       `json:"attempt"`
+	Message    string    `json:"message,omitempty"`
+	Timestamp  time.Time `json:"timestamp"`
+
+	// Data is the task result's data, e.g. the days until a certificate
+	// expires
+	Data map[string]interface{} `json:"data,omitempty"`
+}
+
+// Summary is a one-line description of the notification, e.g. "Action
+// cert-check failed".
+func (n *Notification) Summary() string {
+	outcome := "failed"
+	switch n.Event {
+	case EventActionSucceeded:
+		outcome = "succeeded"
+	case EventActionRecovered:
+		outcome = "recovered"
+	}
+	return fmt.Sprintf("Action %s %s", n.ActionName, outcome)
+}
+
+// Notifier delivers notifications to one destination. Adapters for chat
+// services like Slack or Teams implement it and are added with
+// Scheduler.AddNotifier.
+type Notifier interface {
+	// Name identifies the notifier in logs, e.g. "webhook"
+	Name() string
+
+	// Notify delivers a notification
+	Notify(ctx context.Context, n *Notification) error
+}
+
+// Dispatcher sends notifications to every registered notifier.
+type Dispatcher struct {
+	mu        sync.RWMutex
+	notifiers []Notifier
+}
+
+// Add registers a notifier.
+func (d *Dispatcher) Add(notifier Notifier) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.notifiers = append(d.notifiers, notifier)
+}
+
+// Dispatch delivers a notification to all notifiers in the background.
+// Failures are logged, not returned.
+func (d *Dispatcher) Dispatch(n *Notification) {
+	d.mu.RLock()
+	notifiers := append([]Notifier{}, d.notifiers...)
+	d.mu.RUnlock()
+
+	for _, notifier := range notifiers {
+		go func(notifier Notifier) {
+			ctx, cancel := context.WithTimeout(context.Background(), notifyTimeout)
+			defer cancel()
+			if err := notifier.Notify(ctx, n); err != nil {
+				log.Printf("Warning: %s notification for action %s failed: %v\n", notifier.Name(), n.ActionID, err)
+			}
+		}(notifier)
+	}
+}
+
+// NewNotifiers returns the notifiers configured in cfg: a webhook and
+// email, each if set.
+func NewNotifiers(cfg config.NotificationsConfig) []Notifier {
+	var notifiers []Notifier
+	if cfg.WebhookURL != "" {
+		notifiers = append(notifiers, NewWebhookNotifier(cfg.WebhookURL))
+	}
+	if cfg.SMTP.Host != "" {
+		notifiers = append(notifiers, NewEmailNotifier(cfg.SMTP))
+	}
+	return notifiers
+}
+
+// WebhookNotifier POSTs notifications as JSON to a URL.
+type WebhookNotifier struct {
+	url    string
+	client *http.Client
+}
+
+// NewWebhookNotifier creates a notifier posting to url.
+func NewWebhookNotifier(url string) *WebhookNotifier {
+	return &WebhookNotifier{url: url, client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+// Name implements Notifier.
+func (w *WebhookNotifier) Name() string {
+	return "webhook"
+}
+
+// Notify implements Notifier.
+func (w *WebhookNotifier) Notify(ctx context.Context, n *Notification) error {
+	payload, err := json.Marshal(n)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to create webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	_ = resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
+
+// EmailNotifier sends notifications by email through an SMTP server.
+type EmailNotifier struct {
+	cfg config.SMTPConfig
+
+	// send is smtp.SendMail, replaceable in tests
+	send func(addr string, auth smtp.Auth, from string, to []string, msg []byte) error
+}
+
+// NewEmailNotifier creates a notifier sending through the server in cfg.
+func NewEmailNotifier(cfg config.SMTPConfig) *EmailNotifier {
+	return &EmailNotifier{cfg: cfg, send: smtp.SendMail}
+}
+
+// Name implements Notifier.
+func (e *EmailNotifier) Name() string {
+	return "email"
+}
+
+// Notify implements Notifier. The context is not used: net/smtp has no
+// cancellation.
+func (e *EmailNotifier) Notify(ctx context.Context, n *Notification) error {
+	var auth smtp.Auth
+	if e.cfg.Username != "" {
+		auth = smtp.PlainAuth("", e.cfg.Username, e.cfg.Password, e.cfg.Host)
+	}
+	addr := net.JoinHostPort(e.cfg.Host, strconv.Itoa(e.cfg.Port))
+	return e.send(addr, auth, e.cfg.From, e.cfg.To, e.message(n))
+}
+
+// message returns the email for a notification.
+func (e *EmailNotifier) message(n *Notification) []byte {
+	var body strings.Builder
+	fmt.Fprintf(&body, "%s.\r\n\r\n", n.Summary())
+	fmt.Fprintf(&body, "Action:  %s (%s)\r\n", n.ActionName, n.ActionID)
+	fmt.Fprintf(&body, "Type:    %s\r\n", n.ActionType)
+	fmt.Fprintf(&body, "Agent:   %s\r\n", n.Agent)
+	fmt.Fprintf(&body, "Task:    %s (attempt %d)\r\n", n.TaskID, n.Attempt)
+	fmt.Fprintf(&body, "Time:    %s\r\n", n.Timestamp.Format(time.RFC3339))
+	if n.Message != "" {
+		fmt.Fprintf(&body, "Message: %s\r\n", n.Message)
+	}
+	if len(n.Data) > 0 {
+		body.WriteString("\r\nData:\r\n")
+		keys := make([]string, 0, len(n.Data))
+		for key := range n.Data {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			fmt.Fprintf(&body, "  %s: %v\r\n", key, n.Data[key])
+		}
+	}
+
+	var msg strings.Builder
+	fmt.Fprintf(&msg, "From: %s\r\n", e.cfg.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(e.cfg.To, ", "))
+	// The summary holds the user-supplied action name; encoding it keeps
+	// CR/LF in the name from adding headers
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", "[Graphium] "+n.Summary()))
+	fmt.Fprintf(&msg, "Date: %s\r\n", n.Timestamp.Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	msg.WriteString(body.String())
+	return []byte(msg.String())
+}
+
+// newNotification describes the decided run of an action whose last task
+// was finished.
+func newNotification(action *models.ScheduledAction, finished *models.AgentTask, success, recovered bool, attempt int) *Notification {
+	n := &Notification{
+		Event:      EventActionFailed,
+		ActionID:   action.ID,
+		ActionName: action.Name,
+		ActionType: action.Type,
+		Agent:      action.Agent,
+		TaskID:     finished.ID,
+		Success:    success,
+		Attempt:    attempt,
+		Timestamp:  time.Now(),
+	}
+	switch {
+	case recovered:
+		n.Event = EventActionRecovered
+	case success:
+		n.Event = EventActionSucceeded
+	}
+
+	if result, err := finished.GetResult(); err == nil && result != nil {
+		n.Message = result.Message
+		n.Data = result.Data
+	}
+	if n.Message == "" && finished.Error != nil {
+		n.Message = finished.Error.Message
+	}
+	return n
+}
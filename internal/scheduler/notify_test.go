@@ -0,0 +1,177 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"mime"
+	"net/http"
+	"net/http/httptest"
+	"net/smtp"
+	"strings"
+	"testing"
+	"time"
+
+	"eve.evalgo.org/semantic"
+
+	"evalgo.org/graphium/internal/config"
+	"evalgo.org/graphium/models"
+)
+
+// certCheck returns a certificate check action and its finished task,
+// whose result reports the days until the certificate expires
+func certCheck(t *testing.T, status string) (*models.ScheduledAction, *models.AgentTask) {
+	t.Helper()
+	action := &models.ScheduledAction{ID: "action-1", Name: "cert-check", Type: models.ActionTypeCheck, Agent: "host-1"}
+	task := &models.AgentTask{ID: "task-1", ActionStatus: status, ScheduledBy: action.ID}
+	if err := task.SetResult(&models.TaskResult{Message: "certificate expires soon", Data: map[string]interface{}{"daysUntilExpiry": 5}}); err != nil {
+		t.Fatalf("SetResult failed: %v", err)
+	}
+	return action, task
+}
+
+func TestNewNotification(t *testing.T) {
+	action, task := certCheck(t, models.TaskStatusFailed)
+	n := newNotification(action, task, false, false, 2)
+	if n.Event != EventActionFailed || n.Attempt != 2 || n.Message != "certificate expires soon" {
+		t.Errorf("Unexpected notification %+v", n)
+	}
+	if n.Data["daysUntilExpiry"] != float64(5) {
+		t.Errorf("Expected the task result data, got %v", n.Data)
+	}
+
+	if n := newNotification(action, task, true, true, 1); n.Event != EventActionRecovered || n.Summary() != "Action cert-check recovered" {
+		t.Errorf("Expected a recovery notification, got %s (%s)", n.Event, n.Summary())
+	}
+
+	task.SemanticResult = nil
+	task.Error = &semantic.SemanticError{Message: "connection refused"}
+	if n := newNotification(action, task, false, false, 1); n.Message != "connection refused" {
+		t.Errorf("Expected the task error as message, got %q", n.Message)
+	}
+}
+
+func TestWebhookNotifier(t *testing.T) {
+	received := make(chan Notification, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var n Notification
+		if err := json.NewDecoder(r.Body).Decode(&n); err != nil {
+			t.Errorf("Failed to decode notification: %v", err)
+		}
+		received <- n
+	}))
+	defer server.Close()
+
+	action, task := certCheck(t, models.TaskStatusFailed)
+	if err := NewWebhookNotifier(server.URL).Notify(context.Background(), newNotification(action, task, false, false, 1)); err != nil {
+		t.Fatalf("Notify failed: %v", err)
+	}
+	n := <-received
+	if n.ActionID != "action-1" || n.Event != EventActionFailed || n.Data["daysUntilExpiry"] != float64(5) {
+		t.Errorf("Unexpected webhook payload %+v", n)
+	}
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer failing.Close()
+	if err := NewWebhookNotifier(failing.URL).Notify(context.Background(), &Notification{}); err == nil {
+		t.Error("Expected an error for a failing webhook")
+	}
+}
+
+func TestEmailNotifier(t *testing.T) {
+	notifier := NewEmailNotifier(config.SMTPConfig{
+		Host: "smtp.example.com", Port: 587, Username: "graphium", Password: "secret",
+		From: "graphium@example.com", To: []string{"ops@example.com", "dev@example.com"},
+	})
+	var addr string
+	var to []string
+	var msg string
+	notifier.send = func(a string, auth smtp.Auth, from string, recipients []string, body []byte) error {
+		addr, to, msg = a, recipients, string(body)
+		return nil
+	}
+
+	action, task := certCheck(t, models.TaskStatusFailed)
+	if err := notifier.Notify(context.Background(), newNotification(action, task, false, false, 1)); err != nil {
+		t.Fatalf("Notify failed: %v", err)
+	}
+	if addr != "smtp.example.com:587" || len(to) != 2 {
+		t.Errorf("Expected mail to both recipients through smtp.example.com:587, got %s %v", addr, to)
+	}
+	for _, want := range []string{"Subject: [Graphium] Action cert-check failed", "To: ops@example.com, dev@example.com", "daysUntilExpiry: 5"} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("Expected %q in the email, got:\n%s", want, msg)
+		}
+	}
+}
+
+func TestEmailNotifier_NameCannotInjectHeaders(t *testing.T) {
+	notifier := NewEmailNotifier(config.SMTPConfig{Host: "smtp.example.com", Port: 25, From: "graphium@example.com", To: []string{"ops@example.com"}})
+	var msg string
+	notifier.send = func(_ string, _ smtp.Auth, _ string, _ []string, body []byte) error {
+		msg = string(body)
+		return nil
+	}
+
+	action, task := certCheck(t, models.TaskStatusFailed)
+	action.Name = "cert-check\r\nBcc: x@example.com"
+	if err := notifier.Notify(context.Background(), newNotification(action, task, false, false, 1)); err != nil {
+		t.Fatalf("Notify failed: %v", err)
+	}
+
+	header, _, _ := strings.Cut(msg, "\r\n\r\n")
+	var subject string
+	for _, line := range strings.Split(header, "\r\n") {
+		if strings.HasPrefix(line, "Bcc:") {
+			t.Fatalf("Expected the action name not to add a header, got:\n%s", header)
+		}
+		if encoded, ok := strings.CutPrefix(line, "Subject: "); ok {
+			subject, _ = new(mime.WordDecoder).DecodeHeader(encoded)
+		}
+	}
+	if !strings.Contains(subject, "Bcc: x@example.com") {
+		t.Errorf("Expected the encoded action name in the subject, got %q", subject)
+	}
+}
+
+// recordingNotifier is a notifier like a chat adapter would add
+type recordingNotifier struct {
+	received chan *Notification
+}
+
+func (r *recordingNotifier) Name() string { return "recording" }
+
+func (r *recordingNotifier) Notify(ctx context.Context, n *Notification) error {
+	r.received <- n
+	return nil
+}
+
+func TestScheduler_AddNotifier(t *testing.T) {
+	s := &Scheduler{}
+	notifier := &recordingNotifier{received: make(chan *Notification, 1)}
+	s.AddNotifier(notifier)
+
+	s.notifications.Dispatch(&Notification{ActionID: "action-1"})
+	select {
+	case n := <-notifier.received:
+		if n.ActionID != "action-1" {
+			t.Errorf("Unexpected notification %+v", n)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected the notification to be dispatched")
+	}
+}
+
+func TestNewNotifiers(t *testing.T) {
+	if len(NewNotifiers(config.NotificationsConfig{})) != 0 {
+		t.Error("Expected no notifiers without configuration")
+	}
+	notifiers := NewNotifiers(config.NotificationsConfig{
+		WebhookURL: "https://hooks.example.com/graphium",
+		SMTP:       config.SMTPConfig{Host: "smtp.example.com", Port: 587},
+	})
+	if len(notifiers) != 2 || notifiers[0].Name() != "webhook" || notifiers[1].Name() != "email" {
+		t.Errorf("Expected webhook and email notifiers, got %v", notifiers)
+	}
+}
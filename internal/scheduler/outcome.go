@@ -0,0 +1,103 @@
+package scheduler
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"evalgo.org/graphium/models"
+)
+
+// AddNotifier registers a notifier for the notifications of scheduled
+// actions.
+func (s *Scheduler) AddNotifier(notifier Notifier) {
+	s.notifications.Add(notifier)
+}
+
+// RecordOutcome records the outcome of a finished task on the scheduled
+// action with a retry policy or notifications that created it. If the task
+// failed and the retry policy allows another attempt, the attempt is
+// scheduled and RecordOutcome reports true: the run hasn't failed yet, so
+// the action's OnFailure action must not be triggered. It reports true as
+// well when the task was a step of a scale run and the next step was
+// created. Otherwise the run is decided and notified of if the action's
+// notifyOn asks for it.
+func (s *Scheduler) RecordOutcome(finished *models.AgentTask) (bool, error) {
+	done, success := models.TaskOutcome(finished.ActionStatus)
+	if !done || finished.ScheduledBy == "" {
+		return false, nil
+	}
+
+	action, err := s.storage.GetScheduledAction(finished.ScheduledBy)
+	if err != nil {
+		return false, fmt.Errorf("failed to get action %s: %w", finished.ScheduledBy, err)
+	}
+	// A scale run decides once its last step succeeded
+	if success {
+		if continued, err := s.continueScale(action, finished); continued || err != nil {
+			return continued, err
+		}
+	}
+	if action.RetryPolicy == nil && len(action.NotifyOn) == 0 {
+		return false, nil
+	}
+
+	// Only the task of the run's current attempt decides the run
+	attempt := max(finished.Attempt, 1)
+	if attempt != max(action.Attempt, 1) || action.RetryAt != nil {
+		return false, nil
+	}
+
+	now := time.Now()
+	previous := action.LastOutcome
+	if success {
+		value := map[string]interface{}{"taskId": finished.ID, "attempt": attempt}
+		if result, err := finished.GetResult(); err == nil && result != nil && len(result.Data) > 0 {
+			value["data"] = result.Data
+		}
+		action.MarkCompleted(&models.ActionResult{
+			Type:        "Thing",
+			Name:        "Completed",
+			Description: fmt.Sprintf("Task %s succeeded on attempt %d", finished.ID, attempt),
+			Value:       value,
+			Timestamp:   now,
+		})
+		action.LastOutcome = models.OutcomeSuccess
+	} else {
+		message := "task failed"
+		if finished.Error != nil && finished.Error.Message != "" {
+			message = finished.Error.Message
+		}
+		actionErr := &models.ActionError{
+			Type:        "Thing",
+			Name:        "TaskFailed",
+			Description: fmt.Sprintf("Attempt %d failed: %s", attempt, message),
+			Timestamp:   now,
+		}
+
+		if action.RetryPolicy != nil {
+			retryAt, reason := s.nextAttempt(action, finished, attempt, now)
+			if retryAt != nil {
+				action.MarkRetrying(attempt, *retryAt, actionErr)
+				if err := s.storage.UpdateScheduledAction(action); err != nil {
+					return false, err
+				}
+				log.Printf("Attempt %d of scheduled action %s failed, retrying at %s\n", attempt, action.ID, retryAt.Format(time.RFC3339))
+				return true, nil
+			}
+			actionErr.Description += " (not retried: " + reason + ")"
+		}
+		action.MarkFailed(actionErr)
+		action.LastOutcome = models.OutcomeFailure
+	}
+
+	if err := s.storage.UpdateScheduledAction(action); err != nil {
+		return false, err
+	}
+
+	if models.ShouldNotify(action.NotifyOn, previous, success) {
+		recovered := success && previous == models.OutcomeFailure
+		s.notifications.Dispatch(newNotification(action, finished, success, recovered, attempt))
+	}
+	return false, nil
+}
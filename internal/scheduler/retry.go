@@ -0,0 +1,73 @@
+package scheduler
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"evalgo.org/graphium/models"
+)
+
+// nextAttempt returns when the attempt following failed attempt number
+// attempt of an action's run is due, or nil and the reason the run gets no
+// further attempt: the task was cancelled, the action disabled, the policy
+// exhausted, or the next scheduled run would be due first.
+func (s *Scheduler) nextAttempt(action *models.ScheduledAction, finished *models.AgentTask, attempt int, now time.Time) (*time.Time, string) {
+	policy := action.RetryPolicy
+	switch {
+	case finished.ActionStatus == "cancelled":
+		return nil, "the task was cancelled"
+	case !action.Enabled:
+		return nil, "the action is disabled"
+	case !policy.CanRetry(attempt):
+		return nil, fmt.Sprintf("all %d attempts failed", policy.MaxAttempts)
+	}
+
+	retryAt := now.Add(policy.Backoff(attempt))
+	if action.StartTime != nil && action.Schedule != nil {
+		next := s.calculateNextExecution(*action.StartTime, action.Schedule, scheduleLocation(action.Schedule))
+		if next != nil && !retryAt.Before(*next) {
+			return nil, "the next scheduled run is due first"
+		}
+	}
+	return &retryAt, ""
+}
+
+// retry creates the task of the attempt of a failed run that is due.
+// Retries belong to the run, so the action's condition isn't evaluated
+// again and its start time is kept.
+func (s *Scheduler) retry(action *models.ScheduledAction) {
+	task, err := s.createTaskFromAction(action)
+	if err != nil {
+		log.Printf("Error creating retry task from action %s: %v\n", action.ID, err)
+		return
+	}
+	task.Attempt = action.Attempt + 1
+
+	// Refuse tasks on protected containers and commands exec doesn't allow
+	if name, violation := s.refusal(task); violation != "" {
+		log.Printf("Refusing retry of scheduled action %s: %s\n", action.ID, violation)
+		action.MarkFailed(&models.ActionError{
+			Type:        "Thing",
+			Name:        name,
+			Description: violation,
+			Timestamp:   time.Now(),
+		})
+		if err := s.storage.UpdateScheduledAction(action); err != nil {
+			log.Printf("Error updating action %s: %v\n", action.ID, err)
+		}
+		return
+	}
+
+	if err := s.storage.CreateTask(task); err != nil {
+		log.Printf("Error creating retry task for action %s: %v\n", action.ID, err)
+		return
+	}
+
+	action.MarkRetryStarted()
+	if err := s.storage.UpdateScheduledAction(action); err != nil {
+		log.Printf("Error updating action %s: %v\n", action.ID, err)
+	}
+
+	log.Printf("Created task %s for attempt %d of scheduled action %s\n", task.ID, task.Attempt, action.ID)
+}
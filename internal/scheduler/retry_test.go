@@ -0,0 +1,70 @@
+package scheduler
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"evalgo.org/graphium/models"
+)
+
+// retryingAction returns an enabled action running every hour since start
+// that allows three attempts per run, 30s and then 60s apart
+func retryingAction(start time.Time) *models.ScheduledAction {
+	return &models.ScheduledAction{
+		ID:          "action-1",
+		Enabled:     true,
+		StartTime:   &start,
+		Schedule:    &Schedule{RepeatFrequency: "PT1H"},
+		RetryPolicy: &models.RetryPolicy{MaxAttempts: 3},
+	}
+}
+
+func TestNextAttempt_Backoff(t *testing.T) {
+	s := &Scheduler{}
+	start := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	action := retryingAction(start)
+	failed := &models.AgentTask{ActionStatus: models.TaskStatusFailed}
+
+	for attempt, delay := range map[int]time.Duration{1: 30 * time.Second, 2: time.Minute} {
+		now := start.Add(5 * time.Minute)
+		retryAt, reason := s.nextAttempt(action, failed, attempt, now)
+		if retryAt == nil || !retryAt.Equal(now.Add(delay)) {
+			t.Errorf("Expected attempt %d to be retried after %v, got %v (%s)", attempt, delay, retryAt, reason)
+		}
+	}
+}
+
+func TestNextAttempt_TerminalFailure(t *testing.T) {
+	s := &Scheduler{}
+	start := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	now := start.Add(5 * time.Minute)
+
+	tests := []struct {
+		name    string
+		modify  func(*models.ScheduledAction, *models.AgentTask)
+		attempt int
+		reason  string
+	}{
+		{"attempts exhausted", func(*models.ScheduledAction, *models.AgentTask) {}, 3, "all 3 attempts failed"},
+		{"task cancelled", func(_ *models.ScheduledAction, task *models.AgentTask) { task.ActionStatus = "cancelled" }, 1, "cancelled"},
+		{"action disabled", func(action *models.ScheduledAction, _ *models.AgentTask) { action.Enabled = false }, 1, "disabled"},
+		{"next run due first", func(action *models.ScheduledAction, _ *models.AgentTask) {
+			action.Schedule.RepeatFrequency = "PT5M"
+		}, 1, "next scheduled run"},
+		{"next cron run due first", func(action *models.ScheduledAction, _ *models.AgentTask) {
+			action.Schedule.RepeatFrequency = ""
+			action.Schedule.CronExpression = "*/5 * * * *"
+		}, 1, "next scheduled run"},
+	}
+	for _, tt := range tests {
+		action := retryingAction(start)
+		task := &models.AgentTask{ActionStatus: models.TaskStatusFailed}
+		tt.modify(action, task)
+
+		retryAt, reason := s.nextAttempt(action, task, tt.attempt, now)
+		if retryAt != nil || !strings.Contains(reason, tt.reason) {
+			t.Errorf("%s: expected no retry because %q, got %v (%s)", tt.name, tt.reason, retryAt, reason)
+		}
+	}
+}
@@ -0,0 +1,225 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"time"
+
+	"eve.evalgo.org/semantic"
+
+	"evalgo.org/graphium/internal/stack"
+	"evalgo.org/graphium/models"
+)
+
+// SetPlacement sets the default placement strategy of scale actions and
+// whether their replicas may exceed a host's remaining capacity, like stack
+// deployments.
+func (s *Scheduler) SetPlacement(strategy string, allowOvercommit bool) {
+	s.placementStrategy = strategy
+	s.allowOvercommit = allowOvercommit
+}
+
+// PlanScale plans a run of a scale action and makes task its first step:
+// the replicas missing across the action's hosts are placed by the
+// placement strategy, surplus ones are taken from the hosts running the
+// most, and task converges the first host that changes. The steps of the
+// other hosts follow once it succeeds. Datacenter policies apply per host
+// as in stack deployments: they exclude hosts whose datacenter protects the
+// group, decide whether a host may be overcommitted, and supply the
+// strategy when all hosts share a datacenter and the spec names none.
+func (s *Scheduler) PlanScale(task *models.AgentTask, action *models.ScheduledAction) error {
+	spec, err := models.ScaleSpecFromInstrument(action.Instrument)
+	if err != nil {
+		return err
+	}
+	if err := spec.Validate(); err != nil {
+		return fmt.Errorf("invalid scale spec: %w", err)
+	}
+
+	policies, err := s.storage.ListDatacenterPolicies()
+	if err != nil {
+		return fmt.Errorf("failed to list datacenter policies: %w", err)
+	}
+	candidates, current, err := s.scaleHosts(spec, policies)
+	if err != nil {
+		return err
+	}
+
+	strategy := stack.BuiltinStrategy(scaleStrategy(spec, candidates, policies, s.placementStrategy))
+	if strategy == nil {
+		strategy = stack.BuiltinStrategy(models.PlacementFirstFit)
+	}
+	allowOvercommit := func(info *models.HostInfo) bool {
+		if policy := policies[info.Host.Datacenter]; policy != nil && policy.AllowOvercommit != nil {
+			return *policy.AllowOvercommit
+		}
+		return s.allowOvercommit
+	}
+
+	target, err := stack.PlanReplicas(context.Background(), strategy, &spec.Spec.ContainerSpec, candidates, current, spec.Replicas, allowOvercommit)
+	if err != nil {
+		return fmt.Errorf("failed to place %d replicas of %s: %w", spec.Replicas, spec.Group, err)
+	}
+	plan := models.NewScalePlan(current, target)
+	if len(plan.Steps) == 0 {
+		return fmt.Errorf("no hosts available for scale group %s", spec.Group)
+	}
+
+	return setScaleStep(task, &models.ScalePayload{
+		Group: spec.Group,
+		Spec:  spec.Spec,
+		Plan:  plan,
+	}, 0)
+}
+
+// scaleStrategy returns the name of the strategy placing the replicas of a
+// scale spec: the spec's own, else the policy of the one datacenter all
+// candidates are in, else fallback.
+func scaleStrategy(spec *models.ScaleSpec, candidates []*models.HostInfo, policies map[string]*models.DatacenterPolicy, fallback string) string {
+	if spec.PlacementStrategy != "" {
+		return spec.PlacementStrategy
+	}
+	if len(candidates) > 0 {
+		datacenter := candidates[0].Host.Datacenter
+		shared := !slices.ContainsFunc(candidates, func(info *models.HostInfo) bool {
+			return info.Host.Datacenter != datacenter
+		})
+		if policy := policies[datacenter]; shared && policy != nil && policy.PlacementStrategy != "" {
+			return policy.PlacementStrategy
+		}
+	}
+	return fallback
+}
+
+// scaleHosts returns the active hosts a scale spec may use, with their
+// load, and the replicas of its group running on each. Hosts whose
+// datacenter policy protects the group's name or image, or one of its
+// replicas there, are left out. Replicas on hosts left out or not active
+// aren't counted: a run neither removes them nor places replicas in their
+// stead.
+func (s *Scheduler) scaleHosts(spec *models.ScaleSpec, policies map[string]*models.DatacenterPolicy) ([]*models.HostInfo, map[string]int, error) {
+	hosts, err := s.storage.ListHosts(map[string]interface{}{"status": "active"})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list hosts: %w", err)
+	}
+	replicas, err := s.storage.GetContainersByLabel(models.ScaleGroupLabel, spec.Group)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list replicas of %s: %w", spec.Group, err)
+	}
+	stacks, err := s.storage.ListStacks(nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list stacks: %w", err)
+	}
+	committed := models.CommittedByHost(stacks)
+
+	var candidates []*models.HostInfo
+	current := make(map[string]int)
+	for _, host := range hosts {
+		if len(spec.Hosts) > 0 && !slices.Contains(spec.Hosts, host.ID) {
+			continue
+		}
+		if reason := scaleProtection(spec, host, replicas, policies); reason != "" {
+			log.Printf("Skipping host %s for scale group %s: %s\n", host.ID, spec.Group, reason)
+			continue
+		}
+		info := &models.HostInfo{Host: host}
+		if containers, err := s.storage.GetContainersByHost(host.ID); err == nil {
+			info.CurrentLoad.ContainerCount = len(containers)
+		}
+		if reserved := committed[host.ID]; reserved != nil {
+			info.CurrentLoad.CommittedCPUs = reserved.CPUs
+			info.CurrentLoad.CommittedMemory = reserved.Memory
+		}
+		candidates = append(candidates, info)
+	}
+
+	for _, container := range replicas {
+		if container.Status == "running" && slices.ContainsFunc(candidates, func(info *models.HostInfo) bool {
+			return info.Host.ID == container.HostedOn
+		}) {
+			current[container.HostedOn]++
+		}
+	}
+	return candidates, current, nil
+}
+
+// scaleProtection explains why the datacenter policy of a host protects a
+// scale group there, or returns "": the group's name or image, or a
+// replica of the group on the host, is protected.
+func scaleProtection(spec *models.ScaleSpec, host *models.Host, replicas []*models.Container, policies map[string]*models.DatacenterPolicy) string {
+	policy := policies[host.Datacenter]
+	if policy == nil {
+		return ""
+	}
+	protection := policy.Protection()
+	if ok, reason := protection.Protects(spec.Group, spec.Spec.ContainerSpec.Image); ok {
+		return fmt.Sprintf("datacenter %s protects it: %s", host.Datacenter, reason)
+	}
+	for _, replica := range replicas {
+		if replica.HostedOn != host.ID {
+			continue
+		}
+		if ok, reason := protection.Protects(replica.Name, replica.Image); ok {
+			return fmt.Sprintf("datacenter %s protects replica %s: %s", host.Datacenter, replica.Name, reason)
+		}
+	}
+	return ""
+}
+
+// setScaleStep makes task the step of a scale run with the given index.
+func setScaleStep(task *models.AgentTask, payload *models.ScalePayload, index int) error {
+	step := payload.Plan.Steps[index]
+	payload.Step = index
+	payload.Replicas = step.Replicas
+
+	task.HostID = step.HostID
+	task.Agent = &semantic.SemanticAgent{
+		Type: "SoftwareApplication",
+		Name: step.HostID,
+	}
+	if err := task.SetPayload(payload); err != nil {
+		return fmt.Errorf("failed to set task payload: %w", err)
+	}
+	return nil
+}
+
+// continueScale creates the task of the next step of a scale run whose
+// current step succeeded. It reports true if it did: the run goes on and
+// isn't decided yet. Runs of disabled actions stop after the current step.
+func (s *Scheduler) continueScale(action *models.ScheduledAction, finished *models.AgentTask) (bool, error) {
+	if finished.Type != models.ActionTypeScale || !action.Enabled {
+		return false, nil
+	}
+	if max(finished.Attempt, 1) != max(action.Attempt, 1) || action.RetryAt != nil {
+		return false, nil
+	}
+
+	var payload models.ScalePayload
+	if err := finished.GetPayloadAs(&payload); err != nil || payload.Plan == nil || payload.Step+1 >= len(payload.Plan.Steps) {
+		return false, nil
+	}
+
+	task := &models.AgentTask{
+		Context:      "https://schema.org",
+		ID:           models.GenerateID("task"),
+		Type:         finished.Type,
+		ActionStatus: models.TaskStatusPending,
+		ScheduledBy:  finished.ScheduledBy,
+		Attempt:      finished.Attempt,
+		CreatedAt:    time.Now(),
+		TriggeredBy:  finished.TriggeredBy,
+		ChainPath:    finished.ChainPath,
+	}
+	if err := setScaleStep(task, &payload, payload.Step+1); err != nil {
+		return false, err
+	}
+	if err := s.storage.CreateTask(task); err != nil {
+		return false, fmt.Errorf("failed to create task for step %d of scale action %s: %w", payload.Step+1, action.ID, err)
+	}
+
+	log.Printf("Created task %s for step %d of %d of scale action %s on host %s\n",
+		task.ID, payload.Step+1, len(payload.Plan.Steps), action.ID, task.HostID)
+	return true, nil
+}
@@ -0,0 +1,92 @@
+package scheduler
+
+import (
+	"testing"
+
+	"evalgo.org/graphium/models"
+)
+
+func TestSetScaleStep(t *testing.T) {
+	plan := models.NewScalePlan(
+		map[string]int{"host-1": 3},
+		map[string]int{"host-1": 1, "host-2": 2},
+	)
+	payload := &models.ScalePayload{
+		Group: "web",
+		Spec:  models.DeployContainerPayload{ContainerSpec: models.ContainerSpec{Image: "nginx:alpine"}},
+		Plan:  plan,
+	}
+
+	task := &models.AgentTask{Type: models.ActionTypeScale}
+	if err := setScaleStep(task, payload, 1); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if task.HostID != "host-1" || task.Agent == nil || task.Agent.Name != "host-1" {
+		t.Errorf("Expected the second step on host-1, got host %q", task.HostID)
+	}
+
+	var got models.ScalePayload
+	if err := task.GetPayloadAs(&got); err != nil {
+		t.Fatalf("Unexpected error reading payload: %v", err)
+	}
+	if got.Step != 1 || got.Replicas != 1 || got.Group != "web" || got.Plan == nil || len(got.Plan.Steps) != 2 {
+		t.Errorf("Unexpected payload %+v", got)
+	}
+	if err := got.Validate(); err != nil {
+		t.Errorf("Validate() = %v, want nil", err)
+	}
+}
+
+func TestScaleStrategy(t *testing.T) {
+	policies := map[string]*models.DatacenterPolicy{
+		"dc1": {Datacenter: "dc1", PlacementStrategy: models.PlacementBinPack},
+	}
+	host := func(id, datacenter string) *models.HostInfo {
+		return &models.HostInfo{Host: &models.Host{ID: id, Datacenter: datacenter}}
+	}
+
+	tests := []struct {
+		name       string
+		spec       string
+		candidates []*models.HostInfo
+		want       string
+	}{
+		{"spec strategy", models.PlacementSpread, []*models.HostInfo{host("h1", "dc1")}, models.PlacementSpread},
+		{"shared datacenter", "", []*models.HostInfo{host("h1", "dc1"), host("h2", "dc1")}, models.PlacementBinPack},
+		{"mixed datacenters", "", []*models.HostInfo{host("h1", "dc1"), host("h2", "dc2")}, models.PlacementFirstFit},
+		{"no candidates", "", nil, models.PlacementFirstFit},
+	}
+	for _, tt := range tests {
+		spec := &models.ScaleSpec{Group: "web", PlacementStrategy: tt.spec}
+		if got := scaleStrategy(spec, tt.candidates, policies, models.PlacementFirstFit); got != tt.want {
+			t.Errorf("%s: scaleStrategy() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestScaleProtection(t *testing.T) {
+	policies := map[string]*models.DatacenterPolicy{
+		"dc1": {Datacenter: "dc1", ProtectedImages: []string{"postgres"}},
+		"dc2": {Datacenter: "dc2", ProtectedNamePatterns: []string{"web-legacy*"}},
+	}
+	replicas := []*models.Container{{Name: "web-legacy1", Image: "nginx:alpine", HostedOn: "h2"}}
+
+	tests := []struct {
+		name      string
+		image     string
+		host      *models.Host
+		protected bool
+	}{
+		{"no policy", "postgres:16", &models.Host{ID: "h3", Datacenter: "dc3"}, false},
+		{"protected image", "postgres:16", &models.Host{ID: "h1", Datacenter: "dc1"}, true},
+		{"other image", "nginx:alpine", &models.Host{ID: "h1", Datacenter: "dc1"}, false},
+		{"protected replica", "nginx:alpine", &models.Host{ID: "h2", Datacenter: "dc2"}, true},
+		{"replica elsewhere", "nginx:alpine", &models.Host{ID: "h4", Datacenter: "dc2"}, false},
+	}
+	for _, tt := range tests {
+		spec := &models.ScaleSpec{Group: "web", Spec: models.DeployContainerPayload{ContainerSpec: models.ContainerSpec{Image: tt.image}}}
+		if got := scaleProtection(spec, tt.host, replicas, policies) != ""; got != tt.protected {
+			t.Errorf("%s: protected = %v, want %v", tt.name, got, tt.protected)
+		}
+	}
+}
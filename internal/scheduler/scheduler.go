@@ -14,21 +14,56 @@ import (
 
 // Scheduler manages scheduled actions and creates tasks for execution
 type Scheduler struct {
-	storage *storage.Storage
-	ticker  *time.Ticker
-	stop    chan bool
-	running bool
+	storage    *storage.Storage
+	protection *models.ProtectionPolicy
+	ticker     *time.Ticker
+	stop       chan bool
+	running    bool
+
+	// notifications delivers the notifications of decided runs
+	notifications Dispatcher
+
+	// placementStrategy and allowOvercommit place the replicas of scale
+	// actions (see SetPlacement)
+	placementStrategy string
+	allowOvercommit   bool
+
+	// exec decides which commands tasks may run inside containers (see
+	// SetExecPolicy)
+	exec *models.ExecPolicy
 }
 
-// New creates a new scheduler instance
-func New(store *storage.Storage) *Scheduler {
+// New creates a new scheduler instance. Actions that would delete, stop or
+// control a container covered by protection fail instead of creating a task.
+func New(store *storage.Storage, protection *models.ProtectionPolicy) *Scheduler {
 	return &Scheduler{
-		storage: store,
-		stop:    make(chan bool),
-		running: false,
+		storage:    store,
+		protection: protection,
+		stop:       make(chan bool),
+		running:    false,
 	}
 }
 
+// SetExecPolicy sets which commands actions may run inside containers.
+// Without one, actions running commands fail instead of creating a task.
+func (s *Scheduler) SetExecPolicy(policy *models.ExecPolicy) {
+	s.exec = policy
+}
+
+// refusal returns why a task must not be created, as an error name and a
+// description: it deletes, stops or controls a protected container, or runs
+// a command the exec policy doesn't allow. The description is "" if the
+// task is allowed.
+func (s *Scheduler) refusal(task *models.AgentTask) (string, string) {
+	if violation := s.protection.ProtectedTarget(task, s.storage.GetContainer); violation != "" {
+		return "ProtectedContainer", violation
+	}
+	if violation := s.exec.ExecViolation(task, s.storage.GetContainer); violation != "" {
+		return "ExecNotAllowed", violation
+	}
+	return "", ""
+}
+
 // Start begins the scheduler loop
 func (s *Scheduler) Start() {
 	if s.running {
@@ -37,7 +72,7 @@ func (s *Scheduler) Start() {
 	}
 
 	s.running = true
-	s.ticker = time.NewTicker(30 * time.Second) // Evaluate every 30 seconds
+	s.ticker = time.NewTicker(evaluationInterval)
 
 	log.Println("Scheduler started - evaluating schedules every 30 seconds")
 
@@ -84,6 +119,14 @@ func (s *Scheduler) evaluateSchedules() {
 	now := time.Now()
 
 	for _, action := range actions {
+		// Run the next attempt of a failed run once its backoff has passed
+		if action.RetryAt != nil {
+			if !now.Before(*action.RetryAt) {
+				s.retry(action)
+			}
+			continue
+		}
+
 		// Skip if currently executing
 		if action.ActionStatus == models.ActionStatusActive {
 			continue
@@ -91,7 +134,33 @@ func (s *Scheduler) evaluateSchedules() {
 
 		// Determine if action should execute now
 		if s.shouldExecute(action, now) {
-			log.Printf("Executing scheduled action: %s (type: %s)\n", action.Name, action.Type)
+			// Check-then-act: skip the run unless the condition holds
+			if action.Condition != nil {
+				holds, err := s.evaluateCondition(action)
+				if err != nil {
+					log.Printf("Error evaluating condition of action %s: %v\n", action.ID, err)
+					action.MarkRunFailed(&models.ActionError{
+						Type:        "Thing",
+						Name:        "ConditionError",
+						Description: err.Error(),
+						Timestamp:   now,
+					})
+					if err := s.storage.UpdateScheduledAction(action); err != nil {
+						log.Printf("Error updating action %s: %v\n", action.ID, err)
+					}
+					continue
+				}
+				if !holds {
+					log.Printf("Skipping scheduled action %s: condition %s not met\n", action.ID, action.Condition)
+					action.MarkSkipped("Condition not met: " + action.Condition.String())
+					if err := s.storage.UpdateScheduledAction(action); err != nil {
+						log.Printf("Error updating action %s: %v\n", action.ID, err)
+					}
+					continue
+				}
+			}
+
+			log.Printf("Executing scheduled action: %s (type: %s, schedule: %s)\n", action.Name, action.Type, FormatSchedule(action.Schedule))
 
 			// Create task from action
 			task, err := s.createTaskFromAction(action)
@@ -100,6 +169,21 @@ func (s *Scheduler) evaluateSchedules() {
 				continue
 			}
 
+			// Refuse tasks on protected containers and commands exec doesn't allow
+			if name, violation := s.refusal(task); violation != "" {
+				log.Printf("Refusing scheduled action %s: %s\n", action.ID, violation)
+				action.MarkRunFailed(&models.ActionError{
+					Type:        "Thing",
+					Name:        name,
+					Description: violation,
+					Timestamp:   now,
+				})
+				if err := s.storage.UpdateScheduledAction(action); err != nil {
+					log.Printf("Error updating action %s: %v\n", action.ID, err)
+				}
+				continue
+			}
+
 			// Create the task
 			if err := s.storage.CreateTask(task); err != nil {
 				log.Printf("Error creating task for action %s: %v\n", action.ID, err)
@@ -145,10 +229,7 @@ func (s *Scheduler) shouldExecute(action *models.ScheduledAction, now time.Time)
 	}
 
 	// Get timezone
-	loc, err := time.LoadLocation(schedule.ScheduleTimezone)
-	if err != nil {
-		loc = time.UTC
-	}
+	loc := scheduleLocation(schedule)
 	now = now.In(loc)
 
 	// Check by day, month, monthday constraints
@@ -160,11 +241,11 @@ func (s *Scheduler) shouldExecute(action *models.ScheduledAction, now time.Time)
 	lastExecution := action.StartTime
 	if lastExecution == nil {
 		// Never executed before - check if we should execute now
-		return s.shouldExecuteFirstTime(action, now)
+		return s.shouldExecuteFirstTime(action, now, loc)
 	}
 
-	// Calculate next execution time based on repeat frequency
-	nextExecution := s.calculateNextExecution(*lastExecution, schedule)
+	// Calculate next execution time based on cron expression or repeat frequency
+	nextExecution := s.calculateNextExecution(*lastExecution, schedule, loc)
 	if nextExecution == nil {
 		return false
 	}
@@ -173,10 +254,106 @@ func (s *Scheduler) shouldExecute(action *models.ScheduledAction, now time.Time)
 	return now.After(*nextExecution) || now.Equal(*nextExecution)
 }
 
+// scheduleLocation returns the timezone of a schedule, UTC if it has none
+// or an unknown one.
+func scheduleLocation(schedule *Schedule) *time.Location {
+	loc, err := time.LoadLocation(schedule.ScheduleTimezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// evaluateCondition reports whether the condition of an action holds for the
+// current container or host state, or the latest result of a check.
+func (s *Scheduler) evaluateCondition(action *models.ScheduledAction) (bool, error) {
+	subject, err := s.conditionSubject(action)
+	if err != nil {
+		return false, err
+	}
+	return action.Condition.Evaluate(subject)
+}
+
+// conditionSubject loads the document an action's condition is evaluated
+// against.
+func (s *Scheduler) conditionSubject(action *models.ScheduledAction) (interface{}, error) {
+	condition := action.Condition
+	switch condition.Source {
+	case models.ConditionSourceContainer:
+		id := condition.TargetID
+		if id == "" && action.Object != nil {
+			id = action.Object.ID
+		}
+		if id == "" {
+			return nil, fmt.Errorf("container condition needs a targetId or an action object")
+		}
+		return s.storage.GetContainer(id)
+
+	case models.ConditionSourceHost:
+		id := condition.TargetID
+		if id == "" {
+			id = action.Agent
+		}
+		return s.storage.GetHost(id)
+
+	case models.ConditionSourceCheck:
+		actionID := condition.ActionID
+		if actionID == "" {
+			actionID = action.ID
+		}
+		tasks, err := s.storage.GetTasksByScheduledAction(actionID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get results of action %s: %w", actionID, err)
+		}
+		return checkResult(tasks)
+	}
+	return nil, fmt.Errorf("unknown condition source %q", condition.Source)
+}
+
+// checkResult returns the result of the most recently finished task along
+// with its action status. Before any task has finished, every field is
+// missing.
+func checkResult(tasks []*models.AgentTask) (map[string]interface{}, error) {
+	var latest *models.AgentTask
+	for _, task := range tasks {
+		if finished, _ := models.TaskOutcome(task.ActionStatus); !finished || task.EndTime == nil {
+			continue
+		}
+		if latest == nil || task.EndTime.After(*latest.EndTime) {
+			latest = task
+		}
+	}
+	if latest == nil {
+		return map[string]interface{}{}, nil
+	}
+
+	subject := map[string]interface{}{"status": latest.ActionStatus}
+	result, err := latest.GetResult()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read result of task %s: %w", latest.ID, err)
+	}
+	if result != nil {
+		subject["success"] = result.Success
+		subject["message"] = result.Message
+		subject["data"] = result.Data
+	}
+	return subject, nil
+}
+
 // shouldExecuteFirstTime determines if an action should execute for the first time
-func (s *Scheduler) shouldExecuteFirstTime(action *models.ScheduledAction, now time.Time) bool {
+func (s *Scheduler) shouldExecuteFirstTime(action *models.ScheduledAction, now time.Time, loc *time.Location) bool {
 	schedule := action.Schedule
 
+	// Cron schedules first run at the first matching time after they start
+	if expr := cronExpression(schedule); expr != "" {
+		from := action.CreatedAt
+		if schedule.StartDate != nil {
+			from = schedule.StartDate.Add(-time.Second)
+		}
+		next := nextCronExecution(expr, from, loc)
+		return next != nil && !now.Before(*next)
+	}
+
 	// If there's a start date, check if we've passed it
 	if schedule.StartDate != nil {
 		if now.Before(*schedule.StartDate) {
@@ -190,97 +367,28 @@ func (s *Scheduler) shouldExecuteFirstTime(action *models.ScheduledAction, now t
 	return true
 }
 
-// calculateNextExecution calculates when the action should next execute
-func (s *Scheduler) calculateNextExecution(lastExecution time.Time, schedule *Schedule) *time.Time {
-	// Parse repeat frequency
-	duration, isCron := s.parseRepeatFrequency(schedule.RepeatFrequency)
-
-	if isCron {
-		// For cron expressions, we need a cron parser
-		// For now, return nil - we'll implement this next
-		return nil
-	}
-
-	// Add duration to last execution
-	next := lastExecution.Add(duration)
-	return &next
-}
-
-// parseRepeatFrequency parses ISO 8601 duration or cron expression
-// Returns duration and bool indicating if it's a cron expression
-func (s *Scheduler) parseRepeatFrequency(freq string) (time.Duration, bool) {
-	// Check if it's a cron expression (contains spaces)
-	if strings.Contains(freq, " ") {
-		return 0, true
+// calculateNextExecution calculates when the action should next execute.
+// Cron expressions and calendar months and years of repeat frequencies are
+// evaluated in the schedule's timezone loc.
+func (s *Scheduler) calculateNextExecution(lastExecution time.Time, schedule *Schedule, loc *time.Location) *time.Time {
+	if expr := cronExpression(schedule); expr != "" {
+		next := nextCronExecution(expr, lastExecution, loc)
+		if next == nil {
+			log.Printf("Error parsing cron expression '%s'\n", expr)
+		}
+		return next
 	}
 
 	// Parse ISO 8601 duration
-	duration, err := parseISO8601Duration(freq)
+	duration, err := parseISO8601Duration(schedule.RepeatFrequency)
 	if err != nil {
-		log.Printf("Error parsing repeat frequency '%s': %v\n", freq, err)
-		return 0, false
-	}
-
-	return duration, false
-}
-
-// parseISO8601Duration parses ISO 8601 duration strings
-// Examples: PT5M (5 minutes), PT1H (1 hour), P1D (1 day), P1W (1 week)
-func parseISO8601Duration(duration string) (time.Duration, error) {
-	if duration == "" {
-		return 0, fmt.Errorf("empty duration")
-	}
-
-	// Simple parser for common cases
-	// Full ISO 8601 parser would be more complex
-	switch {
-	case strings.HasPrefix(duration, "PT"):
-		// Time duration
-		timepart := duration[2:]
-		if strings.HasSuffix(timepart, "S") {
-			// Seconds
-			var seconds int
-			if _, err := fmt.Sscanf(timepart, "%dS", &seconds); err == nil {
-				return time.Duration(seconds) * time.Second, nil
-			}
-		} else if strings.HasSuffix(timepart, "M") {
-			// Minutes
-			var minutes int
-			if _, err := fmt.Sscanf(timepart, "%dM", &minutes); err == nil {
-				return time.Duration(minutes) * time.Minute, nil
-			}
-		} else if strings.HasSuffix(timepart, "H") {
-			// Hours
-			var hours int
-			if _, err := fmt.Sscanf(timepart, "%dH", &hours); err == nil {
-				return time.Duration(hours) * time.Hour, nil
-			}
-		}
-	case strings.HasPrefix(duration, "P"):
-		// Date duration
-		datepart := duration[1:]
-		if strings.HasSuffix(datepart, "D") {
-			// Days
-			var days int
-			if _, err := fmt.Sscanf(datepart, "%dD", &days); err == nil {
-				return time.Duration(days) * 24 * time.Hour, nil
-			}
-		} else if strings.HasSuffix(datepart, "W") {
-			// Weeks
-			var weeks int
-			if _, err := fmt.Sscanf(datepart, "%dW", &weeks); err == nil {
-				return time.Duration(weeks) * 7 * 24 * time.Hour, nil
-			}
-		} else if strings.HasSuffix(datepart, "M") {
-			// Months (approximate - 30 days)
-			var months int
-			if _, err := fmt.Sscanf(datepart, "%dM", &months); err == nil {
-				return time.Duration(months) * 30 * 24 * time.Hour, nil
-			}
-		}
+		log.Printf("Error parsing repeat frequency '%s': %v\n", schedule.RepeatFrequency, err)
+		return nil
 	}
 
-	return 0, fmt.Errorf("unsupported duration format: %s", duration)
+	// Add duration to last execution, months and years in the schedule's timezone
+	next := duration.addTo(lastExecution.In(loc))
+	return &next
 }
 
 // matchesDayConstraints checks if the given time matches day/month constraints
@@ -353,6 +461,7 @@ func (s *Scheduler) createTaskFromAction(action *models.ScheduledAction) (*model
 		HostID:       action.Agent,
 		ActionStatus: models.TaskStatusPending,
 		ScheduledBy:  action.ID, // Link task to the action that created it
+		Attempt:      1,
 		CreatedAt:    now,
 		Agent: &semantic.SemanticAgent{
 			Type: "SoftwareApplication",
@@ -376,6 +485,14 @@ func (s *Scheduler) createTaskFromAction(action *models.ScheduledAction) (*model
 		task.Type = action.Type
 	}
 
+	// Spread the replicas of a scale action over the hosts
+	if action.Type == models.ActionTypeScale {
+		if err := s.PlanScale(task, action); err != nil {
+			return nil, err
+		}
+		return task, nil
+	}
+
 	// Build payload from action instrument and object
 	payload := make(map[string]interface{})
 
@@ -0,0 +1,381 @@
+package stack
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/go-connections/nat"
+
+	"eve.evalgo.org/common"
+
+	"evalgo.org/graphium/models"
+)
+
+// DeployMode is how a stack is deployed.
+type DeployMode string
+
+// DeployModeBlueGreen deploys a new version of a stack alongside the running
+// one: its green containers get the -green suffix until Promote cuts over
+// to them. The default mode deploys containers under their own names.
+const DeployModeBlueGreen DeployMode = "blue-green"
+
+// Blue-green cutover statuses.
+const (
+	BlueGreenPending  = "pending"
+	BlueGreenPromoted = "promoted"
+)
+
+const (
+	// greenSuffix is appended to the names of green containers until they
+	// are promoted.
+	greenSuffix = "-green"
+
+	// blueSuffix is appended to the names of blue containers while green
+	// ones take their names over.
+	blueSuffix = "-blue"
+)
+
+// containerRenamer is implemented by Docker clients that can rename a
+// container. The Docker SDK client implements it.
+type containerRenamer interface {
+	ContainerRename(ctx context.Context, containerID, newContainerName string) error
+}
+
+// containerName returns the name of the stack container of a spec, with the
+// green suffix in blue-green mode.
+func (o DeployOptions) containerName(name string) string {
+	if o.Mode == DeployModeBlueGreen {
+		return fmt.Sprintf("%s-%s%s", o.StackName, name, greenSuffix)
+	}
+	return fmt.Sprintf("%s-%s", o.StackName, name)
+}
+
+// blueGreenPlan returns the cutover state of a blue-green deployment and the
+// plan to deploy its green containers with.
+//
+// Green containers run alongside the blue containers of opts.Blue, so they
+// can't publish the same fixed host ports: green containers publish all
+// their ports on ephemeral host ports, and those with fixed ones are
+// recreated on them by Promote once the blue containers released them. The
+// recreated container keeps the green one's mounts, file mounts included.
+//
+// The green containers join the blue deployment's network, and share its
+// volumes, without taking them over until they're promoted, so a failed
+// green deployment rolls back without touching blue.
+func blueGreenPlan(plan *models.DeploymentPlan, opts DeployOptions) (*models.BlueGreenState, *models.DeploymentPlan) {
+	bg := &models.BlueGreenState{
+		Status: BlueGreenPending,
+		Ports:  make(map[string][]models.PortMapping),
+	}
+	for _, spec := range plan.ContainerSpecs {
+		var fixed []models.PortMapping
+		for _, p := range spec.Ports {
+			if p.HostPort != 0 {
+				fixed = append(fixed, p)
+			}
+		}
+		if len(fixed) == 0 {
+			continue
+		}
+		bg.Ports[fmt.Sprintf("%s-%s", opts.StackName, spec.Name)] = fixed
+	}
+
+	blue := opts.Blue
+	if blue == nil {
+		return bg, plan
+	}
+	bg.BlueDeploymentID = blue.ID
+	bg.Blue = make(map[string]*models.ContainerPlacement, len(blue.Placements))
+	for name, placement := range blue.Placements {
+		bg.Blue[name] = placement
+	}
+	bg.BlueNetwork = blue.NetworkInfo
+	bg.BlueVolumes = blue.VolumeInfo
+
+	if plan.Network != nil && blue.NetworkInfo != nil {
+		spec := *plan.Network
+		spec.Name = blue.NetworkInfo.NetworkName
+		spec.External = true
+		green := *plan
+		green.Network = &spec
+		plan = &green
+	}
+	return bg, plan
+}
+
+// publishEphemeral publishes the ports of a green container on ephemeral
+// host ports, so they don't conflict with the blue container's.
+func publishEphemeral(hostConfig *container.HostConfig) {
+	for _, bindings := range hostConfig.PortBindings {
+		for i := range bindings {
+			bindings[i].HostPort = ""
+		}
+	}
+}
+
+// Promote cuts a pending blue-green deployment over to its green
+// containers. Each blue container is renamed out of the way with the -blue
+// suffix, its green replacement takes its name over and the blue container
+// is removed, as are blue containers the new version no longer has. Green
+// containers with fixed host ports are recreated on them once their blue
+// container is stopped; if that fails, the blue container is restored. The
+// promoted deployment takes the blue deployment's network and volumes over.
+func (d *Deployer) Promote(ctx context.Context, state *models.DeploymentState) error {
+	if state == nil {
+		return fmt.Errorf("deployment state is nil")
+	}
+	bg := state.BlueGreen
+	if bg == nil || bg.Status != BlueGreenPending {
+		return fmt.Errorf("deployment %s has no pending blue-green cutover", state.ID)
+	}
+	if state.Status != "running" {
+		return fmt.Errorf("deployment %s is %s, only running deployments can be promoted", state.ID, state.Status)
+	}
+
+	state.Phase = "promotion"
+	d.addEvent(state, "info", "promotion", "", "Promoting green containers")
+
+	greens := make([]string, 0, len(state.Placements))
+	for name := range state.Placements {
+		if strings.HasSuffix(name, greenSuffix) {
+			greens = append(greens, name)
+		}
+	}
+	sort.Strings(greens)
+	for _, green := range greens {
+		if err := d.promoteContainer(ctx, state, green); err != nil {
+			d.addEvent(state, "error", "promotion", green, err.Error())
+			_ = d.DB.Update(ctx, state)
+			return fmt.Errorf("failed to promote container %s: %w", green, err)
+		}
+	}
+
+	// Blue containers the new version no longer has
+	blues := make([]string, 0, len(bg.Blue))
+	for name := range bg.Blue {
+		blues = append(blues, name)
+	}
+	sort.Strings(blues)
+	for _, name := range blues {
+		d.removeBlue(ctx, state, name)
+	}
+
+	if bg.BlueNetwork != nil {
+		state.NetworkInfo = bg.BlueNetwork
+	}
+	for name, info := range bg.BlueVolumes {
+		if state.VolumeInfo == nil {
+			state.VolumeInfo = make(map[string]*models.VolumeInfo)
+		}
+		if _, ok := state.VolumeInfo[name]; !ok {
+			state.VolumeInfo[name] = info
+		}
+	}
+
+	now := time.Now()
+	bg.Status = BlueGreenPromoted
+	bg.PromotedAt = &now
+	state.Phase = "promoted"
+	d.addEvent(state, "info", "promotion", "", fmt.Sprintf("Promoted %d green container(s)", len(greens)))
+
+	return d.DB.Update(ctx, state)
+}
+
+// promoteContainer gives a green container the name of the blue container
+// it replaces and removes the blue one.
+func (d *Deployer) promoteContainer(ctx context.Context, state *models.DeploymentState, green string) error {
+	bg := state.BlueGreen
+	placement := state.Placements[green]
+	name := strings.TrimSuffix(green, greenSuffix)
+
+	client, err := d.DockerClientFactory.GetClient(ctx, placement.HostID)
+	if err != nil {
+		return fmt.Errorf("failed to get Docker client: %w", err)
+	}
+	renamer, ok := client.(containerRenamer)
+	if !ok {
+		return fmt.Errorf("docker client does not support renaming containers")
+	}
+
+	if blue := bg.Blue[name]; blue != nil {
+		blueClient, err := d.DockerClientFactory.GetClient(ctx, blue.HostID)
+		if err != nil {
+			return fmt.Errorf("failed to get Docker client: %w", err)
+		}
+		blueRenamer, ok := blueClient.(containerRenamer)
+		if !ok {
+			return fmt.Errorf("docker client does not support renaming containers")
+		}
+		if err := blueRenamer.ContainerRename(ctx, blue.ContainerID, name+blueSuffix); err != nil {
+			return fmt.Errorf("failed to rename blue container: %w", err)
+		}
+		d.addEvent(state, "info", "promotion", name,
+			fmt.Sprintf("Renamed blue container %s to %s", name, name+blueSuffix))
+	}
+
+	if ports := bg.Ports[name]; len(ports) > 0 {
+		// The fixed host ports are only free once blue is stopped; blue is
+		// removed only after green took them over, and restored otherwise
+		if err := d.stopBlue(ctx, state, name); err != nil {
+			d.restoreBlue(ctx, state, name)
+			return err
+		}
+		if err := d.recreateOnPorts(ctx, client, state, placement, name, ports); err != nil {
+			d.restoreBlue(ctx, state, name)
+			return err
+		}
+		d.removeBlue(ctx, state, name)
+	} else {
+		if err := renamer.ContainerRename(ctx, placement.ContainerID, name); err != nil {
+			return fmt.Errorf("failed to rename green container: %w", err)
+		}
+		d.removeBlue(ctx, state, name)
+	}
+
+	delete(state.Placements, green)
+	placement.ContainerName = name
+	state.Placements[name] = placement
+	d.addEvent(state, "info", "promotion", name,
+		fmt.Sprintf("Green container %s promoted to %s", green, name))
+	return nil
+}
+
+// stopBlue stops the blue container named name, if any.
+func (d *Deployer) stopBlue(ctx context.Context, state *models.DeploymentState, name string) error {
+	blue := state.BlueGreen.Blue[name]
+	if blue == nil {
+		return nil
+	}
+	client, err := d.DockerClientFactory.GetClient(ctx, blue.HostID)
+	if err != nil {
+		return fmt.Errorf("failed to get Docker client: %w", err)
+	}
+	if err := client.ContainerStop(ctx, blue.ContainerID, container.StopOptions{}); err != nil {
+		return fmt.Errorf("failed to stop blue container: %w", err)
+	}
+	d.addEvent(state, "info", "promotion", name, fmt.Sprintf("Stopped blue container %s", name))
+	return nil
+}
+
+// restoreBlue starts the blue container named name again under its own
+// name, after its green replacement failed to take over. Failures are
+// recorded as events only.
+func (d *Deployer) restoreBlue(ctx context.Context, state *models.DeploymentState, name string) {
+	blue := state.BlueGreen.Blue[name]
+	if blue == nil {
+		return
+	}
+
+	client, err := d.DockerClientFactory.GetClient(ctx, blue.HostID)
+	if err == nil {
+		err = client.ContainerStart(ctx, blue.ContainerID, container.StartOptions{})
+	}
+	if err == nil {
+		if renamer, ok := client.(containerRenamer); ok {
+			err = renamer.ContainerRename(ctx, blue.ContainerID, name)
+		}
+	}
+	if err != nil {
+		d.addEvent(state, "error", "promotion", name,
+			fmt.Sprintf("Failed to restore blue container %s: %v", name, err))
+		return
+	}
+	d.addEvent(state, "info", "promotion", name, fmt.Sprintf("Restored blue container %s", name))
+}
+
+// removeBlue stops and removes the blue container named name, if any.
+// Failures are recorded as events only, as the green containers already
+// took over.
+func (d *Deployer) removeBlue(ctx context.Context, state *models.DeploymentState, name string) {
+	blue := state.BlueGreen.Blue[name]
+	if blue == nil {
+		return
+	}
+	delete(state.BlueGreen.Blue, name)
+
+	client, err := d.DockerClientFactory.GetClient(ctx, blue.HostID)
+	if err == nil {
+		_ = client.ContainerStop(ctx, blue.ContainerID, container.StopOptions{})
+		err = removeContainer(ctx, client, blue.ContainerID, container.RemoveOptions{Force: true})
+	}
+	if err != nil {
+		d.addEvent(state, "warning", "promotion", name,
+			fmt.Sprintf("Failed to remove blue container %s: %v", name, err))
+		return
+	}
+	d.addEvent(state, "info", "promotion", name, fmt.Sprintf("Removed blue container %s", name))
+}
+
+// recreateOnPorts replaces the green container of placement with one named
+// name, created from its configuration with the fixed host ports published.
+func (d *Deployer) recreateOnPorts(ctx context.Context, client common.DockerClient, state *models.DeploymentState, placement *models.ContainerPlacement, name string, ports []models.PortMapping) error {
+	green, err := client.ContainerInspect(ctx, placement.ContainerID)
+	if err != nil {
+		return fmt.Errorf("failed to inspect green container: %w", err)
+	}
+	if green.Config == nil {
+		return fmt.Errorf("green container %s has no configuration", placement.ContainerName)
+	}
+
+	hostConfig := &container.HostConfig{}
+	if green.HostConfig != nil {
+		copied := *green.HostConfig
+		hostConfig = &copied
+	}
+	hostConfig.PortBindings = make(nat.PortMap)
+	if green.HostConfig != nil {
+		for port, bindings := range green.HostConfig.PortBindings {
+			hostConfig.PortBindings[port] = bindings
+		}
+	}
+	for _, p := range ports {
+		port, binding := portBinding(p)
+		hostConfig.PortBindings[port] = []nat.PortBinding{binding}
+	}
+
+	var networkConfig *network.NetworkingConfig
+	if green.NetworkSettings != nil && len(green.NetworkSettings.Networks) > 0 {
+		networkConfig = &network.NetworkingConfig{EndpointsConfig: make(map[string]*network.EndpointSettings)}
+		for networkName, endpoint := range green.NetworkSettings.Networks {
+			settings := &network.EndpointSettings{}
+			if endpoint != nil {
+				settings.Aliases = endpoint.Aliases
+				settings.IPAMConfig = endpoint.IPAMConfig
+			}
+			networkConfig.EndpointsConfig[networkName] = settings
+		}
+	}
+
+	resp, err := client.ContainerCreate(ctx, green.Config, hostConfig, networkConfig, nil, name)
+	if err != nil {
+		return fmt.Errorf("failed to create container on its host ports: %w", err)
+	}
+	if err := client.ContainerStart(ctx, resp.ID, container.StartOptions{}); err != nil {
+		_ = client.ContainerRemove(ctx, resp.ID, container.RemoveOptions{Force: true})
+		return fmt.Errorf("failed to start container on its host ports: %w", err)
+	}
+	info, err := client.ContainerInspect(ctx, resp.ID)
+	if err != nil {
+		_ = client.ContainerRemove(ctx, resp.ID, container.RemoveOptions{Force: true})
+		return fmt.Errorf("failed to inspect container: %w", err)
+	}
+
+	if err := client.ContainerRemove(ctx, placement.ContainerID, container.RemoveOptions{Force: true}); err != nil {
+		d.addEvent(state, "warning", "promotion", name,
+			fmt.Sprintf("Failed to remove green container %s: %v", placement.ContainerName, err))
+	}
+	d.addEvent(state, "info", "promotion", name,
+		fmt.Sprintf("Recreated %s on its host ports", placement.ContainerName))
+
+	placement.ContainerID = info.ID
+	placement.Ports = publishedPorts(info)
+	if info.State != nil {
+		placement.Status = info.State.Status
+	}
+	return nil
+}
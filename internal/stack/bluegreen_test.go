@@ -0,0 +1,332 @@
+package stack
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/go-connections/nat"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+
+	"eve.evalgo.org/common"
+
+	"evalgo.org/graphium/models"
+)
+
+// blueGreenContainer is a container of the blue-green Docker client
+type blueGreenContainer struct {
+	name       string
+	config     *container.Config
+	hostConfig *container.HostConfig
+	ports      nat.PortMap
+	stopped    bool
+}
+
+// blueGreenDockerClient keeps its containers by ID, renames them and refuses
+// to publish a host port twice, like the Docker daemon
+type blueGreenDockerClient struct {
+	*common.MockDockerClient
+	mu         sync.Mutex
+	containers map[string]*blueGreenContainer
+	nextID     int
+	nextPort   int
+	failCreate string
+}
+
+func newBlueGreenDockerClient() *blueGreenDockerClient {
+	return &blueGreenDockerClient{
+		MockDockerClient: common.NewMockDockerClient(),
+		containers:       make(map[string]*blueGreenContainer),
+		nextPort:         32768,
+	}
+}
+
+// run adds a running container, e.g. of the blue deployment
+func (c *blueGreenDockerClient) run(name string, ports nat.PortMap) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.nextID++
+	id := fmt.Sprintf("c%d", c.nextID)
+	c.containers[id] = &blueGreenContainer{name: name, config: &container.Config{}, hostConfig: &container.HostConfig{}, ports: ports}
+	return id
+}
+
+// byName returns the container named name
+func (c *blueGreenDockerClient) byName(name string) *blueGreenContainer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, ctr := range c.containers {
+		if ctr.name == name {
+			return ctr
+		}
+	}
+	return nil
+}
+
+func (c *blueGreenDockerClient) ContainerCreate(ctx context.Context, config *container.Config, hostConfig *container.HostConfig, networkingConfig *network.NetworkingConfig, platform *ocispec.Platform, containerName string) (container.CreateResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if strings.HasSuffix(containerName, "-bad-green") || (c.failCreate != "" && containerName == c.failCreate) {
+		return container.CreateResponse{}, errors.New("no space left on device")
+	}
+
+	ports := make(nat.PortMap)
+	for port, bindings := range hostConfig.PortBindings {
+		for _, binding := range bindings {
+			if binding.HostPort == "" {
+				binding.HostPort = fmt.Sprintf("%d", c.nextPort)
+				c.nextPort++
+			}
+			for _, other := range c.containers {
+				if other.stopped {
+					continue
+				}
+				for _, used := range other.ports {
+					if len(used) > 0 && used[0].HostPort == binding.HostPort {
+						return container.CreateResponse{}, fmt.Errorf("port %s is already allocated", binding.HostPort)
+					}
+				}
+			}
+			ports[port] = []nat.PortBinding{binding}
+		}
+	}
+
+	c.nextID++
+	id := fmt.Sprintf("c%d", c.nextID)
+	c.containers[id] = &blueGreenContainer{name: containerName, config: config, hostConfig: hostConfig, ports: ports}
+	return container.CreateResponse{ID: id}, nil
+}
+
+func (c *blueGreenDockerClient) ContainerStart(ctx context.Context, containerID string, options container.StartOptions) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if ctr, ok := c.containers[containerID]; ok {
+		ctr.stopped = false
+	}
+	return nil
+}
+
+func (c *blueGreenDockerClient) ContainerStop(ctx context.Context, containerID string, options container.StopOptions) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if ctr, ok := c.containers[containerID]; ok {
+		ctr.stopped = true
+	}
+	return nil
+}
+
+func (c *blueGreenDockerClient) ContainerInspect(ctx context.Context, containerID string) (container.InspectResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ctr, ok := c.containers[containerID]
+	if !ok {
+		return container.InspectResponse{}, errors.New("no such container")
+	}
+	resp := container.InspectResponse{}
+	resp.ContainerJSONBase = &container.ContainerJSONBase{ID: containerID, Name: "/" + ctr.name, HostConfig: ctr.hostConfig, State: &container.State{Running: true, Status: "running"}}
+	resp.Config = ctr.config
+	resp.NetworkSettings = &container.NetworkSettings{NetworkSettingsBase: container.NetworkSettingsBase{Ports: ctr.ports}}
+	return resp, nil
+}
+
+func (c *blueGreenDockerClient) ContainerRename(ctx context.Context, containerID, newContainerName string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for id, ctr := range c.containers {
+		if ctr.name == newContainerName && id != containerID {
+			return fmt.Errorf("container name %s is already in use", newContainerName)
+		}
+	}
+	ctr, ok := c.containers[containerID]
+	if !ok {
+		return errors.New("no such container")
+	}
+	ctr.name = newContainerName
+	return nil
+}
+
+func (c *blueGreenDockerClient) ContainerRemove(ctx context.Context, containerID string, options container.RemoveOptions) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.containers, containerID)
+	return nil
+}
+
+// blueGreenClientFactory always returns the same blue-green client
+type blueGreenClientFactory struct {
+	client *blueGreenDockerClient
+}
+
+func (f *blueGreenClientFactory) GetClient(ctx context.Context, hostID string) (common.DockerClient, error) {
+	return f.client, nil
+}
+
+// blueGreenStack runs the blue deployment of a stack with web, published on
+// host port 8080, and api, and returns the plan of its next version
+func blueGreenStack(services ...string) (*Deployer, *blueGreenDockerClient, *models.DeploymentState, *models.DeploymentPlan) {
+	resolver := &MockHostResolver{
+		hosts: map[string]*models.HostInfo{
+			"host1": {Host: &models.Host{ID: "host1", Name: "test-host", IPAddress: "192.168.1.10"}},
+		},
+	}
+	client := newBlueGreenDockerClient()
+	deployer := NewDeployer(&MockDatabase{}, resolver, &blueGreenClientFactory{client: client})
+
+	blue := &models.DeploymentState{
+		ID:     "deployment-shop-1",
+		Status: "running",
+		Placements: map[string]*models.ContainerPlacement{
+			"shop-web": {ContainerID: client.run("shop-web", nat.PortMap{"80/tcp": {{HostPort: "8080"}}}), ContainerName: "shop-web", HostID: "host1", Ports: map[int]int{80: 8080}},
+			"shop-api": {ContainerID: client.run("shop-api", nil), ContainerName: "shop-api", HostID: "host1"},
+		},
+	}
+
+	plan := &models.DeploymentPlan{
+		StackNode:       &models.GraphNode{ID: "stack1", Name: "shop"},
+		HostMap:         make(map[string]string),
+		DependencyGraph: [][]string{services},
+	}
+	for _, name := range services {
+		spec := models.ContainerSpec{ID: name, Name: name, Image: name + ":v2"}
+		if name == "web" {
+			spec.Ports = []models.PortMapping{{ContainerPort: 80, HostPort: 8080, Protocol: "tcp"}}
+		}
+		plan.ContainerSpecs = append(plan.ContainerSpecs, spec)
+		plan.HostMap[name] = "host1"
+	}
+	return deployer, client, blue, plan
+}
+
+func TestDeployer_BlueGreenDeployAndPromote(t *testing.T) {
+	deployer, client, blue, plan := blueGreenStack("web", "api")
+	opts := DeployOptions{Timeout: time.Minute, StackName: "shop", Mode: DeployModeBlueGreen, Blue: blue}
+
+	state, err := deployer.Deploy(context.Background(), plan, opts)
+	if err != nil {
+		t.Fatalf("Deploy failed: %v", err)
+	}
+
+	// Green runs alongside blue, web on an ephemeral port
+	for _, name := range []string{"shop-web", "shop-api", "shop-web-green", "shop-api-green"} {
+		if client.byName(name) == nil {
+			t.Errorf("Expected container %s to run during the parallel run", name)
+		}
+	}
+	if port := state.Placements["shop-web-green"].Ports[80]; port == 8080 || port == 0 {
+		t.Errorf("Expected green web on an ephemeral host port, got %d", port)
+	}
+	if state.BlueGreen == nil || state.BlueGreen.Status != BlueGreenPending || len(state.BlueGreen.Ports["shop-web"]) != 1 {
+		t.Fatalf("Expected a pending cutover with web's fixed port, got %+v", state.BlueGreen)
+	}
+
+	if err := deployer.Promote(context.Background(), state); err != nil {
+		t.Fatalf("Promote failed: %v", err)
+	}
+
+	// Green took the names over, web its fixed port, and blue is gone
+	web, api := client.byName("shop-web"), client.byName("shop-api")
+	if web == nil || web.config.Image != "web:v2" || web.ports["80/tcp"][0].HostPort != "8080" {
+		t.Errorf("Expected the new web on port 8080, got %+v", web)
+	}
+	if api == nil || api.config.Image != "api:v2" {
+		t.Errorf("Expected the green api renamed to shop-api, got %+v", api)
+	}
+	if len(client.containers) != 2 {
+		t.Errorf("Expected only the promoted containers to remain, got %d", len(client.containers))
+	}
+	if len(state.Placements) != 2 || state.Placements["shop-web"].Ports[80] != 8080 || state.Placements["shop-api"] == nil {
+		t.Errorf("Expected the placements under the promoted names, got %v", state.Placements)
+	}
+	if state.BlueGreen.Status != BlueGreenPromoted || len(state.BlueGreen.Blue) != 0 {
+		t.Errorf("Expected the cutover to be promoted, got %+v", state.BlueGreen)
+	}
+
+	if err := deployer.Promote(context.Background(), state); err == nil {
+		t.Error("Expected a promoted deployment not to be promoted again")
+	}
+}
+
+func TestDeployer_BlueGreenPromoteRemovesDroppedBlue(t *testing.T) {
+	deployer, client, blue, plan := blueGreenStack("web")
+
+	state, err := deployer.Deploy(context.Background(), plan, DeployOptions{Timeout: time.Minute, StackName: "shop", Mode: DeployModeBlueGreen, Blue: blue})
+	if err != nil {
+		t.Fatalf("Deploy failed: %v", err)
+	}
+	if err := deployer.Promote(context.Background(), state); err != nil {
+		t.Fatalf("Promote failed: %v", err)
+	}
+	if client.byName("shop-api") != nil {
+		t.Error("Expected the blue api the new version dropped to be removed")
+	}
+}
+
+func TestDeployer_BlueGreenFailureRemovesGreen(t *testing.T) {
+	deployer, client, blue, plan := blueGreenStack("web", "bad")
+
+	state, err := deployer.Deploy(context.Background(), plan, DeployOptions{Timeout: time.Minute, StackName: "shop", Mode: DeployModeBlueGreen, Blue: blue})
+	if err == nil {
+		t.Fatal("Expected deploy to fail, but it succeeded")
+	}
+	if state.RollbackState == nil {
+		t.Error("Expected the green deployment to be rolled back")
+	}
+	if client.byName("shop-web-green") != nil {
+		t.Error("Expected the green containers to be removed")
+	}
+	if client.byName("shop-web") == nil || client.byName("shop-api") == nil {
+		t.Error("Expected the blue containers to keep running")
+	}
+}
+
+func TestDeployer_BlueGreenPromoteFailureRestoresBlue(t *testing.T) {
+	deployer, client, blue, plan := blueGreenStack("web")
+
+	state, err := deployer.Deploy(context.Background(), plan, DeployOptions{Timeout: time.Minute, StackName: "shop", Mode: DeployModeBlueGreen, Blue: blue})
+	if err != nil {
+		t.Fatalf("Deploy failed: %v", err)
+	}
+	client.failCreate = "shop-web"
+	if err := deployer.Promote(context.Background(), state); err == nil {
+		t.Fatal("Expected promotion to fail")
+	}
+
+	web := client.byName("shop-web")
+	if web == nil || web.stopped || web.ports["80/tcp"][0].HostPort != "8080" {
+		t.Errorf("Expected the blue web running again on port 8080, got %+v", web)
+	}
+	if client.byName("shop-web-green") == nil {
+		t.Error("Expected the green web to be kept")
+	}
+}
+
+func TestDeployer_BlueGreenKeepsFilesOnFixedPorts(t *testing.T) {
+	deployer, client, blue, plan := blueGreenStack("web")
+	plan.ContainerSpecs[0].Files = []models.FileMount{{Target: "/etc/nginx/nginx.conf", Content: "events {}"}}
+
+	state, err := deployer.Deploy(context.Background(), plan, DeployOptions{Timeout: time.Minute, StackName: "shop", Mode: DeployModeBlueGreen, Blue: blue})
+	if err != nil {
+		t.Fatalf("Deploy failed: %v", err)
+	}
+	if err := deployer.Promote(context.Background(), state); err != nil {
+		t.Fatalf("Promote failed: %v", err)
+	}
+
+	web := client.byName("shop-web")
+	if web == nil || web.ports["80/tcp"][0].HostPort != "8080" {
+		t.Fatalf("Expected the new web on port 8080, got %+v", web)
+	}
+	var mounted bool
+	for _, m := range web.hostConfig.Mounts {
+		mounted = mounted || (m.Target == "/etc/nginx/nginx.conf" && m.ReadOnly)
+	}
+	if !mounted {
+		t.Errorf("Expected the recreated web to keep its read-only file mount, got %+v", web.hostConfig.Mounts)
+	}
+}
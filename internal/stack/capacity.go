@@ -0,0 +1,169 @@
+package stack
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"evalgo.org/graphium/models"
+)
+
+// ErrInsufficientCapacity is returned when a container's reservation does not
+// fit into the remaining CPU or memory of any eligible host.
+var ErrInsufficientCapacity = errors.New("insufficient host capacity")
+
+// OversubscribedError is returned when the reservations of the containers a
+// plan assigns to a host exceed the host's remaining capacity. It matches
+// ErrInsufficientCapacity with errors.Is.
+type OversubscribedError struct {
+	// HostID is the oversubscribed host
+	HostID string
+
+	// Containers are the containers the plan reserves resources for on the host
+	Containers []string
+
+	// ExcessCPUs is how many CPUs the reservations exceed the host's
+	// remaining CPUs by (0 if they fit)
+	ExcessCPUs float64
+
+	// ExcessMemory is how many bytes the reservations exceed the host's
+	// remaining memory by (0 if they fit)
+	ExcessMemory int64
+}
+
+func (e *OversubscribedError) Error() string {
+	var excess []string
+	if e.ExcessCPUs > 0 {
+		excess = append(excess, fmt.Sprintf("%.2f CPUs", e.ExcessCPUs))
+	}
+	if e.ExcessMemory > 0 {
+		excess = append(excess, fmt.Sprintf("%d bytes of memory", e.ExcessMemory))
+	}
+	return fmt.Sprintf("%v: host %s is oversubscribed by %s (containers %s)",
+		ErrInsufficientCapacity, e.HostID, strings.Join(excess, " and "), strings.Join(e.Containers, ", "))
+}
+
+func (e *OversubscribedError) Unwrap() error {
+	return ErrInsufficientCapacity
+}
+
+// hostCapacity returns the CPUs and memory of a host, preferring its
+// available resources over the host's own figures. 0 means unknown.
+func hostCapacity(info *models.HostInfo) (cpus int, memory int64) {
+	cpus, memory = info.AvailableResources.CPU, info.AvailableResources.Memory
+	if cpus == 0 {
+		cpus = info.Host.CPU
+	}
+	if memory == 0 {
+		memory = info.Host.Memory
+	}
+	return cpus, memory
+}
+
+// capacityShortfall explains why need does not fit on the host, or returns ""
+// if it fits. The host's committed resources (info.CurrentLoad) plus the
+// reservations already planned in this deployment count against its capacity.
+// A host with unknown capacity (0 CPUs or memory) accepts any reservation.
+func capacityShortfall(info *models.HostInfo, need, planned *models.ResourceReservations) string {
+	if need == nil || info == nil || info.Host == nil {
+		return ""
+	}
+
+	committedCPUs := info.CurrentLoad.CommittedCPUs
+	committedMemory := info.CurrentLoad.CommittedMemory
+	if planned != nil {
+		committedCPUs += planned.CPUs
+		committedMemory += planned.Memory
+	}
+
+	cpus, memory := hostCapacity(info)
+	if cpus > 0 && need.CPUs > 0 && committedCPUs+need.CPUs > float64(cpus) {
+		return fmt.Sprintf("needs %.2f CPUs, %.2f of %d remaining",
+			need.CPUs, float64(cpus)-committedCPUs, cpus)
+	}
+	if memory > 0 && need.Memory > 0 && committedMemory+need.Memory > memory {
+		return fmt.Sprintf("needs %d bytes of memory, %d of %d remaining",
+			need.Memory, memory-committedMemory, memory)
+	}
+	return ""
+}
+
+// checkReservations refuses a plan whose reservations oversubscribe a host
+// before any container is deployed: the reservations of the containers the
+// plan assigns to each host are summed and compared with the host's
+// capacity minus its committed load. Hosts that allow overcommit and hosts
+// with unknown capacity are skipped. Containers placed automatically are
+// checked when their host is selected.
+func (d *Deployer) checkReservations(plan *models.DeploymentPlan) error {
+	totals := make(map[string]*models.ResourceReservations)
+	containers := make(map[string][]string)
+	for _, spec := range plan.ContainerSpecs {
+		hostID := plan.HostMap[spec.ID]
+		reserved := spec.Resources.ReservedResources()
+		if hostID == "" || reserved == nil {
+			continue
+		}
+		addReservation(totals, hostID, reserved)
+		containers[hostID] = append(containers[hostID], spec.Name)
+	}
+	if len(totals) == 0 || (d.AllowOvercommit && len(d.Policies) == 0) {
+		return nil
+	}
+
+	hosts := make([]string, 0, len(totals))
+	for hostID := range totals {
+		hosts = append(hosts, hostID)
+	}
+	sort.Strings(hosts)
+
+	for _, hostID := range hosts {
+		info, err := d.HostResolver.ResolveHost(hostID)
+		if err != nil {
+			return fmt.Errorf("failed to resolve host %s: %w", hostID, err)
+		}
+		if info == nil || info.Host == nil || d.allowOvercommit(info) {
+			continue
+		}
+
+		cpus, memory := hostCapacity(info)
+		total := totals[hostID]
+		oversubscribed := &OversubscribedError{HostID: hostID, Containers: containers[hostID]}
+		if excess := info.CurrentLoad.CommittedCPUs + total.CPUs - float64(cpus); cpus > 0 && total.CPUs > 0 && excess > 0 {
+			oversubscribed.ExcessCPUs = excess
+		}
+		if excess := info.CurrentLoad.CommittedMemory + total.Memory - memory; memory > 0 && total.Memory > 0 && excess > 0 {
+			oversubscribed.ExcessMemory = excess
+		}
+		if oversubscribed.ExcessCPUs > 0 || oversubscribed.ExcessMemory > 0 {
+			return oversubscribed
+		}
+	}
+	return nil
+}
+
+// plannedReservations sums the reservations of containers already placed in
+// a deployment, keyed by host ID.
+func plannedReservations(placements map[string]*models.ContainerPlacement) map[string]*models.ResourceReservations {
+	planned := make(map[string]*models.ResourceReservations)
+	for _, placement := range placements {
+		if placement != nil && placement.Reserved != nil {
+			addReservation(planned, placement.HostID, placement.Reserved)
+		}
+	}
+	return planned
+}
+
+// addReservation adds a reservation to the total of a host.
+func addReservation(totals map[string]*models.ResourceReservations, hostID string, reserved *models.ResourceReservations) {
+	if reserved == nil {
+		return
+	}
+	total, ok := totals[hostID]
+	if !ok {
+		total = &models.ResourceReservations{}
+		totals[hostID] = total
+	}
+	total.CPUs += reserved.CPUs
+	total.Memory += reserved.Memory
+}
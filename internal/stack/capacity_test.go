@@ -0,0 +1,180 @@
+package stack
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"eve.evalgo.org/common"
+
+	"evalgo.org/graphium/models"
+)
+
+func capacityHost(id string, cpu int, memory int64, committedCPUs float64, committedMemory int64) *models.HostInfo {
+	return &models.HostInfo{
+		Host: &models.Host{ID: id, CPU: cpu, Memory: memory},
+		CurrentLoad: models.ResourceLoad{
+			CommittedCPUs:   committedCPUs,
+			CommittedMemory: committedMemory,
+		},
+	}
+}
+
+func reservingSpec(cpus float64, memory int64) *models.ContainerSpec {
+	return &models.ContainerSpec{
+		ID:    "container1",
+		Name:  "web",
+		Image: "nginx:latest",
+		Resources: &models.ResourceConstraints{
+			Reservations: &models.ResourceReservations{CPUs: cpus, Memory: memory},
+		},
+	}
+}
+
+func TestDeployer_SelectHostRefusesOvercommit(t *testing.T) {
+	resolver := &MockHostResolver{
+		hosts: map[string]*models.HostInfo{
+			"host1": capacityHost("host1", 4, 8<<30, 3, 4<<30),
+		},
+	}
+	deployer := NewDeployer(nil, resolver, nil)
+	plan := &models.DeploymentPlan{HostMap: map[string]string{"container1": "host1"}}
+
+	if _, _, err := deployer.selectHost(context.Background(), plan, reservingSpec(2, 0), nil, nil); !errors.Is(err, ErrInsufficientCapacity) {
+		t.Errorf("Expected ErrInsufficientCapacity for CPU, got %v", err)
+	}
+	if _, _, err := deployer.selectHost(context.Background(), plan, reservingSpec(0, 5<<30), nil, nil); !errors.Is(err, ErrInsufficientCapacity) {
+		t.Errorf("Expected ErrInsufficientCapacity for memory, got %v", err)
+	}
+	if _, _, err := deployer.selectHost(context.Background(), plan, reservingSpec(1, 4<<30), nil, nil); err != nil {
+		t.Errorf("Expected a reservation that exactly fits to be placed, got %v", err)
+	}
+
+	// Reservations already planned in the same deployment count as well
+	planned := map[string]*models.ResourceReservations{"host1": {CPUs: 1}}
+	if _, _, err := deployer.selectHost(context.Background(), plan, reservingSpec(1, 0), planned, nil); !errors.Is(err, ErrInsufficientCapacity) {
+		t.Errorf("Expected planned reservations to be counted, got %v", err)
+	}
+
+	deployer.AllowOvercommit = true
+	if hostID, _, err := deployer.selectHost(context.Background(), plan, reservingSpec(2, 0), nil, nil); err != nil || hostID != "host1" {
+		t.Errorf("Expected overcommit to be allowed, got %q, %v", hostID, err)
+	}
+}
+
+func TestDeployer_SelectHostPicksHostWithCapacity(t *testing.T) {
+	resolver := &MockHostResolver{
+		hosts: map[string]*models.HostInfo{
+			"full":    capacityHost("full", 2, 0, 2, 0),
+			"free":    capacityHost("free", 8, 0, 1, 0),
+			"unknown": nil,
+		},
+	}
+	deployer := NewDeployer(nil, resolver, nil)
+	plan := &models.DeploymentPlan{}
+
+	hostID, autoSelected, err := deployer.selectHost(context.Background(), plan, reservingSpec(2, 0), nil, nil)
+	if err != nil {
+		t.Fatalf("selectHost failed: %v", err)
+	}
+	if hostID != "free" || !autoSelected {
+		t.Errorf("Expected auto-selected host 'free', got %q (autoSelected=%v)", hostID, autoSelected)
+	}
+
+	if _, _, err := deployer.selectHost(context.Background(), plan, reservingSpec(16, 0), nil, nil); !errors.Is(err, ErrInsufficientCapacity) {
+		t.Errorf("Expected ErrInsufficientCapacity when no host fits, got %v", err)
+	}
+}
+
+func TestCapacityShortfall_UnknownCapacity(t *testing.T) {
+	info := capacityHost("host1", 0, 0, 0, 0)
+	if reason := capacityShortfall(info, &models.ResourceReservations{CPUs: 64, Memory: 1 << 40}, nil); reason != "" {
+		t.Errorf("Expected a host without known capacity to accept any reservation, got %q", reason)
+	}
+}
+
+// reservingPlan assigns containers reserving cpus and memory each to host1
+func reservingPlan(cpus float64, memory int64, names ...string) *models.DeploymentPlan {
+	plan := &models.DeploymentPlan{
+		StackNode: &models.GraphNode{ID: "stack1", Name: "test-stack"},
+		HostMap:   make(map[string]string),
+	}
+	for _, name := range names {
+		spec := *reservingSpec(cpus, memory)
+		spec.ID, spec.Name = name, name
+		plan.ContainerSpecs = append(plan.ContainerSpecs, spec)
+		plan.HostMap[name] = "host1"
+	}
+	plan.DependencyGraph = [][]string{names}
+	return plan
+}
+
+func TestDeployer_CheckReservationsRejectsOversubscribedHost(t *testing.T) {
+	resolver := &MockHostResolver{
+		hosts: map[string]*models.HostInfo{
+			"host1": capacityHost("host1", 4, 8<<30, 1, 2<<30),
+		},
+	}
+	deployer := NewDeployer(nil, resolver, nil)
+
+	// Each container fits on its own, together they exceed the 3 CPUs left
+	err := deployer.checkReservations(reservingPlan(2, 1<<30, "web", "api"))
+	var oversubscribed *OversubscribedError
+	if !errors.As(err, &oversubscribed) || !errors.Is(err, ErrInsufficientCapacity) {
+		t.Fatalf("Expected an OversubscribedError, got %v", err)
+	}
+	if oversubscribed.HostID != "host1" || oversubscribed.ExcessCPUs != 1 || oversubscribed.ExcessMemory != 0 {
+		t.Errorf("Expected host1 oversubscribed by 1 CPU, got %+v", oversubscribed)
+	}
+	if len(oversubscribed.Containers) != 2 || oversubscribed.Containers[0] != "web" || oversubscribed.Containers[1] != "api" {
+		t.Errorf("Expected containers web and api, got %v", oversubscribed.Containers)
+	}
+
+	err = deployer.checkReservations(reservingPlan(1, 4<<30, "web", "api"))
+	if !errors.As(err, &oversubscribed) || oversubscribed.ExcessMemory != 2<<30 || oversubscribed.ExcessCPUs != 0 {
+		t.Errorf("Expected host1 oversubscribed by 2 GiB of memory, got %v", err)
+	}
+
+	if err := deployer.checkReservations(reservingPlan(1.5, 3<<30, "web", "api")); err != nil {
+		t.Errorf("Expected reservations that exactly fit to pass, got %v", err)
+	}
+
+	deployer.AllowOvercommit = true
+	if err := deployer.checkReservations(reservingPlan(2, 0, "web", "api")); err != nil {
+		t.Errorf("Expected overcommit to be allowed, got %v", err)
+	}
+}
+
+func TestDeployer_CheckReservationsUsesAvailableResources(t *testing.T) {
+	info := capacityHost("host1", 16, 0, 0, 0)
+	info.AvailableResources = models.Resources{CPU: 2}
+	deployer := NewDeployer(nil, &MockHostResolver{hosts: map[string]*models.HostInfo{"host1": info}}, nil)
+
+	var oversubscribed *OversubscribedError
+	if err := deployer.checkReservations(reservingPlan(2, 0, "web", "api")); !errors.As(err, &oversubscribed) || oversubscribed.ExcessCPUs != 2 {
+		t.Errorf("Expected host1 oversubscribed by 2 of its 2 available CPUs, got %v", err)
+	}
+}
+
+func TestDeployer_DeployRefusesOversubscribedPlan(t *testing.T) {
+	resolver := &MockHostResolver{
+		hosts: map[string]*models.HostInfo{
+			"host1": capacityHost("host1", 4, 0, 0, 0),
+		},
+	}
+	client := common.NewMockDockerClient()
+	deployer := NewDeployer(&MockDatabase{}, resolver, &MockDockerClientFactory{defaultClient: client})
+
+	// The first wave would fit; the plan is refused before it is deployed
+	plan := reservingPlan(2, 0, "db", "web", "api")
+	plan.DependencyGraph = [][]string{{"db"}, {"web", "api"}}
+
+	state, err := deployer.Deploy(context.Background(), plan, DeployOptions{StackName: "test-stack"})
+	if !errors.Is(err, ErrInsufficientCapacity) || !strings.Contains(err.Error(), "host host1 is oversubscribed by 2.00 CPUs") {
+		t.Fatalf("Expected the oversubscribed host in the error, got %v", err)
+	}
+	if state != nil || client.ContainerCreateCalled {
+		t.Error("Expected the plan to be refused before any container is created")
+	}
+}
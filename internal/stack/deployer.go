@@ -2,12 +2,19 @@ package stack
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
+	cerrdefs "github.com/containerd/errdefs"
 	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/api/types/mount"
 	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/api/types/registry"
 	"github.com/docker/docker/api/types/volume"
 	"github.com/docker/go-connections/nat"
 
@@ -35,6 +42,43 @@ type Deployer struct {
 
 	// DockerClientFactory creates Docker clients for hosts
 	DockerClientFactory DockerClientFactory
+
+	// Secrets resolves secrets referenced by container file mounts (optional)
+	Secrets SecretStore
+
+	// AllowOvercommit places containers even when their CPU/memory reservation
+	// exceeds the host's remaining capacity
+	AllowOvercommit bool
+
+	// Protection lists containers deployments must never create or replace
+	// (optional)
+	Protection *models.ProtectionPolicy
+
+	// PlacementStrategy is the default strategy for containers the plan
+	// doesn't assign to a host (first-fit, spread, binpack or the name of a
+	// custom strategy; default: first-fit)
+	PlacementStrategy string
+
+	// Strategies are custom placement strategies keyed by name, chosen like
+	// the built-in ones by the stack, datacenter policy or PlacementStrategy
+	// (optional)
+	Strategies map[string]PlacementStrategy
+
+	// Policies are the datacenter policies keyed by datacenter. The policy
+	// of the datacenter a container is placed in overrides AllowOvercommit,
+	// adds to Protection and sets the default network mode (optional)
+	Policies map[string]*models.DatacenterPolicy
+
+	// RegistryAuth are registry credentials keyed by registry host, e.g.
+	// docker.io, ghcr.io or registry.example.com:5000 (optional)
+	RegistryAuth map[string]registry.AuthConfig
+
+	// mu guards the deployment state while the containers of a wave deploy
+	// concurrently
+	mu sync.Mutex
+
+	// networkMu serializes creating the stack network on further hosts
+	networkMu sync.Mutex
 }
 
 // DockerClientFactory creates Docker clients for different hosts.
@@ -43,21 +87,148 @@ type DockerClientFactory interface {
 	GetClient(ctx context.Context, hostID string) (common.DockerClient, error)
 }
 
+// Deployment phases that have their own timeout.
+const (
+	PhaseNetwork = "network"
+	PhaseVolume  = "volume"
+	PhasePull    = "pull"
+	PhaseCreate  = "create"
+	PhaseHealth  = "health"
+)
+
+// PhaseTimeouts limits how long each deployment phase may take.
+// Zero values fall back to DefaultPhaseTimeouts.
+type PhaseTimeouts struct {
+	// Network is the budget for creating or inspecting the stack network
+	Network time.Duration
+
+	// Volume is the budget for creating all named volumes
+	Volume time.Duration
+
+	// Pull is the budget for pulling all images (only used with PullImages)
+	Pull time.Duration
+
+	// Create is the budget for creating and starting a single container
+	Create time.Duration
+
+	// Health is the budget for a single wave to become healthy
+	Health time.Duration
+}
+
+// DefaultPhaseTimeouts returns the default per-phase timeouts.
+func DefaultPhaseTimeouts() PhaseTimeouts {
+	return PhaseTimeouts{
+		Network: 30 * time.Second,
+		Volume:  30 * time.Second,
+		Pull:    5 * time.Minute,
+		Create:  1 * time.Minute,
+		Health:  2 * time.Minute,
+	}
+}
+
+// withDefaults fills unset phase timeouts from DefaultPhaseTimeouts.
+func (t PhaseTimeouts) withDefaults() PhaseTimeouts {
+	defaults := DefaultPhaseTimeouts()
+	if t.Network <= 0 {
+		t.Network = defaults.Network
+	}
+	if t.Volume <= 0 {
+		t.Volume = defaults.Volume
+	}
+	if t.Pull <= 0 {
+		t.Pull = defaults.Pull
+	}
+	if t.Create <= 0 {
+		t.Create = defaults.Create
+	}
+	if t.Health <= 0 {
+		t.Health = defaults.Health
+	}
+	return t
+}
+
+// PhaseTimeoutError is returned when a deployment phase exceeds its timeout.
+type PhaseTimeoutError struct {
+	// Phase is the phase that timed out (network, volume, pull, create, health)
+	Phase string
+
+	// Timeout is the budget the phase exceeded
+	Timeout time.Duration
+
+	// Err is the underlying error
+	Err error
+}
+
+func (e *PhaseTimeoutError) Error() string {
+	return fmt.Sprintf("%s phase timed out after %s: %v", e.Phase, e.Timeout, e.Err)
+}
+
+func (e *PhaseTimeoutError) Unwrap() error {
+	return e.Err
+}
+
+// DeployTimeoutError is returned when a deployment exceeds its overall
+// timeout. It matches context.DeadlineExceeded with errors.Is.
+type DeployTimeoutError struct {
+	// Timeout is the deployment timeout that expired
+	Timeout time.Duration
+
+	// Err is the error the deployment failed with
+	Err error
+}
+
+func (e *DeployTimeoutError) Error() string {
+	return fmt.Sprintf("deployment timed out after %s: %v", e.Timeout, e.Err)
+}
+
+func (e *DeployTimeoutError) Unwrap() error {
+	return e.Err
+}
+
+func (e *DeployTimeoutError) Is(target error) bool {
+	return target == context.DeadlineExceeded
+}
+
 // DeployOptions contains options for deployment.
 type DeployOptions struct {
-	// Timeout for operations (default: 5 minutes)
+	// Timeout is the overall budget for the whole deployment (default: 5 minutes)
 	Timeout time.Duration
 
+	// PhaseTimeouts limits each deployment phase individually
+	PhaseTimeouts PhaseTimeouts
+
 	// RollbackOnError automatically rolls back on any error
 	RollbackOnError bool
 
 	// StackName is the name of the stack (will prefix all containers)
 	StackName string
 
-	// PullImages pulls images before deployment
+	// PullImages pulls images before deployment. Without it, images are
+	// pulled only when they're missing on the host a container is created on
 	PullImages bool
+
+	// Concurrency is how many containers of a wave are created and started
+	// at once (default: DefaultWaveConcurrency). Waves still deploy one
+	// after another
+	Concurrency int
+
+	// Mode is how the stack is deployed (empty or DeployModeBlueGreen)
+	Mode DeployMode
+
+	// Blue is the running deployment a blue-green deployment replaces
+	// (optional)
+	Blue *models.DeploymentState
 }
 
+// DefaultWaveConcurrency is the default number of containers of a wave
+// deployed at once.
+const DefaultWaveConcurrency = 4
+
+// waveCleanupTimeout is the budget for removing the containers of a failed
+// wave or a partially deployed container, which may run after the
+// deployment timeout expired.
+const waveCleanupTimeout = 30 * time.Second
+
 // NewDeployer creates a new deployer.
 func NewDeployer(database Database, resolver HostResolver, clientFactory DockerClientFactory) *Deployer {
 	return &Deployer{
@@ -73,6 +244,29 @@ func (d *Deployer) Deploy(ctx context.Context, plan *models.DeploymentPlan, opts
 	if opts.Timeout == 0 {
 		opts.Timeout = 5 * time.Minute
 	}
+	opts.PhaseTimeouts = opts.PhaseTimeouts.withDefaults()
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = DefaultWaveConcurrency
+	}
+
+	// Refuse the whole plan before touching any host
+	if err := d.checkProtection(plan); err != nil {
+		return nil, err
+	}
+	if err := d.checkReservations(plan); err != nil {
+		return nil, err
+	}
+
+	// A failed green deployment is simply removed, leaving blue running
+	var blueGreen *models.BlueGreenState
+	switch opts.Mode {
+	case "":
+	case DeployModeBlueGreen:
+		blueGreen, plan = blueGreenPlan(plan, opts)
+		opts.RollbackOnError = true
+	default:
+		return nil, fmt.Errorf("unknown deploy mode %q", opts.Mode)
+	}
 
 	// Create deployment context with timeout
 	deployCtx, cancel := context.WithTimeout(ctx, opts.Timeout)
@@ -89,6 +283,7 @@ func (d *Deployer) Deploy(ctx context.Context, plan *models.DeploymentPlan, opts
 		Placements: make(map[string]*models.ContainerPlacement),
 		Events:     []models.DeploymentEvent{},
 		StartedAt:  time.Now(),
+		BlueGreen:  blueGreen,
 	}
 
 	// Add initialization event
@@ -99,28 +294,54 @@ func (d *Deployer) Deploy(ctx context.Context, plan *models.DeploymentPlan, opts
 		return state, fmt.Errorf("failed to save deployment state: %w", err)
 	}
 
+	// Failing and rolling back must outlive a canceled or timed out
+	// deployment, or its partial work would be left behind
+	cleanupCtx := context.WithoutCancel(ctx)
+	fail := func(phase string, err error) (*models.DeploymentState, error) {
+		if errors.Is(deployCtx.Err(), context.DeadlineExceeded) && ctx.Err() == nil {
+			err = &DeployTimeoutError{Timeout: opts.Timeout, Err: err}
+		}
+		return d.failDeployment(cleanupCtx, state, phase, err)
+	}
+
 	// Defer rollback on error if requested
 	if opts.RollbackOnError {
 		defer func() {
 			if state.Status == "failed" {
-				d.rollback(ctx, state)
+				d.rollback(cleanupCtx, state)
 			}
 		}()
 	}
 
 	// Step 1: Create network if needed
-	if err := d.deployNetwork(deployCtx, plan, state, opts); err != nil {
-		return d.failDeployment(deployCtx, state, "network creation failed", err)
+	err := d.runPhase(deployCtx, state, PhaseNetwork, opts.PhaseTimeouts.Network, func(ctx context.Context) error {
+		return d.deployNetwork(ctx, plan, state, opts)
+	})
+	if err != nil {
+		return fail("network creation failed", err)
 	}
 
 	// Step 2: Create volumes if needed
-	if err := d.deployVolumes(deployCtx, plan, state, opts); err != nil {
-		return d.failDeployment(deployCtx, state, "volume creation failed", err)
+	err = d.runPhase(deployCtx, state, PhaseVolume, opts.PhaseTimeouts.Volume, func(ctx context.Context) error {
+		return d.deployVolumes(ctx, plan, state, opts)
+	})
+	if err != nil {
+		return fail("volume creation failed", err)
 	}
 
-	// Step 3: Deploy containers in waves
+	// Step 3: Pull images if requested
+	if opts.PullImages {
+		err = d.runPhase(deployCtx, state, PhasePull, opts.PhaseTimeouts.Pull, func(ctx context.Context) error {
+			return d.pullImages(ctx, plan, state)
+		})
+		if err != nil {
+			return fail("image pull failed", err)
+		}
+	}
+
+	// Step 4: Deploy containers in waves
 	if err := d.deployContainersInWaves(deployCtx, plan, state, opts); err != nil {
-		return d.failDeployment(deployCtx, state, "container deployment failed", err)
+		return fail("container deployment failed", err)
 	}
 
 	// Mark deployment as complete
@@ -140,6 +361,30 @@ func (d *Deployer) Deploy(ctx context.Context, plan *models.DeploymentPlan, opts
 	return state, nil
 }
 
+// runPhase runs a deployment phase with its own timeout. If the phase exceeds
+// its timeout (rather than the overall deployment timeout), the error is
+// wrapped in a PhaseTimeoutError and recorded as an event.
+func (d *Deployer) runPhase(ctx context.Context, state *models.DeploymentState, phase string, timeout time.Duration, fn func(ctx context.Context) error) error {
+	phaseCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	err := fn(phaseCtx)
+	if err == nil {
+		return nil
+	}
+
+	if errors.Is(phaseCtx.Err(), context.DeadlineExceeded) && ctx.Err() == nil {
+		d.mu.Lock()
+		state.TimedOutPhase = phase
+		d.mu.Unlock()
+		d.addEvent(state, "error", phase, "",
+			fmt.Sprintf("Phase %s timed out after %s", phase, timeout))
+		return &PhaseTimeoutError{Phase: phase, Timeout: timeout, Err: err}
+	}
+
+	return err
+}
+
 // deployNetwork creates the Docker network if specified.
 func (d *Deployer) deployNetwork(ctx context.Context, plan *models.DeploymentPlan, state *models.DeploymentState, opts DeployOptions) error {
 	if plan.Network == nil {
@@ -149,8 +394,9 @@ func (d *Deployer) deployNetwork(ctx context.Context, plan *models.DeploymentPla
 	state.Phase = "network-creation"
 	d.addEvent(state, "info", "network-creation", "", fmt.Sprintf("Creating network %s", plan.Network.Name))
 
-	// For single-host, create on the target host
-	// For multi-host, this would create overlay network (Phase 2)
+	// The network is created on the primary host. An overlay network spans
+	// the Swarm from there; other networks are also created on the other
+	// hosts as containers are deployed to them.
 	hostID := d.getPrimaryHost(plan)
 	if hostID == "" {
 		return fmt.Errorf("no target host for network creation")
@@ -163,96 +409,50 @@ func (d *Deployer) deployNetwork(ctx context.Context, plan *models.DeploymentPla
 
 	// Check if network already exists (if external)
 	if plan.Network.External {
-		networkInfo, err := client.NetworkInspect(ctx, plan.Network.Name, network.InspectOptions{})
+		info, err := inspectNetwork(ctx, client, plan.Network.Name)
 		if err != nil {
 			return fmt.Errorf("external network %s not found: %w", plan.Network.Name, err)
 		}
-		// Extract IPAM config for subnet/gateway if available
-		var subnet, gateway string
-		if len(networkInfo.IPAM.Config) > 0 {
-			subnet = networkInfo.IPAM.Config[0].Subnet
-			gateway = networkInfo.IPAM.Config[0].Gateway
-		}
-		state.NetworkInfo = &models.DeployedNetworkInfo{
-			NetworkID:   networkInfo.ID,
-			NetworkName: networkInfo.Name,
-			Driver:      networkInfo.Driver,
-			Subnet:      subnet,
-			Gateway:     gateway,
-			Scope:       networkInfo.Scope,
-		}
+		state.NetworkInfo = info
 		return nil
 	}
 
-	// Create the network
-	createOpts := network.CreateOptions{
-		Driver: plan.Network.Driver,
-		Labels: plan.Network.Labels,
-	}
-
-	// Set IPAM config if subnet/gateway specified
-	if plan.Network.Subnet != "" || plan.Network.Gateway != "" {
-		createOpts.IPAM = &network.IPAM{
-			Config: []network.IPAMConfig{
-				{
-					Subnet:  plan.Network.Subnet,
-					Gateway: plan.Network.Gateway,
-					IPRange: plan.Network.IPRange,
-				},
-			},
-		}
-	}
-
-	// Set driver options
-	if len(plan.Network.Options) > 0 {
-		createOpts.Options = plan.Network.Options
-	}
-
-	resp, err := client.NetworkCreate(ctx, plan.Network.Name, createOpts)
-	if err != nil {
-		return fmt.Errorf("failed to create network: %w", err)
-	}
-
-	// Get network info
-	networkInfo, err := client.NetworkInspect(ctx, resp.ID, network.InspectOptions{})
+	info, err := createNetwork(ctx, client, plan.Network)
 	if err != nil {
-		return fmt.Errorf("failed to inspect created network: %w", err)
-	}
-
-	// Extract IPAM config
-	var subnet, gateway string
-	if len(networkInfo.IPAM.Config) > 0 {
-		subnet = networkInfo.IPAM.Config[0].Subnet
-		gateway = networkInfo.IPAM.Config[0].Gateway
-	}
-
-	state.NetworkInfo = &models.DeployedNetworkInfo{
-		NetworkID:   networkInfo.ID,
-		NetworkName: networkInfo.Name,
-		Driver:      networkInfo.Driver,
-		Subnet:      subnet,
-		Gateway:     gateway,
-		Scope:       networkInfo.Scope,
+		return err
 	}
+	info.HostNetworks = map[string]string{hostID: info.NetworkID}
+	state.NetworkInfo = info
 
 	d.addEvent(state, "info", "network-creation", "",
-		fmt.Sprintf("Network %s created with ID %s", plan.Network.Name, resp.ID))
+		fmt.Sprintf("Network %s created with ID %s", plan.Network.Name, info.NetworkID))
 
 	return d.DB.Update(ctx, state)
 }
 
-// deployVolumes creates any named volumes needed.
+// deployVolumes creates any named volumes needed. Volumes that already
+// exist are used as they are and not recorded in state.VolumeInfo, so a
+// rollback removes only the volumes this deployment created.
 func (d *Deployer) deployVolumes(ctx context.Context, plan *models.DeploymentPlan, state *models.DeploymentState, opts DeployOptions) error {
 	state.Phase = "volume-creation"
 	state.VolumeInfo = make(map[string]*models.VolumeInfo)
 
-	// Collect all named volumes
+	// Collect all named volumes; external volumes must exist already
 	volumes := make(map[string]*models.VolumeMount)
 	for _, spec := range plan.ContainerSpecs {
 		for _, vol := range spec.VolumeMounts {
-			if vol.Type == "volume" && vol.Source != "" {
-				volumes[vol.Source] = &vol
+			if vol.Type != "volume" || vol.Source == "" {
+				continue
 			}
+			if vol.VolumeOptions != nil && vol.VolumeOptions.External {
+				continue
+			}
+			// Green containers share the volumes of the deployment they
+			// replace
+			if opts.Blue != nil && opts.Blue.VolumeInfo[vol.Source] != nil {
+				continue
+			}
+			volumes[vol.Source] = &vol
 		}
 	}
 
@@ -293,12 +493,22 @@ func (d *Deployer) deployVolumes(ctx context.Context, plan *models.DeploymentPla
 			}
 		}
 
+		// Keep the data of a volume that exists already, e.g. from an
+		// earlier deployment: VolumeCreate would succeed for it too
+		if _, err := client.VolumeInspect(ctx, volName); err == nil {
+			d.addEvent(state, "info", "volume-creation", "",
+				fmt.Sprintf("Volume %s already exists, using it", volName))
+			continue
+		} else if !cerrdefs.IsNotFound(err) {
+			return fmt.Errorf("failed to inspect volume %s: %w", volName, err)
+		}
+
 		// Create volume
 		volResp, err := client.VolumeCreate(ctx, volume.CreateOptions{
 			Name:       volName,
 			Driver:     driver,
 			DriverOpts: driverOpts,
-			Labels:     labels,
+			Labels:     managedLabels(labels),
 		})
 		if err != nil {
 			return fmt.Errorf("failed to create volume %s: %w", volName, err)
@@ -307,6 +517,7 @@ func (d *Deployer) deployVolumes(ctx context.Context, plan *models.DeploymentPla
 		now := time.Now()
 		state.VolumeInfo[volName] = &models.VolumeInfo{
 			VolumeName: volName,
+			HostID:     hostID,
 			Driver:     volResp.Driver,
 			Scope:      volResp.Scope,
 			CreatedAt:  &now,
@@ -320,6 +531,8 @@ func (d *Deployer) deployVolumes(ctx context.Context, plan *models.DeploymentPla
 }
 
 // deployContainersInWaves deploys containers in dependency-ordered waves.
+// Waves deploy one after another; the containers of a wave deploy
+// concurrently, up to opts.Concurrency at once.
 func (d *Deployer) deployContainersInWaves(ctx context.Context, plan *models.DeploymentPlan, state *models.DeploymentState, opts DeployOptions) error {
 	state.Phase = "container-deployment"
 
@@ -333,18 +546,38 @@ func (d *Deployer) deployContainersInWaves(ctx context.Context, plan *models.Dep
 			fmt.Sprintf("Starting deployment wave %d/%d with %d container(s)",
 				waveNum+1, len(waves), len(wave)))
 
-		// Deploy all containers in this wave in parallel
-		for _, spec := range wave {
-			if err := d.deployContainer(ctx, plan, &spec, state, opts); err != nil {
+		// Place the wave one container at a time, so containers deployed
+		// concurrently never claim the same remaining capacity
+		planned := plannedReservations(state.Placements)
+		placed := plannedContainers(state.Placements)
+		hosts := make([]string, len(wave))
+		for i := range wave {
+			spec := &wave[i]
+			hostID, autoSelected, err := d.selectHost(ctx, plan, spec, planned, placed)
+			if err != nil {
 				return fmt.Errorf("failed to deploy container %s: %w", spec.Name, err)
 			}
+			if autoSelected {
+				d.addEvent(state, "info", "container-deployment", opts.containerName(spec.Name),
+					fmt.Sprintf("Auto-selected host %s for container %s", hostID, spec.Name))
+			}
+			addReservation(planned, hostID, spec.Resources.ReservedResources())
+			placed[hostID]++
+			hosts[i] = hostID
+		}
+
+		if err := d.deployWave(ctx, plan, wave, hosts, state, opts, func() {
 			deployed++
 			state.Progress = (deployed * 100) / totalContainers
-			// d.DB.Update(ctx, state) // Commented out to avoid CouchDB revision conflicts
+		}); err != nil {
+			return err
 		}
 
 		// Wait for wave to be healthy before proceeding
-		if err := d.waitForWaveHealth(ctx, wave, state, opts); err != nil {
+		err := d.runPhase(ctx, state, PhaseHealth, opts.PhaseTimeouts.Health, func(ctx context.Context) error {
+			return d.waitForWaveHealth(ctx, wave, state, opts)
+		})
+		if err != nil {
 			return fmt.Errorf("wave %d failed health check: %w", waveNum+1, err)
 		}
 	}
@@ -352,28 +585,124 @@ func (d *Deployer) deployContainersInWaves(ctx context.Context, plan *models.Dep
 	return nil
 }
 
-// deployContainer deploys a single container.
-func (d *Deployer) deployContainer(ctx context.Context, plan *models.DeploymentPlan, spec *models.ContainerSpec, state *models.DeploymentState, opts DeployOptions) error {
-	containerName := fmt.Sprintf("%s-%s", opts.StackName, spec.Name)
+// deployWave creates and starts the containers of a wave on their hosts,
+// up to opts.Concurrency at once, calling done (with the state locked) as
+// each one is deployed. If a container fails, containers not yet started
+// are skipped and the ones that did start are removed, so a wave deploys
+// completely or not at all; the error of the first failure is returned.
+func (d *Deployer) deployWave(ctx context.Context, plan *models.DeploymentPlan, wave []models.ContainerSpec, hosts []string, state *models.DeploymentState, opts DeployOptions, done func()) error {
+	waveCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
 
-	d.addEvent(state, "info", "container-deployment", containerName,
-		fmt.Sprintf("Deploying container %s with image %s", containerName, spec.Image))
+	errs := make([]error, len(wave))
+	slots := make(chan struct{}, opts.Concurrency)
+	var wg sync.WaitGroup
+	for i := range wave {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			spec := &wave[i]
+
+			select {
+			case slots <- struct{}{}:
+				defer func() { <-slots }()
+			case <-waveCtx.Done():
+				errs[i] = waveCtx.Err()
+				return
+			}
+			if err := waveCtx.Err(); err != nil {
+				errs[i] = err
+				return
+			}
 
-	// Get target host
-	hostID := plan.HostMap[spec.ID]
-	if hostID == "" {
-		// No host assigned, automatically select one
-		hosts, err := d.HostResolver.ListHosts()
-		if err != nil {
-			return fmt.Errorf("failed to list hosts for automatic placement: %w", err)
+			if err := d.deployWaveContainer(waveCtx, plan, spec, hosts[i], state, opts); err != nil {
+				errs[i] = fmt.Errorf("failed to deploy container %s: %w", spec.Name, err)
+				cancel()
+				return
+			}
+			d.mu.Lock()
+			done()
+			d.mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	// Containers skipped or interrupted because another one failed report
+	// the cancellation; the failure itself is the error to return
+	var failure error
+	for _, err := range errs {
+		if err == nil {
+			continue
+		}
+		if failure == nil || (errors.Is(failure, context.Canceled) && !errors.Is(err, context.Canceled)) {
+			failure = err
 		}
-		if len(hosts) == 0 {
-			return fmt.Errorf("no hosts available for container %s", spec.Name)
+	}
+	if failure == nil {
+		return nil
+	}
+
+	cleanupCtx, cancelCleanup := context.WithTimeout(context.WithoutCancel(ctx), waveCleanupTimeout)
+	defer cancelCleanup()
+	for i := range wave {
+		containerName := opts.containerName(wave[i].Name)
+		placement, ok := state.Placements[containerName]
+		if !ok {
+			continue
+		}
+		client, err := d.DockerClientFactory.GetClient(cleanupCtx, placement.HostID)
+		if err == nil {
+			err = removeContainer(cleanupCtx, client, placement.ContainerID, container.RemoveOptions{Force: true})
+		}
+		if err != nil {
+			d.addEvent(state, "warning", "container-deployment", containerName,
+				fmt.Sprintf("Failed to remove container %s of the failed wave: %v", containerName, err))
+			continue
 		}
-		// Use the first available host (TODO: implement smarter placement strategy)
-		hostID = hosts[0].Host.ID
+		delete(state.Placements, containerName)
 		d.addEvent(state, "info", "container-deployment", containerName,
-			fmt.Sprintf("Auto-selected host %s for container %s", hostID, spec.Name))
+			fmt.Sprintf("Removed container %s of the failed wave", containerName))
+	}
+
+	return failure
+}
+
+// deployWaveContainer deploys a container of a wave on hostID. If its image
+// is missing there, the image is pulled and the container created again.
+func (d *Deployer) deployWaveContainer(ctx context.Context, plan *models.DeploymentPlan, spec *models.ContainerSpec, hostID string, state *models.DeploymentState, opts DeployOptions) error {
+	deploy := func(ctx context.Context) error {
+		return d.deployContainer(ctx, plan, spec, hostID, state, opts)
+	}
+	err := d.runPhase(ctx, state, PhaseCreate, opts.PhaseTimeouts.Create, deploy)
+
+	var missing *missingImageError
+	if !errors.As(err, &missing) {
+		return err
+	}
+	err = d.runPhase(ctx, state, PhasePull, opts.PhaseTimeouts.Pull, func(ctx context.Context) error {
+		client, err := d.DockerClientFactory.GetClient(ctx, hostID)
+		if err != nil {
+			return fmt.Errorf("failed to get Docker client for host %s: %w", hostID, err)
+		}
+		return d.pullImage(ctx, client, state, opts.containerName(spec.Name), missing.Image, hostID)
+	})
+	if err != nil {
+		return err
+	}
+	return d.runPhase(ctx, state, PhaseCreate, opts.PhaseTimeouts.Create, deploy)
+}
+
+// deployContainer deploys a single container on hostID.
+func (d *Deployer) deployContainer(ctx context.Context, plan *models.DeploymentPlan, spec *models.ContainerSpec, hostID string, state *models.DeploymentState, opts DeployOptions) error {
+	containerName := opts.containerName(spec.Name)
+
+	d.addEvent(state, "info", "container-deployment", containerName,
+		fmt.Sprintf("Deploying container %s with image %s on host %s", containerName, spec.Image, hostID))
+
+	// The datacenter the container lands in may protect it further
+	policy := d.resolveHostPolicy(hostID)
+	if ok, reason := policy.Protection().Protects(spec.Name, spec.Image); ok {
+		return fmt.Errorf("%w: container %s in datacenter %s: %s", ErrProtectedContainer, spec.Name, policy.Datacenter, reason)
 	}
 
 	// Get Docker client
@@ -382,59 +711,109 @@ func (d *Deployer) deployContainer(ctx context.Context, plan *models.DeploymentP
 		return fmt.Errorf("failed to get Docker client: %w", err)
 	}
 
+	// A local stack network must exist on every host of the stack
+	if err := d.ensureHostNetwork(ctx, plan, hostID, state); err != nil {
+		return err
+	}
+
 	// Build container configuration
 	containerConfig := d.buildContainerConfig(spec)
+
+	// Dependencies on other hosts are reached through their host's
+	// published ports, as their names only resolve on their own host
+	d.mu.Lock()
+	env, unreachable := dependencyEnv(plan, spec, hostID, state.Placements, opts)
+	d.mu.Unlock()
+	containerConfig.Env = append(containerConfig.Env, env...)
+	for _, dep := range unreachable {
+		d.addEvent(state, "warning", "container-deployment", containerName,
+			fmt.Sprintf("Dependency %s runs on another host and publishes no ports", dep))
+	}
 	hostConfig := d.buildHostConfig(spec)
+	applyNetworkMode(hostConfig, plan, policy)
+	if opts.Mode == DeployModeBlueGreen {
+		publishEphemeral(hostConfig)
+	}
 	networkConfig := d.buildNetworkConfig(plan, spec)
+	missingImage := func(err error) error {
+		d.addEvent(state, "info", "container-deployment", containerName,
+			fmt.Sprintf("Image %s not found on host %s", spec.Image, hostID))
+		return &missingImageError{HostID: hostID, Image: spec.Image, Err: err}
+	}
+
+	// Config and secret files are mounted read-only from a volume filled
+	// before the container is created
+	files, err := d.prepareFiles(ctx, client, spec)
+	if cerrdefs.IsNotFound(err) {
+		return missingImage(err)
+	}
+	if err != nil {
+		return err
+	}
+	hostConfig.Mounts = append(hostConfig.Mounts, files...)
 
 	// Create container (platform nil for default)
 	resp, err := client.ContainerCreate(ctx, containerConfig, hostConfig, networkConfig, nil, containerName)
+	if err != nil && len(files) > 0 {
+		_ = client.VolumeRemove(context.WithoutCancel(ctx), files[0].Source, true)
+	}
+	if cerrdefs.IsNotFound(err) {
+		return missingImage(err)
+	}
 	if err != nil {
 		return fmt.Errorf("failed to create container: %w", err)
 	}
+	if len(files) > 0 {
+		d.addEvent(state, "info", "container-deployment", containerName,
+			fmt.Sprintf("Mounted %d file(s) read-only into container %s", len(files), containerName))
+	}
+
+	// A container that doesn't get deployed completely is removed with
+	// its files, even when the deployment was canceled or timed out
+	// meanwhile, since only containers with a placement are cleaned up later
+	removeCreated := func() {
+		removeCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), waveCleanupTimeout)
+		defer cancel()
+		if err := removeContainer(removeCtx, client, resp.ID, container.RemoveOptions{Force: true}); err != nil {
+			d.addEvent(state, "warning", "container-deployment", containerName,
+				fmt.Sprintf("Failed to remove partially deployed container %s: %v", containerName, err))
+		}
+	}
 
 	// Start container
 	if err := client.ContainerStart(ctx, resp.ID, container.StartOptions{}); err != nil {
+		removeCreated()
 		return fmt.Errorf("failed to start container: %w", err)
 	}
 
 	// Get container info
 	info, err := client.ContainerInspect(ctx, resp.ID)
 	if err != nil {
+		removeCreated()
 		return fmt.Errorf("failed to inspect container: %w", err)
 	}
 
 	// Get host IP
 	hostInfo, err := d.HostResolver.ResolveHost(hostID)
 	if err != nil {
+		removeCreated()
 		return fmt.Errorf("failed to resolve host: %w", err)
 	}
 
-	// Extract port mappings from inspect response
-	ports := make(map[int]int)
-	if info.NetworkSettings != nil {
-		for port, bindings := range info.NetworkSettings.Ports {
-			if len(bindings) > 0 {
-				containerPort := port.Int()
-				var hostPort int
-				if _, err := fmt.Sscanf(bindings[0].HostPort, "%d", &hostPort); err == nil {
-					ports[containerPort] = hostPort
-				}
-			}
-		}
-	}
-
 	// Store placement
 	now := time.Now()
+	d.mu.Lock()
 	state.Placements[containerName] = &models.ContainerPlacement{
 		ContainerID:   info.ID,
 		ContainerName: containerName,
 		HostID:        hostID,
 		IPAddress:     hostInfo.Host.IPAddress,
-		Ports:         ports,
+		Ports:         publishedPorts(info),
 		Status:        info.State.Status,
 		StartedAt:     &now,
+		Reserved:      spec.Resources.ReservedResources(),
 	}
+	d.mu.Unlock()
 
 	d.addEvent(state, "info", "container-deployment", containerName,
 		fmt.Sprintf("Container deployed successfully with ID %s", resp.ID))
@@ -442,6 +821,132 @@ func (d *Deployer) deployContainer(ctx context.Context, plan *models.DeploymentP
 	return nil
 }
 
+// selectHost returns the host a container is deployed to. If the plan doesn't
+// assign one, the plan's placement strategy selects one of the hosts with
+// enough remaining capacity and autoSelected is true; a plan with a target
+// datacenter only considers hosts there. planned and placed hold the
+// reservations and containers already placed in this deployment. Unless
+// overcommit is allowed for the host (AllowOvercommit or its datacenter
+// policy), a container whose reservation doesn't fit is refused with
+// ErrInsufficientCapacity.
+func (d *Deployer) selectHost(ctx context.Context, plan *models.DeploymentPlan, spec *models.ContainerSpec, planned map[string]*models.ResourceReservations, placed map[string]int) (hostID string, autoSelected bool, err error) {
+	need := spec.Resources.ReservedResources()
+
+	if hostID := plan.HostMap[spec.ID]; hostID != "" {
+		if need != nil && (!d.AllowOvercommit || len(d.Policies) > 0) {
+			info, err := d.HostResolver.ResolveHost(hostID)
+			if err != nil {
+				return "", false, fmt.Errorf("failed to resolve host %s: %w", hostID, err)
+			}
+			if d.allowOvercommit(info) {
+				return hostID, false, nil
+			}
+			if reason := capacityShortfall(info, need, planned[hostID]); reason != "" {
+				return "", false, fmt.Errorf("%w: container %s on host %s %s", ErrInsufficientCapacity, spec.Name, hostID, reason)
+			}
+		}
+		return hostID, false, nil
+	}
+
+	// No host assigned, automatically select one
+	hosts, err := d.HostResolver.ListHosts()
+	if err != nil {
+		return "", false, fmt.Errorf("failed to list hosts for automatic placement: %w", err)
+	}
+
+	datacenter := targetDatacenter(plan)
+	var candidates []*models.HostInfo
+	var reasons []string
+	for _, info := range hosts {
+		if info == nil || info.Host == nil {
+			continue
+		}
+		if datacenter != "" && info.Host.Datacenter != datacenter {
+			continue
+		}
+		if need != nil && !d.allowOvercommit(info) {
+			if reason := capacityShortfall(info, need, planned[info.Host.ID]); reason != "" {
+				reasons = append(reasons, info.Host.ID+" "+reason)
+				continue
+			}
+		}
+		candidates = append(candidates, info)
+	}
+
+	if len(candidates) == 0 {
+		if len(reasons) > 0 {
+			return "", false, fmt.Errorf("%w: no host fits container %s (%s)", ErrInsufficientCapacity, spec.Name, strings.Join(reasons, "; "))
+		}
+		if datacenter != "" {
+			return "", false, fmt.Errorf("no hosts available in datacenter %s for container %s", datacenter, spec.Name)
+		}
+		return "", false, fmt.Errorf("no hosts available for container %s", spec.Name)
+	}
+
+	name := d.placementStrategy(plan)
+	hostID, err = d.strategy(name).SelectHost(ctx, spec, candidates, &PlacementState{
+		Plan:    plan,
+		Planned: planned,
+		Placed:  placed,
+	})
+	if err != nil {
+		return "", false, fmt.Errorf("placement strategy %s failed for container %s: %w", name, spec.Name, err)
+	}
+	for _, info := range candidates {
+		if info.Host.ID == hostID {
+			return hostID, true, nil
+		}
+	}
+	return "", false, fmt.Errorf("placement strategy %s selected host %q, which is not a candidate for container %s", name, hostID, spec.Name)
+}
+
+// pullImages pulls each image on the hosts that will run it.
+func (d *Deployer) pullImages(ctx context.Context, plan *models.DeploymentPlan, state *models.DeploymentState) error {
+	state.Phase = "image-pull"
+
+	pulled := make(map[string]bool)
+	planned := make(map[string]*models.ResourceReservations)
+	placed := make(map[string]int)
+	for i := range plan.ContainerSpecs {
+		spec := &plan.ContainerSpecs[i]
+
+		hostID, _, err := d.selectHost(ctx, plan, spec, planned, placed)
+		if err != nil {
+			return err
+		}
+		addReservation(planned, hostID, spec.Resources.ReservedResources())
+		placed[hostID]++
+
+		key := hostID + "|" + spec.Image
+		if pulled[key] {
+			continue
+		}
+
+		client, err := d.DockerClientFactory.GetClient(ctx, hostID)
+		if err != nil {
+			return fmt.Errorf("failed to get Docker client for host %s: %w", hostID, err)
+		}
+		if err := d.pullImage(ctx, client, state, "", spec.Image, hostID); err != nil {
+			return err
+		}
+
+		pulled[key] = true
+	}
+
+	return nil
+}
+
+// managedLabels returns a copy of labels with models.ManagedLabel set, so
+// host prune tasks leave the resource alone.
+func managedLabels(labels map[string]string) map[string]string {
+	result := make(map[string]string, len(labels)+1)
+	for key, value := range labels {
+		result[key] = value
+	}
+	result[models.ManagedLabel] = "true"
+	return result
+}
+
 // buildContainerConfig builds the Docker container.Config from ContainerSpec.
 func (d *Deployer) buildContainerConfig(spec *models.ContainerSpec) *container.Config {
 	config := &container.Config{
@@ -449,6 +954,19 @@ func (d *Deployer) buildContainerConfig(spec *models.ContainerSpec) *container.C
 		Env:    []string{},
 		Labels: spec.Labels,
 	}
+	if spec.StopGracePeriod > 0 {
+		stopTimeout := spec.StopGracePeriod
+		config.StopTimeout = &stopTimeout
+	}
+
+	// Docker has no CPU reservation; record it for the agent to report
+	if spec.Resources != nil && spec.Resources.Reservations != nil && spec.Resources.Reservations.CPUs > 0 {
+		config.Labels = make(map[string]string, len(spec.Labels)+1)
+		for key, value := range spec.Labels {
+			config.Labels[key] = value
+		}
+		config.Labels[models.CPUReservationLabel] = strconv.FormatFloat(spec.Resources.Reservations.CPUs, 'f', -1, 64)
+	}
 
 	// Environment variables
 	for _, env := range spec.Environment {
@@ -500,13 +1018,7 @@ func (d *Deployer) buildHostConfig(spec *models.ContainerSpec) *container.HostCo
 
 	// Port bindings
 	for _, p := range spec.Ports {
-		port := nat.Port(fmt.Sprintf("%d/%s", p.ContainerPort, p.Protocol))
-		binding := nat.PortBinding{
-			HostPort: fmt.Sprintf("%d", p.HostPort),
-		}
-		if p.HostIP != "" {
-			binding.HostIP = p.HostIP
-		}
+		port, binding := portBinding(p)
 		hostConfig.PortBindings[port] = []nat.PortBinding{binding}
 	}
 
@@ -547,13 +1059,46 @@ func (d *Deployer) buildHostConfig(spec *models.ContainerSpec) *container.HostCo
 				hostConfig.PidsLimit = &pidsLimit
 			}
 		}
-		// Note: Reservations are not directly supported in standalone Docker
-		// They would be used in Docker Swarm mode
+		// Standalone Docker only knows a soft memory reservation; CPU
+		// reservations are enforced by placement (see selectHost)
+		if spec.Resources.Reservations != nil && spec.Resources.Reservations.Memory > 0 {
+			hostConfig.MemoryReservation = spec.Resources.Reservations.Memory
+		}
 	}
 
 	return hostConfig
 }
 
+// portBinding returns the Docker port binding of a port mapping.
+func portBinding(p models.PortMapping) (nat.Port, nat.PortBinding) {
+	port := nat.Port(fmt.Sprintf("%d/%s", p.ContainerPort, p.Protocol))
+	binding := nat.PortBinding{
+		HostPort: fmt.Sprintf("%d", p.HostPort),
+	}
+	if p.HostIP != "" {
+		binding.HostIP = p.HostIP
+	}
+	return port, binding
+}
+
+// publishedPorts returns the host ports a container's ports are published
+// on, keyed by container port.
+func publishedPorts(info container.InspectResponse) map[int]int {
+	ports := make(map[int]int)
+	if info.NetworkSettings != nil {
+		for port, bindings := range info.NetworkSettings.Ports {
+			if len(bindings) > 0 {
+				containerPort := port.Int()
+				var hostPort int
+				if _, err := fmt.Sscanf(bindings[0].HostPort, "%d", &hostPort); err == nil {
+					ports[containerPort] = hostPort
+				}
+			}
+		}
+	}
+	return ports
+}
+
 // buildNetworkConfig builds the Docker network.NetworkingConfig.
 func (d *Deployer) buildNetworkConfig(plan *models.DeploymentPlan, spec *models.ContainerSpec) *network.NetworkingConfig {
 	if plan.Network == nil {
@@ -598,8 +1143,12 @@ func (d *Deployer) getContainerWaves(plan *models.DeploymentPlan) [][]models.Con
 func (d *Deployer) waitForWaveHealth(ctx context.Context, wave []models.ContainerSpec, state *models.DeploymentState, opts DeployOptions) error {
 	// Simple wait for now - just give containers time to start
 	// In a full implementation, this would check health checks
-	time.Sleep(2 * time.Second)
-	return nil
+	select {
+	case <-time.After(2 * time.Second):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
 }
 
 // getPrimaryHost gets the primary host for network/volume creation.
@@ -633,6 +1182,8 @@ func (d *Deployer) getVolumeHost(plan *models.DeploymentPlan, volumeName string)
 
 // addEvent adds an event to the deployment state.
 func (d *Deployer) addEvent(state *models.DeploymentState, eventType, phase, container, message string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
 	state.Events = append(state.Events, models.DeploymentEvent{
 		Timestamp: time.Now(),
 		Type:      eventType,
@@ -676,7 +1227,7 @@ func (d *Deployer) rollback(ctx context.Context, state *models.DeploymentState)
 			continue
 		}
 
-		if err := client.ContainerRemove(ctx, placement.ContainerID, container.RemoveOptions{Force: true}); err != nil {
+		if err := removeContainer(ctx, client, placement.ContainerID, container.RemoveOptions{Force: true}); err != nil {
 			d.addEvent(state, "error", "rollback", name,
 				fmt.Sprintf("Failed to remove container: %v", err))
 		} else {
@@ -685,6 +1236,11 @@ func (d *Deployer) rollback(ctx context.Context, state *models.DeploymentState)
 		}
 	}
 
+	// Then the volumes and network the deployment created, in reverse order
+	// of creation; external and pre-existing volumes were never recorded
+	d.rollbackVolumes(ctx, state)
+	d.rollbackNetworks(ctx, state)
+
 	completedAt := time.Now()
 	state.RollbackState.Status = "rolled-back"
 	state.RollbackState.CompletedAt = &completedAt
@@ -693,6 +1249,79 @@ func (d *Deployer) rollback(ctx context.Context, state *models.DeploymentState)
 	_ = d.DB.Update(ctx, state)
 }
 
+// rollbackVolumes removes the volumes recorded in the state, the most
+// recently created first.
+func (d *Deployer) rollbackVolumes(ctx context.Context, state *models.DeploymentState) {
+	volumes := make([]*models.VolumeInfo, 0, len(state.VolumeInfo))
+	for _, info := range state.VolumeInfo {
+		if info != nil && info.HostID != "" {
+			volumes = append(volumes, info)
+		}
+	}
+	sort.SliceStable(volumes, func(i, j int) bool {
+		if volumes[i].CreatedAt == nil || volumes[j].CreatedAt == nil {
+			return volumes[i].CreatedAt != nil
+		}
+		return volumes[i].CreatedAt.After(*volumes[j].CreatedAt)
+	})
+
+	for _, info := range volumes {
+		client, err := d.DockerClientFactory.GetClient(ctx, info.HostID)
+		if err != nil {
+			d.addEvent(state, "error", "rollback", "",
+				fmt.Sprintf("Failed to get client for rollback of volume %s: %v", info.VolumeName, err))
+			continue
+		}
+		if err := client.VolumeRemove(ctx, info.VolumeName, false); err != nil {
+			d.addEvent(state, "error", "rollback", "",
+				fmt.Sprintf("Failed to remove volume %s: %v", info.VolumeName, err))
+			continue
+		}
+		state.RollbackState.RemovedVolumes = append(state.RollbackState.RemovedVolumes, info.VolumeName)
+		d.addEvent(state, "info", "rollback", "", fmt.Sprintf("Volume %s removed", info.VolumeName))
+	}
+}
+
+// rollbackNetworks removes the networks the deployment created, those on
+// the other hosts before the one on the primary host, which was created
+// first. External networks are never recorded in HostNetworks.
+func (d *Deployer) rollbackNetworks(ctx context.Context, state *models.DeploymentState) {
+	if state.NetworkInfo == nil || len(state.NetworkInfo.HostNetworks) == 0 {
+		return
+	}
+
+	hosts := make([]string, 0, len(state.NetworkInfo.HostNetworks))
+	var primary string
+	for hostID, networkID := range state.NetworkInfo.HostNetworks {
+		if networkID == state.NetworkInfo.NetworkID && primary == "" {
+			primary = hostID
+			continue
+		}
+		hosts = append(hosts, hostID)
+	}
+	sort.Strings(hosts)
+	if primary != "" {
+		hosts = append(hosts, primary)
+	}
+
+	for _, hostID := range hosts {
+		client, err := d.DockerClientFactory.GetClient(ctx, hostID)
+		if err != nil {
+			d.addEvent(state, "error", "rollback", "",
+				fmt.Sprintf("Failed to get client for rollback of network on host %s: %v", hostID, err))
+			continue
+		}
+		if err := client.NetworkRemove(ctx, state.NetworkInfo.HostNetworks[hostID]); err != nil {
+			d.addEvent(state, "error", "rollback", "",
+				fmt.Sprintf("Failed to remove network %s on host %s: %v", state.NetworkInfo.NetworkName, hostID, err))
+			continue
+		}
+		state.RollbackState.RemovedNetworks = append(state.RollbackState.RemovedNetworks, hostID)
+		d.addEvent(state, "info", "rollback", "",
+			fmt.Sprintf("Network %s removed on host %s", state.NetworkInfo.NetworkName, hostID))
+	}
+}
+
 // Stop stops all containers in a deployment.
 func (d *Deployer) Stop(ctx context.Context, state *models.DeploymentState) error {
 	if state == nil {
@@ -714,8 +1343,8 @@ func (d *Deployer) Stop(ctx context.Context, state *models.DeploymentState) erro
 			continue
 		}
 
-		timeout := 10 // 10 seconds
-		if err := client.ContainerStop(ctx, placement.ContainerID, container.StopOptions{Timeout: &timeout}); err != nil {
+		// No explicit timeout: Docker uses the container's stop grace period
+		if err := client.ContainerStop(ctx, placement.ContainerID, container.StopOptions{}); err != nil {
 			d.addEvent(state, "error", "stopping", name,
 				fmt.Sprintf("Failed to stop container: %v", err))
 		} else {
@@ -751,12 +1380,16 @@ func (d *Deployer) Remove(ctx context.Context, state *models.DeploymentState, re
 			continue
 		}
 
+		// Give the container its stop grace period to shut down cleanly
+		// before it is force-removed
+		_ = client.ContainerStop(ctx, placement.ContainerID, container.StopOptions{})
+
 		removeOpts := container.RemoveOptions{
 			Force:         true,
 			RemoveVolumes: removeVolumes,
 		}
 
-		if err := client.ContainerRemove(ctx, placement.ContainerID, removeOpts); err != nil {
+		if err := removeContainer(ctx, client, placement.ContainerID, removeOpts); err != nil {
 			d.addEvent(state, "error", "removing", name,
 				fmt.Sprintf("Failed to remove container: %v", err))
 		} else {
@@ -764,8 +1397,23 @@ func (d *Deployer) Remove(ctx context.Context, state *models.DeploymentState, re
 		}
 	}
 
-	// Remove network if it was created
-	if state.NetworkInfo != nil && state.NetworkInfo.NetworkID != "" {
+	// Remove the networks the deployment created on each host
+	if info := state.NetworkInfo; info != nil && len(info.HostNetworks) > 0 {
+		for hostID, networkID := range info.HostNetworks {
+			client, err := d.DockerClientFactory.GetClient(ctx, hostID)
+			if err != nil {
+				d.addEvent(state, "error", "removing", "",
+					fmt.Sprintf("Failed to get client for host %s: %v", hostID, err))
+				continue
+			}
+			if err := client.NetworkRemove(ctx, networkID); err != nil {
+				d.addEvent(state, "error", "removing", "",
+					fmt.Sprintf("Failed to remove network on host %s: %v", hostID, err))
+			} else {
+				d.addEvent(state, "info", "removing", "", fmt.Sprintf("Network removed on host %s", hostID))
+			}
+		}
+	} else if state.NetworkInfo != nil && state.NetworkInfo.NetworkID != "" {
 		// Get the primary host (first host with a container)
 		var primaryHostID string
 		for _, placement := range state.Placements {
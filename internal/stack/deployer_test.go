@@ -1,11 +1,26 @@
 package stack
 
 import (
+	"archive/tar"
+	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
+	cerrdefs "github.com/containerd/errdefs"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/api/types/volume"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+
 	"eve.evalgo.org/common"
 
 	"evalgo.org/graphium/models"
@@ -376,6 +391,142 @@ func TestDeployer_RollbackOnError(t *testing.T) {
 	}
 }
 
+// rollbackDockerClient fails to start containers and records the volumes and
+// networks removed in order. Volumes in existing exist before the deployment.
+type rollbackDockerClient struct {
+	*common.MockDockerClient
+	removed  []string
+	existing map[string]bool
+}
+
+func (c *rollbackDockerClient) VolumeInspect(ctx context.Context, volumeID string) (volume.Volume, error) {
+	if c.existing[volumeID] {
+		return volume.Volume{Name: volumeID, Driver: "local"}, nil
+	}
+	return volume.Volume{}, cerrdefs.ErrNotFound
+}
+
+func (c *rollbackDockerClient) ContainerStart(ctx context.Context, containerID string, options container.StartOptions) error {
+	return errors.New("failed to start container")
+}
+
+func (c *rollbackDockerClient) VolumeRemove(ctx context.Context, volumeID string, force bool) error {
+	c.removed = append(c.removed, "volume:"+volumeID)
+	return nil
+}
+
+func (c *rollbackDockerClient) NetworkRemove(ctx context.Context, networkID string) error {
+	c.removed = append(c.removed, "network:"+networkID)
+	return nil
+}
+
+func TestDeployer_RollbackRemovesNetworkAndVolumes(t *testing.T) {
+	resolver := &MockHostResolver{
+		hosts: map[string]*models.HostInfo{
+			"host1": {Host: &models.Host{ID: "host1", Name: "test-host", IPAddress: "192.168.1.10"}},
+		},
+	}
+	client := &rollbackDockerClient{MockDockerClient: common.NewMockDockerClient()}
+	deployer := NewDeployer(&MockDatabase{}, resolver, &rollbackClientFactory{client: client})
+
+	plan := &models.DeploymentPlan{
+		StackNode: &models.GraphNode{ID: "stack1", Name: "test-stack"},
+		ContainerSpecs: []models.ContainerSpec{
+			{
+				ID: "container1", Name: "db", Image: "postgres:16",
+				VolumeMounts: []models.VolumeMount{
+					{Source: "db-data", Target: "/var/lib/postgresql/data", Type: "volume"},
+					{Source: "backups", Target: "/backups", Type: "volume", VolumeOptions: &models.VolumeOptions{External: true}},
+				},
+			},
+		},
+		HostMap:         map[string]string{"container1": "host1"},
+		Network:         &models.NetworkSpec{Name: "app-network", Driver: "bridge"},
+		DependencyGraph: [][]string{{"db"}},
+	}
+
+	state, err := deployer.Deploy(context.Background(), plan, DeployOptions{
+		Timeout:         time.Minute,
+		RollbackOnError: true,
+		StackName:       "test-stack",
+	})
+	if err == nil {
+		t.Fatal("Expected deploy to fail, but it succeeded")
+	}
+
+	want := []string{"volume:db-data", "network:mock-net-app-network"}
+	if strings.Join(client.removed, ",") != strings.Join(want, ",") {
+		t.Errorf("Expected removals %v, got %v", want, client.removed)
+	}
+	rollback := state.RollbackState
+	if rollback == nil || len(rollback.RemovedVolumes) != 1 || len(rollback.RemovedNetworks) != 1 {
+		t.Fatalf("Expected the volume and network in the rollback state, got %+v", rollback)
+	}
+
+	var events int
+	for _, event := range state.Events {
+		if event.Phase == "rollback" && (strings.HasPrefix(event.Message, "Volume ") || strings.HasPrefix(event.Message, "Network ")) {
+			events++
+		}
+	}
+	if events != 2 {
+		t.Errorf("Expected a rollback event per removed volume and network, got %d", events)
+	}
+}
+
+func TestDeployer_RollbackKeepsExistingVolumes(t *testing.T) {
+	resolver := &MockHostResolver{
+		hosts: map[string]*models.HostInfo{
+			"host1": {Host: &models.Host{ID: "host1", Name: "test-host", IPAddress: "192.168.1.10"}},
+		},
+	}
+	client := &rollbackDockerClient{
+		MockDockerClient: common.NewMockDockerClient(),
+		existing:         map[string]bool{"db-data": true},
+	}
+	deployer := NewDeployer(&MockDatabase{}, resolver, &rollbackClientFactory{client: client})
+
+	plan := &models.DeploymentPlan{
+		StackNode: &models.GraphNode{ID: "stack1", Name: "test-stack"},
+		ContainerSpecs: []models.ContainerSpec{
+			{
+				ID: "container1", Name: "db", Image: "postgres:16",
+				VolumeMounts: []models.VolumeMount{
+					{Source: "db-data", Target: "/var/lib/postgresql/data", Type: "volume"},
+					{Source: "db-logs", Target: "/var/log/postgresql", Type: "volume"},
+				},
+			},
+		},
+		HostMap:         map[string]string{"container1": "host1"},
+		DependencyGraph: [][]string{{"db"}},
+	}
+
+	state, err := deployer.Deploy(context.Background(), plan, DeployOptions{
+		Timeout:         time.Minute,
+		RollbackOnError: true,
+		StackName:       "test-stack",
+	})
+	if err == nil {
+		t.Fatal("Expected deploy to fail, but it succeeded")
+	}
+
+	if strings.Join(client.removed, ",") != "volume:db-logs" {
+		t.Errorf("Expected only the created volume to be removed, got %v", client.removed)
+	}
+	if _, ok := state.VolumeInfo["db-data"]; ok {
+		t.Error("Expected the existing volume not to be recorded as created")
+	}
+}
+
+// rollbackClientFactory always returns the same rollback client
+type rollbackClientFactory struct {
+	client *rollbackDockerClient
+}
+
+func (f *rollbackClientFactory) GetClient(ctx context.Context, hostID string) (common.DockerClient, error) {
+	return f.client, nil
+}
+
 func TestDeployer_HostNotFound(t *testing.T) {
 	db := &MockDatabase{documents: make(map[string]interface{})}
 	resolver := &MockHostResolver{
@@ -517,6 +668,57 @@ func TestDeployer_BuildContainerConfig(t *testing.T) {
 	if config.User != "nginx" {
 		t.Errorf("Expected user 'nginx', got '%s'", config.User)
 	}
+
+	if _, ok := config.Labels[models.CPUReservationLabel]; ok {
+		t.Error("Expected no CPU reservation label without a reservation")
+	}
+}
+
+func TestDeployer_StopGracePeriod(t *testing.T) {
+	deployer := NewDeployer(&MockDatabase{}, &MockHostResolver{}, &MockDockerClientFactory{})
+
+	config := deployer.buildContainerConfig(&models.ContainerSpec{Name: "db", Image: "postgres:15"})
+	if config.StopTimeout != nil {
+		t.Errorf("Expected Docker's default stop timeout without a grace period, got %d", *config.StopTimeout)
+	}
+
+	config = deployer.buildContainerConfig(&models.ContainerSpec{Name: "db", Image: "postgres:15", StopGracePeriod: 60})
+	if config.StopTimeout == nil || *config.StopTimeout != 60 {
+		t.Errorf("Expected a stop timeout of 60 seconds, got %v", config.StopTimeout)
+	}
+}
+
+func TestDeployer_ResourceLimitsAndReservations(t *testing.T) {
+	deployer := NewDeployer(&MockDatabase{}, &MockHostResolver{}, &MockDockerClientFactory{})
+
+	spec := &models.ContainerSpec{
+		Name:   "web",
+		Image:  "nginx:latest",
+		Labels: map[string]string{"app": "web"},
+		Resources: &models.ResourceConstraints{
+			Limits:       &models.ResourceLimits{CPUs: 2, Memory: 1 << 30},
+			Reservations: &models.ResourceReservations{CPUs: 0.5, Memory: 512 << 20},
+		},
+	}
+
+	hostConfig := deployer.buildHostConfig(spec)
+	if hostConfig.NanoCPUs != 2e9 || hostConfig.Memory != 1<<30 {
+		t.Errorf("Expected limits of 2 CPUs and 1GiB, got %d nano CPUs and %d bytes", hostConfig.NanoCPUs, hostConfig.Memory)
+	}
+	if hostConfig.MemoryReservation != 512<<20 {
+		t.Errorf("Expected a 512MiB memory reservation, got %d", hostConfig.MemoryReservation)
+	}
+
+	config := deployer.buildContainerConfig(spec)
+	if got := config.Labels[models.CPUReservationLabel]; got != "0.5" {
+		t.Errorf("Expected CPU reservation label 0.5, got %q", got)
+	}
+	if config.Labels["app"] != "web" {
+		t.Error("Expected the spec labels to be kept")
+	}
+	if _, ok := spec.Labels[models.CPUReservationLabel]; ok {
+		t.Error("Expected the spec labels not to be modified")
+	}
 }
 
 func TestDeployer_EventLogging(t *testing.T) {
@@ -601,3 +803,610 @@ func TestDeployer_EventLogging(t *testing.T) {
 		t.Error("Expected container deployment event")
 	}
 }
+
+func TestDeployer_PhaseTimeout(t *testing.T) {
+	db := &MockDatabase{documents: make(map[string]interface{})}
+	resolver := &MockHostResolver{
+		hosts: map[string]*models.HostInfo{
+			"host1": {
+				Host: &models.Host{
+					ID:        "host1",
+					Name:      "test-host",
+					IPAddress: "192.168.1.10",
+				},
+			},
+		},
+	}
+
+	clientFactory := &MockDockerClientFactory{
+		defaultClient: common.NewMockDockerClient(),
+	}
+
+	deployer := NewDeployer(db, resolver, clientFactory)
+
+	plan := &models.DeploymentPlan{
+		StackNode: &models.GraphNode{
+			ID:   "stack1",
+			Name: "test-stack",
+		},
+		ContainerSpecs: []models.ContainerSpec{
+			{
+				ID:    "container1",
+				Name:  "web",
+				Image: "nginx:latest",
+			},
+		},
+		HostMap: map[string]string{
+			"container1": "host1",
+		},
+		DependencyGraph: [][]string{
+			{"web"},
+		},
+	}
+
+	// The health wait takes longer than the health phase budget
+	opts := DeployOptions{
+		Timeout:   5 * time.Minute,
+		StackName: "test-stack",
+		PhaseTimeouts: PhaseTimeouts{
+			Health: 10 * time.Millisecond,
+		},
+	}
+
+	state, err := deployer.Deploy(context.Background(), plan, opts)
+	if err == nil {
+		t.Fatal("Expected deployment to fail with a phase timeout")
+	}
+
+	var phaseErr *PhaseTimeoutError
+	if !errors.As(err, &phaseErr) {
+		t.Fatalf("Expected PhaseTimeoutError, got %v", err)
+	}
+	if phaseErr.Phase != PhaseHealth {
+		t.Errorf("Expected timed out phase %s, got %s", PhaseHealth, phaseErr.Phase)
+	}
+
+	if state.Status != "failed" {
+		t.Errorf("Expected status failed, got %s", state.Status)
+	}
+	if state.TimedOutPhase != PhaseHealth {
+		t.Errorf("Expected TimedOutPhase %s, got %s", PhaseHealth, state.TimedOutPhase)
+	}
+
+	hasTimeoutEvent := false
+	for _, event := range state.Events {
+		if event.Type == "error" && event.Phase == PhaseHealth {
+			hasTimeoutEvent = true
+		}
+	}
+	if !hasTimeoutEvent {
+		t.Error("Expected an error event for the health phase")
+	}
+}
+
+func TestPhaseTimeouts_WithDefaults(t *testing.T) {
+	timeouts := PhaseTimeouts{Pull: 10 * time.Minute}.withDefaults()
+	defaults := DefaultPhaseTimeouts()
+
+	if timeouts.Pull != 10*time.Minute {
+		t.Errorf("Expected pull override 10m, got %v", timeouts.Pull)
+	}
+	if timeouts.Network != defaults.Network {
+		t.Errorf("Expected default network timeout %v, got %v", defaults.Network, timeouts.Network)
+	}
+	if timeouts.Create != defaults.Create {
+		t.Errorf("Expected default create timeout %v, got %v", defaults.Create, timeouts.Create)
+	}
+	if timeouts.Health != defaults.Health {
+		t.Errorf("Expected default health timeout %v, got %v", defaults.Health, timeouts.Health)
+	}
+}
+
+// copyingDockerClient records archives copied into containers and the host
+// configuration of created containers
+type copyingDockerClient struct {
+	*common.MockDockerClient
+	copied      map[string][]byte
+	hostConfigs map[string]*container.HostConfig
+	removed     map[string]bool
+}
+
+func (c *copyingDockerClient) ContainerCreate(ctx context.Context, config *container.Config, hostConfig *container.HostConfig, networkingConfig *network.NetworkingConfig, platform *ocispec.Platform, containerName string) (container.CreateResponse, error) {
+	if c.hostConfigs == nil {
+		c.hostConfigs = make(map[string]*container.HostConfig)
+	}
+	c.hostConfigs[containerName] = hostConfig
+	return c.MockDockerClient.ContainerCreate(ctx, config, hostConfig, networkingConfig, platform, containerName)
+}
+
+func (c *copyingDockerClient) ContainerRemove(ctx context.Context, containerID string, options container.RemoveOptions) error {
+	if c.removed == nil {
+		c.removed = make(map[string]bool)
+	}
+	c.removed[containerID] = true
+	return c.MockDockerClient.ContainerRemove(ctx, containerID, options)
+}
+
+func (c *copyingDockerClient) CopyToContainer(ctx context.Context, containerID, dstPath string, content io.Reader, options container.CopyToContainerOptions) error {
+	data, err := io.ReadAll(content)
+	if err != nil {
+		return err
+	}
+	if c.copied == nil {
+		c.copied = make(map[string][]byte)
+	}
+	c.copied[containerID] = data
+	return nil
+}
+
+// copyingClientFactory always returns the same copying client
+type copyingClientFactory struct {
+	client *copyingDockerClient
+}
+
+func (f *copyingClientFactory) GetClient(ctx context.Context, hostID string) (common.DockerClient, error) {
+	return f.client, nil
+}
+
+// mapSecretStore is an in-memory SecretStore
+type mapSecretStore map[string]string
+
+func (m mapSecretStore) GetSecret(ctx context.Context, name string) ([]byte, error) {
+	value, ok := m[name]
+	if !ok {
+		return nil, ErrSecretNotFound
+	}
+	return []byte(value), nil
+}
+
+func TestDeployer_DeployWithFiles(t *testing.T) {
+	db := &MockDatabase{documents: make(map[string]interface{})}
+	resolver := &MockHostResolver{
+		hosts: map[string]*models.HostInfo{
+			"host1": {
+				Host: &models.Host{
+					ID:        "host1",
+					Name:      "test-host",
+					IPAddress: "192.168.1.10",
+				},
+			},
+		},
+	}
+
+	client := &copyingDockerClient{MockDockerClient: common.NewMockDockerClient()}
+	deployer := NewDeployer(db, resolver, &copyingClientFactory{client: client})
+	deployer.Secrets = mapSecretStore{"db-password": "s3cret"}
+
+	plan := &models.DeploymentPlan{
+		StackNode: &models.GraphNode{
+			ID:   "stack1",
+			Name: "test-stack",
+		},
+		ContainerSpecs: []models.ContainerSpec{
+			{
+				ID:    "container1",
+				Name:  "web",
+				Image: "nginx:latest",
+				Files: []models.FileMount{
+					{Target: "/etc/app/app.conf", Content: "port=80"},
+					{Target: "/run/secrets/db-password", Secret: "db-password", Mode: "0400", UID: 999},
+				},
+			},
+		},
+		HostMap: map[string]string{
+			"container1": "host1",
+		},
+		DependencyGraph: [][]string{
+			{"web"},
+		},
+	}
+
+	opts := DeployOptions{
+		Timeout:       5 * time.Minute,
+		StackName:     "test-stack",
+		PhaseTimeouts: PhaseTimeouts{Health: time.Minute},
+	}
+
+	state, err := deployer.Deploy(context.Background(), plan, opts)
+	if err != nil {
+		t.Fatalf("Deploy failed: %v", err)
+	}
+
+	// The files are written into a volume through a helper container,
+	// which is removed again
+	archive, ok := client.copied["mock-"]
+	if !ok {
+		t.Fatal("Expected files to be copied into the helper container")
+	}
+	if !client.removed["mock-"] {
+		t.Error("Expected the helper container to be removed")
+	}
+	if _, ok := client.copied["mock-test-stack-web"]; ok {
+		t.Error("Expected no files in the container's writable layer")
+	}
+	mounts := make(map[string]mount.Mount)
+	for _, m := range client.hostConfigs["test-stack-web"].Mounts {
+		mounts[m.Target] = m
+	}
+	for _, target := range []string{"/etc/app/app.conf", "/run/secrets/db-password"} {
+		m, ok := mounts[target]
+		if !ok || !m.ReadOnly || m.Type != mount.TypeVolume || m.VolumeOptions == nil || m.VolumeOptions.Subpath != target[1:] {
+			t.Errorf("Expected %s mounted read-only from the files volume, got %+v", target, m)
+		}
+	}
+
+	type entry struct {
+		content string
+		mode    int64
+		uid     int
+	}
+	entries := make(map[string]entry)
+	tr := tar.NewReader(bytes.NewReader(archive))
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Failed to read archive: %v", err)
+		}
+		data, _ := io.ReadAll(tr)
+		entries[header.Name] = entry{content: string(data), mode: header.Mode, uid: header.Uid}
+	}
+
+	if got := entries["etc/app/app.conf"]; got.content != "port=80" || got.mode != 0444 {
+		t.Errorf("Unexpected config file entry: %+v", got)
+	}
+	if got := entries["run/secrets/db-password"]; got.content != "s3cret" || got.mode != 0400 || got.uid != 999 {
+		t.Errorf("Unexpected secret file entry: %+v", got)
+	}
+
+	// Secret values must never end up in the persisted deployment state
+	for _, doc := range db.documents {
+		data, err := json.Marshal(doc)
+		if err != nil {
+			t.Fatalf("Failed to marshal document: %v", err)
+		}
+		if bytes.Contains(data, []byte("s3cret")) {
+			t.Error("Secret content was persisted")
+		}
+	}
+	if state.Placements["test-stack-web"] == nil {
+		t.Error("Expected container placement")
+	}
+}
+
+func TestDeployer_DeployWithMissingSecret(t *testing.T) {
+	db := &MockDatabase{documents: make(map[string]interface{})}
+	resolver := &MockHostResolver{
+		hosts: map[string]*models.HostInfo{
+			"host1": {Host: &models.Host{ID: "host1", IPAddress: "192.168.1.10"}},
+		},
+	}
+
+	client := &copyingDockerClient{MockDockerClient: common.NewMockDockerClient()}
+	deployer := NewDeployer(db, resolver, &copyingClientFactory{client: client})
+	deployer.Secrets = mapSecretStore{}
+
+	plan := &models.DeploymentPlan{
+		StackNode: &models.GraphNode{ID: "stack1", Name: "test-stack"},
+		ContainerSpecs: []models.ContainerSpec{
+			{
+				ID:    "container1",
+				Name:  "web",
+				Image: "nginx:latest",
+				Files: []models.FileMount{
+					{Target: "/run/secrets/token", Secret: "token"},
+				},
+			},
+		},
+		HostMap:         map[string]string{"container1": "host1"},
+		DependencyGraph: [][]string{{"web"}},
+	}
+
+	_, err := deployer.Deploy(context.Background(), plan, DeployOptions{StackName: "test-stack"})
+	if !errors.Is(err, ErrSecretNotFound) {
+		t.Fatalf("Expected ErrSecretNotFound, got %v", err)
+	}
+	if client.ContainerCreateCalled {
+		t.Error("Container must not be created when its files cannot be placed")
+	}
+}
+
+func TestDirSecretStore(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "api-key"), []byte("abc"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	store := NewDirSecretStore(dir)
+
+	value, err := store.GetSecret(context.Background(), "api-key")
+	if err != nil || string(value) != "abc" {
+		t.Errorf("Expected secret abc, got %q (%v)", value, err)
+	}
+	if _, err := store.GetSecret(context.Background(), "missing"); !errors.Is(err, ErrSecretNotFound) {
+		t.Errorf("Expected ErrSecretNotFound, got %v", err)
+	}
+	if _, err := store.GetSecret(context.Background(), "../etc/passwd"); err == nil {
+		t.Error("Expected error for secret name with path separator")
+	}
+
+	if err := store.PutSecret(context.Background(), "api-key", []byte("rotated")); err != nil {
+		t.Fatalf("PutSecret failed: %v", err)
+	}
+	if value, _ := store.GetSecret(context.Background(), "api-key"); string(value) != "rotated" {
+		t.Errorf("Expected rotated secret, got %q", value)
+	}
+	if info, err := os.Stat(filepath.Join(dir, "api-key")); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("Expected secret file mode 0600, got %v (%v)", info.Mode().Perm(), err)
+	}
+	if err := store.PutSecret(context.Background(), "../escape", []byte("x")); err == nil {
+		t.Error("Expected error for secret name with path separator")
+	}
+}
+
+func TestFindSecretReferences(t *testing.T) {
+	stk := &models.Stack{
+		ID: "shop",
+		CurrentDeployment: &models.StackSnapshot{
+			Definition: models.StackDefinition{
+				Graph: []models.GraphNode{
+					{
+						ID:   "shop",
+						Type: "datacenter:Stack",
+						Name: "shop",
+						HasPart: []models.ContainerSpec{
+							{
+								Name:  "api",
+								Image: "shop-api:1",
+								Files: []models.FileMount{
+									{Target: "/run/secrets/db", Secret: "db-password", RestartOnChange: true},
+									{Target: "/etc/api.conf", Content: "x"},
+								},
+							},
+							{
+								Name:  "worker",
+								Image: "shop-worker:1",
+								Files: []models.FileMount{
+									{Target: "/run/secrets/db", Secret: "db-password"},
+								},
+							},
+							{
+								Name:  "web",
+								Image: "nginx",
+							},
+						},
+					},
+				},
+			},
+			Placements: map[string]*models.ContainerPlacement{
+				"shop-api": {ContainerID: "c-api", HostID: "host1"},
+			},
+		},
+	}
+
+	refs := FindSecretReferences(stk, "db-password")
+	if len(refs) != 2 {
+		t.Fatalf("Expected 2 references, got %+v", refs)
+	}
+	if refs[0].ContainerName != "shop-api" || refs[0].ContainerID != "c-api" || refs[0].HostID != "host1" || !refs[0].RestartOnChange {
+		t.Errorf("Unexpected api reference: %+v", refs[0])
+	}
+	if refs[1].ContainerName != "shop-worker" || refs[1].RestartOnChange || refs[1].ContainerID != "" {
+		t.Errorf("Unexpected worker reference: %+v", refs[1])
+	}
+
+	if refs := FindSecretReferences(stk, "other"); len(refs) != 0 {
+		t.Errorf("Expected no references to an unused secret, got %+v", refs)
+	}
+}
+
+// waveDockerClient creates containers slowly, recording how many are
+// created at once; containers named "*-bad" fail
+type waveDockerClient struct {
+	*common.MockDockerClient
+	mu       sync.Mutex
+	inFlight int
+	maxCount int
+	created  map[string]bool
+	removed  map[string]bool
+}
+
+func (c *waveDockerClient) ContainerCreate(ctx context.Context, config *container.Config, hostConfig *container.HostConfig, networkingConfig *network.NetworkingConfig, platform *ocispec.Platform, containerName string) (container.CreateResponse, error) {
+	c.mu.Lock()
+	c.inFlight++
+	c.maxCount = max(c.maxCount, c.inFlight)
+	c.mu.Unlock()
+
+	time.Sleep(20 * time.Millisecond)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.inFlight--
+	if strings.HasSuffix(containerName, "-bad") {
+		return container.CreateResponse{}, errors.New("no space left on device")
+	}
+	c.created["mock-"+containerName] = true
+	return container.CreateResponse{ID: "mock-" + containerName}, nil
+}
+
+func (c *waveDockerClient) ContainerStart(ctx context.Context, containerID string, options container.StartOptions) error {
+	return nil
+}
+
+func (c *waveDockerClient) ContainerInspect(ctx context.Context, containerID string) (container.InspectResponse, error) {
+	resp := container.InspectResponse{}
+	resp.ContainerJSONBase = &container.ContainerJSONBase{ID: containerID, State: &container.State{Running: true, Status: "running"}}
+	return resp, nil
+}
+
+func (c *waveDockerClient) ContainerRemove(ctx context.Context, containerID string, options container.RemoveOptions) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.removed[containerID] = true
+	return nil
+}
+
+// waveClientFactory always returns the same wave client
+type waveClientFactory struct {
+	client *waveDockerClient
+}
+
+func (f *waveClientFactory) GetClient(ctx context.Context, hostID string) (common.DockerClient, error) {
+	return f.client, nil
+}
+
+// wavePlan is a plan deploying the named containers in a single wave
+func wavePlan(names ...string) *models.DeploymentPlan {
+	plan := &models.DeploymentPlan{
+		StackNode:       &models.GraphNode{ID: "stack1", Name: "wave"},
+		HostMap:         make(map[string]string),
+		DependencyGraph: [][]string{names},
+	}
+	for _, name := range names {
+		plan.ContainerSpecs = append(plan.ContainerSpecs, models.ContainerSpec{ID: name, Name: name, Image: "nginx:latest"})
+		plan.HostMap[name] = "host1"
+	}
+	return plan
+}
+
+func TestDeployer_DeployWaveConcurrently(t *testing.T) {
+	resolver := &MockHostResolver{
+		hosts: map[string]*models.HostInfo{
+			"host1": {Host: &models.Host{ID: "host1", Name: "test-host", IPAddress: "192.168.1.10"}},
+		},
+	}
+	client := &waveDockerClient{MockDockerClient: common.NewMockDockerClient(), created: map[string]bool{}, removed: map[string]bool{}}
+	deployer := NewDeployer(&MockDatabase{}, resolver, &waveClientFactory{client: client})
+
+	state, err := deployer.Deploy(context.Background(), wavePlan("a", "b", "c", "d", "e"), DeployOptions{
+		Timeout:     time.Minute,
+		StackName:   "wave",
+		Concurrency: 2,
+	})
+	if err != nil {
+		t.Fatalf("Deploy failed: %v", err)
+	}
+	if len(state.Placements) != 5 || state.Progress != 100 {
+		t.Errorf("Expected 5 placements and full progress, got %d and %d%%", len(state.Placements), state.Progress)
+	}
+	if client.maxCount != 2 {
+		t.Errorf("Expected 2 containers created at once, got %d", client.maxCount)
+	}
+}
+
+func TestDeployer_FailedWaveRemovesStartedContainers(t *testing.T) {
+	resolver := &MockHostResolver{
+		hosts: map[string]*models.HostInfo{
+			"host1": {Host: &models.Host{ID: "host1", Name: "test-host", IPAddress: "192.168.1.10"}},
+		},
+	}
+	client := &waveDockerClient{MockDockerClient: common.NewMockDockerClient(), created: map[string]bool{}, removed: map[string]bool{}}
+	deployer := NewDeployer(&MockDatabase{}, resolver, &waveClientFactory{client: client})
+
+	state, err := deployer.Deploy(context.Background(), wavePlan("a", "b", "bad"), DeployOptions{
+		Timeout:     time.Minute,
+		StackName:   "wave",
+		Concurrency: 3,
+	})
+	if err == nil || !strings.Contains(err.Error(), "failed to deploy container bad") {
+		t.Fatalf("Expected the failure of container bad, got %v", err)
+	}
+	if state.Status != "failed" {
+		t.Errorf("Expected status failed, got %s", state.Status)
+	}
+	if len(state.Placements) != 0 {
+		t.Errorf("Expected the wave's containers to be removed, got placements %v", state.Placements)
+	}
+	for id := range client.created {
+		if !client.removed[id] {
+			t.Errorf("Expected started container %s to be removed", id)
+		}
+	}
+}
+
+// blockingDockerClient blocks starting the containers named slow until the
+// deployment is canceled, and records the containers removed
+type blockingDockerClient struct {
+	*common.MockDockerClient
+	mu      sync.Mutex
+	removed []string
+}
+
+func (c *blockingDockerClient) ContainerStart(ctx context.Context, containerID string, options container.StartOptions) error {
+	if strings.HasSuffix(containerID, "-slow") {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	return nil
+}
+
+func (c *blockingDockerClient) ContainerRemove(ctx context.Context, containerID string, options container.RemoveOptions) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.removed = append(c.removed, containerID)
+	return nil
+}
+
+func TestDeployer_TimeoutRemovesPartialWork(t *testing.T) {
+	for _, rollback := range []bool{false, true} {
+		resolver := &MockHostResolver{
+			hosts: map[string]*models.HostInfo{
+				"host1": {Host: &models.Host{ID: "host1", Name: "test-host", IPAddress: "192.168.1.10"}},
+			},
+		}
+		client := &blockingDockerClient{MockDockerClient: common.NewMockDockerClient()}
+		deployer := NewDeployer(&MockDatabase{}, resolver, &blockingClientFactory{client: client})
+
+		// web deploys in the first wave, slow blocks the second one until
+		// the deployment times out
+		plan := &models.DeploymentPlan{
+			StackNode: &models.GraphNode{ID: "stack1", Name: "test-stack"},
+			ContainerSpecs: []models.ContainerSpec{
+				{ID: "web", Name: "web", Image: "nginx:latest"},
+				{ID: "slow", Name: "slow", Image: "nginx:latest", DependsOn: []string{"web"}},
+			},
+			HostMap:         map[string]string{"web": "host1", "slow": "host1"},
+			DependencyGraph: [][]string{{"web"}, {"slow"}},
+		}
+
+		state, err := deployer.Deploy(context.Background(), plan, DeployOptions{
+			Timeout:         3 * time.Second,
+			RollbackOnError: rollback,
+			StackName:       "test-stack",
+		})
+		var timeoutErr *DeployTimeoutError
+		if !errors.Is(err, context.DeadlineExceeded) || !errors.As(err, &timeoutErr) || timeoutErr.Timeout != 3*time.Second {
+			t.Fatalf("rollback=%v: expected a deployment timeout, got %v", rollback, err)
+		}
+		if state.Status != "failed" {
+			t.Errorf("rollback=%v: expected status failed, got %s", rollback, state.Status)
+		}
+		if !strings.Contains(state.ErrorMessage, "deployment timed out after 3s") {
+			t.Errorf("rollback=%v: expected the timeout in the error message, got %q", rollback, state.ErrorMessage)
+		}
+
+		removed := strings.Join(client.removed, ",")
+		if !strings.Contains(removed, "mock-test-stack-slow") {
+			t.Errorf("rollback=%v: expected the interrupted container to be removed, got %v", rollback, client.removed)
+		}
+		if _, ok := state.Placements["test-stack-web"]; !ok {
+			t.Errorf("rollback=%v: expected the placement of web to be recorded, got %v", rollback, state.Placements)
+		}
+		if got := strings.Contains(removed, "mock-test-stack-web"); got != rollback {
+			t.Errorf("rollback=%v: expected web removed %v, got removals %v", rollback, rollback, client.removed)
+		}
+	}
+}
+
+// blockingClientFactory always returns the same blocking client
+type blockingClientFactory struct {
+	client *blockingDockerClient
+}
+
+func (f *blockingClientFactory) GetClient(ctx context.Context, hostID string) (common.DockerClient, error) {
+	return f.client, nil
+}
@@ -0,0 +1,393 @@
+package stack
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/api/types/volume"
+	"github.com/google/uuid"
+
+	"eve.evalgo.org/common"
+
+	"evalgo.org/graphium/models"
+)
+
+// DefaultFileMode is the mode of a file mount when none is specified.
+const DefaultFileMode os.FileMode = 0444
+
+// ErrSecretNotFound is returned by a SecretStore when the secret does not exist.
+var ErrSecretNotFound = errors.New("secret not found")
+
+// SecretStore provides the content of secrets referenced by file mounts.
+type SecretStore interface {
+	// GetSecret returns the value of the named secret
+	GetSecret(ctx context.Context, name string) ([]byte, error)
+}
+
+// SecretWriter is implemented by secret stores that can update secrets.
+type SecretWriter interface {
+	// PutSecret creates or replaces the value of the named secret
+	PutSecret(ctx context.Context, name string, value []byte) error
+}
+
+// DirSecretStore is a SecretStore that reads each secret from a file with
+// the secret's name in a directory.
+type DirSecretStore struct {
+	Dir string
+}
+
+// NewDirSecretStore creates a secret store backed by the given directory.
+func NewDirSecretStore(dir string) *DirSecretStore {
+	return &DirSecretStore{Dir: dir}
+}
+
+// GetSecret reads the named secret from the store directory.
+func (s *DirSecretStore) GetSecret(ctx context.Context, name string) ([]byte, error) {
+	if !validSecretName(name) {
+		return nil, fmt.Errorf("invalid secret name %q", name)
+	}
+
+	data, err := os.ReadFile(filepath.Join(s.Dir, name))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("%w: %s", ErrSecretNotFound, name)
+		}
+		return nil, fmt.Errorf("failed to read secret %s: %w", name, err)
+	}
+	return data, nil
+}
+
+// PutSecret writes the named secret to the store directory. The file is
+// replaced atomically and is only readable by the owner.
+func (s *DirSecretStore) PutSecret(ctx context.Context, name string, value []byte) error {
+	if !validSecretName(name) {
+		return fmt.Errorf("invalid secret name %q", name)
+	}
+
+	tmp, err := os.CreateTemp(s.Dir, "."+name+".*")
+	if err != nil {
+		return fmt.Errorf("failed to write secret %s: %w", name, err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := tmp.Chmod(0600); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write secret %s: %w", name, err)
+	}
+	if _, err := tmp.Write(value); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write secret %s: %w", name, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write secret %s: %w", name, err)
+	}
+	if err := os.Rename(tmp.Name(), filepath.Join(s.Dir, name)); err != nil {
+		return fmt.Errorf("failed to write secret %s: %w", name, err)
+	}
+	return nil
+}
+
+// validSecretName reports whether name can be used as a file name in the store
+// without escaping its directory.
+func validSecretName(name string) bool {
+	if name == "" || name == "." || name == ".." {
+		return false
+	}
+	return !strings.ContainsAny(name, `/\`)
+}
+
+// ParseFileMode parses an octal file mode such as "0440".
+// An empty string yields DefaultFileMode.
+func ParseFileMode(mode string) (os.FileMode, error) {
+	if mode == "" {
+		return DefaultFileMode, nil
+	}
+	value, err := strconv.ParseUint(mode, 8, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid file mode %q: must be octal (e.g. 0440)", mode)
+	}
+	if value > 0777 {
+		return 0, fmt.Errorf("invalid file mode %q: only permission bits (up to 0777) are allowed", mode)
+	}
+	return os.FileMode(value), nil
+}
+
+// ValidateFileMount checks the target path, mode and content source of a file mount.
+func ValidateFileMount(file models.FileMount) error {
+	if file.Target == "" {
+		return fmt.Errorf("target path is required")
+	}
+	if !path.IsAbs(file.Target) {
+		return fmt.Errorf("target path %s must be absolute", file.Target)
+	}
+	if path.Clean(file.Target) != file.Target || strings.HasSuffix(file.Target, "/") {
+		return fmt.Errorf("target path %s must be a clean file path", file.Target)
+	}
+	if file.Content != "" && file.Secret != "" {
+		return fmt.Errorf("file %s: content and secret are mutually exclusive", file.Target)
+	}
+	if file.Content == "" && file.Secret == "" {
+		return fmt.Errorf("file %s: either content or secret is required", file.Target)
+	}
+	if file.Secret != "" && !validSecretName(file.Secret) {
+		return fmt.Errorf("file %s: invalid secret name %q", file.Target, file.Secret)
+	}
+	if file.UID < 0 || file.GID < 0 {
+		return fmt.Errorf("file %s: uid and gid must not be negative", file.Target)
+	}
+	if _, err := ParseFileMode(file.Mode); err != nil {
+		return fmt.Errorf("file %s: %w", file.Target, err)
+	}
+	return nil
+}
+
+// fileCopier is implemented by Docker clients that can copy an archive into a
+// container. The Docker SDK client implements it.
+type fileCopier interface {
+	CopyToContainer(ctx context.Context, containerID, dstPath string, content io.Reader, options container.CopyToContainerOptions) error
+}
+
+// filesVolumePrefix starts the names of the volumes holding the file mounts
+// of a container.
+const filesVolumePrefix = "graphium-files-"
+
+// filesPath is where the helper container filling a files volume mounts it.
+const filesPath = "/files"
+
+// prepareFiles writes the spec's file mounts into a new volume on client's
+// host and returns the read-only mounts placing each file at its target, so
+// the files stay out of the container's writable layer. Secret content is
+// resolved here and only ever held in memory.
+func (d *Deployer) prepareFiles(ctx context.Context, client common.DockerClient, spec *models.ContainerSpec) ([]mount.Mount, error) {
+	if len(spec.Files) == 0 {
+		return nil, nil
+	}
+
+	archive, err := d.buildFilesArchive(ctx, spec.Files)
+	if err != nil {
+		return nil, err
+	}
+
+	vol, err := client.VolumeCreate(ctx, volume.CreateOptions{
+		Name:   filesVolumePrefix + uuid.NewString(),
+		Labels: managedLabels(nil),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create files volume: %w", err)
+	}
+	if err := writeFiles(ctx, client, spec.Image, vol.Name, archive); err != nil {
+		_ = client.VolumeRemove(context.WithoutCancel(ctx), vol.Name, true)
+		return nil, err
+	}
+
+	mounts := make([]mount.Mount, 0, len(spec.Files))
+	for _, file := range spec.Files {
+		mounts = append(mounts, mount.Mount{
+			Type:     mount.TypeVolume,
+			Source:   vol.Name,
+			Target:   file.Target,
+			ReadOnly: true,
+			VolumeOptions: &mount.VolumeOptions{
+				NoCopy:  true,
+				Subpath: strings.TrimPrefix(file.Target, "/"),
+			},
+		})
+	}
+	return mounts, nil
+}
+
+// writeFiles extracts archive into the named volume through a helper
+// container of image, which is created but never started.
+func writeFiles(ctx context.Context, client common.DockerClient, image, volumeName string, archive io.Reader) error {
+	copier, ok := client.(fileCopier)
+	if !ok {
+		return fmt.Errorf("docker client does not support copying files into containers")
+	}
+
+	resp, err := client.ContainerCreate(ctx,
+		&container.Config{Image: image, Labels: managedLabels(nil)},
+		&container.HostConfig{Mounts: []mount.Mount{{Type: mount.TypeVolume, Source: volumeName, Target: filesPath}}},
+		nil, nil, "")
+	if err != nil {
+		return fmt.Errorf("failed to create files helper container: %w", err)
+	}
+	defer func() {
+		_ = client.ContainerRemove(context.WithoutCancel(ctx), resp.ID, container.RemoveOptions{Force: true})
+	}()
+
+	if err := copier.CopyToContainer(ctx, resp.ID, filesPath, archive, container.CopyToContainerOptions{}); err != nil {
+		return fmt.Errorf("failed to write files: %w", err)
+	}
+	return nil
+}
+
+// removeContainer force-removes a container and the volume holding its
+// file mounts.
+func removeContainer(ctx context.Context, client common.DockerClient, containerID string, options container.RemoveOptions) error {
+	info, inspectErr := client.ContainerInspect(ctx, containerID)
+	if err := client.ContainerRemove(ctx, containerID, options); err != nil {
+		return err
+	}
+	if inspectErr != nil {
+		return nil
+	}
+	for _, volumeName := range filesVolumes(info) {
+		_ = client.VolumeRemove(ctx, volumeName, true)
+	}
+	return nil
+}
+
+// filesVolumes returns the names of the files volumes a container mounts.
+func filesVolumes(info container.InspectResponse) []string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, m := range info.Mounts {
+		if m.Type == mount.TypeVolume && strings.HasPrefix(m.Name, filesVolumePrefix) && !seen[m.Name] {
+			seen[m.Name] = true
+			names = append(names, m.Name)
+		}
+	}
+	return names
+}
+
+// buildFilesArchive builds a tar archive containing the file mounts, rooted at "/".
+func (d *Deployer) buildFilesArchive(ctx context.Context, files []models.FileMount) (io.Reader, error) {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	now := time.Now()
+
+	for _, file := range files {
+		if err := ValidateFileMount(file); err != nil {
+			return nil, err
+		}
+		mode, _ := ParseFileMode(file.Mode)
+
+		content := []byte(file.Content)
+		if file.Secret != "" {
+			if d.Secrets == nil {
+				return nil, fmt.Errorf("file %s references secret %s but no secret store is configured", file.Target, file.Secret)
+			}
+			secret, err := d.Secrets.GetSecret(ctx, file.Secret)
+			if err != nil {
+				return nil, fmt.Errorf("file %s: %w", file.Target, err)
+			}
+			content = secret
+		}
+
+		if err := writeTarFile(tw, file, mode, content, now); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to build files archive: %w", err)
+	}
+	return &buf, nil
+}
+
+// writeTarFile adds a single file mount to the archive.
+func writeTarFile(tw *tar.Writer, file models.FileMount, mode os.FileMode, content []byte, modTime time.Time) error {
+	header := &tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     strings.TrimPrefix(file.Target, "/"),
+		Mode:     int64(mode),
+		Uid:      file.UID,
+		Gid:      file.GID,
+		Size:     int64(len(content)),
+		ModTime:  modTime,
+	}
+	if err := tw.WriteHeader(header); err != nil {
+		return fmt.Errorf("failed to add file %s: %w", file.Target, err)
+	}
+	if _, err := tw.Write(content); err != nil {
+		return fmt.Errorf("failed to add file %s: %w", file.Target, err)
+	}
+	return nil
+}
+
+// RefreshFiles writes files into the files volume of an existing container
+// on hostID, replacing the previous content. The container must be
+// restarted to see the new content.
+func (d *Deployer) RefreshFiles(ctx context.Context, hostID, containerID string, files []models.FileMount) error {
+	client, err := d.DockerClientFactory.GetClient(ctx, hostID)
+	if err != nil {
+		return fmt.Errorf("failed to get Docker client: %w", err)
+	}
+	info, err := client.ContainerInspect(ctx, containerID)
+	if err != nil {
+		return fmt.Errorf("failed to inspect container: %w", err)
+	}
+	if info.Config == nil {
+		return fmt.Errorf("container %s has no configuration", containerID)
+	}
+
+	volumes := filesVolumes(info)
+	if len(volumes) != 1 {
+		return fmt.Errorf("container %s has no files volume; redeploy it to mount its files", containerID)
+	}
+	archive, err := d.buildFilesArchive(ctx, files)
+	if err != nil {
+		return err
+	}
+	return writeFiles(ctx, client, info.Config.Image, volumes[0], archive)
+}
+
+// SecretReference is a deployed container that mounts a secret as a file.
+type SecretReference struct {
+	StackID         string `json:"stackId"`
+	ContainerName   string `json:"containerName"`
+	ContainerID     string `json:"containerId,omitempty"`
+	HostID          string `json:"hostId,omitempty"`
+	Target          string `json:"target"`
+	RestartOnChange bool   `json:"restartOnChange"`
+
+	// File is the full file mount, used to refresh the file content
+	File models.FileMount `json:"-"`
+}
+
+// FindSecretReferences returns the containers of a stack's current deployment
+// that mount the named secret, one entry per file mount.
+func FindSecretReferences(stk *models.Stack, secret string) []SecretReference {
+	if stk == nil || stk.CurrentDeployment == nil {
+		return nil
+	}
+
+	stackNode, err := NewStackParser(nil).extractStackNode(&stk.CurrentDeployment.Definition)
+	if err != nil {
+		return nil
+	}
+
+	var refs []SecretReference
+	for _, spec := range stackNode.HasPart {
+		for _, file := range spec.Files {
+			if file.Secret != secret {
+				continue
+			}
+			ref := SecretReference{
+				StackID:         stk.ID,
+				ContainerName:   fmt.Sprintf("%s-%s", stackNode.Name, spec.Name),
+				Target:          file.Target,
+				RestartOnChange: file.RestartOnChange,
+				File:            file,
+			}
+			if placement := stk.CurrentDeployment.Placements[ref.ContainerName]; placement != nil {
+				ref.ContainerID = placement.ContainerID
+				ref.HostID = placement.HostID
+			}
+			refs = append(refs, ref)
+		}
+	}
+	return refs
+}
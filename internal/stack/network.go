@@ -0,0 +1,203 @@
+package stack
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/docker/docker/api/types/network"
+
+	"eve.evalgo.org/common"
+
+	"evalgo.org/graphium/models"
+)
+
+// overlayDriver is the driver of networks spanning the hosts of a Docker
+// Swarm.
+const overlayDriver = "overlay"
+
+// isOverlay reports whether the stack network spans hosts by itself. Other
+// networks are local to a host and are created on every host a container
+// of the stack runs on.
+func isOverlay(spec *models.NetworkSpec) bool {
+	return spec != nil && spec.Driver == overlayDriver
+}
+
+// createNetwork creates the stack network on a host and returns its
+// details. Overlay networks are created attachable, so the stack's
+// standalone containers can join them from any node of the Swarm.
+func createNetwork(ctx context.Context, client common.DockerClient, spec *models.NetworkSpec) (*models.DeployedNetworkInfo, error) {
+	createOpts := network.CreateOptions{
+		Driver:     spec.Driver,
+		Labels:     managedLabels(spec.Labels),
+		Attachable: isOverlay(spec),
+	}
+
+	// Set IPAM config if subnet/gateway specified
+	if spec.Subnet != "" || spec.Gateway != "" {
+		createOpts.IPAM = &network.IPAM{
+			Config: []network.IPAMConfig{
+				{
+					Subnet:  spec.Subnet,
+					Gateway: spec.Gateway,
+					IPRange: spec.IPRange,
+				},
+			},
+		}
+	}
+
+	// Set driver options
+	if len(spec.Options) > 0 {
+		createOpts.Options = spec.Options
+	}
+
+	resp, err := client.NetworkCreate(ctx, spec.Name, createOpts)
+	if err != nil {
+		if isOverlay(spec) {
+			return nil, fmt.Errorf("failed to create overlay network (the hosts must be in a Docker Swarm and the primary host a manager): %w", err)
+		}
+		return nil, fmt.Errorf("failed to create network: %w", err)
+	}
+
+	info, err := inspectNetwork(ctx, client, resp.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to inspect created network: %w", err)
+	}
+	return info, nil
+}
+
+// inspectNetwork returns the details of a network on a host.
+func inspectNetwork(ctx context.Context, client common.DockerClient, id string) (*models.DeployedNetworkInfo, error) {
+	networkInfo, err := client.NetworkInspect(ctx, id, network.InspectOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	// Extract IPAM config
+	var subnet, gateway string
+	if len(networkInfo.IPAM.Config) > 0 {
+		subnet = networkInfo.IPAM.Config[0].Subnet
+		gateway = networkInfo.IPAM.Config[0].Gateway
+	}
+
+	return &models.DeployedNetworkInfo{
+		NetworkID:   networkInfo.ID,
+		NetworkName: networkInfo.Name,
+		Driver:      networkInfo.Driver,
+		Subnet:      subnet,
+		Gateway:     gateway,
+		Scope:       networkInfo.Scope,
+	}, nil
+}
+
+// ensureHostNetwork creates the stack network on hostID unless it exists
+// there already. Overlay and external networks are left alone: overlay
+// networks reach every Swarm node, and external networks must exist on
+// every host beforehand.
+func (d *Deployer) ensureHostNetwork(ctx context.Context, plan *models.DeploymentPlan, hostID string, state *models.DeploymentState) error {
+	if plan.Network == nil || plan.Network.External || isOverlay(plan.Network) {
+		return nil
+	}
+
+	// Held while creating, so containers of a wave landing on the same new
+	// host create the network once
+	d.networkMu.Lock()
+	defer d.networkMu.Unlock()
+
+	// Without network info the deployment has no network of its own to
+	// create on more hosts
+	d.mu.Lock()
+	info := state.NetworkInfo
+	skip := info == nil
+	if !skip {
+		_, skip = info.HostNetworks[hostID]
+	}
+	d.mu.Unlock()
+	if skip {
+		return nil
+	}
+
+	client, err := d.DockerClientFactory.GetClient(ctx, hostID)
+	if err != nil {
+		return fmt.Errorf("failed to get Docker client for host %s: %w", hostID, err)
+	}
+	created, err := createNetwork(ctx, client, plan.Network)
+	if err != nil {
+		return fmt.Errorf("host %s: %w", hostID, err)
+	}
+
+	d.mu.Lock()
+	if info.HostNetworks == nil {
+		info.HostNetworks = make(map[string]string)
+	}
+	info.HostNetworks[hostID] = created.NetworkID
+	d.mu.Unlock()
+	d.addEvent(state, "info", "network-creation", "",
+		fmt.Sprintf("Network %s created on host %s with ID %s", plan.Network.Name, hostID, created.NetworkID))
+	return nil
+}
+
+// dependencyEnv returns the environment giving a container the addresses
+// of its dependencies on other hosts, whose names don't resolve across
+// hosts without an overlay network: <DEP>_HOST is the dependency's host IP
+// and <DEP>_PORT_<containerPort> each published host port, with <DEP>_PORT
+// the lowest one. Dependencies on the same host, or on an overlay network,
+// are reached by name. Variables the spec sets itself are kept. Cross-host
+// dependencies that publish no ports are returned as unreachable.
+func dependencyEnv(plan *models.DeploymentPlan, spec *models.ContainerSpec, hostID string, placements map[string]*models.ContainerPlacement, opts DeployOptions) (env []string, unreachable []string) {
+	if isOverlay(plan.Network) {
+		return nil, nil
+	}
+
+	set := make(map[string]bool, len(spec.Environment))
+	for _, variable := range spec.Environment {
+		set[variable.Name] = true
+	}
+	add := func(name, value string) {
+		if !set[name] {
+			env = append(env, name+"="+value)
+		}
+	}
+
+	for _, dep := range spec.DependsOn {
+		placement := placements[opts.containerName(dep)]
+		if placement == nil || placement.HostID == hostID {
+			continue
+		}
+		if len(placement.Ports) == 0 {
+			unreachable = append(unreachable, dep)
+			continue
+		}
+
+		prefix := envName(dep)
+		add(prefix+"_HOST", placement.IPAddress)
+
+		containerPorts := make([]int, 0, len(placement.Ports))
+		for containerPort := range placement.Ports {
+			containerPorts = append(containerPorts, containerPort)
+		}
+		sort.Ints(containerPorts)
+		add(prefix+"_PORT", strconv.Itoa(placement.Ports[containerPorts[0]]))
+		for _, containerPort := range containerPorts {
+			add(prefix+"_PORT_"+strconv.Itoa(containerPort), strconv.Itoa(placement.Ports[containerPort]))
+		}
+	}
+	return env, unreachable
+}
+
+// envName returns a container name as an environment variable prefix,
+// e.g. DB_PRIMARY for db-primary.
+func envName(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		default:
+			return '_'
+		}
+	}, name)
+}
@@ -0,0 +1,193 @@
+package stack
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/go-connections/nat"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+
+	"eve.evalgo.org/common"
+
+	"evalgo.org/graphium/models"
+)
+
+// networkDockerClient records the networks and containers created on a host
+// and publishes the configured ports of its containers
+type networkDockerClient struct {
+	*common.MockDockerClient
+	mu       sync.Mutex
+	networks []network.CreateOptions
+	removed  []string
+	env      map[string][]string
+	ports    map[string]nat.PortMap
+}
+
+func (c *networkDockerClient) NetworkCreate(ctx context.Context, name string, options network.CreateOptions) (network.CreateResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.networks = append(c.networks, options)
+	return network.CreateResponse{ID: "net-" + name}, nil
+}
+
+func (c *networkDockerClient) NetworkRemove(ctx context.Context, networkID string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.removed = append(c.removed, networkID)
+	return nil
+}
+
+func (c *networkDockerClient) ContainerCreate(ctx context.Context, config *container.Config, hostConfig *container.HostConfig, networkingConfig *network.NetworkingConfig, platform *ocispec.Platform, containerName string) (container.CreateResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.env[containerName] = config.Env
+	return container.CreateResponse{ID: containerName}, nil
+}
+
+func (c *networkDockerClient) ContainerInspect(ctx context.Context, containerID string) (container.InspectResponse, error) {
+	resp := container.InspectResponse{}
+	resp.ContainerJSONBase = &container.ContainerJSONBase{ID: containerID, State: &container.State{Running: true, Status: "running"}}
+	resp.NetworkSettings = &container.NetworkSettings{NetworkSettingsBase: container.NetworkSettingsBase{Ports: c.ports[containerID]}}
+	return resp, nil
+}
+
+// networkClientFactory returns the client of each host
+type networkClientFactory struct {
+	clients map[string]*networkDockerClient
+}
+
+func (f *networkClientFactory) GetClient(ctx context.Context, hostID string) (common.DockerClient, error) {
+	return f.clients[hostID], nil
+}
+
+// twoHostDeployer deploys to host1 and host2, where host1 publishes port
+// 5432 of stack-db as 15432
+func twoHostDeployer() (*Deployer, map[string]*networkDockerClient) {
+	resolver := &MockHostResolver{
+		hosts: map[string]*models.HostInfo{
+			"host1": {Host: &models.Host{ID: "host1", Name: "host1", IPAddress: "10.0.0.1"}},
+			"host2": {Host: &models.Host{ID: "host2", Name: "host2", IPAddress: "10.0.0.2"}},
+		},
+	}
+	clients := map[string]*networkDockerClient{
+		"host1": {
+			MockDockerClient: common.NewMockDockerClient(),
+			env:              map[string][]string{},
+			ports:            map[string]nat.PortMap{"stack-db": {"5432/tcp": {{HostIP: "0.0.0.0", HostPort: "15432"}}}},
+		},
+		"host2": {MockDockerClient: common.NewMockDockerClient(), env: map[string][]string{}},
+	}
+	deployer := NewDeployer(&MockDatabase{}, resolver, &networkClientFactory{clients: clients})
+	return deployer, clients
+}
+
+// twoHostPlan places db on host1 and web, which depends on it, on host2
+func twoHostPlan(driver string) *models.DeploymentPlan {
+	return &models.DeploymentPlan{
+		StackNode: &models.GraphNode{ID: "stack1", Name: "stack"},
+		ContainerSpecs: []models.ContainerSpec{
+			{ID: "db", Name: "db", Image: "postgres:16"},
+			{
+				ID: "web", Name: "web", Image: "nginx:latest", DependsOn: []string{"db"},
+				Environment: []models.EnvironmentVariable{{Name: "DB_PORT", Value: "5432"}},
+			},
+		},
+		HostMap:         map[string]string{"db": "host1", "web": "host2"},
+		Network:         &models.NetworkSpec{Name: "stack-net", Driver: driver},
+		DependencyGraph: [][]string{{"db"}, {"web"}},
+	}
+}
+
+func TestDeployer_MultiHostBridgeNetwork(t *testing.T) {
+	deployer, clients := twoHostDeployer()
+
+	state, err := deployer.Deploy(context.Background(), twoHostPlan("bridge"), DeployOptions{Timeout: time.Minute, StackName: "stack"})
+	if err != nil {
+		t.Fatalf("Deploy failed: %v", err)
+	}
+
+	for hostID, client := range clients {
+		if len(client.networks) != 1 {
+			t.Errorf("Expected the network to be created once on %s, got %d", hostID, len(client.networks))
+		}
+		if state.NetworkInfo.HostNetworks[hostID] != "net-stack-net" {
+			t.Errorf("Expected the network of %s to be recorded, got %v", hostID, state.NetworkInfo.HostNetworks)
+		}
+	}
+
+	env := clients["host2"].env["stack-web"]
+	for _, want := range []string{"DB_HOST=10.0.0.1", "DB_PORT_5432=15432"} {
+		if !slices.Contains(env, want) {
+			t.Errorf("Expected %s in the environment of web, got %v", want, env)
+		}
+	}
+	if slices.Contains(env, "DB_PORT=15432") {
+		t.Errorf("Expected DB_PORT set by the spec to be kept, got %v", env)
+	}
+	if len(clients["host1"].env["stack-db"]) != 0 {
+		t.Errorf("Expected no dependency environment for db, got %v", clients["host1"].env["stack-db"])
+	}
+
+	if err := deployer.Remove(context.Background(), state, false); err != nil {
+		t.Fatalf("Remove failed: %v", err)
+	}
+	for hostID, client := range clients {
+		if len(client.removed) != 1 {
+			t.Errorf("Expected the network to be removed on %s, got %v", hostID, client.removed)
+		}
+	}
+}
+
+func TestDeployer_MultiHostOverlayNetwork(t *testing.T) {
+	deployer, clients := twoHostDeployer()
+
+	state, err := deployer.Deploy(context.Background(), twoHostPlan("overlay"), DeployOptions{Timeout: time.Minute, StackName: "stack"})
+	if err != nil {
+		t.Fatalf("Deploy failed: %v", err)
+	}
+
+	if len(clients["host1"].networks) != 1 || !clients["host1"].networks[0].Attachable {
+		t.Errorf("Expected an attachable overlay network on the primary host, got %+v", clients["host1"].networks)
+	}
+	if len(clients["host2"].networks) != 0 {
+		t.Errorf("Expected no network created on host2, got %+v", clients["host2"].networks)
+	}
+	if len(state.NetworkInfo.HostNetworks) != 1 {
+		t.Errorf("Expected only the primary host's network, got %v", state.NetworkInfo.HostNetworks)
+	}
+	for _, variable := range clients["host2"].env["stack-web"] {
+		if strings.HasPrefix(variable, "DB_HOST=") {
+			t.Errorf("Expected db to be reached by name on the overlay network, got %s", variable)
+		}
+	}
+}
+
+func TestDependencyEnv_UnreachableDependency(t *testing.T) {
+	plan := twoHostPlan("bridge")
+	placements := map[string]*models.ContainerPlacement{
+		"stack-db": {HostID: "host1", IPAddress: "10.0.0.1"},
+	}
+	env, unreachable := dependencyEnv(plan, &plan.ContainerSpecs[1], "host2", placements, DeployOptions{StackName: "stack"})
+	if len(env) != 0 || !slices.Equal(unreachable, []string{"db"}) {
+		t.Errorf("Expected db to be unreachable, got env %v and unreachable %v", env, unreachable)
+	}
+}
+
+func TestEnvName(t *testing.T) {
+	tests := map[string]string{
+		"db":         "DB",
+		"db-primary": "DB_PRIMARY",
+		"cache.v2":   "CACHE_V2",
+	}
+	for name, want := range tests {
+		if got := envName(name); got != want {
+			t.Errorf("envName(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
@@ -2,6 +2,8 @@ package stack
 
 import (
 	"fmt"
+	"net/url"
+	"path"
 	"strings"
 
 	"evalgo.org/graphium/models"
@@ -70,18 +72,18 @@ func (p *StackParser) Parse(def *models.StackDefinition) (*ParseResult, error) {
 		result.Warnings = append(result.Warnings, "stack contains no containers")
 	}
 
-	// Build host mapping (container @id -> host @id)
-	hostMap, err := p.buildHostMapping(stackNode, containerSpecs, result)
-	if err != nil {
-		return result, fmt.Errorf("failed to build host mapping: %w", err)
-	}
-
 	// Build topology from @graph nodes
 	topology, err := p.buildTopology(def.Graph)
 	if err != nil {
 		result.Warnings = append(result.Warnings, fmt.Sprintf("topology extraction incomplete: %v", err))
 	}
 
+	// Build host mapping (container @id -> host @id)
+	hostMap, err := p.buildHostMapping(stackNode, containerSpecs, topology, result)
+	if err != nil {
+		return result, fmt.Errorf("failed to build host mapping: %w", err)
+	}
+
 	// Build dependency graph for container startup ordering
 	depGraph, err := p.buildDependencyGraph(containerSpecs)
 	if err != nil {
@@ -152,20 +154,22 @@ func (p *StackParser) isStackType(typeVal interface{}) bool {
 	return false
 }
 
-// buildHostMapping creates a map from container @id to host @id.
-func (p *StackParser) buildHostMapping(stackNode *models.GraphNode, containers []models.ContainerSpec, result *ParseResult) (map[string]string, error) {
+// buildHostMapping creates a map from container @id to host ID.
+// A container's own locatedInHost is a hard placement; containers without one
+// use the stack's locatedInHost, or are left empty for automatic placement.
+// Referenced hosts must exist and be active.
+func (p *StackParser) buildHostMapping(stackNode *models.GraphNode, containers []models.ContainerSpec, topology *models.Topology, result *ParseResult) (map[string]string, error) {
 	hostMap := make(map[string]string)
 
 	// Check if stack has a default host
 	var defaultHostID string
 	if stackNode.LocatedInHost != nil {
-		defaultHostID = stackNode.LocatedInHost.ID
-
-		// Validate the host exists (Option B: absolute URL resolution)
-		if _, err := p.HostResolver.ResolveHost(defaultHostID); err != nil {
-			result.Warnings = append(result.Warnings,
-				fmt.Sprintf("default host %s cannot be resolved: %v", defaultHostID, err))
+		hostID, err := p.resolvePlacementHost(stackNode.LocatedInHost.ID, topology)
+		if err != nil {
+			result.Errors = append(result.Errors,
+				fmt.Sprintf("stack default host %s: %v", stackNode.LocatedInHost.ID, err))
 		}
+		defaultHostID = hostID
 	}
 
 	// Process each container
@@ -174,15 +178,14 @@ func (p *StackParser) buildHostMapping(stackNode *models.GraphNode, containers [
 
 		// Container-specific host takes precedence
 		if container.LocatedInHost != nil {
-			targetHostID = container.LocatedInHost.ID
-
-			// Validate the host exists
-			if _, err := p.HostResolver.ResolveHost(targetHostID); err != nil {
+			hostID, err := p.resolvePlacementHost(container.LocatedInHost.ID, topology)
+			if err != nil {
 				result.Errors = append(result.Errors,
-					fmt.Sprintf("container %s: host %s cannot be resolved: %v",
-						container.Name, targetHostID, err))
+					fmt.Sprintf("container %s: host %s: %v",
+						container.Name, container.LocatedInHost.ID, err))
 				continue
 			}
+			targetHostID = hostID
 		} else if defaultHostID != "" {
 			// Use stack default host
 			targetHostID = defaultHostID
@@ -200,6 +203,42 @@ func (p *StackParser) buildHostMapping(stackNode *models.GraphNode, containers [
 	return hostMap, nil
 }
 
+// resolvePlacementHost resolves a locatedInHost reference to a Graphium host
+// ID. The reference may be a host ID, the @id of a host node in the @graph
+// (resolved by the node's name), or an absolute URL ending in the host ID.
+// The host must be active.
+func (p *StackParser) resolvePlacementHost(ref string, topology *models.Topology) (string, error) {
+	candidates := []string{ref}
+	if topology != nil {
+		if node, ok := topology.Hosts[ref]; ok && node.Name != "" {
+			candidates = append(candidates, node.Name)
+		}
+	}
+	if u, err := url.Parse(ref); err == nil && u.IsAbs() {
+		if last := path.Base(strings.TrimSuffix(u.Path, "/")); last != "" && last != "." && last != "/" {
+			candidates = append(candidates, last)
+		}
+	}
+
+	var lastErr error
+	for _, candidate := range candidates {
+		info, err := p.HostResolver.ResolveHost(candidate)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if info == nil || info.Host == nil {
+			lastErr = fmt.Errorf("host %s not found", candidate)
+			continue
+		}
+		if info.Host.Status != "" && info.Host.Status != "active" {
+			return "", fmt.Errorf("host %s is %s, not active", info.Host.ID, info.Host.Status)
+		}
+		return info.Host.ID, nil
+	}
+	return "", fmt.Errorf("cannot be resolved: %w", lastErr)
+}
+
 // buildTopology extracts host, rack, and datacenter topology from @graph.
 func (p *StackParser) buildTopology(graph []models.GraphNode) (*models.Topology, error) {
 	topology := &models.Topology{
@@ -398,6 +437,23 @@ func (p *StackParser) validateContainerSpec(spec *models.ContainerSpec, result *
 		}
 	}
 
+	// Validate file mounts
+	fileTargets := make(map[string]bool)
+	for i, file := range spec.Files {
+		if err := ValidateFileMount(file); err != nil {
+			return fmt.Errorf("file mount at index %d: %w", i, err)
+		}
+		if fileTargets[file.Target] {
+			return fmt.Errorf("file mount at index %d: duplicate target path %s", i, file.Target)
+		}
+		fileTargets[file.Target] = true
+	}
+
+	// Validate resource limits and reservations
+	if err := spec.Resources.Validate(); err != nil {
+		return fmt.Errorf("container %s: %w", spec.Name, err)
+	}
+
 	// Validate health check
 	if spec.HealthCheck != nil {
 		if spec.HealthCheck.Type == "" {
@@ -415,6 +471,10 @@ func (p *StackParser) validateContainerSpec(spec *models.ContainerSpec, result *
 		}
 	}
 
+	if spec.StopGracePeriod < 0 {
+		return fmt.Errorf("container %s: stopGracePeriod must not be negative", spec.Name)
+	}
+
 	// Validate restart policy
 	if spec.RestartPolicy != "" {
 		validPolicies := map[string]bool{
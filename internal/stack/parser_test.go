@@ -2,6 +2,7 @@ package stack
 
 import (
 	"fmt"
+	"strings"
 	"testing"
 
 	"evalgo.org/graphium/models"
@@ -265,7 +266,7 @@ func TestStackParser_HostMapping(t *testing.T) {
 		Errors:   []string{},
 	}
 
-	hostMap, err := parser.buildHostMapping(stackNode, containers, result)
+	hostMap, err := parser.buildHostMapping(stackNode, containers, nil, result)
 	if err != nil {
 		t.Fatalf("buildHostMapping failed: %v", err)
 	}
@@ -281,6 +282,53 @@ func TestStackParser_HostMapping(t *testing.T) {
 	}
 }
 
+func TestStackParser_HostMappingReferences(t *testing.T) {
+	resolver := &MockHostResolver{
+		hosts: map[string]*models.HostInfo{
+			"storage1": {Host: &models.Host{ID: "storage1", Status: "active"}},
+			"compute1": {Host: &models.Host{ID: "compute1", Status: "active"}},
+			"old1":     {Host: &models.Host{ID: "old1", Status: "maintenance"}},
+		},
+	}
+	parser := NewStackParser(resolver)
+
+	topology := &models.Topology{
+		Hosts: map[string]*models.GraphNode{
+			"https://example.com/nodes/a": {ID: "https://example.com/nodes/a", Name: "storage1"},
+		},
+	}
+
+	containers := []models.ContainerSpec{
+		{ID: "db", Name: "db", LocatedInHost: &models.Reference{ID: "https://example.com/nodes/a"}},
+		{ID: "api", Name: "api", LocatedInHost: &models.Reference{ID: "https://graphium.example.com/api/v1/hosts/compute1"}},
+		{ID: "web", Name: "web"},
+		{ID: "legacy", Name: "legacy", LocatedInHost: &models.Reference{ID: "old1"}},
+		{ID: "ghost", Name: "ghost", LocatedInHost: &models.Reference{ID: "missing"}},
+	}
+
+	result := &ParseResult{Warnings: []string{}, Errors: []string{}}
+	hostMap, err := parser.buildHostMapping(&models.GraphNode{Name: "shop"}, containers, topology, result)
+	if err != nil {
+		t.Fatalf("buildHostMapping failed: %v", err)
+	}
+
+	if hostMap["db"] != "storage1" {
+		t.Errorf("Expected graph host node to resolve to storage1, got %q", hostMap["db"])
+	}
+	if hostMap["api"] != "compute1" {
+		t.Errorf("Expected host URL to resolve to compute1, got %q", hostMap["api"])
+	}
+	if host, ok := hostMap["web"]; !ok || host != "" {
+		t.Errorf("Expected web to be left for automatic placement, got %q", host)
+	}
+	if len(result.Errors) != 2 {
+		t.Fatalf("Expected errors for the inactive and the missing host, got %v", result.Errors)
+	}
+	if !strings.Contains(result.Errors[0], "not active") || !strings.Contains(result.Errors[1], "cannot be resolved") {
+		t.Errorf("Unexpected errors: %v", result.Errors)
+	}
+}
+
 func TestStackParser_ValidateContainerSpec(t *testing.T) {
 	resolver := &MockHostResolver{hosts: map[string]*models.HostInfo{}}
 	parser := NewStackParser(resolver)
@@ -323,6 +371,74 @@ func TestStackParser_ValidateContainerSpec(t *testing.T) {
 			},
 			wantError: false,
 		},
+		{
+			name: "valid with files",
+			spec: models.ContainerSpec{
+				Name:  "web",
+				Image: "nginx:latest",
+				Files: []models.FileMount{
+					{Target: "/etc/nginx/conf.d/site.conf", Content: "server {}"},
+					{Target: "/run/secrets/db-password", Secret: "db-password", Mode: "0400"},
+				},
+			},
+			wantError: false,
+		},
+		{
+			name: "file with relative target",
+			spec: models.ContainerSpec{
+				Name:  "web",
+				Image: "nginx:latest",
+				Files: []models.FileMount{
+					{Target: "etc/app.conf", Content: "x"},
+				},
+			},
+			wantError: true,
+		},
+		{
+			name: "file target escaping with dot-dot",
+			spec: models.ContainerSpec{
+				Name:  "web",
+				Image: "nginx:latest",
+				Files: []models.FileMount{
+					{Target: "/etc/../root/app.conf", Content: "x"},
+				},
+			},
+			wantError: true,
+		},
+		{
+			name: "file with invalid mode",
+			spec: models.ContainerSpec{
+				Name:  "web",
+				Image: "nginx:latest",
+				Files: []models.FileMount{
+					{Target: "/etc/app.conf", Content: "x", Mode: "4755"},
+				},
+			},
+			wantError: true,
+		},
+		{
+			name: "file with content and secret",
+			spec: models.ContainerSpec{
+				Name:  "web",
+				Image: "nginx:latest",
+				Files: []models.FileMount{
+					{Target: "/etc/app.conf", Content: "x", Secret: "app"},
+				},
+			},
+			wantError: true,
+		},
+		{
+			name: "duplicate file targets",
+			spec: models.ContainerSpec{
+				Name:  "web",
+				Image: "nginx:latest",
+				Files: []models.FileMount{
+					{Target: "/etc/app.conf", Content: "a"},
+					{Target: "/etc/app.conf", Content: "b"},
+				},
+			},
+			wantError: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -416,6 +532,21 @@ func TestStackParser_RestartPolicyValidation(t *testing.T) {
 	}
 }
 
+func TestStackParser_StopGracePeriodValidation(t *testing.T) {
+	parser := NewStackParser(&MockHostResolver{hosts: map[string]*models.HostInfo{}})
+	result := &ParseResult{Warnings: []string{}, Errors: []string{}}
+
+	spec := models.ContainerSpec{Name: "db", Image: "postgres:15", StopGracePeriod: 60}
+	if err := parser.validateContainerSpec(&spec, result); err != nil {
+		t.Errorf("Expected a positive grace period to be valid, got %v", err)
+	}
+
+	spec.StopGracePeriod = -1
+	if err := parser.validateContainerSpec(&spec, result); err == nil {
+		t.Error("Expected a negative grace period to be rejected")
+	}
+}
+
 func TestStackParser_GetContainersByWave(t *testing.T) {
 	resolver := &MockHostResolver{hosts: map[string]*models.HostInfo{}}
 	parser := NewStackParser(resolver)
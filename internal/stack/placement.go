@@ -0,0 +1,144 @@
+package stack
+
+import (
+	"sort"
+
+	"github.com/docker/docker/api/types/container"
+
+	"evalgo.org/graphium/models"
+)
+
+// hostPolicy returns the policy of the datacenter a host is in, or nil.
+func (d *Deployer) hostPolicy(info *models.HostInfo) *models.DatacenterPolicy {
+	if info == nil || info.Host == nil || info.Host.Datacenter == "" {
+		return nil
+	}
+	return d.Policies[info.Host.Datacenter]
+}
+
+// resolveHostPolicy resolves a host and returns its datacenter policy, or nil.
+func (d *Deployer) resolveHostPolicy(hostID string) *models.DatacenterPolicy {
+	if len(d.Policies) == 0 || d.HostResolver == nil {
+		return nil
+	}
+	info, err := d.HostResolver.ResolveHost(hostID)
+	if err != nil {
+		return nil
+	}
+	return d.hostPolicy(info)
+}
+
+// allowOvercommit reports whether a host may be placed beyond its remaining
+// capacity. Its datacenter policy overrides AllowOvercommit.
+func (d *Deployer) allowOvercommit(info *models.HostInfo) bool {
+	if policy := d.hostPolicy(info); policy != nil && policy.AllowOvercommit != nil {
+		return *policy.AllowOvercommit
+	}
+	return d.AllowOvercommit
+}
+
+// targetDatacenter returns the datacenter a plan deploys to, or "".
+func targetDatacenter(plan *models.DeploymentPlan) string {
+	if plan.StackNode == nil || plan.StackNode.Deployment == nil {
+		return ""
+	}
+	return plan.StackNode.Deployment.TargetDatacenter
+}
+
+// placementStrategy returns the name of the strategy placing containers of a
+// plan without an assigned host: the stack's own strategy, else the target
+// datacenter's policy, else PlacementStrategy, else first-fit. Names the
+// deployer has no strategy for are skipped.
+func (d *Deployer) placementStrategy(plan *models.DeploymentPlan) string {
+	if plan.StackNode != nil && plan.StackNode.Deployment != nil &&
+		d.strategy(plan.StackNode.Deployment.PlacementStrategy) != nil {
+		return plan.StackNode.Deployment.PlacementStrategy
+	}
+	if policy := d.Policies[targetDatacenter(plan)]; policy != nil && d.strategy(policy.PlacementStrategy) != nil {
+		return policy.PlacementStrategy
+	}
+	if d.strategy(d.PlacementStrategy) != nil {
+		return d.PlacementStrategy
+	}
+	return models.PlacementFirstFit
+}
+
+// rankHosts orders candidate hosts for a placement strategy. First-fit keeps
+// the resolver's order; spread prefers the least and binpack the most
+// utilized host. Utilization is the larger committed share of CPU and memory
+// (including reservations planned in this deployment), then the number of
+// containers on the host.
+func rankHosts(hosts []*models.HostInfo, strategy string, planned map[string]*models.ResourceReservations, placed map[string]int) []*models.HostInfo {
+	ranked := make([]*models.HostInfo, 0, len(hosts))
+	for _, info := range hosts {
+		if info != nil && info.Host != nil {
+			ranked = append(ranked, info)
+		}
+	}
+	if strategy != models.PlacementSpread && strategy != models.PlacementBinPack {
+		return ranked
+	}
+
+	less := func(a, b *models.HostInfo) bool {
+		ua, ub := utilization(a, planned[a.Host.ID]), utilization(b, planned[b.Host.ID])
+		if ua != ub {
+			return ua < ub
+		}
+		ca := a.CurrentLoad.ContainerCount + placed[a.Host.ID]
+		cb := b.CurrentLoad.ContainerCount + placed[b.Host.ID]
+		if ca != cb {
+			return ca < cb
+		}
+		return a.Host.ID < b.Host.ID
+	}
+	sort.SliceStable(ranked, func(i, j int) bool {
+		if strategy == models.PlacementBinPack {
+			return less(ranked[j], ranked[i])
+		}
+		return less(ranked[i], ranked[j])
+	})
+	return ranked
+}
+
+// utilization returns the committed share (0-1) of a host's CPU or memory,
+// whichever is higher. Resources of unknown capacity count as unused.
+func utilization(info *models.HostInfo, planned *models.ResourceReservations) float64 {
+	cpus := info.CurrentLoad.CommittedCPUs
+	memory := info.CurrentLoad.CommittedMemory
+	if planned != nil {
+		cpus += planned.CPUs
+		memory += planned.Memory
+	}
+
+	var share float64
+	if info.Host.CPU > 0 {
+		share = cpus / float64(info.Host.CPU)
+	}
+	if info.Host.Memory > 0 {
+		if memShare := float64(memory) / float64(info.Host.Memory); memShare > share {
+			share = memShare
+		}
+	}
+	return share
+}
+
+// plannedContainers counts the containers already placed in a deployment,
+// keyed by host ID.
+func plannedContainers(placements map[string]*models.ContainerPlacement) map[string]int {
+	placed := make(map[string]int)
+	for _, placement := range placements {
+		if placement != nil {
+			placed[placement.HostID]++
+		}
+	}
+	return placed
+}
+
+// applyNetworkMode sets the datacenter's default network mode on containers
+// of stacks that don't define a network.
+func applyNetworkMode(hostConfig *container.HostConfig, plan *models.DeploymentPlan, policy *models.DatacenterPolicy) {
+	if plan.Network != nil || policy == nil || policy.NetworkMode == "" {
+		return
+	}
+	hostConfig.NetworkMode = container.NetworkMode(policy.NetworkMode)
+}
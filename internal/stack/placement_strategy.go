@@ -0,0 +1,68 @@
+package stack
+
+import (
+	"context"
+	"fmt"
+
+	"evalgo.org/graphium/models"
+)
+
+// PlacementState is the deployment in progress a placement strategy places
+// a container into.
+type PlacementState struct {
+	// Plan is the deployment plan
+	Plan *models.DeploymentPlan
+
+	// Planned holds the reservations already placed in this deployment,
+	// keyed by host ID
+	Planned map[string]*models.ResourceReservations
+
+	// Placed counts the containers already placed in this deployment, keyed
+	// by host ID
+	Placed map[string]int
+}
+
+// PlacementStrategy selects the host of a container the deployment plan
+// doesn't assign to one. The built-in strategies are first-fit, spread and
+// binpack; custom strategies (e.g. based on external cost data) are set in
+// Deployer.Strategies and chosen by name like the built-in ones.
+type PlacementStrategy interface {
+	// SelectHost returns the ID of one of candidates. Candidates are in the
+	// resolver's order, in the plan's target datacenter if it has one, and
+	// have room for the container unless their policy allows overcommit.
+	SelectHost(ctx context.Context, spec *models.ContainerSpec, candidates []*models.HostInfo, state *PlacementState) (hostID string, err error)
+}
+
+// rankingStrategy is a built-in strategy: it picks the first host ranked by
+// rankHosts.
+type rankingStrategy string
+
+// SelectHost returns the best ranked candidate.
+func (s rankingStrategy) SelectHost(ctx context.Context, spec *models.ContainerSpec, candidates []*models.HostInfo, state *PlacementState) (string, error) {
+	ranked := rankHosts(candidates, string(s), state.Planned, state.Placed)
+	if len(ranked) == 0 {
+		return "", fmt.Errorf("no candidate hosts")
+	}
+	return ranked[0].Host.ID, nil
+}
+
+// builtinStrategies are the placement strategies every deployer knows.
+var builtinStrategies = map[string]PlacementStrategy{
+	models.PlacementFirstFit: rankingStrategy(models.PlacementFirstFit),
+	models.PlacementSpread:   rankingStrategy(models.PlacementSpread),
+	models.PlacementBinPack:  rankingStrategy(models.PlacementBinPack),
+}
+
+// BuiltinStrategy returns the built-in placement strategy of a name, or nil.
+func BuiltinStrategy(name string) PlacementStrategy {
+	return builtinStrategies[name]
+}
+
+// strategy returns the built-in or custom placement strategy of a name, or
+// nil if the deployer doesn't know it.
+func (d *Deployer) strategy(name string) PlacementStrategy {
+	if strategy := builtinStrategies[name]; strategy != nil {
+		return strategy
+	}
+	return d.Strategies[name]
+}
@@ -0,0 +1,182 @@
+package stack
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/docker/docker/api/types/container"
+
+	"evalgo.org/graphium/models"
+)
+
+func datacenterHost(id, datacenter string, cpu int, committedCPUs float64) *models.HostInfo {
+	info := capacityHost(id, cpu, 0, committedCPUs, 0)
+	info.Host.Datacenter = datacenter
+	return info
+}
+
+func TestDeployer_SelectHostPlacementStrategies(t *testing.T) {
+	resolver := &MockHostResolver{
+		hosts: map[string]*models.HostInfo{
+			"busy": datacenterHost("busy", "edge", 8, 6),
+			"half": datacenterHost("half", "edge", 8, 4),
+			"idle": datacenterHost("idle", "edge", 8, 0),
+		},
+	}
+	deployer := NewDeployer(nil, resolver, nil)
+	plan := &models.DeploymentPlan{}
+
+	deployer.PlacementStrategy = models.PlacementSpread
+	if hostID, _, err := deployer.selectHost(context.Background(), plan, reservingSpec(1, 0), nil, nil); err != nil || hostID != "idle" {
+		t.Errorf("Expected spread to pick 'idle', got %q, %v", hostID, err)
+	}
+
+	deployer.PlacementStrategy = models.PlacementBinPack
+	if hostID, _, err := deployer.selectHost(context.Background(), plan, reservingSpec(2, 0), nil, nil); err != nil || hostID != "busy" {
+		t.Errorf("Expected binpack to pick 'busy', got %q, %v", hostID, err)
+	}
+	if hostID, _, err := deployer.selectHost(context.Background(), plan, reservingSpec(3, 0), nil, nil); err != nil || hostID != "half" {
+		t.Errorf("Expected binpack to skip the host the container doesn't fit on, got %q, %v", hostID, err)
+	}
+
+	// Containers without reservations are spread by container count
+	deployer.PlacementStrategy = models.PlacementSpread
+	spec := &models.ContainerSpec{ID: "container1", Name: "web"}
+	placed := map[string]int{"idle": 1}
+	planned := map[string]*models.ResourceReservations{"idle": {CPUs: 4}}
+	if hostID, _, err := deployer.selectHost(context.Background(), plan, spec, planned, placed); err != nil || hostID != "half" {
+		t.Errorf("Expected spread to count planned containers, got %q, %v", hostID, err)
+	}
+}
+
+func TestDeployer_DatacenterPolicy(t *testing.T) {
+	resolver := &MockHostResolver{
+		hosts: map[string]*models.HostInfo{
+			"edge1": datacenterHost("edge1", "edge", 2, 2),
+			"core1": datacenterHost("core1", "core", 16, 12),
+			"core2": datacenterHost("core2", "core", 16, 2),
+		},
+	}
+	overcommit := true
+	deployer := NewDeployer(nil, resolver, nil)
+	deployer.Policies = map[string]*models.DatacenterPolicy{
+		"edge": {Datacenter: "edge", AllowOvercommit: &overcommit, ProtectedImages: []string{"postgres"}},
+		"core": {Datacenter: "core", PlacementStrategy: models.PlacementBinPack},
+	}
+
+	// The target datacenter's strategy applies and only its hosts are considered
+	plan := &models.DeploymentPlan{
+		StackNode: &models.GraphNode{Deployment: &models.DeploymentConfig{TargetDatacenter: "core"}},
+	}
+	if hostID, _, err := deployer.selectHost(context.Background(), plan, reservingSpec(2, 0), nil, nil); err != nil || hostID != "core1" {
+		t.Errorf("Expected binpack in 'core' to pick 'core1', got %q, %v", hostID, err)
+	}
+
+	// A stack's own strategy wins over the datacenter's
+	plan.StackNode.Deployment.PlacementStrategy = models.PlacementSpread
+	if hostID, _, err := deployer.selectHost(context.Background(), plan, reservingSpec(2, 0), nil, nil); err != nil || hostID != "core2" {
+		t.Errorf("Expected the stack's spread strategy to pick 'core2', got %q, %v", hostID, err)
+	}
+
+	// The edge policy allows overcommit, the global setting doesn't
+	plan = &models.DeploymentPlan{HostMap: map[string]string{"container1": "edge1"}}
+	if hostID, _, err := deployer.selectHost(context.Background(), plan, reservingSpec(4, 0), nil, nil); err != nil || hostID != "edge1" {
+		t.Errorf("Expected edge policy to allow overcommit, got %q, %v", hostID, err)
+	}
+	plan.HostMap["container1"] = "core2"
+	if _, _, err := deployer.selectHost(context.Background(), plan, reservingSpec(20, 0), nil, nil); !errors.Is(err, ErrInsufficientCapacity) {
+		t.Errorf("Expected ErrInsufficientCapacity in 'core', got %v", err)
+	}
+
+	// Protected patterns apply to containers assigned to the datacenter
+	plan = &models.DeploymentPlan{
+		ContainerSpecs: []models.ContainerSpec{{ID: "container1", Name: "db", Image: "postgres:16"}},
+		HostMap:        map[string]string{"container1": "edge1"},
+	}
+	if _, err := deployer.Deploy(context.Background(), plan, DeployOptions{StackName: "app"}); !errors.Is(err, ErrProtectedContainer) {
+		t.Errorf("Expected ErrProtectedContainer in 'edge', got %v", err)
+	}
+	plan.HostMap["container1"] = "core1"
+	if err := deployer.checkProtection(plan); err != nil {
+		t.Errorf("Expected postgres to be allowed in 'core', got %v", err)
+	}
+}
+
+func TestApplyNetworkMode(t *testing.T) {
+	policy := &models.DatacenterPolicy{Datacenter: "edge", NetworkMode: "host"}
+
+	hostConfig := &container.HostConfig{}
+	applyNetworkMode(hostConfig, &models.DeploymentPlan{}, policy)
+	if hostConfig.NetworkMode != "host" {
+		t.Errorf("Expected network mode 'host', got %q", hostConfig.NetworkMode)
+	}
+
+	// Stacks with their own network keep it
+	hostConfig = &container.HostConfig{}
+	applyNetworkMode(hostConfig, &models.DeploymentPlan{Network: &models.NetworkSpec{Name: "app"}}, policy)
+	if hostConfig.NetworkMode != "" {
+		t.Errorf("Expected no network mode for a stack network, got %q", hostConfig.NetworkMode)
+	}
+}
+
+// cheapestStrategy picks the candidate with the lowest "cost" label.
+type cheapestStrategy struct {
+	candidates []string
+}
+
+func (s *cheapestStrategy) SelectHost(ctx context.Context, spec *models.ContainerSpec, candidates []*models.HostInfo, state *PlacementState) (string, error) {
+	s.candidates = nil
+	best := ""
+	for _, info := range candidates {
+		s.candidates = append(s.candidates, info.Host.ID)
+		if best == "" || info.Labels["cost"] < best {
+			best = info.Labels["cost"]
+		}
+	}
+	for _, info := range candidates {
+		if info.Labels["cost"] == best {
+			return info.Host.ID, nil
+		}
+	}
+	return "", errors.New("no candidates")
+}
+
+func TestDeployer_CustomPlacementStrategy(t *testing.T) {
+	cheap := datacenterHost("cheap", "edge", 2, 2)
+	cheap.Labels = map[string]string{"cost": "1"}
+	pricey := datacenterHost("pricey", "edge", 8, 0)
+	pricey.Labels = map[string]string{"cost": "9"}
+	resolver := &MockHostResolver{
+		hosts: map[string]*models.HostInfo{"cheap": cheap, "pricey": pricey},
+	}
+	strategy := &cheapestStrategy{}
+	deployer := NewDeployer(nil, resolver, nil)
+	deployer.Strategies = map[string]PlacementStrategy{"cheapest": strategy}
+	deployer.Policies = map[string]*models.DatacenterPolicy{
+		"edge": {Datacenter: "edge", PlacementStrategy: "cheapest"},
+	}
+	plan := &models.DeploymentPlan{
+		StackNode: &models.GraphNode{Deployment: &models.DeploymentConfig{TargetDatacenter: "edge"}},
+	}
+
+	hostID, autoSelected, err := deployer.selectHost(context.Background(), plan, reservingSpec(0, 0), nil, nil)
+	if err != nil || hostID != "cheap" || !autoSelected {
+		t.Errorf("Expected the custom strategy to pick 'cheap', got %q, %v, %v", hostID, autoSelected, err)
+	}
+
+	// Hosts the container doesn't fit on are no candidates
+	hostID, _, err = deployer.selectHost(context.Background(), plan, reservingSpec(1, 0), nil, nil)
+	if err != nil || hostID != "pricey" {
+		t.Errorf("Expected 'pricey' for a container that doesn't fit 'cheap', got %q, %v", hostID, err)
+	}
+	if len(strategy.candidates) != 1 || strategy.candidates[0] != "pricey" {
+		t.Errorf("Expected only 'pricey' as candidate, got %v", strategy.candidates)
+	}
+
+	// Unknown strategy names fall back to first-fit
+	deployer.Strategies = nil
+	if name := deployer.placementStrategy(plan); name != models.PlacementFirstFit {
+		t.Errorf("Expected an unregistered strategy to fall back to first-fit, got %q", name)
+	}
+}
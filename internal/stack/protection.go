@@ -0,0 +1,33 @@
+package stack
+
+import (
+	"errors"
+	"fmt"
+
+	"evalgo.org/graphium/models"
+)
+
+// ErrProtectedContainer is returned when a deployment would create or
+// replace a container covered by the protection policy.
+var ErrProtectedContainer = errors.New("protected container")
+
+// checkProtection refuses plans that contain a protected container, either
+// globally or in the datacenter of the host the plan assigns it to.
+// Containers placed automatically are checked against their datacenter's
+// policy once the host is selected.
+func (d *Deployer) checkProtection(plan *models.DeploymentPlan) error {
+	for _, spec := range plan.ContainerSpecs {
+		if ok, reason := d.Protection.Protects(spec.Name, spec.Image); ok {
+			return fmt.Errorf("%w: container %s: %s", ErrProtectedContainer, spec.Name, reason)
+		}
+		hostID := plan.HostMap[spec.ID]
+		if hostID == "" {
+			continue
+		}
+		policy := d.resolveHostPolicy(hostID)
+		if ok, reason := policy.Protection().Protects(spec.Name, spec.Image); ok {
+			return fmt.Errorf("%w: container %s in datacenter %s: %s", ErrProtectedContainer, spec.Name, policy.Datacenter, reason)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,33 @@
+package stack
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"evalgo.org/graphium/models"
+)
+
+func TestDeployer_RefusesProtectedContainers(t *testing.T) {
+	db := &MockDatabase{}
+	deployer := NewDeployer(db, &MockHostResolver{}, &MockDockerClientFactory{})
+	deployer.Protection = &models.ProtectionPolicy{Images: []string{"postgres"}}
+
+	plan := &models.DeploymentPlan{
+		ContainerSpecs: []models.ContainerSpec{
+			{Name: "web", Image: "nginx:latest"},
+			{Name: "db", Image: "postgres:16"},
+		},
+	}
+
+	state, err := deployer.Deploy(context.Background(), plan, DeployOptions{StackName: "app"})
+	if !errors.Is(err, ErrProtectedContainer) {
+		t.Fatalf("Expected ErrProtectedContainer, got %v", err)
+	}
+	if state != nil {
+		t.Errorf("Expected no deployment state, got %+v", state)
+	}
+	if len(db.documents) != 0 {
+		t.Errorf("Expected no deployment state to be saved, got %d", len(db.documents))
+	}
+}
@@ -0,0 +1,183 @@
+package stack
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/distribution/reference"
+	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/api/types/registry"
+
+	"eve.evalgo.org/common"
+
+	"evalgo.org/graphium/models"
+)
+
+// pullProgressStep is the progress, in percent, between pull progress events.
+const pullProgressStep = 20
+
+// missingImageError is returned by deployContainer when the image of a
+// container isn't present on its host, so the image can be pulled there
+// under the pull phase timeout before the container is created again.
+type missingImageError struct {
+	HostID string
+	Image  string
+	Err    error
+}
+
+func (e *missingImageError) Error() string {
+	return fmt.Sprintf("image %s not found on host %s: %v", e.Image, e.HostID, e.Err)
+}
+
+func (e *missingImageError) Unwrap() error {
+	return e.Err
+}
+
+// registryHost returns the registry host of an image reference, e.g.
+// docker.io for nginx:latest or ghcr.io for ghcr.io/org/app:1.0.
+func registryHost(ref string) string {
+	named, err := reference.ParseNormalizedNamed(ref)
+	if err != nil {
+		return ""
+	}
+	return reference.Domain(named)
+}
+
+// pullOptions returns the options to pull ref, with the credentials of its
+// registry from RegistryAuth if there are any. Docker Hub credentials may be
+// keyed by docker.io, index.docker.io or https://index.docker.io/v1/.
+func (d *Deployer) pullOptions(ref string) (image.PullOptions, error) {
+	host := registryHost(ref)
+	keys := []string{host}
+	if host == "docker.io" {
+		keys = append(keys, "index.docker.io", "https://index.docker.io/v1/")
+	}
+
+	for _, key := range keys {
+		auth, ok := d.RegistryAuth[key]
+		if !ok {
+			continue
+		}
+		if auth.ServerAddress == "" {
+			auth.ServerAddress = key
+		}
+		encoded, err := registry.EncodeAuthConfig(auth)
+		if err != nil {
+			return image.PullOptions{}, fmt.Errorf("invalid credentials for registry %s: %w", key, err)
+		}
+		return image.PullOptions{RegistryAuth: encoded}, nil
+	}
+	return image.PullOptions{}, nil
+}
+
+// pullImage pulls ref with client, recording its progress as image-pull
+// events of container (which may be empty) every pullProgressStep percent.
+// Errors the registry reports in the progress stream, such as an unknown
+// manifest or denied access, fail the pull.
+func (d *Deployer) pullImage(ctx context.Context, client common.DockerClient, state *models.DeploymentState, container, ref, hostID string) error {
+	options, err := d.pullOptions(ref)
+	if err != nil {
+		return err
+	}
+
+	d.addEvent(state, "info", "image-pull", container,
+		fmt.Sprintf("Pulling image %s on host %s", ref, hostID))
+
+	reader, err := client.ImagePull(ctx, ref, options)
+	if err != nil {
+		return fmt.Errorf("failed to pull image %s: %w", ref, err)
+	}
+	defer func() { _ = reader.Close() }()
+
+	// The pull only completes once the progress stream is consumed
+	reported := 0
+	err = readPullProgress(reader, func(percent int) {
+		if percent >= reported+pullProgressStep && percent < 100 {
+			reported = percent - percent%pullProgressStep
+			d.addEvent(state, "info", "image-pull", container,
+				fmt.Sprintf("Pulling %s %d%%", ref, percent))
+		}
+	})
+	if err != nil {
+		return fmt.Errorf("failed to pull image %s: %w", ref, err)
+	}
+
+	d.addEvent(state, "info", "image-pull", container,
+		fmt.Sprintf("Pulled image %s on host %s", ref, hostID))
+	return nil
+}
+
+// pullMessage is a message of the progress stream of an image pull.
+type pullMessage struct {
+	ID       string `json:"id"`
+	Status   string `json:"status"`
+	Progress *struct {
+		Current int64 `json:"current"`
+		Total   int64 `json:"total"`
+	} `json:"progressDetail"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"errorDetail"`
+	ErrorMessage string `json:"error"`
+}
+
+// readPullProgress reads the progress stream of an image pull, calling fn
+// with the downloaded percentage of the layers whose size is known whenever
+// it changes. An error message in the stream is returned as an error.
+func readPullProgress(r io.Reader, fn func(percent int)) error {
+	type layer struct{ current, total int64 }
+	layers := make(map[string]*layer)
+	last := -1
+
+	dec := json.NewDecoder(r)
+	for {
+		var msg pullMessage
+		if err := dec.Decode(&msg); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		if msg.Error != nil && msg.Error.Message != "" {
+			return errors.New(msg.Error.Message)
+		}
+		if msg.ErrorMessage != "" {
+			return errors.New(msg.ErrorMessage)
+		}
+		if msg.ID == "" {
+			continue
+		}
+
+		l := layers[msg.ID]
+		switch {
+		case msg.Status == "Downloading" && msg.Progress != nil && msg.Progress.Total > 0:
+			if l == nil {
+				l = &layer{}
+				layers[msg.ID] = l
+			}
+			l.current, l.total = msg.Progress.Current, msg.Progress.Total
+		case msg.Status == "Download complete" || msg.Status == "Pull complete":
+			if l != nil {
+				l.current = l.total
+			}
+		default:
+			continue
+		}
+
+		var current, total int64
+		for _, l := range layers {
+			current += l.current
+			total += l.total
+		}
+		if total == 0 {
+			continue
+		}
+		if percent := int(current * 100 / total); percent != last {
+			last = percent
+			fn(percent)
+		}
+	}
+}
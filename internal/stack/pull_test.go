@@ -0,0 +1,216 @@
+package stack
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	cerrdefs "github.com/containerd/errdefs"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/api/types/registry"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+
+	"eve.evalgo.org/common"
+
+	"evalgo.org/graphium/models"
+)
+
+// pullStream is the progress stream of pulling an image with one 100-byte layer
+const pullStream = `{"status":"Pulling from library/nginx","id":"latest"}
+{"status":"Pulling fs layer","id":"a1"}
+{"status":"Downloading","progressDetail":{"current":25,"total":100},"id":"a1"}
+{"status":"Downloading","progressDetail":{"current":45,"total":100},"id":"a1"}
+{"status":"Downloading","progressDetail":{"current":90,"total":100},"id":"a1"}
+{"status":"Download complete","id":"a1"}
+{"status":"Pull complete","id":"a1"}
+{"status":"Status: Downloaded newer image for nginx:latest"}
+`
+
+// pullingDockerClient has no images until they are pulled
+type pullingDockerClient struct {
+	*common.MockDockerClient
+	stream  string
+	pulled  map[string]bool
+	options image.PullOptions
+}
+
+func (c *pullingDockerClient) ImagePull(ctx context.Context, ref string, options image.PullOptions) (io.ReadCloser, error) {
+	if c.pulled == nil {
+		c.pulled = make(map[string]bool)
+	}
+	c.pulled[ref] = true
+	c.options = options
+	return io.NopCloser(strings.NewReader(c.stream)), nil
+}
+
+func (c *pullingDockerClient) ContainerCreate(ctx context.Context, config *container.Config, hostConfig *container.HostConfig, networkingConfig *network.NetworkingConfig, platform *ocispec.Platform, containerName string) (container.CreateResponse, error) {
+	if !c.pulled[config.Image] {
+		return container.CreateResponse{}, cerrdefs.ErrNotFound.WithMessage("No such image: " + config.Image)
+	}
+	return c.MockDockerClient.ContainerCreate(ctx, config, hostConfig, networkingConfig, platform, containerName)
+}
+
+// pullingClientFactory always returns the same pulling client
+type pullingClientFactory struct {
+	client *pullingDockerClient
+}
+
+func (f *pullingClientFactory) GetClient(ctx context.Context, hostID string) (common.DockerClient, error) {
+	return f.client, nil
+}
+
+func TestReadPullProgress(t *testing.T) {
+	var percents []int
+	if err := readPullProgress(strings.NewReader(pullStream), func(percent int) {
+		percents = append(percents, percent)
+	}); err != nil {
+		t.Fatalf("readPullProgress() error = %v", err)
+	}
+	want := []int{25, 45, 90, 100}
+	if len(percents) != len(want) {
+		t.Fatalf("Expected progress %v, got %v", want, percents)
+	}
+	for i := range want {
+		if percents[i] != want[i] {
+			t.Fatalf("Expected progress %v, got %v", want, percents)
+		}
+	}
+
+	stream := `{"status":"Pulling from library/nginx","id":"9.9"}
+{"errorDetail":{"message":"manifest for nginx:9.9 not found: manifest unknown"},"error":"manifest for nginx:9.9 not found: manifest unknown"}
+`
+	err := readPullProgress(strings.NewReader(stream), func(int) {})
+	if err == nil || !strings.Contains(err.Error(), "manifest unknown") {
+		t.Errorf("Expected the registry error, got %v", err)
+	}
+}
+
+func TestRegistryHost(t *testing.T) {
+	tests := map[string]string{
+		"nginx:latest":                       "docker.io",
+		"library/nginx":                      "docker.io",
+		"ghcr.io/org/app:1.0":                "ghcr.io",
+		"registry.example.com:5000/team/api": "registry.example.com:5000",
+		"localhost/app":                      "localhost",
+	}
+	for ref, want := range tests {
+		if got := registryHost(ref); got != want {
+			t.Errorf("registryHost(%q) = %q, want %q", ref, got, want)
+		}
+	}
+}
+
+func TestDeployer_PullOptions(t *testing.T) {
+	deployer := &Deployer{RegistryAuth: map[string]registry.AuthConfig{
+		"ghcr.io":         {Username: "bot", Password: "secret"},
+		"index.docker.io": {Username: "hub", Password: "token"},
+	}}
+
+	decode := func(ref string) registry.AuthConfig {
+		t.Helper()
+		options, err := deployer.pullOptions(ref)
+		if err != nil {
+			t.Fatalf("pullOptions(%q) error = %v", ref, err)
+		}
+		var auth registry.AuthConfig
+		if options.RegistryAuth == "" {
+			return auth
+		}
+		data, err := base64.URLEncoding.DecodeString(options.RegistryAuth)
+		if err != nil {
+			t.Fatalf("pullOptions(%q) encoded %q: %v", ref, options.RegistryAuth, err)
+		}
+		if err := json.Unmarshal(data, &auth); err != nil {
+			t.Fatalf("pullOptions(%q) encoded %s: %v", ref, data, err)
+		}
+		return auth
+	}
+
+	if auth := decode("ghcr.io/org/app:1.0"); auth.Username != "bot" || auth.ServerAddress != "ghcr.io" {
+		t.Errorf("Expected ghcr.io credentials, got %+v", auth)
+	}
+	if auth := decode("nginx:latest"); auth.Username != "hub" {
+		t.Errorf("Expected Docker Hub credentials, got %+v", auth)
+	}
+	if auth := decode("quay.io/org/app"); auth != (registry.AuthConfig{}) {
+		t.Errorf("Expected no credentials, got %+v", auth)
+	}
+}
+
+func TestDeployer_PullsMissingImage(t *testing.T) {
+	db := &MockDatabase{documents: make(map[string]interface{})}
+	resolver := &MockHostResolver{
+		hosts: map[string]*models.HostInfo{
+			"host1": {Host: &models.Host{ID: "host1", Name: "test-host", IPAddress: "192.168.1.10"}},
+		},
+	}
+	client := &pullingDockerClient{MockDockerClient: common.NewMockDockerClient(), stream: pullStream}
+	deployer := NewDeployer(db, resolver, &pullingClientFactory{client: client})
+
+	plan := &models.DeploymentPlan{
+		StackNode: &models.GraphNode{ID: "stack1", Name: "test-stack"},
+		ContainerSpecs: []models.ContainerSpec{
+			{ID: "container1", Name: "web", Image: "nginx:latest"},
+		},
+		HostMap:         map[string]string{"container1": "host1"},
+		DependencyGraph: [][]string{{"web"}},
+	}
+
+	state, err := deployer.Deploy(context.Background(), plan, DeployOptions{Timeout: time.Minute, StackName: "test-stack"})
+	if err != nil {
+		t.Fatalf("Deploy failed: %v", err)
+	}
+	if !client.pulled["nginx:latest"] {
+		t.Error("Expected the missing image to be pulled")
+	}
+	if len(state.Placements) != 1 {
+		t.Errorf("Expected the container to be created after the pull, got %d placements", len(state.Placements))
+	}
+
+	var progress []string
+	for _, event := range state.Events {
+		if event.Phase == "image-pull" && strings.HasSuffix(event.Message, "%") {
+			progress = append(progress, event.Message)
+		}
+	}
+	if strings.Join(progress, ", ") != "Pulling nginx:latest 25%, Pulling nginx:latest 45%, Pulling nginx:latest 90%" {
+		t.Errorf("Unexpected pull progress events %v", progress)
+	}
+}
+
+func TestDeployer_PullFailureFailsDeploy(t *testing.T) {
+	db := &MockDatabase{documents: make(map[string]interface{})}
+	resolver := &MockHostResolver{
+		hosts: map[string]*models.HostInfo{
+			"host1": {Host: &models.Host{ID: "host1", Name: "test-host", IPAddress: "192.168.1.10"}},
+		},
+	}
+	client := &pullingDockerClient{
+		MockDockerClient: common.NewMockDockerClient(),
+		stream:           `{"errorDetail":{"message":"pull access denied for private/app"},"error":"pull access denied for private/app"}`,
+	}
+	deployer := NewDeployer(db, resolver, &pullingClientFactory{client: client})
+
+	plan := &models.DeploymentPlan{
+		StackNode: &models.GraphNode{ID: "stack1", Name: "test-stack"},
+		ContainerSpecs: []models.ContainerSpec{
+			{ID: "container1", Name: "app", Image: "private/app"},
+		},
+		HostMap:         map[string]string{"container1": "host1"},
+		DependencyGraph: [][]string{{"app"}},
+	}
+
+	state, err := deployer.Deploy(context.Background(), plan, DeployOptions{Timeout: time.Minute, StackName: "test-stack", PullImages: true})
+	if err == nil || !strings.Contains(err.Error(), "pull access denied") {
+		t.Fatalf("Expected the registry error, got %v", err)
+	}
+	if state.Status != "failed" || client.ContainerCreateCalled {
+		t.Errorf("Expected the deployment to fail before creating containers, got status %s", state.Status)
+	}
+}
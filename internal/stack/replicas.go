@@ -0,0 +1,86 @@
+package stack
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"evalgo.org/graphium/models"
+)
+
+// PlanReplicas returns how many replicas of a container each candidate host
+// should run, so that replicas run in total. current holds the replicas
+// running per host; replicas on hosts that aren't candidates are left out.
+// Missing replicas are placed one at a time by the strategy on the
+// candidates with room for them, or on those allowOvercommit allows beyond
+// their capacity; surplus replicas are taken from the hosts running the
+// most.
+func PlanReplicas(ctx context.Context, strategy PlacementStrategy, spec *models.ContainerSpec, candidates []*models.HostInfo, current map[string]int, replicas int, allowOvercommit func(*models.HostInfo) bool) (map[string]int, error) {
+	target := make(map[string]int)
+	total := 0
+	for _, info := range candidates {
+		if info == nil || info.Host == nil {
+			continue
+		}
+		target[info.Host.ID] = current[info.Host.ID]
+		total += current[info.Host.ID]
+	}
+
+	for ; total > replicas; total-- {
+		busiest := ""
+		for hostID, count := range target {
+			if count > target[busiest] || (count > 0 && count == target[busiest] && hostID < busiest) {
+				busiest = hostID
+			}
+		}
+		target[busiest]--
+	}
+
+	need := spec.Resources.ReservedResources()
+	planned := make(map[string]*models.ResourceReservations)
+	placed := make(map[string]int)
+	for ; total < replicas; total++ {
+		var fits []*models.HostInfo
+		var reasons []string
+		for _, info := range candidates {
+			if info == nil || info.Host == nil {
+				continue
+			}
+			if !allowOvercommit(info) {
+				if reason := capacityShortfall(info, need, planned[info.Host.ID]); reason != "" {
+					reasons = append(reasons, info.Host.ID+" "+reason)
+					continue
+				}
+			}
+			fits = append(fits, info)
+		}
+		if len(fits) == 0 {
+			if len(reasons) > 0 {
+				return nil, fmt.Errorf("%w: no host fits replica %d of %d (%s)", ErrInsufficientCapacity, total+1, replicas, strings.Join(reasons, "; "))
+			}
+			return nil, fmt.Errorf("no hosts available for replica %d of %d", total+1, replicas)
+		}
+
+		hostID, err := strategy.SelectHost(ctx, spec, fits, &PlacementState{Planned: planned, Placed: placed})
+		if err != nil {
+			return nil, fmt.Errorf("placement failed for replica %d of %d: %w", total+1, replicas, err)
+		}
+		if !containsHost(fits, hostID) {
+			return nil, fmt.Errorf("placement strategy selected host %q, which is not a candidate for replica %d of %d", hostID, total+1, replicas)
+		}
+		addReservation(planned, hostID, need)
+		placed[hostID]++
+		target[hostID]++
+	}
+	return target, nil
+}
+
+// containsHost reports whether hosts include the host with an ID.
+func containsHost(hosts []*models.HostInfo, hostID string) bool {
+	for _, info := range hosts {
+		if info.Host.ID == hostID {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,85 @@
+package stack
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"evalgo.org/graphium/models"
+)
+
+func TestPlanReplicas_SpreadsMissingReplicas(t *testing.T) {
+	candidates := []*models.HostInfo{
+		capacityHost("host1", 8, 0, 0, 0),
+		capacityHost("host2", 8, 0, 0, 0),
+		capacityHost("host3", 8, 0, 0, 0),
+	}
+	// The replica running on host1 counts in its load
+	candidates[0].CurrentLoad.ContainerCount = 1
+	spec := &models.ContainerSpec{Name: "web", Image: "nginx:alpine"}
+	current := map[string]int{"host1": 1}
+
+	target, err := PlanReplicas(context.Background(), BuiltinStrategy(models.PlacementSpread), spec, candidates, current, 4, noOvercommit)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	want := map[string]int{"host1": 2, "host2": 1, "host3": 1}
+	if !reflect.DeepEqual(target, want) {
+		t.Errorf("Expected %v, got %v", want, target)
+	}
+}
+
+func TestPlanReplicas_FirstFitRespectsCapacity(t *testing.T) {
+	candidates := []*models.HostInfo{
+		capacityHost("host1", 4, 0, 2, 0),
+		capacityHost("host2", 4, 0, 0, 0),
+	}
+
+	target, err := PlanReplicas(context.Background(), BuiltinStrategy(models.PlacementFirstFit), reservingSpec(1, 0), candidates, nil, 5, noOvercommit)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	want := map[string]int{"host1": 2, "host2": 3}
+	if !reflect.DeepEqual(target, want) {
+		t.Errorf("Expected %v, got %v", want, target)
+	}
+
+	if _, err := PlanReplicas(context.Background(), BuiltinStrategy(models.PlacementFirstFit), reservingSpec(1, 0), candidates, nil, 7, noOvercommit); !errors.Is(err, ErrInsufficientCapacity) {
+		t.Errorf("Expected ErrInsufficientCapacity, got %v", err)
+	}
+	if _, err := PlanReplicas(context.Background(), BuiltinStrategy(models.PlacementFirstFit), reservingSpec(1, 0), candidates, nil, 7, func(*models.HostInfo) bool { return true }); err != nil {
+		t.Errorf("Expected overcommit to place all replicas, got %v", err)
+	}
+}
+
+func TestPlanReplicas_RemovesFromBusiestHosts(t *testing.T) {
+	candidates := []*models.HostInfo{
+		capacityHost("host1", 8, 0, 0, 0),
+		capacityHost("host2", 8, 0, 0, 0),
+	}
+	// host3 isn't a candidate, so its replica is left out
+	current := map[string]int{"host1": 3, "host2": 2, "host3": 1}
+
+	target, err := PlanReplicas(context.Background(), BuiltinStrategy(models.PlacementSpread), &models.ContainerSpec{Name: "web"}, candidates, current, 2, noOvercommit)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	want := map[string]int{"host1": 1, "host2": 1}
+	if !reflect.DeepEqual(target, want) {
+		t.Errorf("Expected %v, got %v", want, target)
+	}
+}
+
+func TestPlanReplicas_NoCandidates(t *testing.T) {
+	if _, err := PlanReplicas(context.Background(), BuiltinStrategy(models.PlacementSpread), &models.ContainerSpec{Name: "web"}, nil, nil, 1, noOvercommit); err == nil {
+		t.Error("Expected an error without candidate hosts")
+	}
+	target, err := PlanReplicas(context.Background(), BuiltinStrategy(models.PlacementSpread), &models.ContainerSpec{Name: "web"}, nil, nil, 0, noOvercommit)
+	if err != nil || len(target) != 0 {
+		t.Errorf("Expected an empty plan for zero replicas, got %v, %v", target, err)
+	}
+}
+
+// noOvercommit keeps every host within its capacity.
+func noOvercommit(*models.HostInfo) bool { return false }
@@ -0,0 +1,62 @@
+package storage
+
+import (
+	"fmt"
+	"time"
+
+	"eve.evalgo.org/db"
+
+	"evalgo.org/graphium/models"
+)
+
+// agentLogsRetries bounds how often AppendAgentLogs retries after a
+// conflicting concurrent update.
+const agentLogsRetries = 5
+
+// GetAgentLogs retrieves the log lines shipped by a host's agent. A host
+// whose agent hasn't shipped logs yet has no lines.
+func (s *Storage) GetAgentLogs(hostID string) (*models.AgentLogs, error) {
+	var logs models.AgentLogs
+	err := s.service.GetGenericDocument(models.AgentLogsID(hostID), &logs)
+	if couchErr, ok := err.(*db.CouchDBError); ok && couchErr.IsNotFound() {
+		return &models.AgentLogs{
+			Context: "https://schema.org",
+			Type:    "AgentLogs",
+			ID:      models.AgentLogsID(hostID),
+			HostID:  hostID,
+			Lines:   []models.AgentLogLine{},
+		}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get agent logs: %w", err)
+	}
+	return &logs, nil
+}
+
+// AppendAgentLogs adds lines to a host's shipped agent logs, keeping the
+// most recent models.AgentLogsLimit lines.
+func (s *Storage) AppendAgentLogs(hostID string, lines []models.AgentLogLine) (*models.AgentLogs, error) {
+	if hostID == "" {
+		return nil, fmt.Errorf("host ID is required")
+	}
+
+	for attempt := 0; attempt < agentLogsRetries; attempt++ {
+		logs, err := s.GetAgentLogs(hostID)
+		if err != nil {
+			return nil, err
+		}
+		logs.Append(lines)
+		logs.UpdatedAt = time.Now()
+
+		resp, err := s.service.SaveGenericDocument(logs)
+		if couchErr, ok := err.(*db.CouchDBError); ok && couchErr.IsConflict() {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to save agent logs: %w", err)
+		}
+		logs.Rev = resp.Rev
+		return logs, nil
+	}
+	return nil, fmt.Errorf("failed to save agent logs: too many concurrent updates")
+}
@@ -0,0 +1,103 @@
+package storage
+
+import (
+	"eve.evalgo.org/db"
+
+	"evalgo.org/graphium/models"
+)
+
+// bulkPatchResultInvalid is the bulk result error of documents a patch
+// function refused.
+const bulkPatchResultInvalid = "invalid"
+
+// BulkPatchContainers applies patch to many containers and saves them in a
+// single _bulk_docs request. Containers whose save conflicts with a
+// concurrent update are fetched again, patched again and retried once.
+// Results are returned in the order of ids; ids that don't exist are
+// reported as not_found and containers patch refused as invalid. The
+// saved containers are returned with their new revision.
+func (s *Storage) BulkPatchContainers(ids []string, patch func(*models.Container) error) ([]db.BulkResult, []*models.Container, error) {
+	return bulkPatch(ids, s.getContainersByIDs, s.BulkSaveContainers,
+		func(c *models.Container) string { return c.ID },
+		func(c *models.Container, rev string) { c.Rev = rev },
+		patch)
+}
+
+// BulkPatchHosts applies patch to many hosts like BulkPatchContainers.
+func (s *Storage) BulkPatchHosts(ids []string, patch func(*models.Host) error) ([]db.BulkResult, []*models.Host, error) {
+	return bulkPatch(ids, s.getHostsByIDs, s.BulkSaveHosts,
+		func(h *models.Host) string { return h.ID },
+		func(h *models.Host, rev string) { h.Rev = rev },
+		patch)
+}
+
+// bulkPatch fetches, patches and bulk-saves documents, retrying conflicting
+// saves once with the current documents.
+func bulkPatch[T any](ids []string,
+	fetch func([]string) ([]*T, error),
+	save func([]*T) ([]db.BulkResult, error),
+	id func(*T) string,
+	setRev func(*T, string),
+	patch func(*T) error,
+) ([]db.BulkResult, []*T, error) {
+	results := make(map[string]db.BulkResult, len(ids))
+	saved := make(map[string]*T, len(ids))
+
+	pending := ids
+	for attempt := 0; attempt < 2 && len(pending) > 0; attempt++ {
+		docs, err := fetch(pending)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		byID := make(map[string]*T, len(docs))
+		batch := make([]*T, 0, len(docs))
+		for _, doc := range docs {
+			docID := id(doc)
+			if err := patch(doc); err != nil {
+				results[docID] = db.BulkResult{ID: docID, Error: bulkPatchResultInvalid, Reason: err.Error()}
+				continue
+			}
+			byID[docID] = doc
+			batch = append(batch, doc)
+		}
+		if len(batch) == 0 {
+			break
+		}
+
+		batchResults, err := save(batch)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		pending = nil
+		for _, result := range batchResults {
+			results[result.ID] = result
+			switch {
+			case result.OK:
+				if doc := byID[result.ID]; doc != nil {
+					setRev(doc, result.Rev)
+					saved[result.ID] = doc
+				}
+			case result.Error == "conflict":
+				pending = append(pending, result.ID)
+			}
+		}
+	}
+
+	ordered := make([]db.BulkResult, 0, len(results))
+	for _, result := range results {
+		ordered = append(ordered, result)
+	}
+	docs := make([]*T, 0, len(saved))
+	for _, docID := range ids {
+		if doc := saved[docID]; doc != nil {
+			docs = append(docs, doc)
+		}
+	}
+	found := func(docID string) bool {
+		_, ok := results[docID]
+		return ok
+	}
+	return orderBulkResults(ids, ordered, found), docs, nil
+}
@@ -0,0 +1,73 @@
+package storage
+
+import (
+	"eve.evalgo.org/db"
+
+	"evalgo.org/graphium/models"
+)
+
+// BulkUpsertContainers creates or updates many containers in a single
+// _bulk_docs request. Containers that already exist get the stored
+// revision; merge is called with the stored container (nil for new ones)
+// to carry over server-side state and returns whether the container needs
+// saving. Containers merge skips are reported as saved with their current
+// revision. Results are returned in the order of containers; the saved
+// containers are returned with their new revision.
+func (s *Storage) BulkUpsertContainers(containers []*models.Container, merge func(container, existing *models.Container) bool) ([]db.BulkResult, []*models.Container, error) {
+	ids := make([]string, len(containers))
+	for i, container := range containers {
+		ids[i] = container.ID
+	}
+
+	stored, err := s.getContainersByIDs(ids)
+	if err != nil {
+		return nil, nil, err
+	}
+	existing := make(map[string]*models.Container, len(stored))
+	for _, container := range stored {
+		existing[container.ID] = container
+	}
+
+	results := make(map[string]db.BulkResult, len(containers))
+	batch := make([]*models.Container, 0, len(containers))
+	for _, container := range containers {
+		current := existing[container.ID]
+		if current != nil {
+			container.Rev = current.Rev
+		}
+		if !merge(container, current) {
+			results[container.ID] = db.BulkResult{ID: container.ID, Rev: container.Rev, OK: true}
+			continue
+		}
+		batch = append(batch, container)
+	}
+
+	var saved []*models.Container
+	if len(batch) > 0 {
+		batchResults, err := s.BulkSaveContainers(batch)
+		if err != nil {
+			return nil, nil, err
+		}
+		byID := make(map[string]*models.Container, len(batch))
+		for _, container := range batch {
+			byID[container.ID] = container
+		}
+		for _, result := range batchResults {
+			results[result.ID] = result
+			if container := byID[result.ID]; result.OK && container != nil {
+				container.Rev = result.Rev
+				saved = append(saved, container)
+			}
+		}
+	}
+
+	ordered := make([]db.BulkResult, 0, len(results))
+	for _, result := range results {
+		ordered = append(ordered, result)
+	}
+	found := func(id string) bool {
+		_, ok := results[id]
+		return ok
+	}
+	return orderBulkResults(ids, ordered, found), saved, nil
+}
@@ -0,0 +1,79 @@
+package storage
+
+import (
+	"fmt"
+	"sort"
+
+	"eve.evalgo.org/db"
+
+	"evalgo.org/graphium/models"
+)
+
+// SaveContainerChange stores a container change entry.
+func (s *Storage) SaveContainerChange(entry *models.ContainerChangeEntry) error {
+	if entry.Context == "" {
+		entry.Context = "https://schema.org"
+	}
+	if entry.Type == "" {
+		entry.Type = models.ContainerChangeEntryType
+	}
+	if entry.ID == "" {
+		entry.ID = models.GenerateID("change")
+	}
+	return s.SaveDocument(entry)
+}
+
+// GetContainerHistory returns the change entries of a container, newest
+// first. A limit of 0 returns all entries.
+func (s *Storage) GetContainerHistory(containerID string, limit int) ([]*models.ContainerChangeEntry, error) {
+	query := db.MangoQuery{
+		Selector: map[string]interface{}{
+			"@type": map[string]interface{}{
+				"$in": []string{models.ContainerChangeEntryType, models.LegacyContainerChangeEntryType},
+			},
+			"containerId": containerID,
+		},
+	}
+	s.warnIfUnindexed("container-changes", "GetContainerHistory")
+
+	entries, err := db.FindTyped[models.ContainerChangeEntry](s.service, query)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*models.ContainerChangeEntry, len(entries))
+	for i := range entries {
+		result[i] = &entries[i]
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].ChangedAt.After(result[j].ChangedAt)
+	})
+
+	if limit > 0 && len(result) > limit {
+		result = result[:limit]
+	}
+	return result, nil
+}
+
+// PruneContainerHistory deletes all but the newest keep change entries of a
+// container. A keep of 0 keeps all entries. Returns the number of deleted
+// entries.
+func (s *Storage) PruneContainerHistory(containerID string, keep int) (int, error) {
+	if keep <= 0 {
+		return 0, nil
+	}
+
+	entries, err := s.GetContainerHistory(containerID, 0)
+	if err != nil {
+		return 0, err
+	}
+
+	deleted := 0
+	for _, entry := range models.ExcessChangeEntries(entries, keep) {
+		if err := s.service.DeleteDocument(entry.ID, entry.Rev); err != nil {
+			return deleted, fmt.Errorf("failed to delete change entry %s: %w", entry.ID, err)
+		}
+		deleted++
+	}
+	return deleted, nil
+}
@@ -0,0 +1,55 @@
+package storage
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"evalgo.org/graphium/models"
+)
+
+func TestContainerNameCollisions(t *testing.T) {
+	containers := []*models.Container{
+		{ID: "c1", Name: "/web", HostedOn: "h1"},
+		{ID: "c2", Name: "web", HostedOn: "h1"},
+		{ID: "c3", Name: "web", HostedOn: "h2"},
+		{ID: "c4", Name: "db", HostedOn: "h2"},
+		{ID: "c5", Name: "db", HostedOn: "h2"},
+		{ID: "c6", Name: "cache", HostedOn: "h1"},
+	}
+
+	want := []ContainerNameCollision{
+		{HostID: "h1", Name: "web", ContainerIDs: []string{"c1", "c2"}},
+		{HostID: "h2", Name: "db", ContainerIDs: []string{"c4", "c5"}},
+	}
+	if got := containerNameCollisions(containers); !reflect.DeepEqual(got, want) {
+		t.Errorf("containerNameCollisions() = %+v, want %+v", got, want)
+	}
+
+	if got := containerNameCollisions(containers[2:4]); got == nil || len(got) != 0 {
+		t.Errorf("Expected an empty list without collisions, got %#v", got)
+	}
+}
+
+func TestStackContainerName(t *testing.T) {
+	container := &models.Container{ID: "c1", Name: "web", HostedOn: "h1"}
+	onHost := []*models.Container{
+		container,
+		{ID: "c2", Name: "/shop-web", HostedOn: "h1"},
+	}
+
+	if _, err := stackContainerName(container, "shop", onHost); !errors.Is(err, ErrContainerNameCollision) {
+		t.Errorf("Expected a name collision, got %v", err)
+	}
+
+	name, err := stackContainerName(container, "blog", onHost)
+	if err != nil || name != "blog-web" {
+		t.Errorf("Expected blog-web, got %q %v", name, err)
+	}
+
+	renamed := &models.Container{ID: "c2", Name: "shop-web", HostedOn: "h1"}
+	name, err = stackContainerName(renamed, "shop", onHost)
+	if err != nil || name != "" {
+		t.Errorf("Expected no rename for a container already named for its stack, got %q %v", name, err)
+	}
+}
@@ -0,0 +1,33 @@
+package storage
+
+import (
+	"eve.evalgo.org/db"
+
+	"evalgo.org/graphium/models"
+)
+
+// RecordContainerUsage applies a usage sample to its container and saves it
+// if the usage changed. It reports false for unknown containers and for
+// containers not hosted on hostID. The agent's sync saves the same
+// containers, so a conflicting save is retried once with the current
+// container instead of overwriting it.
+func (s *Storage) RecordContainerUsage(hostID string, sample *models.ContainerUsageSample) (bool, error) {
+	var err error
+	for attempt := 0; attempt < 2; attempt++ {
+		container, getErr := s.GetContainer(sample.ContainerID)
+		if getErr != nil || container.HostedOn != hostID {
+			return false, nil
+		}
+
+		updated := *container
+		sample.Apply(&updated)
+		if !models.UsageChanged(container, &updated) {
+			return true, nil
+		}
+		_, err = s.service.SaveGenericDocument(&updated)
+		if couchErr, ok := err.(*db.CouchDBError); !ok || !couchErr.IsConflict() {
+			break
+		}
+	}
+	return err == nil, err
+}
@@ -0,0 +1,142 @@
+package storage
+
+import (
+	"strings"
+
+	"eve.evalgo.org/db"
+
+	"evalgo.org/graphium/models"
+)
+
+// idSort orders cursor pages by document type and ID, so the "<type>-id"
+// indexes serve both the sort and the range.
+var idSort = []map[string]string{{"@type": "asc"}, {"@id": "asc"}}
+
+// afterKey returns the after value of a page ending with the document of
+// the given type and ID. Hosts are stored under two types, so the position
+// in idSort order needs both.
+func afterKey(docType, id string) string {
+	return docType + "|" + id
+}
+
+// afterSelector restricts selector to documents that sort after after in
+// idSort order. An empty after doesn't restrict it; an after without a type
+// restricts by ID only.
+func afterSelector(selector map[string]interface{}, after string) map[string]interface{} {
+	if after == "" {
+		return selector
+	}
+	docType, id, ok := strings.Cut(after, "|")
+	if !ok {
+		restricted := make(map[string]interface{}, len(selector)+1)
+		for field, value := range selector {
+			restricted[field] = value
+		}
+		restricted["@id"] = map[string]interface{}{"$gt": after}
+		return restricted
+	}
+	// Search selectors use $or themselves
+	return map[string]interface{}{"$and": []map[string]interface{}{
+		selector,
+		{"$or": []map[string]interface{}{
+			{"@type": map[string]interface{}{"$gt": docType}},
+			{"@type": docType, "@id": map[string]interface{}{"$gt": id}},
+		}},
+	}}
+}
+
+// nextAfter returns the after value of the page following one that ended
+// with last, or "" when a page of n documents for limit was the last.
+func nextAfter(n, limit int, last string) string {
+	if limit <= 0 || n < limit {
+		return ""
+	}
+	return last
+}
+
+// ListContainersAfter returns up to limit containers matching filters that
+// sort after after, in idSort order, and the after value of the next page
+// ("" on the last page). Unlike ListContainersPage it neither skips nor
+// counts, so each page costs the same however deep it is, and containers
+// added or removed meanwhile don't shift later pages.
+//
+// Duplicate documents of a container are adjacent in idSort order and dropped,
+// also across pages.
+func (s *Storage) ListContainersAfter(filters map[string]interface{}, after string, limit int) ([]*models.Container, string, error) {
+	return s.containersAfter(containerSelector(filters), after, limit, "ListContainersAfter")
+}
+
+// SearchContainersAfter is SearchContainers with ListContainersAfter paging.
+func (s *Storage) SearchContainersAfter(query string, filters map[string]interface{}, after string, limit int) ([]*models.Container, string, error) {
+	selector, err := s.containerSearchSelector(query, filters)
+	if err != nil {
+		return nil, "", err
+	}
+	return s.containersAfter(selector, after, limit, "SearchContainersAfter")
+}
+
+// containersAfter returns a cursor page of the containers matching selector.
+// query names the caller in index warnings.
+func (s *Storage) containersAfter(selector map[string]interface{}, after string, limit int, query string) ([]*models.Container, string, error) {
+	s.warnIfUnindexed("containers-id", query)
+	containers, err := db.FindTyped[models.Container](s.service, db.MangoQuery{
+		Selector: afterSelector(selector, after),
+		Sort:     idSort,
+		Limit:    limit,
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	result := make([]*models.Container, 0, len(containers))
+	for i := range containers {
+		if n := len(result); n > 0 && result[n-1].ID == containers[i].ID {
+			result[n-1] = &containers[i]
+			continue
+		}
+		result = append(result, &containers[i])
+	}
+
+	var last string
+	if n := len(containers); n > 0 {
+		last = afterKey(containers[n-1].Type, containers[n-1].ID)
+	}
+	return result, nextAfter(len(containers), limit, last), nil
+}
+
+// ListHostsAfter returns up to limit hosts matching filters that sort after
+// after, in idSort order, and the after value of the next page ("" on the
+// last page). Like ListContainersAfter, it neither skips nor counts.
+func (s *Storage) ListHostsAfter(filters map[string]interface{}, after string, limit int) ([]*models.Host, string, error) {
+	return s.hostsAfter(hostSelector(filters), after, limit, "ListHostsAfter")
+}
+
+// SearchHostsAfter is SearchHosts with ListHostsAfter paging.
+func (s *Storage) SearchHostsAfter(query string, filters map[string]interface{}, after string, limit int) ([]*models.Host, string, error) {
+	return s.hostsAfter(hostSearchSelector(query, filters), after, limit, "SearchHostsAfter")
+}
+
+// hostsAfter returns a cursor page of the hosts matching selector. query
+// names the caller in index warnings.
+func (s *Storage) hostsAfter(selector map[string]interface{}, after string, limit int, query string) ([]*models.Host, string, error) {
+	s.warnIfUnindexed("hosts-id", query)
+	hosts, err := db.FindTyped[models.Host](s.service, db.MangoQuery{
+		Selector: afterSelector(selector, after),
+		Sort:     idSort,
+		Limit:    limit,
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	result := make([]*models.Host, len(hosts))
+	for i := range hosts {
+		result[i] = &hosts[i]
+	}
+
+	var last string
+	if n := len(hosts); n > 0 {
+		last = afterKey(hosts[n-1].Type, hosts[n-1].ID)
+	}
+	return result, nextAfter(len(hosts), limit, last), nil
+}
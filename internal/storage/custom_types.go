@@ -0,0 +1,176 @@
+package storage
+
+import (
+	"fmt"
+	"time"
+
+	"eve.evalgo.org/db"
+
+	"evalgo.org/graphium/models"
+)
+
+// configuredCustomTypes returns the custom types registered in the
+// configuration (graph.custom_types).
+func (s *Storage) configuredCustomTypes() []*models.CustomType {
+	types := make([]*models.CustomType, 0, len(s.config.Graph.CustomTypes))
+	for _, cfg := range s.config.Graph.CustomTypes {
+		ct := &models.CustomType{
+			Context:     "https://schema.org",
+			Type:        "CustomType",
+			ID:          models.CustomTypeID(cfg.Name),
+			Name:        cfg.Name,
+			Vocabulary:  cfg.Vocabulary,
+			Description: cfg.Description,
+			Source:      models.CustomTypeSourceConfig,
+		}
+		for _, edge := range cfg.Edges {
+			ct.Edges = append(ct.Edges, models.EdgeRule{
+				Property: edge.Property,
+				Relation: edge.Relation,
+				Target:   edge.Target,
+			})
+		}
+		if err := ct.Validate(); err != nil {
+			s.debugLog("Ignoring configured custom type %s: %v", cfg.Name, err)
+			continue
+		}
+		types = append(types, ct)
+	}
+	return types
+}
+
+// ListCustomTypes returns the custom types registered in the configuration
+// and through the API. Configured types win over stored types of the same
+// name.
+func (s *Storage) ListCustomTypes() ([]*models.CustomType, error) {
+	types := s.configuredCustomTypes()
+	configured := make(map[string]bool, len(types))
+	for _, ct := range types {
+		configured[ct.Name] = true
+	}
+
+	query := db.NewQueryBuilder().
+		Where("@type", "$eq", "CustomType").
+		Build()
+
+	stored, err := db.FindTyped[models.CustomType](s.service, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list custom types: %w", err)
+	}
+	for i := range stored {
+		if !configured[stored[i].Name] {
+			types = append(types, &stored[i])
+		}
+	}
+	return types, nil
+}
+
+// GetCustomType retrieves a registered custom type by name.
+func (s *Storage) GetCustomType(name string) (*models.CustomType, error) {
+	for _, ct := range s.configuredCustomTypes() {
+		if ct.Name == name {
+			return ct, nil
+		}
+	}
+
+	var ct models.CustomType
+	if err := s.service.GetGenericDocument(models.CustomTypeID(name), &ct); err != nil {
+		return nil, fmt.Errorf("custom type not found: %w", err)
+	}
+	return &ct, nil
+}
+
+// SaveCustomType registers a custom type through the API.
+func (s *Storage) SaveCustomType(ct *models.CustomType) error {
+	if err := ct.Validate(); err != nil {
+		return err
+	}
+
+	ct.Context = "https://schema.org"
+	ct.Type = "CustomType"
+	ct.ID = models.CustomTypeID(ct.Name)
+	ct.Source = models.CustomTypeSourceAPI
+	if ct.CreatedAt.IsZero() {
+		ct.CreatedAt = time.Now()
+	}
+
+	resp, err := s.service.SaveGenericDocument(ct)
+	if err != nil {
+		return fmt.Errorf("failed to save custom type: %w", err)
+	}
+
+	ct.Rev = resp.Rev
+	return nil
+}
+
+// DeleteCustomType removes a custom type registered through the API.
+func (s *Storage) DeleteCustomType(ct *models.CustomType) error {
+	if err := s.service.DeleteDocument(ct.ID, ct.Rev); err != nil {
+		return fmt.Errorf("failed to delete custom type: %w", err)
+	}
+	return nil
+}
+
+// SaveCustomEntity creates or updates an entity of a custom type. Updates
+// must carry the entity's current revision.
+func (s *Storage) SaveCustomEntity(entity *models.CustomEntity) error {
+	if entity.ID == "" {
+		entity.ID = models.GenerateID("entity")
+	}
+	entity.CustomEntity = true
+
+	now := time.Now()
+	if entity.CreatedAt.IsZero() {
+		entity.CreatedAt = now
+	}
+	entity.UpdatedAt = now
+
+	resp, err := s.service.SaveGenericDocument(entity)
+	if err != nil {
+		return fmt.Errorf("failed to save entity: %w", err)
+	}
+
+	entity.Rev = resp.Rev
+	return nil
+}
+
+// GetCustomEntity retrieves an entity of a custom type by ID.
+func (s *Storage) GetCustomEntity(id string) (*models.CustomEntity, error) {
+	var entity models.CustomEntity
+	if err := s.service.GetGenericDocument(id, &entity); err != nil {
+		return nil, fmt.Errorf("entity not found: %w", err)
+	}
+	if !entity.CustomEntity {
+		return nil, fmt.Errorf("entity not found: %s is not a custom entity", id)
+	}
+	return &entity, nil
+}
+
+// ListCustomEntities returns the entities of a custom type, or of all
+// custom types if typeName is empty.
+func (s *Storage) ListCustomEntities(typeName string) ([]*models.CustomEntity, error) {
+	qb := db.NewQueryBuilder().
+		Where("customEntity", "$eq", true)
+	if typeName != "" {
+		qb = qb.And().Where("@type", "$eq", typeName)
+	}
+
+	entities, err := db.FindTyped[models.CustomEntity](s.service, qb.Build())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list entities: %w", err)
+	}
+
+	result := make([]*models.CustomEntity, len(entities))
+	for i := range entities {
+		result[i] = &entities[i]
+	}
+	return result, nil
+}
+
+// DeleteCustomEntity deletes an entity of a custom type.
+func (s *Storage) DeleteCustomEntity(id, rev string) error {
+	if err := s.service.DeleteDocument(id, rev); err != nil {
+		return fmt.Errorf("failed to delete entity: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package storage
+
+import (
+	"fmt"
+	"time"
+
+	"eve.evalgo.org/db"
+
+	"evalgo.org/graphium/models"
+)
+
+// SaveDatacenterPolicy creates or replaces the policy of a datacenter.
+func (s *Storage) SaveDatacenterPolicy(policy *models.DatacenterPolicy) error {
+	if policy.Datacenter == "" {
+		return fmt.Errorf("datacenter is required")
+	}
+
+	policy.Context = "https://schema.org"
+	policy.Type = "DatacenterPolicy"
+	policy.ID = models.DatacenterPolicyID(policy.Datacenter)
+	policy.UpdatedAt = time.Now()
+
+	// Replace the existing policy, if any
+	policy.Rev = ""
+	if existing, err := s.GetDatacenterPolicy(policy.Datacenter); err == nil {
+		policy.Rev = existing.Rev
+	}
+
+	resp, err := s.service.SaveGenericDocument(policy)
+	if err != nil {
+		return fmt.Errorf("failed to save datacenter policy: %w", err)
+	}
+
+	policy.Rev = resp.Rev
+	return nil
+}
+
+// GetDatacenterPolicy retrieves the policy of a datacenter.
+func (s *Storage) GetDatacenterPolicy(datacenter string) (*models.DatacenterPolicy, error) {
+	var policy models.DatacenterPolicy
+	if err := s.service.GetGenericDocument(models.DatacenterPolicyID(datacenter), &policy); err != nil {
+		return nil, fmt.Errorf("datacenter policy not found: %w", err)
+	}
+	return &policy, nil
+}
+
+// ListDatacenterPolicies returns all datacenter policies, keyed by datacenter.
+func (s *Storage) ListDatacenterPolicies() (map[string]*models.DatacenterPolicy, error) {
+	query := db.NewQueryBuilder().
+		Where("@type", "$eq", "DatacenterPolicy").
+		Build()
+
+	policies, err := db.FindTyped[models.DatacenterPolicy](s.service, query)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]*models.DatacenterPolicy, len(policies))
+	for i := range policies {
+		result[policies[i].Datacenter] = &policies[i]
+	}
+
+	return result, nil
+}
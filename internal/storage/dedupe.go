@@ -0,0 +1,138 @@
+package storage
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"eve.evalgo.org/db"
+)
+
+// containerDocMeta is the projection of a container document that
+// deduplication needs.
+type containerDocMeta struct {
+	DocID   string `json:"_id"`
+	Rev     string `json:"_rev"`
+	ID      string `json:"@id"`
+	Created string `json:"dateCreated,omitempty"`
+}
+
+// DedupeResult reports a container deduplication run.
+type DedupeResult struct {
+	// Containers is the number of containers with duplicate documents
+	Containers int `json:"containers"`
+
+	// Removed is the number of duplicate documents deleted
+	Removed int `json:"removed"`
+
+	// Errors describe documents that could not be deleted
+	Errors []string `json:"errors,omitempty"`
+}
+
+// DeduplicateContainers deletes all but the newest document of every
+// container that has several documents with the same @id. The newest copy
+// is the one with the latest dateCreated, then the highest revision; a copy
+// whose document ID is the container ID wins ties. The surviving copy is
+// never deleted.
+func (s *Storage) DeduplicateContainers() (*DedupeResult, error) {
+	docs, err := db.FindTyped[containerDocMeta](s.service, db.MangoQuery{
+		Selector: containerSelector(nil),
+		Fields:   []string{"_id", "_rev", "@id", "dateCreated"},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list container documents: %w", err)
+	}
+
+	result := &DedupeResult{}
+	for _, group := range groupContainerDocs(docs) {
+		result.Containers++
+		for _, doc := range redundantContainerDocs(group) {
+			if err := s.service.DeleteDocument(doc.DocID, doc.Rev); err != nil {
+				result.Errors = append(result.Errors, fmt.Sprintf("%s: %v", doc.DocID, err))
+				continue
+			}
+			result.Removed++
+		}
+	}
+	return result, nil
+}
+
+// groupContainerDocs groups the documents of containers that have more than
+// one, ordered by container ID.
+func groupContainerDocs(docs []containerDocMeta) [][]containerDocMeta {
+	byID := make(map[string][]containerDocMeta)
+	for _, doc := range docs {
+		if doc.ID == "" || doc.DocID == "" {
+			continue
+		}
+		byID[doc.ID] = append(byID[doc.ID], doc)
+	}
+
+	ids := make([]string, 0, len(byID))
+	for id, group := range byID {
+		if len(group) > 1 {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+
+	groups := make([][]containerDocMeta, len(ids))
+	for i, id := range ids {
+		groups[i] = byID[id]
+	}
+	return groups
+}
+
+// redundantContainerDocs returns the documents of a container to delete:
+// every document but the newest. A single document is never redundant.
+func redundantContainerDocs(group []containerDocMeta) []containerDocMeta {
+	if len(group) < 2 {
+		return nil
+	}
+
+	keep := 0
+	for i := 1; i < len(group); i++ {
+		if newerContainerDoc(group[i], group[keep]) {
+			keep = i
+		}
+	}
+
+	redundant := make([]containerDocMeta, 0, len(group)-1)
+	for i, doc := range group {
+		if i != keep && doc.DocID != group[keep].DocID {
+			redundant = append(redundant, doc)
+		}
+	}
+	return redundant
+}
+
+// newerContainerDoc reports whether a is a newer copy of a container than b.
+func newerContainerDoc(a, b containerDocMeta) bool {
+	aCreated, aOK := parseCreated(a.Created)
+	bCreated, bOK := parseCreated(b.Created)
+	if aOK != bOK {
+		return aOK
+	}
+	if aOK && !aCreated.Equal(bCreated) {
+		return aCreated.After(bCreated)
+	}
+
+	if aGen, bGen := revGeneration(a.Rev), revGeneration(b.Rev); aGen != bGen {
+		return aGen > bGen
+	}
+	return a.DocID == a.ID && b.DocID != b.ID
+}
+
+// parseCreated parses a dateCreated value, which Docker reports as RFC 3339.
+func parseCreated(created string) (time.Time, bool) {
+	t, err := time.Parse(time.RFC3339Nano, created)
+	return t, err == nil
+}
+
+// revGeneration returns the generation of a CouchDB revision ("3-abc" is 3).
+func revGeneration(rev string) int {
+	n, _ := strconv.Atoi(strings.SplitN(rev, "-", 2)[0])
+	return n
+}
@@ -0,0 +1,80 @@
+package storage
+
+import "testing"
+
+func TestRedundantContainerDocs(t *testing.T) {
+	tests := []struct {
+		name     string
+		group    []containerDocMeta
+		wantKeep string
+	}{
+		{
+			name: "latest created wins",
+			group: []containerDocMeta{
+				{DocID: "c1", Rev: "9-a", ID: "c1", Created: "2024-01-01T00:00:00Z"},
+				{DocID: "dup-1", Rev: "1-b", ID: "c1", Created: "2024-02-01T00:00:00Z"},
+			},
+			wantKeep: "dup-1",
+		},
+		{
+			name: "highest revision wins without dates",
+			group: []containerDocMeta{
+				{DocID: "dup-1", Rev: "2-a", ID: "c1"},
+				{DocID: "dup-2", Rev: "10-b", ID: "c1"},
+				{DocID: "dup-3", Rev: "3-c", ID: "c1"},
+			},
+			wantKeep: "dup-2",
+		},
+		{
+			name: "document ID matching the container wins ties",
+			group: []containerDocMeta{
+				{DocID: "dup-1", Rev: "1-a", ID: "c1", Created: "2024-01-01T00:00:00Z"},
+				{DocID: "c1", Rev: "1-b", ID: "c1", Created: "2024-01-01T00:00:00Z"},
+			},
+			wantKeep: "c1",
+		},
+		{
+			name: "a dated copy beats an undated one",
+			group: []containerDocMeta{
+				{DocID: "dup-1", Rev: "7-a", ID: "c1"},
+				{DocID: "dup-2", Rev: "1-b", ID: "c1", Created: "2024-01-01T00:00:00Z"},
+			},
+			wantKeep: "dup-2",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			redundant := redundantContainerDocs(tt.group)
+			if len(redundant) != len(tt.group)-1 {
+				t.Fatalf("Expected %d redundant documents, got %d", len(tt.group)-1, len(redundant))
+			}
+			for _, doc := range redundant {
+				if doc.DocID == tt.wantKeep {
+					t.Errorf("Expected %s to survive, but it is marked for deletion", tt.wantKeep)
+				}
+			}
+		})
+	}
+}
+
+func TestRedundantContainerDocs_NeverDeletesSingleCopy(t *testing.T) {
+	if redundant := redundantContainerDocs([]containerDocMeta{{DocID: "c1", Rev: "1-a", ID: "c1"}}); len(redundant) != 0 {
+		t.Errorf("Expected a single copy to survive, got %+v", redundant)
+	}
+	if redundant := redundantContainerDocs(nil); len(redundant) != 0 {
+		t.Errorf("Expected nothing to delete, got %+v", redundant)
+	}
+}
+
+func TestGroupContainerDocs(t *testing.T) {
+	groups := groupContainerDocs([]containerDocMeta{
+		{DocID: "c2", ID: "c2"},
+		{DocID: "dup-c2", ID: "c2"},
+		{DocID: "c1", ID: "c1"},
+		{DocID: "orphan"},
+	})
+	if len(groups) != 1 || len(groups[0]) != 2 || groups[0][0].ID != "c2" {
+		t.Errorf("Expected one group of the two c2 documents, got %+v", groups)
+	}
+}
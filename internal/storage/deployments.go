@@ -1,6 +1,8 @@
 package storage
 
 import (
+	"encoding/json"
+
 	"eve.evalgo.org/db"
 
 	"evalgo.org/graphium/models"
@@ -18,6 +20,7 @@ func (s *Storage) ListDeployments(filters map[string]interface{}) ([]*models.Dep
 	}
 
 	query := qb.Build()
+	s.warnIfUnindexed("deployments-stack", "ListDeployments")
 
 	// Execute query
 	deployments, err := db.FindTyped[models.DeploymentState](s.service, query)
@@ -43,11 +46,26 @@ func (s *Storage) GetDeploymentsByStatus(status string) ([]*models.DeploymentSta
 }
 
 // GetDeploymentsByStackID retrieves all deployments for a specific stack.
+// It uses the deployments_by_stack view, so stack-centric graph renders don't
+// scan every deployment.
 func (s *Storage) GetDeploymentsByStackID(stackID string) ([]*models.DeploymentState, error) {
-	filters := map[string]interface{}{
-		"stackId": stackID,
+	result, err := s.service.QueryView("graphium", "deployments_by_stack", db.ViewOptions{
+		Key:         stackID,
+		IncludeDocs: true,
+	})
+	if err != nil {
+		return nil, err
 	}
-	return s.ListDeployments(filters)
+
+	deployments := make([]*models.DeploymentState, 0, len(result.Rows))
+	for _, row := range result.Rows {
+		var deployment models.DeploymentState
+		if err := json.Unmarshal(row.Doc, &deployment); err != nil {
+			continue // Skip invalid documents
+		}
+		deployments = append(deployments, &deployment)
+	}
+	return deployments, nil
 }
 
 // SaveDeploymentState saves a deployment state document to the database.
@@ -0,0 +1,184 @@
+package storage
+
+import (
+	"eve.evalgo.org/db"
+
+	"evalgo.org/graphium/models"
+)
+
+// exportBatchSize is the number of documents fetched per export query.
+const exportBatchSize = 200
+
+// stackSelector selects all stacks.
+var stackSelector = map[string]interface{}{"@type": map[string]interface{}{"$eq": "ItemList"}}
+
+// ExportDocuments calls fn with every host, container and stack document, in
+// that order and by ID within each, without CouchDB's _id and _rev. Only the
+// IDs are loaded up front; documents are fetched exportBatchSize at a time,
+// so memory use doesn't grow with the graph. Duplicate documents of a
+// container are exported once.
+func (s *Storage) ExportDocuments(fn func(doc map[string]interface{}) error) error {
+	idOf := func(doc *map[string]interface{}) string {
+		id, _ := (*doc)["@id"].(string)
+		return id
+	}
+
+	for _, selector := range []map[string]interface{}{hostSelector(nil), containerSelector(nil), stackSelector} {
+		keys, err := s.findSortKeys(selector, ListSort{})
+		if err != nil {
+			return err
+		}
+		ids, _ := pageIDs(keys, ListSort{}, 0, 0)
+
+		for start := 0; start < len(ids); start += exportBatchSize {
+			end := min(start+exportBatchSize, len(ids))
+			docs, err := findPage(s, selector, ids[start:end], idOf)
+			if err != nil {
+				return err
+			}
+			for _, doc := range docs {
+				delete(*doc, "_id")
+				delete(*doc, "_rev")
+				if err := fn(*doc); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// docRev is the projection of revision lookups.
+type docRev struct {
+	DocID string `json:"_id"`
+	Rev   string `json:"_rev"`
+}
+
+// currentRevisions returns the revisions of the stored documents matching
+// selector with the given IDs, by document ID.
+func (s *Storage) currentRevisions(selector map[string]interface{}, ids []string) (map[string]string, error) {
+	revs := make(map[string]string, len(ids))
+	if len(ids) == 0 {
+		return revs, nil
+	}
+
+	query := make(map[string]interface{}, len(selector)+1)
+	for field, value := range selector {
+		query[field] = value
+	}
+	query["_id"] = map[string]interface{}{"$in": ids}
+
+	docs, err := db.FindTyped[docRev](s.service, db.MangoQuery{
+		Selector: query,
+		Fields:   []string{"_id", "_rev"},
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, doc := range docs {
+		revs[doc.DocID] = doc.Rev
+	}
+	return revs, nil
+}
+
+// ImportDocuments saves hosts, containers and stacks, as exported by
+// ExportDocuments, overwriting stored documents with the same ID.
+// Containers deleted with a tombstone are not brought back; they fail with
+// a "tombstoned" result. Results are per document, hosts first, then
+// containers, then stacks, each in argument order.
+func (s *Storage) ImportDocuments(hosts []*models.Host, containers []*models.Container, stacks []*models.Stack) ([]db.BulkResult, error) {
+	results := make([]db.BulkResult, 0, len(hosts)+len(containers)+len(stacks))
+
+	if len(hosts) > 0 {
+		ids := make([]string, len(hosts))
+		for i, host := range hosts {
+			ids[i] = host.ID
+		}
+		revs, err := s.currentRevisions(hostSelector(nil), ids)
+		if err != nil {
+			return nil, err
+		}
+		for _, host := range hosts {
+			host.Rev = revs[host.ID]
+		}
+		saved, err := s.BulkSaveHosts(hosts)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, orderBulkResults(ids, saved, func(string) bool { return true })...)
+	}
+
+	if len(containers) > 0 {
+		tombstoned := make(map[string]bool)
+		tombstones, err := s.ListIgnored()
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range tombstones {
+			tombstoned[entry.ContainerID] = true
+		}
+
+		ids := make([]string, len(containers))
+		batch := make([]*models.Container, 0, len(containers))
+		for i, container := range containers {
+			ids[i] = container.ID
+			if !tombstoned[container.ID] {
+				batch = append(batch, container)
+			}
+		}
+		batchIDs := make([]string, len(batch))
+		for i, container := range batch {
+			batchIDs[i] = container.ID
+		}
+		revs, err := s.currentRevisions(containerSelector(nil), batchIDs)
+		if err != nil {
+			return nil, err
+		}
+		for _, container := range batch {
+			container.Rev = revs[container.ID]
+		}
+
+		var saved []db.BulkResult
+		if len(batch) > 0 {
+			if saved, err = s.BulkSaveContainers(batch); err != nil {
+				return nil, err
+			}
+		}
+		for _, result := range orderBulkResults(ids, saved, func(id string) bool { return !tombstoned[id] }) {
+			if tombstoned[result.ID] {
+				result.Error = "tombstoned"
+				result.Reason = "container was deleted"
+			}
+			results = append(results, result)
+		}
+	}
+
+	if len(stacks) > 0 {
+		ids := make([]string, len(stacks))
+		docs := make([]interface{}, len(stacks))
+		for i, stack := range stacks {
+			ids[i] = stack.ID
+			if stack.Context == "" {
+				stack.Context = "https://schema.org"
+			}
+			if stack.Type == "" {
+				stack.Type = "ItemList"
+			}
+			docs[i] = stack
+		}
+		revs, err := s.currentRevisions(stackSelector, ids)
+		if err != nil {
+			return nil, err
+		}
+		for _, stack := range stacks {
+			stack.Rev = revs[stack.ID]
+		}
+		saved, err := s.service.BulkSaveDocuments(docs)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, orderBulkResults(ids, saved, func(string) bool { return true })...)
+	}
+
+	return results, nil
+}
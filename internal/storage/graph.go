@@ -0,0 +1,140 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"eve.evalgo.org/db"
+)
+
+// GraphNodeBuilder adds nodes and edges to the graph returned by
+// GetGraphData. Builders run in registration order after hosts and
+// containers were added, so they can link their nodes to them.
+type GraphNodeBuilder interface {
+	BuildGraph(s *Storage, graph *db.RelationshipGraph) error
+}
+
+// RegisterGraphNodeBuilder adds a builder to GetGraphData. Register
+// builders before the storage serves requests.
+func (s *Storage) RegisterGraphNodeBuilder(builder GraphNodeBuilder) {
+	s.graphBuilders = append(s.graphBuilders, builder)
+}
+
+// GetGraphData builds the infrastructure graph: hosts, containers with
+// their hostedOn and dependsOn edges, and the nodes of the registered
+// graph node builders (by default the entities of custom types). Unless
+// includeOrphans is set, containers no stack lists are left out.
+func (s *Storage) GetGraphData(includeOrphans bool) (*db.RelationshipGraph, error) {
+	graph := &db.RelationshipGraph{
+		Nodes: make(map[string]json.RawMessage),
+		Edges: []db.RelationshipEdge{},
+	}
+
+	hosts, err := s.ListHosts(nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list hosts: %w", err)
+	}
+	for _, host := range hosts {
+		if err := addNode(graph, host.ID, host); err != nil {
+			return nil, err
+		}
+	}
+
+	containers, err := s.ListContainers(nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list containers: %w", err)
+	}
+	if !includeOrphans {
+		stackMap, err := s.GetContainerStackMap()
+		if err != nil {
+			return nil, err
+		}
+		stacked := containers[:0]
+		for _, container := range containers {
+			if _, ok := stackMap[container.ID]; ok {
+				stacked = append(stacked, container)
+			}
+		}
+		containers = stacked
+	}
+	for _, container := range containers {
+		if err := addNode(graph, container.ID, container); err != nil {
+			return nil, err
+		}
+	}
+	for _, container := range containers {
+		if _, ok := graph.Nodes[container.HostedOn]; ok {
+			graph.Edges = append(graph.Edges, db.RelationshipEdge{From: container.ID, To: container.HostedOn, Type: "hostedOn"})
+		}
+		for _, dep := range container.DependsOn {
+			if _, ok := graph.Nodes[dep]; ok {
+				graph.Edges = append(graph.Edges, db.RelationshipEdge{From: container.ID, To: dep, Type: "dependsOn"})
+			}
+		}
+	}
+
+	for _, builder := range s.graphBuilders {
+		if err := builder.BuildGraph(s, graph); err != nil {
+			return nil, err
+		}
+	}
+
+	return graph, nil
+}
+
+// addNode marshals a document into the graph's node set.
+func addNode(graph *db.RelationshipGraph, id string, doc interface{}) error {
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return fmt.Errorf("failed to encode graph node %s: %w", id, err)
+	}
+	graph.Nodes[id] = data
+	return nil
+}
+
+// customEntityGraphBuilder adds the entities of registered custom types to
+// the graph, with the edges their type's rules derive. Edges to nodes
+// missing from the graph are left out.
+type customEntityGraphBuilder struct{}
+
+func (customEntityGraphBuilder) BuildGraph(s *Storage, graph *db.RelationshipGraph) error {
+	types, err := s.ListCustomTypes()
+	if err != nil {
+		return err
+	}
+	if len(types) == 0 {
+		return nil
+	}
+
+	entities, err := s.ListCustomEntities("")
+	if err != nil {
+		return err
+	}
+
+	byName := make(map[string]int, len(types))
+	for i, ct := range types {
+		byName[ct.Name] = i
+	}
+
+	// Add all nodes first, so edges between custom entities resolve
+	var registered []int
+	for i, entity := range entities {
+		if _, ok := byName[entity.Type]; !ok {
+			continue
+		}
+		if err := addNode(graph, entity.ID, entity); err != nil {
+			return err
+		}
+		registered = append(registered, i)
+	}
+
+	for _, i := range registered {
+		entity := entities[i]
+		for _, edge := range types[byName[entity.Type]].EdgesOf(entity) {
+			if _, ok := graph.Nodes[edge.To]; ok {
+				graph.Edges = append(graph.Edges, db.RelationshipEdge{From: edge.From, To: edge.To, Type: edge.Relation})
+			}
+		}
+	}
+	return nil
+}
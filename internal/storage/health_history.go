@@ -0,0 +1,47 @@
+package storage
+
+import (
+	"fmt"
+	"time"
+
+	"eve.evalgo.org/db"
+
+	"evalgo.org/graphium/models"
+)
+
+// GetHealthHistory returns the health history of a container. A container
+// without recorded transitions gets an empty history.
+func (s *Storage) GetHealthHistory(containerID string) (*models.HealthHistory, error) {
+	var history models.HealthHistory
+	err := s.service.GetGenericDocument(models.HealthHistoryID(containerID), &history)
+	if couchErr, ok := err.(*db.CouchDBError); ok && couchErr.IsNotFound() {
+		return &models.HealthHistory{
+			Context:     "https://schema.org",
+			Type:        "HealthHistory",
+			ID:          models.HealthHistoryID(containerID),
+			ContainerID: containerID,
+		}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read health history: %w", err)
+	}
+	return &history, nil
+}
+
+// RecordHealthTransition appends a container's current status and health to
+// its health history if they changed since the last transition.
+func (s *Storage) RecordHealthTransition(container *models.Container, at time.Time) error {
+	history, err := s.GetHealthHistory(container.ID)
+	if err != nil {
+		return err
+	}
+
+	if !history.Record(container.Status, container.Health, at) {
+		return nil
+	}
+
+	if err := s.SaveDocument(history); err != nil {
+		return fmt.Errorf("failed to save health history: %w", err)
+	}
+	return nil
+}
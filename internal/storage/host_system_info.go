@@ -0,0 +1,41 @@
+package storage
+
+import (
+	"fmt"
+
+	"evalgo.org/graphium/models"
+)
+
+// SaveHostSystemInfo creates or replaces the system info of a host.
+func (s *Storage) SaveHostSystemInfo(info *models.HostSystemInfo) error {
+	if info.HostID == "" {
+		return fmt.Errorf("host ID is required")
+	}
+
+	info.Context = "https://schema.org"
+	info.Type = "HostSystemInfo"
+	info.ID = models.HostSystemInfoID(info.HostID)
+
+	// Replace the previous report, if any
+	info.Rev = ""
+	if existing, err := s.GetHostSystemInfo(info.HostID); err == nil {
+		info.Rev = existing.Rev
+	}
+
+	resp, err := s.service.SaveGenericDocument(info)
+	if err != nil {
+		return fmt.Errorf("failed to save host system info: %w", err)
+	}
+
+	info.Rev = resp.Rev
+	return nil
+}
+
+// GetHostSystemInfo retrieves the last system info reported for a host.
+func (s *Storage) GetHostSystemInfo(hostID string) (*models.HostSystemInfo, error) {
+	var info models.HostSystemInfo
+	if err := s.service.GetGenericDocument(models.HostSystemInfoID(hostID), &info); err != nil {
+		return nil, fmt.Errorf("host system info not found: %w", err)
+	}
+	return &info, nil
+}
@@ -0,0 +1,310 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// indexWarningInterval limits how often a missing-index warning is logged per index.
+const indexWarningInterval = 5 * time.Minute
+
+// IndexStatus reports the health of a single index or view.
+type IndexStatus struct {
+	// Name is the index name or view name
+	Name string `json:"name"`
+
+	// Kind is "index" (Mango) or "view" (MapReduce)
+	Kind string `json:"kind"`
+
+	// Present is true if the index or view exists in the database
+	Present bool `json:"present"`
+
+	// Queryable is true if a query could actually use the index or view
+	Queryable bool `json:"queryable"`
+
+	// Error describes why the index or view is not healthy
+	Error string `json:"error,omitempty"`
+}
+
+// IndexReport is the result of verifying all required indexes and views.
+type IndexReport struct {
+	CheckedAt time.Time     `json:"checkedAt"`
+	Healthy   bool          `json:"healthy"`
+	Indexes   []IndexStatus `json:"indexes"`
+}
+
+// Missing returns the names of indexes and views that are not healthy.
+func (r *IndexReport) Missing() []string {
+	var missing []string
+	for _, status := range r.Indexes {
+		if !status.Present || !status.Queryable {
+			missing = append(missing, status.Name)
+		}
+	}
+	return missing
+}
+
+// indexState tracks the last verification result and rate-limits warnings.
+type indexState struct {
+	mu     sync.Mutex
+	report *IndexReport
+	warned map[string]time.Time
+}
+
+// VerifyIndexes checks that every required index and view exists and can be queried.
+// The result is kept for LastIndexReport and for missing-index warnings on queries.
+func (s *Storage) VerifyIndexes(ctx context.Context) (*IndexReport, error) {
+	existing, err := s.listMangoIndexes(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list indexes: %w", err)
+	}
+
+	report := &IndexReport{CheckedAt: time.Now(), Healthy: true}
+
+	for _, index := range requiredIndexes {
+		status := IndexStatus{Name: index.Name, Kind: "index"}
+		if ddoc, ok := existing[index.Name]; ok {
+			status.Present = true
+			if err := s.explainWithIndex(ctx, ddoc, index.Name, index.Fields); err != nil {
+				status.Error = err.Error()
+			} else {
+				status.Queryable = true
+			}
+		} else {
+			status.Error = "index does not exist"
+		}
+		report.Indexes = append(report.Indexes, status)
+	}
+
+	design := graphiumDesignDoc()
+	viewNames := make([]string, 0, len(design.Views))
+	for name := range design.Views {
+		viewNames = append(viewNames, name)
+	}
+	sort.Strings(viewNames)
+
+	designName := strings.TrimPrefix(design.ID, "_design/")
+	for _, name := range viewNames {
+		status := IndexStatus{Name: name, Kind: "view"}
+		code, err := s.couchRequest(ctx, http.MethodGet,
+			fmt.Sprintf("_design/%s/_view/%s?limit=0", url.PathEscape(designName), url.PathEscape(name)), nil, nil)
+		switch {
+		case err == nil:
+			status.Present = true
+			status.Queryable = true
+		case code == http.StatusNotFound:
+			status.Error = "view does not exist"
+		default:
+			status.Present = code != 0
+			status.Error = err.Error()
+		}
+		report.Indexes = append(report.Indexes, status)
+	}
+
+	report.Healthy = len(report.Missing()) == 0
+
+	s.indexes.mu.Lock()
+	s.indexes.report = report
+	s.indexes.mu.Unlock()
+
+	return report, nil
+}
+
+// RebuildIndexes recreates missing indexes and views and verifies them again.
+func (s *Storage) RebuildIndexes(ctx context.Context) (*IndexReport, error) {
+	report, err := s.VerifyIndexes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	missing := make(map[string]bool)
+	for _, name := range report.Missing() {
+		missing[name] = true
+	}
+
+	rebuildViews := false
+	for _, status := range report.Indexes {
+		if status.Kind == "view" && missing[status.Name] {
+			rebuildViews = true
+		}
+	}
+
+	for _, index := range requiredIndexes {
+		if !missing[index.Name] {
+			continue
+		}
+		if err := s.service.CreateIndex(index); err != nil {
+			return nil, fmt.Errorf("failed to create index %s: %w", index.Name, err)
+		}
+		log.Printf("Recreated index %s", index.Name)
+	}
+
+	if rebuildViews {
+		if err := s.createViews(); err != nil {
+			return nil, fmt.Errorf("failed to recreate views: %w", err)
+		}
+		log.Printf("Recreated design document %s", graphiumDesignDoc().ID)
+	}
+
+	return s.VerifyIndexes(ctx)
+}
+
+// LastIndexReport returns the result of the most recent verification, or nil.
+func (s *Storage) LastIndexReport() *IndexReport {
+	s.indexes.mu.Lock()
+	defer s.indexes.mu.Unlock()
+	return s.indexes.report
+}
+
+// verifyIndexesAfterInit runs the post-initialization check and logs every
+// index or view that is missing.
+func (s *Storage) verifyIndexesAfterInit() {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	report, err := s.VerifyIndexes(ctx)
+	if err != nil {
+		log.Printf("WARNING: could not verify database indexes: %v", err)
+		return
+	}
+	for _, status := range report.Indexes {
+		if !status.Present || !status.Queryable {
+			log.Printf("WARNING: %s %s is not usable (%s); queries relying on it will be slow or fail. Rebuild with POST /api/v1/admin/indexes/rebuild",
+				status.Kind, status.Name, status.Error)
+		}
+	}
+}
+
+// warnIfUnindexed logs a warning when a query runs while its supporting index
+// is known to be missing. Warnings are rate-limited per index.
+func (s *Storage) warnIfUnindexed(indexName, query string) {
+	s.indexes.mu.Lock()
+	defer s.indexes.mu.Unlock()
+
+	if s.indexes.report == nil {
+		return
+	}
+
+	healthy := false
+	for _, status := range s.indexes.report.Indexes {
+		if status.Name == indexName {
+			healthy = status.Present && status.Queryable
+			break
+		}
+	}
+	if healthy {
+		return
+	}
+
+	if s.indexes.warned == nil {
+		s.indexes.warned = make(map[string]time.Time)
+	}
+	if last, ok := s.indexes.warned[indexName]; ok && time.Since(last) < indexWarningInterval {
+		return
+	}
+	s.indexes.warned[indexName] = time.Now()
+
+	log.Printf("WARNING: %s is running without index %s (full database scan). Rebuild with POST /api/v1/admin/indexes/rebuild", query, indexName)
+}
+
+// listMangoIndexes returns the design document of each Mango index, keyed by index name.
+func (s *Storage) listMangoIndexes(ctx context.Context) (map[string]string, error) {
+	var response struct {
+		Indexes []struct {
+			DDoc string `json:"ddoc"`
+			Name string `json:"name"`
+		} `json:"indexes"`
+	}
+	if _, err := s.couchRequest(ctx, http.MethodGet, "_index", nil, &response); err != nil {
+		return nil, err
+	}
+
+	indexes := make(map[string]string, len(response.Indexes))
+	for _, index := range response.Indexes {
+		indexes[index.Name] = index.DDoc
+	}
+	return indexes, nil
+}
+
+// explainWithIndex asks CouchDB to plan a query on fields using the given index
+// and confirms that the index is the one selected.
+func (s *Storage) explainWithIndex(ctx context.Context, ddoc, name string, fields []string) error {
+	selector := make(map[string]interface{}, len(fields))
+	for _, field := range fields {
+		selector[field] = map[string]interface{}{"$exists": true}
+	}
+
+	body := map[string]interface{}{
+		"selector":  selector,
+		"use_index": []string{strings.TrimPrefix(ddoc, "_design/"), name},
+	}
+
+	var response struct {
+		Index struct {
+			Name string `json:"name"`
+		} `json:"index"`
+	}
+	if _, err := s.couchRequest(ctx, http.MethodPost, "_explain", body, &response); err != nil {
+		return err
+	}
+	if response.Index.Name != name {
+		return fmt.Errorf("query planner selected %q instead", response.Index.Name)
+	}
+	return nil
+}
+
+// couchRequest performs a request against the Graphium database and decodes
+// the JSON response into out. It returns the HTTP status code (0 if the
+// request could not be sent).
+func (s *Storage) couchRequest(ctx context.Context, method, path string, body, out interface{}) (int, error) {
+	endpoint := fmt.Sprintf("%s/%s/%s", strings.TrimSuffix(s.config.CouchDB.URL, "/"),
+		url.PathEscape(s.config.CouchDB.Database), path)
+
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return 0, err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, reader)
+	if err != nil {
+		return 0, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if s.config.CouchDB.Username != "" {
+		req.SetBasicAuth(s.config.CouchDB.Username, s.config.CouchDB.Password)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return resp.StatusCode, fmt.Errorf("CouchDB returned %s: %s", resp.Status, strings.TrimSpace(string(data)))
+	}
+
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return resp.StatusCode, fmt.Errorf("invalid CouchDB response: %w", err)
+		}
+	}
+	return resp.StatusCode, nil
+}
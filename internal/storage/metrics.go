@@ -0,0 +1,84 @@
+package storage
+
+import (
+	"eve.evalgo.org/db"
+)
+
+// EntityStatusCounts are the numbers of stored entities by status.
+type EntityStatusCounts struct {
+	Containers  map[string]int
+	Hosts       map[string]int
+	Stacks      map[string]int
+	Tasks       map[string]int
+	Deployments map[string]int
+}
+
+// statusDoc is the projection of status count queries.
+type statusDoc struct {
+	ID           string `json:"@id"`
+	Status       string `json:"status"`
+	ActionStatus string `json:"actionStatus"`
+}
+
+// GetEntityStatusCounts counts containers, hosts, stacks, agent tasks and
+// deployments by status. Only IDs and statuses are fetched; duplicate
+// documents of a container count once.
+func (s *Storage) GetEntityStatusCounts() (*EntityStatusCounts, error) {
+	counts := &EntityStatusCounts{}
+	var err error
+
+	if counts.Containers, err = s.countByStatus(containerSelector(nil), "status"); err != nil {
+		return nil, err
+	}
+	if counts.Hosts, err = s.countByStatus(hostSelector(nil), "status"); err != nil {
+		return nil, err
+	}
+	if counts.Stacks, err = s.countByStatus(map[string]interface{}{"@type": "ItemList"}, "status"); err != nil {
+		return nil, err
+	}
+	// Tasks have an Action @type of their kind (ControlAction, CheckAction,
+	// ...); agent tasks are the actions targeting a host
+	tasks := map[string]interface{}{
+		"actionStatus": map[string]interface{}{"$exists": true},
+		"hostId":       map[string]interface{}{"$exists": true},
+	}
+	if counts.Tasks, err = s.countByStatus(tasks, "actionStatus"); err != nil {
+		return nil, err
+	}
+	if counts.Deployments, err = s.countByStatus(map[string]interface{}{"@type": "DeploymentState"}, "status"); err != nil {
+		return nil, err
+	}
+	return counts, nil
+}
+
+// countByStatus counts the documents matching selector by the value of
+// field (status or actionStatus). Documents sharing an @id count once.
+func (s *Storage) countByStatus(selector map[string]interface{}, field string) (map[string]int, error) {
+	docs, err := db.FindTyped[statusDoc](s.service, db.MangoQuery{
+		Selector: selector,
+		Fields:   []string{"@id", field},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool, len(docs))
+	counts := make(map[string]int)
+	for _, doc := range docs {
+		if doc.ID != "" {
+			if seen[doc.ID] {
+				continue
+			}
+			seen[doc.ID] = true
+		}
+		status := doc.Status
+		if field == "actionStatus" {
+			status = doc.ActionStatus
+		}
+		if status == "" {
+			status = "unknown"
+		}
+		counts[status]++
+	}
+	return counts, nil
+}
@@ -0,0 +1,36 @@
+package storage
+
+import (
+	"fmt"
+
+	"evalgo.org/graphium/models"
+)
+
+// FindOrphanedContainers returns the containers no stack lists, that have
+// no existing host, or that a stack lists but its current deployment
+// didn't place, categorized.
+func (s *Storage) FindOrphanedContainers() (*models.OrphanReport, error) {
+	containers, err := s.ListContainers(nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list containers: %w", err)
+	}
+	hosts, err := s.ListHosts(nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list hosts: %w", err)
+	}
+	stacks, err := s.ListStacks(nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list stacks: %w", err)
+	}
+
+	return models.FindOrphans(containers, hostIDSet(hosts), stacks), nil
+}
+
+// hostIDSet returns the IDs of hosts as a set.
+func hostIDSet(hosts []*models.Host) map[string]bool {
+	ids := make(map[string]bool, len(hosts))
+	for _, host := range hosts {
+		ids[host.ID] = true
+	}
+	return ids
+}
@@ -0,0 +1,138 @@
+package storage
+
+import (
+	"eve.evalgo.org/db"
+
+	"evalgo.org/graphium/models"
+)
+
+// hostTypes are the @type values of host documents.
+var hostTypes = []string{"ComputerServer", "ComputerSystem"}
+
+// idOnly is the projection of count queries.
+type idOnly struct {
+	ID string `json:"@id"`
+}
+
+// listSelector builds a Mango selector from list filters. A []string value
+// matches any of its entries; other values must be equal.
+func listSelector(typeSelector interface{}, filters map[string]interface{}) map[string]interface{} {
+	selector := map[string]interface{}{"@type": typeSelector}
+	for field, value := range filters {
+		if values, ok := value.([]string); ok {
+			selector[field] = map[string]interface{}{"$in": values}
+		} else {
+			selector[field] = map[string]interface{}{"$eq": value}
+		}
+	}
+	return selector
+}
+
+// containerSelector selects the containers matching filters.
+func containerSelector(filters map[string]interface{}) map[string]interface{} {
+	return listSelector(map[string]interface{}{"$eq": "SoftwareApplication"}, filters)
+}
+
+// hostSelector selects the hosts matching filters.
+func hostSelector(filters map[string]interface{}) map[string]interface{} {
+	return listSelector(map[string]interface{}{"$in": hostTypes}, filters)
+}
+
+// ListContainersPage returns limit containers matching filters, after
+// skipping skip, in the given order. It also returns the number of matching
+// containers. A []string filter value matches any of its entries.
+//
+// Only the IDs and sort field of all matches are fetched; they are
+// deduplicated (duplicate documents of a container count once), sorted and
+// sliced before the page's documents are fetched by ID.
+func (s *Storage) ListContainersPage(filters map[string]interface{}, order ListSort, limit, skip int) ([]*models.Container, int, error) {
+	return s.containersPage(containerSelector(filters), order, limit, skip)
+}
+
+// containersPage returns a page of the containers matching selector and the
+// number of matching containers.
+func (s *Storage) containersPage(selector map[string]interface{}, order ListSort, limit, skip int) ([]*models.Container, int, error) {
+	keys, err := s.findSortKeys(selector, order)
+	if err != nil {
+		return nil, 0, err
+	}
+	ids, total := pageIDs(keys, order, limit, skip)
+	containers, err := findPage(s, selector, ids, func(c *models.Container) string { return c.ID })
+	if err != nil {
+		return nil, 0, err
+	}
+	return containers, total, nil
+}
+
+// ListHostsPage returns limit hosts matching filters, after skipping skip,
+// in the given order. It also returns the number of matching hosts. Like
+// ListContainersPage, only the page's documents are fetched in full.
+func (s *Storage) ListHostsPage(filters map[string]interface{}, order ListSort, limit, skip int) ([]*models.Host, int, error) {
+	return s.hostsPage(hostSelector(filters), order, limit, skip)
+}
+
+// hostsPage returns a page of the hosts matching selector and the number of
+// matching hosts.
+func (s *Storage) hostsPage(selector map[string]interface{}, order ListSort, limit, skip int) ([]*models.Host, int, error) {
+	keys, err := s.findSortKeys(selector, order)
+	if err != nil {
+		return nil, 0, err
+	}
+	ids, total := pageIDs(keys, order, limit, skip)
+	hosts, err := findPage(s, selector, ids, func(h *models.Host) string { return h.ID })
+	if err != nil {
+		return nil, 0, err
+	}
+	return hosts, total, nil
+}
+
+// CountContainers returns the number of containers matching the given
+// filters. Only IDs are fetched, and duplicate documents of a container count
+// once.
+func (s *Storage) CountContainers(filters map[string]interface{}) (int, error) {
+	return s.countContainers(containerSelector(filters))
+}
+
+// countContainers returns the number of distinct containers matching selector.
+func (s *Storage) countContainers(selector map[string]interface{}) (int, error) {
+	docs, err := db.FindTyped[idOnly](s.service, db.MangoQuery{
+		Selector: selector,
+		Fields:   []string{"@id"},
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	ids := make(map[string]struct{}, len(docs))
+	for _, doc := range docs {
+		ids[doc.ID] = struct{}{}
+	}
+	return len(ids), nil
+}
+
+// CountHosts returns the number of hosts matching the given filters. Only
+// IDs are fetched.
+func (s *Storage) CountHosts(filters map[string]interface{}) (int, error) {
+	ids, err := s.findHostIDs(hostSelector(filters))
+	if err != nil {
+		return 0, err
+	}
+	return len(ids), nil
+}
+
+// findHostIDs returns the IDs of the hosts matching selector.
+func (s *Storage) findHostIDs(selector map[string]interface{}) ([]string, error) {
+	docs, err := db.FindTyped[idOnly](s.service, db.MangoQuery{
+		Selector: selector,
+		Fields:   []string{"@id"},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, len(docs))
+	for i, doc := range docs {
+		ids[i] = doc.ID
+	}
+	return ids, nil
+}
@@ -17,14 +17,14 @@ func (s *Storage) CreateScheduledAction(action *models.ScheduledAction) error {
 	if action.Name == "" {
 		return fmt.Errorf("action name is required")
 	}
-	if action.Agent == "" {
+	if action.Agent == "" && action.Type != models.ActionTypeScale {
 		return fmt.Errorf("agent (host ID) is required")
 	}
-	if action.Schedule == nil {
-		return fmt.Errorf("schedule is required")
+	if action.Schedule == nil && action.DependsOn == "" {
+		return fmt.Errorf("schedule is required unless the action depends on another")
 	}
-	if action.Schedule.RepeatFrequency == "" {
-		return fmt.Errorf("schedule repeat frequency is required")
+	if action.Schedule != nil && action.Schedule.RepeatFrequency == "" && action.Schedule.CronExpression == "" {
+		return fmt.Errorf("schedule repeat frequency or cron expression is required")
 	}
 
 	// Set defaults
@@ -37,10 +37,10 @@ func (s *Storage) CreateScheduledAction(action *models.ScheduledAction) error {
 	if action.ActionStatus == "" {
 		action.ActionStatus = models.ActionStatusPotential
 	}
-	if action.Schedule.Type == "" {
+	if action.Schedule != nil && action.Schedule.Type == "" {
 		action.Schedule.Type = "Schedule"
 	}
-	if action.Schedule.ScheduleTimezone == "" {
+	if action.Schedule != nil && action.Schedule.ScheduleTimezone == "" {
 		action.Schedule.ScheduleTimezone = "UTC"
 	}
 
@@ -77,31 +77,8 @@ func (s *Storage) GetScheduledAction(id string) (*models.ScheduledAction, error)
 func (s *Storage) ListScheduledActions(filters map[string]interface{}) ([]*models.ScheduledAction, error) {
 	s.debugLog("Listing scheduled actions with filters: %+v\n", filters)
 
-	// Build query using direct MangoQuery since QueryBuilder doesn't handle $in properly
-	selector := map[string]interface{}{
-		"@type": map[string]interface{}{
-			"$in": []string{"Action", "CreateAction", "UpdateAction", "CheckAction", "ControlAction", "TransferAction"},
-		},
-	}
-
-	// Add filters
-	if filters != nil {
-		if actionType, ok := filters["@type"].(string); ok && actionType != "" {
-			selector["@type"] = actionType // Override with specific type
-		}
-		if enabled, ok := filters["enabled"].(bool); ok {
-			selector["enabled"] = enabled
-		}
-		if agent, ok := filters["agent"].(string); ok && agent != "" {
-			selector["agent"] = agent
-		}
-		if actionStatus, ok := filters["actionStatus"].(string); ok && actionStatus != "" {
-			selector["actionStatus"] = actionStatus
-		}
-	}
-
 	query := db.MangoQuery{
-		Selector: selector,
+		Selector: scheduledActionSelector(filters),
 	}
 	s.debugLog("ListScheduledActions query: %+v\n", query)
 
@@ -194,9 +171,50 @@ func (s *Storage) GetActiveScheduledActions() ([]*models.ScheduledAction, error)
 	})
 }
 
+// GetDependentScheduledActions returns the actions whose dependsOn is actionID
+func (s *Storage) GetDependentScheduledActions(actionID string) ([]*models.ScheduledAction, error) {
+	return s.ListScheduledActions(map[string]interface{}{
+		"dependsOn": actionID,
+	})
+}
+
 // GetScheduledActionsByType returns all scheduled actions of a specific type
 func (s *Storage) GetScheduledActionsByType(actionType string) ([]*models.ScheduledAction, error) {
 	return s.ListScheduledActions(map[string]interface{}{
 		"@type": actionType,
 	})
 }
+
+// scheduledActionSelector builds the Mango selector of ListScheduledActions.
+// A direct selector is used since QueryBuilder doesn't handle $in properly.
+func scheduledActionSelector(filters map[string]interface{}) map[string]interface{} {
+	selector := map[string]interface{}{
+		"@type": map[string]interface{}{
+			"$in": []string{"Action", "CreateAction", "UpdateAction", "CheckAction", "ControlAction", "TransferAction", "ScaleAction"},
+		},
+		// Container change entries were stored as UpdateAction before they
+		// got their own @type; they carry a containerId, actions don't
+		"containerId": map[string]interface{}{"$exists": false},
+	}
+
+	// Add filters
+	if filters != nil {
+		if actionType, ok := filters["@type"].(string); ok && actionType != "" {
+			selector["@type"] = actionType // Override with specific type
+		}
+		if enabled, ok := filters["enabled"].(bool); ok {
+			selector["enabled"] = enabled
+		}
+		if agent, ok := filters["agent"].(string); ok && agent != "" {
+			selector["agent"] = agent
+		}
+		if actionStatus, ok := filters["actionStatus"].(string); ok && actionStatus != "" {
+			selector["actionStatus"] = actionStatus
+		}
+		if dependsOn, ok := filters["dependsOn"].(string); ok && dependsOn != "" {
+			selector["dependsOn"] = dependsOn
+		}
+	}
+
+	return selector
+}
@@ -0,0 +1,102 @@
+package storage
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"evalgo.org/graphium/models"
+)
+
+// matchesSelector evaluates the subset of Mango used by
+// scheduledActionSelector ($in, $exists and equality) against a document.
+func matchesSelector(t *testing.T, doc interface{}, selector map[string]interface{}) bool {
+	t.Helper()
+	data, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+
+	for field, cond := range selector {
+		switch field {
+		case "$and", "$or":
+			matched := 0
+			for _, clause := range cond.([]map[string]interface{}) {
+				if matchesSelector(t, doc, clause) {
+					matched++
+				}
+			}
+			if field == "$and" && matched < len(cond.([]map[string]interface{})) || field == "$or" && matched == 0 {
+				return false
+			}
+			continue
+		}
+		value, present := fields[field]
+		ops, ok := cond.(map[string]interface{})
+		if !ok {
+			if !present || !reflect.DeepEqual(value, cond) {
+				return false
+			}
+			continue
+		}
+		for op, arg := range ops {
+			switch op {
+			case "$in":
+				found := false
+				for _, candidate := range arg.([]string) {
+					found = found || value == candidate
+				}
+				if !found {
+					return false
+				}
+			case "$eq":
+				if value != arg {
+					return false
+				}
+			case "$gt":
+				if s, ok := value.(string); !ok || s <= arg.(string) {
+					return false
+				}
+			case "$exists":
+				if present != arg.(bool) {
+					return false
+				}
+			default:
+				t.Fatalf("Unsupported operator %s", op)
+			}
+		}
+	}
+	return true
+}
+
+func TestScheduledActionSelector_ExcludesContainerChanges(t *testing.T) {
+	selector := scheduledActionSelector(nil)
+
+	action := models.NewScheduledAction(models.ActionTypeUpdate, "update config", "host-1", nil)
+	if !matchesSelector(t, action, selector) {
+		t.Error("Expected an UpdateAction to be listed")
+	}
+
+	entry := &models.ContainerChangeEntry{
+		Type:        models.ContainerChangeEntryType,
+		ID:          "change-1",
+		ContainerID: "web-1",
+	}
+	if matchesSelector(t, entry, selector) {
+		t.Error("Expected container change entries not to be listed as actions")
+	}
+
+	entry.Type = models.LegacyContainerChangeEntryType
+	if matchesSelector(t, entry, selector) {
+		t.Error("Expected legacy container change entries not to be listed as actions")
+	}
+
+	filtered := scheduledActionSelector(map[string]interface{}{"@type": models.ActionTypeUpdate})
+	if matchesSelector(t, entry, filtered) {
+		t.Error("Expected legacy container change entries not to match an UpdateAction filter")
+	}
+}
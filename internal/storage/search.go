@@ -0,0 +1,77 @@
+package storage
+
+import (
+	"regexp"
+	"strings"
+
+	"evalgo.org/graphium/models"
+)
+
+// searchPattern returns a case-insensitive CouchDB $regex matching query as
+// a literal substring.
+func searchPattern(query string) string {
+	return "(?i)" + regexp.QuoteMeta(strings.TrimSpace(query))
+}
+
+// withSearch adds an $or clause to selector matching pattern against any of
+// fields, plus any extra clauses.
+func withSearch(selector map[string]interface{}, pattern string, fields []string, extra ...map[string]interface{}) map[string]interface{} {
+	clauses := make([]map[string]interface{}, 0, len(fields)+len(extra))
+	for _, field := range fields {
+		clauses = append(clauses, map[string]interface{}{
+			field: map[string]interface{}{"$regex": pattern},
+		})
+	}
+	clauses = append(clauses, extra...)
+	selector["$or"] = clauses
+	return selector
+}
+
+// SearchContainers returns limit containers matching filters whose name, ID
+// or image contains query, or whose host's datacenter does, after skipping
+// skip, in the given order. Matching is case-insensitive. Like
+// ListContainersPage, the number of matches is returned too.
+func (s *Storage) SearchContainers(query string, filters map[string]interface{}, order ListSort, limit, skip int) ([]*models.Container, int, error) {
+	selector, err := s.containerSearchSelector(query, filters)
+	if err != nil {
+		return nil, 0, err
+	}
+	return s.containersPage(selector, order, limit, skip)
+}
+
+// containerSearchSelector selects the containers matching filters whose
+// name, ID or image contains query, or whose host's datacenter does.
+func (s *Storage) containerSearchSelector(query string, filters map[string]interface{}) (map[string]interface{}, error) {
+	pattern := searchPattern(query)
+
+	// Containers carry no datacenter; match the hosts of matching datacenters
+	hosts, err := s.findHostIDs(map[string]interface{}{
+		"@type":    map[string]interface{}{"$in": hostTypes},
+		"location": map[string]interface{}{"$regex": pattern},
+	})
+	if err != nil {
+		return nil, err
+	}
+	var extra []map[string]interface{}
+	if len(hosts) > 0 {
+		extra = append(extra, map[string]interface{}{
+			"hostedOn": map[string]interface{}{"$in": hosts},
+		})
+	}
+
+	return withSearch(containerSelector(filters), pattern, []string{"name", "@id", "executableName"}, extra...), nil
+}
+
+// SearchHosts returns limit hosts matching filters whose name, ID, IP
+// address or datacenter contains query, after skipping skip, in the given
+// order. Matching is case-insensitive. Like ListHostsPage, the number of
+// matches is returned too.
+func (s *Storage) SearchHosts(query string, filters map[string]interface{}, order ListSort, limit, skip int) ([]*models.Host, int, error) {
+	return s.hostsPage(hostSearchSelector(query, filters), order, limit, skip)
+}
+
+// hostSearchSelector selects the hosts matching filters whose name, ID, IP
+// address or datacenter contains query.
+func hostSearchSelector(query string, filters map[string]interface{}) map[string]interface{} {
+	return withSearch(hostSelector(filters), searchPattern(query), []string{"name", "@id", "ipAddress", "location"})
+}
@@ -0,0 +1,113 @@
+package storage
+
+import (
+	"fmt"
+	"time"
+
+	"eve.evalgo.org/db"
+
+	"evalgo.org/graphium/models"
+)
+
+// CreateShareLink stores a new share link.
+func (s *Storage) CreateShareLink(link *models.ShareLink) error {
+	if link.ExpiresAt.IsZero() {
+		return fmt.Errorf("share link expiration is required")
+	}
+
+	if link.Context == "" {
+		link.Context = "https://schema.org"
+	}
+	if link.Type == "" {
+		link.Type = "ShareLink"
+	}
+	if link.ID == "" {
+		link.ID = models.GenerateID("sharelink")
+	}
+	if link.CreatedAt.IsZero() {
+		link.CreatedAt = time.Now()
+	}
+
+	resp, err := s.service.SaveGenericDocument(link)
+	if err != nil {
+		return fmt.Errorf("failed to create share link: %w", err)
+	}
+
+	link.Rev = resp.Rev
+	return nil
+}
+
+// GetShareLink retrieves a share link by ID.
+func (s *Storage) GetShareLink(id string) (*models.ShareLink, error) {
+	var link models.ShareLink
+	if err := s.service.GetGenericDocument(id, &link); err != nil {
+		return nil, fmt.Errorf("failed to read share link: %w", err)
+	}
+	return &link, nil
+}
+
+// ListShareLinks returns all share links, including revoked and expired ones.
+func (s *Storage) ListShareLinks() ([]*models.ShareLink, error) {
+	query := db.NewQueryBuilder().
+		Where("@type", "$eq", "ShareLink").
+		Build()
+
+	links, err := db.FindTyped[models.ShareLink](s.service, query)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*models.ShareLink, len(links))
+	for i := range links {
+		result[i] = &links[i]
+	}
+
+	return result, nil
+}
+
+// RevokeShareLink marks a share link as revoked. Tokens minted for the link
+// are rejected from then on.
+func (s *Storage) RevokeShareLink(id string) (*models.ShareLink, error) {
+	link, err := s.GetShareLink(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if link.Revoked {
+		return link, nil
+	}
+
+	now := time.Now()
+	link.Revoked = true
+	link.RevokedAt = &now
+
+	resp, err := s.service.SaveGenericDocument(link)
+	if err != nil {
+		return nil, fmt.Errorf("failed to revoke share link: %w", err)
+	}
+
+	link.Rev = resp.Rev
+	return link, nil
+}
+
+// DeleteExpiredShareLinks removes share links that expired before the given time.
+// Returns the number of deleted links.
+func (s *Storage) DeleteExpiredShareLinks(before time.Time) (int, error) {
+	links, err := s.ListShareLinks()
+	if err != nil {
+		return 0, err
+	}
+
+	deleted := 0
+	for _, link := range links {
+		if link.ExpiresAt.Before(before) {
+			if err := s.service.DeleteDocument(link.ID, link.Rev); err != nil {
+				s.debugLog("Failed to delete expired share link %s: %v\n", link.ID, err)
+				continue
+			}
+			deleted++
+		}
+	}
+
+	return deleted, nil
+}
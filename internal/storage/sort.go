@@ -0,0 +1,155 @@
+package storage
+
+import (
+	"sort"
+
+	"eve.evalgo.org/db"
+
+	"evalgo.org/graphium/models"
+)
+
+// ListSort orders list results by a stored field. Field is the JSON name of
+// the field (name, status, hostedOn, location or dateCreated); ties are
+// ordered by ID, so the order is stable across requests.
+type ListSort struct {
+	Field string
+	Desc  bool
+}
+
+// DefaultListSort orders lists by name, ascending.
+var DefaultListSort = ListSort{Field: "name"}
+
+// sortKey is the projection of the fields lists can be sorted by.
+type sortKey struct {
+	ID          string `json:"@id"`
+	Name        string `json:"name"`
+	Status      string `json:"status"`
+	HostedOn    string `json:"hostedOn"`
+	Location    string `json:"location"`
+	DateCreated string `json:"dateCreated"`
+}
+
+// value returns the value of the sort field.
+func (k sortKey) value(field string) string {
+	switch field {
+	case "status":
+		return k.Status
+	case "hostedOn":
+		return k.HostedOn
+	case "location":
+		return k.Location
+	case "dateCreated":
+		return k.DateCreated
+	default:
+		return k.Name
+	}
+}
+
+// less reports whether a sorts before b by order, then by ID.
+func (order ListSort) less(a, b sortKey) bool {
+	av, bv := a.value(order.Field), b.value(order.Field)
+	if av != bv {
+		if order.Desc {
+			return av > bv
+		}
+		return av < bv
+	}
+	return a.ID < b.ID
+}
+
+// pageIDs sorts keys by order, drops duplicate documents of an ID (the
+// last one wins) and returns the IDs of the page after skip, at most limit
+// of them, and the number of distinct IDs.
+func pageIDs(keys []sortKey, order ListSort, limit, skip int) ([]string, int) {
+	index := make(map[string]int, len(keys))
+	distinct := make([]sortKey, 0, len(keys))
+	for _, key := range keys {
+		if i, ok := index[key.ID]; ok {
+			distinct[i] = key
+			continue
+		}
+		index[key.ID] = len(distinct)
+		distinct = append(distinct, key)
+	}
+	sort.SliceStable(distinct, func(i, j int) bool {
+		return order.less(distinct[i], distinct[j])
+	})
+
+	if skip >= len(distinct) {
+		return []string{}, len(distinct)
+	}
+	end := len(distinct)
+	if limit > 0 && skip+limit < end {
+		end = skip + limit
+	}
+	ids := make([]string, 0, end-skip)
+	for _, key := range distinct[skip:end] {
+		ids = append(ids, key.ID)
+	}
+	return ids, len(distinct)
+}
+
+// findSortKeys returns the sort keys of the documents matching selector.
+func (s *Storage) findSortKeys(selector map[string]interface{}, order ListSort) ([]sortKey, error) {
+	fields := []string{"@id"}
+	if order.Field != "" {
+		fields = append(fields, order.Field)
+	}
+	return db.FindTyped[sortKey](s.service, db.MangoQuery{
+		Selector: selector,
+		Fields:   fields,
+	})
+}
+
+// findPage returns the documents matching selector with the given IDs, in
+// the order of ids. Of duplicate documents of an ID, the last one wins.
+func findPage[T any](s *Storage, selector map[string]interface{}, ids []string, idOf func(*T) string) ([]*T, error) {
+	if len(ids) == 0 {
+		return []*T{}, nil
+	}
+
+	pageSelector := make(map[string]interface{}, len(selector)+1)
+	for field, value := range selector {
+		pageSelector[field] = value
+	}
+	pageSelector["@id"] = map[string]interface{}{"$in": ids}
+
+	docs, err := db.FindTyped[T](s.service, db.MangoQuery{Selector: pageSelector})
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[string]*T, len(docs))
+	for i := range docs {
+		byID[idOf(&docs[i])] = &docs[i]
+	}
+	result := make([]*T, 0, len(ids))
+	for _, id := range ids {
+		// A document may change between the two queries
+		if doc, ok := byID[id]; ok {
+			result = append(result, doc)
+		}
+	}
+	return result, nil
+}
+
+// stackTimeLayout formats stack creation times with a fixed width, so they
+// sort as strings.
+const stackTimeLayout = "2006-01-02T15:04:05.000000000Z"
+
+// SortStacks stably sorts stacks by order (name, status, location or
+// dateCreated), then by ID.
+func SortStacks(stacks []*models.Stack, order ListSort) {
+	key := func(stack *models.Stack) sortKey {
+		return sortKey{
+			ID:          stack.ID,
+			Name:        stack.Name,
+			Status:      stack.Status,
+			Location:    stack.Datacenter,
+			DateCreated: stack.CreatedAt.UTC().Format(stackTimeLayout),
+		}
+	}
+	sort.SliceStable(stacks, func(i, j int) bool {
+		return order.less(key(stacks[i]), key(stacks[j]))
+	})
+}
@@ -0,0 +1,146 @@
+package storage
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"evalgo.org/graphium/models"
+)
+
+func TestPageIDs(t *testing.T) {
+	keys := []sortKey{
+		{ID: "c3", Name: "web", Status: "running"},
+		{ID: "c1", Name: "db", Status: "exited"},
+		{ID: "c2", Name: "web", Status: "running"},
+		{ID: "c4", Name: "cache", Status: "running"},
+		// Duplicate document of c1; the last one wins
+		{ID: "c1", Name: "api", Status: "exited"},
+	}
+
+	tests := []struct {
+		name      string
+		order     ListSort
+		limit     int
+		skip      int
+		wantIDs   []string
+		wantTotal int
+	}{
+		{"name ascending, ties by ID", DefaultListSort, 10, 0, []string{"c1", "c4", "c2", "c3"}, 4},
+		{"name descending", ListSort{Field: "name", Desc: true}, 10, 0, []string{"c2", "c3", "c4", "c1"}, 4},
+		{"status", ListSort{Field: "status"}, 10, 0, []string{"c1", "c2", "c3", "c4"}, 4},
+		{"second page", DefaultListSort, 2, 2, []string{"c2", "c3"}, 4},
+		{"past the end", DefaultListSort, 2, 4, []string{}, 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ids, total := pageIDs(keys, tt.order, tt.limit, tt.skip)
+			if !reflect.DeepEqual(ids, tt.wantIDs) || total != tt.wantTotal {
+				t.Errorf("pageIDs() = %v, %d; want %v, %d", ids, total, tt.wantIDs, tt.wantTotal)
+			}
+		})
+	}
+}
+
+func TestSortStacks(t *testing.T) {
+	now := time.Now()
+	stacks := []*models.Stack{
+		{ID: "s1", Name: "b", CreatedAt: now},
+		{ID: "s2", Name: "a", CreatedAt: now.Add(time.Hour)},
+		{ID: "s3", Name: "c", CreatedAt: now.Add(-time.Hour)},
+	}
+
+	ids := func() []string {
+		var ids []string
+		for _, stack := range stacks {
+			ids = append(ids, stack.ID)
+		}
+		return ids
+	}
+
+	SortStacks(stacks, DefaultListSort)
+	if got := ids(); !reflect.DeepEqual(got, []string{"s2", "s1", "s3"}) {
+		t.Errorf("Expected stacks by name, got %v", got)
+	}
+	SortStacks(stacks, ListSort{Field: "dateCreated", Desc: true})
+	if got := ids(); !reflect.DeepEqual(got, []string{"s2", "s1", "s3"}) {
+		t.Errorf("Expected newest stacks first, got %v", got)
+	}
+	SortStacks(stacks, ListSort{Field: "dateCreated"})
+	if got := ids(); !reflect.DeepEqual(got, []string{"s3", "s1", "s2"}) {
+		t.Errorf("Expected oldest stacks first, got %v", got)
+	}
+}
+
+func TestAfterSelector(t *testing.T) {
+	selector := map[string]interface{}{"status": "running"}
+	if got := afterSelector(selector, ""); !reflect.DeepEqual(got, selector) {
+		t.Errorf("afterSelector() = %v, want the selector unchanged", got)
+	}
+
+	got := afterSelector(selector, "c1")
+	want := map[string]interface{}{"status": "running", "@id": map[string]interface{}{"$gt": "c1"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("afterSelector() = %v, want %v", got, want)
+	}
+	if _, ok := selector["@id"]; ok {
+		t.Error("afterSelector() modified its argument")
+	}
+}
+
+func TestAfterSelector_MixedHostTypes(t *testing.T) {
+	var hosts []*models.Host
+	for _, id := range []string{"a", "m", "z"} {
+		hosts = append(hosts,
+			&models.Host{ID: id, Type: "ComputerServer", Status: "active"},
+			&models.Host{ID: id + "2", Type: "ComputerSystem", Status: "active"})
+	}
+	sort.Slice(hosts, func(i, j int) bool {
+		if hosts[i].Type != hosts[j].Type {
+			return hosts[i].Type < hosts[j].Type
+		}
+		return hosts[i].ID < hosts[j].ID
+	})
+
+	// Page the way CouchDB would with idSort
+	selector := hostSelector(map[string]interface{}{"status": "active"})
+	var seen []string
+	after := ""
+	for page := 0; page < len(hosts); page++ {
+		var matched []*models.Host
+		for _, host := range hosts {
+			if len(matched) < 2 && matchesSelector(t, host, afterSelector(selector, after)) {
+				matched = append(matched, host)
+			}
+		}
+		for _, host := range matched {
+			seen = append(seen, host.ID)
+		}
+		var last string
+		if n := len(matched); n > 0 {
+			last = afterKey(matched[n-1].Type, matched[n-1].ID)
+		}
+		if after = nextAfter(len(matched), 2, last); after == "" {
+			break
+		}
+	}
+
+	want := []string{"a", "m", "z", "a2", "m2", "z2"}
+	if !reflect.DeepEqual(seen, want) {
+		t.Errorf("Expected every host once, got %v", seen)
+	}
+}
+
+func TestNextAfter(t *testing.T) {
+	if got := nextAfter(10, 10, "c10"); got != "c10" {
+		t.Errorf("nextAfter() = %q, want c10 for a full page", got)
+	}
+	if got := nextAfter(3, 10, "c3"); got != "" {
+		t.Errorf("nextAfter() = %q, want none for a short page", got)
+	}
+	if got := nextAfter(0, 10, ""); got != "" {
+		t.Errorf("nextAfter() = %q, want none for an empty page", got)
+	}
+}
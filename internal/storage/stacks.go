@@ -3,6 +3,7 @@ package storage
 import (
 	"encoding/json"
 	"fmt"
+	"time"
 
 	"eve.evalgo.org/db"
 
@@ -50,6 +51,29 @@ func (s *Storage) UpdateStack(stack *models.Stack) error {
 	return nil
 }
 
+// RecordRedeployedContainer records the container a redeploy created for a
+// pending placement of a stack (models.Stack.RecordRedeployedContainer).
+// Redeploy tasks of a stack often complete at once, so a conflicting save is
+// retried once with the current stack.
+func (s *Storage) RecordRedeployedContainer(stackID, containerName, containerID string) (bool, error) {
+	var err error
+	for attempt := 0; attempt < 2; attempt++ {
+		var stack *models.Stack
+		stack, err = s.GetStack(stackID)
+		if err != nil {
+			return false, err
+		}
+		if !stack.RecordRedeployedContainer(containerName, containerID, time.Now()) {
+			return false, nil
+		}
+		err = s.UpdateStack(stack)
+		if couchErr, ok := err.(*db.CouchDBError); !ok || !couchErr.IsConflict() {
+			break
+		}
+	}
+	return err == nil, err
+}
+
 // DeleteStack deletes a stack by ID.
 func (s *Storage) DeleteStack(id string) error {
 	// Get the current stack to get its revision
@@ -73,6 +97,7 @@ func (s *Storage) ListStacks(filters map[string]interface{}) ([]*models.Stack, e
 	}
 
 	query := qb.Build()
+	s.warnIfUnindexed("stacks-status-location", "ListStacks")
 
 	// Execute query
 	stacks, err := db.FindTyped[models.Stack](s.service, query)
@@ -91,18 +116,33 @@ func (s *Storage) ListStacks(filters map[string]interface{}) ([]*models.Stack, e
 
 // GetStacksByStatus retrieves all stacks with a specific status.
 func (s *Storage) GetStacksByStatus(status string) ([]*models.Stack, error) {
-	filters := map[string]interface{}{
-		"status": status,
-	}
-	return s.ListStacks(filters)
+	return s.stacksFromView("stacks_by_status", status)
 }
 
 // GetStacksByDatacenter retrieves all stacks in a specific datacenter.
 func (s *Storage) GetStacksByDatacenter(datacenter string) ([]*models.Stack, error) {
-	filters := map[string]interface{}{
-		"location": datacenter,
+	return s.stacksFromView("stacks_by_datacenter", datacenter)
+}
+
+// stacksFromView retrieves the stacks emitted under key by a stack view.
+func (s *Storage) stacksFromView(view, key string) ([]*models.Stack, error) {
+	result, err := s.service.QueryView("graphium", view, db.ViewOptions{
+		Key:         key,
+		IncludeDocs: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	stacks := make([]*models.Stack, 0, len(result.Rows))
+	for _, row := range result.Rows {
+		var stack models.Stack
+		if err := json.Unmarshal(row.Doc, &stack); err != nil {
+			continue // Skip invalid documents
+		}
+		stacks = append(stacks, &stack)
 	}
-	return s.ListStacks(filters)
+	return stacks, nil
 }
 
 // SaveDeployment saves a stack deployment record.
@@ -21,6 +21,7 @@ type Statistics struct {
 	TotalActions        int // Total scheduled actions
 	SuccessfulActions   int // Scheduled actions with last execution successful
 	FailedActions       int // Scheduled actions with last execution failed
+	OrphanedContainers  int // Containers without a stack, host or deployment
 }
 
 // DatacenterTopology contains the topology information for a single datacenter
@@ -72,6 +73,7 @@ func (s *Storage) GetStatistics() (*Statistics, error) {
 		return nil, err
 	}
 	stats.TotalStacks = len(stacks)
+	stats.OrphanedContainers = models.FindOrphans(containers, hostIDSet(hosts), stacks).Total
 
 	// Count running stacks (stacks with at least one running container)
 	for _, stack := range stacks {
@@ -167,47 +169,145 @@ func (s *Storage) GetDatacenterTopology(datacenter string) (*DatacenterTopology,
 	return topology, nil
 }
 
-// CountContainers returns the count of containers matching the given filters
-func (s *Storage) CountContainers(filters map[string]interface{}) (int, error) {
-	containers, err := s.ListContainers(filters)
+// defaultMaxGraphDepth is used when server.max_graph_depth is not configured.
+const defaultMaxGraphDepth = 50
+
+// GetContainerDependencyGraph builds a dependency graph for a container by
+// following dependsOn references breadth-first. A maxDepth of 0 requests an
+// unlimited traversal, but the depth is always capped by server.max_graph_depth.
+// It returns the depth it used and whether the cap cut the traversal short.
+func (s *Storage) GetContainerDependencyGraph(containerID string, maxDepth int) (graph *db.RelationshipGraph, depth int, truncated bool, err error) {
+	depth, capped := graphDepth(maxDepth, s.config.Server.MaxGraphDepth)
+
+	root, err := s.GetContainer(containerID)
 	if err != nil {
-		return 0, err
+		return nil, 0, false, err
 	}
-	return len(containers), nil
-}
 
-// CountHosts returns the count of hosts matching the given filters
-func (s *Storage) CountHosts(filters map[string]interface{}) (int, error) {
-	hosts, err := s.ListHosts(filters)
+	graph, truncated, err = traverseDependencies(root, depth, capped, s.getContainersByIDs)
 	if err != nil {
-		return 0, err
+		return nil, 0, false, err
+	}
+	return graph, depth, truncated, nil
+}
+
+// graphDepth returns the depth of a traversal requesting maxDepth (0 =
+// unlimited) under depthCap (<= 0 = defaultMaxGraphDepth), and whether the
+// cap applies.
+func graphDepth(maxDepth, depthCap int) (int, bool) {
+	if depthCap <= 0 {
+		depthCap = defaultMaxGraphDepth
+	}
+	if maxDepth > 0 && maxDepth < depthCap {
+		return maxDepth, false
 	}
-	return len(hosts), nil
+	return depthCap, true
 }
 
-// GetContainerDependencyGraph builds a dependency graph for a container
-// This is a simplified version that looks for network dependencies
-func (s *Storage) GetContainerDependencyGraph(containerID string, maxDepth int) (*db.RelationshipGraph, error) {
+// traverseDependencies follows the dependsOn references of root up to depth
+// levels, fetching each level with a single call of fetch. It reports the
+// traversal as truncated if capped and dependencies were left unexplored.
+func traverseDependencies(root *models.Container, depth int, capped bool, fetch func(ids []string) ([]*models.Container, error)) (*db.RelationshipGraph, bool, error) {
 	graph := &db.RelationshipGraph{
 		Nodes: make(map[string]json.RawMessage),
 		Edges: []db.RelationshipEdge{},
 	}
+	if err := addGraphNode(graph, root); err != nil {
+		return nil, false, err
+	}
 
-	// Get the root container
-	container, err := s.GetContainer(containerID)
-	if err != nil {
-		return nil, err
+	visited := map[string]bool{root.ID: true}
+	frontier := []*models.Container{root}
+
+	for level := 0; level < depth && len(frontier) > 0; level++ {
+		// Collect the unvisited dependencies of this level
+		var next []string
+		for _, container := range frontier {
+			for _, dep := range container.DependsOn {
+				graph.Edges = append(graph.Edges, db.RelationshipEdge{
+					From: container.ID,
+					To:   dep,
+					Type: "dependsOn",
+				})
+				if !visited[dep] {
+					visited[dep] = true
+					next = append(next, dep)
+				}
+			}
+		}
+
+		if len(next) == 0 {
+			frontier = nil
+			break
+		}
+
+		// Fetch the whole level in a single query instead of one GetContainer per node
+		deps, err := fetch(next)
+		if err != nil {
+			return nil, false, err
+		}
+		for _, dep := range deps {
+			if err := addGraphNode(graph, dep); err != nil {
+				return nil, false, err
+			}
+		}
+		frontier = deps
 	}
 
-	// Marshal container to JSON
+	// The traversal was truncated if the cap stopped us before all
+	// dependencies of the last level were explored
+	truncated := false
+	if capped {
+		for _, container := range frontier {
+			for _, dep := range container.DependsOn {
+				if !visited[dep] {
+					truncated = true
+				}
+			}
+		}
+	}
+
+	return graph, truncated, nil
+}
+
+// addGraphNode marshals a container into the graph's node set.
+func addGraphNode(graph *db.RelationshipGraph, container *models.Container) error {
 	containerJSON, err := json.Marshal(container)
+	if err != nil {
+		return err
+	}
+	graph.Nodes[container.ID] = containerJSON
+	return nil
+}
+
+// getContainersByIDs retrieves multiple containers in a single query.
+// IDs that don't exist are silently skipped.
+func (s *Storage) getContainersByIDs(ids []string) ([]*models.Container, error) {
+	query := db.MangoQuery{
+		Selector: map[string]interface{}{
+			"_id": map[string]interface{}{
+				"$in": ids,
+			},
+		},
+		Limit: len(ids),
+	}
+
+	containers, err := db.FindTyped[models.Container](s.service, query)
 	if err != nil {
 		return nil, err
 	}
-	graph.Nodes[containerID] = containerJSON
 
-	// For now, return a simple graph with just the root node
-	// A full implementation would traverse network links, volume dependencies, etc.
+	// Deduplicate by ID (keep last occurrence), matching ListContainers
+	seen := make(map[string]int)
+	result := make([]*models.Container, 0, len(containers))
+	for i := range containers {
+		if idx, ok := seen[containers[i].ID]; ok {
+			result[idx] = &containers[i]
+			continue
+		}
+		seen[containers[i].ID] = len(result)
+		result = append(result, &containers[i])
+	}
 
-	return graph, nil
+	return result, nil
 }
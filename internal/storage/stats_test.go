@@ -0,0 +1,102 @@
+package storage
+
+import (
+	"testing"
+
+	"evalgo.org/graphium/models"
+)
+
+// fetchFrom returns a fetch function over containers that counts its calls.
+func fetchFrom(containers map[string]*models.Container, calls *int) func(ids []string) ([]*models.Container, error) {
+	return func(ids []string) ([]*models.Container, error) {
+		*calls++
+		var found []*models.Container
+		for _, id := range ids {
+			if container, ok := containers[id]; ok {
+				found = append(found, container)
+			}
+		}
+		return found, nil
+	}
+}
+
+func TestGraphDepth(t *testing.T) {
+	tests := []struct {
+		name       string
+		requested  int
+		depthCap   int
+		wantDepth  int
+		wantCapped bool
+	}{
+		{"below the cap", 5, 50, 5, false},
+		{"unlimited", 0, 50, 50, true},
+		{"above the cap", 80, 50, 50, true},
+		{"at the cap", 50, 50, 50, true},
+		{"default cap", 0, 0, defaultMaxGraphDepth, true},
+	}
+	for _, tt := range tests {
+		depth, capped := graphDepth(tt.requested, tt.depthCap)
+		if depth != tt.wantDepth || capped != tt.wantCapped {
+			t.Errorf("%s: graphDepth(%d, %d) = %d, %v, want %d, %v",
+				tt.name, tt.requested, tt.depthCap, depth, capped, tt.wantDepth, tt.wantCapped)
+		}
+	}
+}
+
+func TestTraverseDependencies_Cycle(t *testing.T) {
+	containers := map[string]*models.Container{
+		"a": {ID: "a", DependsOn: []string{"b"}},
+		"b": {ID: "b", DependsOn: []string{"c"}},
+		"c": {ID: "c", DependsOn: []string{"a"}},
+	}
+	calls := 0
+
+	graph, truncated, err := traverseDependencies(containers["a"], 50, true, fetchFrom(containers, &calls))
+	if err != nil {
+		t.Fatalf("traverseDependencies failed: %v", err)
+	}
+	if len(graph.Nodes) != 3 || len(graph.Edges) != 3 {
+		t.Errorf("Expected 3 nodes and 3 edges, got %d and %d", len(graph.Nodes), len(graph.Edges))
+	}
+	if calls != 2 {
+		t.Errorf("Expected each container to be fetched once (2 levels), got %d fetches", calls)
+	}
+	if truncated {
+		t.Error("Expected a fully explored cycle not to be truncated")
+	}
+}
+
+func TestTraverseDependencies_Cap(t *testing.T) {
+	containers := map[string]*models.Container{
+		"a": {ID: "a", DependsOn: []string{"b"}},
+		"b": {ID: "b", DependsOn: []string{"c"}},
+		"c": {ID: "c", DependsOn: []string{"d"}},
+		"d": {ID: "d"},
+	}
+
+	tests := []struct {
+		name          string
+		depth         int
+		capped        bool
+		wantNodes     int
+		wantTruncated bool
+	}{
+		{"cap cuts the chain", 2, true, 3, true},
+		{"requested depth cuts the chain", 2, false, 3, false},
+		{"cap reaches the end", 3, true, 4, false},
+		{"cap beyond the end", 10, true, 4, false},
+	}
+	for _, tt := range tests {
+		calls := 0
+		graph, truncated, err := traverseDependencies(containers["a"], tt.depth, tt.capped, fetchFrom(containers, &calls))
+		if err != nil {
+			t.Fatalf("%s: traverseDependencies failed: %v", tt.name, err)
+		}
+		if len(graph.Nodes) != tt.wantNodes {
+			t.Errorf("%s: expected %d nodes, got %d", tt.name, tt.wantNodes, len(graph.Nodes))
+		}
+		if truncated != tt.wantTruncated {
+			t.Errorf("%s: expected truncated %v, got %v", tt.name, tt.wantTruncated, truncated)
+		}
+	}
+}
@@ -5,9 +5,12 @@ package storage
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"reflect"
+	"slices"
+	"sort"
 	"strings"
 	"time"
 
@@ -22,8 +25,11 @@ import (
 // It wraps the CouchDB service from eve library and provides
 // type-safe operations for Graphium entities.
 type Storage struct {
-	service *db.CouchDBService
-	config  *config.Config
+	service       *db.CouchDBService
+	config        *config.Config
+	indexes       indexState
+	graphBuilders []GraphNodeBuilder // Extend GetGraphData
+	taskCreated   []func(*models.AgentTask)
 }
 
 // debugLog logs a message only if debug mode is enabled in config
@@ -36,13 +42,37 @@ func (s *Storage) debugLog(format string, args ...interface{}) {
 // New creates a new Storage instance from the application configuration.
 // It initializes the CouchDB connection and ensures the database exists.
 func New(cfg *config.Config) (*Storage, error) {
+	storage, err := connect(cfg, true)
+	if err != nil {
+		return nil, err
+	}
+
+	// Initialize database schema (indexes and views)
+	if err := storage.initializeSchema(); err != nil {
+		return nil, fmt.Errorf("failed to initialize database schema: %w", err)
+	}
+
+	// Index creation failures are only logged above, so confirm the result
+	storage.verifyIndexesAfterInit()
+
+	return storage, nil
+}
+
+// NewReadOnly connects to an existing database without creating it or its
+// indexes and views, so that e.g. graphium check can report what is missing.
+func NewReadOnly(cfg *config.Config) (*Storage, error) {
+	return connect(cfg, false)
+}
+
+// connect creates the CouchDB connection of a Storage instance.
+func connect(cfg *config.Config, createIfMissing bool) (*Storage, error) {
 	// Create CouchDB configuration from app config
 	couchConfig := db.CouchDBConfig{
 		URL:             cfg.CouchDB.URL,
 		Database:        cfg.CouchDB.Database,
 		Username:        cfg.CouchDB.Username,
 		Password:        cfg.CouchDB.Password,
-		CreateIfMissing: true,
+		CreateIfMissing: createIfMissing,
 	}
 
 	// Initialize CouchDB service
@@ -51,46 +81,66 @@ func New(cfg *config.Config) (*Storage, error) {
 		return nil, fmt.Errorf("failed to create CouchDB service: %w", err)
 	}
 
-	storage := &Storage{
-		service: service,
-		config:  cfg,
-	}
-
-	// Initialize database schema (indexes and views)
-	if err := storage.initializeSchema(); err != nil {
-		return nil, fmt.Errorf("failed to initialize database schema: %w", err)
-	}
+	return &Storage{
+		service:       service,
+		config:        cfg,
+		graphBuilders: []GraphNodeBuilder{customEntityGraphBuilder{}},
+	}, nil
+}
 
-	return storage, nil
+// requiredIndexes are the Mango indexes Graphium queries rely on.
+var requiredIndexes = []db.Index{
+	{
+		Name:   "containers-status-host",
+		Fields: []string{"@type", "status", "hostedOn"},
+		Type:   "json",
+	},
+	{
+		Name:   "hosts-datacenter-status",
+		Fields: []string{"@type", "location", "status"},
+		Type:   "json",
+	},
+	{
+		Name:   "containers-name",
+		Fields: []string{"@type", "name"},
+		Type:   "json",
+	},
+	{
+		Name:   "hosts-name",
+		Fields: []string{"@type", "name"},
+		Type:   "json",
+	},
+	{
+		Name:   "containers-id",
+		Fields: []string{"@type", "@id"},
+		Type:   "json",
+	},
+	{
+		Name:   "hosts-id",
+		Fields: []string{"@type", "@id"},
+		Type:   "json",
+	},
+	{
+		Name:   "stacks-status-location",
+		Fields: []string{"@type", "status", "location"},
+		Type:   "json",
+	},
+	{
+		Name:   "deployments-stack",
+		Fields: []string{"@type", "stackId"},
+		Type:   "json",
+	},
+	{
+		Name:   "container-changes",
+		Fields: []string{"@type", "containerId"},
+		Type:   "json",
+	},
 }
 
 // initializeSchema creates indexes and views needed for Graphium queries.
 func (s *Storage) initializeSchema() error {
 	// Create indexes for common queries
-	indexes := []db.Index{
-		{
-			Name:   "containers-status-host",
-			Fields: []string{"@type", "status", "hostedOn"},
-			Type:   "json",
-		},
-		{
-			Name:   "hosts-datacenter-status",
-			Fields: []string{"@type", "location", "status"},
-			Type:   "json",
-		},
-		{
-			Name:   "containers-name",
-			Fields: []string{"@type", "name"},
-			Type:   "json",
-		},
-		{
-			Name:   "hosts-name",
-			Fields: []string{"@type", "name"},
-			Type:   "json",
-		},
-	}
-
-	for _, index := range indexes {
+	for _, index := range requiredIndexes {
 		if err := s.service.CreateIndex(index); err != nil {
 			// Log warning but don't fail - index might already exist
 			fmt.Printf("Warning: failed to create index %s: %v\n", index.Name, err)
@@ -107,7 +157,12 @@ func (s *Storage) initializeSchema() error {
 
 // createViews creates CouchDB MapReduce views for graph traversal and queries.
 func (s *Storage) createViews() error {
-	designDoc := db.DesignDoc{
+	return s.service.CreateDesignDoc(graphiumDesignDoc())
+}
+
+// graphiumDesignDoc returns the design document holding Graphium's views.
+func graphiumDesignDoc() db.DesignDoc {
+	return db.DesignDoc{
 		ID:       "_design/graphium",
 		Language: "javascript",
 		Views: map[string]db.View{
@@ -143,6 +198,73 @@ func (s *Storage) createViews() error {
 					}
 				}`,
 			},
+			// View: containers_by_label - Find containers by label. Emits one
+			// row per label keyed by [key, value]; rows do not embed the
+			// document, so a key-only lookup is a range over [key] to [key, {}].
+			"containers_by_label": {
+				Map: `function(doc) {
+					if (doc['@type'] === 'SoftwareApplication' && doc.labels) {
+						for (var key in doc.labels) {
+							emit([key, doc.labels[key]], null);
+						}
+					}
+				}`,
+			},
+			// View: containers_by_host_port - Find containers by published host port.
+			// Emits one row per host-port binding keyed by the host port number, with
+			// [containerPort, protocol] as the value. Unpublished ports (hostPort 0)
+			// are skipped. Rows do not embed the document, so the index stays small
+			// and a lookup by port costs one B-tree read plus include_docs fetches.
+			"containers_by_host_port": {
+				Map: `function(doc) {
+					if (doc['@type'] === 'SoftwareApplication' && doc.ports) {
+						for (var i = 0; i < doc.ports.length; i++) {
+							var p = doc.ports[i];
+							if (p && p.hostPort > 0) {
+								emit(p.hostPort, [p.containerPort, p.protocol || 'tcp']);
+							}
+						}
+					}
+				}`,
+			},
+			// View: stacks_by_container - Find the stacks listing a container.
+			// Emits one row per container of a stack, keyed by container ID, with
+			// the stack name as the value; the row ID is the stack ID. Rows of one
+			// key are sorted by stack ID.
+			"stacks_by_container": {
+				Map: `function(doc) {
+					if (doc['@type'] === 'ItemList' && doc.containers) {
+						for (var i = 0; i < doc.containers.length; i++) {
+							emit(doc.containers[i], doc.name);
+						}
+					}
+				}`,
+			},
+			// View: stacks_by_status - Find stacks by status. Rows carry no value;
+			// query with include_docs.
+			"stacks_by_status": {
+				Map: `function(doc) {
+					if (doc['@type'] === 'ItemList' && doc.status) {
+						emit(doc.status, null);
+					}
+				}`,
+			},
+			// View: stacks_by_datacenter - Find stacks in a datacenter
+			"stacks_by_datacenter": {
+				Map: `function(doc) {
+					if (doc['@type'] === 'ItemList' && doc.location) {
+						emit(doc.location, null);
+					}
+				}`,
+			},
+			// View: deployments_by_stack - Find the deployment states of a stack
+			"deployments_by_stack": {
+				Map: `function(doc) {
+					if (doc['@type'] === 'DeploymentState' && doc.stackId) {
+						emit(doc.stackId, null);
+					}
+				}`,
+			},
 			// View: container_count_by_host - Count containers per host
 			"container_count_by_host": {
 				Map: `function(doc) {
@@ -163,8 +285,6 @@ func (s *Storage) createViews() error {
 			},
 		},
 	}
-
-	return s.service.CreateDesignDoc(designDoc)
 }
 
 // Close closes the storage connection.
@@ -179,6 +299,8 @@ func (s *Storage) GetDBService() *db.CouchDBService {
 }
 
 // SaveContainer saves a container to the database.
+// Creating a container (no revision) whose tombstone is still active fails
+// with ErrContainerTombstoned, so deleted containers don't come back.
 func (s *Storage) SaveContainer(container *models.Container) error {
 	// Set JSON-LD context and type if not set
 	if container.Context == "" {
@@ -188,6 +310,16 @@ func (s *Storage) SaveContainer(container *models.Container) error {
 		container.Type = "SoftwareApplication"
 	}
 
+	if container.Rev == "" {
+		tombstone, err := s.GetContainerTombstone(container.ID)
+		if err != nil {
+			return err
+		}
+		if tombstone != nil {
+			return fmt.Errorf("%w: %s", ErrContainerTombstoned, container.ID)
+		}
+	}
+
 	_, err := s.service.SaveGenericDocument(container)
 
 	// If we get a conflict, fetch the existing document and retry with its revision
@@ -238,20 +370,16 @@ func (s *Storage) DeleteContainer(containerID, rev string) error {
 	return nil
 }
 
-// ListContainers retrieves all containers matching the given filters.
+// ListContainers retrieves all containers matching the given filters. A
+// []string filter value matches any of its entries.
 func (s *Storage) ListContainers(filters map[string]interface{}) ([]*models.Container, error) {
 	// Build query with filters
-	qb := db.NewQueryBuilder().
-		Where("@type", "$eq", "SoftwareApplication")
-
-	// Apply additional filters
-	for field, value := range filters {
-		qb = qb.And().Where(field, "$eq", value)
+	query := db.MangoQuery{
+		Selector: containerSelector(filters),
 	}
 
-	query := qb.Build()
-
 	s.debugLog("DEBUG: ListContainers query selector: %+v", query.Selector)
+	s.warnIfUnindexed("containers-status-host", "ListContainers")
 
 	// Execute query
 	containers, err := db.FindTyped[models.Container](s.service, query)
@@ -312,6 +440,71 @@ func (s *Storage) GetContainersByHost(hostID string) ([]*models.Container, error
 	return containers, nil
 }
 
+// GetContainersByHostPort retrieves all containers that publish the given host port.
+// It uses the containers_by_host_port view; protocol filtering is left to the caller.
+func (s *Storage) GetContainersByHostPort(hostPort int) ([]*models.Container, error) {
+	result, err := s.service.QueryView("graphium", "containers_by_host_port", db.ViewOptions{
+		Key:         hostPort,
+		IncludeDocs: true,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	// A container publishing the port for both tcp and udp has two rows
+	containerMap := make(map[string]*models.Container)
+	for _, row := range result.Rows {
+		var container models.Container
+		if err := json.Unmarshal(row.Doc, &container); err != nil {
+			continue // Skip invalid documents
+		}
+		containerMap[container.ID] = &container
+	}
+
+	containers := make([]*models.Container, 0, len(containerMap))
+	for _, container := range containerMap {
+		containers = append(containers, container)
+	}
+
+	return containers, nil
+}
+
+// GetContainersByLabel retrieves all containers with the label key set to
+// value, using the containers_by_label view. An empty value matches every
+// container that has the label, whatever its value.
+func (s *Storage) GetContainersByLabel(key, value string) ([]*models.Container, error) {
+	opts := db.ViewOptions{IncludeDocs: true}
+	if value != "" {
+		opts.Key = []string{key, value}
+	} else {
+		opts.StartKey = []interface{}{key}
+		opts.EndKey = []interface{}{key, map[string]interface{}{}}
+	}
+
+	result, err := s.service.QueryView("graphium", "containers_by_label", opts)
+	if err != nil {
+		return nil, err
+	}
+
+	// Deduplicate containers by @id, like GetContainersByHost
+	containerMap := make(map[string]*models.Container)
+	for _, row := range result.Rows {
+		var container models.Container
+		if err := json.Unmarshal(row.Doc, &container); err != nil {
+			continue // Skip invalid documents
+		}
+		containerMap[container.ID] = &container
+	}
+
+	containers := make([]*models.Container, 0, len(containerMap))
+	for _, container := range containerMap {
+		containers = append(containers, container)
+	}
+
+	return containers, nil
+}
+
 // GetContainersByStatus retrieves all containers with a specific status.
 func (s *Storage) GetContainersByStatus(status string) ([]*models.Container, error) {
 	result, err := s.service.QueryView("graphium", "containers_by_status", db.ViewOptions{
@@ -335,6 +528,36 @@ func (s *Storage) GetContainersByStatus(status string) ([]*models.Container, err
 	return containers, nil
 }
 
+// GetContainersByImage retrieves all containers running the given image
+// reference (e.g. nginx:1.19), using the containers_by_image view.
+func (s *Storage) GetContainersByImage(image string) ([]*models.Container, error) {
+	result, err := s.service.QueryView("graphium", "containers_by_image", db.ViewOptions{
+		Key:         image,
+		IncludeDocs: true,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	// Deduplicate containers by @id, like GetContainersByHost
+	containerMap := make(map[string]*models.Container)
+	for _, row := range result.Rows {
+		var container models.Container
+		if err := json.Unmarshal(row.Doc, &container); err != nil {
+			continue // Skip invalid documents
+		}
+		containerMap[container.ID] = &container
+	}
+
+	containers := make([]*models.Container, 0, len(containerMap))
+	for _, container := range containerMap {
+		containers = append(containers, container)
+	}
+
+	return containers, nil
+}
+
 // SaveHost saves a host to the database.
 func (s *Storage) SaveHost(host *models.Host) error {
 	// Set JSON-LD context and type if not set
@@ -384,24 +607,15 @@ func (s *Storage) DeleteHost(id, rev string) error {
 	return s.service.DeleteDocument(id, rev)
 }
 
-// ListHosts retrieves all hosts matching the given filters.
+// ListHosts retrieves all hosts matching the given filters. A []string
+// filter value matches any of its entries.
 func (s *Storage) ListHosts(filters map[string]interface{}) ([]*models.Host, error) {
 	// Build query with filters - accept both ComputerServer and ComputerSystem types
 	// Use direct MangoQuery since QueryBuilder may not support $in properly
-	selector := map[string]interface{}{
-		"@type": map[string]interface{}{
-			"$in": []string{"ComputerServer", "ComputerSystem"},
-		},
-	}
-
-	// Apply additional filters to the selector
-	for field, value := range filters {
-		selector[field] = map[string]interface{}{"$eq": value}
-	}
-
 	query := db.MangoQuery{
-		Selector: selector,
+		Selector: hostSelector(filters),
 	}
+	s.warnIfUnindexed("hosts-datacenter-status", "ListHosts")
 
 	// Execute query
 	hosts, err := db.FindTyped[models.Host](s.service, query)
@@ -516,117 +730,289 @@ func (s *Storage) GetHostContainerCount() (map[string]int, error) {
 	return counts, nil
 }
 
+// containerStackMemberships returns the stacks listing containerID, sorted
+// by stack ID, from the stacks_by_container view. An empty containerID
+// returns the memberships of every container.
+func (s *Storage) containerStackMemberships(containerID string) ([]models.StackMembership, error) {
+	opts := db.ViewOptions{}
+	if containerID != "" {
+		opts.Key = containerID
+	}
+	result, err := s.service.QueryView("graphium", "stacks_by_container", opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query stacks by container: %w", err)
+	}
+
+	memberships := make([]models.StackMembership, 0, len(result.Rows))
+	for _, row := range result.Rows {
+		key, _ := row.Key.(string)
+		name, _ := row.Value.(string)
+		memberships = append(memberships, models.StackMembership{ContainerID: key, StackID: row.ID, StackName: name})
+	}
+	return memberships, nil
+}
+
 // GetContainerStack returns the stack that owns this container, if any.
 // Returns the stack and true if the container belongs to a stack, nil and false otherwise.
+// A container listed by several stacks belongs to the one with the lowest ID.
 func (s *Storage) GetContainerStack(containerID string) (*models.Stack, bool, error) {
-	// Get all stacks
-	stacks, err := s.ListStacks(nil)
+	memberships, err := s.containerStackMemberships(containerID)
 	if err != nil {
 		return nil, false, err
 	}
 
-	// Check each stack's containers list
-	for _, stack := range stacks {
-		for _, cID := range stack.Containers {
-			if cID == containerID {
-				return stack, true, nil
-			}
-		}
+	entry, ok := models.IndexStackMemberships(memberships)[containerID]
+	if !ok {
+		return nil, false, nil
 	}
 
-	return nil, false, nil
+	stack, err := s.GetStack(entry.StackID)
+	if err != nil {
+		return nil, false, err
+	}
+	return stack, true, nil
 }
 
-// GetContainerStackMap returns a map of container ID to stack info for all containers.
-// This is more efficient than calling GetContainerStack for each container individually.
-func (s *Storage) GetContainerStackMap() (map[string]*models.Stack, error) {
-	stackMap := make(map[string]*models.Stack)
-
-	// Get all stacks
-	stacks, err := s.ListStacks(nil)
+// GetContainerStackMap returns a map of container ID to the stack it belongs
+// to for all containers in a stack. It is a single query of the
+// stacks_by_container view rather than a scan of every stack.
+func (s *Storage) GetContainerStackMap() (map[string]models.StackIndexEntry, error) {
+	memberships, err := s.containerStackMemberships("")
 	if err != nil {
 		return nil, err
 	}
-
-	// Build map of containerID -> stack
-	for _, stack := range stacks {
-		for _, containerID := range stack.Containers {
-			stackMap[containerID] = stack
-		}
-	}
-
-	return stackMap, nil
+	return models.IndexStackMemberships(memberships), nil
 }
 
 // RemoveContainerFromStacks removes a container from all stacks that reference it.
 // This should be called when a container is deleted to maintain data consistency.
 func (s *Storage) RemoveContainerFromStacks(containerID string) error {
-	// Get all stacks
-	stacks, err := s.ListStacks(nil)
+	memberships, err := s.containerStackMemberships(containerID)
 	if err != nil {
-		return fmt.Errorf("failed to list stacks: %w", err)
+		return err
 	}
 
-	// Check each stack and remove the container if present
-	for _, stack := range stacks {
-		modified := false
-		newContainers := make([]string, 0, len(stack.Containers))
+	// Only the stacks listing the container are read and updated
+	for _, membership := range memberships {
+		stack, err := s.GetStack(membership.StackID)
+		if err != nil {
+			return err
+		}
 
+		newContainers := make([]string, 0, len(stack.Containers))
 		for _, cID := range stack.Containers {
 			if cID != containerID {
 				newContainers = append(newContainers, cID)
-			} else {
-				modified = true
 			}
 		}
+		if len(newContainers) == len(stack.Containers) {
+			continue
+		}
 
-		// If we removed the container, update the stack
-		if modified {
-			stack.Containers = newContainers
-			if err := s.UpdateStack(stack); err != nil {
-				return fmt.Errorf("failed to update stack %s: %w", stack.ID, err)
-			}
+		stack.Containers = newContainers
+		if err := s.UpdateStack(stack); err != nil {
+			return fmt.Errorf("failed to update stack %s: %w", stack.ID, err)
 		}
 	}
 
 	return nil
 }
 
-// AutoAssignContainerToStack automatically assigns a container to a stack based on naming convention.
-// Container names are expected to follow the pattern: {stack-name}-{service-name}
-// For example: "5-graphium-dev-5-couchdb" belongs to stack "5-graphium-dev"
-func (s *Storage) AutoAssignContainerToStack(containerID, containerName string) error {
-	// Get all stacks
+// PreviewStackAssignment reports which stack a container with the given name
+// and labels would be assigned to, without changing anything.
+func (s *Storage) PreviewStackAssignment(containerName string, labels map[string]string) (models.StackAssignment, error) {
+	stacks, err := s.ListStacks(nil)
+	if err != nil {
+		return models.StackAssignment{}, fmt.Errorf("failed to list stacks: %w", err)
+	}
+
+	return models.ResolveStackAssignment(stacks, containerName, labels), nil
+}
+
+// AutoAssignContainerToStack assigns a container to the stack chosen by
+// models.ResolveStackAssignment (labels first, then the
+// {stack-name}-{service} naming convention). A Compose project without a
+// stack gets one, so stacks started with plain docker compose are grouped.
+// A container that already belongs to a stack is left where it is, so
+// repeated syncs never move it back and forth. The returned assignment
+// describes the decision.
+func (s *Storage) AutoAssignContainerToStack(container *models.Container) (models.StackAssignment, error) {
+	memberships, err := s.containerStackMemberships(container.ID)
+	if err != nil {
+		return models.StackAssignment{}, err
+	}
+	if entry, ok := models.IndexStackMemberships(memberships)[container.ID]; ok {
+		return models.StackAssignment{
+			StackID:   entry.StackID,
+			StackName: entry.StackName,
+			Rule:      models.AssignRuleExisting,
+			Reason:    "container already belongs to stack " + entry.StackName,
+		}, nil
+	}
+
+	// Only unassigned containers need every stack to resolve a rule
 	stacks, err := s.ListStacks(nil)
 	if err != nil {
-		return fmt.Errorf("failed to list stacks: %w", err)
+		return models.StackAssignment{}, fmt.Errorf("failed to list stacks: %w", err)
+	}
+
+	assignment := models.ResolveStackAssignment(stacks, container.Name, container.Labels)
+	if assignment.StackID == "" {
+		// Not all containers belong to stacks
+		return assignment, nil
+	}
+	if assignment.Rule == models.AssignRuleComposeNew {
+		return assignment, s.createComposeStack(assignment.StackID, assignment.StackName, container.ID)
 	}
 
-	// Try to find a stack where the container name starts with the stack name
 	for _, stack := range stacks {
-		if strings.HasPrefix(containerName, stack.Name+"-") {
-			// Check if container is already in the stack
-			found := false
-			for _, cID := range stack.Containers {
-				if cID == containerID {
-					found = true
-					break
-				}
+		if stack.ID == assignment.StackID {
+			stack.Containers = append(stack.Containers, container.ID)
+			if err := s.UpdateStack(stack); err != nil {
+				return assignment, fmt.Errorf("failed to update stack %s: %w", stack.ID, err)
 			}
+			break
+		}
+	}
+
+	return assignment, nil
+}
+
+// createComposeStack creates the stack of a Compose project with its first
+// container. Containers of a new project are often synced at once; if
+// another sync created the stack first, the container is added to it.
+func (s *Storage) createComposeStack(id, project, containerID string) error {
+	now := time.Now()
+	stack := &models.Stack{
+		ID:          id,
+		Name:        project,
+		Description: "Discovered from Docker Compose project " + project,
+		Status:      "running",
+		Containers:  []string{containerID},
+		Labels:      map[string]string{models.ComposeProjectLabel: project},
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if err := s.SaveStack(stack); err == nil {
+		return nil
+	}
+
+	existing, err := s.GetStack(id)
+	if err != nil {
+		return fmt.Errorf("failed to create stack %s: %w", id, err)
+	}
+	// Never overwrite another kind of document with the same ID
+	if existing.Type != "ItemList" {
+		return fmt.Errorf("failed to create stack %s: the ID is used by a %s document", id, existing.Type)
+	}
+	if slices.Contains(existing.Containers, containerID) {
+		return nil
+	}
+	existing.Containers = append(existing.Containers, containerID)
+	if err := s.UpdateStack(existing); err != nil {
+		return fmt.Errorf("failed to update stack %s: %w", id, err)
+	}
+	return nil
+}
+
+// ErrContainerNameCollision is returned when a container name is already used
+// by another container on the same host.
+var ErrContainerNameCollision = errors.New("container name already in use on host")
+
+// ContainerNameCollision describes a name used by more than one container on a host.
+type ContainerNameCollision struct {
+	HostID       string   `json:"hostId"`
+	Name         string   `json:"name"`
+	ContainerIDs []string `json:"containerIds"`
+}
+
+// normalizeContainerName strips the leading slash Docker adds to container names.
+func normalizeContainerName(name string) string {
+	return strings.TrimPrefix(name, "/")
+}
+
+// containerWithName returns the container that uses the given name, ignoring
+// excludeID, or nil if none does.
+func containerWithName(containers []*models.Container, name, excludeID string) *models.Container {
+	name = normalizeContainerName(name)
+	for _, container := range containers {
+		if container.ID != excludeID && normalizeContainerName(container.Name) == name {
+			return container
+		}
+	}
+	return nil
+}
 
-			// If not found, add it
-			if !found {
-				stack.Containers = append(stack.Containers, containerID)
-				if err := s.UpdateStack(stack); err != nil {
-					return fmt.Errorf("failed to update stack %s: %w", stack.ID, err)
-				}
+// GetContainerNameCollisions lists container names that are used by more than
+// one container on the same host. If hostID is empty, all hosts are checked.
+// Containers are keyed by ID, so collisions usually indicate stale documents
+// that should be cleaned up.
+func (s *Storage) GetContainerNameCollisions(hostID string) ([]ContainerNameCollision, error) {
+	var containers []*models.Container
+	var err error
+	if hostID != "" {
+		containers, err = s.GetContainersByHost(hostID)
+	} else {
+		containers, err = s.ListContainers(nil)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return containerNameCollisions(containers), nil
+}
+
+// containerNameCollisions groups containers by host and name and returns the
+// names used more than once, sorted by host and name.
+func containerNameCollisions(containers []*models.Container) []ContainerNameCollision {
+	// Group container IDs by host and name
+	byHostName := make(map[string]map[string][]string)
+	for _, container := range containers {
+		names, ok := byHostName[container.HostedOn]
+		if !ok {
+			names = make(map[string][]string)
+			byHostName[container.HostedOn] = names
+		}
+		name := normalizeContainerName(container.Name)
+		names[name] = append(names[name], container.ID)
+	}
+
+	collisions := make([]ContainerNameCollision, 0)
+	for host, names := range byHostName {
+		for name, ids := range names {
+			if len(ids) > 1 {
+				collisions = append(collisions, ContainerNameCollision{
+					HostID:       host,
+					Name:         name,
+					ContainerIDs: ids,
+				})
 			}
-			return nil
 		}
 	}
+	sort.Slice(collisions, func(i, j int) bool {
+		if collisions[i].HostID != collisions[j].HostID {
+			return collisions[i].HostID < collisions[j].HostID
+		}
+		return collisions[i].Name < collisions[j].Name
+	})
 
-	// No matching stack found - this is fine, not all containers belong to stacks
-	return nil
+	return collisions
+}
+
+// stackContainerName returns the name a container gets in stackName:
+// {stack-name}-{container-name}, or "" if its name already follows that
+// pattern. It returns an error wrapping ErrContainerNameCollision if another
+// of the containers on the host uses the new name.
+func stackContainerName(container *models.Container, stackName string, onHost []*models.Container) (string, error) {
+	if strings.HasPrefix(container.Name, stackName+"-") {
+		return "", nil
+	}
+
+	newName := fmt.Sprintf("%s-%s", stackName, container.Name)
+	if existing := containerWithName(onHost, newName, container.ID); existing != nil {
+		return "", fmt.Errorf("%w: %s is used by container %s on host %s", ErrContainerNameCollision, newName, existing.ID, container.HostedOn)
+	}
+	return newName, nil
 }
 
 // RenameContainerForStack renames a container to follow stack naming convention.
@@ -639,6 +1025,8 @@ func (s *Storage) AutoAssignContainerToStack(containerID, containerName string)
 //   - dockerSocket: Docker socket to use for the rename operation (e.g., "unix:///var/run/docker.sock")
 //
 // Returns error if rename fails. No-op if container already follows naming convention.
+// Returns an error wrapping ErrContainerNameCollision if the new name is already
+// used by another container on the same host.
 func (s *Storage) RenameContainerForStack(containerID, stackName, dockerSocket string) error {
 	// Get container details
 	container, err := s.GetContainer(containerID)
@@ -646,15 +1034,19 @@ func (s *Storage) RenameContainerForStack(containerID, stackName, dockerSocket s
 		return fmt.Errorf("failed to get container: %w", err)
 	}
 
-	// Check if container name already starts with stack name
-	if strings.HasPrefix(container.Name, stackName+"-") {
+	onHost, err := s.GetContainersByHost(container.HostedOn)
+	if err != nil {
+		return fmt.Errorf("failed to check for name collisions: %w", err)
+	}
+	newName, err := stackContainerName(container, stackName, onHost)
+	if err != nil {
+		return err
+	}
+	if newName == "" {
 		// Already follows naming convention, no need to rename
 		return nil
 	}
 
-	// Construct new name: {stack-name}-{original-container-name}
-	newName := fmt.Sprintf("%s-%s", stackName, container.Name)
-
 	// Create Docker client for the host
 	ctx, cli, err := common.CtxCli(dockerSocket)
 	if err != nil {
@@ -688,9 +1080,22 @@ func (s *Storage) GetDatabaseInfo() (*db.DatabaseInfo, error) {
 // Ignore List Operations
 // ===============================================================
 
+// ErrContainerTombstoned is returned when saving a container that was
+// deleted on purpose and whose tombstone is still active.
+var ErrContainerTombstoned = errors.New("container was deleted and is tombstoned")
+
 // AddToIgnoreList adds a container ID to the ignore list.
 // Containers in the ignore list will not be synced by the agent.
 func (s *Storage) AddToIgnoreList(containerID, hostID, reason, createdBy string) error {
+	return s.TombstoneContainer(containerID, hostID, reason, createdBy, 0)
+}
+
+// TombstoneContainer records that a container was deleted on purpose. Until
+// the tombstone expires after ttl (0: until cleared with
+// RemoveFromIgnoreList), SaveContainer refuses to recreate the container and
+// agents skip it.
+func (s *Storage) TombstoneContainer(containerID, hostID, reason, createdBy string, ttl time.Duration) error {
+	now := time.Now()
 	entry := &models.IgnoreListEntry{
 		Context:     "https://schema.org",
 		Type:        "IgnoreListEntry",
@@ -699,15 +1104,26 @@ func (s *Storage) AddToIgnoreList(containerID, hostID, reason, createdBy string)
 		HostID:      hostID,
 		Reason:      reason,
 		CreatedBy:   createdBy,
-		CreatedAt:   time.Now(),
+		CreatedAt:   now,
+	}
+	if ttl > 0 {
+		expires := now.Add(ttl)
+		entry.ExpiresAt = &expires
+	}
+
+	// Replace an existing tombstone of the container
+	var existing models.IgnoreListEntry
+	if err := s.service.GetGenericDocument(entry.ID, &existing); err == nil {
+		entry.Rev = existing.Rev
 	}
 
 	_, err := s.service.SaveGenericDocument(entry)
 	return err
 }
 
-// IsContainerIgnored checks if a container ID is in the ignore list.
-func (s *Storage) IsContainerIgnored(containerID string) (bool, error) {
+// GetContainerTombstone returns the active tombstone of a container, or nil
+// if it has none or it expired.
+func (s *Storage) GetContainerTombstone(containerID string) (*models.IgnoreListEntry, error) {
 	docID := "ignore-" + containerID
 
 	var entry models.IgnoreListEntry
@@ -715,12 +1131,25 @@ func (s *Storage) IsContainerIgnored(containerID string) (bool, error) {
 	if err != nil {
 		// Check if error is a 404 not found using EVE's error type
 		if couchErr, ok := err.(*db.CouchDBError); ok && couchErr.IsNotFound() {
-			return false, nil
+			return nil, nil
 		}
-		return false, fmt.Errorf("failed to check ignore list: %w", err)
+		return nil, fmt.Errorf("failed to check ignore list: %w", err)
 	}
 
-	return true, nil
+	if !entry.Active(time.Now()) {
+		return nil, nil
+	}
+	return &entry, nil
+}
+
+// IsContainerIgnored checks if a container ID is in the ignore list, i.e.
+// has an active tombstone.
+func (s *Storage) IsContainerIgnored(containerID string) (bool, error) {
+	entry, err := s.GetContainerTombstone(containerID)
+	if err != nil {
+		return false, err
+	}
+	return entry != nil, nil
 }
 
 // RemoveFromIgnoreList removes a container ID from the ignore list.
@@ -741,8 +1170,48 @@ func (s *Storage) RemoveFromIgnoreList(containerID string) error {
 	return s.service.DeleteDocument(docID, entry.Rev)
 }
 
-// ListIgnored returns all containers in the ignore list.
+// ListIgnored returns all containers in the ignore list. Expired tombstones
+// are left out.
 func (s *Storage) ListIgnored() ([]*models.IgnoreListEntry, error) {
+	entries, err := s.listTombstones()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	result := make([]*models.IgnoreListEntry, 0, len(entries))
+	for _, entry := range entries {
+		if entry.Active(now) {
+			result = append(result, entry)
+		}
+	}
+	return result, nil
+}
+
+// DeleteExpiredTombstones removes tombstones that expired before the given
+// time. Returns the number of deleted tombstones.
+func (s *Storage) DeleteExpiredTombstones(before time.Time) (int, error) {
+	entries, err := s.listTombstones()
+	if err != nil {
+		return 0, err
+	}
+
+	deleted := 0
+	for _, entry := range entries {
+		if entry.Active(before) {
+			continue
+		}
+		if err := s.service.DeleteDocument(entry.ID, entry.Rev); err != nil {
+			s.debugLog("Failed to delete expired tombstone %s: %v\n", entry.ID, err)
+			continue
+		}
+		deleted++
+	}
+	return deleted, nil
+}
+
+// listTombstones returns every ignore list entry, expired or not.
+func (s *Storage) listTombstones() ([]*models.IgnoreListEntry, error) {
 	// Query for all documents starting with "ignore-"
 	query := db.NewQueryBuilder().
 		Where("_id", "$regex", "^ignore-").
@@ -0,0 +1,169 @@
+package storage
+
+import (
+	"sort"
+
+	"eve.evalgo.org/db"
+
+	"evalgo.org/graphium/models"
+)
+
+// BulkUpdateHostTags adds and removes tags on many hosts in a single _bulk_docs request.
+// Results are returned in the order of ids; ids that don't exist are reported as not_found.
+func (s *Storage) BulkUpdateHostTags(ids []string, add, remove []string) ([]db.BulkResult, []*models.Host, error) {
+	hosts, err := s.getHostsByIDs(ids)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	byID := make(map[string]*models.Host, len(hosts))
+	for _, host := range hosts {
+		host.Tags = applyTags(host.Tags, add, remove)
+		byID[host.ID] = host
+	}
+
+	results, err := s.BulkSaveHosts(hosts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return orderBulkResults(ids, results, func(id string) bool { return byID[id] != nil }), hosts, nil
+}
+
+// BulkUpdateContainerLabels sets and removes labels on many containers in a single _bulk_docs request.
+// Results are returned in the order of ids; ids that don't exist are reported as not_found.
+// Containers whose labels set by Docker would be removed or changed aren't saved
+// and are reported as a conflict, as their agent would report the labels again.
+func (s *Storage) BulkUpdateContainerLabels(ids []string, set map[string]string, remove []string) ([]db.BulkResult, []*models.Container, error) {
+	containers, err := s.getContainersByIDs(ids)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	byID := make(map[string]*models.Container, len(containers))
+	var conflicts []db.BulkResult
+	batch := make([]*models.Container, 0, len(containers))
+	for _, container := range containers {
+		byID[container.ID] = container
+		if result, ok := updateContainerLabels(container, set, remove); !ok {
+			conflicts = append(conflicts, result)
+			continue
+		}
+		batch = append(batch, container)
+	}
+
+	results := conflicts
+	if len(batch) > 0 {
+		saved, err := s.BulkSaveContainers(batch)
+		if err != nil {
+			return nil, nil, err
+		}
+		results = append(results, saved...)
+	}
+
+	return orderBulkResults(ids, results, func(id string) bool { return byID[id] != nil }), batch, nil
+}
+
+// updateContainerLabels sets and removes labels on a container. It refuses,
+// with a conflict result, to remove or change a label set by Docker.
+func updateContainerLabels(container *models.Container, set map[string]string, remove []string) (db.BulkResult, bool) {
+	labels := make(map[string]string, len(container.Labels)+len(set))
+	for key, value := range container.Labels {
+		labels[key] = value
+	}
+	for key, value := range set {
+		labels[key] = value
+	}
+	for _, key := range remove {
+		delete(labels, key)
+	}
+
+	updated := *container
+	updated.Labels = labels
+	if key := updated.DockerLabelConflict(); key != "" {
+		return db.BulkResult{
+			ID:     container.ID,
+			Error:  "conflict",
+			Reason: "label " + key + " is set by Docker and can't be removed or changed",
+		}, false
+	}
+	container.Labels = labels
+	return db.BulkResult{}, true
+}
+
+// getHostsByIDs fetches hosts by ID in a single query.
+func (s *Storage) getHostsByIDs(ids []string) ([]*models.Host, error) {
+	query := db.MangoQuery{
+		Selector: map[string]interface{}{
+			"_id": map[string]interface{}{
+				"$in": ids,
+			},
+		},
+		Limit: len(ids),
+	}
+
+	hosts, err := db.FindTyped[models.Host](s.service, query)
+	if err != nil {
+		return nil, err
+	}
+
+	// Deduplicate by ID (keep last occurrence), matching ListHosts
+	seen := make(map[string]int)
+	result := make([]*models.Host, 0, len(hosts))
+	for i := range hosts {
+		if idx, ok := seen[hosts[i].ID]; ok {
+			result[idx] = &hosts[i]
+			continue
+		}
+		seen[hosts[i].ID] = len(result)
+		result = append(result, &hosts[i])
+	}
+
+	return result, nil
+}
+
+// applyTags returns tags with add appended and remove filtered out, sorted and without duplicates.
+func applyTags(tags, add, remove []string) []string {
+	set := make(map[string]bool, len(tags)+len(add))
+	for _, tag := range tags {
+		set[tag] = true
+	}
+	for _, tag := range add {
+		if tag != "" {
+			set[tag] = true
+		}
+	}
+	for _, tag := range remove {
+		delete(set, tag)
+	}
+
+	result := make([]string, 0, len(set))
+	for tag := range set {
+		result = append(result, tag)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// orderBulkResults returns one result per requested id, in request order.
+// Ids that weren't found get a not_found result.
+func orderBulkResults(ids []string, results []db.BulkResult, found func(id string) bool) []db.BulkResult {
+	byID := make(map[string]db.BulkResult, len(results))
+	for _, result := range results {
+		byID[result.ID] = result
+	}
+
+	ordered := make([]db.BulkResult, 0, len(ids))
+	for _, id := range ids {
+		if result, ok := byID[id]; ok && found(id) {
+			ordered = append(ordered, result)
+			continue
+		}
+		ordered = append(ordered, db.BulkResult{
+			ID:     id,
+			Error:  "not_found",
+			Reason: "document does not exist",
+		})
+	}
+	return ordered
+}
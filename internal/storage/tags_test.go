@@ -0,0 +1,46 @@
+package storage
+
+import (
+	"testing"
+
+	"evalgo.org/graphium/models"
+)
+
+func TestUpdateContainerLabels(t *testing.T) {
+	newContainer := func() *models.Container {
+		return &models.Container{
+			ID:           "web-1",
+			Labels:       map[string]string{"app": "web", "tier": "front"},
+			DockerLabels: map[string]string{"app": "web"},
+		}
+	}
+
+	container := newContainer()
+	if _, ok := updateContainerLabels(container, map[string]string{"team": "core"}, []string{"tier"}); !ok {
+		t.Fatal("Expected labels added through the API to be updated")
+	}
+	if container.Labels["team"] != "core" || container.Labels["tier"] != "" || container.Labels["app"] != "web" {
+		t.Errorf("Unexpected labels %v", container.Labels)
+	}
+
+	for name, update := range map[string]struct {
+		set    map[string]string
+		remove []string
+	}{
+		"removed": {nil, []string{"app"}},
+		"changed": {map[string]string{"app": "api"}, nil},
+	} {
+		container := newContainer()
+		result, ok := updateContainerLabels(container, update.set, update.remove)
+		if ok {
+			t.Errorf("%s: expected a Docker label update to be refused", name)
+			continue
+		}
+		if result.ID != "web-1" || result.Error != "conflict" {
+			t.Errorf("%s: expected a conflict for web-1, got %+v", name, result)
+		}
+		if container.Labels["app"] != "web" || container.Labels["tier"] != "front" {
+			t.Errorf("%s: expected a refused update to keep the labels, got %v", name, container.Labels)
+		}
+	}
+}
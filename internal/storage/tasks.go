@@ -2,6 +2,7 @@ package storage
 
 import (
 	"fmt"
+	"sort"
 	"time"
 
 	"eve.evalgo.org/db"
@@ -37,7 +38,21 @@ func (s *Storage) CreateTask(task *models.AgentTask) error {
 		task.MaxRetries = 3
 	}
 
-	return s.SaveDocument(task)
+	if err := s.SaveDocument(task); err != nil {
+		return err
+	}
+
+	for _, listener := range s.taskCreated {
+		listener(task)
+	}
+	return nil
+}
+
+// OnTaskCreated registers a function called with every task CreateTask
+// saved, e.g. to notify the task's agent. Register listeners before the
+// storage serves requests.
+func (s *Storage) OnTaskCreated(listener func(*models.AgentTask)) {
+	s.taskCreated = append(s.taskCreated, listener)
 }
 
 // GetTask retrieves a task by ID.
@@ -87,7 +102,8 @@ func (s *Storage) ListTasks(filters map[string]interface{}) ([]*models.AgentTask
 	return result, nil
 }
 
-// GetTasksByAgent retrieves tasks for a specific agent with optional status filter.
+// GetTasksByAgent retrieves tasks for a specific agent with optional status filter,
+// ordered by priority (highest first) and creation time (oldest first).
 // If status is empty, returns all tasks for the agent.
 func (s *Storage) GetTasksByAgent(agentID string, status string) ([]*models.AgentTask, error) {
 	filters := map[string]interface{}{
@@ -98,7 +114,12 @@ func (s *Storage) GetTasksByAgent(agentID string, status string) ([]*models.Agen
 		filters["actionStatus"] = status
 	}
 
-	return s.ListTasks(filters)
+	tasks, err := s.ListTasks(filters)
+	if err != nil {
+		return nil, err
+	}
+	sortTasksByPriority(tasks)
+	return tasks, nil
 }
 
 // GetPendingTasksForAgent retrieves pending tasks for a specific agent,
@@ -135,32 +156,28 @@ func (s *Storage) GetPendingTasksForAgent(agentID string) ([]*models.AgentTask,
 	// Combine results
 	allTasks := append(pendingTasks, assignedTasks...)
 
-	// Sort by priority (desc) then by creation time (asc)
-	// Note: EVE's query builder doesn't support OrderBy, so we sort in memory
-	// For production, consider using CouchDB views with sorting
-	for i := 0; i < len(allTasks); i++ {
-		for j := i + 1; j < len(allTasks); j++ {
-			// Sort by priority descending (higher first)
-			if allTasks[i].Priority < allTasks[j].Priority {
-				allTasks[i], allTasks[j] = allTasks[j], allTasks[i]
-			} else if allTasks[i].Priority == allTasks[j].Priority {
-				// If same priority, sort by creation time ascending (older first)
-				if allTasks[i].CreatedAt.After(allTasks[j].CreatedAt) {
-					allTasks[i], allTasks[j] = allTasks[j], allTasks[i]
-				}
-			}
-		}
-	}
-
 	// Convert to pointer slice
 	result := make([]*models.AgentTask, len(allTasks))
 	for i := range allTasks {
 		result[i] = &allTasks[i]
 	}
+	sortTasksByPriority(result)
 
 	return result, nil
 }
 
+// sortTasksByPriority orders tasks by priority (highest first), then by
+// creation time (oldest first). EVE's query builder doesn't support OrderBy,
+// so tasks are sorted in memory.
+func sortTasksByPriority(tasks []*models.AgentTask) {
+	sort.SliceStable(tasks, func(i, j int) bool {
+		if tasks[i].Priority != tasks[j].Priority {
+			return tasks[i].Priority > tasks[j].Priority
+		}
+		return tasks[i].CreatedAt.Before(tasks[j].CreatedAt)
+	})
+}
+
 // GetTasksByStack retrieves all tasks for a specific stack.
 func (s *Storage) GetTasksByStack(stackID string) ([]*models.AgentTask, error) {
 	filters := map[string]interface{}{
@@ -285,47 +302,6 @@ func (s *Storage) FailTask(taskID string, errorMsg string) error {
 	return s.UpdateTask(task)
 }
 
-// RetryTask creates a new task based on a failed task.
-func (s *Storage) RetryTask(taskID string) (*models.AgentTask, error) {
-	originalTask, err := s.GetTask(taskID)
-	if err != nil {
-		return nil, err
-	}
-
-	if !originalTask.CanRetry() {
-		return nil, fmt.Errorf("task has exceeded max retries (%d)", originalTask.MaxRetries)
-	}
-
-	// Create new task with same parameters
-	newTask := &models.AgentTask{
-		ID:             fmt.Sprintf("%s-retry-%d", originalTask.ID, originalTask.RetryCount+1),
-		Context:        "https://schema.org",
-		Type:           originalTask.Type,
-		ActionStatus:   models.TaskStatusPending,
-		Agent:          originalTask.Agent,
-		Object:         originalTask.Object,
-		Instrument:     originalTask.Instrument,
-		HostID:         originalTask.HostID,
-		StackID:        originalTask.StackID,
-		ContainerID:    originalTask.ContainerID,
-		Priority:       originalTask.Priority,
-		CreatedAt:      time.Now(),
-		CreatedBy:      originalTask.CreatedBy,
-		RetryCount:     originalTask.RetryCount + 1,
-		MaxRetries:     originalTask.MaxRetries,
-		TimeoutSeconds: originalTask.TimeoutSeconds,
-		DependsOn:      originalTask.DependsOn,
-		Schedule:       originalTask.Schedule,
-		Properties:     originalTask.Properties,
-	}
-
-	if err := s.CreateTask(newTask); err != nil {
-		return nil, err
-	}
-
-	return newTask, nil
-}
-
 // CancelTask marks a task as cancelled.
 func (s *Storage) CancelTask(taskID string) error {
 	return s.UpdateTaskStatus(taskID, "cancelled", "")
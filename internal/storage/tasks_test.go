@@ -0,0 +1,29 @@
+package storage
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"evalgo.org/graphium/models"
+)
+
+func TestSortTasksByPriority(t *testing.T) {
+	now := time.Now()
+	tasks := []*models.AgentTask{
+		{ID: "slow-old", Priority: 0, CreatedAt: now.Add(-time.Hour)},
+		{ID: "fast-new", Priority: 5, CreatedAt: now},
+		{ID: "slow-new", Priority: 0, CreatedAt: now},
+		{ID: "fast-old", Priority: 5, CreatedAt: now.Add(-time.Minute)},
+	}
+	sortTasksByPriority(tasks)
+
+	ids := make([]string, len(tasks))
+	for i, task := range tasks {
+		ids[i] = task.ID
+	}
+	want := []string{"fast-old", "fast-new", "slow-old", "slow-new"}
+	if !reflect.DeepEqual(ids, want) {
+		t.Errorf("Expected %v, got %v", want, ids)
+	}
+}
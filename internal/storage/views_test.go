@@ -0,0 +1,50 @@
+package storage
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"evalgo.org/graphium/models"
+)
+
+// jsonField returns the JSON name of a struct field.
+func jsonField(t *testing.T, v interface{}, field string) string {
+	t.Helper()
+	f, ok := reflect.TypeOf(v).FieldByName(field)
+	if !ok {
+		t.Fatalf("%T has no field %s", v, field)
+	}
+	return strings.Split(f.Tag.Get("json"), ",")[0]
+}
+
+func TestGraphiumViews_MatchStoredFields(t *testing.T) {
+	views := graphiumDesignDoc().Views
+
+	tests := []struct {
+		view  string
+		doc   interface{}
+		field string
+	}{
+		{"containers_by_image", models.Container{}, "Image"},
+		{"containers_by_status", models.Container{}, "Status"},
+		{"containers_by_host", models.Container{}, "HostedOn"},
+		{"containers_by_label", models.Container{}, "Labels"},
+		{"stacks_by_container", models.Stack{}, "Containers"},
+		{"stacks_by_status", models.Stack{}, "Status"},
+		{"stacks_by_datacenter", models.Stack{}, "Datacenter"},
+		{"deployments_by_stack", models.DeploymentState{}, "StackID"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.view, func(t *testing.T) {
+			view, ok := views[tt.view]
+			if !ok {
+				t.Fatalf("View %s is not defined", tt.view)
+			}
+			name := jsonField(t, tt.doc, tt.field)
+			if !strings.Contains(view.Map, "doc."+name) {
+				t.Errorf("View %s doesn't read doc.%s, the stored name of %T.%s", tt.view, name, tt.doc, tt.field)
+			}
+		})
+	}
+}
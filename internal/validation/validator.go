@@ -321,13 +321,14 @@ func (v *Validator) validateHostFields(host *models.Host) []ValidationError {
 		"inactive":    true,
 		"maintenance": true,
 		"unreachable": true,
+		"draining":    true,
 	}
 
 	if host.Status != "" && !validStatuses[host.Status] {
 		errors = append(errors, ValidationError{
 			Field: "status",
 			Message: fmt.Sprintf("Invalid status: must be one of: %s",
-				strings.Join([]string{"active", "inactive", "maintenance", "unreachable"}, ", ")),
+				strings.Join([]string{"active", "inactive", "maintenance", "unreachable", "draining"}, ", ")),
 			Value: host.Status,
 		})
 	}
@@ -359,6 +360,15 @@ func (v *Validator) validateHostFields(host *models.Host) []ValidationError {
 		})
 	}
 
+	// Validate the Docker connection settings
+	if err := host.ValidateDockerEndpoint(); err != nil {
+		errors = append(errors, ValidationError{
+			Field:   "dockerEndpoint",
+			Message: err.Error(),
+			Value:   host.DockerEndpoint,
+		})
+	}
+
 	return errors
 }
 
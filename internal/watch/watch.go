@@ -0,0 +1,369 @@
+// Package watch keeps short-lived, per-user subscriptions to container state
+// changes for ad-hoc debugging.
+//
+// A watch is registered for one container and expires after a configurable
+// duration. The API server reports every container it stores to Observe;
+// when the status of a watched container changes, the registry calls the
+// OnChange callback (used for targeted WebSocket messages) and POSTs the
+// change to the watch's webhook, if it has one.
+//
+// Webhooks may only target public addresses, unless an allowlist of
+// webhook hosts is configured, in which case only those hosts are accepted.
+// Each user may hold a limited number of watches.
+//
+// Watches are kept in memory only and are lost on restart.
+//
+// Example usage:
+//
+//	registry := watch.NewRegistry(30*time.Minute, 24*time.Hour)
+//	registry.OnChange(func(change watch.Change) { ... })
+//	w, err := registry.Add(containerID, userID, "", 0, container.Status)
+//	registry.Observe(container.ID, container.Name, container.Status)
+package watch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"sort"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrNotFound is returned when a watch does not exist, has expired, or
+// belongs to another user.
+var ErrNotFound = errors.New("watch not found")
+
+// ErrLimitReached is returned when a user already holds the maximum number
+// of watches.
+var ErrLimitReached = errors.New("watch limit reached")
+
+// Watch is a subscription to state changes of a single container.
+type Watch struct {
+	ID          string    `json:"id"`
+	ContainerID string    `json:"containerId"`
+	UserID      string    `json:"userId"`
+	WebhookURL  string    `json:"webhookUrl,omitempty"`
+	CreatedAt   time.Time `json:"createdAt"`
+	ExpiresAt   time.Time `json:"expiresAt"`
+
+	// LastStatus is the container status last seen by the watch
+	LastStatus string `json:"lastStatus,omitempty"`
+
+	// Notifications is the number of changes reported so far
+	Notifications int `json:"notifications"`
+}
+
+// Change describes a status change of a watched container.
+type Change struct {
+	WatchID       string    `json:"watchId"`
+	UserID        string    `json:"userId"`
+	ContainerID   string    `json:"containerId"`
+	ContainerName string    `json:"containerName,omitempty"`
+	OldStatus     string    `json:"oldStatus"`
+	NewStatus     string    `json:"newStatus"`
+	ChangedAt     time.Time `json:"changedAt"`
+}
+
+// Registry holds the active watches.
+type Registry struct {
+	mu      sync.Mutex
+	watches map[string]*Watch
+
+	defaultDuration time.Duration
+	maxDuration     time.Duration
+	maxPerUser      int
+	webhookHosts    []string
+
+	onChange      func(change Change)
+	webhookClient *http.Client // Refuses to connect to non-public addresses
+	trustedClient *http.Client // For allowlisted webhook hosts
+
+	// now is replaceable in tests
+	now func() time.Time
+}
+
+// NewRegistry creates a registry. Watches registered without a duration use
+// defaultDuration; no watch may live longer than maxDuration.
+func NewRegistry(defaultDuration, maxDuration time.Duration) *Registry {
+	return &Registry{
+		watches:         make(map[string]*Watch),
+		defaultDuration: defaultDuration,
+		maxDuration:     maxDuration,
+		webhookClient:   newWebhookClient(publicAddressOnly),
+		trustedClient:   newWebhookClient(nil),
+		now:             time.Now,
+	}
+}
+
+// SetMaxPerUser caps the number of active watches of a single user
+// (0 = unlimited).
+func (r *Registry) SetMaxPerUser(n int) {
+	r.maxPerUser = n
+}
+
+// SetWebhookAllowlist restricts webhooks to the given hosts, which may then
+// also be private addresses. With no allowlist, webhooks may target any
+// host with public addresses only.
+func (r *Registry) SetWebhookAllowlist(hosts []string) {
+	r.webhookHosts = hosts
+}
+
+// OnChange registers a callback invoked for every change of a watched container.
+func (r *Registry) OnChange(fn func(change Change)) {
+	r.onChange = fn
+}
+
+// Add registers a watch on a container for a user. currentStatus is the
+// container's status now, so only later changes are reported.
+func (r *Registry) Add(containerID, userID, webhookURL string, duration time.Duration, currentStatus string) (*Watch, error) {
+	if duration < 0 {
+		return nil, fmt.Errorf("duration must not be negative")
+	}
+	if duration == 0 {
+		duration = r.defaultDuration
+	}
+	if r.maxDuration > 0 && duration > r.maxDuration {
+		return nil, fmt.Errorf("duration %s exceeds the maximum of %s", duration, r.maxDuration)
+	}
+	if webhookURL != "" {
+		if err := r.checkWebhook(webhookURL); err != nil {
+			return nil, err
+		}
+	}
+
+	now := r.now()
+	w := &Watch{
+		ID:          uuid.New().String(),
+		ContainerID: containerID,
+		UserID:      userID,
+		WebhookURL:  webhookURL,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(duration),
+		LastStatus:  currentStatus,
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.expireLocked(now)
+	if r.maxPerUser > 0 && r.countLocked(userID) >= r.maxPerUser {
+		return nil, fmt.Errorf("%w: at most %d active watches per user", ErrLimitReached, r.maxPerUser)
+	}
+	r.watches[w.ID] = w
+
+	copied := *w
+	return &copied, nil
+}
+
+// List returns the active watches of a user, or of all users if userID is
+// empty, ordered by creation time.
+func (r *Registry) List(userID string) []Watch {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.expireLocked(r.now())
+
+	watches := []Watch{}
+	for _, w := range r.watches {
+		if userID == "" || w.UserID == userID {
+			watches = append(watches, *w)
+		}
+	}
+	sort.Slice(watches, func(i, j int) bool {
+		return watches[i].CreatedAt.Before(watches[j].CreatedAt)
+	})
+	return watches
+}
+
+// Cancel removes a watch. A non-empty userID restricts cancellation to that
+// user's watches.
+func (r *Registry) Cancel(id, userID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.expireLocked(r.now())
+
+	w, ok := r.watches[id]
+	if !ok || (userID != "" && w.UserID != userID) {
+		return ErrNotFound
+	}
+	delete(r.watches, id)
+	return nil
+}
+
+// Observe reports the current status of a container. Every active watch on
+// the container whose last seen status differs is notified.
+func (r *Registry) Observe(containerID, containerName, status string) []Change {
+	now := r.now()
+
+	r.mu.Lock()
+	r.expireLocked(now)
+
+	var changes []Change
+	var webhooks []string
+	for _, w := range r.watches {
+		if w.ContainerID != containerID || w.LastStatus == status {
+			continue
+		}
+		changes = append(changes, Change{
+			WatchID:       w.ID,
+			UserID:        w.UserID,
+			ContainerID:   containerID,
+			ContainerName: containerName,
+			OldStatus:     w.LastStatus,
+			NewStatus:     status,
+			ChangedAt:     now,
+		})
+		webhooks = append(webhooks, w.WebhookURL)
+		w.LastStatus = status
+		w.Notifications++
+	}
+	r.mu.Unlock()
+
+	for i, change := range changes {
+		if r.onChange != nil {
+			r.onChange(change)
+		}
+		if webhooks[i] != "" {
+			go r.postWebhook(webhooks[i], change)
+		}
+	}
+	return changes
+}
+
+// expireLocked drops expired watches. r.mu must be held.
+func (r *Registry) expireLocked(now time.Time) {
+	for id, w := range r.watches {
+		if !now.Before(w.ExpiresAt) {
+			delete(r.watches, id)
+		}
+	}
+}
+
+// countLocked returns the number of watches of a user. r.mu must be held.
+func (r *Registry) countLocked(userID string) int {
+	n := 0
+	for _, w := range r.watches {
+		if w.UserID == userID {
+			n++
+		}
+	}
+	return n
+}
+
+// checkWebhook checks that a webhook URL is an http(s) URL of an
+// allowlisted host or, without an allowlist, of a host whose addresses are
+// all public.
+func (r *Registry) checkWebhook(webhookURL string) error {
+	parsed, err := url.Parse(webhookURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return fmt.Errorf("webhook URL must be an absolute http or https URL")
+	}
+	host := parsed.Hostname()
+	if len(r.webhookHosts) > 0 {
+		if !slices.Contains(r.webhookHosts, host) {
+			return fmt.Errorf("webhook host %s is not allowed", host)
+		}
+		return nil
+	}
+
+	ips := []net.IP{net.ParseIP(host)}
+	if ips[0] == nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+		if err != nil {
+			return fmt.Errorf("cannot resolve webhook host %s: %w", host, err)
+		}
+		ips = ips[:0]
+		for _, addr := range addrs {
+			ips = append(ips, addr.IP)
+		}
+	}
+	for _, ip := range ips {
+		if !isPublic(ip) {
+			return fmt.Errorf("webhook URL must not target a loopback, private or link-local address (%s)", ip)
+		}
+	}
+	return nil
+}
+
+// isPublic reports whether an IP address is routable on the internet, as
+// opposed to loopback, private, link-local, multicast or unspecified.
+func isPublic(ip net.IP) bool {
+	return !(ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() ||
+		ip.IsMulticast() || ip.IsUnspecified())
+}
+
+// publicAddressOnly is a net.Dialer Control function refusing connections
+// to non-public addresses, so a webhook host can't be re-resolved to one
+// after it was checked.
+func publicAddressOnly(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if ip := net.ParseIP(host); ip == nil || !isPublic(ip) {
+		return fmt.Errorf("refusing to connect to non-public address %s", host)
+	}
+	return nil
+}
+
+// newWebhookClient returns the HTTP client delivering webhooks. It doesn't
+// use proxies or follow redirects, and control (if set) vets each
+// connection.
+func newWebhookClient(control func(network, address string, c syscall.RawConn) error) *http.Client {
+	dialer := &net.Dialer{Timeout: 5 * time.Second, Control: control}
+	return &http.Client{
+		Timeout:   10 * time.Second,
+		Transport: &http.Transport{DialContext: dialer.DialContext},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// postWebhook delivers a change to a watch's webhook.
+func (r *Registry) postWebhook(webhookURL string, change Change) {
+	payload, err := json.Marshal(map[string]interface{}{
+		"event":  "container_watch",
+		"change": change,
+	})
+	if err != nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhookURL, bytes.NewReader(payload))
+	if err != nil {
+		log.Printf("Warning: failed to create watch webhook request: %v", err)
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := r.webhookClient
+	if len(r.webhookHosts) > 0 {
+		client = r.trustedClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		log.Printf("Warning: watch webhook failed: %v", err)
+		return
+	}
+	_ = resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		log.Printf("Warning: watch webhook returned %s", resp.Status)
+	}
+}
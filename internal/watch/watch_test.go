@@ -0,0 +1,176 @@
+package watch
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRegistry_ObserveReportsStatusChanges(t *testing.T) {
+	registry := NewRegistry(time.Minute, time.Hour)
+
+	var notified []Change
+	registry.OnChange(func(change Change) {
+		notified = append(notified, change)
+	})
+
+	w, err := registry.Add("web-1", "alice", "", 0, "running")
+	if err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	if w.ExpiresAt.Sub(w.CreatedAt) != time.Minute {
+		t.Errorf("Expected default duration of 1m, got %s", w.ExpiresAt.Sub(w.CreatedAt))
+	}
+
+	if changes := registry.Observe("web-1", "web", "running"); len(changes) != 0 {
+		t.Errorf("Expected no change for the same status, got %+v", changes)
+	}
+	if changes := registry.Observe("db-1", "db", "exited"); len(changes) != 0 {
+		t.Errorf("Expected no change for another container, got %+v", changes)
+	}
+
+	changes := registry.Observe("web-1", "web", "exited")
+	if len(changes) != 1 || changes[0].OldStatus != "running" || changes[0].NewStatus != "exited" || changes[0].UserID != "alice" {
+		t.Fatalf("Unexpected changes: %+v", changes)
+	}
+	if len(notified) != 1 {
+		t.Errorf("Expected the callback to be called once, got %d", len(notified))
+	}
+
+	watches := registry.List("alice")
+	if len(watches) != 1 || watches[0].LastStatus != "exited" || watches[0].Notifications != 1 {
+		t.Errorf("Unexpected watch state: %+v", watches)
+	}
+}
+
+func TestRegistry_Expiry(t *testing.T) {
+	registry := NewRegistry(time.Minute, time.Hour)
+	now := time.Now()
+	registry.now = func() time.Time { return now }
+
+	if _, err := registry.Add("web-1", "alice", "", 0, "running"); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+
+	now = now.Add(2 * time.Minute)
+	if changes := registry.Observe("web-1", "web", "exited"); len(changes) != 0 {
+		t.Errorf("Expected expired watch to stay silent, got %+v", changes)
+	}
+	if watches := registry.List(""); len(watches) != 0 {
+		t.Errorf("Expected expired watch to be removed, got %+v", watches)
+	}
+}
+
+func TestRegistry_AddValidation(t *testing.T) {
+	registry := NewRegistry(time.Minute, time.Hour)
+
+	if _, err := registry.Add("web-1", "alice", "", 2*time.Hour, ""); err == nil {
+		t.Error("Expected an error for a duration above the maximum")
+	}
+	if _, err := registry.Add("web-1", "alice", "", -time.Second, ""); err == nil {
+		t.Error("Expected an error for a negative duration")
+	}
+	if _, err := registry.Add("web-1", "alice", "ftp://example.com/hook", 0, ""); err == nil {
+		t.Error("Expected an error for a non-http webhook")
+	}
+}
+
+func TestRegistry_CancelIsScopedToUser(t *testing.T) {
+	registry := NewRegistry(time.Minute, time.Hour)
+	w, err := registry.Add("web-1", "alice", "", 0, "running")
+	if err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+
+	if err := registry.Cancel(w.ID, "bob"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for another user, got %v", err)
+	}
+	if err := registry.Cancel(w.ID, "alice"); err != nil {
+		t.Errorf("Cancel failed: %v", err)
+	}
+	if err := registry.Cancel(w.ID, ""); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound after cancel, got %v", err)
+	}
+}
+
+func TestRegistry_Webhook(t *testing.T) {
+	received := make(chan Change, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Event  string `json:"event"`
+			Change Change `json:"change"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Event != "container_watch" {
+			t.Errorf("Unexpected webhook body: %+v (%v)", body, err)
+		}
+		received <- body.Change
+	}))
+	defer server.Close()
+
+	registry := NewRegistry(time.Minute, time.Hour)
+	registry.SetWebhookAllowlist([]string{"127.0.0.1"})
+	if _, err := registry.Add("web-1", "alice", server.URL, 0, "running"); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	registry.Observe("web-1", "web", "restarting")
+
+	select {
+	case change := <-received:
+		if change.NewStatus != "restarting" {
+			t.Errorf("Unexpected webhook change: %+v", change)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Webhook was not called")
+	}
+}
+
+func TestRegistry_RejectsPrivateWebhooks(t *testing.T) {
+	registry := NewRegistry(time.Minute, time.Hour)
+	for _, target := range []string{
+		"http://127.0.0.1:8080/hook",
+		"http://[::1]/hook",
+		"http://10.0.0.5/hook",
+		"http://192.168.1.1/hook",
+		"http://169.254.169.254/latest/meta-data",
+		"http://0.0.0.0/hook",
+	} {
+		if _, err := registry.Add("web-1", "alice", target, 0, ""); err == nil {
+			t.Errorf("Expected webhook %s to be rejected", target)
+		}
+	}
+	if _, err := registry.Add("web-1", "alice", "https://203.0.113.10/hook", 0, ""); err != nil {
+		t.Errorf("Expected public webhook to be accepted, got %v", err)
+	}
+}
+
+func TestRegistry_WebhookAllowlist(t *testing.T) {
+	registry := NewRegistry(time.Minute, time.Hour)
+	registry.SetWebhookAllowlist([]string{"10.0.0.5"})
+
+	if _, err := registry.Add("web-1", "alice", "http://10.0.0.5/hook", 0, ""); err != nil {
+		t.Errorf("Expected allowlisted webhook to be accepted, got %v", err)
+	}
+	if _, err := registry.Add("web-1", "alice", "https://203.0.113.10/hook", 0, ""); err == nil {
+		t.Error("Expected webhook outside the allowlist to be rejected")
+	}
+}
+
+func TestRegistry_MaxPerUser(t *testing.T) {
+	registry := NewRegistry(time.Minute, time.Hour)
+	registry.SetMaxPerUser(2)
+
+	for i := 0; i < 2; i++ {
+		if _, err := registry.Add("web-1", "alice", "", 0, ""); err != nil {
+			t.Fatalf("Add %d failed: %v", i+1, err)
+		}
+	}
+	if _, err := registry.Add("web-1", "alice", "", 0, ""); !errors.Is(err, ErrLimitReached) {
+		t.Errorf("Expected ErrLimitReached, got %v", err)
+	}
+	if _, err := registry.Add("web-1", "bob", "", 0, ""); err != nil {
+		t.Errorf("Expected another user's watch to be accepted, got %v", err)
+	}
+}
@@ -0,0 +1,139 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+// MaxActionChainDepth is the maximum number of scheduled actions in one
+// chain of OnSuccess/OnFailure triggers, including the first.
+const MaxActionChainDepth = 5
+
+// ActionTrigger records the finished task whose result triggered a chained
+// action. It is stored on the triggered task and passed to the agent in the
+// task payload as "trigger".
+type ActionTrigger struct {
+	// TaskID is the finished task
+	TaskID string `json:"taskId"`
+
+	// ActionID is the scheduled action that created the finished task
+	ActionID string `json:"actionId"`
+
+	// Success is whether the finished task succeeded
+	Success bool `json:"success"`
+
+	// ContainerID is the container the finished task acted on, if any
+	ContainerID string `json:"containerId,omitempty"`
+
+	// Message is the result or error message of the finished task
+	Message string `json:"message,omitempty"`
+}
+
+// TaskOutcome reports whether a task status is final and, if so, whether
+// the task succeeded. Both the canonical action statuses and the short
+// forms accepted by the task status API (completed, failed, cancelled) are
+// recognized.
+func TaskOutcome(status string) (finished, success bool) {
+	switch status {
+	case TaskStatusCompleted, "completed":
+		return true, true
+	case TaskStatusFailed, "failed", "cancelled":
+		return true, false
+	}
+	return false, false
+}
+
+// Run conditions of an action that depends on another: which outcomes of
+// the other action's runs trigger it.
+const (
+	RunOnSuccess = "on-success"
+	RunOnFailure = "on-failure"
+	RunAlways    = "always"
+)
+
+// ValidateRunCondition checks the run condition of a dependent action.
+func ValidateRunCondition(condition string) error {
+	switch condition {
+	case "", RunOnSuccess, RunOnFailure, RunAlways:
+		return nil
+	}
+	return fmt.Errorf("runCondition must be on-success, on-failure or always, got %q", condition)
+}
+
+// RunsAfter reports whether an action that depends on another runs after a
+// run of it with the given outcome.
+func (a *ScheduledAction) RunsAfter(success bool) bool {
+	switch a.RunCondition {
+	case RunAlways:
+		return true
+	case RunOnFailure:
+		return !success
+	default:
+		return success
+	}
+}
+
+// ChainLinks returns the IDs of the actions a run of action can trigger:
+// its OnSuccess and OnFailure actions and the actions depending on it.
+func ChainLinks(action *ScheduledAction, actions []*ScheduledAction) []string {
+	var links []string
+	for _, id := range []string{action.OnSuccess, action.OnFailure} {
+		if id != "" {
+			links = append(links, id)
+		}
+	}
+	for _, other := range actions {
+		if other.DependsOn == action.ID {
+			links = append(links, other.ID)
+		}
+	}
+	return links
+}
+
+// FindActionCycle returns a chain of triggers leading from the action with
+// ID start back to it, e.g. [check restart check], or nil if there is none.
+func FindActionCycle(actions []*ScheduledAction, start string) []string {
+	byID := make(map[string]*ScheduledAction, len(actions))
+	for _, action := range actions {
+		byID[action.ID] = action
+	}
+
+	visited := make(map[string]bool)
+	var path []string
+	var visit func(id string) bool
+	visit = func(id string) bool {
+		path = append(path, id)
+		if len(path) > 1 && id == start {
+			return true
+		}
+		if action := byID[id]; action != nil && !visited[id] {
+			visited[id] = true
+			for _, next := range ChainLinks(action, actions) {
+				if visit(next) {
+					return true
+				}
+			}
+		}
+		path = path[:len(path)-1]
+		return false
+	}
+	if visit(start) {
+		return path
+	}
+	return nil
+}
+
+// CheckActionChain returns an error if running next after the actions in
+// path would form a cycle or exceed MaxActionChainDepth.
+func CheckActionChain(path []string, next string) error {
+	for _, id := range path {
+		if id == next {
+			return fmt.Errorf("action chain cycle: %s -> %s", strings.Join(path, " -> "), next)
+		}
+	}
+	if len(path)+1 > MaxActionChainDepth {
+		return fmt.Errorf("action chain exceeds the maximum depth of %d: %s -> %s",
+			MaxActionChainDepth, strings.Join(path, " -> "), next)
+	}
+	return nil
+}
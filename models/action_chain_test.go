@@ -0,0 +1,97 @@
+package models
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCheckActionChain(t *testing.T) {
+	if err := CheckActionChain([]string{"check"}, "restart"); err != nil {
+		t.Errorf("Expected a two-step chain to be allowed, got %v", err)
+	}
+	if err := CheckActionChain([]string{"check", "restart"}, "check"); err == nil {
+		t.Error("Expected a cycle to be rejected")
+	}
+
+	path := []string{"a1", "a2", "a3", "a4", "a5"}
+	if err := CheckActionChain(path[:4], "a5"); err != nil {
+		t.Errorf("Expected a chain of %d actions to be allowed, got %v", MaxActionChainDepth, err)
+	}
+	if err := CheckActionChain(path, "a6"); err == nil {
+		t.Errorf("Expected a chain longer than %d actions to be rejected", MaxActionChainDepth)
+	}
+}
+
+func TestTaskOutcome(t *testing.T) {
+	tests := []struct {
+		status            string
+		finished, success bool
+	}{
+		{TaskStatusCompleted, true, true},
+		{"completed", true, true},
+		{TaskStatusFailed, true, false},
+		{"cancelled", true, false},
+		{TaskStatusRunning, false, false},
+		{"pending", false, false},
+	}
+	for _, tt := range tests {
+		finished, success := TaskOutcome(tt.status)
+		if finished != tt.finished || success != tt.success {
+			t.Errorf("TaskOutcome(%q) = %v, %v, want %v, %v", tt.status, finished, success, tt.finished, tt.success)
+		}
+	}
+}
+
+func TestScheduledAction_RunsAfter(t *testing.T) {
+	tests := []struct {
+		condition string
+		success   bool
+		want      bool
+	}{
+		{"", true, true},
+		{"", false, false},
+		{RunOnSuccess, false, false},
+		{RunOnFailure, false, true},
+		{RunOnFailure, true, false},
+		{RunAlways, true, true},
+		{RunAlways, false, true},
+	}
+	for _, tt := range tests {
+		action := &ScheduledAction{DependsOn: "check", RunCondition: tt.condition}
+		if got := action.RunsAfter(tt.success); got != tt.want {
+			t.Errorf("RunsAfter(%v) with runCondition %q = %v, want %v", tt.success, tt.condition, got, tt.want)
+		}
+	}
+
+	if err := ValidateRunCondition("on-error"); err == nil {
+		t.Error("Expected an unknown run condition to be rejected")
+	}
+}
+
+func TestFindActionCycle(t *testing.T) {
+	// check fails -> restart -> recheck, and restart also notifies
+	check := &ScheduledAction{ID: "check"}
+	restart := &ScheduledAction{ID: "restart", DependsOn: "check", RunCondition: RunOnFailure, OnSuccess: "recheck"}
+	recheck := &ScheduledAction{ID: "recheck"}
+	notify := &ScheduledAction{ID: "notify", DependsOn: "restart", RunCondition: RunAlways}
+	actions := []*ScheduledAction{check, restart, recheck, notify}
+
+	for _, action := range actions {
+		if cycle := FindActionCycle(actions, action.ID); cycle != nil {
+			t.Errorf("Expected no cycle through %s, got %v", action.ID, cycle)
+		}
+	}
+
+	// recheck closing the loop through a dependsOn link
+	check.DependsOn = "recheck"
+	if cycle := FindActionCycle(actions, "check"); strings.Join(cycle, " ") != "check restart recheck check" {
+		t.Errorf("Expected the cycle check -> restart -> recheck -> check, got %v", cycle)
+	}
+
+	// and through an onFailure link
+	check.DependsOn = ""
+	recheck.OnFailure = "restart"
+	if cycle := FindActionCycle(actions, "recheck"); strings.Join(cycle, " ") != "recheck restart recheck" {
+		t.Errorf("Expected the cycle recheck -> restart -> recheck, got %v", cycle)
+	}
+}
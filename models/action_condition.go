@@ -0,0 +1,201 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Condition sources: what an ActionCondition's field is read from.
+const (
+	// ConditionSourceContainer reads the current container document
+	ConditionSourceContainer = "container"
+
+	// ConditionSourceHost reads the current host document
+	ConditionSourceHost = "host"
+
+	// ConditionSourceCheck reads the result of the latest finished task of
+	// a scheduled action
+	ConditionSourceCheck = "check"
+)
+
+// Condition operators.
+const (
+	ConditionEq       = "eq"
+	ConditionNe       = "ne"
+	ConditionGt       = "gt"
+	ConditionGte      = "gte"
+	ConditionLt       = "lt"
+	ConditionLte      = "lte"
+	ConditionContains = "contains"
+	ConditionExists   = "exists"
+)
+
+// ActionCondition is a predicate the scheduler evaluates before running a
+// scheduled action; the run is skipped when it doesn't hold.
+//
+// Example: restart a container only if it is unhealthy
+//
+//	{"source": "container", "field": "health", "operator": "eq", "value": "unhealthy"}
+type ActionCondition struct {
+	// Source is what Field is read from: container, host or check
+	Source string `json:"source"`
+
+	// TargetID is the container or host to read. Defaults to the action's
+	// object for containers and to the action's agent for hosts.
+	TargetID string `json:"targetId,omitempty"`
+
+	// ActionID is the scheduled action whose latest result a check condition
+	// reads. Defaults to the action itself.
+	ActionID string `json:"actionId,omitempty"`
+
+	// Field is the JSON field to test, dotted for nested fields, e.g.
+	// "health", "cpuUsage" or "data.statusCode". Check conditions read the
+	// task result (success, message, data) plus "status", the task's
+	// action status.
+	Field string `json:"field"`
+
+	// Operator is eq, ne, gt, gte, lt, lte, contains or exists
+	Operator string `json:"operator"`
+
+	// Value is compared with the field; unused for exists
+	Value interface{} `json:"value,omitempty"`
+}
+
+// Validate checks that the condition's source and operator are known.
+func (c *ActionCondition) Validate() error {
+	switch c.Source {
+	case ConditionSourceContainer, ConditionSourceHost, ConditionSourceCheck:
+	default:
+		return fmt.Errorf("condition source must be container, host or check, got %q", c.Source)
+	}
+	if c.Field == "" {
+		return fmt.Errorf("condition field is required")
+	}
+	switch c.Operator {
+	case ConditionEq, ConditionNe, ConditionContains, ConditionExists:
+	case ConditionGt, ConditionGte, ConditionLt, ConditionLte:
+		if _, ok := toFloat(c.Value); !ok {
+			return fmt.Errorf("condition operator %s requires a numeric value", c.Operator)
+		}
+	default:
+		return fmt.Errorf("unknown condition operator %q", c.Operator)
+	}
+	return nil
+}
+
+// String describes the condition, e.g. "container health eq unhealthy".
+func (c *ActionCondition) String() string {
+	if c.Operator == ConditionExists {
+		return fmt.Sprintf("%s %s exists", c.Source, c.Field)
+	}
+	return fmt.Sprintf("%s %s %s %v", c.Source, c.Field, c.Operator, c.Value)
+}
+
+// Evaluate reports whether the condition holds for subject, which is
+// converted to its JSON form before Field is looked up.
+func (c *ActionCondition) Evaluate(subject interface{}) (bool, error) {
+	data, err := json.Marshal(subject)
+	if err != nil {
+		return false, fmt.Errorf("failed to read condition subject: %w", err)
+	}
+	var doc map[string]interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return false, fmt.Errorf("failed to read condition subject: %w", err)
+	}
+
+	value, found := lookupField(doc, c.Field)
+	if c.Operator == ConditionExists {
+		return found && value != nil, nil
+	}
+	if !found {
+		// A missing field only differs from any value
+		return c.Operator == ConditionNe, nil
+	}
+
+	switch c.Operator {
+	case ConditionEq:
+		return valuesEqual(value, c.Value), nil
+	case ConditionNe:
+		return !valuesEqual(value, c.Value), nil
+	case ConditionContains:
+		return containsValue(value, c.Value), nil
+	}
+
+	actual, ok := toFloat(value)
+	if !ok {
+		return false, fmt.Errorf("field %s is not numeric: %v", c.Field, value)
+	}
+	want, _ := toFloat(c.Value)
+	switch c.Operator {
+	case ConditionGt:
+		return actual > want, nil
+	case ConditionGte:
+		return actual >= want, nil
+	case ConditionLt:
+		return actual < want, nil
+	case ConditionLte:
+		return actual <= want, nil
+	}
+	return false, fmt.Errorf("unknown condition operator %q", c.Operator)
+}
+
+// lookupField returns the value at a dotted path in a JSON document.
+func lookupField(doc map[string]interface{}, path string) (interface{}, bool) {
+	var current interface{} = doc
+	for _, key := range strings.Split(path, ".") {
+		object, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if current, ok = object[key]; !ok {
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+// valuesEqual compares a JSON value with a condition value, numerically
+// when both are numbers.
+func valuesEqual(actual, want interface{}) bool {
+	if a, ok := toFloat(actual); ok {
+		if w, ok := toFloat(want); ok {
+			return a == w
+		}
+	}
+	return reflect.DeepEqual(actual, want) || fmt.Sprint(actual) == fmt.Sprint(want)
+}
+
+// containsValue reports whether a string contains want as a substring or a
+// list contains want as an element.
+func containsValue(actual, want interface{}) bool {
+	switch v := actual.(type) {
+	case string:
+		return strings.Contains(v, fmt.Sprint(want))
+	case []interface{}:
+		for _, item := range v {
+			if valuesEqual(item, want) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// toFloat converts a JSON number, or a string holding one, to a float.
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case string:
+		f, err := strconv.ParseFloat(n, 64)
+		return f, err == nil
+	}
+	return 0, false
+}
@@ -0,0 +1,96 @@
+package models
+
+import "testing"
+
+func TestActionCondition_Validate(t *testing.T) {
+	tests := []struct {
+		name      string
+		condition ActionCondition
+		wantErr   bool
+	}{
+		{"valid eq", ActionCondition{Source: "container", Field: "health", Operator: "eq", Value: "unhealthy"}, false},
+		{"valid gt", ActionCondition{Source: "host", Field: "cpuUsage", Operator: "gt", Value: 80.0}, false},
+		{"valid exists", ActionCondition{Source: "check", Field: "data.error", Operator: "exists"}, false},
+		{"unknown source", ActionCondition{Source: "stack", Field: "status", Operator: "eq"}, true},
+		{"missing field", ActionCondition{Source: "host", Operator: "eq"}, true},
+		{"unknown operator", ActionCondition{Source: "host", Field: "status", Operator: "like"}, true},
+		{"non-numeric gt", ActionCondition{Source: "host", Field: "cpuUsage", Operator: "gt", Value: "high"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.condition.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestActionCondition_Evaluate(t *testing.T) {
+	container := &Container{Name: "web", Status: "running", Health: "unhealthy", Labels: map[string]string{"team": "web"}}
+	host := &Host{Name: "node-1", CPUUsage: 85.5}
+	check := map[string]interface{}{
+		"success": false,
+		"data":    map[string]interface{}{"statusCode": 503, "tags": []interface{}{"a", "b"}},
+	}
+
+	tests := []struct {
+		name      string
+		condition ActionCondition
+		subject   interface{}
+		want      bool
+	}{
+		{"health eq", ActionCondition{Field: "health", Operator: "eq", Value: "unhealthy"}, container, true},
+		{"health ne", ActionCondition{Field: "health", Operator: "ne", Value: "unhealthy"}, container, false},
+		{"nested label", ActionCondition{Field: "labels.team", Operator: "eq", Value: "web"}, container, true},
+		{"cpu gt", ActionCondition{Field: "cpuUsage", Operator: "gt", Value: 80}, host, true},
+		{"cpu lte", ActionCondition{Field: "cpuUsage", Operator: "lte", Value: "80"}, host, false},
+		{"check success", ActionCondition{Field: "success", Operator: "eq", Value: false}, check, true},
+		{"check status code", ActionCondition{Field: "data.statusCode", Operator: "gte", Value: 500}, check, true},
+		{"contains string", ActionCondition{Field: "name", Operator: "contains", Value: "node"}, host, true},
+		{"contains list", ActionCondition{Field: "data.tags", Operator: "contains", Value: "b"}, check, true},
+		{"exists", ActionCondition{Field: "data.statusCode", Operator: "exists"}, check, true},
+		{"missing exists", ActionCondition{Field: "data.error", Operator: "exists"}, check, false},
+		{"missing eq", ActionCondition{Field: "data.error", Operator: "eq", Value: "x"}, check, false},
+		{"missing ne", ActionCondition{Field: "data.error", Operator: "ne", Value: "x"}, check, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.condition.Evaluate(tt.subject)
+			if err != nil {
+				t.Fatalf("Evaluate() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Evaluate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	condition := ActionCondition{Field: "name", Operator: "gt", Value: 1}
+	if _, err := condition.Evaluate(host); err == nil {
+		t.Error("Expected an error comparing a non-numeric field")
+	}
+}
+
+func TestScheduledAction_MarkSkipped(t *testing.T) {
+	action := NewScheduledAction(ActionTypeControl, "restart", "host-1", &Schedule{RepeatFrequency: "PT5M"})
+	action.MarkSkipped("Condition not met")
+
+	if action.StartTime == nil || action.Result == nil || !action.Result.Skipped {
+		t.Fatalf("Expected the run to be recorded as skipped, got %+v", action.Result)
+	}
+	if action.ActionStatus != ActionStatusCompleted {
+		t.Errorf("Expected status %s, got %s", ActionStatusCompleted, action.ActionStatus)
+	}
+}
+
+func TestScheduledAction_MarkRunFailed(t *testing.T) {
+	action := NewScheduledAction(ActionTypeControl, "restart", "host-1", &Schedule{RepeatFrequency: "PT5M"})
+	action.MarkRunFailed(&ActionError{Name: "ConditionError"})
+
+	if action.StartTime == nil {
+		t.Error("Expected the failed run to be recorded as an execution")
+	}
+	if action.ActionStatus != ActionStatusFailed || action.Error == nil {
+		t.Errorf("Expected status %s with an error, got %s", ActionStatusFailed, action.ActionStatus)
+	}
+}
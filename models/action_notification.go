@@ -0,0 +1,48 @@
+package models
+
+import "fmt"
+
+// NotifyOn values: which runs of a scheduled action send a notification.
+const (
+	// NotifyOnFailure notifies of every failed run
+	NotifyOnFailure = "failure"
+
+	// NotifyOnRecovery notifies of a successful run after a failed one
+	NotifyOnRecovery = "recovery"
+
+	// NotifyOnAlways notifies of every run
+	NotifyOnAlways = "always"
+)
+
+// Run outcomes recorded in ScheduledAction.LastOutcome.
+const (
+	OutcomeSuccess = "success"
+	OutcomeFailure = "failure"
+)
+
+// ValidateNotifyOn checks the notifyOn values of a scheduled action.
+func ValidateNotifyOn(notifyOn []string) error {
+	for _, value := range notifyOn {
+		switch value {
+		case NotifyOnFailure, NotifyOnRecovery, NotifyOnAlways:
+		default:
+			return fmt.Errorf("notifyOn must be failure, recovery or always, got %q", value)
+		}
+	}
+	return nil
+}
+
+// ShouldNotify reports whether a run with the given outcome sends a
+// notification under notifyOn. previous is the outcome of the run before;
+// a success after a failure is a recovery.
+func ShouldNotify(notifyOn []string, previous string, success bool) bool {
+	for _, value := range notifyOn {
+		switch {
+		case value == NotifyOnAlways,
+			value == NotifyOnFailure && !success,
+			value == NotifyOnRecovery && success && previous == OutcomeFailure:
+			return true
+		}
+	}
+	return false
+}
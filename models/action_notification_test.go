@@ -0,0 +1,37 @@
+package models
+
+import "testing"
+
+func TestShouldNotify(t *testing.T) {
+	tests := []struct {
+		name     string
+		notifyOn []string
+		previous string
+		success  bool
+		want     bool
+	}{
+		{"never", nil, OutcomeFailure, false, false},
+		{"failure on failure", []string{NotifyOnFailure}, OutcomeSuccess, false, true},
+		{"failure on success", []string{NotifyOnFailure}, OutcomeFailure, true, false},
+		{"recovery after failure", []string{NotifyOnRecovery}, OutcomeFailure, true, true},
+		{"recovery after success", []string{NotifyOnRecovery}, OutcomeSuccess, true, false},
+		{"recovery on first run", []string{NotifyOnRecovery}, "", true, false},
+		{"recovery on failure", []string{NotifyOnRecovery}, OutcomeFailure, false, false},
+		{"failure and recovery", []string{NotifyOnFailure, NotifyOnRecovery}, OutcomeFailure, true, true},
+		{"always on success", []string{NotifyOnAlways}, OutcomeSuccess, true, true},
+	}
+	for _, tt := range tests {
+		if got := ShouldNotify(tt.notifyOn, tt.previous, tt.success); got != tt.want {
+			t.Errorf("%s: ShouldNotify() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestValidateNotifyOn(t *testing.T) {
+	if err := ValidateNotifyOn([]string{NotifyOnFailure, NotifyOnRecovery, NotifyOnAlways}); err != nil {
+		t.Errorf("ValidateNotifyOn() = %v, want nil", err)
+	}
+	if err := ValidateNotifyOn([]string{"success"}); err == nil {
+		t.Error("ValidateNotifyOn() = nil, want an error for an unknown value")
+	}
+}
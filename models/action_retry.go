@@ -0,0 +1,90 @@
+package models
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// Retry policy defaults.
+const (
+	// DefaultRetryBackoffSeconds is the delay before the first retry
+	DefaultRetryBackoffSeconds = 30
+
+	// DefaultRetryBackoffMultiplier is the growth of the delay per retry
+	DefaultRetryBackoffMultiplier = 2.0
+)
+
+// RetryPolicy makes the scheduler run a scheduled action again when its
+// task fails, before recording the run as failed. Retries are part of the
+// same run: they skip the action's condition and don't move its schedule.
+// A retry that would not be due before the next scheduled run is dropped,
+// and disabled actions and cancelled tasks are not retried.
+//
+// Example: three attempts, 30s and then 60s apart
+//
+//	{"maxAttempts": 3, "backoffSeconds": 30, "backoffMultiplier": 2}
+type RetryPolicy struct {
+	// MaxAttempts is the number of attempts per run, including the first
+	MaxAttempts int `json:"maxAttempts"`
+
+	// BackoffSeconds is the delay before the first retry (default: 30)
+	BackoffSeconds int `json:"backoffSeconds,omitempty"`
+
+	// BackoffMultiplier is the factor the delay grows by with every
+	// further retry (default: 2, 1 for a fixed delay)
+	BackoffMultiplier float64 `json:"backoffMultiplier,omitempty"`
+
+	// MaxBackoffSeconds caps the delay (0 = no cap)
+	MaxBackoffSeconds int `json:"maxBackoffSeconds,omitempty"`
+}
+
+// Validate checks that the policy allows at least one attempt and that its
+// backoff is sensible.
+func (p *RetryPolicy) Validate() error {
+	if p.MaxAttempts < 1 {
+		return fmt.Errorf("retry policy maxAttempts must be at least 1, got %d", p.MaxAttempts)
+	}
+	if p.BackoffSeconds < 0 {
+		return fmt.Errorf("retry policy backoffSeconds must not be negative, got %d", p.BackoffSeconds)
+	}
+	if p.BackoffMultiplier != 0 && p.BackoffMultiplier < 1 {
+		return fmt.Errorf("retry policy backoffMultiplier must be at least 1, got %g", p.BackoffMultiplier)
+	}
+	if p.MaxBackoffSeconds < 0 {
+		return fmt.Errorf("retry policy maxBackoffSeconds must not be negative, got %d", p.MaxBackoffSeconds)
+	}
+	return nil
+}
+
+// Backoff returns the delay before the attempt following failed attempt
+// number attempt (1 for the first): BackoffSeconds, growing by
+// BackoffMultiplier per further attempt, up to MaxBackoffSeconds.
+func (p *RetryPolicy) Backoff(attempt int) time.Duration {
+	base := p.BackoffSeconds
+	if base == 0 {
+		base = DefaultRetryBackoffSeconds
+	}
+	multiplier := p.BackoffMultiplier
+	if multiplier == 0 {
+		multiplier = DefaultRetryBackoffMultiplier
+	}
+	if attempt < 1 {
+		attempt = 1
+	}
+
+	seconds := float64(base) * math.Pow(multiplier, float64(attempt-1))
+	if p.MaxBackoffSeconds > 0 && seconds > float64(p.MaxBackoffSeconds) {
+		seconds = float64(p.MaxBackoffSeconds)
+	}
+	return time.Duration(seconds * float64(time.Second))
+}
+
+// CanRetry reports whether a run whose attempt number attempt failed gets
+// another attempt.
+func (p *RetryPolicy) CanRetry(attempt int) bool {
+	if attempt < 1 {
+		attempt = 1
+	}
+	return attempt < p.MaxAttempts
+}
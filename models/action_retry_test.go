@@ -0,0 +1,81 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  RetryPolicy
+		attempt int
+		want    time.Duration
+	}{
+		{"defaults first retry", RetryPolicy{MaxAttempts: 3}, 1, 30 * time.Second},
+		{"defaults second retry", RetryPolicy{MaxAttempts: 3}, 2, 60 * time.Second},
+		{"exponential", RetryPolicy{MaxAttempts: 5, BackoffSeconds: 10, BackoffMultiplier: 3}, 3, 90 * time.Second},
+		{"fixed", RetryPolicy{MaxAttempts: 5, BackoffSeconds: 10, BackoffMultiplier: 1}, 4, 10 * time.Second},
+		{"capped", RetryPolicy{MaxAttempts: 10, BackoffSeconds: 60, MaxBackoffSeconds: 300}, 6, 300 * time.Second},
+		{"fractional multiplier", RetryPolicy{MaxAttempts: 3, BackoffSeconds: 10, BackoffMultiplier: 1.5}, 2, 15 * time.Second},
+	}
+	for _, tt := range tests {
+		if got := tt.policy.Backoff(tt.attempt); got != tt.want {
+			t.Errorf("%s: Backoff(%d) = %v, want %v", tt.name, tt.attempt, got, tt.want)
+		}
+	}
+}
+
+func TestRetryPolicy_CanRetry(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 3}
+	for attempt, want := range map[int]bool{0: true, 1: true, 2: true, 3: false, 4: false} {
+		if got := policy.CanRetry(attempt); got != want {
+			t.Errorf("CanRetry(%d) = %v, want %v", attempt, got, want)
+		}
+	}
+	if (&RetryPolicy{MaxAttempts: 1}).CanRetry(1) {
+		t.Error("Expected a single-attempt policy not to retry")
+	}
+}
+
+func TestRetryPolicy_Validate(t *testing.T) {
+	valid := []RetryPolicy{
+		{MaxAttempts: 1},
+		{MaxAttempts: 3, BackoffSeconds: 5, BackoffMultiplier: 1, MaxBackoffSeconds: 60},
+	}
+	for _, p := range valid {
+		if err := p.Validate(); err != nil {
+			t.Errorf("Validate(%+v) = %v, want nil", p, err)
+		}
+	}
+
+	invalid := []RetryPolicy{
+		{},
+		{MaxAttempts: 3, BackoffSeconds: -1},
+		{MaxAttempts: 3, BackoffMultiplier: 0.5},
+		{MaxAttempts: 3, MaxBackoffSeconds: -10},
+	}
+	for _, p := range invalid {
+		if err := p.Validate(); err == nil {
+			t.Errorf("Validate(%+v) = nil, want an error", p)
+		}
+	}
+}
+
+func TestScheduledAction_Retrying(t *testing.T) {
+	action := NewScheduledAction(ActionTypeCheck, "check", "host-1", &Schedule{RepeatFrequency: "PT1H"})
+	action.MarkStarted()
+	started := *action.StartTime
+
+	action.MarkRetrying(1, started.Add(time.Minute), &ActionError{Name: "TaskFailed"})
+	action.MarkRetryStarted()
+	if action.Attempt != 2 || action.RetryAt != nil || !action.StartTime.Equal(started) {
+		t.Errorf("Expected attempt 2 of the same run, got attempt %d, retryAt %v, start %v", action.Attempt, action.RetryAt, action.StartTime)
+	}
+
+	action.MarkRetrying(2, started.Add(3*time.Minute), nil)
+	action.MarkFailed(&ActionError{Name: "TaskFailed"})
+	if action.RetryAt != nil || action.ActionStatus != ActionStatusFailed {
+		t.Errorf("Expected a failed run without retry, got status %s, retryAt %v", action.ActionStatus, action.RetryAt)
+	}
+}
@@ -0,0 +1,212 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+)
+
+// ScaleGroupLabel marks the containers of a scale group with the group's
+// name. A ScaleAction counts, creates and removes the containers carrying
+// it.
+const ScaleGroupLabel = "graphium.scale-group"
+
+// scaleGroupPattern allows group names that are valid Docker container name
+// prefixes.
+var scaleGroupPattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]*$`)
+
+// ScaleSpec is the instrument of a ScaleAction: the number of replicas of a
+// container to keep running across the available hosts. Each run places
+// missing replicas with the placement strategy and removes surplus ones from
+// the hosts running the most.
+//
+// Example: three nginx replicas spread over two hosts
+//
+//	{"group": "web", "replicas": 3, "placementStrategy": "spread",
+//	 "hosts": ["host-1", "host-2"],
+//	 "spec": {"containerSpec": {"image": "nginx:alpine"}}}
+type ScaleSpec struct {
+	// Group names the replicas; they are labelled ScaleGroupLabel=Group and
+	// named after it
+	Group string `json:"group"`
+
+	// Replicas is the number of running replicas to converge to
+	Replicas int `json:"replicas"`
+
+	// Spec is the container each replica runs
+	Spec DeployContainerPayload `json:"spec"`
+
+	// PlacementStrategy places missing replicas (first-fit, spread or
+	// binpack; default: the server's deploy placement strategy)
+	PlacementStrategy string `json:"placementStrategy,omitempty"`
+
+	// Hosts limits the replicas to these hosts (default: all active hosts)
+	Hosts []string `json:"hosts,omitempty"`
+}
+
+// ScaleSpecFromInstrument reads the scale spec of a ScaleAction's
+// instrument.
+func ScaleSpecFromInstrument(instrument map[string]interface{}) (*ScaleSpec, error) {
+	data, err := json.Marshal(instrument)
+	if err != nil {
+		return nil, err
+	}
+	var spec ScaleSpec
+	if err := json.Unmarshal(data, &spec); err != nil {
+		return nil, fmt.Errorf("invalid scale spec: %w", err)
+	}
+	return &spec, nil
+}
+
+// Validate checks that the spec names a group and an image and asks for a
+// sensible number of replicas.
+func (s *ScaleSpec) Validate() error {
+	if err := validateScaleGroup(s.Group, s.Spec.ContainerSpec.Image); err != nil {
+		return err
+	}
+	if s.Replicas < 0 {
+		return fmt.Errorf("replicas must not be negative, got %d", s.Replicas)
+	}
+	if s.PlacementStrategy != "" && !IsPlacementStrategy(s.PlacementStrategy) {
+		return fmt.Errorf("invalid placementStrategy %q (expected first-fit, spread or binpack)", s.PlacementStrategy)
+	}
+	return nil
+}
+
+// validateScaleGroup checks the group name and image of a scale group.
+func validateScaleGroup(group, image string) error {
+	if group == "" {
+		return fmt.Errorf("group is required")
+	}
+	if !scaleGroupPattern.MatchString(group) {
+		return fmt.Errorf("invalid group %q: use letters, digits, '_', '.' and '-'", group)
+	}
+	if image == "" {
+		return fmt.Errorf("spec.containerSpec.image is required")
+	}
+	return nil
+}
+
+// ScaleStep converges the replicas on one host.
+type ScaleStep struct {
+	// HostID is the host
+	HostID string `json:"hostId"`
+
+	// Before is the number of replicas running on the host when the run was
+	// planned
+	Before int `json:"before"`
+
+	// Replicas is the number of replicas the host should run
+	Replicas int `json:"replicas"`
+}
+
+// ScalePlan is a run of a ScaleAction: the steps converging each host whose
+// replicas change, one host after the other.
+type ScalePlan struct {
+	// Replicas is the number of replicas across all hosts after the run
+	Replicas int `json:"replicas"`
+
+	// Before is the number of replicas across all hosts before the run
+	Before int `json:"before"`
+
+	// Steps are the hosts to converge, those gaining replicas first
+	Steps []ScaleStep `json:"steps"`
+}
+
+// NewScalePlan plans the steps from the current to the target replicas per
+// host. Hosts gaining replicas come first, so capacity is added before it's
+// removed. If no host changes, the plan verifies the first host running
+// replicas, or the first host, so every run reports the group's state.
+func NewScalePlan(current, target map[string]int) *ScalePlan {
+	hosts := make(map[string]bool)
+	for hostID := range current {
+		hosts[hostID] = true
+	}
+	for hostID := range target {
+		hosts[hostID] = true
+	}
+	ids := make([]string, 0, len(hosts))
+	for hostID := range hosts {
+		ids = append(ids, hostID)
+	}
+	sort.Strings(ids)
+
+	plan := &ScalePlan{}
+	var removals []ScaleStep
+	for _, hostID := range ids {
+		step := ScaleStep{HostID: hostID, Before: current[hostID], Replicas: target[hostID]}
+		plan.Before += step.Before
+		plan.Replicas += step.Replicas
+		switch {
+		case step.Replicas > step.Before:
+			plan.Steps = append(plan.Steps, step)
+		case step.Replicas < step.Before:
+			removals = append(removals, step)
+		}
+	}
+	plan.Steps = append(plan.Steps, removals...)
+
+	if len(plan.Steps) == 0 && len(ids) > 0 {
+		verify := ids[0]
+		for _, hostID := range ids {
+			if target[hostID] > 0 {
+				verify = hostID
+				break
+			}
+		}
+		plan.Steps = []ScaleStep{{HostID: verify, Before: current[verify], Replicas: target[verify]}}
+	}
+	return plan
+}
+
+// ScalePayload contains data for converging the replicas of a scale group
+// on the task's host.
+type ScalePayload struct {
+	// Group names the replicas (see ScaleSpec.Group)
+	Group string `json:"group"`
+
+	// Replicas is the number of replicas this host should run
+	Replicas int `json:"replicas"`
+
+	// Spec is the container each replica runs
+	Spec DeployContainerPayload `json:"spec"`
+
+	// Plan is the run this task is a step of, if the scheduler planned it
+	Plan *ScalePlan `json:"plan,omitempty"`
+
+	// Step is the index of this task's step in Plan
+	Step int `json:"step,omitempty"`
+}
+
+// Validate checks that the payload names a group and an image and asks for
+// a sensible number of replicas.
+func (p *ScalePayload) Validate() error {
+	if err := validateScaleGroup(p.Group, p.Spec.ContainerSpec.Image); err != nil {
+		return err
+	}
+	if p.Replicas < 0 {
+		return fmt.Errorf("replicas must not be negative, got %d", p.Replicas)
+	}
+	if p.Plan != nil && (p.Step < 0 || p.Step >= len(p.Plan.Steps)) {
+		return fmt.Errorf("step %d is not in the plan's %d steps", p.Step, len(p.Plan.Steps))
+	}
+	return nil
+}
+
+// TotalAfter returns the replicas across the plan's hosts once this step
+// leaves after replicas on its host. Earlier steps reached their target, as
+// a failed step ends the run.
+func (p *ScalePayload) TotalAfter(after int) int {
+	if p.Plan == nil {
+		return after
+	}
+	total := p.Plan.Before
+	for i, step := range p.Plan.Steps[:p.Step+1] {
+		total -= step.Before
+		if i < p.Step {
+			total += step.Replicas
+		}
+	}
+	return total + after
+}
@@ -0,0 +1,122 @@
+package models
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestScaleSpecFromInstrument(t *testing.T) {
+	spec, err := ScaleSpecFromInstrument(map[string]interface{}{
+		"group":             "web",
+		"replicas":          3,
+		"placementStrategy": "spread",
+		"hosts":             []string{"host-1", "host-2"},
+		"spec":              map[string]interface{}{"containerSpec": map[string]interface{}{"image": "nginx:alpine"}},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if spec.Group != "web" || spec.Replicas != 3 || spec.Spec.ContainerSpec.Image != "nginx:alpine" || len(spec.Hosts) != 2 {
+		t.Errorf("Unexpected spec %+v", spec)
+	}
+	if err := spec.Validate(); err != nil {
+		t.Errorf("Validate() = %v, want nil", err)
+	}
+
+	if _, err := ScaleSpecFromInstrument(map[string]interface{}{"replicas": "three"}); err == nil {
+		t.Error("Expected an error for a non-numeric replica count")
+	}
+}
+
+func TestScaleSpec_Validate(t *testing.T) {
+	image := DeployContainerPayload{ContainerSpec: ContainerSpec{Image: "nginx:alpine"}}
+	tests := []struct {
+		name    string
+		spec    ScaleSpec
+		wantErr string
+	}{
+		{"valid", ScaleSpec{Group: "web", Replicas: 2, Spec: image}, ""},
+		{"zero replicas", ScaleSpec{Group: "web", Spec: image}, ""},
+		{"no group", ScaleSpec{Replicas: 2, Spec: image}, "group is required"},
+		{"invalid group", ScaleSpec{Group: "web/api", Replicas: 2, Spec: image}, "invalid group"},
+		{"no image", ScaleSpec{Group: "web", Replicas: 2}, "image is required"},
+		{"negative replicas", ScaleSpec{Group: "web", Replicas: -1, Spec: image}, "must not be negative"},
+		{"unknown strategy", ScaleSpec{Group: "web", Replicas: 2, Spec: image, PlacementStrategy: "random"}, "invalid placementStrategy"},
+	}
+	for _, tt := range tests {
+		err := tt.spec.Validate()
+		switch {
+		case tt.wantErr == "" && err != nil:
+			t.Errorf("%s: unexpected error %v", tt.name, err)
+		case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+			t.Errorf("%s: expected an error containing %q, got %v", tt.name, tt.wantErr, err)
+		}
+	}
+}
+
+func TestNewScalePlan(t *testing.T) {
+	tests := []struct {
+		name    string
+		current map[string]int
+		target  map[string]int
+		want    *ScalePlan
+	}{
+		{
+			name:    "scale up",
+			current: map[string]int{"host-1": 1},
+			target:  map[string]int{"host-1": 2, "host-2": 1, "host-3": 0},
+			want: &ScalePlan{Replicas: 3, Before: 1, Steps: []ScaleStep{
+				{HostID: "host-1", Before: 1, Replicas: 2},
+				{HostID: "host-2", Before: 0, Replicas: 1},
+			}},
+		},
+		{
+			name:    "additions before removals",
+			current: map[string]int{"host-1": 3, "host-2": 0},
+			target:  map[string]int{"host-1": 1, "host-2": 1},
+			want: &ScalePlan{Replicas: 2, Before: 3, Steps: []ScaleStep{
+				{HostID: "host-2", Before: 0, Replicas: 1},
+				{HostID: "host-1", Before: 3, Replicas: 1},
+			}},
+		},
+		{
+			name:    "converged verifies first host with replicas",
+			current: map[string]int{"host-2": 2},
+			target:  map[string]int{"host-1": 0, "host-2": 2},
+			want: &ScalePlan{Replicas: 2, Before: 2, Steps: []ScaleStep{
+				{HostID: "host-2", Before: 2, Replicas: 2},
+			}},
+		},
+		{
+			name:   "no hosts",
+			target: map[string]int{},
+			want:   &ScalePlan{},
+		},
+	}
+	for _, tt := range tests {
+		if got := NewScalePlan(tt.current, tt.target); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: NewScalePlan() = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestScalePayload_TotalAfter(t *testing.T) {
+	plan := NewScalePlan(
+		map[string]int{"host-1": 3, "host-3": 1},
+		map[string]int{"host-1": 1, "host-2": 2, "host-3": 1},
+	)
+	// Steps: host-2 0 -> 2, then host-1 3 -> 1; host-3 keeps its replica
+
+	first := ScalePayload{Plan: plan, Step: 0}
+	if got := first.TotalAfter(2); got != 6 {
+		t.Errorf("TotalAfter after the first step = %d, want 6", got)
+	}
+	last := ScalePayload{Plan: plan, Step: 1}
+	if got := last.TotalAfter(1); got != 4 {
+		t.Errorf("TotalAfter after the last step = %d, want 4", got)
+	}
+	if got := (&ScalePayload{}).TotalAfter(3); got != 3 {
+		t.Errorf("TotalAfter without a plan = %d, want 3", got)
+	}
+}
@@ -3,6 +3,9 @@ package models
 import (
 	"encoding/json"
 	"fmt"
+	"net"
+	"regexp"
+	"strings"
 	"time"
 
 	"eve.evalgo.org/semantic"
@@ -73,6 +76,11 @@ type AgentTask struct {
 	MaxRetries     int       `json:"maxRetries,omitempty"`                  // Max retry limit
 	TimeoutSeconds int       `json:"timeoutSeconds,omitempty"`              // Execution timeout
 	ScheduledBy    string    `json:"scheduledBy,omitempty" couchdb:"index"` // Source ScheduledAction ID
+	Attempt        int       `json:"attempt,omitempty"`                     // Attempt of the scheduled action's run, 1 for the first
+
+	// Action chaining fields
+	TriggeredBy *ActionTrigger `json:"triggeredBy,omitempty"` // Finished task whose result triggered this task
+	ChainPath   []string       `json:"chainPath,omitempty"`   // Scheduled action IDs earlier in the chain, oldest first
 }
 
 // Aliases for convenience and consistency with scheduler
@@ -119,7 +127,8 @@ type DeleteContainerPayload struct {
 	// RemoveVolumes removes associated volumes
 	RemoveVolumes bool `json:"removeVolumes,omitempty"`
 
-	// StopTimeout is the timeout in seconds before force-killing (default: 10)
+	// StopTimeout is the timeout in seconds before force-killing (default:
+	// the container's stop grace period)
 	StopTimeout int `json:"stopTimeout,omitempty"`
 }
 
@@ -131,8 +140,13 @@ type ControlContainerPayload struct {
 	// ContainerName is the container name (for logging)
 	ContainerName string `json:"containerName,omitempty"`
 
-	// Timeout is the timeout in seconds for stop/restart (default: 10)
+	// Timeout is the timeout in seconds for stop/restart (default: the
+	// container's stop grace period)
 	Timeout int `json:"timeout,omitempty"`
+
+	// Force makes a restart kill the container and start it right away,
+	// bypassing Docker's restart backoff of a crash-looping container
+	Force bool `json:"force,omitempty"`
 }
 
 // UpdateContainerPayload contains data for updating a running container.
@@ -181,6 +195,195 @@ type CheckHealthPayload struct {
 
 	// ContainerID is the container to check (optional, for logging)
 	ContainerID string `json:"containerId,omitempty"`
+
+	// Targets turns the check into a multi-target check: every target is
+	// checked and the result lists one CheckTargetResult per target. URL and
+	// ContainerID above are ignored; the other fields are target defaults.
+	Targets []CheckTarget `json:"targets,omitempty"`
+
+	// Quorum is the number of targets that must pass for the check to
+	// succeed (default: all)
+	Quorum int `json:"quorum,omitempty"`
+}
+
+// CheckTypeConnectivity routes a CheckAction to the connectivity probe.
+const CheckTypeConnectivity = "connectivity"
+
+// CheckTypeTCPPort routes a CheckAction to the TCP port check.
+const CheckTypeTCPPort = "tcp-port"
+
+// MaxTCPBannerBytes bounds how much of a service's greeting a TCP port
+// check reads to match ExpectBanner.
+const MaxTCPBannerBytes = 4096
+
+// TCPPortCheckPayload contains data for checking that a TCP port accepts
+// connections, e.g. of a database or message broker without an HTTP
+// endpoint. It is sent as a CheckAction task with CheckType "tcp-port".
+type TCPPortCheckPayload struct {
+	// CheckType routes the CheckAction to the TCP port check (always "tcp-port")
+	CheckType string `json:"checkType"`
+
+	// Address is the host:port to connect to
+	Address string `json:"address"`
+
+	// Timeout is the timeout of the connect and banner read in seconds (default: 5)
+	Timeout int `json:"timeout,omitempty"`
+
+	// ExpectBanner is a regular expression the service's greeting must
+	// match, e.g. "^220 " for SMTP; empty only checks the connect
+	ExpectBanner string `json:"expectBanner,omitempty"`
+
+	// BannerBytes is how many bytes of the greeting are read to match
+	// ExpectBanner (default: 256, at most MaxTCPBannerBytes)
+	BannerBytes int `json:"bannerBytes,omitempty"`
+}
+
+// Validate checks the address and banner settings of a TCP port check.
+func (p *TCPPortCheckPayload) Validate() error {
+	host, port, err := net.SplitHostPort(p.Address)
+	if err != nil || host == "" || port == "" {
+		return fmt.Errorf("invalid address %q: must be host:port", p.Address)
+	}
+	if p.Timeout < 0 {
+		return fmt.Errorf("timeout must not be negative")
+	}
+	if p.BannerBytes < 0 || p.BannerBytes > MaxTCPBannerBytes {
+		return fmt.Errorf("bannerBytes must be between 0 and %d", MaxTCPBannerBytes)
+	}
+	if p.ExpectBanner != "" {
+		if _, err := regexp.Compile(p.ExpectBanner); err != nil {
+			return fmt.Errorf("invalid expectBanner: %w", err)
+		}
+	}
+	return nil
+}
+
+// CheckTypeDNS routes a CheckAction to the DNS resolution check.
+const CheckTypeDNS = "dns"
+
+// DNS record types a DNS check resolves.
+const (
+	DNSRecordA     = "A"
+	DNSRecordAAAA  = "AAAA"
+	DNSRecordCNAME = "CNAME"
+)
+
+// DNSCheckPayload contains data for checking how a hostname resolves, to
+// catch DNS drift or propagation problems. It is sent as a CheckAction task
+// with CheckType "dns".
+type DNSCheckPayload struct {
+	// CheckType routes the CheckAction to the DNS check (always "dns")
+	CheckType string `json:"checkType"`
+
+	// Hostname is the name to resolve
+	Hostname string `json:"hostname"`
+
+	// RecordType is DNSRecordA (default), DNSRecordAAAA or DNSRecordCNAME
+	RecordType string `json:"recordType,omitempty"`
+
+	// Expected are the records every resolved record must be one of (IP
+	// addresses, or the canonical name for CNAME); empty only checks that
+	// the name resolves
+	Expected []string `json:"expected,omitempty"`
+
+	// Nameserver is the DNS server to ask as host:port; empty uses the
+	// agent host's resolver
+	Nameserver string `json:"nameserver,omitempty"`
+
+	// Timeout is the resolution timeout in seconds (default: 5)
+	Timeout int `json:"timeout,omitempty"`
+}
+
+// Validate checks the hostname, record type and expectations of a DNS check.
+func (p *DNSCheckPayload) Validate() error {
+	if strings.TrimSpace(p.Hostname) == "" {
+		return fmt.Errorf("hostname is required")
+	}
+	switch p.RecordType {
+	case "", DNSRecordA, DNSRecordAAAA:
+		for _, expected := range p.Expected {
+			if net.ParseIP(expected) == nil {
+				return fmt.Errorf("expected record %q is not an IP address", expected)
+			}
+		}
+	case DNSRecordCNAME:
+	default:
+		return fmt.Errorf("unsupported recordType %q (expected A, AAAA or CNAME)", p.RecordType)
+	}
+	if p.Nameserver != "" {
+		if _, _, err := net.SplitHostPort(p.Nameserver); err != nil {
+			return fmt.Errorf("invalid nameserver %q: must be host:port", p.Nameserver)
+		}
+	}
+	if p.Timeout < 0 {
+		return fmt.Errorf("timeout must not be negative")
+	}
+	return nil
+}
+
+// Connectivity probe protocols.
+const (
+	ConnectivityProtocolTCP  = "tcp"
+	ConnectivityProtocolHTTP = "http"
+)
+
+// ConnectivityCheckPayload contains data for probing whether a container can
+// reach another service. It is sent as a CheckAction task with CheckType
+// "connectivity"; scheduled actions pass the same fields as instrument.
+type ConnectivityCheckPayload struct {
+	// CheckType routes the CheckAction to the connectivity probe (always "connectivity")
+	CheckType string `json:"checkType"`
+
+	// ContainerID is the source container whose network the probe runs from
+	ContainerID string `json:"containerId"`
+
+	// Target is the service to reach as host:port, where host may be a
+	// container name or network alias on a network shared with the source
+	Target string `json:"target"`
+
+	// Protocol is ConnectivityProtocolTCP (default) or ConnectivityProtocolHTTP
+	Protocol string `json:"protocol,omitempty"`
+
+	// Path is the request path for HTTP probes (default: /)
+	Path string `json:"path,omitempty"`
+
+	// Attempts is the number of probes used for the latency figures (default: 3)
+	Attempts int `json:"attempts,omitempty"`
+
+	// Timeout is the timeout per attempt in seconds (default: 5)
+	Timeout int `json:"timeout,omitempty"`
+}
+
+// Prune modes select which unused resources are removed.
+const (
+	PruneModeDangling = "dangling" // Only dangling images and anonymous volumes
+	PruneModeAll      = "all"      // Every unused image, volume, network and cache entry
+)
+
+// ManagedLabel marks Docker resources created by Graphium. Prune tasks never
+// remove resources carrying this label.
+const ManagedLabel = "graphium.managed"
+
+// PrunePayload contains data for pruning unused Docker resources on a host.
+// It is sent as a DeleteAction task with Action "prune".
+type PrunePayload struct {
+	// Action routes the DeleteAction to the prune handler (always "prune")
+	Action string `json:"action"`
+
+	// Images prunes unused images
+	Images bool `json:"images,omitempty"`
+
+	// Volumes prunes unused volumes
+	Volumes bool `json:"volumes,omitempty"`
+
+	// Networks prunes unused networks
+	Networks bool `json:"networks,omitempty"`
+
+	// BuildCache prunes the build cache
+	BuildCache bool `json:"buildCache,omitempty"`
+
+	// Mode is PruneModeDangling (default) or PruneModeAll
+	Mode string `json:"mode,omitempty"`
 }
 
 // TaskResult contains the result of a task execution.
@@ -222,6 +425,54 @@ func (t *AgentTask) CanRetry() bool {
 	return t.RetryCount < maxRetries
 }
 
+// Retry returns a new pending task with the parameters of t, a failed task.
+// It fails if t has no retries left.
+func (t *AgentTask) Retry() (*AgentTask, error) {
+	if !t.CanRetry() {
+		return nil, fmt.Errorf("task has exceeded max retries (%d)", t.MaxRetries)
+	}
+
+	return &AgentTask{
+		ID:             fmt.Sprintf("%s-retry-%d", t.ID, t.RetryCount+1),
+		Context:        "https://schema.org",
+		Type:           t.Type,
+		ActionStatus:   TaskStatusPending,
+		Agent:          t.Agent,
+		Object:         t.Object,
+		Instrument:     t.Instrument,
+		HostID:         t.HostID,
+		StackID:        t.StackID,
+		ContainerID:    t.ContainerID,
+		Priority:       t.Priority,
+		CreatedAt:      time.Now(),
+		CreatedBy:      t.CreatedBy,
+		RetryCount:     t.RetryCount + 1,
+		MaxRetries:     t.MaxRetries,
+		TimeoutSeconds: t.TimeoutSeconds,
+		DependsOn:      t.DependsOn,
+		Schedule:       t.Schedule,
+		Properties:     t.Properties,
+	}, nil
+}
+
+// Requeue returns a task an agent had to abandon (e.g. because it shut down)
+// to the pending queue, counting it as a retry. It reports false and leaves
+// the task unchanged if the task has no retries left.
+func (t *AgentTask) Requeue(reason string) bool {
+	if !t.CanRetry() {
+		return false
+	}
+
+	t.RetryCount++
+	t.ActionStatus = TaskStatusPending
+	t.StartTime = nil
+	if t.Error == nil {
+		t.Error = &semantic.SemanticError{Type: "Error"}
+	}
+	t.Error.Message = reason
+	return true
+}
+
 // ShouldExecute checks if the task is ready to be executed by an agent.
 func (t *AgentTask) ShouldExecute(agentID string) bool {
 	// Task must be pending or assigned (PotentialActionStatus) or active (ActiveActionStatus)
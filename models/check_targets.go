@@ -0,0 +1,71 @@
+package models
+
+import "fmt"
+
+// CheckTarget is one target of a multi-target health check: an HTTP
+// endpoint, or a container whose state is checked.
+type CheckTarget struct {
+	// URL is the HTTP endpoint to check
+	URL string `json:"url,omitempty"`
+
+	// ContainerID is the container to check when URL is empty; it passes
+	// when it is running and its health check isn't unhealthy
+	ContainerID string `json:"containerId,omitempty"`
+
+	// Method overrides the check's HTTP method
+	Method string `json:"method,omitempty"`
+
+	// ExpectedStatusCode overrides the check's expected HTTP status code
+	ExpectedStatusCode int `json:"expectedStatusCode,omitempty"`
+}
+
+// Name identifies the target in results and messages.
+func (t CheckTarget) Name() string {
+	if t.URL != "" {
+		return t.URL
+	}
+	return t.ContainerID
+}
+
+// CheckTargetResult is the outcome of checking one target.
+type CheckTargetResult struct {
+	// Target is the URL or container ID that was checked
+	Target string `json:"target"`
+
+	// Success is whether the target passed
+	Success bool `json:"success"`
+
+	// StatusCode is the HTTP status code of URL targets
+	StatusCode int `json:"statusCode,omitempty"`
+
+	// Status is the container status of container targets, with the health
+	// status if the container has a health check (e.g. running/healthy)
+	Status string `json:"status,omitempty"`
+
+	// Error is why the target could not be checked
+	Error string `json:"error,omitempty"`
+
+	// DurationMs is how long the check took in milliseconds
+	DurationMs int64 `json:"durationMs"`
+}
+
+// ValidateTargets checks the targets and quorum of a multi-target check.
+func (p *CheckHealthPayload) ValidateTargets() error {
+	for i, target := range p.Targets {
+		if target.URL == "" && target.ContainerID == "" {
+			return fmt.Errorf("target %d: url or containerId is required", i)
+		}
+	}
+	if p.Quorum < 0 || p.Quorum > len(p.Targets) {
+		return fmt.Errorf("quorum must be between 0 (all) and the number of targets (%d), got %d", len(p.Targets), p.Quorum)
+	}
+	return nil
+}
+
+// RequiredPasses returns the number of targets that must pass.
+func (p *CheckHealthPayload) RequiredPasses() int {
+	if p.Quorum <= 0 || p.Quorum > len(p.Targets) {
+		return len(p.Targets)
+	}
+	return p.Quorum
+}
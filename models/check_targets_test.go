@@ -0,0 +1,46 @@
+package models
+
+import "testing"
+
+func TestCheckHealthPayload_ValidateTargets(t *testing.T) {
+	targets := []CheckTarget{{URL: "http://a/health"}, {ContainerID: "c1"}, {URL: "http://b/health"}}
+
+	tests := []struct {
+		name    string
+		payload CheckHealthPayload
+		wantErr bool
+	}{
+		{"all targets", CheckHealthPayload{Targets: targets}, false},
+		{"quorum", CheckHealthPayload{Targets: targets, Quorum: 2}, false},
+		{"quorum above targets", CheckHealthPayload{Targets: targets, Quorum: 4}, true},
+		{"negative quorum", CheckHealthPayload{Targets: targets, Quorum: -1}, true},
+		{"empty target", CheckHealthPayload{Targets: []CheckTarget{{Method: "HEAD"}}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.payload.ValidateTargets(); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateTargets() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCheckHealthPayload_RequiredPasses(t *testing.T) {
+	payload := CheckHealthPayload{Targets: make([]CheckTarget, 5)}
+	if got := payload.RequiredPasses(); got != 5 {
+		t.Errorf("Expected all 5 targets to be required by default, got %d", got)
+	}
+	payload.Quorum = 3
+	if got := payload.RequiredPasses(); got != 3 {
+		t.Errorf("Expected a quorum of 3, got %d", got)
+	}
+}
+
+func TestCheckTarget_Name(t *testing.T) {
+	if name := (CheckTarget{URL: "http://a/health", ContainerID: "c1"}).Name(); name != "http://a/health" {
+		t.Errorf("Expected the URL to name the target, got %q", name)
+	}
+	if name := (CheckTarget{ContainerID: "c1"}).Name(); name != "c1" {
+		t.Errorf("Expected the container ID to name the target, got %q", name)
+	}
+}
@@ -6,6 +6,11 @@
 // REST API representations.
 package models
 
+import (
+	"sort"
+	"time"
+)
+
 //go:generate go run ../tools/generate.go
 
 // Container represents a containerized application running on a host system.
@@ -57,6 +62,10 @@ type Container struct {
 	// Status is the container runtime status (running, stopped, paused, etc.)
 	Status string `json:"status" jsonld:"status" couchdb:"index"`
 
+	// Health is the health check status (healthy, unhealthy, starting; empty
+	// if the container has no health check)
+	Health string `json:"health,omitempty" jsonld:"health"`
+
 	// HostedOn is the ID of the host running this container (creates graph relationship)
 	HostedOn string `json:"hostedOn" jsonld:"hostedOn" couchdb:"relation,index"`
 
@@ -66,12 +75,108 @@ type Container struct {
 	// Env contains environment variables passed to the container
 	Env map[string]string `json:"environment,omitempty" jsonld:"environment"`
 
+	// Labels are key/value labels used to group and filter containers (e.g. team=web).
+	// Labels set by Docker (DockerLabels) can't be removed or changed through
+	// the API, as the agent would report them again on its next sync.
+	Labels map[string]string `json:"labels,omitempty" jsonld:"labels"`
+
+	// DockerName and DockerLabels are the name and labels the agent last
+	// reported from Docker. Unlike Name and Labels they can't be changed
+	// through the API, so policies select containers by them.
+	DockerName   string            `json:"dockerName,omitempty" jsonld:"dockerName"`
+	DockerLabels map[string]string `json:"dockerLabels,omitempty" jsonld:"dockerLabels"`
+
 	// DependsOn lists container names/IDs that this container depends on
 	// These dependencies are used for startup ordering and graph relationships
 	DependsOn []string `json:"dependsOn,omitempty" jsonld:"dependsOn"`
 
 	// Created is the ISO 8601 timestamp when the container was created
 	Created string `json:"dateCreated,omitempty" jsonld:"dateCreated"`
+
+	// ImageDigest is the registry digest (sha256:...) of the image the container runs
+	ImageDigest string `json:"imageDigest,omitempty" jsonld:"imageDigest"`
+
+	// UpdateAvailable is set by the image update checker when the registry
+	// has a newer image for the container's tag
+	UpdateAvailable bool `json:"updateAvailable,omitempty" jsonld:"updateAvailable"`
+
+	// LatestImageDigest is the current registry digest of the container's tag
+	LatestImageDigest string `json:"latestImageDigest,omitempty" jsonld:"latestImageDigest"`
+
+	// ImageCheckedAt is when the image update checker last checked the tag
+	ImageCheckedAt *time.Time `json:"imageCheckedAt,omitempty" jsonld:"imageCheckedAt"`
+
+	// Resources are the effective limits and reservations of the container,
+	// as reported by the agent from Docker
+	Resources *ResourceConstraints `json:"resources,omitempty" jsonld:"resources"`
+
+	// StopGracePeriod is the time in seconds Docker waits after SIGTERM
+	// before killing the container, as reported by the agent; 0 means the
+	// default (DefaultStopGracePeriod)
+	StopGracePeriod int `json:"stopGracePeriod,omitempty" jsonld:"stopGracePeriod"`
+
+	// Restart is the restart state reported by the agent: restart count,
+	// whether Docker is backing off restarts and why the container last
+	// exited. Nil if the container never exited.
+	Restart *RestartState `json:"restart,omitempty" jsonld:"restart"`
+
+	// CPUPercent is the CPU usage in percent of one CPU (200 = two full
+	// CPUs), sampled by the agent on its periodic sync
+	CPUPercent float64 `json:"cpuPercent,omitempty" jsonld:"cpuPercent"`
+
+	// MemoryUsage is the memory in use in bytes, excluding the page cache
+	MemoryUsage int64 `json:"memoryUsage,omitempty" jsonld:"memoryUsage"`
+
+	// NetworkRx and NetworkTx are the bytes received and sent since the
+	// container started
+	NetworkRx int64 `json:"networkRx,omitempty" jsonld:"networkRx"`
+	NetworkTx int64 `json:"networkTx,omitempty" jsonld:"networkTx"`
+
+	// StatsSampledAt is when the agent sampled the usage above; nil if it
+	// never did. Agents report usage in ContainerUsageSamples; container
+	// updates keep the stored usage.
+	StatsSampledAt *time.Time `json:"statsSampledAt,omitempty" jsonld:"statsSampledAt"`
+
+	// Protected is set by the server for containers covered by its
+	// protection policy (deploy.protected_*). Graphium refuses to delete,
+	// stop, control or replace them, and clients should show them read-only.
+	Protected bool `json:"protected,omitempty" jsonld:"protected"`
+}
+
+// RefreshUpdateAvailable recomputes UpdateAvailable from the running and latest digests.
+func (c *Container) RefreshUpdateAvailable() {
+	c.UpdateAvailable = c.ImageDigest != "" && c.LatestImageDigest != "" && c.ImageDigest != c.LatestImageDigest
+}
+
+// DockerLabelConflict returns the first Docker label, by key, that Labels
+// doesn't hold with Docker's value, or "" if Labels holds them all.
+func (c *Container) DockerLabelConflict() string {
+	keys := make([]string, 0, len(c.DockerLabels))
+	for key := range c.DockerLabels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if value, ok := c.Labels[key]; !ok || value != c.DockerLabels[key] {
+			return key
+		}
+	}
+	return ""
+}
+
+// RestoreDockerLabels sets the Docker labels on Labels again.
+func (c *Container) RestoreDockerLabels() {
+	if len(c.DockerLabels) == 0 {
+		return
+	}
+	labels := make(map[string]string, len(c.Labels)+len(c.DockerLabels))
+	for key, value := range c.Labels {
+		labels[key] = value
+	}
+	for key, value := range c.DockerLabels {
+		labels[key] = value
+	}
+	c.Labels = labels
 }
 
 // Port represents a network port mapping between host and container.
@@ -0,0 +1,159 @@
+package models
+
+import (
+	"reflect"
+	"sort"
+	"time"
+)
+
+// ContainerChangeEntry records the fields that changed in one update of a
+// container. Entries form a container's change history.
+//
+// JSON-LD Type: ContainerChange
+type ContainerChangeEntry struct {
+	Context string `json:"@context"`
+	Type    string `json:"@type"`
+	ID      string `json:"@id" couchdb:"_id"`
+	Rev     string `json:"_rev,omitempty" couchdb:"_rev"`
+
+	// ContainerID is the ID of the changed container
+	ContainerID string `json:"containerId"`
+
+	// ContainerName is the container's name after the change
+	ContainerName string `json:"containerName"`
+
+	// HostID is the host the container runs on after the change
+	HostID string `json:"hostId,omitempty"`
+
+	// ChangedBy is the user or agent that sent the update (empty if unauthenticated)
+	ChangedBy string `json:"changedBy,omitempty"`
+
+	// ChangedAt is when the update was stored
+	ChangedAt time.Time `json:"changedAt"`
+
+	// Changes are the changed fields, ordered by field name
+	Changes []FieldChange `json:"changes"`
+}
+
+// FieldChange describes the old and new value of a changed field.
+type FieldChange struct {
+	// Field is the JSON name of the field (e.g. "status", "executableName")
+	Field string `json:"field"`
+
+	Old interface{} `json:"old,omitempty"`
+	New interface{} `json:"new,omitempty"`
+}
+
+// ContainerChangeEntryType is the JSON-LD @type of container change entries.
+// It must not be a schema.org Action type, or the entries would be listed
+// as scheduled actions.
+const ContainerChangeEntryType = "ContainerChange"
+
+// LegacyContainerChangeEntryType is the @type change entries were stored
+// with before they got their own type.
+const LegacyContainerChangeEntryType = "UpdateAction"
+
+// ExcessChangeEntries returns the entries beyond the newest keep, which a
+// capped history drops. A keep of 0 keeps all entries.
+func ExcessChangeEntries(entries []*ContainerChangeEntry, keep int) []*ContainerChangeEntry {
+	if keep <= 0 || len(entries) <= keep {
+		return nil
+	}
+
+	sorted := append([]*ContainerChangeEntry(nil), entries...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].ChangedAt.After(sorted[j].ChangedAt)
+	})
+	return sorted[keep:]
+}
+
+// redactedValue replaces environment values in change entries.
+const redactedValue = "[redacted]"
+
+// DiffContainers returns the field-level changes from old to updated. It
+// ignores the document identity (ID, revision, JSON-LD context and type).
+// Environment values may hold secrets, so only the names of changed
+// variables are reported. An empty result means the update is a no-op.
+func DiffContainers(old, updated *Container) []FieldChange {
+	var changes []FieldChange
+	add := func(field string, oldValue, newValue interface{}) {
+		if !reflect.DeepEqual(oldValue, newValue) {
+			changes = append(changes, FieldChange{Field: field, Old: oldValue, New: newValue})
+		}
+	}
+
+	add("name", old.Name, updated.Name)
+	add("executableName", old.Image, updated.Image)
+	add("status", old.Status, updated.Status)
+	add("health", old.Health, updated.Health)
+	add("hostedOn", old.HostedOn, updated.HostedOn)
+	add("dateCreated", old.Created, updated.Created)
+	add("imageDigest", old.ImageDigest, updated.ImageDigest)
+	add("latestImageDigest", old.LatestImageDigest, updated.LatestImageDigest)
+	add("updateAvailable", old.UpdateAvailable, updated.UpdateAvailable)
+	if !timesEqual(old.ImageCheckedAt, updated.ImageCheckedAt) {
+		changes = append(changes, FieldChange{Field: "imageCheckedAt", Old: old.ImageCheckedAt, New: updated.ImageCheckedAt})
+	}
+	add("ports", emptyAsNil(old.Ports), emptyAsNil(updated.Ports))
+	add("labels", emptyAsNil(old.Labels), emptyAsNil(updated.Labels))
+	add("dockerName", old.DockerName, updated.DockerName)
+	add("dockerLabels", emptyAsNil(old.DockerLabels), emptyAsNil(updated.DockerLabels))
+	add("dependsOn", emptyAsNil(old.DependsOn), emptyAsNil(updated.DependsOn))
+	add("protected", old.Protected, updated.Protected)
+	add("resources", old.Resources, updated.Resources)
+	add("stopGracePeriod", old.StopGracePeriod, updated.StopGracePeriod)
+	add("restart", old.Restart, updated.Restart)
+	if envChanged := changedKeys(old.Env, updated.Env); len(envChanged) > 0 {
+		changes = append(changes, FieldChange{Field: "environment", Old: redactedValue, New: envChanged})
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Field < changes[j].Field
+	})
+	return changes
+}
+
+// UsageChanged reports whether the sampled usage of a container changed.
+// DiffContainers leaves usage out, so it doesn't flood the change history.
+func UsageChanged(old, updated *Container) bool {
+	return old.CPUPercent != updated.CPUPercent ||
+		old.MemoryUsage != updated.MemoryUsage ||
+		old.NetworkRx != updated.NetworkRx ||
+		old.NetworkTx != updated.NetworkTx ||
+		!timesEqual(old.StatsSampledAt, updated.StatsSampledAt)
+}
+
+// changedKeys returns the sorted keys that were added, removed or changed.
+func changedKeys(old, updated map[string]string) []string {
+	var keys []string
+	for key, value := range old {
+		if newValue, ok := updated[key]; !ok || newValue != value {
+			keys = append(keys, key)
+		}
+	}
+	for key := range updated {
+		if _, ok := old[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// emptyAsNil treats empty slices and maps like nil, so an omitted field
+// and an empty one compare equal.
+func emptyAsNil(value interface{}) interface{} {
+	v := reflect.ValueOf(value)
+	if (v.Kind() == reflect.Slice || v.Kind() == reflect.Map) && v.Len() == 0 {
+		return nil
+	}
+	return value
+}
+
+// timesEqual compares optional timestamps by instant.
+func timesEqual(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
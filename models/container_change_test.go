@@ -0,0 +1,101 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDiffContainers(t *testing.T) {
+	old := &Container{
+		ID:       "c1",
+		Rev:      "1-a",
+		Name:     "web",
+		Image:    "nginx:1.25",
+		Status:   "running",
+		HostedOn: "host1",
+		Ports:    []Port{{HostPort: 8080, ContainerPort: 80, Protocol: "tcp"}},
+		Env:      map[string]string{"MODE": "prod", "TOKEN": "secret"},
+		Labels:   map[string]string{},
+	}
+
+	same := *old
+	same.Rev = "2-b"
+	same.Labels = nil
+	if changes := DiffContainers(old, &same); len(changes) != 0 {
+		t.Errorf("Expected no changes, got %+v", changes)
+	}
+
+	updated := *old
+	updated.Image = "nginx:1.27"
+	updated.Status = "exited"
+	updated.Ports = nil
+	updated.Env = map[string]string{"MODE": "prod", "TOKEN": "rotated", "DEBUG": "1"}
+
+	changes := DiffContainers(old, &updated)
+	fields := make([]string, len(changes))
+	for i, change := range changes {
+		fields[i] = change.Field
+	}
+	want := []string{"environment", "executableName", "ports", "status"}
+	if !reflect.DeepEqual(fields, want) {
+		t.Fatalf("Expected changed fields %v, got %v", want, fields)
+	}
+
+	if changes[1].Old != "nginx:1.25" || changes[1].New != "nginx:1.27" {
+		t.Errorf("Unexpected image change: %+v", changes[1])
+	}
+	if !reflect.DeepEqual(changes[0].New, []string{"DEBUG", "TOKEN"}) || changes[0].Old != redactedValue {
+		t.Errorf("Expected only changed variable names, got %+v", changes[0])
+	}
+}
+
+func TestUsageChanged(t *testing.T) {
+	sampled := time.Now()
+	old := &Container{ID: "c1", CPUPercent: 5, MemoryUsage: 1 << 20, StatsSampledAt: &sampled}
+
+	same := *old
+	if UsageChanged(old, &same) {
+		t.Error("Expected unchanged usage")
+	}
+
+	updated := *old
+	updated.NetworkRx = 1024
+	if !UsageChanged(old, &updated) {
+		t.Error("Expected a usage change")
+	}
+	if changes := DiffContainers(old, &updated); len(changes) != 0 {
+		t.Errorf("Expected usage to stay out of the change history, got %+v", changes)
+	}
+
+	later := sampled.Add(time.Minute)
+	resampled := *old
+	resampled.StatsSampledAt = &later
+	if !UsageChanged(old, &resampled) {
+		t.Error("Expected a new sample to count as a change")
+	}
+}
+
+func TestExcessChangeEntries(t *testing.T) {
+	now := time.Now()
+	entries := []*ContainerChangeEntry{
+		{ID: "c", ChangedAt: now.Add(-time.Minute)},
+		{ID: "a", ChangedAt: now.Add(-3 * time.Minute)},
+		{ID: "d", ChangedAt: now},
+		{ID: "b", ChangedAt: now.Add(-2 * time.Minute)},
+	}
+
+	excess := ExcessChangeEntries(entries, 2)
+	if len(excess) != 2 || excess[0].ID != "b" || excess[1].ID != "a" {
+		t.Errorf("Expected the two oldest entries b and a, got %+v", excess)
+	}
+	if entries[0].ID != "c" {
+		t.Error("Expected the input order to be kept")
+	}
+	if excess := ExcessChangeEntries(entries, 4); len(excess) != 0 {
+		t.Errorf("Expected nothing beyond the limit, got %d entries", len(excess))
+	}
+	if excess := ExcessChangeEntries(entries, 0); len(excess) != 0 {
+		t.Errorf("Expected an unlimited history to keep everything, got %d entries", len(excess))
+	}
+}
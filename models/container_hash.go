@@ -0,0 +1,79 @@
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sort"
+)
+
+// ContentHashHeader is the response header carrying the content hash of a
+// container on GET and HEAD /containers/:id. Agents compare it with the
+// hash of the container they observe and skip the update when it matches.
+const ContentHashHeader = "X-Content-Hash"
+
+// ContentHash returns a hash of the container fields the agent reports.
+// Fields maintained by the server (revision, labels, image update state,
+// protection) don't contribute; labels also hold API-set ones on the
+// server, so Docker's contribute as DockerLabels instead. Port order and
+// empty collections don't matter, so an unchanged container hashes the
+// same on the agent and on the server. Sampled usage doesn't contribute
+// either: it changes on every sync and is reported separately
+// (ContainerUsageSample).
+func (c *Container) ContentHash() string {
+	ports := append([]Port(nil), c.Ports...)
+	sort.Slice(ports, func(i, j int) bool {
+		if ports[i].ContainerPort != ports[j].ContainerPort {
+			return ports[i].ContainerPort < ports[j].ContainerPort
+		}
+		if ports[i].Protocol != ports[j].Protocol {
+			return ports[i].Protocol < ports[j].Protocol
+		}
+		return ports[i].HostPort < ports[j].HostPort
+	})
+	env := c.Env
+	if len(env) == 0 {
+		env = nil
+	}
+	dockerLabels := c.DockerLabels
+	if len(dockerLabels) == 0 {
+		dockerLabels = nil
+	}
+
+	// encoding/json sorts map keys, so the encoding is stable
+	data, _ := json.Marshal(struct {
+		ID              string               `json:"id"`
+		Name            string               `json:"name"`
+		DockerName      string               `json:"dockerName"`
+		DockerLabels    map[string]string    `json:"dockerLabels"`
+		Image           string               `json:"image"`
+		Status          string               `json:"status"`
+		Health          string               `json:"health"`
+		HostedOn        string               `json:"hostedOn"`
+		Ports           []Port               `json:"ports"`
+		Env             map[string]string    `json:"env"`
+		Created         string               `json:"created"`
+		ImageDigest     string               `json:"imageDigest"`
+		Resources       *ResourceConstraints `json:"resources"`
+		StopGracePeriod int                  `json:"stopGracePeriod"`
+		Restart         *RestartState        `json:"restart"`
+	}{
+		ID:              c.ID,
+		Name:            c.Name,
+		DockerName:      c.DockerName,
+		DockerLabels:    dockerLabels,
+		Image:           c.Image,
+		Status:          c.Status,
+		Health:          c.Health,
+		HostedOn:        c.HostedOn,
+		Ports:           ports,
+		Env:             env,
+		Created:         c.Created,
+		ImageDigest:     c.ImageDigest,
+		Resources:       c.Resources,
+		StopGracePeriod: c.StopGracePeriod,
+		Restart:         c.Restart,
+	})
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
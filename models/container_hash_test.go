@@ -0,0 +1,78 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestContainer_ContentHash(t *testing.T) {
+	base := func() *Container {
+		return &Container{
+			ID:       "c1",
+			Name:     "web",
+			Image:    "nginx:1.27",
+			Status:   "running",
+			HostedOn: "host-1",
+			Ports: []Port{
+				{HostPort: 8080, ContainerPort: 80, Protocol: "tcp"},
+				{HostPort: 8443, ContainerPort: 443, Protocol: "tcp"},
+			},
+			Env: map[string]string{"A": "1", "B": "2"},
+		}
+	}
+
+	hash := base().ContentHash()
+
+	// Port order and server-maintained fields don't matter
+	reordered := base()
+	reordered.Ports[0], reordered.Ports[1] = reordered.Ports[1], reordered.Ports[0]
+	reordered.Rev = "3-abc"
+	reordered.Labels = map[string]string{"team": "web"}
+	reordered.UpdateAvailable = true
+	reordered.Protected = true
+	if got := reordered.ContentHash(); got != hash {
+		t.Errorf("Expected the same hash for reordered ports and server fields, got %s and %s", got, hash)
+	}
+
+	// The hash survives a JSON round trip, as between agent and server
+	data, err := json.Marshal(base())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded Container
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if got := decoded.ContentHash(); got != hash {
+		t.Errorf("Expected the same hash after a JSON round trip, got %s and %s", got, hash)
+	}
+
+	// Empty and missing environments hash the same
+	empty, missing := base(), base()
+	empty.Env = map[string]string{}
+	missing.Env = nil
+	if empty.ContentHash() != missing.ContentHash() {
+		t.Error("Expected an empty environment to hash like a missing one")
+	}
+
+	changed := base()
+	changed.Status = "exited"
+	if changed.ContentHash() == hash {
+		t.Error("Expected a status change to change the hash")
+	}
+	changed = base()
+	changed.Restart = &RestartState{RestartCount: 3}
+	if changed.ContentHash() == hash {
+		t.Error("Expected a restart state change to change the hash")
+	}
+
+	// Sampled usage is reported separately
+	changed = base()
+	changed.CPUPercent = 12.5
+	sampled := time.Now()
+	changed.StatsSampledAt = &sampled
+	if changed.ContentHash() != hash {
+		t.Error("Expected usage not to change the hash")
+	}
+}
@@ -0,0 +1,49 @@
+package models
+
+import "testing"
+
+func TestDockerLabelConflict(t *testing.T) {
+	docker := map[string]string{"app": "web", "team": "core"}
+	tests := []struct {
+		name   string
+		labels map[string]string
+		want   string
+	}{
+		{"all kept", map[string]string{"app": "web", "team": "core"}, ""},
+		{"API label added", map[string]string{"app": "web", "team": "core", "tier": "front"}, ""},
+		{"removed", map[string]string{"app": "web"}, "team"},
+		{"changed", map[string]string{"app": "api", "team": "core"}, "app"},
+		{"all cleared", nil, "app"},
+	}
+	for _, tt := range tests {
+		c := &Container{Labels: tt.labels, DockerLabels: docker}
+		if got := c.DockerLabelConflict(); got != tt.want {
+			t.Errorf("%s: DockerLabelConflict() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+
+	if got := (&Container{}).DockerLabelConflict(); got != "" {
+		t.Errorf("Expected no conflict without Docker labels, got %q", got)
+	}
+}
+
+func TestRestoreDockerLabels(t *testing.T) {
+	c := &Container{
+		Labels:       map[string]string{"app": "api", "tier": "front"},
+		DockerLabels: map[string]string{"app": "web", "team": "core"},
+	}
+	c.RestoreDockerLabels()
+
+	want := map[string]string{"app": "web", "team": "core", "tier": "front"}
+	if len(c.Labels) != len(want) {
+		t.Fatalf("Expected labels %v, got %v", want, c.Labels)
+	}
+	for key, value := range want {
+		if c.Labels[key] != value {
+			t.Errorf("Expected label %s=%s, got %q", key, value, c.Labels[key])
+		}
+	}
+	if c.DockerLabelConflict() != "" {
+		t.Error("Expected no conflict after restoring the Docker labels")
+	}
+}
@@ -0,0 +1,54 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// RestartState is the restart state of a container, as reported by the
+// agent from docker inspect. It shows whether Docker is backing off the
+// restarts of a crash-looping container and why the container last exited.
+type RestartState struct {
+	// RestartCount is how often Docker restarted the container under its
+	// restart policy
+	RestartCount int `json:"restartCount"`
+
+	// Policy is the restart policy (no, always, on-failure, unless-stopped)
+	Policy string `json:"policy,omitempty"`
+
+	// Restarting is set while Docker waits out its restart backoff before
+	// starting the container again
+	Restarting bool `json:"restarting,omitempty"`
+
+	// ExitCode is the exit code of the last run
+	ExitCode int `json:"exitCode"`
+
+	// OOMKilled is set if the kernel killed the last run for running out of memory
+	OOMKilled bool `json:"oomKilled,omitempty"`
+
+	// LastExitReason describes why the last run ended, e.g.
+	// "exited with code 1" or "killed: out of memory"
+	LastExitReason string `json:"lastExitReason,omitempty"`
+
+	// FinishedAt is when the last run ended
+	FinishedAt *time.Time `json:"finishedAt,omitempty"`
+}
+
+// ExitReason describes why a container run ended from its exit code, OOM
+// flag and the error reported by Docker.
+func ExitReason(exitCode int, oomKilled bool, errMsg string) string {
+	switch {
+	case oomKilled:
+		return "killed: out of memory"
+	case errMsg != "":
+		return errMsg
+	case exitCode == 0:
+		return "exited normally"
+	case exitCode == 137:
+		return "killed (SIGKILL, exit code 137)"
+	case exitCode == 143:
+		return "terminated (SIGTERM, exit code 143)"
+	default:
+		return fmt.Sprintf("exited with code %d", exitCode)
+	}
+}
@@ -0,0 +1,24 @@
+package models
+
+import "testing"
+
+func TestExitReason(t *testing.T) {
+	tests := []struct {
+		exitCode  int
+		oomKilled bool
+		errMsg    string
+		want      string
+	}{
+		{0, false, "", "exited normally"},
+		{1, false, "", "exited with code 1"},
+		{137, true, "", "killed: out of memory"},
+		{137, false, "", "killed (SIGKILL, exit code 137)"},
+		{143, false, "", "terminated (SIGTERM, exit code 143)"},
+		{127, false, "executable file not found", "executable file not found"},
+	}
+	for _, tt := range tests {
+		if got := ExitReason(tt.exitCode, tt.oomKilled, tt.errMsg); got != tt.want {
+			t.Errorf("ExitReason(%d, %v, %q) = %q, want %q", tt.exitCode, tt.oomKilled, tt.errMsg, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+// ContainerUsageSample is one resource usage sample of a container. Agents
+// report samples apart from the container (PUT /hosts/:id/container-usage),
+// so usage, which changes on every sample, leaves the container's content
+// hash alone.
+type ContainerUsageSample struct {
+	// ContainerID is the sampled container
+	ContainerID string `json:"containerId"`
+
+	// CPUPercent is the CPU usage in percent of one CPU (200 = two full CPUs)
+	CPUPercent float64 `json:"cpuPercent"`
+
+	// MemoryUsage is the memory in use in bytes, excluding the page cache
+	MemoryUsage int64 `json:"memoryUsage"`
+
+	// NetworkRx and NetworkTx are the bytes received and sent since the
+	// container started
+	NetworkRx int64 `json:"networkRx"`
+	NetworkTx int64 `json:"networkTx"`
+
+	// SampledAt is when the agent took the sample
+	SampledAt time.Time `json:"sampledAt"`
+}
+
+// Apply sets the usage of a container from the sample.
+func (u *ContainerUsageSample) Apply(c *Container) {
+	c.CPUPercent = u.CPUPercent
+	c.MemoryUsage = u.MemoryUsage
+	c.NetworkRx = u.NetworkRx
+	c.NetworkTx = u.NetworkTx
+	sampledAt := u.SampledAt
+	c.StatsSampledAt = &sampledAt
+}
@@ -0,0 +1,28 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestContainerUsageSample_Apply(t *testing.T) {
+	container := &Container{ID: "c1", Status: "running"}
+	hash := container.ContentHash()
+
+	sample := ContainerUsageSample{ContainerID: "c1", CPUPercent: 42, MemoryUsage: 1 << 20, NetworkRx: 10, NetworkTx: 20, SampledAt: time.Now()}
+	updated := *container
+	sample.Apply(&updated)
+
+	if updated.CPUPercent != 42 || updated.MemoryUsage != 1<<20 || updated.NetworkRx != 10 || updated.NetworkTx != 20 {
+		t.Errorf("Expected the sampled usage, got %+v", updated)
+	}
+	if updated.StatsSampledAt == nil || !updated.StatsSampledAt.Equal(sample.SampledAt) {
+		t.Errorf("Expected the sample time, got %v", updated.StatsSampledAt)
+	}
+	if !UsageChanged(container, &updated) {
+		t.Error("Expected a usage change")
+	}
+	if updated.ContentHash() != hash {
+		t.Error("Expected a usage sample not to change the content hash")
+	}
+}
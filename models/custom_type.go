@@ -0,0 +1,201 @@
+package models
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+)
+
+// Edge targets of custom type edge rules besides other custom types.
+const (
+	// EdgeTargetContainer links an entity to containers by ID
+	EdgeTargetContainer = "container"
+
+	// EdgeTargetHost links an entity to hosts by ID
+	EdgeTargetHost = "host"
+)
+
+// customTypeNamePattern allows plain names (Database) and compact IRIs
+// (ex:LoadBalancer).
+var customTypeNamePattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_.-]*(:[A-Za-z][A-Za-z0-9_.-]*)?$`)
+
+// EdgeRule derives graph edges from a property of a custom entity. The
+// property holds the ID, or a list of IDs, of the entities it points to.
+//
+// Example: a Database entity whose "runsOn" property lists container IDs
+//
+//	{"property": "runsOn", "target": "container"}
+type EdgeRule struct {
+	// Property is the entity property holding the target ID(s)
+	Property string `json:"property"`
+
+	// Relation is the edge type in the graph (default: the property name)
+	Relation string `json:"relation,omitempty"`
+
+	// Target is what the IDs refer to: container, host or the name of a
+	// registered custom type
+	Target string `json:"target"`
+}
+
+// RelationName returns the edge type of the rule.
+func (r EdgeRule) RelationName() string {
+	if r.Relation != "" {
+		return r.Relation
+	}
+	return r.Property
+}
+
+// CustomType registers a JSON-LD @type for entities that Graphium doesn't
+// model itself (databases, load balancers, ...), and how its entities link
+// to containers, hosts and other custom entities in the graph.
+type CustomType struct {
+	Context string `json:"@context"`
+	Type    string `json:"@type"`
+
+	// ID is the document ID (custom-type-{name})
+	ID  string `json:"@id" couchdb:"_id"`
+	Rev string `json:"_rev,omitempty" couchdb:"_rev"`
+
+	// Name is the @type of the entities, e.g. "Database" or "ex:LoadBalancer"
+	Name string `json:"name"`
+
+	// Vocabulary is the @context of the entities (default: https://schema.org)
+	Vocabulary string `json:"vocabulary,omitempty"`
+
+	// Description describes what the entities model
+	Description string `json:"description,omitempty"`
+
+	// Edges are the rules deriving graph edges from entity properties
+	Edges []EdgeRule `json:"edges,omitempty"`
+
+	// Source is where the type was registered: config or api
+	Source string `json:"source,omitempty"`
+
+	// CreatedAt is when the type was registered
+	CreatedAt time.Time `json:"createdAt,omitempty"`
+}
+
+// Custom type sources.
+const (
+	CustomTypeSourceConfig = "config"
+	CustomTypeSourceAPI    = "api"
+)
+
+// CustomTypeID returns the document ID of a custom type registration.
+func CustomTypeID(name string) string {
+	return "custom-type-" + name
+}
+
+// Validate checks the type name and edge rules.
+func (t *CustomType) Validate() error {
+	if !customTypeNamePattern.MatchString(t.Name) {
+		return fmt.Errorf("invalid type name %q: use letters, digits, '_', '.', '-' and an optional prefix (ex:Name)", t.Name)
+	}
+	if IsBuiltinType(t.Name) {
+		return fmt.Errorf("type %s is built into Graphium", t.Name)
+	}
+	for i, rule := range t.Edges {
+		if rule.Property == "" {
+			return fmt.Errorf("edge rule %d: property is required", i)
+		}
+		if rule.Target == "" {
+			return fmt.Errorf("edge rule %d: target is required (container, host or a custom type)", i)
+		}
+	}
+	return nil
+}
+
+// EntityVocabulary returns the @context of the type's entities.
+func (t *CustomType) EntityVocabulary() string {
+	if t.Vocabulary != "" {
+		return t.Vocabulary
+	}
+	return "https://schema.org"
+}
+
+// CustomEntity is a document of a registered custom type. Its properties
+// are free-form; edge rules of its type read target IDs from them.
+type CustomEntity struct {
+	Context string `json:"@context"`
+	Type    string `json:"@type"`
+
+	ID  string `json:"@id" couchdb:"_id"`
+	Rev string `json:"_rev,omitempty" couchdb:"_rev"`
+
+	// Name is the human-readable name of the entity
+	Name string `json:"name"`
+
+	// CustomEntity marks documents of custom types, so they can be listed
+	// regardless of their @type
+	CustomEntity bool `json:"customEntity"`
+
+	// Properties are the entity's properties
+	Properties map[string]interface{} `json:"properties,omitempty"`
+
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// CustomEdge is a graph edge derived from an edge rule.
+type CustomEdge struct {
+	From     string
+	To       string
+	Relation string
+	Target   string
+}
+
+// EdgesOf returns the edges the type's rules derive from an entity.
+// Property values that aren't an ID or a list of IDs are ignored.
+func (t *CustomType) EdgesOf(entity *CustomEntity) []CustomEdge {
+	var edges []CustomEdge
+	for _, rule := range t.Edges {
+		for _, id := range propertyIDs(entity.Properties[rule.Property]) {
+			edges = append(edges, CustomEdge{
+				From:     entity.ID,
+				To:       id,
+				Relation: rule.RelationName(),
+				Target:   rule.Target,
+			})
+		}
+	}
+	return edges
+}
+
+// ValidateEntity checks that the edge properties of an entity hold IDs.
+func (t *CustomType) ValidateEntity(entity *CustomEntity) error {
+	if entity.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	for _, rule := range t.Edges {
+		value, ok := entity.Properties[rule.Property]
+		if !ok || value == nil {
+			continue
+		}
+		if ids := propertyIDs(value); len(ids) == 0 {
+			return fmt.Errorf("property %s must be an ID or a list of IDs", rule.Property)
+		}
+	}
+	return nil
+}
+
+// propertyIDs returns the IDs held by a property value: a string or a
+// list of strings.
+func propertyIDs(value interface{}) []string {
+	switch v := value.(type) {
+	case string:
+		if v != "" {
+			return []string{v}
+		}
+	case []string:
+		return v
+	case []interface{}:
+		var ids []string
+		for _, item := range v {
+			if id, ok := item.(string); ok && id != "" {
+				ids = append(ids, id)
+			}
+		}
+		return ids
+	}
+	return nil
+}
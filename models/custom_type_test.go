@@ -0,0 +1,75 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCustomType_Validate(t *testing.T) {
+	valid := []CustomType{
+		{Name: "Database"},
+		{Name: "ex:LoadBalancer", Edges: []EdgeRule{{Property: "backends", Target: EdgeTargetContainer}}},
+	}
+	for _, ct := range valid {
+		if err := ct.Validate(); err != nil {
+			t.Errorf("Expected %s to be valid, got %v", ct.Name, err)
+		}
+	}
+
+	invalid := []CustomType{
+		{Name: ""},
+		{Name: "has space"},
+		{Name: "SoftwareApplication"},
+		{Name: "AgentTask"},
+		{Name: "DeploymentState"},
+		{Name: "ItemList"},
+		{Name: "DatacenterPolicy"},
+		{Name: "ShareLink"},
+		{Name: "Person"},
+		{Name: "StackIndex"},
+		{Name: ContainerChangeEntryType},
+		{Name: "Database", Edges: []EdgeRule{{Target: EdgeTargetHost}}},
+		{Name: "Database", Edges: []EdgeRule{{Property: "runsOn"}}},
+	}
+	for _, ct := range invalid {
+		if err := ct.Validate(); err == nil {
+			t.Errorf("Expected %+v to be invalid", ct)
+		}
+	}
+}
+
+func TestCustomType_EdgesOf(t *testing.T) {
+	ct := &CustomType{
+		Name: "Database",
+		Edges: []EdgeRule{
+			{Property: "runsOn", Target: EdgeTargetContainer},
+			{Property: "host", Relation: "locatedOn", Target: EdgeTargetHost},
+		},
+	}
+	entity := &CustomEntity{
+		ID:   "entity:db",
+		Name: "orders-db",
+		Properties: map[string]interface{}{
+			"runsOn":  []interface{}{"c1", "c2", 3},
+			"host":    "h1",
+			"version": "16",
+		},
+	}
+
+	want := []CustomEdge{
+		{From: "entity:db", To: "c1", Relation: "runsOn", Target: EdgeTargetContainer},
+		{From: "entity:db", To: "c2", Relation: "runsOn", Target: EdgeTargetContainer},
+		{From: "entity:db", To: "h1", Relation: "locatedOn", Target: EdgeTargetHost},
+	}
+	if got := ct.EdgesOf(entity); !reflect.DeepEqual(got, want) {
+		t.Errorf("EdgesOf() = %+v, want %+v", got, want)
+	}
+
+	if err := ct.ValidateEntity(entity); err != nil {
+		t.Errorf("Expected entity to be valid, got %v", err)
+	}
+	entity.Properties["host"] = 42
+	if err := ct.ValidateEntity(entity); err == nil {
+		t.Error("Expected an error for an edge property without IDs")
+	}
+}
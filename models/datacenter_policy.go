@@ -0,0 +1,102 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// Placement strategies for containers the deployment plan doesn't assign to a host.
+const (
+	// PlacementFirstFit places a container on the first host with enough capacity
+	PlacementFirstFit = "first-fit"
+
+	// PlacementSpread places a container on the least utilized host
+	PlacementSpread = "spread"
+
+	// PlacementBinPack places a container on the most utilized host it still fits on
+	PlacementBinPack = "binpack"
+)
+
+// IsPlacementStrategy reports whether strategy is a known placement strategy.
+func IsPlacementStrategy(strategy string) bool {
+	switch strategy {
+	case PlacementFirstFit, PlacementSpread, PlacementBinPack:
+		return true
+	}
+	return false
+}
+
+// DatacenterPolicy holds the deployment defaults of one datacenter. The
+// deployer consults the policy of the datacenter it places a container in;
+// unset fields fall back to the global configuration.
+type DatacenterPolicy struct {
+	// Context is the JSON-LD @context
+	Context string `json:"@context"`
+
+	// Type is the JSON-LD @type
+	Type string `json:"@type"`
+
+	// ID is the document ID (datacenter-policy-{datacenter})
+	ID string `json:"@id" couchdb:"_id"`
+
+	// Rev is the CouchDB document revision
+	Rev string `json:"_rev,omitempty" couchdb:"_rev"`
+
+	// Datacenter is the datacenter (host location) the policy applies to
+	Datacenter string `json:"datacenter"`
+
+	// PlacementStrategy is the default strategy for automatic placement
+	// (first-fit, spread, binpack or a custom strategy registered with the
+	// server)
+	PlacementStrategy string `json:"placementStrategy,omitempty"`
+
+	// AllowOvercommit overrides whether containers may be placed beyond the
+	// remaining capacity of hosts in this datacenter
+	AllowOvercommit *bool `json:"allowOvercommit,omitempty"`
+
+	// ProtectedNamePatterns are glob patterns of container names deployments
+	// must never create or replace in this datacenter, in addition to the
+	// global protection policy
+	ProtectedNamePatterns []string `json:"protectedNamePatterns,omitempty"`
+
+	// ProtectedImages are image references or glob patterns protected in this
+	// datacenter, in addition to the global protection policy
+	ProtectedImages []string `json:"protectedImages,omitempty"`
+
+	// NetworkMode is the Docker network mode (e.g. bridge, host) of containers
+	// deployed here by stacks that don't define a network
+	NetworkMode string `json:"networkMode,omitempty"`
+
+	// UpdatedBy is the user who last changed the policy
+	UpdatedBy string `json:"updatedBy,omitempty"`
+
+	// UpdatedAt is when the policy was last changed
+	UpdatedAt time.Time `json:"dateModified"`
+}
+
+// DatacenterPolicyID returns the document ID of a datacenter's policy.
+func DatacenterPolicyID(datacenter string) string {
+	return "datacenter-policy-" + datacenter
+}
+
+// Protection returns the policy's protected containers.
+func (p *DatacenterPolicy) Protection() *ProtectionPolicy {
+	if p == nil {
+		return nil
+	}
+	return &ProtectionPolicy{NamePatterns: p.ProtectedNamePatterns, Images: p.ProtectedImages}
+}
+
+// Validate checks the placement strategy, network mode and protection patterns.
+func (p *DatacenterPolicy) Validate() error {
+	if p.PlacementStrategy != "" && !IsPlacementStrategy(p.PlacementStrategy) {
+		return fmt.Errorf("unknown placement strategy %q (expected %s, %s or %s)",
+			p.PlacementStrategy, PlacementFirstFit, PlacementSpread, PlacementBinPack)
+	}
+	switch p.NetworkMode {
+	case "", "bridge", "host", "none":
+	default:
+		return fmt.Errorf("unsupported network mode %q (expected bridge, host or none)", p.NetworkMode)
+	}
+	return p.Protection().Validate()
+}
@@ -0,0 +1,31 @@
+package models
+
+import "testing"
+
+func TestDatacenterPolicy_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  DatacenterPolicy
+		wantErr bool
+	}{
+		{"empty", DatacenterPolicy{Datacenter: "edge"}, false},
+		{"complete", DatacenterPolicy{
+			Datacenter:            "edge",
+			PlacementStrategy:     PlacementBinPack,
+			ProtectedNamePatterns: []string{"traefik*"},
+			NetworkMode:           "host",
+		}, false},
+		{"unknown strategy", DatacenterPolicy{PlacementStrategy: "random"}, true},
+		{"unknown network mode", DatacenterPolicy{NetworkMode: "overlay"}, true},
+		{"malformed pattern", DatacenterPolicy{ProtectedImages: []string{"postgres["}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.policy.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,29 @@
+package models
+
+import "testing"
+
+func TestDNSCheckPayload_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload DNSCheckPayload
+		wantErr bool
+	}{
+		{"hostname", DNSCheckPayload{Hostname: "db.example.com"}, false},
+		{"expected IPs", DNSCheckPayload{Hostname: "db.example.com", Expected: []string{"10.0.0.5", "10.0.0.6"}}, false},
+		{"AAAA", DNSCheckPayload{Hostname: "db.example.com", RecordType: DNSRecordAAAA, Expected: []string{"2001:db8::1"}}, false},
+		{"CNAME", DNSCheckPayload{Hostname: "www.example.com", RecordType: DNSRecordCNAME, Expected: []string{"lb.example.net"}}, false},
+		{"nameserver", DNSCheckPayload{Hostname: "db.example.com", Nameserver: "1.1.1.1:53"}, false},
+		{"missing hostname", DNSCheckPayload{}, true},
+		{"unsupported record type", DNSCheckPayload{Hostname: "example.com", RecordType: "MX"}, true},
+		{"expected value not an IP", DNSCheckPayload{Hostname: "db.example.com", Expected: []string{"lb.example.net"}}, true},
+		{"nameserver without port", DNSCheckPayload{Hostname: "db.example.com", Nameserver: "1.1.1.1"}, true},
+		{"negative timeout", DNSCheckPayload{Hostname: "db.example.com", Timeout: -1}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.payload.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
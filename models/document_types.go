@@ -0,0 +1,35 @@
+package models
+
+import "slices"
+
+// BuiltinTypes are the @types of the documents Graphium stores itself.
+// Queries select documents by @type, so a custom entity reusing one of them
+// would show up as a Graphium document; custom types can't be registered
+// under these names. Add every new document type here.
+var BuiltinTypes = []string{
+	// Containers and hosts
+	"SoftwareApplication", "ComputerServer", "ComputerSystem", "HostSystemInfo",
+
+	// Stacks and deployments
+	"ItemList", "Stack", "StackDeployment", "DeploymentState", "DatacenterPolicy",
+
+	// Agent tasks and scheduled actions
+	"AgentTask", "Schedule", "Action", "ActivateAction", "DeactivateAction", "DeleteAction",
+	"CreateAction", "UpdateAction", "CheckAction", "ControlAction", "TransferAction",
+	"ScaleAction", "WorkflowAction",
+
+	// Container history and agent reports
+	ContainerChangeEntryType, "HealthHistory", "AgentLogs", "IgnoreListEntry",
+
+	// Configuration, users and sharing
+	"CustomType", "datacenter:AgentConfig", "Person", "ShareLink",
+
+	// The former stacks-by-container document, still present in older databases
+	"StackIndex",
+}
+
+// IsBuiltinType reports whether Graphium stores its own documents under
+// the @type name.
+func IsBuiltinType(name string) bool {
+	return slices.Contains(BuiltinTypes, name)
+}
@@ -0,0 +1,286 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+)
+
+// ControlExec routes a ControlAction to a command run inside the container.
+const ControlExec = "exec"
+
+// WorkflowExecStep is the workflow step type running a command inside a
+// container.
+const WorkflowExecStep = "container-exec"
+
+// Output caps of exec tasks: stdout and stderr each keep DefaultExecOutput
+// bytes unless the payload asks for up to MaxExecOutput.
+const (
+	DefaultExecOutput = 64 * 1024
+	MaxExecOutput     = 1024 * 1024
+)
+
+// ExecCommand is the command of an exec task. In JSON it is an array of
+// arguments, or a string run with /bin/sh -c.
+type ExecCommand []string
+
+// UnmarshalJSON reads a command given as an array or a shell string.
+func (c *ExecCommand) UnmarshalJSON(data []byte) error {
+	var line string
+	if err := json.Unmarshal(data, &line); err == nil {
+		*c = ExecCommand{"/bin/sh", "-c", line}
+		return nil
+	}
+	var args []string
+	if err := json.Unmarshal(data, &args); err != nil {
+		return fmt.Errorf("command must be a string or an array of strings")
+	}
+	*c = args
+	return nil
+}
+
+// ExecPayload contains data for running a command inside a container.
+//
+// Example: flush a Redis cache
+//
+//	{"action": "exec", "containerId": "4f2a...", "command": ["redis-cli", "FLUSHALL"]}
+type ExecPayload struct {
+	// Action is ControlExec (the "type" of a workflow step is WorkflowExecStep)
+	Action string `json:"action,omitempty"`
+
+	// ContainerID is the ID of the container the command runs in
+	ContainerID string `json:"containerId"`
+
+	// Command is the command and its arguments
+	Command ExecCommand `json:"command"`
+
+	// WorkDir is the working directory of the command (default: the
+	// container's)
+	WorkDir string `json:"workDir,omitempty"`
+
+	// Env are additional environment variables of the command
+	Env map[string]string `json:"env,omitempty"`
+
+	// User is the user the command runs as (default: the container's)
+	User string `json:"user,omitempty"`
+
+	// MaxOutputBytes caps stdout and stderr each (default:
+	// DefaultExecOutput, at most MaxExecOutput)
+	MaxOutputBytes int `json:"maxOutputBytes,omitempty"`
+}
+
+// Validate checks that the payload names a container and a command.
+func (p *ExecPayload) Validate() error {
+	if p.ContainerID == "" {
+		return fmt.Errorf("containerId is required")
+	}
+	if len(p.Command) == 0 || p.Command[0] == "" {
+		return fmt.Errorf("command is required")
+	}
+	if p.MaxOutputBytes < 0 {
+		return fmt.Errorf("maxOutputBytes must not be negative")
+	}
+	return nil
+}
+
+// OutputLimit returns how many bytes of stdout and stderr each the task
+// keeps.
+func (p *ExecPayload) OutputLimit() int {
+	if p.MaxOutputBytes <= 0 {
+		return DefaultExecOutput
+	}
+	return min(p.MaxOutputBytes, MaxExecOutput)
+}
+
+// ExecPayloads returns the commands a task runs inside containers: the
+// payload of an exec ControlAction, or the exec steps of a workflow.
+func ExecPayloads(task *AgentTask) ([]*ExecPayload, error) {
+	switch task.Type {
+	case "ControlAction":
+		var control struct {
+			Action string `json:"action"`
+		}
+		if task.GetPayloadAs(&control) != nil || control.Action != ControlExec {
+			return nil, nil
+		}
+		var payload ExecPayload
+		if err := task.GetPayloadAs(&payload); err != nil {
+			return nil, fmt.Errorf("invalid exec payload: %w", err)
+		}
+		return []*ExecPayload{&payload}, nil
+
+	case "WorkflowAction":
+		var workflow struct {
+			Actions []json.RawMessage `json:"actions"`
+		}
+		if err := task.GetPayloadAs(&workflow); err != nil {
+			return nil, nil
+		}
+		var payloads []*ExecPayload
+		for i, raw := range workflow.Actions {
+			var step struct {
+				Type string `json:"type"`
+			}
+			if json.Unmarshal(raw, &step) != nil || step.Type != WorkflowExecStep {
+				continue
+			}
+			var payload ExecPayload
+			if err := json.Unmarshal(raw, &payload); err != nil {
+				return nil, fmt.Errorf("invalid exec step %d: %w", i+1, err)
+			}
+			payloads = append(payloads, &payload)
+		}
+		return payloads, nil
+	}
+	return nil, nil
+}
+
+// ExecPolicy decides which commands exec tasks may run in which
+// containers. Exec is refused entirely unless enabled, and then only
+// allowed for commands a rule lists for the container.
+type ExecPolicy struct {
+	// Enabled allows exec tasks at all
+	Enabled bool
+
+	// Rules are the allowed commands per container
+	Rules []ExecRule
+}
+
+// ExecRule allows commands in the containers it selects by name and
+// labels. A rule selects the containers matching all of its criteria and
+// needs at least one. Only the name and labels agents report from Docker
+// count, not the ones users can edit through the API.
+type ExecRule struct {
+	// Containers are glob patterns (path.Match syntax) matched against
+	// Docker container names
+	Containers []string
+
+	// Labels are Docker labels ("key" or "key=value") a container must all
+	// have
+	Labels []string
+
+	// Commands are the allowed command lines. A command given as an array
+	// matches if its arguments are the words of the line; one given as a
+	// string runs in a shell and must be the line exactly.
+	Commands []string
+
+	// User is the user the commands must run as ("" = the container's
+	// default user)
+	User string
+
+	// AllowEnv allows tasks to set environment variables of the commands
+	AllowEnv bool
+}
+
+// Validate checks that each rule selects containers, lists commands and
+// has well-formed patterns.
+func (p *ExecPolicy) Validate() error {
+	if p == nil {
+		return nil
+	}
+	for i, rule := range p.Rules {
+		if len(rule.Containers) == 0 && len(rule.Labels) == 0 {
+			return fmt.Errorf("exec rule %d selects no containers (set containers or labels)", i+1)
+		}
+		if len(rule.Commands) == 0 {
+			return fmt.Errorf("exec rule %d allows no commands", i+1)
+		}
+		for _, pattern := range rule.Containers {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return fmt.Errorf("invalid exec container pattern %q: %w", pattern, err)
+			}
+		}
+		for _, label := range rule.Labels {
+			if key, _, _ := strings.Cut(label, "="); strings.TrimSpace(key) == "" {
+				return fmt.Errorf("invalid exec label %q (expected key or key=value)", label)
+			}
+		}
+		for _, command := range rule.Commands {
+			if strings.TrimSpace(command) == "" {
+				return fmt.Errorf("exec rule %d has an empty command", i+1)
+			}
+		}
+	}
+	return nil
+}
+
+// ExecViolation checks whether a task runs a command in a container the
+// policy doesn't allow. lookup resolves a container ID to the synced
+// container. It returns a description of the violation, or "" if the task
+// runs no commands or only allowed ones.
+func (p *ExecPolicy) ExecViolation(task *AgentTask, lookup func(id string) (*Container, error)) string {
+	payloads, err := ExecPayloads(task)
+	if err != nil {
+		return err.Error()
+	}
+	for _, payload := range payloads {
+		if violation := p.check(payload, lookup); violation != "" {
+			return violation
+		}
+	}
+	return ""
+}
+
+// check checks a single command against the policy.
+func (p *ExecPolicy) check(payload *ExecPayload, lookup func(id string) (*Container, error)) string {
+	if p == nil || !p.Enabled {
+		return "exec is disabled on this server (exec.enabled)"
+	}
+	if err := payload.Validate(); err != nil {
+		return "invalid exec payload: " + err.Error()
+	}
+
+	container, err := lookup(payload.ContainerID)
+	if err != nil || container == nil {
+		return fmt.Sprintf("container %s is unknown: exec needs the ID of a synced container", payload.ContainerID)
+	}
+	command := strings.Join(payload.Command, " ")
+	for _, rule := range p.Rules {
+		if rule.selects(container) && rule.allows(payload) {
+			return ""
+		}
+	}
+	return fmt.Sprintf("command %q is not allowed in container %s", command, strings.TrimPrefix(container.Name, "/"))
+}
+
+// selects reports whether the rule covers a container by its Docker name
+// and labels.
+func (r *ExecRule) selects(container *Container) bool {
+	if len(r.Containers) == 0 && len(r.Labels) == 0 {
+		return false
+	}
+	if len(r.Containers) > 0 {
+		name := strings.TrimPrefix(container.DockerName, "/")
+		if !slices.ContainsFunc(r.Containers, func(pattern string) bool {
+			ok, _ := path.Match(pattern, name)
+			return ok
+		}) {
+			return false
+		}
+	}
+	for _, label := range r.Labels {
+		key, value, hasValue := strings.Cut(label, "=")
+		actual, ok := container.DockerLabels[strings.TrimSpace(key)]
+		if !ok || (hasValue && actual != strings.TrimSpace(value)) {
+			return false
+		}
+	}
+	return true
+}
+
+// allows reports whether the rule allows a payload's command, user and
+// environment.
+func (r *ExecRule) allows(payload *ExecPayload) bool {
+	if payload.User != r.User || (len(payload.Env) > 0 && !r.AllowEnv) {
+		return false
+	}
+	for _, line := range r.Commands {
+		if slices.Equal(payload.Command, strings.Fields(line)) ||
+			slices.Equal(payload.Command, []string{"/bin/sh", "-c", line}) {
+			return true
+		}
+	}
+	return false
+}
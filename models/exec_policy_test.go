@@ -0,0 +1,150 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestExecCommand_UnmarshalJSON(t *testing.T) {
+	var payload ExecPayload
+	if err := json.Unmarshal([]byte(`{"containerId": "c1", "command": "redis-cli FLUSHALL"}`), &payload); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got := strings.Join(payload.Command, "|"); got != "/bin/sh|-c|redis-cli FLUSHALL" {
+		t.Errorf("Expected a shell command, got %q", got)
+	}
+
+	if err := json.Unmarshal([]byte(`{"command": ["redis-cli", "FLUSHALL"]}`), &payload); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got := strings.Join(payload.Command, "|"); got != "redis-cli|FLUSHALL" {
+		t.Errorf("Expected the arguments, got %q", got)
+	}
+
+	if err := json.Unmarshal([]byte(`{"command": 42}`), &payload); err == nil {
+		t.Error("Expected an error for a numeric command")
+	}
+}
+
+func TestExecPayload_OutputLimit(t *testing.T) {
+	tests := map[int]int{
+		0:                 DefaultExecOutput,
+		1000:              1000,
+		MaxExecOutput * 2: MaxExecOutput,
+	}
+	for requested, want := range tests {
+		payload := ExecPayload{MaxOutputBytes: requested}
+		if got := payload.OutputLimit(); got != want {
+			t.Errorf("OutputLimit() with %d requested = %d, want %d", requested, got, want)
+		}
+	}
+}
+
+func TestExecPolicy_ExecViolation(t *testing.T) {
+	containers := map[string]*Container{
+		"c-redis": {Name: "/redis-cache", DockerName: "/redis-cache", DockerLabels: map[string]string{"app": "cache"}},
+		"c-app":   {Name: "/app", DockerName: "/app", DockerLabels: map[string]string{"app": "web", "maintenance": "true"}},
+		// Name and labels edited through the API don't select containers
+		"c-edited": {Name: "redis-worker", DockerName: "worker", Labels: map[string]string{"maintenance": "true"}},
+	}
+	lookup := func(id string) (*Container, error) {
+		if c, ok := containers[id]; ok {
+			return c, nil
+		}
+		return nil, fmt.Errorf("not found")
+	}
+	policy := &ExecPolicy{
+		Enabled: true,
+		Rules: []ExecRule{
+			{Containers: []string{"redis-*"}, Commands: []string{"redis-cli FLUSHALL"}},
+			{Labels: []string{"maintenance=true"}, Commands: []string{"/app/rotate-keys"}, User: "app", AllowEnv: true},
+		},
+	}
+	control := func(payload map[string]interface{}) *AgentTask {
+		task := &AgentTask{Type: "ControlAction"}
+		if _, ok := payload["action"]; !ok {
+			payload["action"] = ControlExec
+		}
+		if err := task.SetPayload(payload); err != nil {
+			t.Fatalf("SetPayload: %v", err)
+		}
+		return task
+	}
+
+	tests := []struct {
+		name    string
+		task    *AgentTask
+		wantErr string
+	}{
+		{"allowed argv", control(map[string]interface{}{"containerId": "c-redis", "command": []string{"redis-cli", "FLUSHALL"}}), ""},
+		{"allowed shell line", control(map[string]interface{}{"containerId": "c-redis", "command": "redis-cli FLUSHALL"}), ""},
+		{"shell injection", control(map[string]interface{}{"containerId": "c-redis", "command": "redis-cli FLUSHALL; rm -rf /data"}), "not allowed"},
+		{"other command", control(map[string]interface{}{"containerId": "c-redis", "command": []string{"redis-cli", "CONFIG", "SET"}}), "not allowed"},
+		{"other container", control(map[string]interface{}{"containerId": "c-app", "command": []string{"redis-cli", "FLUSHALL"}}), "not allowed"},
+		{"label rule", control(map[string]interface{}{"containerId": "c-app", "command": []string{"/app/rotate-keys"}, "user": "app", "env": map[string]string{"KEY_ID": "2"}}), ""},
+		{"wrong user", control(map[string]interface{}{"containerId": "c-app", "command": []string{"/app/rotate-keys"}, "user": "root"}), "not allowed"},
+		{"env not allowed", control(map[string]interface{}{"containerId": "c-redis", "command": "redis-cli FLUSHALL", "env": map[string]string{"PATH": "/tmp"}}), "not allowed"},
+		{"edited name", control(map[string]interface{}{"containerId": "c-edited", "command": "redis-cli FLUSHALL"}), "not allowed"},
+		{"edited label", control(map[string]interface{}{"containerId": "c-edited", "command": []string{"/app/rotate-keys"}, "user": "app"}), "not allowed"},
+		{"unknown container", control(map[string]interface{}{"containerId": "redis-cache", "command": "redis-cli FLUSHALL"}), "unknown"},
+		{"no command", control(map[string]interface{}{"containerId": "c-redis"}), "command is required"},
+		{"other control action", control(map[string]interface{}{"action": "restart", "containerId": "c-app"}), ""},
+	}
+	for _, tt := range tests {
+		violation := policy.ExecViolation(tt.task, lookup)
+		switch {
+		case tt.wantErr == "" && violation != "":
+			t.Errorf("%s: unexpected violation %q", tt.name, violation)
+		case tt.wantErr != "" && !strings.Contains(violation, tt.wantErr):
+			t.Errorf("%s: expected a violation containing %q, got %q", tt.name, tt.wantErr, violation)
+		}
+	}
+
+	workflow := &AgentTask{Type: "WorkflowAction"}
+	if err := workflow.SetPayload(map[string]interface{}{
+		"actions": []map[string]interface{}{
+			{"type": "wait", "duration": 5},
+			{"type": WorkflowExecStep, "containerId": "c-redis", "command": "cat /etc/shadow"},
+		},
+	}); err != nil {
+		t.Fatalf("SetPayload: %v", err)
+	}
+	if violation := policy.ExecViolation(workflow, lookup); !strings.Contains(violation, "not allowed") {
+		t.Errorf("Expected the workflow's exec step to be refused, got %q", violation)
+	}
+
+	disabled := &ExecPolicy{Rules: policy.Rules}
+	if violation := disabled.ExecViolation(tests[0].task, lookup); !strings.Contains(violation, "disabled") {
+		t.Errorf("Expected exec to be refused while disabled, got %q", violation)
+	}
+	var none *ExecPolicy
+	if violation := none.ExecViolation(tests[0].task, lookup); !strings.Contains(violation, "disabled") {
+		t.Errorf("Expected a nil policy to refuse exec, got %q", violation)
+	}
+}
+
+func TestExecPolicy_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		rule    ExecRule
+		wantErr string
+	}{
+		{"valid", ExecRule{Containers: []string{"redis-*"}, Commands: []string{"redis-cli FLUSHALL"}}, ""},
+		{"no selector", ExecRule{Commands: []string{"true"}}, "selects no containers"},
+		{"no commands", ExecRule{Labels: []string{"app=cache"}}, "allows no commands"},
+		{"bad pattern", ExecRule{Containers: []string{"redis-["}, Commands: []string{"true"}}, "invalid exec container pattern"},
+		{"bad label", ExecRule{Labels: []string{"=cache"}, Commands: []string{"true"}}, "invalid exec label"},
+	}
+	for _, tt := range tests {
+		policy := &ExecPolicy{Enabled: true, Rules: []ExecRule{tt.rule}}
+		err := policy.Validate()
+		switch {
+		case tt.wantErr == "" && err != nil:
+			t.Errorf("%s: unexpected error %v", tt.name, err)
+		case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+			t.Errorf("%s: expected an error containing %q, got %v", tt.name, tt.wantErr, err)
+		}
+	}
+}
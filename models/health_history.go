@@ -0,0 +1,127 @@
+package models
+
+import "time"
+
+// HealthHistoryLimit is the number of transitions kept per container; older
+// transitions are dropped.
+const HealthHistoryLimit = 500
+
+// HealthTransition records a change of a container's status or health.
+type HealthTransition struct {
+	// At is when the change was stored
+	At time.Time `json:"at"`
+
+	// Status is the container runtime status after the change
+	Status string `json:"status"`
+
+	// Health is the health check status after the change (healthy,
+	// unhealthy, starting; empty without a health check)
+	Health string `json:"health,omitempty"`
+}
+
+// HealthHistory is the capped status and health timeline of a container.
+type HealthHistory struct {
+	Context string `json:"@context"`
+	Type    string `json:"@type"`
+
+	// ID is the document ID (health-history-{containerID})
+	ID  string `json:"@id" couchdb:"_id"`
+	Rev string `json:"_rev,omitempty" couchdb:"_rev"`
+
+	// ContainerID is the container the history belongs to
+	ContainerID string `json:"containerId"`
+
+	// Transitions are ordered oldest first
+	Transitions []HealthTransition `json:"transitions"`
+}
+
+// HealthHistoryID returns the document ID of a container's health history.
+func HealthHistoryID(containerID string) string {
+	return "health-history-" + containerID
+}
+
+// Record appends a transition if status or health differ from the last one,
+// dropping the oldest transitions beyond HealthHistoryLimit. It reports
+// whether a transition was added.
+func (h *HealthHistory) Record(status, health string, at time.Time) bool {
+	if n := len(h.Transitions); n > 0 {
+		last := h.Transitions[n-1]
+		if last.Status == status && last.Health == health {
+			return false
+		}
+	}
+
+	h.Transitions = append(h.Transitions, HealthTransition{At: at, Status: status, Health: health})
+	if extra := len(h.Transitions) - HealthHistoryLimit; extra > 0 {
+		h.Transitions = append([]HealthTransition(nil), h.Transitions[extra:]...)
+	}
+	return true
+}
+
+// HealthState condenses a status and health into one sparkline state:
+// healthy, unhealthy, starting, running (no health check) or the status
+// itself (e.g. stopped, exited, restarting).
+func HealthState(status, health string) string {
+	if status != "running" {
+		return status
+	}
+	if health != "" {
+		return health
+	}
+	return "running"
+}
+
+// HealthSegment is a period in which a container stayed in one state.
+type HealthSegment struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+
+	// State is the condensed state (see HealthState)
+	State  string `json:"state"`
+	Status string `json:"status"`
+	Health string `json:"health,omitempty"`
+
+	// Up reports whether the container was running and not unhealthy
+	Up bool `json:"up"`
+}
+
+// Timeline returns the segments between from and to, oldest first. The
+// first segment starts at from with the state the container was in then;
+// time before the first recorded transition is left out. It also returns
+// the number of transitions within the window.
+func (h *HealthHistory) Timeline(from, to time.Time) ([]HealthSegment, int) {
+	segments := []HealthSegment{}
+	changes := 0
+
+	for i, transition := range h.Transitions {
+		if transition.At.After(to) {
+			break
+		}
+		end := to
+		if i+1 < len(h.Transitions) && h.Transitions[i+1].At.Before(to) {
+			end = h.Transitions[i+1].At
+		}
+		if !end.After(from) {
+			continue
+		}
+
+		start := transition.At
+		if start.Before(from) {
+			start = from
+		} else {
+			changes++
+		}
+
+		state := HealthState(transition.Status, transition.Health)
+		segments = append(segments, HealthSegment{
+			Start:  start,
+			End:    end,
+			State:  state,
+			Status: transition.Status,
+			Health: transition.Health,
+			Up:     transition.Status == "running" && transition.Health != "unhealthy",
+		})
+	}
+
+	return segments, changes
+}
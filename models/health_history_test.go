@@ -0,0 +1,84 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHealthHistory_Record(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	history := &HealthHistory{}
+
+	if !history.Record("running", "starting", start) {
+		t.Fatal("Expected the first transition to be recorded")
+	}
+	if history.Record("running", "starting", start.Add(time.Minute)) {
+		t.Error("Expected an unchanged state not to be recorded")
+	}
+	if !history.Record("running", "healthy", start.Add(2*time.Minute)) {
+		t.Error("Expected a health change to be recorded")
+	}
+
+	for i := 0; i < HealthHistoryLimit; i++ {
+		health := "healthy"
+		if i%2 == 0 {
+			health = "unhealthy"
+		}
+		history.Record("running", health, start.Add(time.Duration(i+3)*time.Minute))
+	}
+	if len(history.Transitions) != HealthHistoryLimit {
+		t.Fatalf("Expected %d transitions, got %d", HealthHistoryLimit, len(history.Transitions))
+	}
+	if history.Transitions[0].Health == "starting" {
+		t.Error("Expected the oldest transitions to be dropped")
+	}
+}
+
+func TestHealthHistory_Timeline(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	history := &HealthHistory{}
+	history.Record("running", "starting", start)
+	history.Record("running", "healthy", start.Add(1*time.Hour))
+	history.Record("running", "unhealthy", start.Add(3*time.Hour))
+	history.Record("exited", "", start.Add(4*time.Hour))
+
+	segments, transitions := history.Timeline(start.Add(2*time.Hour), start.Add(5*time.Hour))
+	if transitions != 2 {
+		t.Errorf("Expected 2 transitions in the window, got %d", transitions)
+	}
+	if len(segments) != 3 {
+		t.Fatalf("Expected 3 segments, got %d: %+v", len(segments), segments)
+	}
+
+	first := segments[0]
+	if first.State != "healthy" || !first.Up || !first.Start.Equal(start.Add(2*time.Hour)) || !first.End.Equal(start.Add(3*time.Hour)) {
+		t.Errorf("Expected the window to start healthy, got %+v", first)
+	}
+	if segments[1].State != "unhealthy" || segments[1].Up {
+		t.Errorf("Expected an unhealthy segment, got %+v", segments[1])
+	}
+	last := segments[2]
+	if last.State != "exited" || last.Up || !last.End.Equal(start.Add(5*time.Hour)) {
+		t.Errorf("Expected the window to end exited, got %+v", last)
+	}
+
+	// Before the first transition nothing is known
+	segments, _ = history.Timeline(start.Add(-2*time.Hour), start.Add(-time.Hour))
+	if len(segments) != 0 {
+		t.Errorf("Expected no segments before the first transition, got %+v", segments)
+	}
+}
+
+func TestHealthState(t *testing.T) {
+	tests := []struct{ status, health, want string }{
+		{"running", "", "running"},
+		{"running", "unhealthy", "unhealthy"},
+		{"restarting", "unhealthy", "restarting"},
+		{"stopped", "", "stopped"},
+	}
+	for _, tt := range tests {
+		if got := HealthState(tt.status, tt.health); got != tt.want {
+			t.Errorf("HealthState(%q, %q) = %q, want %q", tt.status, tt.health, got, tt.want)
+		}
+	}
+}
@@ -1,5 +1,10 @@
 package models
 
+import (
+	"fmt"
+	"net/url"
+)
+
 // Host represents a physical or virtual machine that runs containers.
 // It follows the Schema.org ComputerSystem type with infrastructure-specific fields.
 //
@@ -59,6 +64,9 @@ type Host struct {
 	// Datacenter is the physical or logical location of the host
 	Datacenter string `json:"location" jsonld:"location" couchdb:"index"`
 
+	// Tags are free-form labels used to group and filter hosts (e.g. "gpu", "edge")
+	Tags []string `json:"tags,omitempty" jsonld:"keywords"`
+
 	// CPUUsage is the current CPU usage percentage (0-100)
 	CPUUsage float64 `json:"cpuUsage,omitempty"`
 
@@ -70,4 +78,76 @@ type Host struct {
 
 	// LastMetricsUpdate is the timestamp when metrics were last updated
 	LastMetricsUpdate string `json:"lastMetricsUpdate,omitempty"`
+
+	// DockerEndpoint is how the server connects to the host's Docker daemon
+	// (ssh://user@host[:port], tcp://host:port or unix:///path). Empty uses
+	// the Docker socket of the host's agent, or tcp://<ipAddress>:2375
+	DockerEndpoint string `json:"dockerEndpoint,omitempty"`
+
+	// DockerTLS are the TLS files on the server of a tcp:// DockerEndpoint
+	DockerTLS *DockerTLSRefs `json:"dockerTLS,omitempty"`
+
+	// DockerSSHKeyPath is the private key file on the server of an ssh://
+	// DockerEndpoint (default: $DOCKER_SSH_IDENTITY or ~/.ssh/id_rsa)
+	DockerSSHKeyPath string `json:"dockerSSHKeyPath,omitempty"`
+}
+
+// DockerTLSRefs reference the TLS files used to connect to a Docker daemon,
+// in the Docker CLI layout (ca.pem, cert.pem, key.pem).
+type DockerTLSRefs struct {
+	// CAFile is the CA certificate the daemon's certificate must be signed
+	// by; empty trusts the system roots
+	CAFile string `json:"caFile,omitempty"`
+
+	// CertFile is the client certificate
+	CertFile string `json:"certFile,omitempty"`
+
+	// KeyFile is the private key of CertFile
+	KeyFile string `json:"keyFile,omitempty"`
+}
+
+// ValidateDockerEndpoint checks the host's Docker connection settings: the
+// endpoint scheme, and that TLS files are only set for tcp:// and the SSH key
+// only for ssh:// endpoints.
+func (h *Host) ValidateDockerEndpoint() error {
+	if h.DockerEndpoint == "" {
+		if h.DockerTLS != nil || h.DockerSSHKeyPath != "" {
+			return fmt.Errorf("dockerTLS and dockerSSHKeyPath require a dockerEndpoint")
+		}
+		return nil
+	}
+
+	u, err := url.Parse(h.DockerEndpoint)
+	if err != nil {
+		return fmt.Errorf("invalid dockerEndpoint: %w", err)
+	}
+	switch u.Scheme {
+	case "ssh":
+		if u.User.Username() == "" || u.Hostname() == "" {
+			return fmt.Errorf("dockerEndpoint %s must be ssh://user@host[:port]", h.DockerEndpoint)
+		}
+	case "tcp":
+		if u.Hostname() == "" {
+			return fmt.Errorf("dockerEndpoint %s must be tcp://host:port", h.DockerEndpoint)
+		}
+	case "unix":
+		if u.Path == "" {
+			return fmt.Errorf("dockerEndpoint %s must be unix:///path", h.DockerEndpoint)
+		}
+	default:
+		return fmt.Errorf("dockerEndpoint %s must use ssh://, tcp:// or unix://", h.DockerEndpoint)
+	}
+
+	if h.DockerTLS != nil {
+		if u.Scheme != "tcp" {
+			return fmt.Errorf("dockerTLS requires a tcp:// dockerEndpoint")
+		}
+		if (h.DockerTLS.CertFile == "") != (h.DockerTLS.KeyFile == "") {
+			return fmt.Errorf("dockerTLS certFile and keyFile must be set together")
+		}
+	}
+	if h.DockerSSHKeyPath != "" && u.Scheme != "ssh" {
+		return fmt.Errorf("dockerSSHKeyPath requires an ssh:// dockerEndpoint")
+	}
+	return nil
 }
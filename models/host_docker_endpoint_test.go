@@ -0,0 +1,33 @@
+package models
+
+import "testing"
+
+func TestHost_ValidateDockerEndpoint(t *testing.T) {
+	tls := &DockerTLSRefs{CAFile: "/certs/ca.pem", CertFile: "/certs/cert.pem", KeyFile: "/certs/key.pem"}
+
+	tests := []struct {
+		name    string
+		host    Host
+		wantErr bool
+	}{
+		{"empty", Host{}, false},
+		{"ssh", Host{DockerEndpoint: "ssh://deploy@web-01:2222", DockerSSHKeyPath: "/keys/id_ed25519"}, false},
+		{"tcp with tls", Host{DockerEndpoint: "tcp://web-01:2376", DockerTLS: tls}, false},
+		{"plain tcp", Host{DockerEndpoint: "tcp://web-01:2375"}, false},
+		{"unix", Host{DockerEndpoint: "unix:///var/run/docker.sock"}, false},
+		{"ssh without user", Host{DockerEndpoint: "ssh://web-01"}, true},
+		{"tcp without host", Host{DockerEndpoint: "tcp://:2376"}, true},
+		{"unknown scheme", Host{DockerEndpoint: "http://web-01:2375"}, true},
+		{"tls without endpoint", Host{DockerTLS: tls}, true},
+		{"tls with ssh", Host{DockerEndpoint: "ssh://deploy@web-01", DockerTLS: tls}, true},
+		{"cert without key", Host{DockerEndpoint: "tcp://web-01:2376", DockerTLS: &DockerTLSRefs{CertFile: "/certs/cert.pem"}}, true},
+		{"ssh key with tcp", Host{DockerEndpoint: "tcp://web-01:2376", DockerSSHKeyPath: "/keys/id_rsa"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.host.ValidateDockerEndpoint(); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateDockerEndpoint() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
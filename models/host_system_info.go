@@ -0,0 +1,87 @@
+package models
+
+import "time"
+
+// CheckTypeSystemInfo routes a CheckAction to a refresh of the host's Docker
+// system info.
+const CheckTypeSystemInfo = "system-info"
+
+// HostSystemInfo is the Docker engine configuration and state of a host, as
+// reported by its agent from docker info.
+type HostSystemInfo struct {
+	Context string `json:"@context"`
+	Type    string `json:"@type"`
+
+	// ID is the document ID (host-system-info-{hostID})
+	ID  string `json:"@id" couchdb:"_id"`
+	Rev string `json:"_rev,omitempty" couchdb:"_rev"`
+
+	// HostID is the host the info belongs to
+	HostID string `json:"hostId"`
+
+	// DockerVersion is the Docker engine version, e.g. 27.3.1
+	DockerVersion string `json:"dockerVersion"`
+
+	// OperatingSystem is the host OS, e.g. Ubuntu 24.04 LTS
+	OperatingSystem string `json:"operatingSystem,omitempty"`
+
+	// OSType is the OS family (linux, windows)
+	OSType string `json:"osType,omitempty"`
+
+	// Architecture is the CPU architecture, e.g. x86_64
+	Architecture string `json:"architecture,omitempty"`
+
+	// KernelVersion is the host kernel version
+	KernelVersion string `json:"kernelVersion,omitempty"`
+
+	// StorageDriver is the Docker storage driver, e.g. overlay2
+	StorageDriver string `json:"storageDriver,omitempty"`
+
+	// CgroupDriver is the cgroup driver (cgroupfs, systemd)
+	CgroupDriver string `json:"cgroupDriver,omitempty"`
+
+	// CgroupVersion is the cgroup version (1, 2)
+	CgroupVersion string `json:"cgroupVersion,omitempty"`
+
+	// DockerRootDir is the Docker data directory, e.g. /var/lib/docker
+	DockerRootDir string `json:"dockerRootDir,omitempty"`
+
+	// CPU is the number of CPUs available to Docker
+	CPU int `json:"cpu"`
+
+	// Memory is the total memory in bytes
+	Memory int64 `json:"memory"`
+
+	// Containers is the total number of containers
+	Containers int `json:"containers"`
+
+	// ContainersRunning is the number of running containers
+	ContainersRunning int `json:"containersRunning"`
+
+	// ContainersPaused is the number of paused containers
+	ContainersPaused int `json:"containersPaused"`
+
+	// ContainersStopped is the number of stopped containers
+	ContainersStopped int `json:"containersStopped"`
+
+	// Images is the number of images
+	Images int `json:"images"`
+
+	// DiskTotal is the size in bytes of the filesystem holding DockerRootDir
+	// (0 if the agent couldn't determine it)
+	DiskTotal int64 `json:"diskTotal,omitempty"`
+
+	// DiskFree is the free space in bytes on that filesystem
+	DiskFree int64 `json:"diskFree,omitempty"`
+
+	// Warnings are the warnings reported by the Docker engine
+	Warnings []string `json:"warnings,omitempty"`
+
+	// ReportedAt is when the agent collected the info
+	ReportedAt time.Time `json:"reportedAt"`
+}
+
+// HostSystemInfoID returns the document ID of a host's system info.
+func HostSystemInfoID(hostID string) string {
+	return "host-system-info-" + hostID
+}
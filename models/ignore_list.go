@@ -2,8 +2,10 @@ package models
 
 import "time"
 
-// IgnoreListEntry represents a container that should be ignored by agents.
-// Containers in the ignore list will not be synced or monitored.
+// IgnoreListEntry is a container tombstone: it records that a container
+// was deleted on purpose, so agents that still see it in Docker don't sync
+// it back. Containers in the ignore list will not be synced or monitored
+// until the entry expires or is cleared.
 type IgnoreListEntry struct {
 	// Context is the JSON-LD @context
 	Context string `json:"@context"`
@@ -31,4 +33,13 @@ type IgnoreListEntry struct {
 
 	// CreatedAt is when this entry was created
 	CreatedAt time.Time `json:"dateCreated"`
+
+	// ExpiresAt is when the tombstone lapses and the container may be synced
+	// again (nil: until cleared)
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+}
+
+// Active reports whether the entry still blocks the container at now.
+func (e *IgnoreListEntry) Active(now time.Time) bool {
+	return e.ExpiresAt == nil || now.Before(*e.ExpiresAt)
 }
@@ -0,0 +1,25 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestIgnoreListEntry_Active(t *testing.T) {
+	now := time.Now()
+	later := now.Add(time.Hour)
+	earlier := now.Add(-time.Hour)
+
+	if !(&IgnoreListEntry{}).Active(now) {
+		t.Error("Expected a tombstone without expiry to stay active")
+	}
+	if !(&IgnoreListEntry{ExpiresAt: &later}).Active(now) {
+		t.Error("Expected a tombstone to be active before it expires")
+	}
+	if (&IgnoreListEntry{ExpiresAt: &earlier}).Active(now) {
+		t.Error("Expected an expired tombstone to be inactive")
+	}
+	if (&IgnoreListEntry{ExpiresAt: &now}).Active(now) {
+		t.Error("Expected a tombstone to lapse at its expiry")
+	}
+}
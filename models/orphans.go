@@ -0,0 +1,131 @@
+package models
+
+import "sort"
+
+// Reasons a container is orphaned.
+const (
+	// OrphanNoStack is a container no stack lists
+	OrphanNoStack = "no-stack"
+
+	// OrphanNoHost is a container without a host, or on a host that no
+	// longer exists
+	OrphanNoHost = "no-host"
+
+	// OrphanNoDeployment is a container listed by a stack whose current
+	// deployment didn't place it
+	OrphanNoDeployment = "no-deployment"
+)
+
+// OrphanedContainer is a container with the reasons it is orphaned.
+type OrphanedContainer struct {
+	Container *Container `json:"container"`
+
+	// Categories are the reasons: no-stack, no-host, no-deployment
+	Categories []string `json:"categories"`
+}
+
+// OrphanReport lists the orphaned containers.
+type OrphanReport struct {
+	// Total is the number of orphaned containers
+	Total int `json:"total"`
+
+	// Counts is the number of orphaned containers per category; a
+	// container can be in several
+	Counts map[string]int `json:"counts"`
+
+	// Containers are the orphaned containers, sorted by name
+	Containers []OrphanedContainer `json:"containers"`
+}
+
+// Has reports whether the container is orphaned for a reason.
+func (o *OrphanedContainer) Has(category string) bool {
+	for _, c := range o.Categories {
+		if c == category {
+			return true
+		}
+	}
+	return false
+}
+
+// IsOrphanCategory reports whether category is a known orphan category.
+func IsOrphanCategory(category string) bool {
+	switch category {
+	case OrphanNoStack, OrphanNoHost, OrphanNoDeployment:
+		return true
+	}
+	return false
+}
+
+// Only returns the report's containers orphaned for a reason. Counts are
+// kept for all categories.
+func (r *OrphanReport) Only(category string) *OrphanReport {
+	filtered := &OrphanReport{Counts: r.Counts, Containers: []OrphanedContainer{}}
+	for _, orphan := range r.Containers {
+		if orphan.Has(category) {
+			filtered.Containers = append(filtered.Containers, orphan)
+		}
+	}
+	filtered.Total = len(filtered.Containers)
+	return filtered
+}
+
+// FindOrphans classifies containers that no stack lists, that have no
+// existing host, or that a stack lists but its current deployment didn't
+// place. hosts holds the IDs of the existing hosts; stacks without a
+// deployment don't make their containers deployment-less.
+func FindOrphans(containers []*Container, hosts map[string]bool, stacks []*Stack) *OrphanReport {
+	index := IndexStacks(stacks)
+
+	deployed := make(map[string]map[string]bool, len(stacks))
+	for _, stack := range stacks {
+		if stack.CurrentDeployment == nil {
+			continue
+		}
+		placed := make(map[string]bool, len(stack.CurrentDeployment.Placements))
+		for _, placement := range stack.CurrentDeployment.Placements {
+			if placement != nil && placement.ContainerID != "" {
+				placed[placement.ContainerID] = true
+			}
+		}
+		deployed[stack.ID] = placed
+	}
+
+	report := &OrphanReport{
+		Counts: map[string]int{
+			OrphanNoStack:      0,
+			OrphanNoHost:       0,
+			OrphanNoDeployment: 0,
+		},
+		Containers: []OrphanedContainer{},
+	}
+	for _, container := range containers {
+		var categories []string
+		entry, inStack := index[container.ID]
+		if !inStack {
+			categories = append(categories, OrphanNoStack)
+		}
+		if container.HostedOn == "" || !hosts[container.HostedOn] {
+			categories = append(categories, OrphanNoHost)
+		}
+		if placed, ok := deployed[entry.StackID]; inStack && ok && !placed[container.ID] {
+			categories = append(categories, OrphanNoDeployment)
+		}
+		if len(categories) == 0 {
+			continue
+		}
+
+		for _, category := range categories {
+			report.Counts[category]++
+		}
+		report.Containers = append(report.Containers, OrphanedContainer{
+			Container:  container,
+			Categories: categories,
+		})
+	}
+
+	sort.SliceStable(report.Containers, func(i, j int) bool {
+		return report.Containers[i].Container.Name < report.Containers[j].Container.Name
+	})
+	report.Total = len(report.Containers)
+	return report
+}
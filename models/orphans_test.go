@@ -0,0 +1,85 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFindOrphans(t *testing.T) {
+	containers := []*Container{
+		{ID: "c-web", Name: "web", HostedOn: "host-1"},
+		{ID: "c-stray", Name: "stray", HostedOn: "host-1"},
+		{ID: "c-lost", Name: "lost", HostedOn: "host-gone"},
+		{ID: "c-drift", Name: "drift", HostedOn: "host-1"},
+		{ID: "c-tool", Name: "tool", HostedOn: "host-1"},
+	}
+	hosts := map[string]bool{"host-1": true}
+	stacks := []*Stack{
+		{
+			ID:         "stack-app",
+			Name:       "app",
+			Containers: []string{"c-web", "c-lost", "c-drift"},
+			CurrentDeployment: &StackSnapshot{Placements: map[string]*ContainerPlacement{
+				"web":  {ContainerID: "c-web", HostID: "host-1"},
+				"lost": {ContainerID: "c-lost", HostID: "host-gone"},
+			}},
+		},
+		// Stacks without a deployment don't make their containers orphans
+		{ID: "stack-tools", Name: "tools", Containers: []string{"c-tool"}},
+	}
+
+	report := FindOrphans(containers, hosts, stacks)
+	if report.Total != 3 {
+		t.Fatalf("Expected 3 orphaned containers, got %d: %+v", report.Total, report.Containers)
+	}
+
+	got := make(map[string][]string)
+	for _, orphan := range report.Containers {
+		got[orphan.Container.ID] = orphan.Categories
+	}
+	want := map[string][]string{
+		"c-stray": {OrphanNoStack},
+		"c-lost":  {OrphanNoHost},
+		"c-drift": {OrphanNoDeployment},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected categories %v, got %v", want, got)
+	}
+	if report.Containers[0].Container.Name != "drift" {
+		t.Errorf("Expected containers sorted by name, got %q first", report.Containers[0].Container.Name)
+	}
+	if report.Counts[OrphanNoStack] != 1 || report.Counts[OrphanNoHost] != 1 || report.Counts[OrphanNoDeployment] != 1 {
+		t.Errorf("Expected one container per category, got %v", report.Counts)
+	}
+
+	noHost := report.Only(OrphanNoHost)
+	if noHost.Total != 1 || noHost.Containers[0].Container.ID != "c-lost" {
+		t.Errorf("Expected only c-lost for no-host, got %+v", noHost.Containers)
+	}
+}
+
+func TestFindOrphans_SharedContainerBelongsToLowestStackID(t *testing.T) {
+	containers := []*Container{{ID: "c-shared", Name: "shared", HostedOn: "host-1"}}
+	hosts := map[string]bool{"host-1": true}
+	owner := &Stack{
+		ID:         "stack-a",
+		Name:       "a",
+		Containers: []string{"c-shared"},
+		CurrentDeployment: &StackSnapshot{Placements: map[string]*ContainerPlacement{
+			"shared": {ContainerID: "c-shared", HostID: "host-1"},
+		}},
+	}
+	other := &Stack{
+		ID:                "stack-b",
+		Name:              "b",
+		Containers:        []string{"c-shared"},
+		CurrentDeployment: &StackSnapshot{Placements: map[string]*ContainerPlacement{}},
+	}
+
+	// The deployment of the owning stack decides, whatever the stack order
+	for _, stacks := range [][]*Stack{{owner, other}, {other, owner}} {
+		if report := FindOrphans(containers, hosts, stacks); report.Total != 0 {
+			t.Errorf("Expected c-shared to be placed by its owner stack-a, got %+v", report.Containers)
+		}
+	}
+}
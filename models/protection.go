@@ -0,0 +1,131 @@
+package models
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// ProtectionPolicy lists containers Graphium must never delete, stop,
+// control or replace. Protected containers are still discovered and shown,
+// but read-only.
+type ProtectionPolicy struct {
+	// NamePatterns are glob patterns (path.Match syntax) matched against
+	// container names, e.g. "traefik" or "prod-db-*"
+	NamePatterns []string
+
+	// Images are image references or glob patterns. An entry without a tag
+	// or digest protects every tag of the image, e.g. "postgres" matches
+	// "postgres:16".
+	Images []string
+}
+
+// Validate checks that all patterns are well-formed.
+func (p *ProtectionPolicy) Validate() error {
+	if p == nil {
+		return nil
+	}
+	for _, pattern := range append(append([]string{}, p.NamePatterns...), p.Images...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid protection pattern %q: %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+// Protects reports whether a container with the given name and image is
+// protected, and why.
+func (p *ProtectionPolicy) Protects(name, image string) (bool, string) {
+	if p == nil {
+		return false, ""
+	}
+
+	name = strings.TrimPrefix(name, "/")
+	if name != "" {
+		for _, pattern := range p.NamePatterns {
+			if ok, _ := path.Match(pattern, name); ok {
+				return true, fmt.Sprintf("name %s matches protected pattern %q", name, pattern)
+			}
+		}
+	}
+
+	if image != "" {
+		for _, pattern := range p.Images {
+			if ImageMatches(pattern, image) {
+				return true, fmt.Sprintf("image %s is protected by %q", image, pattern)
+			}
+		}
+	}
+
+	return false, ""
+}
+
+// ImageMatches reports whether an image reference matches an image pattern
+// (path.Match syntax). A pattern without a tag or digest matches every tag
+// of the image, e.g. "nginx" matches "nginx:1.25".
+func ImageMatches(pattern, image string) bool {
+	if ok, _ := path.Match(pattern, image); ok {
+		return true
+	}
+	ok, _ := path.Match(pattern, imageRepository(image))
+	return ok
+}
+
+// imageRepository strips the tag and digest from an image reference.
+func imageRepository(image string) string {
+	image, _, _ = strings.Cut(image, "@")
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		image = image[:i]
+	}
+	return image
+}
+
+// protectedTaskTypes are the task types that delete, stop or control containers.
+var protectedTaskTypes = map[string]bool{
+	"DeleteAction":     true,
+	"DeactivateAction": true,
+	"ControlAction":    true,
+}
+
+// ProtectedTarget checks whether a task would delete, stop or control a
+// protected container. lookup resolves a container ID to the synced
+// container; it may return an error for unknown containers. It returns a
+// description of the violation, or "" if the task is allowed.
+func (p *ProtectionPolicy) ProtectedTarget(task *AgentTask, lookup func(id string) (*Container, error)) string {
+	if p == nil || (len(p.NamePatterns) == 0 && len(p.Images) == 0) || !protectedTaskTypes[task.Type] {
+		return ""
+	}
+
+	var payload struct {
+		ContainerID   string        `json:"containerId"`
+		ContainerName string        `json:"containerName"`
+		Object        *ActionObject `json:"object"`
+	}
+	_ = task.GetPayloadAs(&payload)
+
+	ids := []string{task.ContainerID, payload.ContainerID}
+	if payload.Object != nil {
+		ids = append(ids, payload.Object.ID)
+	}
+
+	if ok, reason := p.Protects(payload.ContainerName, ""); ok {
+		return fmt.Sprintf("container %s is protected: %s", payload.ContainerName, reason)
+	}
+	for _, id := range ids {
+		if id == "" {
+			continue
+		}
+		container, err := lookup(id)
+		if err != nil || container == nil {
+			// Agents also accept container names in place of IDs
+			if ok, reason := p.Protects(id, ""); ok {
+				return fmt.Sprintf("container %s is protected: %s", id, reason)
+			}
+			continue
+		}
+		if ok, reason := p.Protects(container.Name, container.Image); ok {
+			return fmt.Sprintf("container %s is protected: %s", container.Name, reason)
+		}
+	}
+	return ""
+}
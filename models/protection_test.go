@@ -0,0 +1,82 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestProtectionPolicy_Protects(t *testing.T) {
+	policy := &ProtectionPolicy{
+		NamePatterns: []string{"traefik", "prod-db-*"},
+		Images:       []string{"postgres", "registry.example.com/infra/*", "redis:7"},
+	}
+
+	tests := []struct {
+		name, image string
+		want        bool
+	}{
+		{"traefik", "traefik:v3", true},
+		{"/traefik", "", true},
+		{"traefik-dev", "traefik:v3", false},
+		{"prod-db-1", "mysql:8", true},
+		{"app", "postgres:16", true},
+		{"app", "postgres@sha256:abc", true},
+		{"app", "postgres-exporter:1", false},
+		{"app", "registry.example.com/infra/dns:1.2", true},
+		{"app", "registry.example.com/apps/web:1.2", false},
+		{"cache", "redis:7", true},
+		{"cache", "redis:6", false},
+		{"web", "nginx:latest", false},
+	}
+	for _, tt := range tests {
+		if got, _ := policy.Protects(tt.name, tt.image); got != tt.want {
+			t.Errorf("Protects(%q, %q) = %v, want %v", tt.name, tt.image, got, tt.want)
+		}
+	}
+
+	var none *ProtectionPolicy
+	if ok, _ := none.Protects("traefik", "postgres"); ok {
+		t.Error("Expected a nil policy to protect nothing")
+	}
+	if err := (&ProtectionPolicy{Images: []string{"[bad"}}).Validate(); err == nil {
+		t.Error("Expected a malformed pattern to be rejected")
+	}
+}
+
+func TestProtectionPolicy_ProtectedTarget(t *testing.T) {
+	policy := &ProtectionPolicy{NamePatterns: []string{"traefik"}, Images: []string{"postgres"}}
+	containers := map[string]*Container{
+		"c-proxy": {ID: "c-proxy", Name: "traefik", Image: "traefik:v3"},
+		"c-db":    {ID: "c-db", Name: "db", Image: "postgres:16"},
+		"c-web":   {ID: "c-web", Name: "web", Image: "nginx:latest"},
+	}
+	lookup := func(id string) (*Container, error) {
+		if c, ok := containers[id]; ok {
+			return c, nil
+		}
+		return nil, fmt.Errorf("not found")
+	}
+
+	task := func(taskType string, payload map[string]interface{}) *AgentTask {
+		t := &AgentTask{Type: taskType}
+		_ = t.SetPayload(payload)
+		return t
+	}
+
+	if got := policy.ProtectedTarget(task("DeleteAction", map[string]interface{}{"containerId": "c-db"}), lookup); !strings.Contains(got, "db") {
+		t.Errorf("Expected deleting the postgres container to be refused, got %q", got)
+	}
+	if got := policy.ProtectedTarget(task("ControlAction", map[string]interface{}{"object": map[string]interface{}{"@type": "SoftwareApplication", "@id": "c-proxy"}}), lookup); got == "" {
+		t.Error("Expected controlling traefik through an action object to be refused")
+	}
+	if got := policy.ProtectedTarget(task("DeactivateAction", map[string]interface{}{"containerId": "traefik"}), lookup); got == "" {
+		t.Error("Expected stopping traefik by name to be refused")
+	}
+	if got := policy.ProtectedTarget(task("DeleteAction", map[string]interface{}{"containerId": "c-web"}), lookup); got != "" {
+		t.Errorf("Expected deleting web to be allowed, got %q", got)
+	}
+	if got := policy.ProtectedTarget(task("CheckAction", map[string]interface{}{"containerId": "c-db"}), lookup); got != "" {
+		t.Errorf("Expected health checks on protected containers to be allowed, got %q", got)
+	}
+}
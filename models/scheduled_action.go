@@ -24,11 +24,40 @@ type ScheduledAction struct {
 	Error        *ActionError           `json:"error,omitempty"`       // schema:error - Last error if any
 
 	// Scheduling properties (schema:Schedule embedded)
-	Schedule *Schedule `json:"schedule"` // When and how often to execute
+	Schedule *Schedule `json:"schedule"` // When and how often to execute; nil for actions only run after the one they depend on
+
+	// Condition must hold for a scheduled run to create a task; otherwise
+	// the run is recorded as skipped
+	Condition *ActionCondition `json:"condition,omitempty"`
+
+	// OnSuccess and OnFailure are IDs of scheduled actions to run when a
+	// task of this action succeeds or fails. The triggered action runs
+	// regardless of its schedule and enabled flag; its condition still
+	// applies.
+	OnSuccess string `json:"onSuccess,omitempty"`
+	OnFailure string `json:"onFailure,omitempty"`
+
+	// DependsOn is the ID of a scheduled action whose runs trigger this
+	// action as RunCondition says, like an OnSuccess or OnFailure link
+	// from that action. Unlike those, a disabled dependent action doesn't
+	// run. Dependent actions need no schedule.
+	DependsOn    string `json:"dependsOn,omitempty"`
+	RunCondition string `json:"runCondition,omitempty"` // on-success (default), on-failure or always
+
+	// RetryPolicy retries a failed task before the run is recorded as
+	// failed; nil means failed runs wait for the next scheduled run
+	RetryPolicy *RetryPolicy `json:"retryPolicy,omitempty"`
+
+	// NotifyOn lists which runs send a notification to the configured
+	// webhook and email recipients: failure, recovery or always
+	NotifyOn []string `json:"notifyOn,omitempty"`
 
 	// Execution tracking
-	StartTime *time.Time `json:"startTime,omitempty"` // schema:startTime - Last execution start
-	EndTime   *time.Time `json:"endTime,omitempty"`   // schema:endTime - Last execution end
+	StartTime   *time.Time `json:"startTime,omitempty"`   // schema:startTime - Last execution start
+	EndTime     *time.Time `json:"endTime,omitempty"`     // schema:endTime - Last execution end
+	Attempt     int        `json:"attempt,omitempty"`     // Attempt of the last execution, 1 for the first
+	RetryAt     *time.Time `json:"retryAt,omitempty"`     // When the next attempt of a failed execution is due
+	LastOutcome string     `json:"lastOutcome,omitempty"` // Outcome of the last decided execution (success, failure)
 
 	// Graphium extensions
 	Enabled   bool      `json:"enabled"` // Whether schedule is active
@@ -40,7 +69,8 @@ type ScheduledAction struct {
 // See: https://schema.org/Schedule
 type Schedule struct {
 	Type             string     `json:"@type"`                      // schema:Schedule
-	RepeatFrequency  string     `json:"repeatFrequency"`            // ISO 8601 duration (PT5M) or cron expression (*/5 * * * *)
+	RepeatFrequency  string     `json:"repeatFrequency,omitempty"`  // ISO 8601 duration (PT5M); a value with spaces is read as a cron expression
+	CronExpression   string     `json:"cronExpression,omitempty"`   // Cron expression (0 9 * * 1-5), evaluated in ScheduleTimezone; replaces RepeatFrequency
 	RepeatCount      *int       `json:"repeatCount,omitempty"`      // Number of times to repeat (nil = infinite)
 	StartDate        *time.Time `json:"startDate,omitempty"`        // When to start schedule
 	EndDate          *time.Time `json:"endDate,omitempty"`          // When to end schedule
@@ -67,6 +97,7 @@ type ActionResult struct {
 	Value       map[string]interface{} `json:"value,omitempty"`       // Structured result data
 	Timestamp   time.Time              `json:"timestamp"`             // When result was generated
 	Duration    int64                  `json:"duration"`              // Execution duration in milliseconds
+	Skipped     bool                   `json:"skipped,omitempty"`     // Run was skipped because the condition didn't hold
 }
 
 // ActionError represents an error from action execution
@@ -92,6 +123,7 @@ const (
 	ActionTypeCreate   = "CreateAction"   // For creating resources
 	ActionTypeUpdate   = "UpdateAction"   // For updating configurations
 	ActionTypeTransfer = "TransferAction" // For backups, log collection
+	ActionTypeScale    = "ScaleAction"    // For converging the replicas of a stateless service
 	ActionTypeAction   = "Action"         // Generic action
 )
 
@@ -126,16 +158,55 @@ func (a *ScheduledAction) IsPending() bool {
 func (a *ScheduledAction) MarkStarted() {
 	now := time.Now()
 	a.StartTime = &now
+	a.Attempt = 1
+	a.RetryAt = nil
 	a.ActionStatus = ActionStatusActive
 	a.UpdatedAt = now
 }
 
+// MarkRetrying records that attempt number attempt of the current run
+// failed and the next one is due at retryAt. The run's start time is kept,
+// so retries don't move the schedule.
+func (a *ScheduledAction) MarkRetrying(attempt int, retryAt time.Time, err *ActionError) {
+	a.Attempt = attempt
+	a.RetryAt = &retryAt
+	a.Error = err
+	a.ActionStatus = ActionStatusActive
+	a.UpdatedAt = time.Now()
+}
+
+// MarkRetryStarted records that the attempt due at RetryAt has started.
+func (a *ScheduledAction) MarkRetryStarted() {
+	a.Attempt++
+	a.RetryAt = nil
+	a.UpdatedAt = time.Now()
+}
+
 // MarkCompleted marks the action as completed with a result
 func (a *ScheduledAction) MarkCompleted(result *ActionResult) {
 	now := time.Now()
 	a.EndTime = &now
 	a.Result = result
 	a.Error = nil
+	a.RetryAt = nil
+	a.ActionStatus = ActionStatusCompleted
+	a.UpdatedAt = now
+}
+
+// MarkSkipped records a run that was skipped because the action's condition
+// didn't hold. The run counts as an execution for scheduling.
+func (a *ScheduledAction) MarkSkipped(reason string) {
+	now := time.Now()
+	a.StartTime = &now
+	a.EndTime = &now
+	a.Result = &ActionResult{
+		Type:        "Thing",
+		Name:        "Skipped",
+		Description: reason,
+		Timestamp:   now,
+		Skipped:     true,
+	}
+	a.Error = nil
 	a.ActionStatus = ActionStatusCompleted
 	a.UpdatedAt = now
 }
@@ -144,11 +215,22 @@ func (a *ScheduledAction) MarkCompleted(result *ActionResult) {
 func (a *ScheduledAction) MarkFailed(err *ActionError) {
 	now := time.Now()
 	a.EndTime = &now
+	a.RetryAt = nil
 	a.Error = err
 	a.ActionStatus = ActionStatusFailed
 	a.UpdatedAt = now
 }
 
+// MarkRunFailed records a run that failed before a task was created, e.g.
+// because its condition couldn't be evaluated or it was refused. Like a
+// skipped run, it counts as an execution for scheduling, so the action
+// isn't tried again on every scheduler tick.
+func (a *ScheduledAction) MarkRunFailed(err *ActionError) {
+	now := time.Now()
+	a.StartTime = &now
+	a.MarkFailed(err)
+}
+
 // GetNextScheduledTime calculates when this action should next execute
 // This is a placeholder - actual implementation will be in the scheduler service
 func (a *ScheduledAction) GetNextScheduledTime() *time.Time {
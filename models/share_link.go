@@ -0,0 +1,46 @@
+package models
+
+import "time"
+
+// ShareLink represents a short-lived, read-only share link.
+// The link grants viewer access to the read and WebSocket endpoints,
+// optionally scoped to a single datacenter.
+type ShareLink struct {
+	// Context is the JSON-LD @context
+	Context string `json:"@context"`
+
+	// Type is the JSON-LD @type
+	Type string `json:"@type"`
+
+	// ID is the document ID (sharelink:{uuid})
+	ID string `json:"@id" couchdb:"_id"`
+
+	// Rev is the CouchDB document revision
+	Rev string `json:"_rev,omitempty" couchdb:"_rev"`
+
+	// Name is a human-readable label (e.g. the incident it was created for)
+	Name string `json:"name,omitempty"`
+
+	// Datacenter restricts the link to a single datacenter (empty = all)
+	Datacenter string `json:"location,omitempty" couchdb:"index"`
+
+	// CreatedBy is the user ID that created the link
+	CreatedBy string `json:"creator,omitempty"`
+
+	// CreatedAt is when the link was created
+	CreatedAt time.Time `json:"dateCreated"`
+
+	// ExpiresAt is when the link stops working
+	ExpiresAt time.Time `json:"expires"`
+
+	// Revoked is true once the link has been revoked
+	Revoked bool `json:"revoked"`
+
+	// RevokedAt is when the link was revoked
+	RevokedAt *time.Time `json:"revokedAt,omitempty"`
+}
+
+// IsActive returns true if the link is neither revoked nor expired.
+func (l *ShareLink) IsActive() bool {
+	return !l.Revoked && time.Now().Before(l.ExpiresAt)
+}
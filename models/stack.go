@@ -1,6 +1,9 @@
 package models
 
-import "time"
+import (
+	"strings"
+	"time"
+)
 
 // Stack represents a multi-container application deployment.
 // It follows the Schema.org ItemList type with container orchestration extensions.
@@ -47,7 +50,8 @@ type Stack struct {
 	Description string `json:"description,omitempty" jsonld:"description"`
 
 	// Status is the stack operational status
-	// Values: pending, deploying, running, stopping, stopped, error
+	// Values: pending, deploying, running, degraded, partially-failed, stopping, stopped, error
+	// Deployed stacks are reconciled periodically with the state of their containers
 	Status string `json:"status" jsonld:"status" couchdb:"index"`
 
 	// Datacenter is the primary datacenter for this stack (optional)
@@ -82,6 +86,201 @@ type Stack struct {
 
 	// ErrorMessage contains error details if status is "error"
 	ErrorMessage string `json:"errorMessage,omitempty"`
+
+	// CurrentDeployment is the snapshot of the most recent successful deployment
+	CurrentDeployment *StackSnapshot `json:"currentDeployment,omitempty"`
+
+	// LastGood is the snapshot of the successful deployment before the current one.
+	// Rolling back a stack redeploys this snapshot.
+	LastGood *StackSnapshot `json:"lastGood,omitempty"`
+
+	// RolledBackAt is when the stack was last rolled back to LastGood
+	RolledBackAt *time.Time `json:"rolledBackAt,omitempty"`
+
+	// Health is the per-service breakdown behind the derived status, set by
+	// the stack status reconciler
+	Health *StackHealth `json:"health,omitempty"`
+}
+
+// StackSnapshot records a successful deployment of a stack: the definition
+// that was deployed and where its containers were placed.
+type StackSnapshot struct {
+	// DeploymentID is the ID of the deployment state that produced this snapshot
+	DeploymentID string `json:"deploymentId,omitempty"`
+
+	// Definition is the JSON-LD stack definition that was deployed
+	Definition StackDefinition `json:"definition"`
+
+	// Placements maps container names to their host placements
+	Placements map[string]*ContainerPlacement `json:"placements,omitempty"`
+
+	// DeployedAt is when the deployment completed
+	DeployedAt time.Time `json:"deployedAt"`
+}
+
+// RecordSuccessfulDeploy makes snapshot the current deployment of the stack.
+// The previous current deployment, if any, becomes the last known good one.
+func (s *Stack) RecordSuccessfulDeploy(snapshot *StackSnapshot) {
+	if s.CurrentDeployment != nil {
+		s.LastGood = s.CurrentDeployment
+	}
+	s.CurrentDeployment = snapshot
+}
+
+// PlacementStatusPending is the status of a placement whose container is
+// being redeployed and not known yet.
+const PlacementStatusPending = "pending"
+
+// PendingRedeploy returns a copy of the snapshot to record while it is
+// redeployed: the placements keep their hosts, but their containers are
+// pending until the redeploy reports the new ones
+// (Stack.RecordRedeployedContainer).
+func (s *StackSnapshot) PendingRedeploy() *StackSnapshot {
+	pending := *s
+	pending.Placements = make(map[string]*ContainerPlacement, len(s.Placements))
+	for name, placement := range s.Placements {
+		if placement == nil {
+			continue
+		}
+		copied := *placement
+		copied.ContainerID = ""
+		copied.Status = PlacementStatusPending
+		copied.StartedAt = nil
+		pending.Placements[name] = &copied
+	}
+	return &pending
+}
+
+// RecordRedeployedContainer records the container a redeploy created for a
+// pending placement of the current deployment. Once no placement is pending,
+// a deploying stack is running. It reports whether a placement was updated.
+func (s *Stack) RecordRedeployedContainer(containerName, containerID string, now time.Time) bool {
+	if s.CurrentDeployment == nil || containerID == "" {
+		return false
+	}
+	placement := s.CurrentDeployment.Placements[containerName]
+	if placement == nil || placement.Status != PlacementStatusPending {
+		return false
+	}
+	placement.ContainerID = containerID
+	placement.Status = "running"
+	placement.StartedAt = &now
+
+	for _, other := range s.CurrentDeployment.Placements {
+		if other != nil && other.Status == PlacementStatusPending {
+			return true
+		}
+	}
+	if s.Status == "deploying" {
+		s.Status = "running"
+		s.UpdatedAt = now
+	}
+	return true
+}
+
+// Labels used to assign discovered containers to stacks, in priority order.
+const (
+	// StackLabel names the Graphium stack (ID or name) a container belongs to
+	StackLabel = "graphium.stack"
+
+	// ComposeProjectLabel is set by Docker Compose to the project name
+	ComposeProjectLabel = "com.docker.compose.project"
+)
+
+// Stack assignment rules reported in StackAssignment.Rule.
+const (
+	AssignRuleStackLabel   = "label:" + StackLabel
+	AssignRuleComposeLabel = "label:" + ComposeProjectLabel
+	AssignRuleNamePrefix   = "name-prefix"
+	AssignRuleNone         = "none"
+
+	// AssignRuleComposeNew means no stack matched and a stack is created
+	// for the Compose project, with the project name as its ID and name
+	AssignRuleComposeNew = AssignRuleComposeLabel + ":new"
+
+	// AssignRuleExisting means the container was already in a stack and was left there
+	AssignRuleExisting = "existing"
+)
+
+// StackAssignment is the stack a container maps to and the rule that decided it.
+type StackAssignment struct {
+	StackID   string `json:"stackId,omitempty"`
+	StackName string `json:"stackName,omitempty"`
+	Rule      string `json:"rule"`
+	Reason    string `json:"reason"`
+}
+
+// ComposeStackID returns the ID of the stack discovered for a Compose
+// project. The prefix keeps it from clashing with hosts or other documents
+// whose ID is the project name.
+func ComposeStackID(project string) string {
+	return "stack:compose:" + project
+}
+
+// ResolveStackAssignment decides which of stacks a container belongs to.
+// The graphium.stack label (stack ID or name) wins, then the Compose project
+// label (stack name), then the longest stack name that prefixes the container
+// name as "{stack-name}-". A label naming an unknown stack falls through to
+// the next rule. If none matches, a Compose project gets a new stack with
+// ID ComposeStackID(project) (AssignRuleComposeNew), unless a stack already
+// uses that ID.
+func ResolveStackAssignment(stacks []*Stack, containerName string, labels map[string]string) StackAssignment {
+	var skipped []string
+
+	if value := labels[StackLabel]; value != "" {
+		for _, stack := range stacks {
+			if stack.ID == value || stack.Name == value {
+				return StackAssignment{StackID: stack.ID, StackName: stack.Name, Rule: AssignRuleStackLabel,
+					Reason: StackLabel + "=" + value}
+			}
+		}
+		skipped = append(skipped, StackLabel+"="+value+" matches no stack")
+	}
+
+	if value := labels[ComposeProjectLabel]; value != "" {
+		for _, stack := range stacks {
+			if stack.Name == value {
+				return StackAssignment{StackID: stack.ID, StackName: stack.Name, Rule: AssignRuleComposeLabel,
+					Reason: ComposeProjectLabel + "=" + value}
+			}
+		}
+		skipped = append(skipped, ComposeProjectLabel+"="+value+" matches no stack")
+	}
+
+	name := strings.TrimPrefix(containerName, "/")
+	var best *Stack
+	for _, stack := range stacks {
+		if stack.Name == "" || !strings.HasPrefix(name, stack.Name+"-") {
+			continue
+		}
+		if best == nil || len(stack.Name) > len(best.Name) {
+			best = stack
+		}
+	}
+	if best != nil {
+		return StackAssignment{StackID: best.ID, StackName: best.Name, Rule: AssignRuleNamePrefix,
+			Reason: "container name starts with " + best.Name + "-"}
+	}
+
+	skipped = append(skipped, "no stack name prefixes "+name)
+
+	if project := labels[ComposeProjectLabel]; project != "" {
+		id := ComposeStackID(project)
+		taken := false
+		for _, stack := range stacks {
+			if stack.ID == id {
+				taken = true
+				break
+			}
+		}
+		if !taken {
+			return StackAssignment{StackID: id, StackName: project, Rule: AssignRuleComposeNew,
+				Reason: ComposeProjectLabel + "=" + project + " matches no stack; a stack is created for the project"}
+		}
+		skipped = append(skipped, "stack ID "+id+" is taken by a stack with another name")
+	}
+
+	return StackAssignment{Rule: AssignRuleNone, Reason: strings.Join(skipped, "; ")}
 }
 
 // DeploymentConfig defines how a stack should be deployed.
@@ -177,6 +376,9 @@ type ContainerPlacement struct {
 
 	// StartedAt is when the container started
 	StartedAt *time.Time `json:"startedAt,omitempty"`
+
+	// Reserved is the CPU and memory committed to the container on its host
+	Reserved *ResourceReservations `json:"reserved,omitempty"`
 }
 
 // NetworkConfig contains cross-host networking configuration.
@@ -223,6 +425,36 @@ type ResourceLoad struct {
 
 	// ContainerCount is the number of running containers
 	ContainerCount int `json:"containerCount"`
+
+	// CommittedCPUs is the CPU reserved by stack containers placed on the host
+	CommittedCPUs float64 `json:"committedCpus"`
+
+	// CommittedMemory is the memory in bytes reserved by stack containers placed on the host
+	CommittedMemory int64 `json:"committedMemory"`
+}
+
+// CommittedByHost sums the reservations of the containers placed by each
+// stack's current deployment, keyed by host ID.
+func CommittedByHost(stacks []*Stack) map[string]*ResourceReservations {
+	committed := make(map[string]*ResourceReservations)
+	for _, stack := range stacks {
+		if stack.CurrentDeployment == nil {
+			continue
+		}
+		for _, placement := range stack.CurrentDeployment.Placements {
+			if placement == nil || placement.HostID == "" || placement.Reserved == nil {
+				continue
+			}
+			total, ok := committed[placement.HostID]
+			if !ok {
+				total = &ResourceReservations{}
+				committed[placement.HostID] = total
+			}
+			total.CPUs += placement.Reserved.CPUs
+			total.Memory += placement.Reserved.Memory
+		}
+	}
+	return committed
 }
 
 // Resources represents available resources.
@@ -94,6 +94,9 @@ type ContainerSpec struct {
 	// VolumeMounts defines volume mounts
 	VolumeMounts []VolumeMount `json:"volumeMounts,omitempty"`
 
+	// Files are small config files or secrets placed into the container before it starts
+	Files []FileMount `json:"files,omitempty"`
+
 	// HealthCheck defines the health check configuration
 	HealthCheck *HealthCheck `json:"healthCheck,omitempty"`
 
@@ -106,6 +109,10 @@ type ContainerSpec struct {
 	// RestartPolicy defines the restart behavior (no, always, on-failure, unless-stopped)
 	RestartPolicy string `json:"restartPolicy,omitempty"`
 
+	// StopGracePeriod is the time in seconds to wait after SIGTERM before the
+	// container is killed (default: DefaultStopGracePeriod)
+	StopGracePeriod int `json:"stopGracePeriod,omitempty"`
+
 	// Command overrides the default container command
 	Command []string `json:"command,omitempty"`
 
@@ -253,6 +260,10 @@ type VolumeOptions struct {
 
 	// DriverConfig specifies the volume driver
 	DriverConfig *VolumeDriverConfig `json:"driverConfig,omitempty"`
+
+	// External indicates an externally managed volume, which deployments
+	// neither create nor remove
+	External bool `json:"external,omitempty"`
 }
 
 // VolumeDriverConfig specifies volume driver configuration.
@@ -273,6 +284,32 @@ type BindOptions struct {
 	NonRecursive bool `json:"nonRecursive,omitempty"`
 }
 
+// FileMount places a single file into a container, similar to Docker Swarm
+// configs and secrets. Exactly one of Content or Secret must be set.
+// Secret content is resolved at deploy time and is never stored.
+type FileMount struct {
+	// Target is the absolute path of the file inside the container
+	Target string `json:"target"`
+
+	// Content is the inline file content (for non-sensitive config)
+	Content string `json:"content,omitempty"`
+
+	// Secret is the name of a secret in the secret store whose value becomes the file content
+	Secret string `json:"secret,omitempty"`
+
+	// Mode is the octal file mode (e.g., "0440"). Defaults to "0444".
+	Mode string `json:"mode,omitempty"`
+
+	// UID is the numeric owner of the file (default: 0)
+	UID int `json:"uid,omitempty"`
+
+	// GID is the numeric group of the file (default: 0)
+	GID int `json:"gid,omitempty"`
+
+	// RestartOnChange restarts the container when the referenced secret is updated
+	RestartOnChange bool `json:"restartOnChange,omitempty"`
+}
+
 // HealthCheck defines container health check configuration.
 type HealthCheck struct {
 	// Type is the health check type (http, tcp, exec, grpc)
@@ -303,12 +340,15 @@ type HealthCheck struct {
 	Headers map[string]string `json:"headers,omitempty"`
 }
 
-// ResourceConstraints defines CPU and memory limits.
+// ResourceConstraints defines the resource limits and reservations of a
+// container. Limits cap what the container may use and are enforced by
+// Docker; reservations (requests) are what it is guaranteed and are counted
+// against host capacity when placing containers.
 type ResourceConstraints struct {
 	// Limits defines maximum resource usage
 	Limits *ResourceLimits `json:"limits,omitempty"`
 
-	// Reservations defines guaranteed resource allocation
+	// Reservations defines guaranteed resource allocation (requests)
 	Reservations *ResourceReservations `json:"reservations,omitempty"`
 }
 
@@ -336,6 +376,68 @@ type ResourceReservations struct {
 	Memory int64 `json:"memory,omitempty"`
 }
 
+// DefaultStopGracePeriod is the time in seconds a container gets to shut
+// down after SIGTERM when neither the request nor the container sets one.
+const DefaultStopGracePeriod = 10
+
+// CPUReservationLabel records a container's CPU reservation on the Docker
+// container. Docker has no CPU reservation, so agents read it from the label.
+const CPUReservationLabel = "graphium.reservation.cpus"
+
+// Validate checks that values are not negative and that reservations do
+// not exceed the corresponding limits.
+func (r *ResourceConstraints) Validate() error {
+	if r == nil {
+		return nil
+	}
+	if r.Limits != nil && (r.Limits.CPUs < 0 || r.Limits.Memory < 0 || r.Limits.Pids < 0) {
+		return fmt.Errorf("resource limits must not be negative")
+	}
+	if r.Reservations == nil {
+		return nil
+	}
+	if r.Reservations.CPUs < 0 || r.Reservations.Memory < 0 {
+		return fmt.Errorf("resource reservations must not be negative")
+	}
+	if r.Limits != nil {
+		if r.Limits.CPUs > 0 && r.Reservations.CPUs > r.Limits.CPUs {
+			return fmt.Errorf("CPU reservation %g exceeds the limit %g", r.Reservations.CPUs, r.Limits.CPUs)
+		}
+		if r.Limits.Memory > 0 && r.Reservations.Memory > r.Limits.Memory {
+			return fmt.Errorf("memory reservation %d exceeds the limit %d", r.Reservations.Memory, r.Limits.Memory)
+		}
+	}
+	return nil
+}
+
+// ReservedResources returns the CPU and memory a container commits on its
+// host: the reservation where set, otherwise the limit. It returns nil if
+// the container declares neither.
+func (r *ResourceConstraints) ReservedResources() *ResourceReservations {
+	if r == nil {
+		return nil
+	}
+
+	reserved := &ResourceReservations{}
+	if r.Reservations != nil {
+		reserved.CPUs = r.Reservations.CPUs
+		reserved.Memory = r.Reservations.Memory
+	}
+	if r.Limits != nil {
+		if reserved.CPUs == 0 {
+			reserved.CPUs = r.Limits.CPUs
+		}
+		if reserved.Memory == 0 {
+			reserved.Memory = r.Limits.Memory
+		}
+	}
+
+	if reserved.CPUs == 0 && reserved.Memory == 0 {
+		return nil
+	}
+	return reserved
+}
+
 // Reference represents a JSON-LD @id reference to another node.
 type Reference struct {
 	ID string `json:"@id"`
@@ -403,9 +505,13 @@ type DeploymentState struct {
 	// NetworkInfo contains network configuration details
 	NetworkInfo *DeployedNetworkInfo `json:"networkInfo,omitempty"`
 
-	// VolumeInfo contains volume information
+	// VolumeInfo contains the volumes the deployment created; volumes that
+	// existed already aren't recorded, so rollback never removes them
 	VolumeInfo map[string]*VolumeInfo `json:"volumeInfo,omitempty"`
 
+	// BlueGreen tracks the cutover of a blue-green deployment
+	BlueGreen *BlueGreenState `json:"blueGreen,omitempty"`
+
 	// Events tracks deployment events
 	Events []DeploymentEvent `json:"events,omitempty"`
 
@@ -418,6 +524,9 @@ type DeploymentState struct {
 	// ErrorMessage contains error details if deployment failed
 	ErrorMessage string `json:"errorMessage,omitempty"`
 
+	// TimedOutPhase is the deployment phase that exceeded its timeout, if any
+	TimedOutPhase string `json:"timedOutPhase,omitempty"`
+
 	// RollbackState tracks rollback if needed
 	RollbackState *RollbackState `json:"rollbackState,omitempty"`
 }
@@ -443,6 +552,12 @@ type DeployedNetworkInfo struct {
 
 	// Scope is the network scope (local, swarm, global)
 	Scope string `json:"scope,omitempty"`
+
+	// HostNetworks are the IDs of the networks the deployment created,
+	// keyed by host ID. A local network is created on every host a
+	// container of the stack runs on; an overlay network once, on the
+	// primary host.
+	HostNetworks map[string]string `json:"hostNetworks,omitempty"`
 }
 
 // VolumeInfo contains information about a deployed volume.
@@ -450,6 +565,9 @@ type VolumeInfo struct {
 	// VolumeName is the volume name
 	VolumeName string `json:"volumeName"`
 
+	// HostID is the host the volume was created on
+	HostID string `json:"hostId,omitempty"`
+
 	// Driver is the volume driver
 	Driver string `json:"driver"`
 
@@ -484,6 +602,36 @@ type DeploymentEvent struct {
 	Details map[string]interface{} `json:"details,omitempty"`
 }
 
+// BlueGreenState tracks a blue-green deployment, whose green containers run
+// alongside the blue containers of the deployment they replace until the
+// deployment is promoted.
+type BlueGreenState struct {
+	// Status is the cutover status (pending, promoted)
+	Status string `json:"status"`
+
+	// BlueDeploymentID is the ID of the deployment being replaced, if any
+	BlueDeploymentID string `json:"blueDeploymentId,omitempty"`
+
+	// Blue are the placements of the blue containers, keyed by container name
+	Blue map[string]*ContainerPlacement `json:"blue,omitempty"`
+
+	// BlueNetwork is the network of the blue deployment, which the green
+	// containers join and the promoted deployment takes over
+	BlueNetwork *DeployedNetworkInfo `json:"blueNetwork,omitempty"`
+
+	// BlueVolumes are the volumes of the blue deployment, which the green
+	// containers share and the promoted deployment takes over
+	BlueVolumes map[string]*VolumeInfo `json:"blueVolumes,omitempty"`
+
+	// Ports are the fixed host ports of the green containers, keyed by their
+	// container name after promotion. Until then they're published on
+	// ephemeral host ports
+	Ports map[string][]PortMapping `json:"ports,omitempty"`
+
+	// PromotedAt is when the deployment was promoted
+	PromotedAt *time.Time `json:"promotedAt,omitempty"`
+}
+
 // RollbackState tracks rollback progress if deployment fails.
 type RollbackState struct {
 	// Status is the rollback status (rolling-back, rolled-back, rollback-failed)
@@ -498,6 +646,13 @@ type RollbackState struct {
 	// RemovedContainers lists containers removed during rollback
 	RemovedContainers []string `json:"removedContainers,omitempty"`
 
+	// RemovedVolumes lists volumes removed during rollback
+	RemovedVolumes []string `json:"removedVolumes,omitempty"`
+
+	// RemovedNetworks lists the hosts the network was removed from during
+	// rollback
+	RemovedNetworks []string `json:"removedNetworks,omitempty"`
+
 	// ErrorMessage contains error details if rollback failed
 	ErrorMessage string `json:"errorMessage,omitempty"`
 }
@@ -832,3 +832,170 @@ func TestFullStack_WithNewStructures(t *testing.T) {
 		t.Errorf("Expected MinCPU 2, got %d", container.ResourceRequirements.MinCPU)
 	}
 }
+
+func TestStack_RecordSuccessfulDeploy(t *testing.T) {
+	stk := &Stack{ID: "shop"}
+
+	first := &StackSnapshot{DeploymentID: "d1"}
+	stk.RecordSuccessfulDeploy(first)
+	if stk.CurrentDeployment != first || stk.LastGood != nil {
+		t.Fatal("First deploy should only set the current deployment")
+	}
+
+	second := &StackSnapshot{DeploymentID: "d2"}
+	stk.RecordSuccessfulDeploy(second)
+	if stk.CurrentDeployment != second || stk.LastGood != first {
+		t.Error("Second deploy should make the first one last known good")
+	}
+}
+
+func TestStack_RecordRedeployedContainer(t *testing.T) {
+	lastGood := &StackSnapshot{
+		DeploymentID: "d1",
+		Placements: map[string]*ContainerPlacement{
+			"shop-db":  {ContainerID: "old-db", HostID: "host1", Status: "running"},
+			"shop-web": {ContainerID: "old-web", HostID: "host2", Status: "running"},
+		},
+	}
+	stk := &Stack{ID: "shop", Status: "deploying", LastGood: lastGood}
+	stk.CurrentDeployment = lastGood.PendingRedeploy()
+
+	for name, placement := range stk.CurrentDeployment.Placements {
+		if placement.ContainerID != "" || placement.Status != PlacementStatusPending {
+			t.Errorf("Expected %s to be pending, got %+v", name, placement)
+		}
+	}
+	if lastGood.Placements["shop-db"].ContainerID != "old-db" {
+		t.Error("Expected the last known good placements to be left alone")
+	}
+
+	now := time.Now()
+	if !stk.RecordRedeployedContainer("shop-db", "new-db", now) {
+		t.Fatal("Expected the pending placement to be recorded")
+	}
+	if stk.RecordRedeployedContainer("shop-db", "other-db", now) {
+		t.Error("Expected a recorded placement not to be recorded again")
+	}
+	if stk.RecordRedeployedContainer("shop-cache", "new-cache", now) {
+		t.Error("Expected an unknown container not to be recorded")
+	}
+	if stk.Status != "deploying" {
+		t.Errorf("Expected the stack to deploy until no placement is pending, got %s", stk.Status)
+	}
+
+	stk.RecordRedeployedContainer("shop-web", "new-web", now)
+	if db := stk.CurrentDeployment.Placements["shop-db"]; db.ContainerID != "new-db" || db.HostID != "host1" {
+		t.Errorf("Expected the new container on the same host, got %+v", db)
+	}
+	if stk.Status != "running" {
+		t.Errorf("Expected the stack to run once every container is recorded, got %s", stk.Status)
+	}
+}
+
+func TestResolveStackAssignment(t *testing.T) {
+	stacks := []*Stack{
+		{ID: "stack-web", Name: "web"},
+		{ID: "stack-web-api", Name: "web-api"},
+		{ID: "stack-shop", Name: "shop"},
+		{ID: "stack:compose:ledger", Name: "accounting"},
+	}
+
+	tests := []struct {
+		name          string
+		containerName string
+		labels        map[string]string
+		wantStack     string
+		wantRule      string
+	}{
+		{"longest name prefix wins", "/web-api-worker-1", nil, "stack-web-api", AssignRuleNamePrefix},
+		{"shorter prefix", "web-frontend", nil, "stack-web", AssignRuleNamePrefix},
+		{"graphium label by ID beats name", "web-api-worker", map[string]string{StackLabel: "stack-shop"}, "stack-shop", AssignRuleStackLabel},
+		{"graphium label by name", "x", map[string]string{StackLabel: "web"}, "stack-web", AssignRuleStackLabel},
+		{"compose label beats name", "web-db", map[string]string{ComposeProjectLabel: "shop"}, "stack-shop", AssignRuleComposeLabel},
+		{"graphium label beats compose label", "x", map[string]string{StackLabel: "web", ComposeProjectLabel: "shop"}, "stack-web", AssignRuleStackLabel},
+		{"unknown label falls through", "shop-db", map[string]string{ComposeProjectLabel: "other"}, "stack-shop", AssignRuleNamePrefix},
+		{"no match", "redis", nil, "", AssignRuleNone},
+		{"unknown compose project gets a stack", "billing-api-1", map[string]string{ComposeProjectLabel: "billing"}, "stack:compose:billing", AssignRuleComposeNew},
+		{"name prefix beats a new compose stack", "web-cache-1", map[string]string{ComposeProjectLabel: "billing"}, "stack-web", AssignRuleNamePrefix},
+		{"compose project ID taken", "x", map[string]string{ComposeProjectLabel: "ledger"}, "", AssignRuleNone},
+		{"compose project named like a document", "x", map[string]string{ComposeProjectLabel: "stack-shop"}, "stack:compose:stack-shop", AssignRuleComposeNew},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ResolveStackAssignment(stacks, tt.containerName, tt.labels)
+			if got.StackID != tt.wantStack || got.Rule != tt.wantRule {
+				t.Errorf("Expected %s via %s, got %+v", tt.wantStack, tt.wantRule, got)
+			}
+		})
+	}
+}
+
+func TestResourceConstraints_ReservedResources(t *testing.T) {
+	var none *ResourceConstraints
+	if none.ReservedResources() != nil {
+		t.Error("Expected nil constraints to reserve nothing")
+	}
+
+	limitsOnly := &ResourceConstraints{Limits: &ResourceLimits{CPUs: 2, Memory: 512}}
+	if got := limitsOnly.ReservedResources(); got == nil || got.CPUs != 2 || got.Memory != 512 {
+		t.Errorf("Expected limits to be reserved, got %+v", got)
+	}
+
+	mixed := &ResourceConstraints{
+		Limits:       &ResourceLimits{CPUs: 2, Memory: 512},
+		Reservations: &ResourceReservations{Memory: 256},
+	}
+	if got := mixed.ReservedResources(); got == nil || got.CPUs != 2 || got.Memory != 256 {
+		t.Errorf("Expected the memory reservation and CPU limit, got %+v", got)
+	}
+}
+
+func TestResourceConstraints_Validate(t *testing.T) {
+	valid := []*ResourceConstraints{
+		nil,
+		{Limits: &ResourceLimits{CPUs: 2, Memory: 512}},
+		{Reservations: &ResourceReservations{CPUs: 4, Memory: 1024}},
+		{Limits: &ResourceLimits{CPUs: 2, Memory: 512}, Reservations: &ResourceReservations{CPUs: 1, Memory: 512}},
+		{Limits: &ResourceLimits{Memory: 512}, Reservations: &ResourceReservations{CPUs: 4}},
+	}
+	for _, r := range valid {
+		if err := r.Validate(); err != nil {
+			t.Errorf("Expected %+v to be valid, got %v", r, err)
+		}
+	}
+
+	invalid := []*ResourceConstraints{
+		{Limits: &ResourceLimits{CPUs: -1}},
+		{Reservations: &ResourceReservations{Memory: -1}},
+		{Limits: &ResourceLimits{CPUs: 1}, Reservations: &ResourceReservations{CPUs: 2}},
+		{Limits: &ResourceLimits{Memory: 256}, Reservations: &ResourceReservations{Memory: 512}},
+	}
+	for _, r := range invalid {
+		if err := r.Validate(); err == nil {
+			t.Errorf("Expected an error for limits %+v and reservations %+v", r.Limits, r.Reservations)
+		}
+	}
+}
+
+func TestCommittedByHost(t *testing.T) {
+	stacks := []*Stack{
+		{ID: "a", CurrentDeployment: &StackSnapshot{Placements: map[string]*ContainerPlacement{
+			"web": {HostID: "host1", Reserved: &ResourceReservations{CPUs: 1, Memory: 100}},
+			"db":  {HostID: "host1", Reserved: &ResourceReservations{CPUs: 0.5, Memory: 200}},
+			"tmp": {HostID: "host2"},
+		}}},
+		{ID: "b", CurrentDeployment: &StackSnapshot{Placements: map[string]*ContainerPlacement{
+			"cache": {HostID: "host2", Reserved: &ResourceReservations{Memory: 50}},
+		}}},
+		{ID: "c"},
+	}
+
+	committed := CommittedByHost(stacks)
+	if got := committed["host1"]; got == nil || got.CPUs != 1.5 || got.Memory != 300 {
+		t.Errorf("Unexpected host1 commitment: %+v", got)
+	}
+	if got := committed["host2"]; got == nil || got.CPUs != 0 || got.Memory != 50 {
+		t.Errorf("Unexpected host2 commitment: %+v", got)
+	}
+}
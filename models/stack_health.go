@@ -0,0 +1,149 @@
+package models
+
+import (
+	"sort"
+	"time"
+)
+
+// Stack statuses derived from the state of a stack's containers.
+const (
+	// StackStatusRunning means every service's container is running
+	StackStatusRunning = "running"
+
+	// StackStatusDegraded means some services are running and the others were stopped
+	StackStatusDegraded = "degraded"
+
+	// StackStatusStopped means no service is running and none has failed
+	StackStatusStopped = "stopped"
+
+	// StackStatusPartiallyFailed means at least one service's container is
+	// missing, exited, dead or restarting
+	StackStatusPartiallyFailed = "partially-failed"
+)
+
+// ServiceStateMissing is the state of a service whose container is no
+// longer known to Graphium (e.g. removed outside Graphium).
+const ServiceStateMissing = "missing"
+
+// StackHealth is the status of a stack derived from the actual state of the
+// containers of its current deployment.
+type StackHealth struct {
+	// Status is the derived stack status (running, degraded, stopped, partially-failed)
+	Status string `json:"status"`
+
+	// Running is the number of services whose container is running
+	Running int `json:"running"`
+
+	// Total is the number of services in the current deployment
+	Total int `json:"total"`
+
+	// Services is the per-service breakdown, ordered by service name
+	Services []ServiceHealth `json:"services"`
+
+	// CheckedAt is when the container states were last inspected
+	CheckedAt time.Time `json:"checkedAt"`
+}
+
+// ServiceHealth is the state of one service of a stack.
+type ServiceHealth struct {
+	// Service is the service (container) name in the stack definition
+	Service string `json:"service"`
+
+	// ContainerID is the container the service was deployed to
+	ContainerID string `json:"containerId,omitempty"`
+
+	// HostID is the host the container was placed on
+	HostID string `json:"hostId,omitempty"`
+
+	// State is the container status reported by the agent, or "missing"
+	State string `json:"state"`
+
+	// Running reports whether the container is running
+	Running bool `json:"running"`
+
+	// Failed reports whether the container is missing, exited, dead or restarting
+	Failed bool `json:"failed"`
+}
+
+// DeriveStackHealth computes the health of a stack from its placements and
+// the current status of each container, keyed by container ID. Containers
+// absent from states are reported as missing. It returns nil if there are
+// no placements.
+func DeriveStackHealth(placements map[string]*ContainerPlacement, states map[string]string, now time.Time) *StackHealth {
+	health := &StackHealth{CheckedAt: now}
+
+	failed := 0
+	for service, placement := range placements {
+		if placement == nil {
+			continue
+		}
+		state, ok := states[placement.ContainerID]
+		if !ok || placement.ContainerID == "" {
+			state = ServiceStateMissing
+		}
+
+		entry := ServiceHealth{
+			Service:     service,
+			ContainerID: placement.ContainerID,
+			HostID:      placement.HostID,
+			State:       state,
+			Running:     state == "running",
+			Failed:      serviceFailed(state),
+		}
+		if entry.Running {
+			health.Running++
+		}
+		if entry.Failed {
+			failed++
+		}
+		health.Services = append(health.Services, entry)
+	}
+
+	health.Total = len(health.Services)
+	if health.Total == 0 {
+		return nil
+	}
+	sort.Slice(health.Services, func(i, j int) bool {
+		return health.Services[i].Service < health.Services[j].Service
+	})
+
+	switch {
+	case health.Running == health.Total:
+		health.Status = StackStatusRunning
+	case failed > 0:
+		health.Status = StackStatusPartiallyFailed
+	case health.Running == 0:
+		health.Status = StackStatusStopped
+	default:
+		health.Status = StackStatusDegraded
+	}
+	return health
+}
+
+// serviceFailed reports whether a service in the given state has failed:
+// its container is missing, exited, dead (Docker failed to remove it) or
+// restarting.
+func serviceFailed(state string) bool {
+	switch state {
+	case ServiceStateMissing, "exited", "dead", "restarting":
+		return true
+	}
+	return false
+}
+
+// Equal reports whether two health summaries have the same status and
+// service states, ignoring when they were checked.
+func (h *StackHealth) Equal(other *StackHealth) bool {
+	if h == nil || other == nil {
+		return h == other
+	}
+	if h.Status != other.Status || len(h.Services) != len(other.Services) {
+		return false
+	}
+	for i := range h.Services {
+		if h.Services[i] != other.Services[i] {
+			return false
+		}
+	}
+	return true
+}
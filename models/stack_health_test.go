@@ -0,0 +1,73 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDeriveStackHealth(t *testing.T) {
+	placements := map[string]*ContainerPlacement{
+		"web": {ContainerID: "c-web", HostID: "host1"},
+		"api": {ContainerID: "c-api", HostID: "host2"},
+		"db":  {ContainerID: "c-db", HostID: "host2"},
+	}
+	now := time.Now()
+
+	tests := []struct {
+		name    string
+		states  map[string]string
+		want    string
+		running int
+	}{
+		{"all running", map[string]string{"c-web": "running", "c-api": "running", "c-db": "running"}, StackStatusRunning, 3},
+		{"some stopped", map[string]string{"c-web": "running", "c-api": "stopped", "c-db": "running"}, StackStatusDegraded, 2},
+		{"all stopped", map[string]string{"c-web": "stopped", "c-api": "paused", "c-db": "stopped"}, StackStatusStopped, 0},
+		{"one exited", map[string]string{"c-web": "running", "c-api": "exited", "c-db": "running"}, StackStatusPartiallyFailed, 2},
+		{"one dead", map[string]string{"c-web": "running", "c-api": "dead", "c-db": "running"}, StackStatusPartiallyFailed, 2},
+		{"one missing", map[string]string{"c-web": "running", "c-api": "running"}, StackStatusPartiallyFailed, 2},
+		{"restarting and stopped", map[string]string{"c-web": "stopped", "c-api": "restarting", "c-db": "stopped"}, StackStatusPartiallyFailed, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			health := DeriveStackHealth(placements, tt.states, now)
+			if health.Status != tt.want {
+				t.Errorf("Expected status %s, got %s", tt.want, health.Status)
+			}
+			if health.Running != tt.running || health.Total != 3 {
+				t.Errorf("Expected %d/3 running, got %d/%d", tt.running, health.Running, health.Total)
+			}
+			if health.Services[0].Service != "api" || health.Services[2].Service != "web" {
+				t.Errorf("Expected services ordered by name, got %+v", health.Services)
+			}
+		})
+	}
+
+	health := DeriveStackHealth(placements, map[string]string{"c-web": "running"}, now)
+	if db := health.Services[1]; db.State != ServiceStateMissing || !db.Failed || db.HostID != "host2" {
+		t.Errorf("Expected db to be missing and failed on host2, got %+v", db)
+	}
+
+	if DeriveStackHealth(nil, nil, now) != nil {
+		t.Error("Expected no health for a stack without placements")
+	}
+}
+
+func TestStackHealth_Equal(t *testing.T) {
+	placements := map[string]*ContainerPlacement{"web": {ContainerID: "c-web"}}
+	states := map[string]string{"c-web": "running"}
+
+	a := DeriveStackHealth(placements, states, time.Now())
+	b := DeriveStackHealth(placements, states, time.Now().Add(time.Minute))
+	if !a.Equal(b) {
+		t.Error("Expected health checked at different times to be equal")
+	}
+
+	states["c-web"] = "stopped"
+	if a.Equal(DeriveStackHealth(placements, states, time.Now())) {
+		t.Error("Expected a changed service state to differ")
+	}
+	if a.Equal(nil) {
+		t.Error("Expected health to differ from nil")
+	}
+}
@@ -0,0 +1,40 @@
+package models
+
+// StackIndexEntry is the stack a container belongs to.
+type StackIndexEntry struct {
+	StackID   string `json:"stackId"`
+	StackName string `json:"stackName"`
+}
+
+// IndexStacks maps container IDs to the stack listing them. A container
+// listed by several stacks is indexed under the one with the lowest ID.
+func IndexStacks(stacks []*Stack) map[string]StackIndexEntry {
+	var memberships []StackMembership
+	for _, stack := range stacks {
+		for _, containerID := range stack.Containers {
+			memberships = append(memberships, StackMembership{ContainerID: containerID, StackID: stack.ID, StackName: stack.Name})
+		}
+	}
+	return IndexStackMemberships(memberships)
+}
+
+// StackMembership is a stack listing a container.
+type StackMembership struct {
+	ContainerID string
+	StackID     string
+	StackName   string
+}
+
+// IndexStackMemberships maps container IDs to their stack. A container
+// listed by several stacks belongs to the one with the lowest ID, whatever
+// the order of memberships, so every lookup agrees on the owner.
+func IndexStackMemberships(memberships []StackMembership) map[string]StackIndexEntry {
+	index := make(map[string]StackIndexEntry, len(memberships))
+	for _, m := range memberships {
+		if entry, ok := index[m.ContainerID]; ok && entry.StackID <= m.StackID {
+			continue
+		}
+		index[m.ContainerID] = StackIndexEntry{StackID: m.StackID, StackName: m.StackName}
+	}
+	return index
+}
@@ -0,0 +1,57 @@
+package models
+
+import "testing"
+
+func TestIndexStacks(t *testing.T) {
+	index := IndexStacks([]*Stack{
+		{ID: "s1", Name: "web", Containers: []string{"c1", "c2"}},
+		{ID: "s2", Name: "db", Containers: []string{"c3", "c1"}},
+	})
+
+	if len(index) != 3 {
+		t.Fatalf("Expected 3 indexed containers, got %d", len(index))
+	}
+	if entry := index["c1"]; entry.StackID != "s1" || entry.StackName != "web" {
+		t.Errorf("Expected c1 to be indexed under the first stack, got %+v", entry)
+	}
+	if index["c3"].StackID != "s2" {
+		t.Errorf("Expected c3 in stack s2, got %+v", index["c3"])
+	}
+}
+
+func TestIndexStackMemberships_LowestStackIDWins(t *testing.T) {
+	memberships := []StackMembership{
+		{ContainerID: "c1", StackID: "s2", StackName: "db"},
+		{ContainerID: "c2", StackID: "s2", StackName: "db"},
+		{ContainerID: "c1", StackID: "s1", StackName: "web"},
+		{ContainerID: "c1", StackID: "s3", StackName: "cache"},
+	}
+
+	for _, order := range [][]int{{0, 1, 2, 3}, {3, 2, 1, 0}, {2, 0, 3, 1}} {
+		ordered := make([]StackMembership, len(order))
+		for i, j := range order {
+			ordered[i] = memberships[j]
+		}
+
+		index := IndexStackMemberships(ordered)
+		if len(index) != 2 {
+			t.Fatalf("Expected 2 indexed containers, got %d", len(index))
+		}
+		if entry := index["c1"]; entry.StackID != "s1" || entry.StackName != "web" {
+			t.Errorf("Order %v: expected c1 in the lowest stack s1, got %+v", order, entry)
+		}
+		if entry := index["c2"]; entry.StackID != "s2" {
+			t.Errorf("Order %v: expected c2 in stack s2, got %+v", order, entry)
+		}
+	}
+}
+
+func TestIndexStacks_SharedContainerIgnoresStackOrder(t *testing.T) {
+	index := IndexStacks([]*Stack{
+		{ID: "s2", Name: "db", Containers: []string{"c1"}},
+		{ID: "s1", Name: "web", Containers: []string{"c1"}},
+	})
+	if entry := index["c1"]; entry.StackID != "s1" {
+		t.Errorf("Expected c1 under the lowest stack s1, got %+v", entry)
+	}
+}
@@ -0,0 +1,134 @@
+package models
+
+import (
+	"sort"
+	"time"
+)
+
+// ResourceUsage is the live resource consumption of one or more containers.
+type ResourceUsage struct {
+	// CPUPercent is the CPU usage in percent of one CPU (200 = two full CPUs)
+	CPUPercent float64 `json:"cpuPercent"`
+
+	// MemoryUsage is the memory in use in bytes, excluding the page cache
+	MemoryUsage int64 `json:"memoryUsage"`
+
+	// MemoryLimit is the memory limit in bytes (the host's memory if unlimited)
+	MemoryLimit int64 `json:"memoryLimit,omitempty"`
+
+	// DiskUsage is the size in bytes of the containers' writable layers
+	DiskUsage int64 `json:"diskUsage"`
+
+	// BlockRead and BlockWrite are the bytes read from and written to block
+	// devices since the containers started
+	BlockRead  int64 `json:"blockRead"`
+	BlockWrite int64 `json:"blockWrite"`
+
+	// NetworkRx and NetworkTx are the bytes received and sent since the
+	// containers started
+	NetworkRx int64 `json:"networkRx"`
+	NetworkTx int64 `json:"networkTx"`
+}
+
+// Add adds other's consumption to u.
+func (u *ResourceUsage) Add(other ResourceUsage) {
+	u.CPUPercent += other.CPUPercent
+	u.MemoryUsage += other.MemoryUsage
+	u.MemoryLimit += other.MemoryLimit
+	u.DiskUsage += other.DiskUsage
+	u.BlockRead += other.BlockRead
+	u.BlockWrite += other.BlockWrite
+	u.NetworkRx += other.NetworkRx
+	u.NetworkTx += other.NetworkTx
+}
+
+// ContainerUsage is the live resource consumption of one container of a stack.
+type ContainerUsage struct {
+	// Service is the service (container) name in the stack definition
+	Service string `json:"service"`
+
+	// ContainerID is the container the service was deployed to
+	ContainerID string `json:"containerId"`
+
+	// HostID is the host the container was placed on
+	HostID string `json:"hostId"`
+
+	// Usage is the container's consumption; nil if it is unknown
+	Usage *ResourceUsage `json:"usage,omitempty"`
+
+	// Error explains why the usage is unknown, e.g. an unreachable host
+	Error string `json:"error,omitempty"`
+}
+
+// ServiceUsage is the live resource consumption of one service of a stack.
+type ServiceUsage struct {
+	// Service is the service name in the stack definition
+	Service string `json:"service"`
+
+	// Containers is the number of containers of the service
+	Containers int `json:"containers"`
+
+	// Unknown is the number of containers whose usage is unknown
+	Unknown int `json:"unknown,omitempty"`
+
+	// Usage is the consumption of the containers with known usage
+	Usage ResourceUsage `json:"usage"`
+}
+
+// StackUsage is the live resource consumption of a deployed stack.
+type StackUsage struct {
+	// StackID is the stack the usage belongs to
+	StackID string `json:"stackId"`
+
+	// Total is the consumption of all containers with known usage
+	Total ResourceUsage `json:"total"`
+
+	// Unknown is the number of containers whose usage is unknown; the
+	// totals don't include them
+	Unknown int `json:"unknown"`
+
+	// Services is the per-service breakdown, ordered by service name
+	Services []ServiceUsage `json:"services"`
+
+	// Containers is the per-container breakdown, ordered by service name
+	Containers []ContainerUsage `json:"containers"`
+
+	// CollectedAt is when the usage was read from the hosts
+	CollectedAt time.Time `json:"collectedAt"`
+}
+
+// AggregateStackUsage totals the usage of a stack's containers per service
+// and for the whole stack. Containers with unknown usage are counted but
+// not totalled.
+func AggregateStackUsage(stackID string, containers []ContainerUsage, now time.Time) *StackUsage {
+	usage := &StackUsage{StackID: stackID, Containers: containers, CollectedAt: now}
+
+	services := make(map[string]*ServiceUsage)
+	for _, container := range containers {
+		service, ok := services[container.Service]
+		if !ok {
+			service = &ServiceUsage{Service: container.Service}
+			services[container.Service] = service
+		}
+		service.Containers++
+		if container.Usage == nil {
+			service.Unknown++
+			usage.Unknown++
+			continue
+		}
+		service.Usage.Add(*container.Usage)
+		usage.Total.Add(*container.Usage)
+	}
+
+	usage.Services = make([]ServiceUsage, 0, len(services))
+	for _, service := range services {
+		usage.Services = append(usage.Services, *service)
+	}
+	sort.Slice(usage.Services, func(i, j int) bool {
+		return usage.Services[i].Service < usage.Services[j].Service
+	})
+	sort.SliceStable(usage.Containers, func(i, j int) bool {
+		return usage.Containers[i].Service < usage.Containers[j].Service
+	})
+	return usage
+}
@@ -0,0 +1,35 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAggregateStackUsage(t *testing.T) {
+	containers := []ContainerUsage{
+		{Service: "web", ContainerID: "c2", HostID: "h2", Usage: &ResourceUsage{CPUPercent: 25, MemoryUsage: 100, DiskUsage: 10}},
+		{Service: "db", ContainerID: "c3", HostID: "h3", Error: "host h3 unreachable"},
+		{Service: "web", ContainerID: "c1", HostID: "h1", Usage: &ResourceUsage{CPUPercent: 50, MemoryUsage: 200, NetworkRx: 5}},
+	}
+
+	usage := AggregateStackUsage("stack-1", containers, time.Now())
+
+	if usage.Total.CPUPercent != 75 || usage.Total.MemoryUsage != 300 || usage.Total.DiskUsage != 10 || usage.Total.NetworkRx != 5 {
+		t.Errorf("Unexpected totals: %+v", usage.Total)
+	}
+	if usage.Unknown != 1 {
+		t.Errorf("Expected 1 container with unknown usage, got %d", usage.Unknown)
+	}
+	if len(usage.Services) != 2 || usage.Services[0].Service != "db" || usage.Services[1].Service != "web" {
+		t.Fatalf("Expected services db and web, got %+v", usage.Services)
+	}
+	if db := usage.Services[0]; db.Containers != 1 || db.Unknown != 1 || db.Usage != (ResourceUsage{}) {
+		t.Errorf("Expected db usage to be unknown, got %+v", db)
+	}
+	if web := usage.Services[1]; web.Containers != 2 || web.Usage.CPUPercent != 75 {
+		t.Errorf("Expected web to total both containers, got %+v", web)
+	}
+	if usage.Containers[0].Service != "db" {
+		t.Errorf("Expected containers ordered by service, got %+v", usage.Containers)
+	}
+}
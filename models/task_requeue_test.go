@@ -0,0 +1,59 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAgentTask_Requeue(t *testing.T) {
+	started := time.Now()
+	task := &AgentTask{ActionStatus: TaskStatusRunning, StartTime: &started, MaxRetries: 2}
+
+	if !task.Requeue("agent shut down") {
+		t.Fatal("Requeue() = false, want true with retries left")
+	}
+	if task.ActionStatus != TaskStatusPending {
+		t.Errorf("ActionStatus = %q, want %q", task.ActionStatus, TaskStatusPending)
+	}
+	if task.StartTime != nil {
+		t.Error("StartTime not cleared")
+	}
+	if task.RetryCount != 1 {
+		t.Errorf("RetryCount = %d, want 1", task.RetryCount)
+	}
+	if task.Error == nil || task.Error.Message != "agent shut down" {
+		t.Errorf("Error = %+v, want message %q", task.Error, "agent shut down")
+	}
+
+	task.Requeue("agent shut down")
+	task.ActionStatus = TaskStatusRunning
+	if task.Requeue("agent shut down") {
+		t.Fatal("Requeue() = true, want false without retries left")
+	}
+	if task.ActionStatus != TaskStatusRunning || task.RetryCount != 2 {
+		t.Errorf("task changed without retries left: status %q, retries %d", task.ActionStatus, task.RetryCount)
+	}
+}
+
+func TestAgentTask_Retry(t *testing.T) {
+	task := &AgentTask{ID: "exec-1", Type: "ControlAction", ContainerID: "c-redis", RetryCount: 1, Priority: 5}
+	if err := task.SetPayload(map[string]interface{}{"action": ControlExec, "containerId": "c-redis", "command": "redis-cli PING"}); err != nil {
+		t.Fatalf("SetPayload failed: %v", err)
+	}
+
+	retry, err := task.Retry()
+	if err != nil {
+		t.Fatalf("Retry failed: %v", err)
+	}
+	if retry.ID != "exec-1-retry-2" || retry.RetryCount != 2 || retry.ActionStatus != TaskStatusPending || retry.Priority != 5 {
+		t.Errorf("Unexpected retry %+v", retry)
+	}
+	if payloads, err := ExecPayloads(retry); err != nil || len(payloads) != 1 {
+		t.Errorf("Expected the retry to carry the exec payload, got %v %v", payloads, err)
+	}
+
+	task.RetryCount = 3
+	if _, err := task.Retry(); err == nil {
+		t.Error("Expected a task without retries left not to be retried")
+	}
+}
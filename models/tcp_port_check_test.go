@@ -0,0 +1,27 @@
+package models
+
+import "testing"
+
+func TestTCPPortCheckPayload_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload TCPPortCheckPayload
+		wantErr bool
+	}{
+		{"address", TCPPortCheckPayload{Address: "db:5432"}, false},
+		{"ipv6 address", TCPPortCheckPayload{Address: "[::1]:6379"}, false},
+		{"banner", TCPPortCheckPayload{Address: "mail:25", ExpectBanner: "^220 ", BannerBytes: 64}, false},
+		{"missing port", TCPPortCheckPayload{Address: "db"}, true},
+		{"missing host", TCPPortCheckPayload{Address: ":5432"}, true},
+		{"negative timeout", TCPPortCheckPayload{Address: "db:5432", Timeout: -1}, true},
+		{"banner bytes too large", TCPPortCheckPayload{Address: "db:5432", BannerBytes: MaxTCPBannerBytes + 1}, true},
+		{"invalid banner regex", TCPPortCheckPayload{Address: "mail:25", ExpectBanner: "(220"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.payload.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
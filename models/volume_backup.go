@@ -0,0 +1,123 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// TransferBackupVolume routes a TransferAction to an archive of a named
+// Docker volume.
+const TransferBackupVolume = "backup-volume"
+
+// DefaultBackupHelperImage is the image of the throwaway container that
+// archives a volume; it needs tar and gzip.
+const DefaultBackupHelperImage = "alpine:3.20"
+
+// DefaultBackupDir is where agents write volume archives unless the agent
+// config or the task names another directory.
+const DefaultBackupDir = "/var/lib/graphium/backups"
+
+// volumeNamePattern matches Docker volume names.
+var volumeNamePattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]*$`)
+
+// BackupVolumePayload contains data for archiving a named volume.
+//
+// Example: archive the postgres-data volume to the nightly directory in the
+// agent's backup directory
+//
+//	{"action": "backup-volume", "volume": "postgres-data",
+//	 "destination": "nightly"}
+type BackupVolumePayload struct {
+	// Action is TransferBackupVolume
+	Action string `json:"action"`
+
+	// Volume is the name of the Docker volume to archive
+	Volume string `json:"volume"`
+
+	// Destination is the directory the archive is written to, as a path or
+	// file:// URL (default: the agent's backup directory). It must lie
+	// within the agent's backup directory; relative paths are taken
+	// relative to it. Object storage URLs like s3:// are not supported yet.
+	Destination string `json:"destination,omitempty"`
+
+	// HelperImage is the image of the container archiving the volume
+	// (default: DefaultBackupHelperImage)
+	HelperImage string `json:"helperImage,omitempty"`
+}
+
+// BackupVolumePayloadFromInstrument reads the backup parameters of a
+// scheduled action's instrument. It returns nil if the instrument isn't a
+// backup-volume transfer.
+func BackupVolumePayloadFromInstrument(instrument map[string]interface{}) (*BackupVolumePayload, error) {
+	if action, _ := instrument["action"].(string); action != TransferBackupVolume {
+		return nil, nil
+	}
+	data, err := json.Marshal(instrument)
+	if err != nil {
+		return nil, err
+	}
+	var payload BackupVolumePayload
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return nil, fmt.Errorf("invalid backup parameters: %w", err)
+	}
+	return &payload, nil
+}
+
+// Validate checks the volume name and that the destination is a local
+// directory. Only the agent knows its backup directory, so it checks that
+// an absolute destination lies within it (DestinationDir).
+func (p *BackupVolumePayload) Validate() error {
+	if p.Volume == "" {
+		return fmt.Errorf("volume is required")
+	}
+	if !volumeNamePattern.MatchString(p.Volume) {
+		return fmt.Errorf("invalid volume name %q", p.Volume)
+	}
+	dir, err := p.destinationPath()
+	if err != nil {
+		return err
+	}
+	if dir != "" && !filepath.IsAbs(dir) && !filepath.IsLocal(dir) {
+		return fmt.Errorf("backup destination %q is outside the backup directory", p.Destination)
+	}
+	return nil
+}
+
+// DestinationDir returns the local directory the archive is written to: the
+// payload's destination within backupDir, or backupDir if it has none. A
+// destination outside backupDir is refused, so tasks can't make the agent
+// write anywhere else on the host.
+func (p *BackupVolumePayload) DestinationDir(backupDir string) (string, error) {
+	root := filepath.Clean(backupDir)
+	dir, err := p.destinationPath()
+	if err != nil {
+		return "", err
+	}
+	if dir == "" {
+		return root, nil
+	}
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(root, dir)
+	}
+	dir = filepath.Clean(dir)
+	if rel, err := filepath.Rel(root, dir); err != nil || !filepath.IsLocal(rel) {
+		return "", fmt.Errorf("backup destination %q is outside the backup directory %s", p.Destination, root)
+	}
+	return dir, nil
+}
+
+// destinationPath returns the destination as a path, without its file://
+// scheme.
+func (p *BackupVolumePayload) destinationPath() (string, error) {
+	dir := p.Destination
+	if scheme, _, ok := strings.Cut(dir, "://"); ok {
+		if scheme != "file" {
+			return "", fmt.Errorf("unsupported backup destination %q: only local paths and file:// URLs are supported", dir)
+		}
+		dir = strings.TrimPrefix(dir, "file://")
+	}
+	return dir, nil
+}
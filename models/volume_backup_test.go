@@ -0,0 +1,76 @@
+package models
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBackupVolumePayload_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload BackupVolumePayload
+		wantErr string
+	}{
+		{"default destination", BackupVolumePayload{Volume: "postgres-data"}, ""},
+		{"path", BackupVolumePayload{Volume: "postgres-data", Destination: "/srv/backups"}, ""},
+		{"file URL", BackupVolumePayload{Volume: "postgres_data.1", Destination: "file:///srv/backups"}, ""},
+		{"no volume", BackupVolumePayload{}, "volume is required"},
+		{"invalid volume", BackupVolumePayload{Volume: "../etc"}, "invalid volume name"},
+		{"relative path", BackupVolumePayload{Volume: "data", Destination: "nightly"}, ""},
+		{"relative path escaping", BackupVolumePayload{Volume: "data", Destination: "../../etc"}, "outside the backup directory"},
+		{"object storage", BackupVolumePayload{Volume: "data", Destination: "s3://bucket/backups"}, "unsupported backup destination"},
+	}
+	for _, tt := range tests {
+		err := tt.payload.Validate()
+		switch {
+		case tt.wantErr == "" && err != nil:
+			t.Errorf("%s: unexpected error %v", tt.name, err)
+		case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+			t.Errorf("%s: expected an error containing %q, got %v", tt.name, tt.wantErr, err)
+		}
+	}
+}
+
+func TestBackupVolumePayload_DestinationDir(t *testing.T) {
+	tests := []struct {
+		destination string
+		want        string
+		wantErr     bool
+	}{
+		{"", "/var/backups", false},
+		{"/var/backups/", "/var/backups", false},
+		{"file:///var/backups/nightly", "/var/backups/nightly", false},
+		{"nightly/db", "/var/backups/nightly/db", false},
+		{"/srv/backups", "", true},
+		{"/var/backups-other", "", true},
+		{"/var/backups/../../etc", "", true},
+		{"file:///etc/cron.d", "", true},
+		{"../etc", "", true},
+	}
+	for _, tt := range tests {
+		payload := BackupVolumePayload{Volume: "data", Destination: tt.destination}
+		got, err := payload.DestinationDir("/var/backups")
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("DestinationDir() with destination %q = %q, %v, want %q (error: %v)", tt.destination, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestBackupVolumePayloadFromInstrument(t *testing.T) {
+	payload, err := BackupVolumePayloadFromInstrument(map[string]interface{}{"action": "collect-logs"})
+	if err != nil || payload != nil {
+		t.Errorf("Expected no payload for other transfers, got %+v, %v", payload, err)
+	}
+
+	payload, err = BackupVolumePayloadFromInstrument(map[string]interface{}{
+		"action":      "backup-volume",
+		"volume":      "postgres-data",
+		"destination": "/srv/backups",
+	})
+	if err != nil || payload == nil {
+		t.Fatalf("Unexpected result %+v, %v", payload, err)
+	}
+	if payload.Volume != "postgres-data" || payload.Destination != "/srv/backups" {
+		t.Errorf("Unexpected payload %+v", payload)
+	}
+}
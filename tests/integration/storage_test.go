@@ -7,6 +7,7 @@ import (
 	"context"
 	"fmt"
 	"testing"
+	"time"
 
 	"evalgo.org/graphium/internal/config"
 	"evalgo.org/graphium/internal/storage"
@@ -204,5 +205,50 @@ func TestCouchDBIntegration(t *testing.T) {
 		}
 	})
 
+	t.Run("Deleted Container Is Not Resurrected", func(t *testing.T) {
+		container := &models.Container{
+			ID:       "test-container-tombstone",
+			Name:     "test-deleted",
+			Image:    "nginx:latest",
+			Status:   "running",
+			HostedOn: "test-host-003",
+		}
+		require.NoError(t, store.SaveContainer(container))
+
+		// A user deletes the container while it still runs in Docker
+		stored, err := store.GetContainer(container.ID)
+		require.NoError(t, err)
+		require.NoError(t, store.TombstoneContainer(container.ID, container.HostedOn, "user-deleted", "test", 0))
+		require.NoError(t, store.DeleteContainer(stored.ID, stored.Rev))
+
+		// The agent's next sync must not bring it back
+		resync := &models.Container{ID: container.ID, Name: container.Name, Image: container.Image, HostedOn: container.HostedOn}
+		err = store.SaveContainer(resync)
+		require.ErrorIs(t, err, storage.ErrContainerTombstoned)
+		ignored, err := store.IsContainerIgnored(container.ID)
+		require.NoError(t, err)
+		require.True(t, ignored)
+
+		// Clearing the tombstone allows it again
+		require.NoError(t, store.RemoveFromIgnoreList(container.ID))
+		require.NoError(t, store.SaveContainer(resync))
+
+		// An expired tombstone no longer blocks it either
+		saved, err := store.GetContainer(container.ID)
+		require.NoError(t, err)
+		require.NoError(t, store.TombstoneContainer(container.ID, container.HostedOn, "user-deleted", "test", time.Millisecond))
+		require.NoError(t, store.DeleteContainer(saved.ID, saved.Rev))
+		time.Sleep(10 * time.Millisecond)
+		again := &models.Container{ID: container.ID, Name: container.Name, Image: container.Image, HostedOn: container.HostedOn}
+		require.NoError(t, store.SaveContainer(again))
+
+		deleted, err := store.DeleteExpiredTombstones(time.Now())
+		require.NoError(t, err)
+		require.Equal(t, 1, deleted)
+		again, err = store.GetContainer(container.ID)
+		require.NoError(t, err)
+		require.NoError(t, store.DeleteContainer(again.ID, again.Rev))
+	})
+
 	t.Logf("✅ All CouchDB integration tests passed with real container")
 }